@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"tennis-platform/backend/internal/config"
+	"tennis-platform/backend/internal/db"
+	"tennis-platform/backend/internal/services"
+)
+
+// 將已上傳的文件搬到另一個存儲，並改寫 AvatarURL 及 Images 欄位
+//
+//	go run ./cmd/migrate_storage -from local -to s3 -dry-run
+func main() {
+	from := flag.String("from", "local", "來源存儲驅動（local 或 s3）")
+	to := flag.String("to", "s3", "目標存儲驅動（local 或 s3）")
+	dryRun := flag.Bool("dry-run", false, "只統計需要搬移的文件，不寫入")
+	deleteSource := flag.Bool("delete-source", false, "完成後刪除來源文件")
+	flag.Parse()
+
+	if *from == *to {
+		log.Fatal("Source and destination storage must differ")
+	}
+
+	// Load config
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal("Failed to load config:", err)
+	}
+
+	src, err := newStorage(cfg, *from)
+	if err != nil {
+		log.Fatal("Failed to initialize source storage:", err)
+	}
+	dst, err := newStorage(cfg, *to)
+	if err != nil {
+		log.Fatal("Failed to initialize destination storage:", err)
+	}
+
+	database, err := db.NewDatabase(cfg)
+	if err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+	defer database.Close()
+
+	migrator := services.NewStorageMigrator(database.DB, src, dst)
+	migrator.DryRun = *dryRun
+	migrator.DeleteSource = *deleteSource
+
+	log.Printf("Migrating files from %s to %s (dry run: %v)...", *from, *to, *dryRun)
+
+	result, err := migrator.Migrate(context.Background())
+	if err != nil {
+		log.Fatal("Migration failed:", err)
+	}
+
+	for _, key := range result.MissingFiles {
+		log.Printf("Missing source file, URL left unchanged: %s", key)
+	}
+	log.Printf("Migration completed: %d files copied, %d rows updated, %d missing files.",
+		result.FilesCopied, result.RowsUpdated, len(result.MissingFiles))
+}
+
+// newStorage 以指定驅動創建存儲，其餘設定沿用配置
+func newStorage(cfg *config.Config, driver string) (services.Storage, error) {
+	storageCfg := *cfg
+	storageCfg.Storage.Driver = driver
+	return services.NewStorage(&storageCfg)
+}
@@ -41,7 +41,7 @@ func main() {
 	defer dbManager.Close()
 
 	// 初始化 API 服務器
-	server := api.NewServer(cfg, dbManager.DB)
+	server := api.NewServer(cfg, dbManager.DB, dbManager.Redis)
 
 	// 啟動服務器
 	log.Printf("Starting server on port %s", cfg.Port)
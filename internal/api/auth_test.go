@@ -107,7 +107,7 @@ func setupTestServer() (*Server, *gorm.DB) {
 
 	// 初始化控制器層
 	authController := controllers.NewAuthController(authUsecase)
-	uploadService := services.NewUploadService(cfg)
+	uploadService := services.NewUploadService(cfg, services.NewLocalStorage(cfg.Upload.UploadPath, "test-signing-secret"))
 	userController := controllers.NewUserController(userUsecase, uploadService)
 
 	// 創建服務器
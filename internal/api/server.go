@@ -1,13 +1,18 @@
 package api
 
 import (
+	"context"
+	"log"
 	"net/http"
+	"path/filepath"
+	"tennis-platform/backend/internal/apperror"
 	"tennis-platform/backend/internal/config"
 	"tennis-platform/backend/internal/controllers"
 	"tennis-platform/backend/internal/db"
 	"tennis-platform/backend/internal/middleware"
 	"tennis-platform/backend/internal/services"
 	"tennis-platform/backend/internal/usecases"
+	"time"
 
 	_ "tennis-platform/backend/docs"
 
@@ -24,6 +29,7 @@ type Server struct {
 	router                    *gin.Engine
 	jwtService                *services.JWTService
 	websocketService          *services.WebSocketService
+	eventBus                  *services.EventBus
 	authController            *controllers.AuthController
 	userController            *controllers.UserController
 	courtController           *controllers.CourtController
@@ -35,19 +41,49 @@ type Server struct {
 	reputationController      *controllers.ReputationController
 	matchStatisticsController *controllers.MatchStatisticsController
 	racketController          *controllers.RacketController
+	webhookController         *controllers.WebhookController
+	fileController            *controllers.FileController
+	calendarController        *controllers.CalendarController
+	coachCalendarController   *controllers.CoachCalendarController
+	webhookService            *services.WebhookService
+	coachCalendarService      *services.CoachCalendarService
+	paymentController         *controllers.PaymentController
+	paymentService            *services.PaymentService
+	cancellationController    *controllers.CancellationPolicyController
+	cancellationService       *services.CancellationService
+	courtPriceRuleController  *controllers.CourtPriceRuleController
+	courtUnitController       *controllers.CourtUnitController
+	courtEquipmentController  *controllers.CourtEquipmentController
+	courtClosureController    *controllers.CourtClosureController
+	courtAnalyticsController  *controllers.CourtAnalyticsController
+	invoiceController         *controllers.InvoiceController
+	promoCodeController       *controllers.PromoCodeController
+	bookingUsecase            *usecases.BookingUsecase
 }
 
 // NewServer 創建新的 API 服務器
-func NewServer(cfg *config.Config, database *db.Database) *Server {
+// redisClient 不為 nil 時透過 Redis 在多個實例間轉發 WebSocket 訊息
+func NewServer(cfg *config.Config, database *db.Database, redisClient *db.RedisClient) *Server {
 	// 設置 Gin 模式
 	if cfg.Env == "production" {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
+	// 驗證錯誤使用 JSON 欄位名稱
+	apperror.UseJSONFieldNames()
+
 	// 初始化服務層
 	jwtService := services.NewJWTService(cfg)
-	uploadService := services.NewUploadService(cfg)
+	storage, err := services.NewStorage(cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize storage: %v", err)
+	}
+	uploadService := services.NewUploadService(cfg, storage)
+	uploadService.UseDB(database.DB)
 	websocketService := services.NewWebSocketService()
+	if redisClient != nil {
+		websocketService.UseBackplane(services.NewRedisBackplane(redisClient.GetClient()))
+	}
 
 	// 初始化通知服務
 	var notificationService services.NotificationService
@@ -60,18 +96,76 @@ func NewServer(cfg *config.Config, database *db.Database) *Server {
 		notificationService = services.NewMockNotificationService()
 	}
 
+	// 初始化領域事件總線並註冊訂閱者
+	//
+	// 目前沒有應用層的數據緩存（Redis 只用於 WebSocket 轉發，行事曆訂閱只設置 HTTP Cache-Control），
+	// 因此不註冊緩存失效的訂閱者；之後加入緩存時在此訂閱相關事件以清除過期的數據。
+	eventBus := services.NewEventBus(database.DB)
+	services.RegisterNotificationSubscribers(eventBus, database.DB, notificationService)
+	services.RegisterReputationSubscribers(eventBus, database.DB)
+
+	// 初始化發票服務，扣款及退款後開立發票或折讓單
+	invoiceService := services.NewInvoiceService(database.DB, uploadService, notificationService, cfg.Invoice)
+	services.RegisterInvoiceSubscribers(eventBus, invoiceService)
+
+	// 預訂或課程取消時退回優惠碼的使用次數
+	services.RegisterPromoSubscribers(eventBus, services.NewPromoService(database.DB))
+
+	// 預訂取消時退回租借的器材
+	services.RegisterEquipmentSubscribers(eventBus, services.NewEquipmentService(database.DB))
+
+	// 初始化 Webhook 投遞服務
+	webhookService := services.NewWebhookService(database.DB)
+	services.RegisterWebhookSubscribers(eventBus, database.DB, webhookService)
+
+	// 初始化教練外部行事曆同步服務
+	coachCalendarService := services.NewCoachCalendarService(database.DB)
+
+	// 初始化付款服務
+	paymentProvider, err := services.NewPaymentProvider(cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize payment provider: %v", err)
+	}
+	paymentService := services.NewPaymentService(database.DB, paymentProvider, eventBus, time.Duration(cfg.Payment.HoldMinutes)*time.Minute)
+
+	// 初始化取消政策服務
+	cancellationService := services.NewCancellationService(database.DB, paymentService)
+
 	// 初始化用例層
 	authUsecase := usecases.NewAuthUsecase(database.DB, cfg)
 	userUsecase := usecases.NewUserUsecase(database.DB)
 	courtUsecase := usecases.NewCourtUsecase(database.DB)
 	reviewUsecase := usecases.NewReviewUsecase(database.DB, uploadService)
-	bookingUsecase := usecases.NewBookingUsecase(database.DB, notificationService)
-	coachUsecase := usecases.NewCoachUsecase(database.DB)
-	matchingUsecase := usecases.NewMatchingUsecase(database.DB)
+	bookingUsecase := usecases.NewBookingUsecase(database.DB, eventBus)
+	bookingUsecase.UsePaymentHold(paymentService.HoldDuration)
+	bookingUsecase.UseSlotHold(time.Duration(cfg.Payment.SlotHoldMinutes) * time.Minute)
+	bookingUsecase.UseCancellations(cancellationService)
+	bookingUsecase.UseWaitlistClaim(time.Duration(cfg.Payment.WaitlistClaimMinutes) * time.Minute)
+	bookingUsecase.RegisterWaitlistSubscribers(eventBus)
+	bookingUsecase.UseCheckIn(
+		services.NewCheckInSigner(cfg.CheckIn.Secret, time.Duration(cfg.CheckIn.OpensMinutes)*time.Minute),
+		time.Duration(cfg.CheckIn.NoShowGraceMinutes)*time.Minute,
+	)
+	coachUsecase := usecases.NewCoachUsecase(database.DB, eventBus)
+	coachUsecase.UsePaymentHold(paymentService.HoldDuration)
+	coachUsecase.UseCancellations(cancellationService)
+	matchingUsecase := usecases.NewMatchingUsecase(database.DB, eventBus)
 	chatUsecase := usecases.NewChatUsecase(database.DB)
 	racketUsecase := usecases.NewRacketUsecase(database.DB)
 	racketPriceUsecase := usecases.NewRacketPriceUsecase(database.DB)
 	racketReviewUsecase := usecases.NewRacketReviewUsecase(database.DB)
+	webhookUsecase := usecases.NewWebhookUsecase(database.DB, webhookService)
+	calendarUsecase := usecases.NewCalendarUsecase(database.DB, cfg)
+	coachCalendarUsecase := usecases.NewCoachCalendarUsecase(database.DB, coachCalendarService)
+	paymentUsecase := usecases.NewPaymentUsecase(database.DB, paymentService)
+	cancellationPolicyUsecase := usecases.NewCancellationPolicyUsecase(database.DB, cancellationService)
+	courtPriceRuleUsecase := usecases.NewCourtPriceRuleUsecase(database.DB)
+	courtUnitUsecase := usecases.NewCourtUnitUsecase(database.DB)
+	courtEquipmentUsecase := usecases.NewCourtEquipmentUsecase(database.DB)
+	courtClosureUsecase := usecases.NewCourtClosureUsecase(database.DB, bookingUsecase)
+	courtAnalyticsUsecase := usecases.NewCourtAnalyticsUsecase(database.DB, bookingUsecase)
+	invoiceUsecase := usecases.NewInvoiceUsecase(database.DB, invoiceService)
+	promoCodeUsecase := usecases.NewPromoCodeUsecase(database.DB)
 
 	// 初始化控制器層
 	authController := controllers.NewAuthController(authUsecase)
@@ -83,8 +177,21 @@ func NewServer(cfg *config.Config, database *db.Database) *Server {
 	matchesController := controllers.NewMatchesController(matchingUsecase)
 	chatController := controllers.NewChatController(chatUsecase, websocketService)
 	reputationController := controllers.NewReputationController(database.DB)
-	matchStatisticsController := controllers.NewMatchStatisticsController(database.DB)
+	matchStatisticsController := controllers.NewMatchStatisticsController(database.DB, eventBus)
 	racketController := controllers.NewRacketController(racketUsecase, racketPriceUsecase, racketReviewUsecase, uploadService)
+	webhookController := controllers.NewWebhookController(webhookUsecase)
+	fileController := controllers.NewFileController(uploadService)
+	calendarController := controllers.NewCalendarController(calendarUsecase)
+	coachCalendarController := controllers.NewCoachCalendarController(coachCalendarUsecase)
+	paymentController := controllers.NewPaymentController(paymentUsecase)
+	cancellationController := controllers.NewCancellationPolicyController(cancellationPolicyUsecase)
+	courtPriceRuleController := controllers.NewCourtPriceRuleController(courtPriceRuleUsecase)
+	courtUnitController := controllers.NewCourtUnitController(courtUnitUsecase)
+	courtEquipmentController := controllers.NewCourtEquipmentController(courtEquipmentUsecase)
+	courtClosureController := controllers.NewCourtClosureController(courtClosureUsecase)
+	courtAnalyticsController := controllers.NewCourtAnalyticsController(courtAnalyticsUsecase)
+	invoiceController := controllers.NewInvoiceController(invoiceUsecase)
+	promoCodeController := controllers.NewPromoCodeController(promoCodeUsecase)
 
 	server := &Server{
 		config:     cfg,
@@ -93,6 +200,7 @@ func NewServer(cfg *config.Config, database *db.Database) *Server {
 		jwtService: jwtService,
 
 		websocketService:          websocketService,
+		eventBus:                  eventBus,
 		authController:            authController,
 		userController:            userController,
 		courtController:           courtController,
@@ -104,6 +212,24 @@ func NewServer(cfg *config.Config, database *db.Database) *Server {
 		reputationController:      reputationController,
 		matchStatisticsController: matchStatisticsController,
 		racketController:          racketController,
+		webhookController:         webhookController,
+		fileController:            fileController,
+		calendarController:        calendarController,
+		coachCalendarController:   coachCalendarController,
+		webhookService:            webhookService,
+		coachCalendarService:      coachCalendarService,
+		paymentController:         paymentController,
+		paymentService:            paymentService,
+		cancellationController:    cancellationController,
+		cancellationService:       cancellationService,
+		courtPriceRuleController:  courtPriceRuleController,
+		courtUnitController:       courtUnitController,
+		courtEquipmentController:  courtEquipmentController,
+		courtClosureController:    courtClosureController,
+		courtAnalyticsController:  courtAnalyticsController,
+		invoiceController:         invoiceController,
+		promoCodeController:       promoCodeController,
+		bookingUsecase:            bookingUsecase,
 	}
 
 	// Disable automatic redirect for trailing slash
@@ -133,14 +259,18 @@ func (s *Server) setupRoutes() {
 		"Accept",
 		"Cache-Control",
 		"X-Requested-With",
+		"If-Match",
+		"If-None-Match",
 	}
-	config.ExposeHeaders = []string{"Content-Length"}
+	config.ExposeHeaders = []string{"Content-Length", "ETag"}
 	config.AllowCredentials = true
 
 	s.router.Use(cors.New(config))
 
-	// 靜態文件服務
-	s.router.Static("/uploads", s.config.Upload.UploadPath)
+	// 靜態文件服務，只公開圖片目錄；發票等私人文件經授權的端點下載
+	for _, dir := range services.PublicDirectories {
+		s.router.Static("/uploads/"+dir, filepath.Join(s.config.Upload.UploadPath, dir))
+	}
 
 	// Swagger 文檔
 	s.router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
@@ -187,6 +317,11 @@ func (s *Server) setupRoutes() {
 				users.PUT("/preferences", s.userController.UpdatePreferences)
 				users.PUT("/location", s.userController.UpdateLocation)
 				users.POST("/avatar", s.userController.UploadAvatar)
+				users.GET("/me/calendar", s.calendarController.GetMyCalendar)
+				users.GET("/me/calendar/feed", s.calendarController.GetCalendarFeed)
+				users.POST("/me/calendar/feed/rotate", s.calendarController.RotateCalendarFeed)
+				users.DELETE("/me/calendar/feed", s.calendarController.DeleteCalendarFeed)
+				users.GET("/free-busy", s.calendarController.GetFreeBusy)
 			}
 
 			// OAuth 帳號管理路由（需要認證）
@@ -215,6 +350,11 @@ func (s *Server) setupRoutes() {
 			courts.GET("/availability", s.courtController.GetCourtAvailability)
 			courts.GET("/:id", s.courtController.GetCourt)
 			courts.GET("/:id/reviews/statistics", s.courtController.GetReviewStatistics)
+			courts.GET("/:id/cancellation-policy", s.cancellationController.GetCourtPolicy)
+			courts.GET("/:id/price-rules", s.courtPriceRuleController.GetRules)
+			courts.GET("/:id/units", s.courtUnitController.GetUnits)
+			courts.GET("/:id/equipment", s.courtEquipmentController.GetEquipment)
+			courts.GET("/:id/closures", s.courtClosureController.GetClosures)
 
 			// 需要認證的路由
 			courtsProtected := courts.Group("/")
@@ -225,6 +365,38 @@ func (s *Server) setupRoutes() {
 				courtsProtected.DELETE("/:id", s.courtController.DeleteCourt)
 				courtsProtected.POST("/:id/images", s.courtController.UploadCourtImages)
 
+				// 取消政策（僅場地擁有者）
+				courtsProtected.PUT("/:id/cancellation-policy", s.cancellationController.UpdateCourtPolicy)
+				courtsProtected.DELETE("/:id/cancellation-policy", s.cancellationController.DeleteCourtPolicy)
+				courtsProtected.GET("/:id/cancellation-overrides", s.cancellationController.GetCourtOverrides)
+				courtsProtected.POST("/:id/cancellation-overrides", s.cancellationController.CreateCourtOverride)
+				courtsProtected.DELETE("/:id/cancellation-overrides/:overrideId", s.cancellationController.DeleteCourtOverride)
+
+				// 球場（僅場地擁有者）
+				courtsProtected.POST("/:id/units", s.courtUnitController.CreateUnit)
+				courtsProtected.PUT("/:id/units/:unitId", s.courtUnitController.UpdateUnit)
+				courtsProtected.DELETE("/:id/units/:unitId", s.courtUnitController.DeleteUnit)
+
+				// 租借器材（僅場地擁有者）
+				courtsProtected.POST("/:id/equipment", s.courtEquipmentController.CreateEquipment)
+				courtsProtected.PUT("/:id/equipment/:equipmentId", s.courtEquipmentController.UpdateEquipment)
+				courtsProtected.DELETE("/:id/equipment/:equipmentId", s.courtEquipmentController.DeleteEquipment)
+
+				// 休館及封鎖時段（僅場地擁有者）
+				courtsProtected.POST("/:id/closures", s.courtClosureController.CreateClosure)
+				courtsProtected.POST("/:id/closures/preview", s.courtClosureController.PreviewClosure)
+				courtsProtected.DELETE("/:id/closures/:closureId", s.courtClosureController.DeleteClosure)
+
+				// 場地分析（僅場地擁有者）
+				courtsProtected.GET("/:id/analytics", s.courtAnalyticsController.GetAnalytics)
+				courtsProtected.GET("/:id/analytics/demand", s.courtAnalyticsController.GetDemand)
+				courtsProtected.GET("/:id/analytics/export", s.courtAnalyticsController.ExportAnalytics)
+
+				// 價格規則（僅場地擁有者）
+				courtsProtected.POST("/:id/price-rules", s.courtPriceRuleController.CreateRule)
+				courtsProtected.PUT("/:id/price-rules/:ruleId", s.courtPriceRuleController.UpdateRule)
+				courtsProtected.DELETE("/:id/price-rules/:ruleId", s.courtPriceRuleController.DeleteRule)
+
 			}
 		}
 
@@ -253,10 +425,67 @@ func (s *Server) setupRoutes() {
 		bookings.Use(middleware.AuthMiddleware(s.jwtService))
 		{
 			bookings.POST("", s.courtController.CreateBooking)
+			bookings.POST("/holds", s.courtController.HoldSlot)
+			bookings.DELETE("/holds/:holdId", s.courtController.ReleaseHold)
+			bookings.POST("/series", s.courtController.CreateBookingSeries)
+			bookings.GET("/series/:seriesId", s.courtController.GetBookingSeries)
+			bookings.PUT("/series/:seriesId", s.courtController.UpdateBookingSeries)
+			bookings.POST("/series/:seriesId/cancel", s.courtController.CancelBookingSeries)
+			bookings.POST("/waitlist", s.courtController.JoinWaitlist)
+			bookings.GET("/waitlist", s.courtController.GetWaitlist)
+			bookings.GET("/waitlist/:entryId", s.courtController.GetWaitlistEntry)
+			bookings.DELETE("/waitlist/:entryId", s.courtController.LeaveWaitlist)
+			bookings.POST("/waitlist/:entryId/claim", s.courtController.ClaimWaitlistOffer)
+			bookings.GET("/payment-shares", s.courtController.GetPaymentShares)
+			bookings.POST("/check-in", s.courtController.CheckIn)
 			bookings.GET("", s.courtController.GetBookings)
 			bookings.GET("/:id", s.courtController.GetBooking)
 			bookings.PUT("/:id", s.courtController.UpdateBooking)
+			bookings.PUT("/:id/add-ons", s.courtController.UpdateBookingAddOns)
+			bookings.GET("/:id/cancellation-quote", s.courtController.GetCancellationQuote)
 			bookings.POST("/:id/cancel", s.courtController.CancelBooking)
+			bookings.POST("/:id/split", s.courtController.CreateBookingSplit)
+			bookings.GET("/:id/split", s.courtController.GetBookingSplit)
+			bookings.DELETE("/:id/split", s.courtController.CancelBookingSplit)
+			bookings.GET("/:id/check-in-pass", s.courtController.GetCheckInPass)
+			bookings.GET("/:id/ics", s.calendarController.ExportBooking)
+		}
+
+		// 付款相關路由
+		payments := v1.Group("/payments")
+		{
+			// 付款服務商通知，以簽名驗證
+			payments.POST("/webhooks/:provider", s.paymentController.HandleWebhook)
+
+			paymentsProtected := payments.Group("")
+			paymentsProtected.Use(middleware.AuthMiddleware(s.jwtService))
+			{
+				paymentsProtected.POST("", s.paymentController.CreatePayment)
+				paymentsProtected.GET("/:id", s.paymentController.GetPayment)
+				paymentsProtected.POST("/:id/capture", s.paymentController.CapturePayment)
+				paymentsProtected.POST("/:id/cancel", s.paymentController.CancelPayment)
+			}
+		}
+
+		// 發票相關路由
+		invoices := v1.Group("/invoices")
+		invoices.Use(middleware.AuthMiddleware(s.jwtService))
+		{
+			invoices.GET("", s.invoiceController.ListInvoices)
+			invoices.GET("/:id", s.invoiceController.GetInvoice)
+			invoices.GET("/:id/pdf", s.invoiceController.DownloadInvoice)
+			invoices.POST("/:id/resend", s.invoiceController.ResendInvoice)
+		}
+
+		// 優惠碼相關路由
+		promoCodes := v1.Group("/promo-codes")
+		promoCodes.Use(middleware.AuthMiddleware(s.jwtService))
+		{
+			promoCodes.POST("", s.promoCodeController.CreatePromoCode)
+			promoCodes.GET("", s.promoCodeController.ListPromoCodes)
+			promoCodes.GET("/:id", s.promoCodeController.GetPromoCode)
+			promoCodes.PUT("/:id", s.promoCodeController.UpdatePromoCode)
+			promoCodes.GET("/:id/redemptions", s.promoCodeController.ListRedemptions)
 		}
 
 		// 教練相關路由
@@ -274,6 +503,7 @@ func (s *Server) setupRoutes() {
 			coaches.GET("/:id/availability", s.coachController.GetCoachAvailability)
 			coaches.GET("/:id/schedule", s.coachController.GetCoachSchedule)
 			coaches.GET("/:id/review-statistics", s.coachController.GetCoachReviewStatistics)
+			coaches.GET("/:id/cancellation-policy", s.cancellationController.GetCoachPolicy)
 
 			// 需要認證的路由
 			coachesProtected := coaches.Group("")
@@ -287,6 +517,20 @@ func (s *Server) setupRoutes() {
 				// 課程類型管理
 				coachesProtected.POST("/lesson-types", s.coachController.CreateLessonType)
 				coachesProtected.PUT("/schedule", s.coachController.UpdateCoachSchedule)
+
+				// 外部行事曆
+				coachesProtected.GET("/my-calendars", s.coachCalendarController.GetMyCalendars)
+				coachesProtected.POST("/my-calendars", s.coachCalendarController.CreateMyCalendar)
+				coachesProtected.POST("/my-calendars/upload", s.coachCalendarController.UploadMyCalendar)
+				coachesProtected.POST("/my-calendars/:sourceId/sync", s.coachCalendarController.SyncMyCalendar)
+				coachesProtected.DELETE("/my-calendars/:sourceId", s.coachCalendarController.DeleteMyCalendar)
+
+				// 取消政策
+				coachesProtected.PUT("/my-cancellation-policy", s.cancellationController.UpdateMyCoachPolicy)
+				coachesProtected.DELETE("/my-cancellation-policy", s.cancellationController.DeleteMyCoachPolicy)
+				coachesProtected.GET("/my-cancellation-overrides", s.cancellationController.GetMyCoachOverrides)
+				coachesProtected.POST("/my-cancellation-overrides", s.cancellationController.CreateMyCoachOverride)
+				coachesProtected.DELETE("/my-cancellation-overrides/:overrideId", s.cancellationController.DeleteMyCoachOverride)
 			}
 		}
 
@@ -309,7 +553,9 @@ func (s *Server) setupRoutes() {
 				lessonsProtected.GET("", s.coachController.GetLessons)
 				lessonsProtected.GET("/:id", s.coachController.GetLesson)
 				lessonsProtected.PUT("/:id", s.coachController.UpdateLesson)
+				lessonsProtected.GET("/:id/cancellation-quote", s.coachController.GetLessonCancellationQuote)
 				lessonsProtected.POST("/:id/cancel", s.coachController.CancelLesson)
+				lessonsProtected.GET("/:id/ics", s.calendarController.ExportLesson)
 			}
 		}
 
@@ -386,6 +632,13 @@ func (s *Server) setupRoutes() {
 			matches.POST("/find", s.matchesController.FindMatches)
 			matches.GET("/history", s.matchesController.GetMatchHistory)
 			matches.POST("/create", s.matchesController.CreateMatch)
+			matches.GET("/:id/ics", s.calendarController.ExportMatch)
+		}
+
+		// 行事曆訂閱路由（以網址中的密鑰認證）
+		calendar := v1.Group("/calendar")
+		{
+			calendar.GET("/feeds/:token", s.calendarController.GetFeed)
 		}
 
 		// 俱樂部相關路由
@@ -437,6 +690,37 @@ func (s *Server) setupRoutes() {
 			racketReviews.POST("/:reviewId/helpful", s.racketController.MarkRacketReviewHelpful)
 		}
 
+		// 文件存儲相關路由
+		files := v1.Group("/files")
+		{
+			// 簽名連結自帶授權，不需要登入
+			files.GET("/object/*key", s.fileController.DownloadObject)
+			files.PUT("/object/*key", s.fileController.UploadObject)
+			files.GET("/images", s.fileController.GetImageAssets)
+
+			filesProtected := files.Group("")
+			filesProtected.Use(middleware.AuthMiddleware(s.jwtService))
+			{
+				filesProtected.GET("/signed-url", s.fileController.GetSignedURL)
+				filesProtected.POST("/presign", s.fileController.PresignUpload)
+				filesProtected.POST("/complete", s.fileController.CompleteUpload)
+			}
+		}
+
+		// Webhook 相關路由
+		webhooks := v1.Group("/webhooks")
+		webhooks.Use(middleware.AuthMiddleware(s.jwtService))
+		{
+			webhooks.POST("", s.webhookController.CreateWebhook)
+			webhooks.GET("", s.webhookController.GetWebhooks)
+			webhooks.GET("/:id", s.webhookController.GetWebhook)
+			webhooks.PUT("/:id", s.webhookController.UpdateWebhook)
+			webhooks.DELETE("/:id", s.webhookController.DeleteWebhook)
+			webhooks.POST("/:id/rotate-secret", s.webhookController.RotateWebhookSecret)
+			webhooks.GET("/:id/deliveries", s.webhookController.GetWebhookDeliveries)
+			webhooks.POST("/:id/deliveries/:deliveryId/redeliver", s.webhookController.RedeliverWebhook)
+		}
+
 		// 聊天相關路由
 		chat := v1.Group("/chat")
 		chat.Use(middleware.AuthMiddleware(s.jwtService))
@@ -516,6 +800,30 @@ func (s *Server) setupRoutes() {
 
 // Start 啟動服務器
 func (s *Server) Start() error {
+	// 啟動發件箱事件派送
+	go s.eventBus.Start(context.Background())
+
+	// 啟動 Webhook 投遞
+	go s.webhookService.Start(context.Background())
+
+	// 啟動教練外部行事曆同步
+	go s.coachCalendarService.Start(context.Background())
+
+	// 啟動逾期未付款保留的取消
+	go s.paymentService.Start(context.Background())
+
+	// 啟動取消退款的重試
+	go s.cancellationService.Start(context.Background())
+
+	// 啟動逾期未認領候補的轉交
+	go s.bookingUsecase.StartWaitlist(context.Background())
+
+	// 啟動已結束預訂的出席結算
+	go s.bookingUsecase.StartAttendance(context.Background())
+
+	// 啟動 WebSocket 跨實例轉發
+	go s.websocketService.Start(context.Background())
+
 	return s.router.Run(":" + s.config.Port)
 }
 
@@ -0,0 +1,95 @@
+package apperror
+
+import (
+	"errors"
+	"net/http"
+)
+
+// Error 帶有穩定錯誤碼的領域錯誤
+//
+// Error() 返回默認語系（zh-TW）的訊息，回應時依 Accept-Language 從訊息目錄重新渲染。
+type Error struct {
+	Code   Code
+	Params map[string]interface{}
+	Cause  error
+	Fields []FieldError
+
+	// Extensions 附加在問題詳情上的擴展欄位，例如時段衝突時建議的替代時段
+	Extensions map[string]interface{}
+}
+
+// New 創建領域錯誤
+func New(code Code) *Error {
+	return &Error{Code: code}
+}
+
+// Wrap 創建帶有底層原因的領域錯誤，原因僅用於日誌，不會返回給客戶端
+func Wrap(code Code, cause error) *Error {
+	return &Error{Code: code, Cause: cause}
+}
+
+// With 設置訊息參數，返回副本以免修改共用的錯誤值
+func (e *Error) With(key string, value interface{}) *Error {
+	clone := *e
+	clone.Params = make(map[string]interface{}, len(e.Params)+1)
+	for k, v := range e.Params {
+		clone.Params[k] = v
+	}
+	clone.Params[key] = value
+	return &clone
+}
+
+// WithExtension 設置問題詳情的擴展欄位，返回副本以免修改共用的錯誤值
+func (e *Error) WithExtension(key string, value interface{}) *Error {
+	clone := *e
+	clone.Extensions = make(map[string]interface{}, len(e.Extensions)+1)
+	for k, v := range e.Extensions {
+		clone.Extensions[k] = v
+	}
+	clone.Extensions[key] = value
+	return &clone
+}
+
+// Error 實現 error 接口
+func (e *Error) Error() string {
+	return e.Message(DefaultLocale)
+}
+
+// Message 以指定語系渲染錯誤訊息
+func (e *Error) Message(locale string) string {
+	return Message(locale, e.Code, e.Params)
+}
+
+// Unwrap 返回底層原因
+func (e *Error) Unwrap() error {
+	return e.Cause
+}
+
+// Is 以錯誤碼比較，使 errors.Is 不受參數與原因影響
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// Status 錯誤碼對應的 HTTP 狀態碼
+func (e *Error) Status() int {
+	if status, ok := statuses[e.Code]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// From 從錯誤鏈中取出領域錯誤，非領域錯誤一律視為內部錯誤
+func From(err error) *Error {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	return Wrap(CodeInternal, err)
+}
+
+// HasCode 判斷錯誤鏈中是否包含指定錯誤碼
+func HasCode(err error, code Code) bool {
+	var appErr *Error
+	return errors.As(err, &appErr) && appErr.Code == code
+}
@@ -0,0 +1,122 @@
+package apperror
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCatalogs_CoverAllCodes(t *testing.T) {
+	for code := range statuses {
+		for locale, catalog := range catalogs {
+			_, ok := catalog[code]
+			assert.True(t, ok, "%s 缺少 %s 的訊息", locale, code)
+		}
+	}
+}
+
+func TestNegotiateLocale(t *testing.T) {
+	cases := map[string]string{
+		"":                        LocaleZhTW,
+		"en-US,en;q=0.9":          LocaleEN,
+		"zh-TW,zh;q=0.9,en;q=0.8": LocaleZhTW,
+		"fr-FR, en;q=0.5":         LocaleEN,
+		"en;q=0.3, zh-Hant;q=0.8": LocaleZhTW,
+		"ja-JP":                   LocaleZhTW,
+		"en;q=0":                  LocaleZhTW,
+	}
+	for header, expected := range cases {
+		assert.Equal(t, expected, NegotiateLocale(header), header)
+	}
+}
+
+func TestError_MessageAndIs(t *testing.T) {
+	base := New(CodeCourtInvalidFacility)
+	err := fmt.Errorf("驗證失敗: %w", base.With("facility", "pool"))
+
+	assert.True(t, errors.Is(err, base))
+	assert.True(t, HasCode(err, CodeCourtInvalidFacility))
+	assert.Equal(t, "無效的設施: pool", From(err).Error())
+	assert.Equal(t, "Invalid facility: pool", From(err).Message(LocaleEN))
+	assert.Nil(t, base.Params, "With 不應修改原錯誤")
+}
+
+func performWrite(t *testing.T, err error, acceptLanguage string) (*httptest.ResponseRecorder, Problem) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/bookings/123", nil)
+	c.Request.Header.Set("Accept-Language", acceptLanguage)
+
+	Write(c, err)
+
+	var problem Problem
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	return w, problem
+}
+
+func TestWrite_LocalizedProblem(t *testing.T) {
+	w, problem := performWrite(t, New(CodeBookingCancelWindowPassed).With("hours", 2), "en-US")
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.True(t, strings.HasPrefix(w.Header().Get("Content-Type"), ProblemContentType))
+	assert.Equal(t, LocaleEN, w.Header().Get("Content-Language"))
+	assert.Equal(t, "urn:tennis-platform:problem:booking.cancel_window_passed", problem.Type)
+	assert.Equal(t, CodeBookingCancelWindowPassed, problem.Code)
+	assert.Equal(t, "Bookings cannot be cancelled within 2 hours of the start time", problem.Detail)
+	assert.Equal(t, "/api/v1/bookings/123", problem.Instance)
+
+	_, problem = performWrite(t, New(CodeBookingCancelWindowPassed).With("hours", 2), "zh-TW")
+	assert.Equal(t, "預訂開始前2小時內無法取消", problem.Detail)
+}
+
+func TestWrite_UntypedErrorIsInternal(t *testing.T) {
+	w, problem := performWrite(t, errors.New("pq: connection refused"), "en")
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, CodeInternal, problem.Code)
+	assert.NotContains(t, problem.Detail, "pq:")
+}
+
+func TestValidation_FieldErrors(t *testing.T) {
+	UseJSONFieldNames()
+
+	var req struct {
+		CourtID string `json:"courtId" binding:"required"`
+		Rating  int    `json:"rating" binding:"min=1,max=5"`
+	}
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"rating": 9}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+	err := c.ShouldBindJSON(&req)
+
+	problem := NewProblem(Validation(err), LocaleEN, "")
+	assert.Equal(t, http.StatusBadRequest, problem.Status)
+	assert.Len(t, problem.Errors, 2)
+	assert.Equal(t, "courtId", problem.Errors[0].Field)
+	assert.Equal(t, "This field is required", problem.Errors[0].Message)
+	assert.Equal(t, "rating", problem.Errors[1].Field)
+	assert.Equal(t, "Must be at most 5", problem.Errors[1].Message)
+}
+
+func TestWrite_ProblemExtensions(t *testing.T) {
+	base := New(CodeBookingSlotTaken)
+	err := base.WithExtension("alternatives", []string{"10:00", "11:00"}).WithExtension("code", "ignored")
+	assert.Nil(t, base.Extensions, "WithExtension 不應修改原錯誤")
+
+	w, problem := performWrite(t, err, "en")
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, CodeBookingSlotTaken, problem.Code, "擴展欄位不應覆蓋標準欄位")
+
+	var body map[string]interface{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, []interface{}{"10:00", "11:00"}, body["alternatives"])
+}
@@ -0,0 +1,645 @@
+package apperror
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 支援的語系
+const (
+	LocaleZhTW    = "zh-TW"
+	LocaleEN      = "en"
+	DefaultLocale = LocaleZhTW
+)
+
+// catalogs 錯誤訊息目錄，參數以 {name} 表示
+var catalogs = map[string]map[Code]string{
+	LocaleZhTW: {
+		CodeInternal:           "伺服器內部錯誤，請稍後再試",
+		CodeValidation:         "請求參數錯誤",
+		CodeUnauthorized:       "用戶未認證",
+		CodeInvalidCursor:      "無效的分頁游標",
+		CodeUnsupportedSort:    "不支援的排序欄位",
+		CodeMissingParam:       "缺少必要參數: {name}",
+		CodePreconditionFailed: "資源已被其他人修改，請重新取得最新版本後再試",
+
+		CodeMissingToken:             "缺少認證令牌",
+		CodeInvalidTokenFormat:       "無效的認證令牌格式",
+		CodeInvalidToken:             "無效的認證令牌",
+		CodeInvalidCredentials:       "用戶不存在或密碼錯誤",
+		CodeAccountDisabled:          "帳號已被停用",
+		CodeInvalidRefreshToken:      "無效的刷新令牌",
+		CodeRefreshTokenExpired:      "刷新令牌不存在或已過期",
+		CodeOAuthUnsupportedProvider: "不支援的 OAuth 提供商",
+		CodeOAuthInvalidState:        "無效的狀態參數",
+		CodeOAuthExchangeFailed:      "OAuth 令牌交換失敗",
+		CodeOAuthUserInfoFailed:      "獲取 OAuth 用戶資訊失敗",
+		CodeOAuthAccountTaken:        "該 OAuth 帳號已被其他用戶關聯",
+		CodeOAuthAccountLinked:       "該 OAuth 帳號已關聯到您的帳號",
+		CodeOAuthProviderLinked:      "您已關聯該提供商的帳號",
+		CodeOAuthLastLoginMethod:     "無法解除關聯：您需要設置密碼或關聯其他登入方式",
+		CodeOAuthAccountNotFound:     "未找到要解除關聯的帳號",
+
+		CodeUserNotFound:         "用戶不存在",
+		CodeUserAlreadyExists:    "用戶已存在",
+		CodeUserProfileExists:    "用戶檔案已存在",
+		CodeUserInvalidNTRPLevel: "NTRP 等級必須在 1.0 到 7.0 之間，且為 0.5 的倍數（例如：1.0, 1.5, 2.0...）",
+
+		CodeCalendarInvalidRange: "結束時間必須晚於開始時間",
+		CodeCalendarRangeTooLong: "查詢範圍不能超過{days}天",
+		CodeCalendarItemNotFound: "行程不存在或無權限查看",
+		CodeCalendarFeedNotFound: "行事曆訂閱不存在或已失效",
+
+		CodeCoachNotFound:             "教練檔案不存在",
+		CodeCoachProfileExists:        "教練檔案已存在",
+		CodeCoachForbidden:            "無權限管理此教練檔案",
+		CodeCoachInvalidWeekday:       "無效的星期: {day}",
+		CodeCoachInvalidTime:          "無效的時間: {value}，格式應為 HH:MM",
+		CodeCoachInvalidTimeSlot:      "無效的時間段: {value}，格式應為 HH:MM-HH:MM",
+		CodeCoachInvalidTimeRange:     "開始時間必須早於結束時間",
+		CodeCoachInvalidDate:          "日期格式錯誤，應為 YYYY-MM-DD",
+		CodeCoachCalendarNotFound:     "外部行事曆不存在",
+		CodeCoachCalendarInvalidURL:   "無效的行事曆地址，必須使用 https、http 或 webcal",
+		CodeCoachCalendarInvalidFile:  "無法解析 iCalendar 文件",
+		CodeCoachCalendarLimitReached: "最多只能連結{max}個外部行事曆",
+
+		CodeLessonStatusReadOnly:          "課程不能以修改直接取消，請使用取消課程以套用取消政策及退款",
+		CodeLessonNotFound:                "課程不存在",
+		CodeLessonForbidden:               "無權限操作此課程",
+		CodeLessonNotEditable:             "已完成或已取消的課程無法修改",
+		CodeLessonNotCancellable:          "課程已完成或已取消",
+		CodeLessonCancelForbidden:         "無權限取消此課程",
+		CodeLessonCancelWindowPassed:      "課程開始前{hours}小時內無法取消",
+		CodeLessonTimeConflict:            "時間衝突：該時段已有其他課程",
+		CodeLessonTypeNotFound:            "課程類型不存在",
+		CodeLessonTypeGroupSizeRequired:   "團體課程必須設定最大參與人數（至少2人）",
+		CodeLessonTypeInvalidParticipants: "最小參與人數不能大於最大參與人數",
+		CodeLessonTypeInUse:               "無法刪除：存在關聯的課程",
+
+		CodeCoachReviewNotFound:           "評價不存在",
+		CodeCoachReviewNotOwned:           "只有評價者本人可以修改或刪除評價",
+		CodeCoachReviewNotEditable:        "評價創建超過24小時，無法修改或刪除",
+		CodeCoachReviewLessonNotFound:     "課程不存在或無權限評價",
+		CodeCoachReviewLessonNotCompleted: "只能評價已完成的課程",
+		CodeCoachReviewLessonReviewed:     "該課程已經評價過",
+		CodeCoachReviewDuplicate:          "已經評價過該教練",
+		CodeCoachReviewOwnHelpful:         "不能標記自己的評價",
+
+		CodeSchedulingNoAvailableTime: "未找到合適的課程時間",
+
+		CodeMatchNotFound:             "比賽不存在",
+		CodeMatchNotParticipant:       "您不是此比賽的參與者",
+		CodeMatchInvalidType:          "不支援的配對類型",
+		CodeMatchCardInvalidAction:    "不支援的卡片操作",
+		CodeMatchCardSelfAction:       "不能對自己執行此操作",
+		CodeMatchNotificationNotFound: "通知不存在",
+
+		CodeMatchHistoryPrivate:         "用戶配對歷史為私人設定",
+		CodeSkillProgressionPrivate:     "技術等級進展為私人設定",
+		CodeReputationScorePrivate:      "信譽分數為私人設定",
+		CodeBehaviorReviewsPrivate:      "行為評價為私人設定",
+		CodeMatchResultNotFound:         "比賽結果不存在",
+		CodeMatchResultInvalid:          "比賽結果缺少勝負方，無法確認",
+		CodeMatchResultInvalidPlayers:   "勝方與負方都必須是比賽參與者",
+		CodeMatchResultForbidden:        "無權限確認此結果",
+		CodeMatchResultAlreadyConfirmed: "您已確認過此結果",
+
+		CodeReputationMatchNotScheduled: "比賽尚未排定時間",
+		CodeReputationInvalidRating:     "評分必須介於 1.0 到 5.0 之間",
+		CodeReputationSelfReview:        "不能評價自己",
+		CodeReputationNotParticipants:   "評價雙方都必須是比賽參與者",
+		CodeReputationDuplicateReview:   "您已評價過此用戶在這場比賽的表現",
+
+		CodeUploadInvalidForm:     "獲取上傳文件失敗",
+		CodeUploadMissingFile:     "未找到上傳文件",
+		CodeUploadUnsupportedType: "不支援的文件類型，僅支援 {allowed}",
+		CodeUploadTooLarge:        "文件大小超過限制，最大允許 {maxMB} MB",
+		CodeUploadInvalidKey:      "無效的文件路徑",
+		CodeUploadInvalidLink:     "連結無效或已過期",
+		CodeUploadNotFound:        "文件不存在",
+		CodeUploadInvalidImage:    "圖片內容無效或與文件類型不符",
+		CodeUploadForbidden:       "無權限訪問此文件",
+
+		CodeCourtNotFound:             "場地不存在",
+		CodeCourtUnavailable:          "場地不存在或不可用",
+		CodeCourtInvalidHours:         "營業時間格式錯誤",
+		CodeCourtInvalidWeekday:       "無效的星期: {day}",
+		CodeCourtInvalidTimeRange:     "無效的時間格式: {value}，應為 HH:MM-HH:MM 或 closed",
+		CodeCourtInvalidFacility:      "無效的設施: {facility}",
+		CodeCourtClosed:               "場地在該日期不營業",
+		CodeBookingOutsideHours:       "預訂時間超出營業時間範圍 ({hours})",
+		CodeCourtBlocked:              "場地在該時段暫停開放",
+		CodeBookingNotFound:           "預訂不存在",
+		CodeBookingModifyForbidden:    "無權限修改此預訂",
+		CodeBookingCancelForbidden:    "無權限取消此預訂",
+		CodeBookingNotPending:         "只有待確認的預訂可以修改時間",
+		CodeBookingAlreadyCancelled:   "預訂已經取消",
+		CodeBookingAlreadyCompleted:   "已完成的預訂無法取消",
+		CodeBookingCancelWindowPassed: "預訂開始前{hours}小時內無法取消",
+		CodeBookingInvalidTimeRange:   "結束時間必須晚於開始時間",
+		CodeBookingTooShort:           "預訂時長不能少於{minutes}分鐘",
+		CodeBookingTooLong:            "預訂時長不能超過{hours}小時",
+		CodeBookingInPast:             "不能預訂過去的時間",
+		CodeBookingTooFarAhead:        "不能預訂{days}天後的時間",
+		CodeBookingSlotTaken:          "該時間段已被預訂",
+		CodeBookingHoldNotFound:       "保留的時段不存在或已過期",
+		CodeBookingHoldMismatch:       "預訂內容與保留的時段不符",
+		CodeBookingStatusReadOnly:     "預訂狀態不能直接修改，完成付款後自動確認，取消請使用取消預訂",
+
+		CodeBookingSeriesNotFound:           "重複預訂不存在",
+		CodeBookingSeriesForbidden:          "只有預訂者可以修改重複預訂",
+		CodeBookingSeriesCancelled:          "重複預訂已取消",
+		CodeBookingSeriesInvalidRule:        "重複規則無效",
+		CodeBookingSeriesEndRequired:        "需指定結束日期或重複次數其中之一",
+		CodeBookingSeriesTooMany:            "重複預訂最多{max}次",
+		CodeBookingSeriesTooFarAhead:        "重複預訂不能超過{days}天後",
+		CodeBookingSeriesConflict:           "有{count}次無法預訂或修改",
+		CodeBookingSeriesOccurrenceNotFound: "該預訂不屬於此重複預訂",
+
+		CodeWaitlistNotFound:        "候補不存在",
+		CodeWaitlistForbidden:       "無權查看或修改此候補",
+		CodeWaitlistDuplicate:       "已在此時段的候補中",
+		CodeWaitlistSlotAvailable:   "時段仍可預訂，請直接預訂",
+		CodeWaitlistInvalidDuration: "預訂時長不能超過候補的時間範圍",
+		CodeWaitlistNotOffered:      "目前沒有提供給此候補的時段",
+		CodeWaitlistOfferExpired:    "認領期限已過",
+		CodeWaitlistClosed:          "候補已結束",
+
+		CodeCheckInInvalidPass: "報到碼無效或已失效",
+		CodeCheckInForbidden:   "無權限為此場地的預訂報到",
+		CodeCheckInNotAllowed:  "只有已確認的預訂可以報到",
+		CodeCheckInNotOpen:     "預訂開始前{minutes}分鐘開放報到",
+		CodeCheckInClosed:      "預訂已結束，無法報到",
+		CodeCheckInAlreadyDone: "預訂已報到",
+		CodeCheckInDisabled:    "報到功能未啟用",
+
+		CodeCourtUnitNotFound:    "球場不存在",
+		CodeCourtUnitForbidden:   "無權限管理此場地的球場",
+		CodeCourtUnitDuplicate:   "場地內已有{number}號球場",
+		CodeCourtUnitUnavailable: "{number}號球場暫停開放預訂",
+		CodeCourtUnitHasBookings: "球場還有{count}筆未開始的預訂，無法刪除",
+
+		CodeEquipmentNotFound:        "租借器材不存在",
+		CodeEquipmentForbidden:       "無權限管理此場地的租借器材",
+		CodeEquipmentUnavailable:     "{name}暫停租借",
+		CodeEquipmentInsufficient:    "{name}在此時段只剩{available}件可租借",
+		CodeEquipmentHasReservations: "器材還有{count}筆未開始的租借，無法刪除",
+		CodeBookingAddOnsLocked:      "只有未付款的單次預訂可以修改租借器材",
+
+		CodeCourtClosureNotFound:         "場地例外不存在",
+		CodeCourtClosureForbidden:        "無權限管理此場地的例外",
+		CodeCourtClosureInvalidDateRange: "日期格式需為 YYYY-MM-DD，且結束日期不能早於開始日期",
+		CodeCourtClosureInvalidTimeRange: "時間格式需為 HH:MM，且結束時間需晚於開始時間",
+		CodeCourtClosureUnitNotAllowed:   "更改營業時間不能指定球場",
+
+		CodeCourtAnalyticsForbidden:       "無權限查看此場地的分析",
+		CodeCourtAnalyticsInvalidRange:    "日期格式需為 YYYY-MM-DD，結束日期不能早於開始日期，且範圍不能超過{days}天",
+		CodeCourtAnalyticsInvalidTimezone: "時區無效",
+
+		CodePriceRuleNotFound:         "價格規則不存在",
+		CodePriceRuleForbidden:        "無權限管理此場地的價格規則",
+		CodePriceRuleInvalidTimeRange: "無效的時段: {value}，應為 HH:MM 且開始時間早於結束時間",
+		CodePriceRuleInvalidDateRange: "無效的日期範圍: {value}，應為 YYYY-MM-DD 且開始日期不晚於結束日期",
+		CodePriceRuleClubRequired:     "會員價或非會員價規則必須指定俱樂部",
+
+		CodePaymentNotFound:         "付款記錄不存在",
+		CodePaymentTargetNotFound:   "付款項目不存在",
+		CodePaymentNotRequired:      "此項目無需付款",
+		CodePaymentAlreadyPaid:      "此項目已完成付款",
+		CodePaymentHoldExpired:      "付款期限已過，保留已被取消",
+		CodePaymentInvalidState:     "付款狀態為 {status}，無法執行此操作",
+		CodePaymentProviderError:    "付款服務暫時無法使用，請稍後再試",
+		CodePaymentInvalidSignature: "無效的付款通知簽名",
+
+		CodeBookingSplitNotFound:        "分攤付款不存在",
+		CodeBookingSplitForbidden:       "只有預訂者可以管理分攤付款",
+		CodeBookingSplitExists:          "預訂已有進行中的分攤付款",
+		CodeBookingSplitNotAllowed:      "只有尚未付款的單次預訂可以分攤付款",
+		CodeBookingSplitInvalidShares:   "分攤金額總和需等於預訂金額 {total}",
+		CodeBookingSplitInvalidPayers:   "分攤對象需為其他有效用戶且不能重複",
+		CodeBookingSplitInvalidMatch:    "比賽不存在或您不是比賽參與者",
+		CodeBookingSplitInvalidDeadline: "付款期限需晚於現在且不晚於預訂開始時間",
+		CodeBookingSplitHasPayments:     "已有分攤完成付款，無法取消分攤",
+
+		CodeInvoiceNotFound: "發票不存在",
+
+		CodePromoCodeNotFound:          "優惠碼不存在",
+		CodePromoCodeForbidden:         "只有優惠碼的建立者可以管理優惠碼",
+		CodePromoCodeExists:            "優惠碼 {code} 已存在",
+		CodePromoCodeInvalid:           "優惠碼無效或已停用",
+		CodePromoCodeNotApplicable:     "優惠碼不適用於此預訂或課程",
+		CodePromoCodeExpired:           "優惠碼不在有效期內",
+		CodePromoCodeExhausted:         "優惠碼已達使用次數上限",
+		CodePromoCodeUserLimit:         "您已達此優惠碼的使用次數上限",
+		CodePromoCodeFirstPurchaseOnly: "此優惠碼只限首次預訂使用",
+		CodePromoCodeMinSpend:          "消費滿 {amount} 才能使用此優惠碼",
+		CodePromoCodeInvalidDiscount:   "百分比折扣需大於 0 且不超過 100，固定折扣需大於 0",
+		CodePromoCodeInvalidScope:      "優惠碼需指定自己的場地或教練檔案，課程類型需屬於該教練",
+		CodePromoCodeInvalidTimeRange:  "有效期及時段的開始需早於結束",
+
+		CodeCancellationPolicyForbidden:    "無權限管理此取消政策",
+		CodeCancellationPolicyInvalidTiers: "退款級距的時數不可重複",
+		CodeCancellationOverrideNotFound:   "取消例外不存在",
+		CodeCancellationOverrideInvalid:    "取消例外的結束時間必須晚於開始時間",
+		CodeCancellationQuoteChanged:       "退款金額已變更為 {refundAmount}，請確認後重新取消",
+
+		CodeReviewNotFound:        "評價不存在",
+		CodeReviewNotOwned:        "評價不存在或無權限操作",
+		CodeReviewNotEditable:     "該評價無法修改",
+		CodeReviewDuplicate:       "您已經評價過該場地",
+		CodeReviewAlreadyReported: "您已經舉報過該評價",
+		CodeReviewOwnHelpful:      "不能對自己的評價標記有用",
+
+		CodeRacketNotFound:         "球拍不存在",
+		CodeRacketDuplicate:        "相同品牌與型號的球拍已存在",
+		CodeRacketPriceNotFound:    "價格記錄不存在",
+		CodeRacketPriceDuplicate:   "該零售商的價格已存在",
+		CodeRacketReviewNotFound:   "球拍評價不存在",
+		CodeRacketReviewNotOwned:   "球拍評價不存在或無權限操作",
+		CodeRacketReviewDuplicate:  "您已經評價過該球拍",
+		CodeRacketReviewOwnHelpful: "不能對自己的評價標記有用",
+
+		CodeChatRoomNotFound:         "聊天室不存在",
+		CodeChatNotParticipant:       "您不是此聊天室的參與者",
+		CodeChatParticipantsRequired: "至少需要一個參與者",
+
+		CodeWebhookNotFound:           "Webhook 訂閱不存在",
+		CodeWebhookDeliveryNotFound:   "投遞記錄不存在",
+		CodeWebhookDisabled:           "Webhook 訂閱已停用，請先重新啟用",
+		CodeWebhookInvalidURL:         "無效的 Webhook 地址",
+		CodeWebhookInvalidScheme:      "Webhook 地址必須使用 http 或 https",
+		CodeWebhookPrivateAddress:     "Webhook 地址不能指向內部網絡",
+		CodeWebhookEventTypesRequired: "至少需要訂閱一種事件類型",
+		CodeWebhookUnsupportedEvent:   "不支援的事件類型: {eventType}",
+	},
+	LocaleEN: {
+		CodeInternal:           "Internal server error, please try again later",
+		CodeValidation:         "Invalid request parameters",
+		CodeUnauthorized:       "Authentication required",
+		CodeInvalidCursor:      "Invalid pagination cursor",
+		CodeUnsupportedSort:    "Unsupported sort field",
+		CodeMissingParam:       "Missing required parameter: {name}",
+		CodePreconditionFailed: "The resource has been modified by someone else, fetch the latest version and retry",
+
+		CodeMissingToken:             "Missing authentication token",
+		CodeInvalidTokenFormat:       "Invalid authentication token format",
+		CodeInvalidToken:             "Invalid or expired authentication token",
+		CodeInvalidCredentials:       "Invalid email or password",
+		CodeAccountDisabled:          "This account has been disabled",
+		CodeInvalidRefreshToken:      "Invalid refresh token",
+		CodeRefreshTokenExpired:      "Refresh token not found or expired",
+		CodeOAuthUnsupportedProvider: "Unsupported OAuth provider",
+		CodeOAuthInvalidState:        "Invalid OAuth state",
+		CodeOAuthExchangeFailed:      "Failed to exchange the OAuth authorization code",
+		CodeOAuthUserInfoFailed:      "Failed to fetch user info from the OAuth provider",
+		CodeOAuthAccountTaken:        "This OAuth account is linked to another user",
+		CodeOAuthAccountLinked:       "This OAuth account is already linked to your account",
+		CodeOAuthProviderLinked:      "You have already linked an account from this provider",
+		CodeOAuthLastLoginMethod:     "Cannot unlink: set a password or link another login method first",
+		CodeOAuthAccountNotFound:     "No linked account found for this provider",
+
+		CodeUserNotFound:         "User not found",
+		CodeUserAlreadyExists:    "A user with this email already exists",
+		CodeUserProfileExists:    "User profile already exists",
+		CodeUserInvalidNTRPLevel: "NTRP level must be between 1.0 and 7.0 in steps of 0.5 (e.g. 1.0, 1.5, 2.0...)",
+
+		CodeCalendarInvalidRange: "'to' must be later than 'from'",
+		CodeCalendarRangeTooLong: "The requested range cannot exceed {days} days",
+		CodeCalendarItemNotFound: "Calendar item not found or not accessible",
+		CodeCalendarFeedNotFound: "Calendar feed not found or revoked",
+
+		CodeCoachNotFound:             "Coach profile not found",
+		CodeCoachProfileExists:        "Coach profile already exists",
+		CodeCoachForbidden:            "You do not have permission to manage this coach profile",
+		CodeCoachInvalidWeekday:       "Invalid weekday: {day}",
+		CodeCoachInvalidTime:          "Invalid time: {value}, expected HH:MM",
+		CodeCoachInvalidTimeSlot:      "Invalid time slot: {value}, expected HH:MM-HH:MM",
+		CodeCoachInvalidTimeRange:     "Start time must be earlier than end time",
+		CodeCoachInvalidDate:          "Invalid date, expected YYYY-MM-DD",
+		CodeCoachCalendarNotFound:     "External calendar not found",
+		CodeCoachCalendarInvalidURL:   "Invalid calendar URL, it must use https, http or webcal",
+		CodeCoachCalendarInvalidFile:  "The file is not a valid iCalendar file",
+		CodeCoachCalendarLimitReached: "You can link at most {max} external calendars",
+
+		CodeLessonStatusReadOnly:          "Lessons cannot be cancelled by editing them; use the cancel endpoint so the cancellation policy and refund apply",
+		CodeLessonNotFound:                "Lesson not found",
+		CodeLessonForbidden:               "You do not have permission to manage this lesson",
+		CodeLessonNotEditable:             "Completed or cancelled lessons cannot be modified",
+		CodeLessonNotCancellable:          "The lesson is already completed or cancelled",
+		CodeLessonCancelForbidden:         "You do not have permission to cancel this lesson",
+		CodeLessonCancelWindowPassed:      "Lessons cannot be cancelled within {hours} hours of the start time",
+		CodeLessonTimeConflict:            "The coach already has another lesson at this time",
+		CodeLessonTypeNotFound:            "Lesson type not found",
+		CodeLessonTypeGroupSizeRequired:   "Group lessons must allow at least 2 participants",
+		CodeLessonTypeInvalidParticipants: "Minimum participants cannot exceed maximum participants",
+		CodeLessonTypeInUse:               "Cannot delete a lesson type that has lessons",
+
+		CodeCoachReviewNotFound:           "Coach review not found",
+		CodeCoachReviewNotOwned:           "Only the author can edit or delete this review",
+		CodeCoachReviewNotEditable:        "Reviews can only be edited or deleted within 24 hours",
+		CodeCoachReviewLessonNotFound:     "Lesson not found or not yours to review",
+		CodeCoachReviewLessonNotCompleted: "Only completed lessons can be reviewed",
+		CodeCoachReviewLessonReviewed:     "You have already reviewed this lesson",
+		CodeCoachReviewDuplicate:          "You have already reviewed this coach",
+		CodeCoachReviewOwnHelpful:         "You cannot mark your own review as helpful",
+
+		CodeSchedulingNoAvailableTime: "No suitable lesson time was found",
+
+		CodeMatchNotFound:             "Match not found",
+		CodeMatchNotParticipant:       "You are not a participant of this match",
+		CodeMatchInvalidType:          "Unsupported match type",
+		CodeMatchCardInvalidAction:    "Unsupported card action",
+		CodeMatchCardSelfAction:       "You cannot perform this action on yourself",
+		CodeMatchNotificationNotFound: "Notification not found",
+
+		CodeMatchHistoryPrivate:         "This user's match history is private",
+		CodeSkillProgressionPrivate:     "This user's skill progression is private",
+		CodeReputationScorePrivate:      "This user's reputation score is private",
+		CodeBehaviorReviewsPrivate:      "This user's behavior reviews are private",
+		CodeMatchResultNotFound:         "Match result not found",
+		CodeMatchResultInvalid:          "The match result has no winner or loser and cannot be confirmed",
+		CodeMatchResultInvalidPlayers:   "The winner and loser must both be match participants",
+		CodeMatchResultForbidden:        "You are not allowed to confirm this result",
+		CodeMatchResultAlreadyConfirmed: "You have already confirmed this result",
+
+		CodeReputationMatchNotScheduled: "The match has no scheduled time",
+		CodeReputationInvalidRating:     "Rating must be between 1.0 and 5.0",
+		CodeReputationSelfReview:        "You cannot review yourself",
+		CodeReputationNotParticipants:   "Both users must be participants of the match",
+		CodeReputationDuplicateReview:   "You have already reviewed this user for this match",
+
+		CodeUploadInvalidForm:     "Invalid multipart form",
+		CodeUploadMissingFile:     "No file was uploaded",
+		CodeUploadUnsupportedType: "Unsupported file type, allowed: {allowed}",
+		CodeUploadTooLarge:        "File is too large, maximum size is {maxMB} MB",
+		CodeUploadInvalidKey:      "Invalid file path",
+		CodeUploadInvalidLink:     "The link is invalid or has expired",
+		CodeUploadNotFound:        "File not found",
+		CodeUploadInvalidImage:    "The image content is invalid or does not match its file type",
+		CodeUploadForbidden:       "You do not have access to this file",
+
+		CodeCourtNotFound:             "Court not found",
+		CodeCourtUnavailable:          "Court not found or unavailable",
+		CodeCourtInvalidHours:         "Invalid operating hours format",
+		CodeCourtInvalidWeekday:       "Invalid day of week: {day}",
+		CodeCourtInvalidTimeRange:     "Invalid time range: {value}, expected HH:MM-HH:MM or closed",
+		CodeCourtInvalidFacility:      "Invalid facility: {facility}",
+		CodeCourtClosed:               "The court is closed on this date",
+		CodeBookingOutsideHours:       "Booking is outside operating hours ({hours})",
+		CodeCourtBlocked:              "The court is unavailable during this time",
+		CodeBookingNotFound:           "Booking not found",
+		CodeBookingModifyForbidden:    "You are not allowed to modify this booking",
+		CodeBookingCancelForbidden:    "You are not allowed to cancel this booking",
+		CodeBookingNotPending:         "Only pending bookings can be rescheduled",
+		CodeBookingAlreadyCancelled:   "Booking is already cancelled",
+		CodeBookingAlreadyCompleted:   "Completed bookings cannot be cancelled",
+		CodeBookingCancelWindowPassed: "Bookings cannot be cancelled within {hours} hours of the start time",
+		CodeBookingInvalidTimeRange:   "End time must be after start time",
+		CodeBookingTooShort:           "Bookings must be at least {minutes} minutes long",
+		CodeBookingTooLong:            "Bookings cannot be longer than {hours} hours",
+		CodeBookingInPast:             "Cannot book a time in the past",
+		CodeBookingTooFarAhead:        "Cannot book more than {days} days ahead",
+		CodeBookingSlotTaken:          "This time slot is already booked",
+		CodeBookingHoldNotFound:       "The slot hold does not exist or has expired",
+		CodeBookingHoldMismatch:       "The booking does not match the held slot",
+		CodeBookingStatusReadOnly:     "Booking status cannot be set directly; bookings are confirmed by payment and cancelled through the cancel endpoint",
+
+		CodeBookingSeriesNotFound:           "Booking series not found",
+		CodeBookingSeriesForbidden:          "Only the booker can modify this booking series",
+		CodeBookingSeriesCancelled:          "The booking series has been cancelled",
+		CodeBookingSeriesInvalidRule:        "Invalid recurrence rule",
+		CodeBookingSeriesEndRequired:        "Specify either an end date or an occurrence count",
+		CodeBookingSeriesTooMany:            "A booking series can have at most {max} occurrences",
+		CodeBookingSeriesTooFarAhead:        "A booking series cannot extend more than {days} days ahead",
+		CodeBookingSeriesConflict:           "{count} occurrences cannot be booked or changed",
+		CodeBookingSeriesOccurrenceNotFound: "The booking is not part of this series",
+
+		CodeWaitlistNotFound:        "Waitlist entry not found",
+		CodeWaitlistForbidden:       "You are not allowed to access this waitlist entry",
+		CodeWaitlistDuplicate:       "You are already on the waitlist for this time",
+		CodeWaitlistSlotAvailable:   "A slot is still available; book it directly",
+		CodeWaitlistInvalidDuration: "Duration cannot exceed the waitlist time range",
+		CodeWaitlistNotOffered:      "No slot is currently offered to this waitlist entry",
+		CodeWaitlistOfferExpired:    "The claim period has expired",
+		CodeWaitlistClosed:          "This waitlist entry has already ended",
+
+		CodeCheckInInvalidPass: "Invalid or outdated check-in pass",
+		CodeCheckInForbidden:   "You are not allowed to check in bookings for this court",
+		CodeCheckInNotAllowed:  "Only confirmed bookings can be checked in",
+		CodeCheckInNotOpen:     "Check-in opens {minutes} minutes before the booking starts",
+		CodeCheckInClosed:      "The booking has ended and can no longer be checked in",
+		CodeCheckInAlreadyDone: "The booking has already been checked in",
+		CodeCheckInDisabled:    "Check-in is not enabled",
+
+		CodeCourtUnitNotFound:    "Court unit not found",
+		CodeCourtUnitForbidden:   "You are not allowed to manage units for this court",
+		CodeCourtUnitDuplicate:   "Court number {number} already exists at this venue",
+		CodeCourtUnitUnavailable: "Court number {number} is not open for booking",
+		CodeCourtUnitHasBookings: "The court unit still has {count} upcoming bookings and cannot be deleted",
+
+		CodeEquipmentNotFound:        "Equipment not found",
+		CodeEquipmentForbidden:       "You are not allowed to manage equipment for this court",
+		CodeEquipmentUnavailable:     "{name} is not available for rent",
+		CodeEquipmentInsufficient:    "Only {available} of {name} are available for this time",
+		CodeEquipmentHasReservations: "The equipment still has {count} upcoming rentals and cannot be deleted",
+		CodeBookingAddOnsLocked:      "Add-ons can only be changed on unpaid single bookings",
+
+		CodeCourtClosureNotFound:         "Court closure not found",
+		CodeCourtClosureForbidden:        "You are not allowed to manage closures for this court",
+		CodeCourtClosureInvalidDateRange: "Dates must be YYYY-MM-DD and the end date cannot be before the start date",
+		CodeCourtClosureInvalidTimeRange: "Times must be HH:MM and the end time must be after the start time",
+		CodeCourtClosureUnitNotAllowed:   "Altered hours cannot target a single court unit",
+
+		CodeCourtAnalyticsForbidden:       "You are not allowed to view analytics for this court",
+		CodeCourtAnalyticsInvalidRange:    "Dates must be YYYY-MM-DD, the end date cannot be before the start date and the range cannot exceed {days} days",
+		CodeCourtAnalyticsInvalidTimezone: "Invalid timezone",
+
+		CodePriceRuleNotFound:         "Price rule not found",
+		CodePriceRuleForbidden:        "You are not allowed to manage price rules for this court",
+		CodePriceRuleInvalidTimeRange: "Invalid time range: {value}, expected HH:MM with the start before the end",
+		CodePriceRuleInvalidDateRange: "Invalid date range: {value}, expected YYYY-MM-DD with the start not after the end",
+		CodePriceRuleClubRequired:     "Member and public price rules must specify a club",
+
+		CodePaymentNotFound:         "Payment not found",
+		CodePaymentTargetNotFound:   "The item to pay for was not found",
+		CodePaymentNotRequired:      "This item does not require payment",
+		CodePaymentAlreadyPaid:      "This item has already been paid",
+		CodePaymentHoldExpired:      "The payment deadline has passed and the hold was released",
+		CodePaymentInvalidState:     "The payment is {status} and cannot be changed this way",
+		CodePaymentProviderError:    "The payment provider is temporarily unavailable, please try again later",
+		CodePaymentInvalidSignature: "Invalid payment notification signature",
+
+		CodeBookingSplitNotFound:        "Payment split not found",
+		CodeBookingSplitForbidden:       "Only the booking owner can manage the payment split",
+		CodeBookingSplitExists:          "The booking already has an open payment split",
+		CodeBookingSplitNotAllowed:      "Only unpaid single bookings can be split",
+		CodeBookingSplitInvalidShares:   "Shares must add up to the booking total {total}",
+		CodeBookingSplitInvalidPayers:   "Co-payers must be other existing users without duplicates",
+		CodeBookingSplitInvalidMatch:    "The match does not exist or you are not a participant",
+		CodeBookingSplitInvalidDeadline: "The deadline must be in the future and no later than the booking start",
+		CodeBookingSplitHasPayments:     "Some shares are already paid, the split cannot be cancelled",
+
+		CodeInvoiceNotFound: "Invoice not found",
+
+		CodePromoCodeNotFound:          "Promo code not found",
+		CodePromoCodeForbidden:         "Only the creator can manage this promo code",
+		CodePromoCodeExists:            "Promo code {code} already exists",
+		CodePromoCodeInvalid:           "The promo code is invalid or inactive",
+		CodePromoCodeNotApplicable:     "The promo code does not apply to this booking or lesson",
+		CodePromoCodeExpired:           "The promo code is not valid at this time",
+		CodePromoCodeExhausted:         "The promo code has reached its redemption limit",
+		CodePromoCodeUserLimit:         "You have reached your redemption limit for this promo code",
+		CodePromoCodeFirstPurchaseOnly: "This promo code is for first-time customers only",
+		CodePromoCodeMinSpend:          "A minimum spend of {amount} is required for this promo code",
+		CodePromoCodeInvalidDiscount:   "Percent discounts must be above 0 and at most 100, fixed discounts must be above 0",
+		CodePromoCodeInvalidScope:      "A promo code must be scoped to your own court or coach profile, and the lesson type must belong to that coach",
+		CodePromoCodeInvalidTimeRange:  "The validity window and time of day must start before they end",
+
+		CodeCancellationPolicyForbidden:    "You do not have permission to manage this cancellation policy",
+		CodeCancellationPolicyInvalidTiers: "Refund tiers must not share the same number of hours",
+		CodeCancellationOverrideNotFound:   "Cancellation override not found",
+		CodeCancellationOverrideInvalid:    "The override end time must be after its start time",
+		CodeCancellationQuoteChanged:       "The refund amount has changed to {refundAmount}, please review and cancel again",
+
+		CodeReviewNotFound:        "Review not found",
+		CodeReviewNotOwned:        "Review not found or not owned by you",
+		CodeReviewNotEditable:     "This review can no longer be edited",
+		CodeReviewDuplicate:       "You have already reviewed this court",
+		CodeReviewAlreadyReported: "You have already reported this review",
+		CodeReviewOwnHelpful:      "You cannot mark your own review as helpful",
+
+		CodeRacketNotFound:         "Racket not found",
+		CodeRacketDuplicate:        "A racket with the same brand and model already exists",
+		CodeRacketPriceNotFound:    "Price not found",
+		CodeRacketPriceDuplicate:   "A price for this retailer already exists",
+		CodeRacketReviewNotFound:   "Racket review not found",
+		CodeRacketReviewNotOwned:   "Racket review not found or not owned by you",
+		CodeRacketReviewDuplicate:  "You have already reviewed this racket",
+		CodeRacketReviewOwnHelpful: "You cannot mark your own review as helpful",
+
+		CodeChatRoomNotFound:         "Chat room not found",
+		CodeChatNotParticipant:       "You are not a participant of this chat room",
+		CodeChatParticipantsRequired: "At least one participant is required",
+
+		CodeWebhookNotFound:           "Webhook subscription not found",
+		CodeWebhookDeliveryNotFound:   "Webhook delivery not found",
+		CodeWebhookDisabled:           "Webhook subscription is disabled, re-enable it first",
+		CodeWebhookInvalidURL:         "Invalid webhook URL",
+		CodeWebhookInvalidScheme:      "Webhook URL must use http or https",
+		CodeWebhookPrivateAddress:     "Webhook URL must not point to a private network",
+		CodeWebhookEventTypesRequired: "At least one event type is required",
+		CodeWebhookUnsupportedEvent:   "Unsupported event type: {eventType}",
+	},
+}
+
+// fieldCatalogs 欄位驗證規則的訊息目錄，未列出的規則使用 "default"
+var fieldCatalogs = map[string]map[string]string{
+	LocaleZhTW: {
+		"required": "此欄位為必填",
+		"email":    "電子郵件格式不正確",
+		"min":      "不能小於 {param}",
+		"max":      "不能大於 {param}",
+		"gte":      "不能小於 {param}",
+		"lte":      "不能大於 {param}",
+		"oneof":    "必須是以下之一: {param}",
+		"url":      "網址格式不正確",
+		"default":  "格式不正確",
+	},
+	LocaleEN: {
+		"required": "This field is required",
+		"email":    "Invalid email address",
+		"min":      "Must be at least {param}",
+		"max":      "Must be at most {param}",
+		"gte":      "Must be at least {param}",
+		"lte":      "Must be at most {param}",
+		"oneof":    "Must be one of: {param}",
+		"url":      "Invalid URL",
+		"default":  "Invalid format",
+	},
+}
+
+// Message 渲染錯誤訊息，找不到時依序回退至默認語系及錯誤碼本身
+func Message(locale string, code Code, params map[string]interface{}) string {
+	template, ok := catalogs[locale][code]
+	if !ok {
+		template, ok = catalogs[DefaultLocale][code]
+	}
+	if !ok {
+		return string(code)
+	}
+	return render(template, params)
+}
+
+// fieldMessage 渲染欄位驗證訊息
+func fieldMessage(locale, rule, param string) string {
+	messages, ok := fieldCatalogs[locale]
+	if !ok {
+		messages = fieldCatalogs[DefaultLocale]
+	}
+	template, ok := messages[rule]
+	if !ok {
+		template = messages["default"]
+	}
+	return render(template, map[string]interface{}{"param": param})
+}
+
+// render 替換訊息中的 {name} 參數
+func render(template string, params map[string]interface{}) string {
+	if len(params) == 0 {
+		return template
+	}
+
+	pairs := make([]string, 0, len(params)*2)
+	for key, value := range params {
+		pairs = append(pairs, "{"+key+"}", fmt.Sprint(value))
+	}
+	return strings.NewReplacer(pairs...).Replace(template)
+}
+
+// NegotiateLocale 依 Accept-Language 選擇支援的語系
+func NegotiateLocale(acceptLanguage string) string {
+	type candidate struct {
+		locale string
+		q      float64
+	}
+
+	var candidates []candidate
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, q := strings.TrimSpace(part), 1.0
+		if i := strings.Index(tag, ";"); i >= 0 {
+			if v, ok := strings.CutPrefix(strings.TrimSpace(tag[i+1:]), "q="); ok {
+				if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+					q = parsed
+				}
+			}
+			tag = strings.TrimSpace(tag[:i])
+		}
+		if locale := matchLocale(tag); locale != "" && q > 0 {
+			candidates = append(candidates, candidate{locale: locale, q: q})
+		}
+	}
+
+	if len(candidates) == 0 {
+		return DefaultLocale
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].q > candidates[j].q
+	})
+	return candidates[0].locale
+}
+
+// matchLocale 將語言標籤對應到支援的語系
+func matchLocale(tag string) string {
+	tag = strings.ToLower(tag)
+	switch {
+	case tag == "zh" || strings.HasPrefix(tag, "zh-"):
+		return LocaleZhTW
+	case tag == "en" || strings.HasPrefix(tag, "en-"):
+		return LocaleEN
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,613 @@
+package apperror
+
+import "net/http"
+
+// Code 穩定的錯誤碼，客戶端可據此判斷錯誤類型，不應依賴訊息文字
+type Code string
+
+// 通用
+const (
+	CodeInternal           Code = "internal_error"
+	CodeValidation         Code = "validation_failed"
+	CodeUnauthorized       Code = "unauthorized"
+	CodeInvalidCursor      Code = "pagination.invalid_cursor"
+	CodeUnsupportedSort    Code = "pagination.unsupported_sort"
+	CodeMissingParam       Code = "request.missing_param"
+	CodePreconditionFailed Code = "request.precondition_failed"
+)
+
+// 認證
+const (
+	CodeMissingToken             Code = "auth.missing_token"
+	CodeInvalidTokenFormat       Code = "auth.invalid_token_format"
+	CodeInvalidToken             Code = "auth.invalid_token"
+	CodeInvalidCredentials       Code = "auth.invalid_credentials"
+	CodeAccountDisabled          Code = "auth.account_disabled"
+	CodeInvalidRefreshToken      Code = "auth.invalid_refresh_token"
+	CodeRefreshTokenExpired      Code = "auth.refresh_token_expired"
+	CodeOAuthUnsupportedProvider Code = "auth.oauth_unsupported_provider"
+	CodeOAuthInvalidState        Code = "auth.oauth_invalid_state"
+	CodeOAuthExchangeFailed      Code = "auth.oauth_exchange_failed"
+	CodeOAuthUserInfoFailed      Code = "auth.oauth_user_info_failed"
+	CodeOAuthAccountTaken        Code = "auth.oauth_account_taken"
+	CodeOAuthAccountLinked       Code = "auth.oauth_account_linked"
+	CodeOAuthProviderLinked      Code = "auth.oauth_provider_linked"
+	CodeOAuthLastLoginMethod     Code = "auth.oauth_last_login_method"
+	CodeOAuthAccountNotFound     Code = "auth.oauth_account_not_found"
+)
+
+// 用戶
+const (
+	CodeUserNotFound         Code = "user.not_found"
+	CodeUserAlreadyExists    Code = "user.already_exists"
+	CodeUserProfileExists    Code = "user.profile_exists"
+	CodeUserInvalidNTRPLevel Code = "user.invalid_ntrp_level"
+)
+
+// 行事曆
+const (
+	CodeCalendarInvalidRange Code = "calendar.invalid_range"
+	CodeCalendarRangeTooLong Code = "calendar.range_too_long"
+	CodeCalendarItemNotFound Code = "calendar.item_not_found"
+	CodeCalendarFeedNotFound Code = "calendar.feed_not_found"
+)
+
+// 教練
+const (
+	CodeCoachNotFound             Code = "coach.not_found"
+	CodeCoachProfileExists        Code = "coach.profile_exists"
+	CodeCoachForbidden            Code = "coach.forbidden"
+	CodeCoachInvalidWeekday       Code = "coach.invalid_weekday"
+	CodeCoachInvalidTime          Code = "coach.invalid_time"
+	CodeCoachInvalidTimeSlot      Code = "coach.invalid_time_slot"
+	CodeCoachInvalidTimeRange     Code = "coach.invalid_time_range"
+	CodeCoachInvalidDate          Code = "coach.invalid_date"
+	CodeCoachCalendarNotFound     Code = "coach_calendar.not_found"
+	CodeCoachCalendarInvalidURL   Code = "coach_calendar.invalid_url"
+	CodeCoachCalendarInvalidFile  Code = "coach_calendar.invalid_file"
+	CodeCoachCalendarLimitReached Code = "coach_calendar.limit_reached"
+)
+
+// 課程
+const (
+	CodeLessonStatusReadOnly          Code = "lesson.status_read_only"
+	CodeLessonNotFound                Code = "lesson.not_found"
+	CodeLessonForbidden               Code = "lesson.forbidden"
+	CodeLessonNotEditable             Code = "lesson.not_editable"
+	CodeLessonNotCancellable          Code = "lesson.not_cancellable"
+	CodeLessonCancelForbidden         Code = "lesson.cancel_forbidden"
+	CodeLessonCancelWindowPassed      Code = "lesson.cancel_window_passed"
+	CodeLessonTimeConflict            Code = "lesson.time_conflict"
+	CodeLessonTypeNotFound            Code = "lesson_type.not_found"
+	CodeLessonTypeGroupSizeRequired   Code = "lesson_type.group_size_required"
+	CodeLessonTypeInvalidParticipants Code = "lesson_type.invalid_participants"
+	CodeLessonTypeInUse               Code = "lesson_type.in_use"
+)
+
+// 教練評價
+const (
+	CodeCoachReviewNotFound           Code = "coach_review.not_found"
+	CodeCoachReviewNotOwned           Code = "coach_review.not_owned"
+	CodeCoachReviewNotEditable        Code = "coach_review.not_editable"
+	CodeCoachReviewLessonNotFound     Code = "coach_review.lesson_not_found"
+	CodeCoachReviewLessonNotCompleted Code = "coach_review.lesson_not_completed"
+	CodeCoachReviewLessonReviewed     Code = "coach_review.lesson_reviewed"
+	CodeCoachReviewDuplicate          Code = "coach_review.duplicate"
+	CodeCoachReviewOwnHelpful         Code = "coach_review.own_helpful"
+)
+
+// 智能排課
+const (
+	CodeSchedulingNoAvailableTime Code = "scheduling.no_available_time"
+)
+
+// 配對
+const (
+	CodeMatchNotFound             Code = "match.not_found"
+	CodeMatchNotParticipant       Code = "match.not_participant"
+	CodeMatchInvalidType          Code = "match.invalid_type"
+	CodeMatchCardInvalidAction    Code = "match_card.invalid_action"
+	CodeMatchCardSelfAction       Code = "match_card.self_action"
+	CodeMatchNotificationNotFound Code = "match_notification.not_found"
+)
+
+// 比賽統計
+const (
+	CodeMatchHistoryPrivate         Code = "match_statistics.history_private"
+	CodeSkillProgressionPrivate     Code = "match_statistics.progression_private"
+	CodeReputationScorePrivate      Code = "match_statistics.reputation_private"
+	CodeBehaviorReviewsPrivate      Code = "match_statistics.reviews_private"
+	CodeMatchResultNotFound         Code = "match_result.not_found"
+	CodeMatchResultInvalid          Code = "match_result.invalid"
+	CodeMatchResultInvalidPlayers   Code = "match_result.invalid_players"
+	CodeMatchResultForbidden        Code = "match_result.forbidden"
+	CodeMatchResultAlreadyConfirmed Code = "match_result.already_confirmed"
+)
+
+// 信譽
+const (
+	CodeReputationMatchNotScheduled Code = "reputation.match_not_scheduled"
+	CodeReputationInvalidRating     Code = "reputation.invalid_rating"
+	CodeReputationSelfReview        Code = "reputation.self_review"
+	CodeReputationNotParticipants   Code = "reputation.not_participants"
+	CodeReputationDuplicateReview   Code = "reputation.duplicate_review"
+)
+
+// 文件上傳
+const (
+	CodeUploadInvalidForm     Code = "upload.invalid_form"
+	CodeUploadMissingFile     Code = "upload.missing_file"
+	CodeUploadUnsupportedType Code = "upload.unsupported_type"
+	CodeUploadTooLarge        Code = "upload.file_too_large"
+	CodeUploadInvalidKey      Code = "upload.invalid_key"
+	CodeUploadInvalidLink     Code = "upload.invalid_link"
+	CodeUploadNotFound        Code = "upload.not_found"
+	CodeUploadInvalidImage    Code = "upload.invalid_image"
+	CodeUploadForbidden       Code = "upload.forbidden"
+)
+
+// 場地
+const (
+	CodeCourtNotFound             Code = "court.not_found"
+	CodeCourtUnavailable          Code = "court.unavailable"
+	CodeCourtInvalidHours         Code = "court.invalid_operating_hours"
+	CodeCourtInvalidWeekday       Code = "court.invalid_weekday"
+	CodeCourtInvalidTimeRange     Code = "court.invalid_time_range"
+	CodeCourtInvalidFacility      Code = "court.invalid_facility"
+	CodeCourtClosed               Code = "court.closed"
+	CodeBookingOutsideHours       Code = "booking.outside_operating_hours"
+	CodeCourtBlocked              Code = "court.blocked"
+	CodeBookingNotFound           Code = "booking.not_found"
+	CodeBookingModifyForbidden    Code = "booking.modify_forbidden"
+	CodeBookingCancelForbidden    Code = "booking.cancel_forbidden"
+	CodeBookingNotPending         Code = "booking.not_pending"
+	CodeBookingAlreadyCancelled   Code = "booking.already_cancelled"
+	CodeBookingAlreadyCompleted   Code = "booking.already_completed"
+	CodeBookingCancelWindowPassed Code = "booking.cancel_window_passed"
+	CodeBookingInvalidTimeRange   Code = "booking.invalid_time_range"
+	CodeBookingTooShort           Code = "booking.duration_too_short"
+	CodeBookingTooLong            Code = "booking.duration_too_long"
+	CodeBookingInPast             Code = "booking.in_past"
+	CodeBookingTooFarAhead        Code = "booking.too_far_ahead"
+	CodeBookingSlotTaken          Code = "booking.slot_taken"
+	CodeBookingHoldNotFound       Code = "booking.hold_not_found"
+	CodeBookingHoldMismatch       Code = "booking.hold_mismatch"
+	CodeBookingStatusReadOnly     Code = "booking.status_read_only"
+)
+
+// 重複預訂
+const (
+	CodeBookingSeriesNotFound           Code = "booking_series.not_found"
+	CodeBookingSeriesForbidden          Code = "booking_series.forbidden"
+	CodeBookingSeriesCancelled          Code = "booking_series.cancelled"
+	CodeBookingSeriesInvalidRule        Code = "booking_series.invalid_recurrence"
+	CodeBookingSeriesEndRequired        Code = "booking_series.end_required"
+	CodeBookingSeriesTooMany            Code = "booking_series.too_many_occurrences"
+	CodeBookingSeriesTooFarAhead        Code = "booking_series.too_far_ahead"
+	CodeBookingSeriesConflict           Code = "booking_series.conflict"
+	CodeBookingSeriesOccurrenceNotFound Code = "booking_series.occurrence_not_found"
+)
+
+// 候補
+const (
+	CodeWaitlistNotFound        Code = "waitlist.not_found"
+	CodeWaitlistForbidden       Code = "waitlist.forbidden"
+	CodeWaitlistDuplicate       Code = "waitlist.duplicate"
+	CodeWaitlistSlotAvailable   Code = "waitlist.slot_available"
+	CodeWaitlistInvalidDuration Code = "waitlist.invalid_duration"
+	CodeWaitlistNotOffered      Code = "waitlist.not_offered"
+	CodeWaitlistOfferExpired    Code = "waitlist.offer_expired"
+	CodeWaitlistClosed          Code = "waitlist.closed"
+)
+
+// 報到
+const (
+	CodeCheckInInvalidPass Code = "check_in.invalid_pass"
+	CodeCheckInForbidden   Code = "check_in.forbidden"
+	CodeCheckInNotAllowed  Code = "check_in.not_allowed"
+	CodeCheckInNotOpen     Code = "check_in.not_open"
+	CodeCheckInClosed      Code = "check_in.closed"
+	CodeCheckInAlreadyDone Code = "check_in.already_done"
+	CodeCheckInDisabled    Code = "check_in.disabled"
+)
+
+// 場地球場
+const (
+	CodeCourtUnitNotFound    Code = "court_unit.not_found"
+	CodeCourtUnitForbidden   Code = "court_unit.forbidden"
+	CodeCourtUnitDuplicate   Code = "court_unit.duplicate_number"
+	CodeCourtUnitUnavailable Code = "court_unit.unavailable"
+	CodeCourtUnitHasBookings Code = "court_unit.has_bookings"
+)
+
+// 租借器材
+const (
+	CodeEquipmentNotFound        Code = "equipment.not_found"
+	CodeEquipmentForbidden       Code = "equipment.forbidden"
+	CodeEquipmentUnavailable     Code = "equipment.unavailable"
+	CodeEquipmentInsufficient    Code = "equipment.insufficient"
+	CodeEquipmentHasReservations Code = "equipment.has_reservations"
+	CodeBookingAddOnsLocked      Code = "booking.add_ons_locked"
+)
+
+// 場地例外
+const (
+	CodeCourtClosureNotFound         Code = "court_closure.not_found"
+	CodeCourtClosureForbidden        Code = "court_closure.forbidden"
+	CodeCourtClosureInvalidDateRange Code = "court_closure.invalid_date_range"
+	CodeCourtClosureInvalidTimeRange Code = "court_closure.invalid_time_range"
+	CodeCourtClosureUnitNotAllowed   Code = "court_closure.unit_not_allowed"
+)
+
+// 場地分析
+const (
+	CodeCourtAnalyticsForbidden       Code = "court_analytics.forbidden"
+	CodeCourtAnalyticsInvalidRange    Code = "court_analytics.invalid_range"
+	CodeCourtAnalyticsInvalidTimezone Code = "court_analytics.invalid_timezone"
+)
+
+// 場地價格規則
+const (
+	CodePriceRuleNotFound         Code = "price_rule.not_found"
+	CodePriceRuleForbidden        Code = "price_rule.forbidden"
+	CodePriceRuleInvalidTimeRange Code = "price_rule.invalid_time_range"
+	CodePriceRuleInvalidDateRange Code = "price_rule.invalid_date_range"
+	CodePriceRuleClubRequired     Code = "price_rule.club_required"
+)
+
+// 付款
+const (
+	CodePaymentNotFound         Code = "payment.not_found"
+	CodePaymentTargetNotFound   Code = "payment.target_not_found"
+	CodePaymentNotRequired      Code = "payment.not_required"
+	CodePaymentAlreadyPaid      Code = "payment.already_paid"
+	CodePaymentHoldExpired      Code = "payment.hold_expired"
+	CodePaymentInvalidState     Code = "payment.invalid_state"
+	CodePaymentProviderError    Code = "payment.provider_error"
+	CodePaymentInvalidSignature Code = "payment.invalid_signature"
+)
+
+// 分攤付款
+const (
+	CodeBookingSplitNotFound        Code = "booking_split.not_found"
+	CodeBookingSplitForbidden       Code = "booking_split.forbidden"
+	CodeBookingSplitExists          Code = "booking_split.exists"
+	CodeBookingSplitNotAllowed      Code = "booking_split.not_allowed"
+	CodeBookingSplitInvalidShares   Code = "booking_split.invalid_shares"
+	CodeBookingSplitInvalidPayers   Code = "booking_split.invalid_payers"
+	CodeBookingSplitInvalidMatch    Code = "booking_split.invalid_match"
+	CodeBookingSplitInvalidDeadline Code = "booking_split.invalid_deadline"
+	CodeBookingSplitHasPayments     Code = "booking_split.has_payments"
+)
+
+// 發票
+const (
+	CodeInvoiceNotFound Code = "invoice.not_found"
+)
+
+// 優惠碼
+const (
+	CodePromoCodeNotFound          Code = "promo_code.not_found"
+	CodePromoCodeForbidden         Code = "promo_code.forbidden"
+	CodePromoCodeExists            Code = "promo_code.exists"
+	CodePromoCodeInvalid           Code = "promo_code.invalid"
+	CodePromoCodeNotApplicable     Code = "promo_code.not_applicable"
+	CodePromoCodeExpired           Code = "promo_code.expired"
+	CodePromoCodeExhausted         Code = "promo_code.exhausted"
+	CodePromoCodeUserLimit         Code = "promo_code.user_limit"
+	CodePromoCodeFirstPurchaseOnly Code = "promo_code.first_purchase_only"
+	CodePromoCodeMinSpend          Code = "promo_code.min_spend"
+	CodePromoCodeInvalidDiscount   Code = "promo_code.invalid_discount"
+	CodePromoCodeInvalidScope      Code = "promo_code.invalid_scope"
+	CodePromoCodeInvalidTimeRange  Code = "promo_code.invalid_time_range"
+)
+
+// 取消政策
+const (
+	CodeCancellationPolicyForbidden    Code = "cancellation_policy.forbidden"
+	CodeCancellationPolicyInvalidTiers Code = "cancellation_policy.invalid_tiers"
+	CodeCancellationOverrideNotFound   Code = "cancellation_override.not_found"
+	CodeCancellationOverrideInvalid    Code = "cancellation_override.invalid_range"
+	CodeCancellationQuoteChanged       Code = "cancellation.quote_changed"
+)
+
+// 場地評價
+const (
+	CodeReviewNotFound        Code = "review.not_found"
+	CodeReviewNotOwned        Code = "review.not_owned"
+	CodeReviewNotEditable     Code = "review.not_editable"
+	CodeReviewDuplicate       Code = "review.duplicate"
+	CodeReviewAlreadyReported Code = "review.already_reported"
+	CodeReviewOwnHelpful      Code = "review.own_helpful"
+)
+
+// 球拍
+const (
+	CodeRacketNotFound         Code = "racket.not_found"
+	CodeRacketDuplicate        Code = "racket.duplicate"
+	CodeRacketPriceNotFound    Code = "racket.price_not_found"
+	CodeRacketPriceDuplicate   Code = "racket.price_duplicate"
+	CodeRacketReviewNotFound   Code = "racket.review_not_found"
+	CodeRacketReviewNotOwned   Code = "racket.review_not_owned"
+	CodeRacketReviewDuplicate  Code = "racket.review_duplicate"
+	CodeRacketReviewOwnHelpful Code = "racket.review_own_helpful"
+)
+
+// 聊天
+const (
+	CodeChatRoomNotFound         Code = "chat.room_not_found"
+	CodeChatNotParticipant       Code = "chat.not_participant"
+	CodeChatParticipantsRequired Code = "chat.participants_required"
+)
+
+// Webhook
+const (
+	CodeWebhookNotFound           Code = "webhook.not_found"
+	CodeWebhookDeliveryNotFound   Code = "webhook.delivery_not_found"
+	CodeWebhookDisabled           Code = "webhook.disabled"
+	CodeWebhookInvalidURL         Code = "webhook.invalid_url"
+	CodeWebhookInvalidScheme      Code = "webhook.invalid_scheme"
+	CodeWebhookPrivateAddress     Code = "webhook.private_address"
+	CodeWebhookEventTypesRequired Code = "webhook.event_types_required"
+	CodeWebhookUnsupportedEvent   Code = "webhook.unsupported_event_type"
+)
+
+// statuses 錯誤碼與 HTTP 狀態碼的對應
+var statuses = map[Code]int{
+	CodeInternal:           http.StatusInternalServerError,
+	CodeValidation:         http.StatusBadRequest,
+	CodeUnauthorized:       http.StatusUnauthorized,
+	CodeInvalidCursor:      http.StatusBadRequest,
+	CodeUnsupportedSort:    http.StatusBadRequest,
+	CodeMissingParam:       http.StatusBadRequest,
+	CodePreconditionFailed: http.StatusPreconditionFailed,
+
+	CodeMissingToken:             http.StatusUnauthorized,
+	CodeInvalidTokenFormat:       http.StatusUnauthorized,
+	CodeInvalidToken:             http.StatusUnauthorized,
+	CodeInvalidCredentials:       http.StatusUnauthorized,
+	CodeAccountDisabled:          http.StatusForbidden,
+	CodeInvalidRefreshToken:      http.StatusUnauthorized,
+	CodeRefreshTokenExpired:      http.StatusUnauthorized,
+	CodeOAuthUnsupportedProvider: http.StatusBadRequest,
+	CodeOAuthInvalidState:        http.StatusBadRequest,
+	CodeOAuthExchangeFailed:      http.StatusBadRequest,
+	CodeOAuthUserInfoFailed:      http.StatusBadGateway,
+	CodeOAuthAccountTaken:        http.StatusConflict,
+	CodeOAuthAccountLinked:       http.StatusConflict,
+	CodeOAuthProviderLinked:      http.StatusConflict,
+	CodeOAuthLastLoginMethod:     http.StatusUnprocessableEntity,
+	CodeOAuthAccountNotFound:     http.StatusNotFound,
+
+	CodeUserNotFound:         http.StatusNotFound,
+	CodeUserAlreadyExists:    http.StatusConflict,
+	CodeUserProfileExists:    http.StatusConflict,
+	CodeUserInvalidNTRPLevel: http.StatusBadRequest,
+
+	CodeCalendarInvalidRange: http.StatusBadRequest,
+	CodeCalendarRangeTooLong: http.StatusBadRequest,
+	CodeCalendarItemNotFound: http.StatusNotFound,
+	CodeCalendarFeedNotFound: http.StatusNotFound,
+
+	CodeCoachNotFound:             http.StatusNotFound,
+	CodeCoachProfileExists:        http.StatusConflict,
+	CodeCoachForbidden:            http.StatusForbidden,
+	CodeCoachInvalidWeekday:       http.StatusBadRequest,
+	CodeCoachInvalidTime:          http.StatusBadRequest,
+	CodeCoachInvalidTimeSlot:      http.StatusBadRequest,
+	CodeCoachInvalidTimeRange:     http.StatusBadRequest,
+	CodeCoachInvalidDate:          http.StatusBadRequest,
+	CodeCoachCalendarNotFound:     http.StatusNotFound,
+	CodeCoachCalendarInvalidURL:   http.StatusBadRequest,
+	CodeCoachCalendarInvalidFile:  http.StatusUnprocessableEntity,
+	CodeCoachCalendarLimitReached: http.StatusConflict,
+
+	CodeLessonStatusReadOnly:          http.StatusBadRequest,
+	CodeLessonNotFound:                http.StatusNotFound,
+	CodeLessonForbidden:               http.StatusForbidden,
+	CodeLessonNotEditable:             http.StatusConflict,
+	CodeLessonNotCancellable:          http.StatusConflict,
+	CodeLessonCancelForbidden:         http.StatusForbidden,
+	CodeLessonCancelWindowPassed:      http.StatusUnprocessableEntity,
+	CodeLessonTimeConflict:            http.StatusConflict,
+	CodeLessonTypeNotFound:            http.StatusNotFound,
+	CodeLessonTypeGroupSizeRequired:   http.StatusBadRequest,
+	CodeLessonTypeInvalidParticipants: http.StatusBadRequest,
+	CodeLessonTypeInUse:               http.StatusConflict,
+
+	CodeCoachReviewNotFound:           http.StatusNotFound,
+	CodeCoachReviewNotOwned:           http.StatusForbidden,
+	CodeCoachReviewNotEditable:        http.StatusUnprocessableEntity,
+	CodeCoachReviewLessonNotFound:     http.StatusNotFound,
+	CodeCoachReviewLessonNotCompleted: http.StatusUnprocessableEntity,
+	CodeCoachReviewLessonReviewed:     http.StatusConflict,
+	CodeCoachReviewDuplicate:          http.StatusConflict,
+	CodeCoachReviewOwnHelpful:         http.StatusUnprocessableEntity,
+
+	CodeSchedulingNoAvailableTime: http.StatusUnprocessableEntity,
+
+	CodeMatchNotFound:             http.StatusNotFound,
+	CodeMatchNotParticipant:       http.StatusForbidden,
+	CodeMatchInvalidType:          http.StatusBadRequest,
+	CodeMatchCardInvalidAction:    http.StatusBadRequest,
+	CodeMatchCardSelfAction:       http.StatusBadRequest,
+	CodeMatchNotificationNotFound: http.StatusNotFound,
+
+	CodeMatchHistoryPrivate:         http.StatusForbidden,
+	CodeSkillProgressionPrivate:     http.StatusForbidden,
+	CodeReputationScorePrivate:      http.StatusForbidden,
+	CodeBehaviorReviewsPrivate:      http.StatusForbidden,
+	CodeMatchResultNotFound:         http.StatusNotFound,
+	CodeMatchResultInvalid:          http.StatusUnprocessableEntity,
+	CodeMatchResultInvalidPlayers:   http.StatusBadRequest,
+	CodeMatchResultForbidden:        http.StatusForbidden,
+	CodeMatchResultAlreadyConfirmed: http.StatusConflict,
+
+	CodeReputationMatchNotScheduled: http.StatusUnprocessableEntity,
+	CodeReputationInvalidRating:     http.StatusBadRequest,
+	CodeReputationSelfReview:        http.StatusUnprocessableEntity,
+	CodeReputationNotParticipants:   http.StatusUnprocessableEntity,
+	CodeReputationDuplicateReview:   http.StatusConflict,
+
+	CodeUploadInvalidForm:     http.StatusBadRequest,
+	CodeUploadMissingFile:     http.StatusBadRequest,
+	CodeUploadUnsupportedType: http.StatusUnsupportedMediaType,
+	CodeUploadTooLarge:        http.StatusRequestEntityTooLarge,
+	CodeUploadInvalidKey:      http.StatusBadRequest,
+	CodeUploadInvalidLink:     http.StatusForbidden,
+	CodeUploadNotFound:        http.StatusNotFound,
+	CodeUploadInvalidImage:    http.StatusUnprocessableEntity,
+	CodeUploadForbidden:       http.StatusForbidden,
+
+	CodeCourtNotFound:             http.StatusNotFound,
+	CodeCourtUnavailable:          http.StatusNotFound,
+	CodeCourtInvalidHours:         http.StatusBadRequest,
+	CodeCourtInvalidWeekday:       http.StatusBadRequest,
+	CodeCourtInvalidTimeRange:     http.StatusBadRequest,
+	CodeCourtInvalidFacility:      http.StatusBadRequest,
+	CodeCourtClosed:               http.StatusUnprocessableEntity,
+	CodeBookingOutsideHours:       http.StatusUnprocessableEntity,
+	CodeCourtBlocked:              http.StatusUnprocessableEntity,
+	CodeBookingNotFound:           http.StatusNotFound,
+	CodeBookingModifyForbidden:    http.StatusForbidden,
+	CodeBookingCancelForbidden:    http.StatusForbidden,
+	CodeBookingNotPending:         http.StatusConflict,
+	CodeBookingAlreadyCancelled:   http.StatusConflict,
+	CodeBookingAlreadyCompleted:   http.StatusConflict,
+	CodeBookingCancelWindowPassed: http.StatusUnprocessableEntity,
+	CodeBookingInvalidTimeRange:   http.StatusBadRequest,
+	CodeBookingTooShort:           http.StatusBadRequest,
+	CodeBookingTooLong:            http.StatusBadRequest,
+	CodeBookingInPast:             http.StatusBadRequest,
+	CodeBookingTooFarAhead:        http.StatusBadRequest,
+	CodeBookingSlotTaken:          http.StatusConflict,
+	CodeBookingHoldNotFound:       http.StatusNotFound,
+	CodeBookingHoldMismatch:       http.StatusUnprocessableEntity,
+	CodeBookingStatusReadOnly:     http.StatusBadRequest,
+
+	CodeBookingSeriesNotFound:           http.StatusNotFound,
+	CodeBookingSeriesForbidden:          http.StatusForbidden,
+	CodeBookingSeriesCancelled:          http.StatusConflict,
+	CodeBookingSeriesInvalidRule:        http.StatusBadRequest,
+	CodeBookingSeriesEndRequired:        http.StatusBadRequest,
+	CodeBookingSeriesTooMany:            http.StatusBadRequest,
+	CodeBookingSeriesTooFarAhead:        http.StatusBadRequest,
+	CodeBookingSeriesConflict:           http.StatusConflict,
+	CodeBookingSeriesOccurrenceNotFound: http.StatusNotFound,
+
+	CodeWaitlistNotFound:        http.StatusNotFound,
+	CodeWaitlistForbidden:       http.StatusForbidden,
+	CodeWaitlistDuplicate:       http.StatusConflict,
+	CodeWaitlistSlotAvailable:   http.StatusConflict,
+	CodeWaitlistInvalidDuration: http.StatusBadRequest,
+	CodeWaitlistNotOffered:      http.StatusConflict,
+	CodeWaitlistOfferExpired:    http.StatusConflict,
+	CodeWaitlistClosed:          http.StatusConflict,
+
+	CodeCheckInInvalidPass: http.StatusBadRequest,
+	CodeCheckInForbidden:   http.StatusForbidden,
+	CodeCheckInNotAllowed:  http.StatusConflict,
+	CodeCheckInNotOpen:     http.StatusConflict,
+	CodeCheckInClosed:      http.StatusConflict,
+	CodeCheckInAlreadyDone: http.StatusConflict,
+	CodeCheckInDisabled:    http.StatusServiceUnavailable,
+
+	CodeCourtUnitNotFound:    http.StatusNotFound,
+	CodeCourtUnitForbidden:   http.StatusForbidden,
+	CodeCourtUnitDuplicate:   http.StatusConflict,
+	CodeCourtUnitUnavailable: http.StatusUnprocessableEntity,
+	CodeCourtUnitHasBookings: http.StatusConflict,
+
+	CodeEquipmentNotFound:        http.StatusNotFound,
+	CodeEquipmentForbidden:       http.StatusForbidden,
+	CodeEquipmentUnavailable:     http.StatusUnprocessableEntity,
+	CodeEquipmentInsufficient:    http.StatusConflict,
+	CodeEquipmentHasReservations: http.StatusConflict,
+	CodeBookingAddOnsLocked:      http.StatusConflict,
+
+	CodeCourtClosureNotFound:         http.StatusNotFound,
+	CodeCourtClosureForbidden:        http.StatusForbidden,
+	CodeCourtClosureInvalidDateRange: http.StatusBadRequest,
+	CodeCourtClosureInvalidTimeRange: http.StatusBadRequest,
+	CodeCourtClosureUnitNotAllowed:   http.StatusBadRequest,
+
+	CodeCourtAnalyticsForbidden:       http.StatusForbidden,
+	CodeCourtAnalyticsInvalidRange:    http.StatusBadRequest,
+	CodeCourtAnalyticsInvalidTimezone: http.StatusBadRequest,
+
+	CodePriceRuleNotFound:         http.StatusNotFound,
+	CodePriceRuleForbidden:        http.StatusForbidden,
+	CodePriceRuleInvalidTimeRange: http.StatusBadRequest,
+	CodePriceRuleInvalidDateRange: http.StatusBadRequest,
+	CodePriceRuleClubRequired:     http.StatusBadRequest,
+
+	CodePaymentNotFound:         http.StatusNotFound,
+	CodePaymentTargetNotFound:   http.StatusNotFound,
+	CodePaymentNotRequired:      http.StatusConflict,
+	CodePaymentAlreadyPaid:      http.StatusConflict,
+	CodePaymentHoldExpired:      http.StatusConflict,
+	CodePaymentInvalidState:     http.StatusConflict,
+	CodePaymentProviderError:    http.StatusBadGateway,
+	CodePaymentInvalidSignature: http.StatusBadRequest,
+
+	CodeBookingSplitNotFound:        http.StatusNotFound,
+	CodeBookingSplitForbidden:       http.StatusForbidden,
+	CodeBookingSplitExists:          http.StatusConflict,
+	CodeBookingSplitNotAllowed:      http.StatusConflict,
+	CodeBookingSplitInvalidShares:   http.StatusBadRequest,
+	CodeBookingSplitInvalidPayers:   http.StatusBadRequest,
+	CodeBookingSplitInvalidMatch:    http.StatusBadRequest,
+	CodeBookingSplitInvalidDeadline: http.StatusBadRequest,
+	CodeBookingSplitHasPayments:     http.StatusConflict,
+
+	CodeInvoiceNotFound: http.StatusNotFound,
+
+	CodePromoCodeNotFound:          http.StatusNotFound,
+	CodePromoCodeForbidden:         http.StatusForbidden,
+	CodePromoCodeExists:            http.StatusConflict,
+	CodePromoCodeInvalid:           http.StatusBadRequest,
+	CodePromoCodeNotApplicable:     http.StatusBadRequest,
+	CodePromoCodeExpired:           http.StatusBadRequest,
+	CodePromoCodeExhausted:         http.StatusConflict,
+	CodePromoCodeUserLimit:         http.StatusConflict,
+	CodePromoCodeFirstPurchaseOnly: http.StatusConflict,
+	CodePromoCodeMinSpend:          http.StatusBadRequest,
+	CodePromoCodeInvalidDiscount:   http.StatusBadRequest,
+	CodePromoCodeInvalidScope:      http.StatusBadRequest,
+	CodePromoCodeInvalidTimeRange:  http.StatusBadRequest,
+
+	CodeCancellationPolicyForbidden:    http.StatusForbidden,
+	CodeCancellationPolicyInvalidTiers: http.StatusBadRequest,
+	CodeCancellationOverrideNotFound:   http.StatusNotFound,
+	CodeCancellationOverrideInvalid:    http.StatusBadRequest,
+	CodeCancellationQuoteChanged:       http.StatusConflict,
+
+	CodeReviewNotFound:        http.StatusNotFound,
+	CodeReviewNotOwned:        http.StatusNotFound,
+	CodeReviewNotEditable:     http.StatusConflict,
+	CodeReviewDuplicate:       http.StatusConflict,
+	CodeReviewAlreadyReported: http.StatusConflict,
+	CodeReviewOwnHelpful:      http.StatusBadRequest,
+
+	CodeRacketNotFound:         http.StatusNotFound,
+	CodeRacketDuplicate:        http.StatusConflict,
+	CodeRacketPriceNotFound:    http.StatusNotFound,
+	CodeRacketPriceDuplicate:   http.StatusConflict,
+	CodeRacketReviewNotFound:   http.StatusNotFound,
+	CodeRacketReviewNotOwned:   http.StatusNotFound,
+	CodeRacketReviewDuplicate:  http.StatusConflict,
+	CodeRacketReviewOwnHelpful: http.StatusBadRequest,
+
+	CodeChatRoomNotFound:         http.StatusNotFound,
+	CodeChatNotParticipant:       http.StatusForbidden,
+	CodeChatParticipantsRequired: http.StatusBadRequest,
+
+	CodeWebhookNotFound:           http.StatusNotFound,
+	CodeWebhookDeliveryNotFound:   http.StatusNotFound,
+	CodeWebhookDisabled:           http.StatusConflict,
+	CodeWebhookInvalidURL:         http.StatusBadRequest,
+	CodeWebhookInvalidScheme:      http.StatusBadRequest,
+	CodeWebhookPrivateAddress:     http.StatusBadRequest,
+	CodeWebhookEventTypesRequired: http.StatusBadRequest,
+	CodeWebhookUnsupportedEvent:   http.StatusBadRequest,
+}
@@ -0,0 +1,145 @@
+package apperror
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// ProblemContentType RFC 7807 回應的內容類型
+const ProblemContentType = "application/problem+json"
+
+// problemTypePrefix 問題類型 URI 前綴，後接錯誤碼
+const problemTypePrefix = "urn:tennis-platform:problem:"
+
+// Problem RFC 7807 問題詳情
+type Problem struct {
+	Type     string       `json:"type"`
+	Title    string       `json:"title"`
+	Status   int          `json:"status"`
+	Detail   string       `json:"detail"`
+	Instance string       `json:"instance,omitempty"`
+	Code     Code         `json:"code"`
+	Errors   []FieldError `json:"errors,omitempty"`
+
+	// Extensions 擴展欄位，序列化時與標準欄位並列
+	Extensions map[string]interface{} `json:"-"`
+}
+
+// problemFields 序列化標準欄位用的別名，避免 MarshalJSON 遞迴
+type problemFields Problem
+
+// MarshalJSON 將擴展欄位與標準欄位合併輸出，擴展欄位不會覆蓋標準欄位
+func (p Problem) MarshalJSON() ([]byte, error) {
+	standard, err := json.Marshal(problemFields(p))
+	if err != nil || len(p.Extensions) == 0 {
+		return standard, err
+	}
+
+	fields := make(map[string]json.RawMessage, len(p.Extensions)+8)
+	for key, value := range p.Extensions {
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		fields[key] = raw
+	}
+	if err := json.Unmarshal(standard, &fields); err != nil {
+		return nil, err
+	}
+	return json.Marshal(fields)
+}
+
+// FieldError 欄位驗證錯誤
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+// Validation 將參數綁定錯誤轉換為領域錯誤，並展開各欄位的驗證結果
+func Validation(err error) *Error {
+	appErr := Wrap(CodeValidation, err)
+
+	var validationErrors validator.ValidationErrors
+	if errors.As(err, &validationErrors) {
+		for _, fe := range validationErrors {
+			appErr.Fields = append(appErr.Fields, FieldError{
+				Field: fe.Field(),
+				Rule:  fe.Tag(),
+				Param: fe.Param(),
+			})
+		}
+	}
+
+	return appErr
+}
+
+// NewProblem 以指定語系生成問題詳情
+func NewProblem(err error, locale, instance string) *Problem {
+	appErr := From(err)
+	status := appErr.Status()
+
+	problem := &Problem{
+		Type:       problemTypePrefix + string(appErr.Code),
+		Title:      http.StatusText(status),
+		Status:     status,
+		Detail:     appErr.Message(locale),
+		Instance:   instance,
+		Code:       appErr.Code,
+		Extensions: appErr.Extensions,
+	}
+
+	for _, fe := range appErr.Fields {
+		fe.Message = fieldMessage(locale, fe.Rule, fe.Param)
+		problem.Errors = append(problem.Errors, fe)
+	}
+
+	return problem
+}
+
+// Write 將錯誤寫為 problem+json 回應，語系依 Accept-Language 決定
+func Write(c *gin.Context, err error) {
+	locale := NegotiateLocale(c.GetHeader("Accept-Language"))
+	problem := NewProblem(err, locale, c.Request.URL.Path)
+
+	if problem.Status >= http.StatusInternalServerError {
+		log.Printf("%s %s: %v", c.Request.Method, c.Request.URL.Path, err)
+	}
+
+	c.Header("Content-Language", locale)
+	c.Header("Content-Type", ProblemContentType+"; charset=utf-8")
+	c.JSON(problem.Status, problem)
+}
+
+// Abort 寫入錯誤回應並中止後續處理，供中間件使用
+func Abort(c *gin.Context, err error) {
+	Write(c, err)
+	c.Abort()
+}
+
+// UseJSONFieldNames 讓驗證錯誤使用 json（或 form）標籤作為欄位名稱
+func UseJSONFieldNames() {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		for _, tag := range []string{"json", "form"} {
+			name := strings.SplitN(field.Tag.Get(tag), ",", 2)[0]
+			if name != "" && name != "-" {
+				return name
+			}
+		}
+		return field.Name
+	})
+}
@@ -13,6 +13,7 @@ type Config struct {
 	Port        string
 	Env         string
 	FrontendURL string
+	PublicURL   string // API 對外網址，用於產生行事曆訂閱等絕對網址
 
 	// 數據庫配置
 	Database DatabaseConfig
@@ -28,6 +29,18 @@ type Config struct {
 
 	// 文件上傳配置
 	Upload UploadConfig
+
+	// 對象存儲配置
+	Storage StorageConfig
+
+	// 付款配置
+	Payment PaymentConfig
+
+	// 報到配置
+	CheckIn CheckInConfig
+
+	// 發票配置
+	Invoice InvoiceConfig
 }
 
 // DatabaseConfig 數據庫配置
@@ -76,6 +89,46 @@ type UploadConfig struct {
 	UploadPath  string // 上傳路徑
 }
 
+// StorageConfig 對象存儲配置
+type StorageConfig struct {
+	Driver        string // local 或 s3
+	SigningSecret string // 本機簽名連結的密鑰
+	S3            S3Config
+}
+
+// S3Config S3 相容存儲配置（AWS S3、MinIO 等）
+type S3Config struct {
+	Endpoint      string // 例如 s3.amazonaws.com 或 minio:9000
+	Region        string
+	Bucket        string
+	AccessKey     string
+	SecretKey     string
+	UseSSL        bool
+	PublicBaseURL string // 公開訪問網址，留空時使用 Endpoint/Bucket
+}
+
+// PaymentConfig 付款配置
+type PaymentConfig struct {
+	Provider             string // 付款服務商，目前支援 local（僅限開發及測試環境）
+	HoldMinutes          int    // 未付款的預訂保留多久（分鐘），0 表示不限時
+	SlotHoldMinutes      int    // 結帳期間保留時段多久（分鐘）
+	WaitlistClaimMinutes int    // 候補者認領釋出時段的期限（分鐘）
+	WebhookSecret        string // 驗證付款通知簽名的密鑰
+}
+
+// CheckInConfig 預訂報到配置
+type CheckInConfig struct {
+	Secret             string // 簽發報到碼的密鑰
+	OpensMinutes       int    // 預訂開始前多久開放報到（分鐘）
+	NoShowGraceMinutes int    // 預訂結束後多久結算出席（分鐘）
+}
+
+// InvoiceConfig 發票及收據配置
+type InvoiceConfig struct {
+	TaxRate  float64 // 稅率（百分比），金額為含稅價
+	FontPath string  // PDF 使用的 TrueType 字型，需支援中文；未設置時以英文及內建字型輸出
+}
+
 // Load 載入配置
 func Load() (*Config, error) {
 	// 載入 .env 文件（如果存在）
@@ -85,6 +138,7 @@ func Load() (*Config, error) {
 		Port:        getEnv("PORT", "8080"),
 		Env:         getEnv("ENV", "development"),
 		FrontendURL: getEnv("FRONTEND_URL", "http://localhost:3000"),
+		PublicURL:   getEnv("PUBLIC_URL", "http://localhost:8080"),
 
 		Database: DatabaseConfig{
 			Host:     getEnv("DB_HOST", "localhost"),
@@ -131,6 +185,39 @@ func Load() (*Config, error) {
 			AllowedExts: getEnv("UPLOAD_ALLOWED_EXTS", "jpg,jpeg,png,gif,pdf"),
 			UploadPath:  getEnv("UPLOAD_PATH", "./uploads"),
 		},
+
+		Storage: StorageConfig{
+			Driver:        getEnv("STORAGE_DRIVER", "local"),
+			SigningSecret: getEnv("STORAGE_SIGNING_SECRET", getEnv("JWT_SECRET", "your-jwt-secret-key")),
+			S3: S3Config{
+				Endpoint:      getEnv("S3_ENDPOINT", ""),
+				Region:        getEnv("S3_REGION", "us-east-1"),
+				Bucket:        getEnv("S3_BUCKET", ""),
+				AccessKey:     getEnv("S3_ACCESS_KEY", ""),
+				SecretKey:     getEnv("S3_SECRET_KEY", ""),
+				UseSSL:        getEnvAsBool("S3_USE_SSL", true),
+				PublicBaseURL: getEnv("S3_PUBLIC_BASE_URL", ""),
+			},
+		},
+
+		Payment: PaymentConfig{
+			Provider:             getEnv("PAYMENT_PROVIDER", "local"),
+			HoldMinutes:          getEnvAsInt("PAYMENT_HOLD_MINUTES", 15),
+			SlotHoldMinutes:      getEnvAsInt("SLOT_HOLD_MINUTES", 10),
+			WaitlistClaimMinutes: getEnvAsInt("WAITLIST_CLAIM_MINUTES", 30),
+			WebhookSecret:        getEnv("PAYMENT_WEBHOOK_SECRET", getEnv("JWT_SECRET", "your-jwt-secret-key")),
+		},
+
+		CheckIn: CheckInConfig{
+			Secret:             getEnv("CHECK_IN_SECRET", getEnv("JWT_SECRET", "your-jwt-secret-key")),
+			OpensMinutes:       getEnvAsInt("CHECK_IN_OPENS_MINUTES", 30),
+			NoShowGraceMinutes: getEnvAsInt("NO_SHOW_GRACE_MINUTES", 15),
+		},
+
+		Invoice: InvoiceConfig{
+			TaxRate:  getEnvAsFloat("INVOICE_TAX_RATE", 5),
+			FontPath: getEnv("INVOICE_FONT_PATH", ""),
+		},
 	}
 
 	return cfg, nil
@@ -163,3 +250,23 @@ func getEnvAsInt64(key string, defaultValue int64) int64 {
 	}
 	return defaultValue
 }
+
+// getEnvAsFloat 獲取環境變量並轉換為浮點數
+func getEnvAsFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatValue
+		}
+	}
+	return defaultValue
+}
+
+// getEnvAsBool 獲取環境變量並轉換為布林值
+func getEnvAsBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"net/http"
+	"tennis-platform/backend/internal/apperror"
 	"tennis-platform/backend/internal/dto"
 	"tennis-platform/backend/internal/usecases"
 
@@ -28,24 +29,19 @@ func NewAuthController(authUsecase *usecases.AuthUsecase) *AuthController {
 // @Produce json
 // @Param request body dto.RegisterRequest true "註冊請求"
 // @Success 201 {object} dto.AuthResponse
-// @Failure 400 {object} map[string]interface{}
-// @Failure 500 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
 // @Router /api/v1/auth/register [post]
 func (ac *AuthController) Register(c *gin.Context) {
 	var req dto.RegisterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "請求參數錯誤",
-			"details": err.Error(),
-		})
+		apperror.Write(c, apperror.Validation(err))
 		return
 	}
 
 	response, err := ac.authUsecase.Register(&req)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
@@ -60,24 +56,19 @@ func (ac *AuthController) Register(c *gin.Context) {
 // @Produce json
 // @Param request body dto.LoginRequest true "登入請求"
 // @Success 200 {object} dto.AuthResponse
-// @Failure 400 {object} map[string]interface{}
-// @Failure 401 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
 // @Router /api/v1/auth/login [post]
 func (ac *AuthController) Login(c *gin.Context) {
 	var req dto.LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "請求參數錯誤",
-			"details": err.Error(),
-		})
+		apperror.Write(c, apperror.Validation(err))
 		return
 	}
 
 	response, err := ac.authUsecase.Login(&req)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
@@ -92,24 +83,19 @@ func (ac *AuthController) Login(c *gin.Context) {
 // @Produce json
 // @Param request body dto.RefreshTokenRequest true "刷新令牌請求"
 // @Success 200 {object} dto.AuthResponse
-// @Failure 400 {object} map[string]interface{}
-// @Failure 401 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
 // @Router /api/v1/auth/refresh [post]
 func (ac *AuthController) RefreshToken(c *gin.Context) {
 	var req dto.RefreshTokenRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "請求參數錯誤",
-			"details": err.Error(),
-		})
+		apperror.Write(c, apperror.Validation(err))
 		return
 	}
 
 	response, err := ac.authUsecase.RefreshToken(&req)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
@@ -124,22 +110,17 @@ func (ac *AuthController) RefreshToken(c *gin.Context) {
 // @Produce json
 // @Param request body dto.RefreshTokenRequest true "登出請求"
 // @Success 200 {object} map[string]interface{}
-// @Failure 400 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
 // @Router /api/v1/auth/logout [post]
 func (ac *AuthController) Logout(c *gin.Context) {
 	var req dto.RefreshTokenRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "請求參數錯誤",
-			"details": err.Error(),
-		})
+		apperror.Write(c, apperror.Validation(err))
 		return
 	}
 
 	if err := ac.authUsecase.Logout(req.RefreshToken); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "登出失敗",
-		})
+		apperror.Write(c, err)
 		return
 	}
 
@@ -156,22 +137,17 @@ func (ac *AuthController) Logout(c *gin.Context) {
 // @Produce json
 // @Param request body dto.ForgotPasswordRequest true "忘記密碼請求"
 // @Success 200 {object} map[string]interface{}
-// @Failure 400 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
 // @Router /api/v1/auth/forgot-password [post]
 func (ac *AuthController) ForgotPassword(c *gin.Context) {
 	var req dto.ForgotPasswordRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "請求參數錯誤",
-			"details": err.Error(),
-		})
+		apperror.Write(c, apperror.Validation(err))
 		return
 	}
 
 	if err := ac.authUsecase.ForgotPassword(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
@@ -188,22 +164,17 @@ func (ac *AuthController) ForgotPassword(c *gin.Context) {
 // @Produce json
 // @Param request body dto.ResetPasswordRequest true "重設密碼請求"
 // @Success 200 {object} map[string]interface{}
-// @Failure 400 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
 // @Router /api/v1/auth/reset-password [post]
 func (ac *AuthController) ResetPassword(c *gin.Context) {
 	var req dto.ResetPasswordRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "請求參數錯誤",
-			"details": err.Error(),
-		})
+		apperror.Write(c, apperror.Validation(err))
 		return
 	}
 
 	if err := ac.authUsecase.ResetPassword(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
@@ -219,16 +190,14 @@ func (ac *AuthController) ResetPassword(c *gin.Context) {
 // @Produce json
 // @Param provider path string true "OAuth 提供商" Enums(google,facebook,apple)
 // @Success 200 {object} map[string]interface{}
-// @Failure 400 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
 // @Router /api/v1/auth/oauth/{provider} [get]
 func (ac *AuthController) GetOAuthAuthURL(c *gin.Context) {
 	provider := c.Param("provider")
 
 	authURL, err := ac.authUsecase.GetOAuthAuthURL(provider)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
@@ -246,17 +215,14 @@ func (ac *AuthController) GetOAuthAuthURL(c *gin.Context) {
 // @Param provider path string true "OAuth 提供商" Enums(google,facebook,apple)
 // @Param request body dto.OAuthLoginRequest true "OAuth 登入請求"
 // @Success 200 {object} dto.AuthResponse
-// @Failure 400 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
 // @Router /api/v1/auth/oauth/{provider}/callback [post]
 func (ac *AuthController) OAuthCallback(c *gin.Context) {
 	provider := c.Param("provider")
 
 	var req dto.OAuthLoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "請求參數錯誤",
-			"details": err.Error(),
-		})
+		apperror.Write(c, apperror.Validation(err))
 		return
 	}
 
@@ -265,9 +231,7 @@ func (ac *AuthController) OAuthCallback(c *gin.Context) {
 
 	response, err := ac.authUsecase.OAuthLogin(&req)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
@@ -284,26 +248,21 @@ func (ac *AuthController) OAuthCallback(c *gin.Context) {
 // @Param provider path string true "OAuth 提供商" Enums(google,facebook,apple)
 // @Param request body dto.LinkOAuthAccountRequest true "關聯 OAuth 帳號請求"
 // @Success 200 {object} map[string]interface{}
-// @Failure 400 {object} map[string]interface{}
-// @Failure 401 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
 // @Router /api/v1/auth/oauth/{provider}/link [post]
 func (ac *AuthController) LinkOAuthAccount(c *gin.Context) {
 	provider := c.Param("provider")
 	userID := c.GetString("userID") // 從中間件獲取用戶ID
 
 	if userID == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "未授權",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
 		return
 	}
 
 	var req dto.LinkOAuthAccountRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "請求參數錯誤",
-			"details": err.Error(),
-		})
+		apperror.Write(c, apperror.Validation(err))
 		return
 	}
 
@@ -311,9 +270,7 @@ func (ac *AuthController) LinkOAuthAccount(c *gin.Context) {
 	req.Provider = provider
 
 	if err := ac.authUsecase.LinkOAuthAccount(userID, &req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
@@ -331,17 +288,15 @@ func (ac *AuthController) LinkOAuthAccount(c *gin.Context) {
 // @Security BearerAuth
 // @Param provider path string true "OAuth 提供商" Enums(google,facebook,apple)
 // @Success 200 {object} map[string]interface{}
-// @Failure 400 {object} map[string]interface{}
-// @Failure 401 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
 // @Router /api/v1/auth/oauth/{provider}/unlink [delete]
 func (ac *AuthController) UnlinkOAuthAccount(c *gin.Context) {
 	provider := c.Param("provider")
 	userID := c.GetString("userID") // 從中間件獲取用戶ID
 
 	if userID == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "未授權",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
 		return
 	}
 
@@ -350,9 +305,7 @@ func (ac *AuthController) UnlinkOAuthAccount(c *gin.Context) {
 	}
 
 	if err := ac.authUsecase.UnlinkOAuthAccount(userID, &req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
@@ -368,23 +321,19 @@ func (ac *AuthController) UnlinkOAuthAccount(c *gin.Context) {
 // @Produce json
 // @Security BearerAuth
 // @Success 200 {object} map[string]interface{}
-// @Failure 401 {object} map[string]interface{}
+// @Failure 401 {object} apperror.Problem
 // @Router /api/v1/auth/oauth/accounts [get]
 func (ac *AuthController) GetLinkedOAuthAccounts(c *gin.Context) {
 	userID := c.GetString("userID") // 從中間件獲取用戶ID
 
 	if userID == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "未授權",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
 		return
 	}
 
 	accounts, err := ac.authUsecase.GetLinkedOAuthAccounts(userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "獲取 OAuth 帳號列表失敗",
-		})
+		apperror.Write(c, err)
 		return
 	}
 
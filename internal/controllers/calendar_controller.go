@@ -0,0 +1,266 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"tennis-platform/backend/internal/apperror"
+	"tennis-platform/backend/internal/dto"
+	"tennis-platform/backend/internal/ical"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CalendarUsecaseInterface 行事曆用例接口
+type CalendarUsecaseInterface interface {
+	GetCalendar(ctx context.Context, userID string, req *dto.CalendarRequest) (*dto.CalendarResponse, error)
+	GetFreeBusy(ctx context.Context, requesterID string, req *dto.FreeBusyRequest) (*dto.FreeBusyResponse, error)
+	GetCalendarFeed(ctx context.Context, userID string) (*dto.CalendarFeedResponse, error)
+	RotateCalendarFeed(ctx context.Context, userID string) (*dto.CalendarFeedResponse, error)
+	DeleteCalendarFeed(ctx context.Context, userID string) error
+	RenderCalendarFeed(ctx context.Context, token string) ([]byte, error)
+	ExportCalendarItem(ctx context.Context, userID, itemType, id string) ([]byte, error)
+}
+
+// CalendarController 行事曆控制器
+type CalendarController struct {
+	calendarUsecase CalendarUsecaseInterface
+}
+
+// NewCalendarController 創建新的行事曆控制器
+func NewCalendarController(calendarUsecase CalendarUsecaseInterface) *CalendarController {
+	return &CalendarController{
+		calendarUsecase: calendarUsecase,
+	}
+}
+
+// GetMyCalendar 獲取我的行事曆
+// @Summary 獲取我的行事曆
+// @Description 合併場地預訂、課程（學生或教練）、比賽及俱樂部活動為單一時間軸，並標記時間重疊的項目
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Param from query string false "開始時間 (RFC3339)，默認為現在"
+// @Param to query string false "結束時間 (RFC3339)，默認為開始後 30 天，最長 92 天"
+// @Param type query []string false "項目類型" Enums(booking, lesson, match, club_event)
+// @Param includeCancelled query bool false "是否包含已取消的項目"
+// @Success 200 {object} dto.CalendarResponse
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Router /api/v1/users/me/calendar [get]
+func (cc *CalendarController) GetMyCalendar(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
+		return
+	}
+
+	var req dto.CalendarRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		apperror.Write(c, apperror.Validation(err))
+		return
+	}
+
+	calendar, err := cc.calendarUsecase.GetCalendar(c.Request.Context(), userID.(string), &req)
+	if err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, calendar)
+}
+
+// GetFreeBusy 查詢用戶的空閒/忙碌時段
+// @Summary 查詢用戶的空閒/忙碌時段
+// @Description 返回一位或多位用戶合併後的忙碌時段，不包含項目內容，可用於安排比賽或課程；未公開檔案的用戶不提供忙碌時段
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Param userId query []string true "用戶ID，最多 10 個"
+// @Param from query string false "開始時間 (RFC3339)，默認為現在"
+// @Param to query string false "結束時間 (RFC3339)，默認為開始後 30 天，最長 92 天"
+// @Success 200 {object} dto.FreeBusyResponse
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Router /api/v1/users/free-busy [get]
+func (cc *CalendarController) GetFreeBusy(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
+		return
+	}
+
+	var req dto.FreeBusyRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		apperror.Write(c, apperror.Validation(err))
+		return
+	}
+
+	freeBusy, err := cc.calendarUsecase.GetFreeBusy(c.Request.Context(), userID.(string), &req)
+	if err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, freeBusy)
+}
+
+// GetCalendarFeed 獲取行事曆訂閱網址
+// @Summary 獲取行事曆訂閱網址
+// @Description 返回可在 Google、Apple 等行事曆訂閱的 iCalendar 網址，尚未建立時自動建立；網址含有密鑰，持有者不需登入即可讀取行程
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} dto.CalendarFeedResponse
+// @Failure 401 {object} apperror.Problem
+// @Router /api/v1/users/me/calendar/feed [get]
+func (cc *CalendarController) GetCalendarFeed(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
+		return
+	}
+
+	feed, err := cc.calendarUsecase.GetCalendarFeed(c.Request.Context(), userID.(string))
+	if err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, feed)
+}
+
+// RotateCalendarFeed 重新產生行事曆訂閱網址
+// @Summary 重新產生行事曆訂閱網址
+// @Description 產生新的訂閱網址，舊網址立即失效，適用於網址外洩時
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} dto.CalendarFeedResponse
+// @Failure 401 {object} apperror.Problem
+// @Router /api/v1/users/me/calendar/feed/rotate [post]
+func (cc *CalendarController) RotateCalendarFeed(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
+		return
+	}
+
+	feed, err := cc.calendarUsecase.RotateCalendarFeed(c.Request.Context(), userID.(string))
+	if err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, feed)
+}
+
+// DeleteCalendarFeed 停用行事曆訂閱
+// @Summary 停用行事曆訂閱
+// @Description 刪除訂閱網址，已訂閱的行事曆將無法再更新
+// @Tags users
+// @Security BearerAuth
+// @Success 204
+// @Failure 401 {object} apperror.Problem
+// @Router /api/v1/users/me/calendar/feed [delete]
+func (cc *CalendarController) DeleteCalendarFeed(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
+		return
+	}
+
+	if err := cc.calendarUsecase.DeleteCalendarFeed(c.Request.Context(), userID.(string)); err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetFeed 讀取行事曆訂閱內容
+// @Summary 讀取行事曆訂閱內容
+// @Description 以訂閱網址中的密鑰返回 iCalendar 格式的行程，供行事曆應用程式定期更新
+// @Tags calendar
+// @Produce text/calendar
+// @Param token path string true "訂閱密鑰，可帶 .ics 副檔名"
+// @Success 200 {string} string "iCalendar 內容"
+// @Failure 404 {object} apperror.Problem
+// @Router /api/v1/calendar/feeds/{token} [get]
+func (cc *CalendarController) GetFeed(c *gin.Context) {
+	token := strings.TrimSuffix(c.Param("token"), ".ics")
+
+	data, err := cc.calendarUsecase.RenderCalendarFeed(c.Request.Context(), token)
+	if err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.Header("Cache-Control", "private, max-age=300")
+	c.Data(http.StatusOK, ical.ContentType, data)
+}
+
+// ExportBooking 匯出預訂的 .ics
+// @Summary 匯出預訂的 .ics
+// @Description 下載單一預訂的 iCalendar 文件，可匯入 Google、Apple 等行事曆
+// @Tags bookings
+// @Produce text/calendar
+// @Security BearerAuth
+// @Param id path string true "預訂ID"
+// @Success 200 {string} string "iCalendar 內容"
+// @Failure 401 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Router /api/v1/bookings/{id}/ics [get]
+func (cc *CalendarController) ExportBooking(c *gin.Context) {
+	cc.exportItem(c, dto.CalendarItemBooking)
+}
+
+// ExportLesson 匯出課程的 .ics
+// @Summary 匯出課程的 .ics
+// @Description 下載單一課程的 iCalendar 文件，學生及教練皆可匯出
+// @Tags lessons
+// @Produce text/calendar
+// @Security BearerAuth
+// @Param id path string true "課程ID"
+// @Success 200 {string} string "iCalendar 內容"
+// @Failure 401 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Router /api/v1/lessons/{id}/ics [get]
+func (cc *CalendarController) ExportLesson(c *gin.Context) {
+	cc.exportItem(c, dto.CalendarItemLesson)
+}
+
+// ExportMatch 匯出比賽的 .ics
+// @Summary 匯出比賽的 .ics
+// @Description 下載單一比賽的 iCalendar 文件，只有參與者可以匯出，未排定時間的比賽無法匯出
+// @Tags matches
+// @Produce text/calendar
+// @Security BearerAuth
+// @Param id path string true "比賽ID"
+// @Success 200 {string} string "iCalendar 內容"
+// @Failure 401 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Router /api/v1/matches/{id}/ics [get]
+func (cc *CalendarController) ExportMatch(c *gin.Context) {
+	cc.exportItem(c, dto.CalendarItemMatch)
+}
+
+func (cc *CalendarController) exportItem(c *gin.Context, itemType string) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
+		return
+	}
+
+	id := c.Param("id")
+	data, err := cc.calendarUsecase.ExportCalendarItem(c.Request.Context(), userID.(string), itemType, id)
+	if err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-%s.ics"`, itemType, id))
+	c.Data(http.StatusOK, ical.ContentType, data)
+}
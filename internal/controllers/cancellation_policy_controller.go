@@ -0,0 +1,381 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"tennis-platform/backend/internal/apperror"
+	"tennis-platform/backend/internal/dto"
+	"tennis-platform/backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CancellationPolicyUsecaseInterface 取消政策用例接口
+type CancellationPolicyUsecaseInterface interface {
+	GetCourtPolicy(ctx context.Context, courtID string) (*models.CancellationPolicy, error)
+	GetCoachPolicy(ctx context.Context, coachID string) (*models.CancellationPolicy, error)
+	UpdateCourtPolicy(ctx context.Context, userID, courtID string, req *dto.UpdateCancellationPolicyRequest) (*models.CancellationPolicy, error)
+	UpdateMyCoachPolicy(ctx context.Context, userID string, req *dto.UpdateCancellationPolicyRequest) (*models.CancellationPolicy, error)
+	DeleteCourtPolicy(ctx context.Context, userID, courtID string) (*models.CancellationPolicy, error)
+	DeleteMyCoachPolicy(ctx context.Context, userID string) (*models.CancellationPolicy, error)
+	GetCourtOverrides(ctx context.Context, userID, courtID string) ([]models.CancellationOverride, error)
+	GetMyCoachOverrides(ctx context.Context, userID string) ([]models.CancellationOverride, error)
+	CreateCourtOverride(ctx context.Context, userID, courtID string, req *dto.CreateCancellationOverrideRequest) (*models.CancellationOverride, error)
+	CreateMyCoachOverride(ctx context.Context, userID string, req *dto.CreateCancellationOverrideRequest) (*models.CancellationOverride, error)
+	DeleteCourtOverride(ctx context.Context, userID, courtID, overrideID string) error
+	DeleteMyCoachOverride(ctx context.Context, userID, overrideID string) error
+}
+
+// CancellationPolicyController 取消政策控制器
+type CancellationPolicyController struct {
+	cancellationPolicyUsecase CancellationPolicyUsecaseInterface
+}
+
+// NewCancellationPolicyController 創建新的取消政策控制器
+func NewCancellationPolicyController(cancellationPolicyUsecase CancellationPolicyUsecaseInterface) *CancellationPolicyController {
+	return &CancellationPolicyController{
+		cancellationPolicyUsecase: cancellationPolicyUsecase,
+	}
+}
+
+// GetCourtPolicy 獲取場地的取消政策
+// @Summary 獲取場地的取消政策
+// @Description 返回場地的退款級距及取消期限，未設置時返回默認政策（開始前 2 小時以前取消全額退款）
+// @Tags courts
+// @Produce json
+// @Param id path string true "場地ID"
+// @Success 200 {object} models.CancellationPolicy
+// @Failure 404 {object} apperror.Problem
+// @Router /api/v1/courts/{id}/cancellation-policy [get]
+func (cc *CancellationPolicyController) GetCourtPolicy(c *gin.Context) {
+	policy, err := cc.cancellationPolicyUsecase.GetCourtPolicy(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+// UpdateCourtPolicy 設置場地的取消政策
+// @Summary 設置場地的取消政策
+// @Description 場地擁有者設置退款級距及取消期限，只影響之後的取消
+// @Tags courts
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "場地ID"
+// @Param request body dto.UpdateCancellationPolicyRequest true "取消政策"
+// @Success 200 {object} models.CancellationPolicy
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Router /api/v1/courts/{id}/cancellation-policy [put]
+func (cc *CancellationPolicyController) UpdateCourtPolicy(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
+		return
+	}
+
+	var req dto.UpdateCancellationPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperror.Write(c, apperror.Validation(err))
+		return
+	}
+
+	policy, err := cc.cancellationPolicyUsecase.UpdateCourtPolicy(c.Request.Context(), userID.(string), c.Param("id"), &req)
+	if err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+// DeleteCourtPolicy 刪除場地的取消政策
+// @Summary 刪除場地的取消政策
+// @Description 刪除場地自訂的取消政策，恢復使用默認政策並返回之
+// @Tags courts
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "場地ID"
+// @Success 200 {object} models.CancellationPolicy
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Router /api/v1/courts/{id}/cancellation-policy [delete]
+func (cc *CancellationPolicyController) DeleteCourtPolicy(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
+		return
+	}
+
+	policy, err := cc.cancellationPolicyUsecase.DeleteCourtPolicy(c.Request.Context(), userID.(string), c.Param("id"))
+	if err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+// GetCourtOverrides 獲取場地的取消例外
+// @Summary 獲取場地的取消例外
+// @Description 場地擁有者查看天候及不可抗力例外，按開始時間倒序
+// @Tags courts
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "場地ID"
+// @Success 200 {array} models.CancellationOverride
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Router /api/v1/courts/{id}/cancellation-overrides [get]
+func (cc *CancellationPolicyController) GetCourtOverrides(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
+		return
+	}
+
+	overrides, err := cc.cancellationPolicyUsecase.GetCourtOverrides(c.Request.Context(), userID.(string), c.Param("id"))
+	if err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, overrides)
+}
+
+// CreateCourtOverride 創建場地的取消例外
+// @Summary 創建場地的取消例外
+// @Description 因天候或不可抗力，與時段重疊的預訂可不受取消期限限制取消，並依指定比例（默認全額）退款
+// @Tags courts
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "場地ID"
+// @Param request body dto.CreateCancellationOverrideRequest true "取消例外"
+// @Success 201 {object} models.CancellationOverride
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Router /api/v1/courts/{id}/cancellation-overrides [post]
+func (cc *CancellationPolicyController) CreateCourtOverride(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
+		return
+	}
+
+	var req dto.CreateCancellationOverrideRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperror.Write(c, apperror.Validation(err))
+		return
+	}
+
+	override, err := cc.cancellationPolicyUsecase.CreateCourtOverride(c.Request.Context(), userID.(string), c.Param("id"), &req)
+	if err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, override)
+}
+
+// DeleteCourtOverride 刪除場地的取消例外
+// @Summary 刪除場地的取消例外
+// @Description 刪除後不再適用於之後的取消，已按例外取消的記錄不受影響
+// @Tags courts
+// @Security BearerAuth
+// @Param id path string true "場地ID"
+// @Param overrideId path string true "取消例外ID"
+// @Success 204
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Router /api/v1/courts/{id}/cancellation-overrides/{overrideId} [delete]
+func (cc *CancellationPolicyController) DeleteCourtOverride(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
+		return
+	}
+
+	if err := cc.cancellationPolicyUsecase.DeleteCourtOverride(c.Request.Context(), userID.(string), c.Param("id"), c.Param("overrideId")); err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetCoachPolicy 獲取教練的取消政策
+// @Summary 獲取教練的取消政策
+// @Description 返回教練的退款級距及取消期限，未設置時返回默認政策（開始前取消全額退款）
+// @Tags coaches
+// @Produce json
+// @Param id path string true "教練ID"
+// @Success 200 {object} models.CancellationPolicy
+// @Failure 404 {object} apperror.Problem
+// @Router /api/v1/coaches/{id}/cancellation-policy [get]
+func (cc *CancellationPolicyController) GetCoachPolicy(c *gin.Context) {
+	policy, err := cc.cancellationPolicyUsecase.GetCoachPolicy(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+// UpdateMyCoachPolicy 設置我的取消政策
+// @Summary 設置我的取消政策
+// @Description 教練設置學生取消課程時的退款級距及取消期限，只影響之後的取消
+// @Tags coaches
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.UpdateCancellationPolicyRequest true "取消政策"
+// @Success 200 {object} models.CancellationPolicy
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Router /api/v1/coaches/my-cancellation-policy [put]
+func (cc *CancellationPolicyController) UpdateMyCoachPolicy(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
+		return
+	}
+
+	var req dto.UpdateCancellationPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperror.Write(c, apperror.Validation(err))
+		return
+	}
+
+	policy, err := cc.cancellationPolicyUsecase.UpdateMyCoachPolicy(c.Request.Context(), userID.(string), &req)
+	if err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+// DeleteMyCoachPolicy 刪除我的取消政策
+// @Summary 刪除我的取消政策
+// @Description 刪除教練自訂的取消政策，恢復使用默認政策並返回之
+// @Tags coaches
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} models.CancellationPolicy
+// @Failure 401 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Router /api/v1/coaches/my-cancellation-policy [delete]
+func (cc *CancellationPolicyController) DeleteMyCoachPolicy(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
+		return
+	}
+
+	policy, err := cc.cancellationPolicyUsecase.DeleteMyCoachPolicy(c.Request.Context(), userID.(string))
+	if err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+// GetMyCoachOverrides 獲取我的取消例外
+// @Summary 獲取我的取消例外
+// @Description 教練查看天候及不可抗力例外，按開始時間倒序
+// @Tags coaches
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.CancellationOverride
+// @Failure 401 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Router /api/v1/coaches/my-cancellation-overrides [get]
+func (cc *CancellationPolicyController) GetMyCoachOverrides(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
+		return
+	}
+
+	overrides, err := cc.cancellationPolicyUsecase.GetMyCoachOverrides(c.Request.Context(), userID.(string))
+	if err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, overrides)
+}
+
+// CreateMyCoachOverride 創建我的取消例外
+// @Summary 創建我的取消例外
+// @Description 因天候或不可抗力，與時段重疊的課程可由學生不受取消期限限制取消，並依指定比例（默認全額）退款
+// @Tags coaches
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.CreateCancellationOverrideRequest true "取消例外"
+// @Success 201 {object} models.CancellationOverride
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Router /api/v1/coaches/my-cancellation-overrides [post]
+func (cc *CancellationPolicyController) CreateMyCoachOverride(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
+		return
+	}
+
+	var req dto.CreateCancellationOverrideRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperror.Write(c, apperror.Validation(err))
+		return
+	}
+
+	override, err := cc.cancellationPolicyUsecase.CreateMyCoachOverride(c.Request.Context(), userID.(string), &req)
+	if err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, override)
+}
+
+// DeleteMyCoachOverride 刪除我的取消例外
+// @Summary 刪除我的取消例外
+// @Description 刪除後不再適用於之後的取消，已按例外取消的記錄不受影響
+// @Tags coaches
+// @Security BearerAuth
+// @Param overrideId path string true "取消例外ID"
+// @Success 204
+// @Failure 401 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Router /api/v1/coaches/my-cancellation-overrides/{overrideId} [delete]
+func (cc *CancellationPolicyController) DeleteMyCoachOverride(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
+		return
+	}
+
+	if err := cc.cancellationPolicyUsecase.DeleteMyCoachOverride(c.Request.Context(), userID.(string), c.Param("overrideId")); err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
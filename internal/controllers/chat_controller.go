@@ -3,6 +3,7 @@ package controllers
 import (
 	"net/http"
 	"strconv"
+	"tennis-platform/backend/internal/apperror"
 	"tennis-platform/backend/internal/dto"
 	"tennis-platform/backend/internal/services"
 	"tennis-platform/backend/internal/usecases"
@@ -44,26 +45,26 @@ func (cc *ChatController) HandleWebSocket(c *gin.Context) {
 // @Security BearerAuth
 // @Param request body dto.CreateChatRoomRequest true "創建聊天室請求"
 // @Success 201 {object} dto.ChatRoomResponse
-// @Failure 400 {object} map[string]interface{}
-// @Failure 401 {object} map[string]interface{}
-// @Failure 500 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
 // @Router /api/v1/chat/rooms [post]
 func (cc *ChatController) CreateChatRoom(c *gin.Context) {
 	userID := c.GetString("userID")
 	if userID == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授權"})
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
 		return
 	}
 
 	var req dto.CreateChatRoomRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "請求格式錯誤", "details": err.Error()})
+		apperror.Write(c, apperror.Validation(err))
 		return
 	}
 
 	chatRoom, err := cc.chatUsecase.CreateChatRoom(userID, &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "創建聊天室失敗", "details": err.Error()})
+		apperror.Write(c, err)
 		return
 	}
 
@@ -78,19 +79,19 @@ func (cc *ChatController) CreateChatRoom(c *gin.Context) {
 // @Produce json
 // @Security BearerAuth
 // @Success 200 {array} dto.ChatRoomResponse
-// @Failure 401 {object} map[string]interface{}
-// @Failure 500 {object} map[string]interface{}
+// @Failure 401 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
 // @Router /api/v1/chat/rooms [get]
 func (cc *ChatController) GetChatRooms(c *gin.Context) {
 	userID := c.GetString("userID")
 	if userID == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授權"})
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
 		return
 	}
 
 	chatRooms, err := cc.chatUsecase.GetChatRooms(userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "獲取聊天室列表失敗", "details": err.Error()})
+		apperror.Write(c, err)
 		return
 	}
 
@@ -106,31 +107,27 @@ func (cc *ChatController) GetChatRooms(c *gin.Context) {
 // @Security BearerAuth
 // @Param roomId path string true "聊天室ID"
 // @Success 200 {object} dto.ChatRoomResponse
-// @Failure 400 {object} map[string]interface{}
-// @Failure 401 {object} map[string]interface{}
-// @Failure 404 {object} map[string]interface{}
-// @Failure 500 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
 // @Router /api/v1/chat/rooms/{roomId} [get]
 func (cc *ChatController) GetChatRoom(c *gin.Context) {
 	userID := c.GetString("userID")
 	if userID == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授權"})
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
 		return
 	}
 
 	roomID := c.Param("roomId")
 	if roomID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "聊天室ID不能為空"})
+		apperror.Write(c, apperror.New(apperror.CodeMissingParam).With("name", "roomId"))
 		return
 	}
 
 	chatRoom, err := cc.chatUsecase.GetChatRoom(roomID, userID)
 	if err != nil {
-		if err.Error() == "您不是此聊天室的參與者" || err.Error() == "聊天室不存在" {
-			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "獲取聊天室失敗", "details": err.Error()})
+		apperror.Write(c, err)
 		return
 	}
 
@@ -146,31 +143,27 @@ func (cc *ChatController) GetChatRoom(c *gin.Context) {
 // @Security BearerAuth
 // @Param request body dto.SendMessageRequest true "發送訊息請求"
 // @Success 201 {object} dto.ChatMessageResponse
-// @Failure 400 {object} map[string]interface{}
-// @Failure 401 {object} map[string]interface{}
-// @Failure 403 {object} map[string]interface{}
-// @Failure 500 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
 // @Router /api/v1/chat/messages [post]
 func (cc *ChatController) SendMessage(c *gin.Context) {
 	userID := c.GetString("userID")
 	if userID == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授權"})
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
 		return
 	}
 
 	var req dto.SendMessageRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "請求格式錯誤", "details": err.Error()})
+		apperror.Write(c, apperror.Validation(err))
 		return
 	}
 
 	message, err := cc.chatUsecase.SendMessage(userID, &req)
 	if err != nil {
-		if err.Error() == "您不是此聊天室的參與者" {
-			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "發送訊息失敗", "details": err.Error()})
+		apperror.Write(c, err)
 		return
 	}
 
@@ -200,25 +193,26 @@ func (cc *ChatController) SendMessage(c *gin.Context) {
 // @Produce json
 // @Security BearerAuth
 // @Param roomId path string true "聊天室ID"
+// @Param cursor query string false "分頁游標，提供時忽略 page"
 // @Param page query int false "頁碼" default(1)
 // @Param limit query int false "每頁數量" default(50)
 // @Param before query string false "獲取此時間之前的訊息 (RFC3339格式)"
-// @Success 200 {array} dto.ChatMessageResponse
-// @Failure 400 {object} map[string]interface{}
-// @Failure 401 {object} map[string]interface{}
-// @Failure 403 {object} map[string]interface{}
-// @Failure 500 {object} map[string]interface{}
+// @Success 200 {object} dto.ChatMessageListResponse
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
 // @Router /api/v1/chat/rooms/{roomId}/messages [get]
 func (cc *ChatController) GetMessages(c *gin.Context) {
 	userID := c.GetString("userID")
 	if userID == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授權"})
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
 		return
 	}
 
 	roomID := c.Param("roomId")
 	if roomID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "聊天室ID不能為空"})
+		apperror.Write(c, apperror.New(apperror.CodeMissingParam).With("name", "roomId"))
 		return
 	}
 
@@ -235,6 +229,7 @@ func (cc *ChatController) GetMessages(c *gin.Context) {
 
 	req := dto.GetMessagesRequest{
 		ChatRoomID: roomID,
+		Cursor:     c.Query("cursor"),
 		Page:       page,
 		Limit:      limit,
 		Before:     beforeTime,
@@ -242,11 +237,7 @@ func (cc *ChatController) GetMessages(c *gin.Context) {
 
 	messages, err := cc.chatUsecase.GetMessages(userID, &req)
 	if err != nil {
-		if err.Error() == "您不是此聊天室的參與者" {
-			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "獲取訊息失敗", "details": err.Error()})
+		apperror.Write(c, err)
 		return
 	}
 
@@ -262,31 +253,27 @@ func (cc *ChatController) GetMessages(c *gin.Context) {
 // @Security BearerAuth
 // @Param roomId path string true "聊天室ID"
 // @Success 200 {object} map[string]interface{}
-// @Failure 400 {object} map[string]interface{}
-// @Failure 401 {object} map[string]interface{}
-// @Failure 403 {object} map[string]interface{}
-// @Failure 500 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
 // @Router /api/v1/chat/rooms/{roomId}/read [post]
 func (cc *ChatController) MarkMessagesAsRead(c *gin.Context) {
 	userID := c.GetString("userID")
 	if userID == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授權"})
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
 		return
 	}
 
 	roomID := c.Param("roomId")
 	if roomID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "聊天室ID不能為空"})
+		apperror.Write(c, apperror.New(apperror.CodeMissingParam).With("name", "roomId"))
 		return
 	}
 
 	err := cc.chatUsecase.MarkMessagesAsRead(userID, roomID)
 	if err != nil {
-		if err.Error() == "您不是此聊天室的參與者" {
-			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "標記已讀失敗", "details": err.Error()})
+		apperror.Write(c, err)
 		return
 	}
 
@@ -314,31 +301,27 @@ func (cc *ChatController) MarkMessagesAsRead(c *gin.Context) {
 // @Security BearerAuth
 // @Param roomId path string true "聊天室ID"
 // @Success 200 {object} map[string]interface{}
-// @Failure 400 {object} map[string]interface{}
-// @Failure 401 {object} map[string]interface{}
-// @Failure 403 {object} map[string]interface{}
-// @Failure 500 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
 // @Router /api/v1/chat/rooms/{roomId}/leave [post]
 func (cc *ChatController) LeaveChatRoom(c *gin.Context) {
 	userID := c.GetString("userID")
 	if userID == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授權"})
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
 		return
 	}
 
 	roomID := c.Param("roomId")
 	if roomID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "聊天室ID不能為空"})
+		apperror.Write(c, apperror.New(apperror.CodeMissingParam).With("name", "roomId"))
 		return
 	}
 
 	err := cc.chatUsecase.LeaveChatRoom(userID, roomID)
 	if err != nil {
-		if err.Error() == "您不是此聊天室的參與者" {
-			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "離開聊天室失敗", "details": err.Error()})
+		apperror.Write(c, err)
 		return
 	}
 
@@ -369,32 +352,28 @@ func (cc *ChatController) LeaveChatRoom(c *gin.Context) {
 // @Security BearerAuth
 // @Param roomId path string true "聊天室ID"
 // @Success 200 {object} map[string]interface{}
-// @Failure 400 {object} map[string]interface{}
-// @Failure 401 {object} map[string]interface{}
-// @Failure 403 {object} map[string]interface{}
-// @Failure 500 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
 // @Router /api/v1/chat/rooms/{roomId}/join [post]
 func (cc *ChatController) JoinChatRoom(c *gin.Context) {
 	userID := c.GetString("userID")
 	if userID == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授權"})
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
 		return
 	}
 
 	roomID := c.Param("roomId")
 	if roomID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "聊天室ID不能為空"})
+		apperror.Write(c, apperror.New(apperror.CodeMissingParam).With("name", "roomId"))
 		return
 	}
 
 	// 檢查用戶是否有權限加入聊天室
 	_, err := cc.chatUsecase.GetChatRoom(roomID, userID)
 	if err != nil {
-		if err.Error() == "您不是此聊天室的參與者" || err.Error() == "聊天室不存在" {
-			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "檢查聊天室權限失敗", "details": err.Error()})
+		apperror.Write(c, err)
 		return
 	}
 
@@ -424,12 +403,12 @@ func (cc *ChatController) JoinChatRoom(c *gin.Context) {
 // @Produce json
 // @Security BearerAuth
 // @Success 200 {object} map[string]interface{}
-// @Failure 401 {object} map[string]interface{}
+// @Failure 401 {object} apperror.Problem
 // @Router /api/v1/chat/online-users [get]
 func (cc *ChatController) GetOnlineUsers(c *gin.Context) {
 	userID := c.GetString("userID")
 	if userID == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授權"})
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
 		return
 	}
 
@@ -449,19 +428,19 @@ func (cc *ChatController) GetOnlineUsers(c *gin.Context) {
 // @Security BearerAuth
 // @Param roomId path string true "聊天室ID"
 // @Success 200 {object} map[string]interface{}
-// @Failure 400 {object} map[string]interface{}
-// @Failure 401 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
 // @Router /api/v1/chat/rooms/{roomId}/online-users [get]
 func (cc *ChatController) GetRoomUsers(c *gin.Context) {
 	userID := c.GetString("userID")
 	if userID == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授權"})
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
 		return
 	}
 
 	roomID := c.Param("roomId")
 	if roomID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "聊天室ID不能為空"})
+		apperror.Write(c, apperror.New(apperror.CodeMissingParam).With("name", "roomId"))
 		return
 	}
 
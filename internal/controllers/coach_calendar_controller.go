@@ -0,0 +1,214 @@
+package controllers
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"tennis-platform/backend/internal/apperror"
+	"tennis-platform/backend/internal/dto"
+	"tennis-platform/backend/internal/models"
+	"tennis-platform/backend/internal/services"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CoachCalendarUsecaseInterface 教練外部行事曆用例接口
+type CoachCalendarUsecaseInterface interface {
+	GetCalendarSources(ctx context.Context, userID string) ([]models.CoachCalendarSource, error)
+	CreateCalendarSource(ctx context.Context, userID string, req *dto.CreateCoachCalendarRequest) (*models.CoachCalendarSource, error)
+	UploadCalendarFile(ctx context.Context, userID, name string, content []byte) (*models.CoachCalendarSource, error)
+	SyncCalendarSource(ctx context.Context, userID, sourceID string) (*models.CoachCalendarSource, error)
+	DeleteCalendarSource(ctx context.Context, userID, sourceID string) error
+}
+
+// CoachCalendarController 教練外部行事曆控制器
+type CoachCalendarController struct {
+	coachCalendarUsecase CoachCalendarUsecaseInterface
+}
+
+// NewCoachCalendarController 創建新的教練外部行事曆控制器
+func NewCoachCalendarController(coachCalendarUsecase CoachCalendarUsecaseInterface) *CoachCalendarController {
+	return &CoachCalendarController{
+		coachCalendarUsecase: coachCalendarUsecase,
+	}
+}
+
+// GetMyCalendars 獲取我連結的外部行事曆
+// @Summary 獲取我連結的外部行事曆
+// @Description 返回教練連結的外部行事曆及最近一次同步的狀態
+// @Tags coaches
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.CoachCalendarSource
+// @Failure 401 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Router /api/v1/coaches/my-calendars [get]
+func (cc *CoachCalendarController) GetMyCalendars(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
+		return
+	}
+
+	sources, err := cc.coachCalendarUsecase.GetCalendarSources(c.Request.Context(), userID.(string))
+	if err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, sources)
+}
+
+// CreateMyCalendar 連結外部行事曆訂閱網址
+// @Summary 連結外部行事曆訂閱網址
+// @Description 連結 Google、Apple、Outlook 等行事曆的 ICS 訂閱網址，其中的行程會從可預約時段中扣除；建立後立即同步，之後每 30 分鐘同步一次
+// @Tags coaches
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.CreateCoachCalendarRequest true "外部行事曆"
+// @Success 201 {object} models.CoachCalendarSource
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Failure 409 {object} apperror.Problem
+// @Router /api/v1/coaches/my-calendars [post]
+func (cc *CoachCalendarController) CreateMyCalendar(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
+		return
+	}
+
+	var req dto.CreateCoachCalendarRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperror.Write(c, apperror.Validation(err))
+		return
+	}
+
+	source, err := cc.coachCalendarUsecase.CreateCalendarSource(c.Request.Context(), userID.(string), &req)
+	if err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, source)
+}
+
+// UploadMyCalendar 上傳 .ics 文件
+// @Summary 上傳 .ics 文件
+// @Description 上傳 iCalendar 文件，其中的行程會從可預約時段中扣除；內容不會自動更新，需要時請重新上傳
+// @Tags coaches
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param file formData file true ".ics 文件，最大 5 MB"
+// @Param name formData string false "名稱，默認為文件名"
+// @Success 201 {object} models.CoachCalendarSource
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Failure 409 {object} apperror.Problem
+// @Failure 413 {object} apperror.Problem
+// @Failure 422 {object} apperror.Problem
+// @Router /api/v1/coaches/my-calendars/upload [post]
+func (cc *CoachCalendarController) UploadMyCalendar(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
+		return
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		apperror.Write(c, apperror.New(apperror.CodeUploadMissingFile))
+		return
+	}
+	if file.Size > services.MaxCoachCalendarSize {
+		apperror.Write(c, apperror.New(apperror.CodeUploadTooLarge).With("maxMB", services.MaxCoachCalendarSize/(1024*1024)))
+		return
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		apperror.Write(c, apperror.Wrap(apperror.CodeUploadInvalidForm, err))
+		return
+	}
+	defer src.Close()
+
+	content, err := io.ReadAll(src)
+	if err != nil {
+		apperror.Write(c, apperror.Wrap(apperror.CodeUploadInvalidForm, err))
+		return
+	}
+
+	name := strings.TrimSpace(c.PostForm("name"))
+	if name == "" {
+		name = file.Filename
+	}
+	if utf8.RuneCountInString(name) > 100 {
+		name = string([]rune(name)[:100])
+	}
+
+	source, err := cc.coachCalendarUsecase.UploadCalendarFile(c.Request.Context(), userID.(string), name, content)
+	if err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, source)
+}
+
+// SyncMyCalendar 立即同步外部行事曆
+// @Summary 立即同步外部行事曆
+// @Description 立即重新讀取外部行事曆，同步失敗時 lastError 記錄原因，並保留上次同步的忙碌時段
+// @Tags coaches
+// @Produce json
+// @Security BearerAuth
+// @Param sourceId path string true "外部行事曆ID"
+// @Success 200 {object} models.CoachCalendarSource
+// @Failure 401 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Router /api/v1/coaches/my-calendars/{sourceId}/sync [post]
+func (cc *CoachCalendarController) SyncMyCalendar(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
+		return
+	}
+
+	source, err := cc.coachCalendarUsecase.SyncCalendarSource(c.Request.Context(), userID.(string), c.Param("sourceId"))
+	if err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, source)
+}
+
+// DeleteMyCalendar 刪除外部行事曆
+// @Summary 刪除外部行事曆
+// @Description 取消連結外部行事曆，其中的行程不再從可預約時段中扣除
+// @Tags coaches
+// @Security BearerAuth
+// @Param sourceId path string true "外部行事曆ID"
+// @Success 204
+// @Failure 401 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Router /api/v1/coaches/my-calendars/{sourceId} [delete]
+func (cc *CoachCalendarController) DeleteMyCalendar(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
+		return
+	}
+
+	if err := cc.coachCalendarUsecase.DeleteCalendarSource(c.Request.Context(), userID.(string), c.Param("sourceId")); err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
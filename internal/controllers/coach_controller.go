@@ -3,8 +3,12 @@ package controllers
 import (
 	"net/http"
 	"strconv"
+	"tennis-platform/backend/internal/apperror"
 	"tennis-platform/backend/internal/dto"
+	"tennis-platform/backend/internal/etag"
 	"tennis-platform/backend/internal/models"
+	"tennis-platform/backend/internal/pagination"
+	"tennis-platform/backend/internal/services"
 
 	"github.com/gin-gonic/gin"
 )
@@ -29,8 +33,9 @@ type CoachUsecaseInterface interface {
 	CreateLesson(req *dto.CreateLessonRequest) (*models.Lesson, error)
 	GetLesson(lessonID string) (*models.Lesson, error)
 	GetLessons(req *dto.GetLessonsRequest) ([]models.Lesson, int64, error)
-	UpdateLesson(lessonID string, req *dto.UpdateLessonRequest) (*models.Lesson, error)
-	CancelLesson(lessonID string, req *dto.CancelLessonRequest) (*models.Lesson, error)
+	UpdateLesson(lessonID string, req *dto.UpdateLessonRequest, expectedVersion *int64) (*models.Lesson, error)
+	GetLessonCancellationQuote(lessonID, userID string) (*services.CancellationQuote, error)
+	CancelLesson(lessonID, userID string, req *dto.CancelLessonRequest) (*dto.CancelLessonResponse, error)
 
 	GetCoachAvailability(coachID string, date string) ([]models.TimeSlot, error)
 	UpdateCoachSchedule(coachID string, req *dto.UpdateScheduleRequest) error
@@ -46,7 +51,7 @@ type CoachUsecaseInterface interface {
 	// 教練評價相關方法
 	CreateCoachReview(userID string, req *dto.CreateCoachReviewRequest) (*models.CoachReview, error)
 	GetCoachReview(reviewID string) (*models.CoachReview, error)
-	GetCoachReviews(req *dto.CoachReviewSearchRequest) ([]models.CoachReview, int64, error)
+	GetCoachReviews(req *dto.CoachReviewSearchRequest) ([]models.CoachReview, pagination.PageInfo, error)
 	UpdateCoachReview(reviewID string, userID string, req *dto.UpdateCoachReviewRequest) (*models.CoachReview, error)
 	DeleteCoachReview(reviewID string, userID string) error
 	MarkReviewHelpful(userID string, req *dto.MarkReviewHelpfulRequest) (*models.CoachReview, error)
@@ -76,32 +81,25 @@ func NewCoachController(coachUsecase CoachUsecaseInterface) *CoachController {
 // @Security BearerAuth
 // @Param request body dto.CreateCoachProfileRequest true "教練檔案創建請求"
 // @Success 201 {object} models.Coach
-// @Failure 400 {object} map[string]interface{}
-// @Failure 401 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
 // @Router /api/v1/coaches [post]
 func (cc *CoachController) CreateCoachProfile(c *gin.Context) {
 	userID, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "未找到用戶信息",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
 		return
 	}
 
 	var profileCreate dto.CreateCoachProfileRequest
 	if err := c.ShouldBindJSON(&profileCreate); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "請求參數錯誤",
-			"details": err.Error(),
-		})
+		apperror.Write(c, apperror.Validation(err))
 		return
 	}
 
 	coach, err := cc.coachUsecase.CreateCoachProfile(userID.(string), &profileCreate)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
@@ -116,22 +114,18 @@ func (cc *CoachController) CreateCoachProfile(c *gin.Context) {
 // @Produce json
 // @Param id path string true "教練ID"
 // @Success 200 {object} models.Coach
-// @Failure 404 {object} map[string]interface{}
+// @Failure 404 {object} apperror.Problem
 // @Router /api/v1/coaches/{id} [get]
 func (cc *CoachController) GetCoach(c *gin.Context) {
 	coachID := c.Param("id")
 	if coachID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "教練ID不能為空",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeMissingParam).With("name", "id"))
 		return
 	}
 
 	coach, err := cc.coachUsecase.GetCoachByID(coachID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
@@ -146,23 +140,19 @@ func (cc *CoachController) GetCoach(c *gin.Context) {
 // @Produce json
 // @Security BearerAuth
 // @Success 200 {object} models.Coach
-// @Failure 401 {object} map[string]interface{}
-// @Failure 404 {object} map[string]interface{}
+// @Failure 401 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
 // @Router /api/v1/coaches/my-profile [get]
 func (cc *CoachController) GetMyCoachProfile(c *gin.Context) {
 	userID, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "未找到用戶信息",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
 		return
 	}
 
 	coach, err := cc.coachUsecase.GetCoachByUserID(userID.(string))
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
@@ -179,57 +169,44 @@ func (cc *CoachController) GetMyCoachProfile(c *gin.Context) {
 // @Param id path string true "教練ID"
 // @Param request body dto.UpdateCoachProfileRequest true "教練檔案更新請求"
 // @Success 200 {object} models.Coach
-// @Failure 400 {object} map[string]interface{}
-// @Failure 401 {object} map[string]interface{}
-// @Failure 403 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
 // @Router /api/v1/coaches/{id} [put]
 func (cc *CoachController) UpdateCoachProfile(c *gin.Context) {
 	userID, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "未找到用戶信息",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
 		return
 	}
 
 	coachID := c.Param("id")
 	if coachID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "教練ID不能為空",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeMissingParam).With("name", "id"))
 		return
 	}
 
 	// 檢查權限：只有教練本人可以更新自己的檔案
 	coach, err := cc.coachUsecase.GetCoachByID(coachID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
 	if coach.UserID != userID.(string) {
-		c.JSON(http.StatusForbidden, gin.H{
-			"error": "無權限更新此教練檔案",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeCoachForbidden))
 		return
 	}
 
 	var profileUpdate dto.UpdateCoachProfileRequest
 	if err := c.ShouldBindJSON(&profileUpdate); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "請求參數錯誤",
-			"details": err.Error(),
-		})
+		apperror.Write(c, apperror.Validation(err))
 		return
 	}
 
 	updatedCoach, err := cc.coachUsecase.UpdateCoachProfile(coachID, &profileUpdate)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
@@ -261,18 +238,13 @@ func (cc *CoachController) SearchCoaches(c *gin.Context) {
 
 	// 綁定查詢參數
 	if err := c.ShouldBindQuery(&searchReq); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "查詢參數錯誤",
-			"details": err.Error(),
-		})
+		apperror.Write(c, apperror.Validation(err))
 		return
 	}
 
 	coaches, total, err := cc.coachUsecase.SearchCoaches(&searchReq)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
@@ -312,17 +284,15 @@ func (cc *CoachController) SearchCoaches(c *gin.Context) {
 // @Security BearerAuth
 // @Param request body dto.CoachVerificationRequest true "教練認證請求"
 // @Success 200 {object} models.Coach
-// @Failure 400 {object} map[string]interface{}
-// @Failure 401 {object} map[string]interface{}
-// @Failure 403 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
 // @Router /api/v1/coaches/verify [post]
 func (cc *CoachController) VerifyCoach(c *gin.Context) {
 	// TODO: 添加管理員權限檢查
 	userID, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "未找到用戶信息",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
 		return
 	}
 
@@ -332,18 +302,13 @@ func (cc *CoachController) VerifyCoach(c *gin.Context) {
 
 	var verificationReq dto.CoachVerificationRequest
 	if err := c.ShouldBindJSON(&verificationReq); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "請求參數錯誤",
-			"details": err.Error(),
-		})
+		apperror.Write(c, apperror.Validation(err))
 		return
 	}
 
 	coach, err := cc.coachUsecase.VerifyCoach(&verificationReq)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
@@ -388,23 +353,19 @@ func (cc *CoachController) GetCoachCertifications(c *gin.Context) {
 // @Produce json
 // @Param id path string true "教練ID"
 // @Success 200 {object} map[string]interface{}
-// @Failure 404 {object} map[string]interface{}
+// @Failure 404 {object} apperror.Problem
 // @Router /api/v1/coaches/{id}/statistics [get]
 func (cc *CoachController) GetCoachStatistics(c *gin.Context) {
 	coachID := c.Param("id")
 	if coachID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "教練ID不能為空",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeMissingParam).With("name", "id"))
 		return
 	}
 
 	// 檢查教練是否存在
 	coach, err := cc.coachUsecase.GetCoachByID(coachID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
@@ -482,41 +443,32 @@ func (cc *CoachController) GetAvailableCurrencies(c *gin.Context) {
 // @Security BearerAuth
 // @Param request body dto.CreateLessonTypeRequest true "課程類型創建請求"
 // @Success 201 {object} models.LessonType
-// @Failure 400 {object} map[string]interface{}
-// @Failure 401 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
 // @Router /api/v1/coaches/lesson-types [post]
 func (cc *CoachController) CreateLessonType(c *gin.Context) {
 	userID, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "未找到用戶信息",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
 		return
 	}
 
 	// 獲取教練信息
 	coach, err := cc.coachUsecase.GetCoachByUserID(userID.(string))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "教練檔案不存在",
-		})
+		apperror.Write(c, err)
 		return
 	}
 
 	var req dto.CreateLessonTypeRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "請求參數錯誤",
-			"details": err.Error(),
-		})
+		apperror.Write(c, apperror.Validation(err))
 		return
 	}
 
 	lessonType, err := cc.coachUsecase.CreateLessonType(coach.ID, &req)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
@@ -535,17 +487,13 @@ func (cc *CoachController) CreateLessonType(c *gin.Context) {
 func (cc *CoachController) GetLessonTypes(c *gin.Context) {
 	coachID := c.Param("id")
 	if coachID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "教練ID不能為空",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeMissingParam).With("name", "id"))
 		return
 	}
 
 	lessonTypes, err := cc.coachUsecase.GetLessonTypes(coachID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
@@ -564,32 +512,25 @@ func (cc *CoachController) GetLessonTypes(c *gin.Context) {
 // @Param id path string true "課程類型ID"
 // @Param request body dto.UpdateLessonTypeRequest true "課程類型更新請求"
 // @Success 200 {object} models.LessonType
-// @Failure 400 {object} map[string]interface{}
-// @Failure 401 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
 // @Router /api/v1/lesson-types/{id} [put]
 func (cc *CoachController) UpdateLessonType(c *gin.Context) {
 	lessonTypeID := c.Param("id")
 	if lessonTypeID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "課程類型ID不能為空",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeMissingParam).With("name", "id"))
 		return
 	}
 
 	var req dto.UpdateLessonTypeRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "請求參數錯誤",
-			"details": err.Error(),
-		})
+		apperror.Write(c, apperror.Validation(err))
 		return
 	}
 
 	lessonType, err := cc.coachUsecase.UpdateLessonType(lessonTypeID, &req)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
@@ -605,22 +546,18 @@ func (cc *CoachController) UpdateLessonType(c *gin.Context) {
 // @Security BearerAuth
 // @Param id path string true "課程類型ID"
 // @Success 204
-// @Failure 400 {object} map[string]interface{}
-// @Failure 401 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
 // @Router /api/v1/lesson-types/{id} [delete]
 func (cc *CoachController) DeleteLessonType(c *gin.Context) {
 	lessonTypeID := c.Param("id")
 	if lessonTypeID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "課程類型ID不能為空",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeMissingParam).With("name", "id"))
 		return
 	}
 
 	if err := cc.coachUsecase.DeleteLessonType(lessonTypeID); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
@@ -629,31 +566,26 @@ func (cc *CoachController) DeleteLessonType(c *gin.Context) {
 
 // CreateLesson 創建課程
 // @Summary 創建課程
-// @Description 學生預訂課程
+// @Description 學生預訂課程，提供 promoCode 時從價格扣除優惠碼折扣
 // @Tags lessons
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param request body dto.CreateLessonRequest true "課程創建請求"
 // @Success 201 {object} models.Lesson
-// @Failure 400 {object} map[string]interface{}
-// @Failure 401 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
 // @Router /api/v1/lessons [post]
 func (cc *CoachController) CreateLesson(c *gin.Context) {
 	userID, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "未找到用戶信息",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
 		return
 	}
 
 	var req dto.CreateLessonRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "請求參數錯誤",
-			"details": err.Error(),
-		})
+		apperror.Write(c, apperror.Validation(err))
 		return
 	}
 
@@ -662,9 +594,7 @@ func (cc *CoachController) CreateLesson(c *gin.Context) {
 
 	lesson, err := cc.coachUsecase.CreateLesson(&req)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
@@ -679,27 +609,25 @@ func (cc *CoachController) CreateLesson(c *gin.Context) {
 // @Produce json
 // @Security BearerAuth
 // @Param id path string true "課程ID"
+// @Param If-None-Match header string false "先前取得的 ETag，相符時返回 304"
 // @Success 200 {object} models.Lesson
-// @Failure 404 {object} map[string]interface{}
+// @Success 304
+// @Failure 404 {object} apperror.Problem
 // @Router /api/v1/lessons/{id} [get]
 func (cc *CoachController) GetLesson(c *gin.Context) {
 	lessonID := c.Param("id")
 	if lessonID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "課程ID不能為空",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeMissingParam).With("name", "id"))
 		return
 	}
 
 	lesson, err := cc.coachUsecase.GetLesson(lessonID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, lesson)
+	etag.JSON(c, http.StatusOK, lesson.Version, lesson)
 }
 
 // GetLessons 獲取課程列表
@@ -721,18 +649,13 @@ func (cc *CoachController) GetLesson(c *gin.Context) {
 func (cc *CoachController) GetLessons(c *gin.Context) {
 	var req dto.GetLessonsRequest
 	if err := c.ShouldBindQuery(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "查詢參數錯誤",
-			"details": err.Error(),
-		})
+		apperror.Write(c, apperror.Validation(err))
 		return
 	}
 
 	lessons, total, err := cc.coachUsecase.GetLessons(&req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
@@ -772,79 +695,107 @@ func (cc *CoachController) GetLessons(c *gin.Context) {
 // @Security BearerAuth
 // @Param id path string true "課程ID"
 // @Param request body dto.UpdateLessonRequest true "課程更新請求"
+// @Param If-Match header string false "資源的 ETag，不符時返回 412"
 // @Success 200 {object} models.Lesson
-// @Failure 400 {object} map[string]interface{}
-// @Failure 401 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 412 {object} apperror.Problem
 // @Router /api/v1/lessons/{id} [put]
 func (cc *CoachController) UpdateLesson(c *gin.Context) {
 	lessonID := c.Param("id")
 	if lessonID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "課程ID不能為空",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeMissingParam).With("name", "id"))
+		return
+	}
+
+	expectedVersion, err := etag.IfMatchVersion(c)
+	if err != nil {
+		apperror.Write(c, err)
 		return
 	}
 
 	var req dto.UpdateLessonRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "請求參數錯誤",
-			"details": err.Error(),
-		})
+		apperror.Write(c, apperror.Validation(err))
+		return
+	}
+
+	lesson, err := cc.coachUsecase.UpdateLesson(lessonID, &req, expectedVersion)
+	if err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	etag.JSON(c, http.StatusOK, lesson.Version, lesson)
+}
+
+// GetLessonCancellationQuote 獲取取消課程的退款報價
+// @Summary 獲取取消課程的退款報價
+// @Description 學生取消依教練取消政策及例外計算退款，教練取消一律全額退款；allowed 為 false 表示已超過取消期限
+// @Tags lessons
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "課程ID"
+// @Success 200 {object} services.CancellationQuote
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Router /api/v1/lessons/{id}/cancellation-quote [get]
+func (cc *CoachController) GetLessonCancellationQuote(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
 		return
 	}
 
-	lesson, err := cc.coachUsecase.UpdateLesson(lessonID, &req)
+	quote, err := cc.coachUsecase.GetLessonCancellationQuote(c.Param("id"), userID.(string))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, lesson)
+	c.JSON(http.StatusOK, quote)
 }
 
 // CancelLesson 取消課程
 // @Summary 取消課程
-// @Description 取消課程
+// @Description 學生或教練取消課程，並依取消政策退還已付款項；提供 expectedRefundAmount 時，與取消當下的退款金額不符會返回 409 且不取消
 // @Tags lessons
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param id path string true "課程ID"
 // @Param request body dto.CancelLessonRequest true "取消課程請求"
-// @Success 200 {object} models.Lesson
-// @Failure 400 {object} map[string]interface{}
-// @Failure 401 {object} map[string]interface{}
+// @Success 200 {object} dto.CancelLessonResponse
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 409 {object} apperror.Problem
 // @Router /api/v1/lessons/{id}/cancel [post]
 func (cc *CoachController) CancelLesson(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
+		return
+	}
+
 	lessonID := c.Param("id")
 	if lessonID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "課程ID不能為空",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeMissingParam).With("name", "id"))
 		return
 	}
 
 	var req dto.CancelLessonRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "請求參數錯誤",
-			"details": err.Error(),
-		})
+		apperror.Write(c, apperror.Validation(err))
 		return
 	}
 
-	lesson, err := cc.coachUsecase.CancelLesson(lessonID, &req)
+	result, err := cc.coachUsecase.CancelLesson(lessonID, userID.(string), &req)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, lesson)
+	c.JSON(http.StatusOK, result)
 }
 
 // GetCoachAvailability 獲取教練可用時間
@@ -860,25 +811,19 @@ func (cc *CoachController) CancelLesson(c *gin.Context) {
 func (cc *CoachController) GetCoachAvailability(c *gin.Context) {
 	coachID := c.Param("id")
 	if coachID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "教練ID不能為空",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeMissingParam).With("name", "id"))
 		return
 	}
 
 	date := c.Query("date")
 	if date == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "日期參數不能為空",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeMissingParam).With("name", "date"))
 		return
 	}
 
 	availability, err := cc.coachUsecase.GetCoachAvailability(coachID, date)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
@@ -897,40 +842,31 @@ func (cc *CoachController) GetCoachAvailability(c *gin.Context) {
 // @Security BearerAuth
 // @Param request body dto.UpdateScheduleRequest true "時間表更新請求"
 // @Success 200 {object} map[string]interface{}
-// @Failure 400 {object} map[string]interface{}
-// @Failure 401 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
 // @Router /api/v1/coaches/schedule [put]
 func (cc *CoachController) UpdateCoachSchedule(c *gin.Context) {
 	userID, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "未找到用戶信息",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
 		return
 	}
 
 	// 獲取教練信息
 	coach, err := cc.coachUsecase.GetCoachByUserID(userID.(string))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "教練檔案不存在",
-		})
+		apperror.Write(c, err)
 		return
 	}
 
 	var req dto.UpdateScheduleRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "請求參數錯誤",
-			"details": err.Error(),
-		})
+		apperror.Write(c, apperror.Validation(err))
 		return
 	}
 
 	if err := cc.coachUsecase.UpdateCoachSchedule(coach.ID, &req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
@@ -952,17 +888,13 @@ func (cc *CoachController) UpdateCoachSchedule(c *gin.Context) {
 func (cc *CoachController) GetCoachSchedule(c *gin.Context) {
 	coachID := c.Param("id")
 	if coachID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "教練ID不能為空",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeMissingParam).With("name", "id"))
 		return
 	}
 
 	schedule, err := cc.coachUsecase.GetCoachSchedule(coachID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
@@ -1012,24 +944,19 @@ func parseBoolParam(c *gin.Context, key string) *bool {
 // @Security BearerAuth
 // @Param request body dto.IntelligentSchedulingRequest true "智能排課請求"
 // @Success 200 {object} map[string]interface{}
-// @Failure 400 {object} map[string]interface{}
-// @Failure 401 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
 // @Router /api/v1/intelligent-scheduling/recommendations [post]
 func (cc *CoachController) GetIntelligentRecommendations(c *gin.Context) {
 	userID, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "未找到用戶信息",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
 		return
 	}
 
 	var req dto.IntelligentSchedulingRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "請求參數錯誤",
-			"details": err.Error(),
-		})
+		apperror.Write(c, apperror.Validation(err))
 		return
 	}
 
@@ -1038,9 +965,7 @@ func (cc *CoachController) GetIntelligentRecommendations(c *gin.Context) {
 
 	recommendations, err := cc.coachUsecase.GetIntelligentRecommendations(&req)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
@@ -1059,24 +984,19 @@ func (cc *CoachController) GetIntelligentRecommendations(c *gin.Context) {
 // @Security BearerAuth
 // @Param request body dto.OptimalTimeRequest true "最佳時間查詢請求"
 // @Success 200 {object} map[string]interface{}
-// @Failure 400 {object} map[string]interface{}
-// @Failure 401 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
 // @Router /api/v1/intelligent-scheduling/optimal-time [post]
 func (cc *CoachController) FindOptimalLessonTime(c *gin.Context) {
 	userID, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "未找到用戶信息",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
 		return
 	}
 
 	var req dto.OptimalTimeRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "請求參數錯誤",
-			"details": err.Error(),
-		})
+		apperror.Write(c, apperror.Validation(err))
 		return
 	}
 
@@ -1085,9 +1005,7 @@ func (cc *CoachController) FindOptimalLessonTime(c *gin.Context) {
 
 	recommendation, err := cc.coachUsecase.FindOptimalLessonTime(&req)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
@@ -1105,48 +1023,37 @@ func (cc *CoachController) FindOptimalLessonTime(c *gin.Context) {
 // @Security BearerAuth
 // @Param request body dto.ConflictDetectionRequest true "衝突檢測請求"
 // @Success 200 {object} map[string]interface{}
-// @Failure 400 {object} map[string]interface{}
-// @Failure 401 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
 // @Router /api/v1/intelligent-scheduling/detect-conflicts [post]
 func (cc *CoachController) DetectSchedulingConflicts(c *gin.Context) {
 	userID, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "未找到用戶信息",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
 		return
 	}
 
 	var req dto.ConflictDetectionRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "請求參數錯誤",
-			"details": err.Error(),
-		})
+		apperror.Write(c, apperror.Validation(err))
 		return
 	}
 
 	// 檢查權限：只有教練本人可以檢測自己的衝突
 	coach, err := cc.coachUsecase.GetCoachByUserID(userID.(string))
 	if err != nil {
-		c.JSON(http.StatusForbidden, gin.H{
-			"error": "只有教練可以檢測排課衝突",
-		})
+		apperror.Write(c, err)
 		return
 	}
 
 	if coach.ID != req.CoachID {
-		c.JSON(http.StatusForbidden, gin.H{
-			"error": "無權限檢測其他教練的排課衝突",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeCoachForbidden))
 		return
 	}
 
 	conflicts, err := cc.coachUsecase.DetectSchedulingConflicts(&req)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
@@ -1166,33 +1073,26 @@ func (cc *CoachController) DetectSchedulingConflicts(c *gin.Context) {
 // @Security BearerAuth
 // @Param request body dto.ConflictResolutionRequest true "衝突解決請求"
 // @Success 200 {object} map[string]interface{}
-// @Failure 400 {object} map[string]interface{}
-// @Failure 401 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
 // @Router /api/v1/intelligent-scheduling/resolve-conflict [post]
 func (cc *CoachController) ResolveSchedulingConflict(c *gin.Context) {
 	userID, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "未找到用戶信息",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
 		return
 	}
 
 	var req dto.ConflictResolutionRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "請求參數錯誤",
-			"details": err.Error(),
-		})
+		apperror.Write(c, apperror.Validation(err))
 		return
 	}
 
 	// 檢查權限：只有相關的教練或學生可以解決衝突
 	lesson, err := cc.coachUsecase.GetLesson(req.ConflictingLessonID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": "課程不存在",
-		})
+		apperror.Write(c, err)
 		return
 	}
 
@@ -1204,16 +1104,12 @@ func (cc *CoachController) ResolveSchedulingConflict(c *gin.Context) {
 	isStudent := lesson.StudentID == userID.(string)
 
 	if !isCoach && !isStudent {
-		c.JSON(http.StatusForbidden, gin.H{
-			"error": "無權限解決此課程的衝突",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeLessonForbidden))
 		return
 	}
 
 	if err := cc.coachUsecase.ResolveSchedulingConflict(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
@@ -1232,32 +1128,25 @@ func (cc *CoachController) ResolveSchedulingConflict(c *gin.Context) {
 // @Param coachId path string true "教練ID"
 // @Param request body dto.IntelligentSchedulingRequest true "學生偏好"
 // @Success 200 {object} map[string]interface{}
-// @Failure 400 {object} map[string]interface{}
-// @Failure 401 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
 // @Router /api/v1/intelligent-scheduling/coaches/{coachId}/factors [post]
 func (cc *CoachController) GetCoachRecommendationFactors(c *gin.Context) {
 	userID, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "未找到用戶信息",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
 		return
 	}
 
 	coachID := c.Param("coachId")
 	if coachID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "教練ID不能為空",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeMissingParam).With("name", "coachId"))
 		return
 	}
 
 	var req dto.IntelligentSchedulingRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "請求參數錯誤",
-			"details": err.Error(),
-		})
+		apperror.Write(c, apperror.Validation(err))
 		return
 	}
 
@@ -1266,9 +1155,7 @@ func (cc *CoachController) GetCoachRecommendationFactors(c *gin.Context) {
 
 	factors, err := cc.coachUsecase.GetCoachRecommendationFactors(coachID, &req)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
@@ -1342,32 +1229,25 @@ func (cc *CoachController) GetIntelligentSchedulingOptions(c *gin.Context) {
 // @Security BearerAuth
 // @Param request body dto.CreateCoachReviewRequest true "評價創建請求"
 // @Success 201 {object} models.CoachReview
-// @Failure 400 {object} map[string]interface{}
-// @Failure 401 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
 // @Router /api/v1/coach-reviews [post]
 func (cc *CoachController) CreateCoachReview(c *gin.Context) {
 	userID, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "未找到用戶信息",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
 		return
 	}
 
 	var req dto.CreateCoachReviewRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "請求參數錯誤",
-			"details": err.Error(),
-		})
+		apperror.Write(c, apperror.Validation(err))
 		return
 	}
 
 	review, err := cc.coachUsecase.CreateCoachReview(userID.(string), &req)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
@@ -1382,22 +1262,18 @@ func (cc *CoachController) CreateCoachReview(c *gin.Context) {
 // @Produce json
 // @Param id path string true "評價ID"
 // @Success 200 {object} models.CoachReview
-// @Failure 404 {object} map[string]interface{}
+// @Failure 404 {object} apperror.Problem
 // @Router /api/v1/coach-reviews/{id} [get]
 func (cc *CoachController) GetCoachReview(c *gin.Context) {
 	reviewID := c.Param("id")
 	if reviewID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "評價ID不能為空",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeMissingParam).With("name", "id"))
 		return
 	}
 
 	review, err := cc.coachUsecase.GetCoachReview(reviewID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
@@ -1414,6 +1290,7 @@ func (cc *CoachController) GetCoachReview(c *gin.Context) {
 // @Param rating query int false "評分篩選"
 // @Param hasComment query bool false "是否有評論"
 // @Param tags query []string false "標籤篩選" collectionFormat(multi)
+// @Param cursor query string false "分頁游標，提供時忽略 page"
 // @Param page query int false "頁碼" default(1)
 // @Param limit query int false "每頁數量" default(20)
 // @Param sortBy query string false "排序欄位" Enums(rating, createdAt, isHelpful)
@@ -1423,45 +1300,19 @@ func (cc *CoachController) GetCoachReview(c *gin.Context) {
 func (cc *CoachController) GetCoachReviews(c *gin.Context) {
 	var req dto.CoachReviewSearchRequest
 	if err := c.ShouldBindQuery(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "查詢參數錯誤",
-			"details": err.Error(),
-		})
+		apperror.Write(c, apperror.Validation(err))
 		return
 	}
 
-	reviews, total, err := cc.coachUsecase.GetCoachReviews(&req)
+	reviews, pageInfo, err := cc.coachUsecase.GetCoachReviews(&req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
-	// 計算分頁信息
-	page := req.Page
-	if page < 1 {
-		page = 1
-	}
-	limit := req.Limit
-	if limit < 1 || limit > 100 {
-		limit = 20
-	}
-
-	totalPages := (int(total) + limit - 1) / limit
-	hasNext := page < totalPages
-	hasPrev := page > 1
-
 	c.JSON(http.StatusOK, gin.H{
-		"reviews": reviews,
-		"pagination": gin.H{
-			"total":      total,
-			"page":       page,
-			"limit":      limit,
-			"totalPages": totalPages,
-			"hasNext":    hasNext,
-			"hasPrev":    hasPrev,
-		},
+		"reviews":    reviews,
+		"pagination": pageInfo,
 	})
 }
 
@@ -1475,41 +1326,32 @@ func (cc *CoachController) GetCoachReviews(c *gin.Context) {
 // @Param id path string true "評價ID"
 // @Param request body dto.UpdateCoachReviewRequest true "評價更新請求"
 // @Success 200 {object} models.CoachReview
-// @Failure 400 {object} map[string]interface{}
-// @Failure 401 {object} map[string]interface{}
-// @Failure 403 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
 // @Router /api/v1/coach-reviews/{id} [put]
 func (cc *CoachController) UpdateCoachReview(c *gin.Context) {
 	userID, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "未找到用戶信息",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
 		return
 	}
 
 	reviewID := c.Param("id")
 	if reviewID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "評價ID不能為空",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeMissingParam).With("name", "id"))
 		return
 	}
 
 	var req dto.UpdateCoachReviewRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "請求參數錯誤",
-			"details": err.Error(),
-		})
+		apperror.Write(c, apperror.Validation(err))
 		return
 	}
 
 	review, err := cc.coachUsecase.UpdateCoachReview(reviewID, userID.(string), &req)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
@@ -1525,31 +1367,25 @@ func (cc *CoachController) UpdateCoachReview(c *gin.Context) {
 // @Security BearerAuth
 // @Param id path string true "評價ID"
 // @Success 204
-// @Failure 400 {object} map[string]interface{}
-// @Failure 401 {object} map[string]interface{}
-// @Failure 403 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
 // @Router /api/v1/coach-reviews/{id} [delete]
 func (cc *CoachController) DeleteCoachReview(c *gin.Context) {
 	userID, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "未找到用戶信息",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
 		return
 	}
 
 	reviewID := c.Param("id")
 	if reviewID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "評價ID不能為空",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeMissingParam).With("name", "id"))
 		return
 	}
 
 	if err := cc.coachUsecase.DeleteCoachReview(reviewID, userID.(string)); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
@@ -1565,32 +1401,25 @@ func (cc *CoachController) DeleteCoachReview(c *gin.Context) {
 // @Security BearerAuth
 // @Param request body dto.MarkReviewHelpfulRequest true "標記有用請求"
 // @Success 200 {object} models.CoachReview
-// @Failure 400 {object} map[string]interface{}
-// @Failure 401 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
 // @Router /api/v1/coach-reviews/mark-helpful [post]
 func (cc *CoachController) MarkReviewHelpful(c *gin.Context) {
 	userID, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "未找到用戶信息",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
 		return
 	}
 
 	var req dto.MarkReviewHelpfulRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "請求參數錯誤",
-			"details": err.Error(),
-		})
+		apperror.Write(c, apperror.Validation(err))
 		return
 	}
 
 	review, err := cc.coachUsecase.MarkReviewHelpful(userID.(string), &req)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
@@ -1605,22 +1434,18 @@ func (cc *CoachController) MarkReviewHelpful(c *gin.Context) {
 // @Produce json
 // @Param id path string true "教練ID"
 // @Success 200 {object} map[string]interface{}
-// @Failure 404 {object} map[string]interface{}
+// @Failure 404 {object} apperror.Problem
 // @Router /api/v1/coaches/{id}/review-statistics [get]
 func (cc *CoachController) GetCoachReviewStatistics(c *gin.Context) {
 	coachID := c.Param("id")
 	if coachID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "教練ID不能為空",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeMissingParam).With("name", "id"))
 		return
 	}
 
 	statistics, err := cc.coachUsecase.GetCoachReviewStatistics(coachID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
@@ -1654,22 +1479,18 @@ func (cc *CoachController) GetAvailableReviewTags(c *gin.Context) {
 // @Param coachId query string true "教練ID"
 // @Param lessonId query string false "課程ID"
 // @Success 200 {object} map[string]interface{}
-// @Failure 401 {object} map[string]interface{}
+// @Failure 401 {object} apperror.Problem
 // @Router /api/v1/coach-reviews/can-review [get]
 func (cc *CoachController) CheckCanReviewCoach(c *gin.Context) {
 	userID, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "未找到用戶信息",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
 		return
 	}
 
 	coachID := c.Query("coachId")
 	if coachID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "教練ID不能為空",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeMissingParam).With("name", "coachId"))
 		return
 	}
 
@@ -1680,9 +1501,7 @@ func (cc *CoachController) CheckCanReviewCoach(c *gin.Context) {
 
 	canReview, message, err := cc.coachUsecase.CheckCanReviewCoach(userID.(string), coachID, lessonID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
@@ -0,0 +1,152 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"tennis-platform/backend/internal/apperror"
+	"tennis-platform/backend/internal/dto"
+
+	"github.com/gin-gonic/gin"
+)
+
+// csvContentType CSV 匯出的內容類型
+const csvContentType = "text/csv; charset=utf-8"
+
+// CourtAnalyticsUsecaseInterface 場地分析用例接口
+type CourtAnalyticsUsecaseInterface interface {
+	GetAnalytics(ctx context.Context, userID, courtID string, req *dto.CourtAnalyticsRequest) (*dto.CourtAnalyticsResponse, error)
+	GetDemand(ctx context.Context, userID, courtID string, req *dto.CourtAnalyticsRequest) (*dto.CourtDemandResponse, error)
+	ExportAnalytics(ctx context.Context, userID, courtID string, req *dto.CourtAnalyticsExportRequest) ([]byte, error)
+}
+
+// CourtAnalyticsController 場地分析控制器
+type CourtAnalyticsController struct {
+	courtAnalyticsUsecase CourtAnalyticsUsecaseInterface
+}
+
+// NewCourtAnalyticsController 創建新的場地分析控制器
+func NewCourtAnalyticsController(courtAnalyticsUsecase CourtAnalyticsUsecaseInterface) *CourtAnalyticsController {
+	return &CourtAnalyticsController{
+		courtAnalyticsUsecase: courtAnalyticsUsecase,
+	}
+}
+
+// GetAnalytics 獲取場地分析
+// @Summary 獲取場地分析
+// @Description 返回場地在範圍內的取消率、未到場率、平均提前預訂時數、回頭客比例、各星期及小時的使用率及各週期的營收，僅場地擁有者
+// @Tags courts
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "場地ID"
+// @Param from query string false "開始日期 YYYY-MM-DD，默認為 29 天前"
+// @Param to query string false "結束日期 YYYY-MM-DD，默認為今天"
+// @Param tz query string false "IANA 時區，默認 UTC"
+// @Param period query string false "營收的統計週期" Enums(day, week, month)
+// @Success 200 {object} dto.CourtAnalyticsResponse
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Router /api/v1/courts/{id}/analytics [get]
+func (cc *CourtAnalyticsController) GetAnalytics(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
+		return
+	}
+
+	var req dto.CourtAnalyticsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		apperror.Write(c, apperror.Validation(err))
+		return
+	}
+
+	analytics, err := cc.courtAnalyticsUsecase.GetAnalytics(c.Request.Context(), userID.(string), c.Param("id"), &req)
+	if err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, analytics)
+}
+
+// GetDemand 獲取場地的需求熱度
+// @Summary 獲取場地的需求熱度
+// @Description 返回範圍內各星期及小時查詢時已無空位、預訂或保留時段失敗的次數，僅場地擁有者
+// @Tags courts
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "場地ID"
+// @Param from query string false "開始日期 YYYY-MM-DD，默認為 29 天前"
+// @Param to query string false "結束日期 YYYY-MM-DD，默認為今天"
+// @Param tz query string false "IANA 時區，默認 UTC"
+// @Success 200 {object} dto.CourtDemandResponse
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Router /api/v1/courts/{id}/analytics/demand [get]
+func (cc *CourtAnalyticsController) GetDemand(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
+		return
+	}
+
+	var req dto.CourtAnalyticsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		apperror.Write(c, apperror.Validation(err))
+		return
+	}
+
+	demand, err := cc.courtAnalyticsUsecase.GetDemand(c.Request.Context(), userID.(string), c.Param("id"), &req)
+	if err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, demand)
+}
+
+// ExportAnalytics 匯出場地分析 CSV
+// @Summary 匯出場地分析 CSV
+// @Description 下載指定報表的 CSV 文件，僅場地擁有者
+// @Tags courts
+// @Produce text/csv
+// @Security BearerAuth
+// @Param id path string true "場地ID"
+// @Param report query string true "報表" Enums(summary, occupancy, revenue, demand)
+// @Param from query string false "開始日期 YYYY-MM-DD，默認為 29 天前"
+// @Param to query string false "結束日期 YYYY-MM-DD，默認為今天"
+// @Param tz query string false "IANA 時區，默認 UTC"
+// @Param period query string false "營收的統計週期" Enums(day, week, month)
+// @Success 200 {string} string "CSV 內容"
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Router /api/v1/courts/{id}/analytics/export [get]
+func (cc *CourtAnalyticsController) ExportAnalytics(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
+		return
+	}
+
+	var req dto.CourtAnalyticsExportRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		apperror.Write(c, apperror.Validation(err))
+		return
+	}
+
+	courtID := c.Param("id")
+	data, err := cc.courtAnalyticsUsecase.ExportAnalytics(c.Request.Context(), userID.(string), courtID, &req)
+	if err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="court-%s-%s.csv"`, courtID, req.Report))
+	c.Data(http.StatusOK, csvContentType, data)
+}
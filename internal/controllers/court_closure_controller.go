@@ -0,0 +1,160 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"tennis-platform/backend/internal/apperror"
+	"tennis-platform/backend/internal/dto"
+	"tennis-platform/backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CourtClosureUsecaseInterface 場地例外用例接口
+type CourtClosureUsecaseInterface interface {
+	GetClosures(ctx context.Context, courtID string, req *dto.CourtClosureListRequest) ([]models.CourtClosure, error)
+	PreviewClosure(ctx context.Context, userID, courtID string, req *dto.CourtClosureRequest) (*dto.CourtClosureImpact, error)
+	CreateClosure(ctx context.Context, userID, courtID string, req *dto.CourtClosureRequest) (*dto.CourtClosureResponse, error)
+	DeleteClosure(ctx context.Context, userID, courtID, closureID string) error
+}
+
+// CourtClosureController 場地例外控制器
+type CourtClosureController struct {
+	courtClosureUsecase CourtClosureUsecaseInterface
+}
+
+// NewCourtClosureController 創建新的場地例外控制器
+func NewCourtClosureController(courtClosureUsecase CourtClosureUsecaseInterface) *CourtClosureController {
+	return &CourtClosureController{
+		courtClosureUsecase: courtClosureUsecase,
+	}
+}
+
+// GetClosures 獲取場地的例外
+// @Summary 獲取場地的例外
+// @Description 返回場地的休館、更改營業時間及封鎖時段，未指定範圍時列出尚未結束的例外
+// @Tags courts
+// @Produce json
+// @Param id path string true "場地ID"
+// @Param from query string false "開始日期 YYYY-MM-DD"
+// @Param to query string false "結束日期 YYYY-MM-DD"
+// @Success 200 {array} models.CourtClosure
+// @Failure 400 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Router /api/v1/courts/{id}/closures [get]
+func (cc *CourtClosureController) GetClosures(c *gin.Context) {
+	var req dto.CourtClosureListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		apperror.Write(c, apperror.Validation(err))
+		return
+	}
+
+	closures, err := cc.courtClosureUsecase.GetClosures(c.Request.Context(), c.Param("id"), &req)
+	if err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, closures)
+}
+
+// PreviewClosure 預覽場地例外的影響
+// @Summary 預覽場地例外的影響
+// @Description 列出例外會取消的預訂及退款金額，不寫入例外
+// @Tags courts
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "場地ID"
+// @Param request body dto.CourtClosureRequest true "場地例外"
+// @Success 200 {object} dto.CourtClosureImpact
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Router /api/v1/courts/{id}/closures/preview [post]
+func (cc *CourtClosureController) PreviewClosure(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
+		return
+	}
+
+	var req dto.CourtClosureRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperror.Write(c, apperror.Validation(err))
+		return
+	}
+
+	impact, err := cc.courtClosureUsecase.PreviewClosure(c.Request.Context(), userID.(string), c.Param("id"), &req)
+	if err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, impact)
+}
+
+// CreateClosure 創建場地例外
+// @Summary 創建場地例外
+// @Description 場地擁有者設置休館、更改營業時間或封鎖時段，例外涵蓋的預訂由場地取消並全額退款
+// @Tags courts
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "場地ID"
+// @Param request body dto.CourtClosureRequest true "場地例外"
+// @Success 201 {object} dto.CourtClosureResponse
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Router /api/v1/courts/{id}/closures [post]
+func (cc *CourtClosureController) CreateClosure(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
+		return
+	}
+
+	var req dto.CourtClosureRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperror.Write(c, apperror.Validation(err))
+		return
+	}
+
+	response, err := cc.courtClosureUsecase.CreateClosure(c.Request.Context(), userID.(string), c.Param("id"), &req)
+	if err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, response)
+}
+
+// DeleteClosure 刪除場地例外
+// @Summary 刪除場地例外
+// @Description 時段恢復開放，已取消的預訂不會恢復
+// @Tags courts
+// @Security BearerAuth
+// @Param id path string true "場地ID"
+// @Param closureId path string true "例外ID"
+// @Success 204
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Router /api/v1/courts/{id}/closures/{closureId} [delete]
+func (cc *CourtClosureController) DeleteClosure(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
+		return
+	}
+
+	if err := cc.courtClosureUsecase.DeleteClosure(c.Request.Context(), userID.(string), c.Param("id"), c.Param("closureId")); err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
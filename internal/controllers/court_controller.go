@@ -2,7 +2,9 @@ package controllers
 
 import (
 	"net/http"
+	"tennis-platform/backend/internal/apperror"
 	"tennis-platform/backend/internal/dto"
+	"tennis-platform/backend/internal/etag"
 	"tennis-platform/backend/internal/models"
 	"tennis-platform/backend/internal/services"
 
@@ -13,7 +15,7 @@ import (
 type CourtUsecaseInterface interface {
 	CreateCourt(req *dto.CreateCourtRequest) (*models.Court, error)
 	GetCourtByID(courtID string) (*models.Court, error)
-	UpdateCourt(courtID string, req *dto.UpdateCourtRequest) (*models.Court, error)
+	UpdateCourt(courtID string, req *dto.UpdateCourtRequest, expectedVersion *int64) (*models.Court, error)
 	DeleteCourt(courtID string) error
 	SearchCourts(req *dto.CourtSearchRequest) (*dto.CourtSearchResponse, error)
 	GetAvailableFacilities() []map[string]interface{}
@@ -23,9 +25,28 @@ type CourtUsecaseInterface interface {
 // BookingUsecaseInterface 預訂用例接口
 type BookingUsecaseInterface interface {
 	CreateBooking(userID string, req *dto.CreateBookingRequest) (*models.Booking, error)
+	HoldSlot(userID string, req *dto.CreateSlotHoldRequest) (*models.SlotHold, error)
+	ReleaseHold(userID, holdID string) error
 	GetBooking(bookingID string) (*models.Booking, error)
-	UpdateBooking(bookingID, userID string, req *dto.UpdateBookingRequest) (*models.Booking, error)
-	CancelBooking(bookingID, userID string) error
+	UpdateBooking(bookingID, userID string, req *dto.UpdateBookingRequest, expectedVersion *int64) (*models.Booking, error)
+	UpdateBookingAddOns(bookingID, userID string, req *dto.UpdateBookingAddOnsRequest) (*models.Booking, error)
+	GetCancellationQuote(bookingID, userID string) (*services.CancellationQuote, error)
+	CancelBooking(bookingID, userID string, req *dto.CancelBookingRequest) (*models.Cancellation, error)
+	CreateBookingSeries(userID string, req *dto.CreateBookingSeriesRequest) (*dto.BookingSeriesResponse, error)
+	GetBookingSeries(seriesID, userID string) (*dto.BookingSeriesResponse, error)
+	UpdateBookingSeries(seriesID, userID string, req *dto.UpdateBookingSeriesRequest) (*dto.BookingSeriesResponse, error)
+	CancelBookingSeries(seriesID, userID string, req *dto.CancelBookingSeriesRequest) (*dto.CancelBookingSeriesResponse, error)
+	JoinWaitlist(userID string, req *dto.JoinWaitlistRequest) (*models.BookingWaitlistEntry, error)
+	GetWaitlist(userID string, req *dto.WaitlistListRequest) ([]models.BookingWaitlistEntry, error)
+	GetWaitlistEntry(entryID, userID string) (*models.BookingWaitlistEntry, error)
+	LeaveWaitlist(entryID, userID string) error
+	ClaimWaitlistOffer(entryID, userID string) (*models.Booking, error)
+	CreateBookingSplit(bookingID, userID string, req *dto.CreateBookingSplitRequest) (*dto.BookingSplitResponse, error)
+	GetBookingSplit(bookingID, userID string) (*dto.BookingSplitResponse, error)
+	CancelBookingSplit(bookingID, userID string) error
+	GetPaymentShares(userID string, req *dto.PaymentShareListRequest) ([]models.BookingPaymentShare, error)
+	GetCheckInPass(bookingID, userID string) (*dto.CheckInPassResponse, error)
+	CheckIn(userID string, req *dto.CheckInRequest) (*models.Booking, error)
 	GetBookings(req *dto.BookingListRequest) (*dto.BookingListResponse, error)
 	GetAvailability(req *dto.AvailabilityRequest) (*dto.AvailabilityResponse, error)
 }
@@ -69,16 +90,13 @@ func NewCourtController(courtUsecase CourtUsecaseInterface, reviewUsecase Review
 // @Security BearerAuth
 // @Param request body dto.CreateCourtRequest true "創建場地請求"
 // @Success 201 {object} models.Court
-// @Failure 400 {object} map[string]interface{}
-// @Failure 401 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
 // @Router /api/v1/courts [post]
 func (cc *CourtController) CreateCourt(c *gin.Context) {
 	var req dto.CreateCourtRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "請求參數錯誤",
-			"details": err.Error(),
-		})
+		apperror.Write(c, apperror.Validation(err))
 		return
 	}
 
@@ -90,9 +108,7 @@ func (cc *CourtController) CreateCourt(c *gin.Context) {
 
 	court, err := cc.courtUsecase.CreateCourt(&req)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
@@ -106,27 +122,25 @@ func (cc *CourtController) CreateCourt(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param id path string true "場地ID"
+// @Param If-None-Match header string false "先前取得的 ETag，相符時返回 304"
 // @Success 200 {object} models.Court
-// @Failure 404 {object} map[string]interface{}
+// @Success 304
+// @Failure 404 {object} apperror.Problem
 // @Router /api/v1/courts/{id} [get]
 func (cc *CourtController) GetCourt(c *gin.Context) {
 	courtID := c.Param("id")
 	if courtID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "場地ID不能為空",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeMissingParam).With("name", "id"))
 		return
 	}
 
 	court, err := cc.courtUsecase.GetCourtByID(courtID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, court)
+	etag.JSON(c, http.StatusOK, court.Version, court)
 }
 
 // UpdateCourt 更新場地
@@ -138,38 +152,39 @@ func (cc *CourtController) GetCourt(c *gin.Context) {
 // @Security BearerAuth
 // @Param id path string true "場地ID"
 // @Param request body dto.UpdateCourtRequest true "更新場地請求"
+// @Param If-Match header string false "資源的 ETag，不符時返回 412"
 // @Success 200 {object} models.Court
-// @Failure 400 {object} map[string]interface{}
-// @Failure 401 {object} map[string]interface{}
-// @Failure 404 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Failure 412 {object} apperror.Problem
 // @Router /api/v1/courts/{id} [put]
 func (cc *CourtController) UpdateCourt(c *gin.Context) {
 	courtID := c.Param("id")
 	if courtID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "場地ID不能為空",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeMissingParam).With("name", "id"))
+		return
+	}
+
+	expectedVersion, err := etag.IfMatchVersion(c)
+	if err != nil {
+		apperror.Write(c, err)
 		return
 	}
 
 	var req dto.UpdateCourtRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "請求參數錯誤",
-			"details": err.Error(),
-		})
+		apperror.Write(c, apperror.Validation(err))
 		return
 	}
 
-	court, err := cc.courtUsecase.UpdateCourt(courtID, &req)
+	court, err := cc.courtUsecase.UpdateCourt(courtID, &req, expectedVersion)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, court)
+	etag.JSON(c, http.StatusOK, court.Version, court)
 }
 
 // DeleteCourt 刪除場地
@@ -181,23 +196,19 @@ func (cc *CourtController) UpdateCourt(c *gin.Context) {
 // @Security BearerAuth
 // @Param id path string true "場地ID"
 // @Success 200 {object} map[string]interface{}
-// @Failure 400 {object} map[string]interface{}
-// @Failure 401 {object} map[string]interface{}
-// @Failure 404 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
 // @Router /api/v1/courts/{id} [delete]
 func (cc *CourtController) DeleteCourt(c *gin.Context) {
 	courtID := c.Param("id")
 	if courtID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "場地ID不能為空",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeMissingParam).With("name", "id"))
 		return
 	}
 
 	if err := cc.courtUsecase.DeleteCourt(courtID); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
@@ -226,23 +237,18 @@ func (cc *CourtController) DeleteCourt(c *gin.Context) {
 // @Param page query int false "頁碼"
 // @Param pageSize query int false "每頁數量"
 // @Success 200 {object} dto.CourtSearchResponse
-// @Failure 400 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
 // @Router /api/v1/courts [get]
 func (cc *CourtController) SearchCourts(c *gin.Context) {
 	var req dto.CourtSearchRequest
 	if err := c.ShouldBindQuery(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "請求參數錯誤",
-			"details": err.Error(),
-		})
+		apperror.Write(c, apperror.Validation(err))
 		return
 	}
 
 	response, err := cc.courtUsecase.SearchCourts(&req)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
@@ -259,41 +265,33 @@ func (cc *CourtController) SearchCourts(c *gin.Context) {
 // @Param id path string true "場地ID"
 // @Param images formData file true "圖片文件"
 // @Success 200 {object} map[string]interface{}
-// @Failure 400 {object} map[string]interface{}
-// @Failure 401 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
 // @Router /api/v1/courts/{id}/images [post]
 func (cc *CourtController) UploadCourtImages(c *gin.Context) {
 	courtID := c.Param("id")
 	if courtID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "場地ID不能為空",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeMissingParam).With("name", "id"))
 		return
 	}
 
 	// 檢查場地是否存在
 	_, err := cc.courtUsecase.GetCourtByID(courtID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
 	// 獲取上傳的文件
 	form, err := c.MultipartForm()
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "獲取上傳文件失敗",
-		})
+		apperror.Write(c, apperror.Wrap(apperror.CodeUploadInvalidForm, err))
 		return
 	}
 
 	files := form.File["images"]
 	if len(files) == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "未找到上傳文件",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeUploadMissingFile))
 		return
 	}
 
@@ -304,9 +302,7 @@ func (cc *CourtController) UploadCourtImages(c *gin.Context) {
 	for _, file := range files {
 		uploadResult, err := cc.uploadService.UploadFile(file, "courts")
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error": err.Error(),
-			})
+			apperror.Write(c, err)
 			return
 		}
 
@@ -317,25 +313,21 @@ func (cc *CourtController) UploadCourtImages(c *gin.Context) {
 	// 獲取現有圖片
 	court, err := cc.courtUsecase.GetCourtByID(courtID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
 	// 合併新舊圖片URL
 	allImages := append(court.Images, imageURLs...)
 
-	// 更新場地圖片
+	// 更新場地圖片，以讀取時的版本號避免覆蓋同時上傳的圖片
 	updateReq := dto.UpdateCourtRequest{
 		Images: allImages,
 	}
 
-	updatedCourt, err := cc.courtUsecase.UpdateCourt(courtID, &updateReq)
+	updatedCourt, err := cc.courtUsecase.UpdateCourt(courtID, &updateReq, &court.Version)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
@@ -387,32 +379,25 @@ func (cc *CourtController) GetCourtTypes(c *gin.Context) {
 // @Security BearerAuth
 // @Param request body dto.CreateReviewRequest true "創建評價請求"
 // @Success 201 {object} models.CourtReview
-// @Failure 400 {object} map[string]interface{}
-// @Failure 401 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
 // @Router /api/v1/reviews [post]
 func (cc *CourtController) CreateReview(c *gin.Context) {
 	userID, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "用戶未認證",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
 		return
 	}
 
 	var req dto.CreateReviewRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "請求參數錯誤",
-			"details": err.Error(),
-		})
+		apperror.Write(c, apperror.Validation(err))
 		return
 	}
 
 	review, err := cc.reviewUsecase.CreateReview(userID.(string), &req)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
@@ -427,22 +412,18 @@ func (cc *CourtController) CreateReview(c *gin.Context) {
 // @Produce json
 // @Param id path string true "評價ID"
 // @Success 200 {object} models.CourtReview
-// @Failure 404 {object} map[string]interface{}
+// @Failure 404 {object} apperror.Problem
 // @Router /api/v1/reviews/{id} [get]
 func (cc *CourtController) GetReview(c *gin.Context) {
 	reviewID := c.Param("id")
 	if reviewID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "評價ID不能為空",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeMissingParam).With("name", "id"))
 		return
 	}
 
 	review, err := cc.reviewUsecase.GetReview(reviewID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
@@ -459,41 +440,32 @@ func (cc *CourtController) GetReview(c *gin.Context) {
 // @Param id path string true "評價ID"
 // @Param request body dto.UpdateReviewRequest true "更新評價請求"
 // @Success 200 {object} models.CourtReview
-// @Failure 400 {object} map[string]interface{}
-// @Failure 401 {object} map[string]interface{}
-// @Failure 404 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
 // @Router /api/v1/reviews/{id} [put]
 func (cc *CourtController) UpdateReview(c *gin.Context) {
 	userID, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "用戶未認證",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
 		return
 	}
 
 	reviewID := c.Param("id")
 	if reviewID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "評價ID不能為空",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeMissingParam).With("name", "id"))
 		return
 	}
 
 	var req dto.UpdateReviewRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "請求參數錯誤",
-			"details": err.Error(),
-		})
+		apperror.Write(c, apperror.Validation(err))
 		return
 	}
 
 	review, err := cc.reviewUsecase.UpdateReview(reviewID, userID.(string), &req)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
@@ -509,31 +481,25 @@ func (cc *CourtController) UpdateReview(c *gin.Context) {
 // @Security BearerAuth
 // @Param id path string true "評價ID"
 // @Success 200 {object} map[string]interface{}
-// @Failure 400 {object} map[string]interface{}
-// @Failure 401 {object} map[string]interface{}
-// @Failure 404 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
 // @Router /api/v1/reviews/{id} [delete]
 func (cc *CourtController) DeleteReview(c *gin.Context) {
 	userID, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "用戶未認證",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
 		return
 	}
 
 	reviewID := c.Param("id")
 	if reviewID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "評價ID不能為空",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeMissingParam).With("name", "id"))
 		return
 	}
 
 	if err := cc.reviewUsecase.DeleteReview(reviewID, userID.(string)); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
@@ -553,26 +519,22 @@ func (cc *CourtController) DeleteReview(c *gin.Context) {
 // @Param rating query int false "評分篩選"
 // @Param sortBy query string false "排序欄位" Enums(rating,created_at,helpful)
 // @Param sortOrder query string false "排序順序" Enums(asc,desc)
+// @Param cursor query string false "分頁游標，提供時忽略 page"
 // @Param page query int false "頁碼"
 // @Param pageSize query int false "每頁數量"
 // @Success 200 {object} dto.ReviewListResponse
-// @Failure 400 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
 // @Router /api/v1/reviews [get]
 func (cc *CourtController) GetReviews(c *gin.Context) {
 	var req dto.ReviewListRequest
 	if err := c.ShouldBindQuery(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "請求參數錯誤",
-			"details": err.Error(),
-		})
+		apperror.Write(c, apperror.Validation(err))
 		return
 	}
 
 	response, err := cc.reviewUsecase.GetReviews(&req)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
@@ -589,39 +551,30 @@ func (cc *CourtController) GetReviews(c *gin.Context) {
 // @Param id path string true "評價ID"
 // @Param request body dto.ReportReviewRequest true "舉報評價請求"
 // @Success 200 {object} map[string]interface{}
-// @Failure 400 {object} map[string]interface{}
-// @Failure 401 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
 // @Router /api/v1/reviews/{id}/report [post]
 func (cc *CourtController) ReportReview(c *gin.Context) {
 	userID, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "用戶未認證",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
 		return
 	}
 
 	reviewID := c.Param("id")
 	if reviewID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "評價ID不能為空",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeMissingParam).With("name", "id"))
 		return
 	}
 
 	var req dto.ReportReviewRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "請求參數錯誤",
-			"details": err.Error(),
-		})
+		apperror.Write(c, apperror.Validation(err))
 		return
 	}
 
 	if err := cc.reviewUsecase.ReportReview(reviewID, userID.(string), &req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
@@ -640,32 +593,26 @@ func (cc *CourtController) ReportReview(c *gin.Context) {
 // @Param id path string true "評價ID"
 // @Param helpful query bool true "是否有用"
 // @Success 200 {object} map[string]interface{}
-// @Failure 400 {object} map[string]interface{}
-// @Failure 401 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
 // @Router /api/v1/reviews/{id}/helpful [post]
 func (cc *CourtController) MarkReviewHelpful(c *gin.Context) {
 	userID, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "用戶未認證",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
 		return
 	}
 
 	reviewID := c.Param("id")
 	if reviewID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "評價ID不能為空",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeMissingParam).With("name", "id"))
 		return
 	}
 
 	helpful := c.Query("helpful") == "true"
 
 	if err := cc.reviewUsecase.MarkReviewHelpful(reviewID, userID.(string), helpful); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
@@ -682,23 +629,19 @@ func (cc *CourtController) MarkReviewHelpful(c *gin.Context) {
 // @Produce json
 // @Param courtId path string true "場地ID"
 // @Success 200 {object} dto.ReviewStatistics
-// @Failure 400 {object} map[string]interface{}
-// @Failure 404 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
 // @Router /api/v1/courts/{courtId}/reviews/statistics [get]
 func (cc *CourtController) GetReviewStatistics(c *gin.Context) {
 	courtID := c.Param("courtId")
 	if courtID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "場地ID不能為空",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeMissingParam).With("name", "courtId"))
 		return
 	}
 
 	stats, err := cc.reviewUsecase.GetReviewStatistics(courtID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
@@ -714,32 +657,26 @@ func (cc *CourtController) GetReviewStatistics(c *gin.Context) {
 // @Security BearerAuth
 // @Param images formData file true "圖片文件"
 // @Success 200 {object} map[string]interface{}
-// @Failure 400 {object} map[string]interface{}
-// @Failure 401 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
 // @Router /api/v1/reviews/images [post]
 func (cc *CourtController) UploadReviewImages(c *gin.Context) {
 	_, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "用戶未認證",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
 		return
 	}
 
 	// 獲取上傳的文件
 	form, err := c.MultipartForm()
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "獲取上傳文件失敗",
-		})
+		apperror.Write(c, apperror.Wrap(apperror.CodeUploadInvalidForm, err))
 		return
 	}
 
 	files := form.File["images"]
 	if len(files) == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "未找到上傳文件",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeUploadMissingFile))
 		return
 	}
 
@@ -750,9 +687,7 @@ func (cc *CourtController) UploadReviewImages(c *gin.Context) {
 	for _, file := range files {
 		uploadResult, err := cc.uploadService.UploadFile(file, "reviews")
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error": err.Error(),
-			})
+			apperror.Write(c, err)
 			return
 		}
 
@@ -769,45 +704,103 @@ func (cc *CourtController) UploadReviewImages(c *gin.Context) {
 
 // CreateBooking 創建場地預訂
 // @Summary 創建場地預訂
-// @Description 為指定場地創建預訂
+// @Description 為指定場地創建預訂，場地設置球場時可指定球場，未指定時自動分配可用的球場；提供 holdId 時使用先前保留的時段，提供 addOns 時一併租借場地的器材並計入總價，提供 promoCode 時從價格扣除優惠碼折扣。時段已被預訂時返回 409 及建議的替代時段
 // @Tags bookings
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param request body dto.CreateBookingRequest true "創建預訂請求"
 // @Success 201 {object} models.Booking
-// @Failure 400 {object} map[string]interface{}
-// @Failure 401 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Failure 409 {object} apperror.Problem
+// @Failure 422 {object} apperror.Problem
 // @Router /api/v1/bookings [post]
 func (cc *CourtController) CreateBooking(c *gin.Context) {
 	userID, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "用戶未認證",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
 		return
 	}
 
 	var req dto.CreateBookingRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "請求參數錯誤",
-			"details": err.Error(),
-		})
+		apperror.Write(c, apperror.Validation(err))
 		return
 	}
 
 	booking, err := cc.bookingUsecase.CreateBooking(userID.(string), &req)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
 	c.JSON(http.StatusCreated, booking)
 }
 
+// HoldSlot 保留時段
+// @Summary 保留時段
+// @Description 結帳期間暫時保留時段（默認 10 分鐘），期間其他用戶無法預訂；以返回的 ID 作為創建預訂的 holdId。時段已被預訂時返回 409 及建議的替代時段
+// @Tags bookings
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.CreateSlotHoldRequest true "保留時段請求"
+// @Success 201 {object} models.SlotHold
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Failure 409 {object} apperror.Problem
+// @Failure 422 {object} apperror.Problem
+// @Router /api/v1/bookings/holds [post]
+func (cc *CourtController) HoldSlot(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
+		return
+	}
+
+	var req dto.CreateSlotHoldRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperror.Write(c, apperror.Validation(err))
+		return
+	}
+
+	hold, err := cc.bookingUsecase.HoldSlot(userID.(string), &req)
+	if err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, hold)
+}
+
+// ReleaseHold 釋放保留的時段
+// @Summary 釋放保留的時段
+// @Description 放棄結帳時提前釋放保留的時段
+// @Tags bookings
+// @Security BearerAuth
+// @Param holdId path string true "保留ID"
+// @Success 204
+// @Failure 401 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Router /api/v1/bookings/holds/{holdId} [delete]
+func (cc *CourtController) ReleaseHold(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
+		return
+	}
+
+	if err := cc.bookingUsecase.ReleaseHold(userID.(string), c.Param("holdId")); err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
 // GetBooking 獲取預訂詳情
 // @Summary 獲取預訂詳情
 // @Description 根據ID獲取預訂的詳細信息
@@ -816,122 +809,680 @@ func (cc *CourtController) CreateBooking(c *gin.Context) {
 // @Produce json
 // @Security BearerAuth
 // @Param id path string true "預訂ID"
+// @Param If-None-Match header string false "先前取得的 ETag，相符時返回 304"
 // @Success 200 {object} models.Booking
-// @Failure 404 {object} map[string]interface{}
+// @Success 304
+// @Failure 404 {object} apperror.Problem
 // @Router /api/v1/bookings/{id} [get]
 func (cc *CourtController) GetBooking(c *gin.Context) {
 	bookingID := c.Param("id")
 	if bookingID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "預訂ID不能為空",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeMissingParam).With("name", "id"))
 		return
 	}
 
 	booking, err := cc.bookingUsecase.GetBooking(bookingID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, booking)
+	etag.JSON(c, http.StatusOK, booking.Version, booking)
 }
 
 // UpdateBooking 更新預訂
 // @Summary 更新預訂
-// @Description 更新預訂信息
+// @Description 修改預訂的時間及備註；預訂狀態不能直接修改，付款完成後自動確認，取消請使用取消預訂
 // @Tags bookings
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param id path string true "預訂ID"
 // @Param request body dto.UpdateBookingRequest true "更新預訂請求"
+// @Param If-Match header string false "資源的 ETag，不符時返回 412"
 // @Success 200 {object} models.Booking
-// @Failure 400 {object} map[string]interface{}
-// @Failure 401 {object} map[string]interface{}
-// @Failure 404 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Failure 412 {object} apperror.Problem
 // @Router /api/v1/bookings/{id} [put]
 func (cc *CourtController) UpdateBooking(c *gin.Context) {
 	userID, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "用戶未認證",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
 		return
 	}
 
 	bookingID := c.Param("id")
 	if bookingID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "預訂ID不能為空",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeMissingParam).With("name", "id"))
+		return
+	}
+
+	expectedVersion, err := etag.IfMatchVersion(c)
+	if err != nil {
+		apperror.Write(c, err)
 		return
 	}
 
 	var req dto.UpdateBookingRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "請求參數錯誤",
-			"details": err.Error(),
-		})
+		apperror.Write(c, apperror.Validation(err))
+		return
+	}
+
+	booking, err := cc.bookingUsecase.UpdateBooking(bookingID, userID.(string), &req, expectedVersion)
+	if err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	etag.JSON(c, http.StatusOK, booking.Version, booking)
+}
+
+// UpdateBookingAddOns 修改預訂租借的器材
+// @Summary 修改預訂租借的器材
+// @Description 以請求內容替換預訂租借的器材並重新計算總價，只限未付款的單次預訂；傳入空的 addOns 取消全部租借
+// @Tags bookings
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "預訂ID"
+// @Param request body dto.UpdateBookingAddOnsRequest true "租借的器材"
+// @Success 200 {object} models.Booking
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Failure 409 {object} apperror.Problem
+// @Failure 422 {object} apperror.Problem
+// @Router /api/v1/bookings/{id}/add-ons [put]
+func (cc *CourtController) UpdateBookingAddOns(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
+		return
+	}
+
+	var req dto.UpdateBookingAddOnsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperror.Write(c, apperror.Validation(err))
 		return
 	}
 
-	booking, err := cc.bookingUsecase.UpdateBooking(bookingID, userID.(string), &req)
+	booking, err := cc.bookingUsecase.UpdateBookingAddOns(c.Param("id"), userID.(string), &req)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, booking)
 }
 
+// GetCancellationQuote 獲取取消預訂的退款報價
+// @Summary 獲取取消預訂的退款報價
+// @Description 依場地取消政策及天候、不可抗力例外，計算此刻取消是否允許及可退還的金額；allowed 為 false 表示已超過取消期限
+// @Tags bookings
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "預訂ID"
+// @Success 200 {object} services.CancellationQuote
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Failure 409 {object} apperror.Problem
+// @Router /api/v1/bookings/{id}/cancellation-quote [get]
+func (cc *CourtController) GetCancellationQuote(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
+		return
+	}
+
+	quote, err := cc.bookingUsecase.GetCancellationQuote(c.Param("id"), userID.(string))
+	if err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, quote)
+}
+
 // CancelBooking 取消預訂
 // @Summary 取消預訂
-// @Description 取消指定的預訂
+// @Description 依場地取消政策取消預訂並退還已付款項的可退金額；提供 expectedRefundAmount 時，與取消當下的退款金額不符會返回 409 且不取消
 // @Tags bookings
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param id path string true "預訂ID"
-// @Success 200 {object} map[string]interface{}
-// @Failure 400 {object} map[string]interface{}
-// @Failure 401 {object} map[string]interface{}
-// @Failure 404 {object} map[string]interface{}
+// @Param request body dto.CancelBookingRequest false "取消預訂請求"
+// @Success 200 {object} dto.CancelBookingResponse
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Failure 409 {object} apperror.Problem
+// @Failure 422 {object} apperror.Problem
 // @Router /api/v1/bookings/{id}/cancel [post]
 func (cc *CourtController) CancelBooking(c *gin.Context) {
 	userID, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "用戶未認證",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
 		return
 	}
 
 	bookingID := c.Param("id")
 	if bookingID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "預訂ID不能為空",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeMissingParam).With("name", "id"))
 		return
 	}
 
-	if err := cc.bookingUsecase.CancelBooking(bookingID, userID.(string)); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+	// 請求體可省略，保持與舊客戶端相容
+	var req dto.CancelBookingRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			apperror.Write(c, apperror.Validation(err))
+			return
+		}
+	}
+
+	cancellation, err := cc.bookingUsecase.CancelBooking(bookingID, userID.(string), &req)
+	if err != nil {
+		apperror.Write(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"message": "預訂取消成功",
+	c.JSON(http.StatusOK, dto.CancelBookingResponse{
+		Message:      "預訂取消成功",
+		Cancellation: cancellation,
 	})
 }
 
+// CreateBookingSeries 創建重複預訂
+// @Summary 創建重複預訂
+// @Description 依重複規則（例如每週二）及結束日期或次數一次預訂多個日期，所有日期先檢查衝突；有日期無法預訂時返回 409 及各日期的失敗原因，skipConflicts 為 true 時只預訂其餘日期
+// @Tags bookings
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.CreateBookingSeriesRequest true "創建重複預訂請求"
+// @Success 201 {object} dto.BookingSeriesResponse
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Failure 409 {object} apperror.Problem
+// @Router /api/v1/bookings/series [post]
+func (cc *CourtController) CreateBookingSeries(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
+		return
+	}
+
+	var req dto.CreateBookingSeriesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperror.Write(c, apperror.Validation(err))
+		return
+	}
+
+	series, err := cc.bookingUsecase.CreateBookingSeries(userID.(string), &req)
+	if err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, series)
+}
+
+// GetBookingSeries 獲取重複預訂
+// @Summary 獲取重複預訂
+// @Description 獲取重複預訂及按時間排序的各次預訂
+// @Tags bookings
+// @Produce json
+// @Security BearerAuth
+// @Param seriesId path string true "重複預訂ID"
+// @Success 200 {object} dto.BookingSeriesResponse
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Router /api/v1/bookings/series/{seriesId} [get]
+func (cc *CourtController) GetBookingSeries(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
+		return
+	}
+
+	series, err := cc.bookingUsecase.GetBookingSeries(c.Param("seriesId"), userID.(string))
+	if err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, series)
+}
+
+// UpdateBookingSeries 修改重複預訂
+// @Summary 修改重複預訂
+// @Description 修改所選的一次（this）、所選及之後（following）或所有尚未開始（all）的預訂；變更時間時各次按所選預訂的位移調整，任何一次無法修改時返回 409 且不修改
+// @Tags bookings
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param seriesId path string true "重複預訂ID"
+// @Param request body dto.UpdateBookingSeriesRequest true "修改重複預訂請求"
+// @Success 200 {object} dto.BookingSeriesResponse
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Failure 409 {object} apperror.Problem
+// @Failure 412 {object} apperror.Problem
+// @Router /api/v1/bookings/series/{seriesId} [put]
+func (cc *CourtController) UpdateBookingSeries(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
+		return
+	}
+
+	var req dto.UpdateBookingSeriesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperror.Write(c, apperror.Validation(err))
+		return
+	}
+
+	series, err := cc.bookingUsecase.UpdateBookingSeries(c.Param("seriesId"), userID.(string), &req)
+	if err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, series)
+}
+
+// CancelBookingSeries 取消重複預訂
+// @Summary 取消重複預訂
+// @Description 依場地取消政策取消所選的一次、所選及之後或所有尚未開始的預訂；已過取消期限的預訂略過並列於 skipped
+// @Tags bookings
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param seriesId path string true "重複預訂ID"
+// @Param request body dto.CancelBookingSeriesRequest true "取消重複預訂請求"
+// @Success 200 {object} dto.CancelBookingSeriesResponse
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Failure 409 {object} apperror.Problem
+// @Failure 422 {object} apperror.Problem
+// @Router /api/v1/bookings/series/{seriesId}/cancel [post]
+func (cc *CourtController) CancelBookingSeries(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
+		return
+	}
+
+	var req dto.CancelBookingSeriesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperror.Write(c, apperror.Validation(err))
+		return
+	}
+
+	result, err := cc.bookingUsecase.CancelBookingSeries(c.Param("seriesId"), userID.(string), &req)
+	if err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// JoinWaitlist 加入候補
+// @Summary 加入候補
+// @Description 時間範圍內沒有可預訂的時段時加入候補；時段因取消而釋出時依加入順序為候補者保留並發送限時認領通知
+// @Tags bookings
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.JoinWaitlistRequest true "加入候補請求"
+// @Success 201 {object} models.BookingWaitlistEntry
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Failure 409 {object} apperror.Problem
+// @Router /api/v1/bookings/waitlist [post]
+func (cc *CourtController) JoinWaitlist(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
+		return
+	}
+
+	var req dto.JoinWaitlistRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperror.Write(c, apperror.Validation(err))
+		return
+	}
+
+	entry, err := cc.bookingUsecase.JoinWaitlist(userID.(string), &req)
+	if err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, entry)
+}
+
+// GetWaitlist 獲取我的候補
+// @Summary 獲取我的候補
+// @Description 獲取當前用戶的候補，默認只列出等待中及已提供時段的候補
+// @Tags bookings
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "候補狀態" Enums(waiting, offered, claimed, expired, cancelled)
+// @Success 200 {array} models.BookingWaitlistEntry
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Router /api/v1/bookings/waitlist [get]
+func (cc *CourtController) GetWaitlist(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
+		return
+	}
+
+	var req dto.WaitlistListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		apperror.Write(c, apperror.Validation(err))
+		return
+	}
+
+	entries, err := cc.bookingUsecase.GetWaitlist(userID.(string), &req)
+	if err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
+
+// GetWaitlistEntry 獲取候補
+// @Summary 獲取候補
+// @Description 獲取候補詳情，已提供時段時包含保留的時段及認領期限
+// @Tags bookings
+// @Produce json
+// @Security BearerAuth
+// @Param entryId path string true "候補ID"
+// @Success 200 {object} models.BookingWaitlistEntry
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Router /api/v1/bookings/waitlist/{entryId} [get]
+func (cc *CourtController) GetWaitlistEntry(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
+		return
+	}
+
+	entry, err := cc.bookingUsecase.GetWaitlistEntry(c.Param("entryId"), userID.(string))
+	if err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, entry)
+}
+
+// LeaveWaitlist 退出候補
+// @Summary 退出候補
+// @Description 退出候補；已提供的時段改為提供給下一位候補者
+// @Tags bookings
+// @Security BearerAuth
+// @Param entryId path string true "候補ID"
+// @Success 204
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Failure 409 {object} apperror.Problem
+// @Router /api/v1/bookings/waitlist/{entryId} [delete]
+func (cc *CourtController) LeaveWaitlist(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
+		return
+	}
+
+	if err := cc.bookingUsecase.LeaveWaitlist(c.Param("entryId"), userID.(string)); err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ClaimWaitlistOffer 認領候補時段
+// @Summary 認領候補時段
+// @Description 在認領期限內以提供的保留創建預訂
+// @Tags bookings
+// @Produce json
+// @Security BearerAuth
+// @Param entryId path string true "候補ID"
+// @Success 201 {object} models.Booking
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Failure 409 {object} apperror.Problem
+// @Router /api/v1/bookings/waitlist/{entryId}/claim [post]
+func (cc *CourtController) ClaimWaitlistOffer(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
+		return
+	}
+
+	booking, err := cc.bookingUsecase.ClaimWaitlistOffer(c.Param("entryId"), userID.(string))
+	if err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, booking)
+}
+
+// CreateBookingSplit 分攤預訂費用
+// @Summary 分攤預訂費用
+// @Description 預訂者邀請其他用戶或比賽參與者分攤尚未付款的預訂，各付款人以 booking_share 付款；全部付清後確認預訂，期限前未付清時依 fallback 取消預訂或改由預訂者支付
+// @Tags bookings
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "預訂ID"
+// @Param request body dto.CreateBookingSplitRequest true "分攤付款請求"
+// @Success 201 {object} dto.BookingSplitResponse
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Failure 409 {object} apperror.Problem
+// @Router /api/v1/bookings/{id}/split [post]
+func (cc *CourtController) CreateBookingSplit(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
+		return
+	}
+
+	var req dto.CreateBookingSplitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperror.Write(c, apperror.Validation(err))
+		return
+	}
+
+	split, err := cc.bookingUsecase.CreateBookingSplit(c.Param("id"), userID.(string), &req)
+	if err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, split)
+}
+
+// GetBookingSplit 獲取預訂的分攤付款
+// @Summary 獲取預訂的分攤付款
+// @Description 獲取預訂最近一次的分攤付款及各分攤的付款狀態，預訂者及分攤的付款人可查看
+// @Tags bookings
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "預訂ID"
+// @Success 200 {object} dto.BookingSplitResponse
+// @Failure 401 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Router /api/v1/bookings/{id}/split [get]
+func (cc *CourtController) GetBookingSplit(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
+		return
+	}
+
+	split, err := cc.bookingUsecase.GetBookingSplit(c.Param("id"), userID.(string))
+	if err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, split)
+}
+
+// CancelBookingSplit 取消分攤付款
+// @Summary 取消分攤付款
+// @Description 取消進行中的分攤付款，預訂恢復由預訂者一次付款；已有分攤完成付款時不可取消
+// @Tags bookings
+// @Security BearerAuth
+// @Param id path string true "預訂ID"
+// @Success 204
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Failure 409 {object} apperror.Problem
+// @Router /api/v1/bookings/{id}/split [delete]
+func (cc *CourtController) CancelBookingSplit(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
+		return
+	}
+
+	if err := cc.bookingUsecase.CancelBookingSplit(c.Param("id"), userID.(string)); err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetPaymentShares 獲取我的分攤
+// @Summary 獲取我的分攤
+// @Description 獲取當前用戶需支付的分攤，默認只列出待付款的分攤
+// @Tags bookings
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "分攤狀態" Enums(pending, paid, reassigned, cancelled)
+// @Success 200 {array} models.BookingPaymentShare
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Router /api/v1/bookings/payment-shares [get]
+func (cc *CourtController) GetPaymentShares(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
+		return
+	}
+
+	var req dto.PaymentShareListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		apperror.Write(c, apperror.Validation(err))
+		return
+	}
+
+	shares, err := cc.bookingUsecase.GetPaymentShares(userID.(string), &req)
+	if err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, shares)
+}
+
+// GetCheckInPass 獲取預訂的報到碼
+// @Summary 獲取預訂的報到碼
+// @Description 預訂者獲取已確認預訂的報到碼，以 payload 生成 QR code 供場地掃描
+// @Tags bookings
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "預訂ID"
+// @Success 200 {object} dto.CheckInPassResponse
+// @Failure 401 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Failure 409 {object} apperror.Problem
+// @Router /api/v1/bookings/{id}/check-in-pass [get]
+func (cc *CourtController) GetCheckInPass(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
+		return
+	}
+
+	pass, err := cc.bookingUsecase.GetCheckInPass(c.Param("id"), userID.(string))
+	if err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, pass)
+}
+
+// CheckIn 掃描報到碼報到
+// @Summary 掃描報到碼報到
+// @Description 場地擁有者或以其帳號登入的自助報到機掃描預訂者的報到碼，記錄預訂的報到時間
+// @Tags bookings
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.CheckInRequest true "報到請求"
+// @Success 200 {object} models.Booking
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 409 {object} apperror.Problem
+// @Router /api/v1/bookings/check-in [post]
+func (cc *CourtController) CheckIn(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
+		return
+	}
+
+	var req dto.CheckInRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperror.Write(c, apperror.Validation(err))
+		return
+	}
+
+	booking, err := cc.bookingUsecase.CheckIn(userID.(string), &req)
+	if err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, booking)
+}
+
 // GetBookings 獲取預訂列表
 // @Summary 獲取預訂列表
 // @Description 根據條件獲取預訂列表
@@ -944,26 +1495,22 @@ func (cc *CourtController) CancelBooking(c *gin.Context) {
 // @Param status query string false "預訂狀態" Enums(pending,confirmed,cancelled,completed)
 // @Param startDate query string false "開始日期 (YYYY-MM-DD)"
 // @Param endDate query string false "結束日期 (YYYY-MM-DD)"
+// @Param cursor query string false "分頁游標，提供時忽略 page"
 // @Param page query int false "頁碼"
 // @Param pageSize query int false "每頁數量"
 // @Success 200 {object} dto.BookingListResponse
-// @Failure 400 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
 // @Router /api/v1/bookings [get]
 func (cc *CourtController) GetBookings(c *gin.Context) {
 	var req dto.BookingListRequest
 	if err := c.ShouldBindQuery(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "請求參數錯誤",
-			"details": err.Error(),
-		})
+		apperror.Write(c, apperror.Validation(err))
 		return
 	}
 
 	response, err := cc.bookingUsecase.GetBookings(&req)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
@@ -972,31 +1519,29 @@ func (cc *CourtController) GetBookings(c *gin.Context) {
 
 // GetCourtAvailability 獲取場地可用時間
 // @Summary 獲取場地可用時間
-// @Description 查詢指定場地在指定日期的可用時間段
+// @Description 查詢指定場地在指定日期的可用時間段，場地設置球場時返回各球場是否可預訂
 // @Tags bookings
 // @Accept json
 // @Produce json
 // @Param courtId query string true "場地ID"
+// @Param courtUnitId query string false "球場ID，只查詢指定球場"
 // @Param date query string true "查詢日期 (YYYY-MM-DD)"
 // @Param duration query int false "預訂時長（分鐘），默認60分鐘"
 // @Success 200 {object} dto.AvailabilityResponse
-// @Failure 400 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Failure 422 {object} apperror.Problem
 // @Router /api/v1/courts/availability [get]
 func (cc *CourtController) GetCourtAvailability(c *gin.Context) {
 	var req dto.AvailabilityRequest
 	if err := c.ShouldBindQuery(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "請求參數錯誤",
-			"details": err.Error(),
-		})
+		apperror.Write(c, apperror.Validation(err))
 		return
 	}
 
 	response, err := cc.bookingUsecase.GetAvailability(&req)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
@@ -0,0 +1,162 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"tennis-platform/backend/internal/apperror"
+	"tennis-platform/backend/internal/dto"
+	"tennis-platform/backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CourtEquipmentUsecaseInterface 租借器材用例接口
+type CourtEquipmentUsecaseInterface interface {
+	GetEquipment(ctx context.Context, courtID string, req *dto.CourtEquipmentListRequest) ([]dto.CourtEquipmentResponse, error)
+	CreateEquipment(ctx context.Context, userID, courtID string, req *dto.CourtEquipmentRequest) (*models.CourtEquipment, error)
+	UpdateEquipment(ctx context.Context, userID, courtID, equipmentID string, req *dto.CourtEquipmentRequest) (*models.CourtEquipment, error)
+	DeleteEquipment(ctx context.Context, userID, courtID, equipmentID string) error
+}
+
+// CourtEquipmentController 租借器材控制器
+type CourtEquipmentController struct {
+	courtEquipmentUsecase CourtEquipmentUsecaseInterface
+}
+
+// NewCourtEquipmentController 創建新的租借器材控制器
+func NewCourtEquipmentController(courtEquipmentUsecase CourtEquipmentUsecaseInterface) *CourtEquipmentController {
+	return &CourtEquipmentController{
+		courtEquipmentUsecase: courtEquipmentUsecase,
+	}
+}
+
+// GetEquipment 獲取場地的租借器材
+// @Summary 獲取場地的租借器材
+// @Description 返回場地的所有租借器材（包含停用的器材），按名稱排序；提供時段時返回各器材在時段內的可租借數量
+// @Tags courts
+// @Produce json
+// @Param id path string true "場地ID"
+// @Param startTime query string false "開始時間 (RFC3339)"
+// @Param endTime query string false "結束時間 (RFC3339)"
+// @Success 200 {array} dto.CourtEquipmentResponse
+// @Failure 400 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Router /api/v1/courts/{id}/equipment [get]
+func (ec *CourtEquipmentController) GetEquipment(c *gin.Context) {
+	var req dto.CourtEquipmentListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		apperror.Write(c, apperror.Validation(err))
+		return
+	}
+
+	equipment, err := ec.courtEquipmentUsecase.GetEquipment(c.Request.Context(), c.Param("id"), &req)
+	if err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, equipment)
+}
+
+// CreateEquipment 創建場地的租借器材
+// @Summary 創建場地的租借器材
+// @Description 場地擁有者新增可隨預訂租借的器材，例如球拍、球籃及發球機，設定庫存數量及每次或每小時的租金
+// @Tags courts
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "場地ID"
+// @Param request body dto.CourtEquipmentRequest true "租借器材"
+// @Success 201 {object} models.CourtEquipment
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Router /api/v1/courts/{id}/equipment [post]
+func (ec *CourtEquipmentController) CreateEquipment(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
+		return
+	}
+
+	var req dto.CourtEquipmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperror.Write(c, apperror.Validation(err))
+		return
+	}
+
+	item, err := ec.courtEquipmentUsecase.CreateEquipment(c.Request.Context(), userID.(string), c.Param("id"), &req)
+	if err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, item)
+}
+
+// UpdateEquipment 更新場地的租借器材
+// @Summary 更新場地的租借器材
+// @Description 以請求內容替換器材資料，價格、庫存及停用只影響之後的租借，已租借的項目不受影響
+// @Tags courts
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "場地ID"
+// @Param equipmentId path string true "器材ID"
+// @Param request body dto.CourtEquipmentRequest true "租借器材"
+// @Success 200 {object} models.CourtEquipment
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Router /api/v1/courts/{id}/equipment/{equipmentId} [put]
+func (ec *CourtEquipmentController) UpdateEquipment(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
+		return
+	}
+
+	var req dto.CourtEquipmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperror.Write(c, apperror.Validation(err))
+		return
+	}
+
+	item, err := ec.courtEquipmentUsecase.UpdateEquipment(c.Request.Context(), userID.(string), c.Param("id"), c.Param("equipmentId"), &req)
+	if err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, item)
+}
+
+// DeleteEquipment 刪除場地的租借器材
+// @Summary 刪除場地的租借器材
+// @Description 器材還有未結束的租借時無法刪除，可改為停用
+// @Tags courts
+// @Security BearerAuth
+// @Param id path string true "場地ID"
+// @Param equipmentId path string true "器材ID"
+// @Success 204
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Failure 409 {object} apperror.Problem
+// @Router /api/v1/courts/{id}/equipment/{equipmentId} [delete]
+func (ec *CourtEquipmentController) DeleteEquipment(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
+		return
+	}
+
+	if err := ec.courtEquipmentUsecase.DeleteEquipment(c.Request.Context(), userID.(string), c.Param("id"), c.Param("equipmentId")); err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
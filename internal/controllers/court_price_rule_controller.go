@@ -0,0 +1,152 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"tennis-platform/backend/internal/apperror"
+	"tennis-platform/backend/internal/dto"
+	"tennis-platform/backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CourtPriceRuleUsecaseInterface 場地價格規則用例接口
+type CourtPriceRuleUsecaseInterface interface {
+	GetRules(ctx context.Context, courtID string) ([]models.CourtPriceRule, error)
+	CreateRule(ctx context.Context, userID, courtID string, req *dto.CourtPriceRuleRequest) (*models.CourtPriceRule, error)
+	UpdateRule(ctx context.Context, userID, courtID, ruleID string, req *dto.CourtPriceRuleRequest) (*models.CourtPriceRule, error)
+	DeleteRule(ctx context.Context, userID, courtID, ruleID string) error
+}
+
+// CourtPriceRuleController 場地價格規則控制器
+type CourtPriceRuleController struct {
+	courtPriceRuleUsecase CourtPriceRuleUsecaseInterface
+}
+
+// NewCourtPriceRuleController 創建新的場地價格規則控制器
+func NewCourtPriceRuleController(courtPriceRuleUsecase CourtPriceRuleUsecaseInterface) *CourtPriceRuleController {
+	return &CourtPriceRuleController{
+		courtPriceRuleUsecase: courtPriceRuleUsecase,
+	}
+}
+
+// GetRules 獲取場地的價格規則
+// @Summary 獲取場地的價格規則
+// @Description 返回場地的所有價格規則（包含停用的規則），按優先級由高到低排序
+// @Tags courts
+// @Produce json
+// @Param id path string true "場地ID"
+// @Success 200 {array} models.CourtPriceRule
+// @Failure 404 {object} apperror.Problem
+// @Router /api/v1/courts/{id}/price-rules [get]
+func (pc *CourtPriceRuleController) GetRules(c *gin.Context) {
+	rules, err := pc.courtPriceRuleUsecase.GetRules(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, rules)
+}
+
+// CreateRule 創建場地的價格規則
+// @Summary 創建場地的價格規則
+// @Description 場地擁有者按星期、時段、日期範圍及會員資格設定每小時價格或附加費，只影響之後的預訂
+// @Tags courts
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "場地ID"
+// @Param request body dto.CourtPriceRuleRequest true "價格規則"
+// @Success 201 {object} models.CourtPriceRule
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Router /api/v1/courts/{id}/price-rules [post]
+func (pc *CourtPriceRuleController) CreateRule(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
+		return
+	}
+
+	var req dto.CourtPriceRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperror.Write(c, apperror.Validation(err))
+		return
+	}
+
+	rule, err := pc.courtPriceRuleUsecase.CreateRule(c.Request.Context(), userID.(string), c.Param("id"), &req)
+	if err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, rule)
+}
+
+// UpdateRule 更新場地的價格規則
+// @Summary 更新場地的價格規則
+// @Description 以請求內容替換整條規則，已預訂的價格明細不受影響
+// @Tags courts
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "場地ID"
+// @Param ruleId path string true "價格規則ID"
+// @Param request body dto.CourtPriceRuleRequest true "價格規則"
+// @Success 200 {object} models.CourtPriceRule
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Router /api/v1/courts/{id}/price-rules/{ruleId} [put]
+func (pc *CourtPriceRuleController) UpdateRule(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
+		return
+	}
+
+	var req dto.CourtPriceRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperror.Write(c, apperror.Validation(err))
+		return
+	}
+
+	rule, err := pc.courtPriceRuleUsecase.UpdateRule(c.Request.Context(), userID.(string), c.Param("id"), c.Param("ruleId"), &req)
+	if err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+// DeleteRule 刪除場地的價格規則
+// @Summary 刪除場地的價格規則
+// @Description 刪除後不再適用於之後的預訂，已預訂的價格明細不受影響
+// @Tags courts
+// @Security BearerAuth
+// @Param id path string true "場地ID"
+// @Param ruleId path string true "價格規則ID"
+// @Success 204
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Router /api/v1/courts/{id}/price-rules/{ruleId} [delete]
+func (pc *CourtPriceRuleController) DeleteRule(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
+		return
+	}
+
+	if err := pc.courtPriceRuleUsecase.DeleteRule(c.Request.Context(), userID.(string), c.Param("id"), c.Param("ruleId")); err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
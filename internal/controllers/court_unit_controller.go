@@ -0,0 +1,155 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"tennis-platform/backend/internal/apperror"
+	"tennis-platform/backend/internal/dto"
+	"tennis-platform/backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CourtUnitUsecaseInterface 場地球場用例接口
+type CourtUnitUsecaseInterface interface {
+	GetUnits(ctx context.Context, courtID string) ([]models.CourtUnit, error)
+	CreateUnit(ctx context.Context, userID, courtID string, req *dto.CourtUnitRequest) (*models.CourtUnit, error)
+	UpdateUnit(ctx context.Context, userID, courtID, unitID string, req *dto.CourtUnitRequest) (*models.CourtUnit, error)
+	DeleteUnit(ctx context.Context, userID, courtID, unitID string) error
+}
+
+// CourtUnitController 場地球場控制器
+type CourtUnitController struct {
+	courtUnitUsecase CourtUnitUsecaseInterface
+}
+
+// NewCourtUnitController 創建新的場地球場控制器
+func NewCourtUnitController(courtUnitUsecase CourtUnitUsecaseInterface) *CourtUnitController {
+	return &CourtUnitController{
+		courtUnitUsecase: courtUnitUsecase,
+	}
+}
+
+// GetUnits 獲取場地的球場
+// @Summary 獲取場地的球場
+// @Description 返回場地內的所有球場（包含停用的球場），按編號排序
+// @Tags courts
+// @Produce json
+// @Param id path string true "場地ID"
+// @Success 200 {array} models.CourtUnit
+// @Failure 404 {object} apperror.Problem
+// @Router /api/v1/courts/{id}/units [get]
+func (uc *CourtUnitController) GetUnits(c *gin.Context) {
+	units, err := uc.courtUnitUsecase.GetUnits(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, units)
+}
+
+// CreateUnit 創建場地的球場
+// @Summary 創建場地的球場
+// @Description 場地擁有者新增可單獨預訂的球場，設置球場後預訂可指定球場或由系統自動分配
+// @Tags courts
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "場地ID"
+// @Param request body dto.CourtUnitRequest true "球場"
+// @Success 201 {object} models.CourtUnit
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Failure 409 {object} apperror.Problem
+// @Router /api/v1/courts/{id}/units [post]
+func (uc *CourtUnitController) CreateUnit(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
+		return
+	}
+
+	var req dto.CourtUnitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperror.Write(c, apperror.Validation(err))
+		return
+	}
+
+	unit, err := uc.courtUnitUsecase.CreateUnit(c.Request.Context(), userID.(string), c.Param("id"), &req)
+	if err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, unit)
+}
+
+// UpdateUnit 更新場地的球場
+// @Summary 更新場地的球場
+// @Description 以請求內容替換球場資料，停用後不再開放預訂，已有的預訂不受影響
+// @Tags courts
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "場地ID"
+// @Param unitId path string true "球場ID"
+// @Param request body dto.CourtUnitRequest true "球場"
+// @Success 200 {object} models.CourtUnit
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Failure 409 {object} apperror.Problem
+// @Router /api/v1/courts/{id}/units/{unitId} [put]
+func (uc *CourtUnitController) UpdateUnit(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
+		return
+	}
+
+	var req dto.CourtUnitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperror.Write(c, apperror.Validation(err))
+		return
+	}
+
+	unit, err := uc.courtUnitUsecase.UpdateUnit(c.Request.Context(), userID.(string), c.Param("id"), c.Param("unitId"), &req)
+	if err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, unit)
+}
+
+// DeleteUnit 刪除場地的球場
+// @Summary 刪除場地的球場
+// @Description 球場還有未結束的預訂時無法刪除，可改為停用
+// @Tags courts
+// @Security BearerAuth
+// @Param id path string true "場地ID"
+// @Param unitId path string true "球場ID"
+// @Success 204
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Failure 409 {object} apperror.Problem
+// @Router /api/v1/courts/{id}/units/{unitId} [delete]
+func (uc *CourtUnitController) DeleteUnit(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
+		return
+	}
+
+	if err := uc.courtUnitUsecase.DeleteUnit(c.Request.Context(), userID.(string), c.Param("id"), c.Param("unitId")); err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -5,8 +5,10 @@ import (
 	"strconv"
 	"time"
 
+	"tennis-platform/backend/internal/apperror"
 	"tennis-platform/backend/internal/dto"
 	"tennis-platform/backend/internal/models"
+	"tennis-platform/backend/internal/pagination"
 	"tennis-platform/backend/internal/services"
 	"tennis-platform/backend/internal/usecases"
 
@@ -33,24 +35,20 @@ func NewDiscoveryController(matchingUsecase *usecases.MatchingUsecase) *Discover
 // @Produce json
 // @Param request body dto.FindMatchesRequest true "配對條件"
 // @Success 200 {object} map[string]interface{} "配對結果"
-// @Failure 400 {object} map[string]interface{} "請求錯誤"
-// @Failure 401 {object} map[string]interface{} "未授權"
-// @Failure 500 {object} map[string]interface{} "伺服器錯誤"
+// @Failure 400 {object} apperror.Problem "請求錯誤"
+// @Failure 401 {object} apperror.Problem "未授權"
+// @Failure 500 {object} apperror.Problem "伺服器錯誤"
 // @Router /api/v1/discovery/find [post]
 func (c *DiscoveryController) FindMatches(ctx *gin.Context) {
 	userID, exists := ctx.Get("userID")
 	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{
-			"error": "User not authenticated",
-		})
+		apperror.Write(ctx, apperror.New(apperror.CodeUnauthorized))
 		return
 	}
 
 	var req dto.FindMatchesRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid request format",
-		})
+		apperror.Write(ctx, apperror.Validation(err))
 		return
 	}
 
@@ -77,9 +75,7 @@ func (c *DiscoveryController) FindMatches(ctx *gin.Context) {
 	// 尋找配對
 	results, err := c.matchingUsecase.FindMatches(ctx, userID.(string), criteria, req.Limit)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to find matches",
-		})
+		apperror.Write(ctx, err)
 		return
 	}
 
@@ -139,15 +135,13 @@ func (c *DiscoveryController) FindMatches(ctx *gin.Context) {
 // @Produce json
 // @Param count query int false "配對數量" default(5)
 // @Success 200 {object} map[string]interface{} "隨機配對結果"
-// @Failure 401 {object} map[string]interface{} "未授權"
-// @Failure 500 {object} map[string]interface{} "伺服器錯誤"
+// @Failure 401 {object} apperror.Problem "未授權"
+// @Failure 500 {object} apperror.Problem "伺服器錯誤"
 // @Router /api/v1/discovery/random [get]
 func (c *DiscoveryController) FindRandomMatches(ctx *gin.Context) {
 	userID, exists := ctx.Get("userID")
 	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{
-			"error": "User not authenticated",
-		})
+		apperror.Write(ctx, apperror.New(apperror.CodeUnauthorized))
 		return
 	}
 
@@ -169,9 +163,7 @@ func (c *DiscoveryController) FindRandomMatches(ctx *gin.Context) {
 	// 尋找隨機配對
 	results, err := c.matchingUsecase.FindRandomMatches(ctx, userID.(string), criteria, count)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to find random matches",
-		})
+		apperror.Write(ctx, err)
 		return
 	}
 
@@ -187,23 +179,19 @@ func (c *DiscoveryController) FindRandomMatches(ctx *gin.Context) {
 // @Tags discovery
 // @Produce json
 // @Success 200 {object} map[string]interface{} "信譽分數"
-// @Failure 401 {object} map[string]interface{} "未授權"
-// @Failure 500 {object} map[string]interface{} "伺服器錯誤"
+// @Failure 401 {object} apperror.Problem "未授權"
+// @Failure 500 {object} apperror.Problem "伺服器錯誤"
 // @Router /api/v1/discovery/reputation [get]
 func (c *DiscoveryController) GetReputationScore(ctx *gin.Context) {
 	userID, exists := ctx.Get("userID")
 	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{
-			"error": "User not authenticated",
-		})
+		apperror.Write(ctx, apperror.New(apperror.CodeUnauthorized))
 		return
 	}
 
 	reputation, err := c.matchingUsecase.GetUserReputationScore(ctx, userID.(string))
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to get reputation score",
-		})
+		apperror.Write(ctx, err)
 		return
 	}
 
@@ -217,54 +205,41 @@ func (c *DiscoveryController) GetReputationScore(ctx *gin.Context) {
 // @Description 獲取用戶的配對歷史記錄
 // @Tags discovery
 // @Produce json
+// @Param cursor query string false "分頁游標，提供時忽略 page"
 // @Param page query int false "頁碼" default(1)
 // @Param limit query int false "每頁數量" default(10)
 // @Success 200 {object} map[string]interface{} "配對歷史"
-// @Failure 401 {object} map[string]interface{} "未授權"
-// @Failure 500 {object} map[string]interface{} "伺服器錯誤"
+// @Failure 401 {object} apperror.Problem "未授權"
+// @Failure 500 {object} apperror.Problem "伺服器錯誤"
 // @Router /api/v1/discovery/history [get]
 func (c *DiscoveryController) GetMatchingHistory(ctx *gin.Context) {
 	userID, exists := ctx.Get("userID")
 	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{
-			"error": "User not authenticated",
-		})
+		apperror.Write(ctx, apperror.New(apperror.CodeUnauthorized))
 		return
 	}
 
 	// 獲取分頁參數
-	pageStr := ctx.DefaultQuery("page", "1")
-	limitStr := ctx.DefaultQuery("limit", "10")
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "10"))
 
-	page, err := strconv.Atoi(pageStr)
-	if err != nil || page <= 0 {
-		page = 1
+	pageQuery := pagination.Query{
+		Limit:  limit,
+		Page:   page,
+		Cursor: ctx.Query("cursor"),
 	}
-
-	limit, err := strconv.Atoi(limitStr)
-	if err != nil || limit <= 0 {
-		limit = 10
-	}
-	if limit > 50 {
-		limit = 50 // 限制最大數量
-	}
-
-	offset := (page - 1) * limit
+	pageQuery.Normalize(10, 50)
 
 	// 獲取配對歷史
-	matches, err := c.matchingUsecase.GetMatchingHistory(ctx, userID.(string), limit, offset)
+	matches, pageInfo, err := c.matchingUsecase.GetMatchingHistory(ctx, userID.(string), nil, &pageQuery)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to get matching history",
-		})
+		apperror.Write(ctx, err)
 		return
 	}
 
 	ctx.JSON(http.StatusOK, gin.H{
-		"matches": matches,
-		"page":    page,
-		"limit":   limit,
-		"total":   len(matches),
+		"matches":    matches,
+		"pagination": pageInfo,
 	})
 }
 
@@ -276,24 +251,20 @@ func (c *DiscoveryController) GetMatchingHistory(ctx *gin.Context) {
 // @Produce json
 // @Param request body dto.CreateMatchRequest true "配對資訊"
 // @Success 201 {object} map[string]interface{} "創建成功"
-// @Failure 400 {object} map[string]interface{} "請求錯誤"
-// @Failure 401 {object} map[string]interface{} "未授權"
-// @Failure 500 {object} map[string]interface{} "伺服器錯誤"
+// @Failure 400 {object} apperror.Problem "請求錯誤"
+// @Failure 401 {object} apperror.Problem "未授權"
+// @Failure 500 {object} apperror.Problem "伺服器錯誤"
 // @Router /api/v1/discovery/create [post]
 func (c *DiscoveryController) CreateMatch(ctx *gin.Context) {
 	userID, exists := ctx.Get("userID")
 	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{
-			"error": "User not authenticated",
-		})
+		apperror.Write(ctx, apperror.New(apperror.CodeUnauthorized))
 		return
 	}
 
 	var req dto.CreateMatchRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid request format",
-		})
+		apperror.Write(ctx, apperror.Validation(err))
 		return
 	}
 
@@ -304,9 +275,7 @@ func (c *DiscoveryController) CreateMatch(ctx *gin.Context) {
 		"tournament": true,
 	}
 	if !validTypes[req.MatchType] {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid match type",
-		})
+		apperror.Write(ctx, apperror.New(apperror.CodeMatchInvalidType))
 		return
 	}
 
@@ -325,9 +294,7 @@ func (c *DiscoveryController) CreateMatch(ctx *gin.Context) {
 		nil,
 	)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to create match",
-		})
+		apperror.Write(ctx, err)
 		return
 	}
 
@@ -342,23 +309,19 @@ func (c *DiscoveryController) CreateMatch(ctx *gin.Context) {
 // @Tags discovery
 // @Produce json
 // @Success 200 {object} map[string]interface{} "統計資訊"
-// @Failure 401 {object} map[string]interface{} "未授權"
-// @Failure 500 {object} map[string]interface{} "伺服器錯誤"
+// @Failure 401 {object} apperror.Problem "未授權"
+// @Failure 500 {object} apperror.Problem "伺服器錯誤"
 // @Router /api/v1/discovery/statistics [get]
 func (c *DiscoveryController) GetMatchingStatistics(ctx *gin.Context) {
 	userID, exists := ctx.Get("userID")
 	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{
-			"error": "User not authenticated",
-		})
+		apperror.Write(ctx, apperror.New(apperror.CodeUnauthorized))
 		return
 	}
 
 	stats, err := c.matchingUsecase.GetMatchingStatistics(ctx, userID.(string))
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to get statistics",
-		})
+		apperror.Write(ctx, err)
 		return
 	}
 
@@ -376,9 +339,9 @@ func (c *DiscoveryController) GetMatchingStatistics(ctx *gin.Context) {
 // @Param userID path string true "用戶ID"
 // @Param request body dto.UpdateReputationRequest true "信譽更新資訊"
 // @Success 200 {object} map[string]interface{} "更新成功"
-// @Failure 400 {object} map[string]interface{} "請求錯誤"
-// @Failure 401 {object} map[string]interface{} "未授權"
-// @Failure 500 {object} map[string]interface{} "伺服器錯誤"
+// @Failure 400 {object} apperror.Problem "請求錯誤"
+// @Failure 401 {object} apperror.Problem "未授權"
+// @Failure 500 {object} apperror.Problem "伺服器錯誤"
 // @Router /api/v1/discovery/reputation/{userID} [put]
 func (c *DiscoveryController) UpdateReputation(ctx *gin.Context) {
 	// 這個API通常只有系統或管理員可以調用
@@ -386,25 +349,19 @@ func (c *DiscoveryController) UpdateReputation(ctx *gin.Context) {
 
 	targetUserID := ctx.Param("userID")
 	if targetUserID == "" {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "User ID is required",
-		})
+		apperror.Write(ctx, apperror.New(apperror.CodeMissingParam).With("name", "userID"))
 		return
 	}
 
 	var req dto.UpdateReputationRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid request format",
-		})
+		apperror.Write(ctx, apperror.Validation(err))
 		return
 	}
 
 	// 驗證行為評分範圍
 	if req.BehaviorRating < 1.0 || req.BehaviorRating > 5.0 {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "Behavior rating must be between 1.0 and 5.0",
-		})
+		apperror.Write(ctx, apperror.New(apperror.CodeReputationInvalidRating))
 		return
 	}
 
@@ -417,9 +374,7 @@ func (c *DiscoveryController) UpdateReputation(ctx *gin.Context) {
 		req.BehaviorRating,
 	)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to update reputation",
-		})
+		apperror.Write(ctx, err)
 		return
 	}
 
@@ -436,24 +391,20 @@ func (c *DiscoveryController) UpdateReputation(ctx *gin.Context) {
 // @Produce json
 // @Param request body dto.CardActionRequest true "抽卡動作"
 // @Success 200 {object} map[string]interface{} "處理結果"
-// @Failure 400 {object} map[string]interface{} "請求錯誤"
-// @Failure 401 {object} map[string]interface{} "未授權"
-// @Failure 500 {object} map[string]interface{} "伺服器錯誤"
+// @Failure 400 {object} apperror.Problem "請求錯誤"
+// @Failure 401 {object} apperror.Problem "未授權"
+// @Failure 500 {object} apperror.Problem "伺服器錯誤"
 // @Router /api/v1/discovery/card-action [post]
 func (c *DiscoveryController) ProcessCardAction(ctx *gin.Context) {
 	userID, exists := ctx.Get("userID")
 	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{
-			"error": "User not authenticated",
-		})
+		apperror.Write(ctx, apperror.New(apperror.CodeUnauthorized))
 		return
 	}
 
 	var req dto.CardActionRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid request format",
-		})
+		apperror.Write(ctx, apperror.Validation(err))
 		return
 	}
 
@@ -464,17 +415,13 @@ func (c *DiscoveryController) ProcessCardAction(ctx *gin.Context) {
 		"skip":    true,
 	}
 	if !validActions[req.Action] {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid action type",
-		})
+		apperror.Write(ctx, apperror.New(apperror.CodeMatchCardInvalidAction))
 		return
 	}
 
 	// 不能對自己執行動作
 	if req.TargetUserID == userID.(string) {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "Cannot perform action on yourself",
-		})
+		apperror.Write(ctx, apperror.New(apperror.CodeMatchCardSelfAction))
 		return
 	}
 
@@ -486,9 +433,7 @@ func (c *DiscoveryController) ProcessCardAction(ctx *gin.Context) {
 		req.Action,
 	)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to process card action",
-		})
+		apperror.Write(ctx, err)
 		return
 	}
 
@@ -506,15 +451,13 @@ func (c *DiscoveryController) ProcessCardAction(ctx *gin.Context) {
 // @Param limit query int false "每頁數量" default(20)
 // @Param action query string false "動作類型篩選" Enums(like, dislike, skip)
 // @Success 200 {object} map[string]interface{} "互動歷史"
-// @Failure 401 {object} map[string]interface{} "未授權"
-// @Failure 500 {object} map[string]interface{} "伺服器錯誤"
+// @Failure 401 {object} apperror.Problem "未授權"
+// @Failure 500 {object} apperror.Problem "伺服器錯誤"
 // @Router /api/v1/discovery/card-history [get]
 func (c *DiscoveryController) GetCardInteractionHistory(ctx *gin.Context) {
 	userID, exists := ctx.Get("userID")
 	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{
-			"error": "User not authenticated",
-		})
+		apperror.Write(ctx, apperror.New(apperror.CodeUnauthorized))
 		return
 	}
 
@@ -547,9 +490,7 @@ func (c *DiscoveryController) GetCardInteractionHistory(ctx *gin.Context) {
 		offset,
 	)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to get card interaction history",
-		})
+		apperror.Write(ctx, err)
 		return
 	}
 
@@ -570,15 +511,13 @@ func (c *DiscoveryController) GetCardInteractionHistory(ctx *gin.Context) {
 // @Param limit query int false "每頁數量" default(20)
 // @Param unread_only query bool false "只顯示未讀" default(false)
 // @Success 200 {object} map[string]interface{} "通知列表"
-// @Failure 401 {object} map[string]interface{} "未授權"
-// @Failure 500 {object} map[string]interface{} "伺服器錯誤"
+// @Failure 401 {object} apperror.Problem "未授權"
+// @Failure 500 {object} apperror.Problem "伺服器錯誤"
 // @Router /api/v1/discovery/notifications [get]
 func (c *DiscoveryController) GetMatchNotifications(ctx *gin.Context) {
 	userID, exists := ctx.Get("userID")
 	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{
-			"error": "User not authenticated",
-		})
+		apperror.Write(ctx, apperror.New(apperror.CodeUnauthorized))
 		return
 	}
 
@@ -612,9 +551,7 @@ func (c *DiscoveryController) GetMatchNotifications(ctx *gin.Context) {
 		offset,
 	)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to get notifications",
-		})
+		apperror.Write(ctx, err)
 		return
 	}
 
@@ -634,25 +571,21 @@ func (c *DiscoveryController) GetMatchNotifications(ctx *gin.Context) {
 // @Produce json
 // @Param notificationID path string true "通知ID"
 // @Success 200 {object} map[string]interface{} "標記成功"
-// @Failure 400 {object} map[string]interface{} "請求錯誤"
-// @Failure 401 {object} map[string]interface{} "未授權"
-// @Failure 404 {object} map[string]interface{} "通知不存在"
-// @Failure 500 {object} map[string]interface{} "伺服器錯誤"
+// @Failure 400 {object} apperror.Problem "請求錯誤"
+// @Failure 401 {object} apperror.Problem "未授權"
+// @Failure 404 {object} apperror.Problem "通知不存在"
+// @Failure 500 {object} apperror.Problem "伺服器錯誤"
 // @Router /api/v1/discovery/notifications/{notificationID}/read [put]
 func (c *DiscoveryController) MarkNotificationAsRead(ctx *gin.Context) {
 	userID, exists := ctx.Get("userID")
 	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{
-			"error": "User not authenticated",
-		})
+		apperror.Write(ctx, apperror.New(apperror.CodeUnauthorized))
 		return
 	}
 
 	notificationID := ctx.Param("notificationID")
 	if notificationID == "" {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "Notification ID is required",
-		})
+		apperror.Write(ctx, apperror.New(apperror.CodeMissingParam).With("name", "notificationID"))
 		return
 	}
 
@@ -663,15 +596,7 @@ func (c *DiscoveryController) MarkNotificationAsRead(ctx *gin.Context) {
 		notificationID,
 	)
 	if err != nil {
-		if err.Error() == "notification not found" {
-			ctx.JSON(http.StatusNotFound, gin.H{
-				"error": "Notification not found",
-			})
-			return
-		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to mark notification as read",
-		})
+		apperror.Write(ctx, err)
 		return
 	}
 
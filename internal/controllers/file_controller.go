@@ -0,0 +1,225 @@
+package controllers
+
+import (
+	"context"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"tennis-platform/backend/internal/apperror"
+	"tennis-platform/backend/internal/dto"
+	"tennis-platform/backend/internal/models"
+	"tennis-platform/backend/internal/services"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FileUploadServiceInterface 文件存儲服務接口
+type FileUploadServiceInterface interface {
+	SignedDownloadURL(ctx context.Context, userID, key string) (string, time.Time, error)
+	PresignUpload(ctx context.Context, userID, directory, filename string, size int64) (*services.PresignedUpload, error)
+	OpenSigned(ctx context.Context, key, expires, signature string) (io.ReadCloser, error)
+	PutSigned(ctx context.Context, key, contentType, expires, signature string, r io.Reader, size int64) error
+	ProcessUploaded(ctx context.Context, userID, key string) (*services.UploadResult, error)
+	GetImageAssets(ctx context.Context, urls []string) ([]models.ImageAsset, error)
+}
+
+// FileController 文件存儲控制器
+type FileController struct {
+	uploadService FileUploadServiceInterface
+}
+
+// NewFileController 創建新的文件存儲控制器
+func NewFileController(uploadService FileUploadServiceInterface) *FileController {
+	return &FileController{
+		uploadService: uploadService,
+	}
+}
+
+// GetSignedURL 獲取簽名下載網址
+// @Summary 獲取簽名下載網址
+// @Description 為公開目錄的文件或用戶自己直傳的文件生成限時有效的下載網址，發票等私人文件需經其授權的端點下載
+// @Tags files
+// @Produce json
+// @Security BearerAuth
+// @Param key query string true "文件鍵，例如 courts/xxx.jpg"
+// @Success 200 {object} dto.SignedURLResponse
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Router /api/v1/files/signed-url [get]
+func (fc *FileController) GetSignedURL(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
+		return
+	}
+
+	var req dto.SignedURLRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		apperror.Write(c, apperror.Validation(err))
+		return
+	}
+
+	url, expiresAt, err := fc.uploadService.SignedDownloadURL(c.Request.Context(), userID.(string), req.Key)
+	if err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.SignedURLResponse{URL: url, ExpiresAt: expiresAt})
+}
+
+// PresignUpload 獲取直傳上傳網址
+// @Summary 獲取直傳上傳網址
+// @Description 生成限時有效的上傳網址，客戶端以回應中的 method 及 headers 直接將文件送到存儲，完成後以 publicUrl 更新資源
+// @Tags files
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.PresignUploadRequest true "上傳信息"
+// @Success 200 {object} services.PresignedUpload
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 413 {object} apperror.Problem
+// @Failure 415 {object} apperror.Problem
+// @Router /api/v1/files/presign [post]
+func (fc *FileController) PresignUpload(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
+		return
+	}
+
+	var req dto.PresignUploadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperror.Write(c, apperror.Validation(err))
+		return
+	}
+
+	upload, err := fc.uploadService.PresignUpload(c.Request.Context(), userID.(string), req.Directory, req.FileName, req.Size)
+	if err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, upload)
+}
+
+// CompleteUpload 完成直傳上傳
+// @Summary 完成直傳上傳
+// @Description 直傳的圖片上傳後由申請直傳網址的用戶調用此端點，伺服器校正方向、去除 EXIF 等中繼資料並生成縮圖，內容無效時刪除已上傳的文件
+// @Tags files
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.CompleteUploadRequest true "文件鍵"
+// @Success 200 {object} services.UploadResult
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Failure 422 {object} apperror.Problem
+// @Router /api/v1/files/complete [post]
+func (fc *FileController) CompleteUpload(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
+		return
+	}
+
+	var req dto.CompleteUploadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperror.Write(c, apperror.Validation(err))
+		return
+	}
+
+	result, err := fc.uploadService.ProcessUploaded(c.Request.Context(), userID.(string), req.Key)
+	if err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// GetImageAssets 查詢圖片資訊
+// @Summary 查詢圖片資訊
+// @Description 依圖片網址查詢尺寸、縮圖網址、BlurHash 及主色，供客戶端顯示佔位圖
+// @Tags files
+// @Produce json
+// @Param url query []string true "圖片網址，可重複，最多 50 個" collectionFormat(multi)
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
+// @Router /api/v1/files/images [get]
+func (fc *FileController) GetImageAssets(c *gin.Context) {
+	var req dto.ImageAssetsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		apperror.Write(c, apperror.Validation(err))
+		return
+	}
+
+	assets, err := fc.uploadService.GetImageAssets(c.Request.Context(), req.URLs)
+	if err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"images": assets})
+}
+
+// DownloadObject 以簽名連結下載文件（本機存儲）
+// @Summary 以簽名連結下載文件
+// @Description 本機存儲的簽名下載網址，由 /api/v1/files/signed-url 生成
+// @Tags files
+// @Produce octet-stream
+// @Param key path string true "文件鍵"
+// @Param expires query int true "到期時間（Unix 秒）"
+// @Param signature query string true "簽名"
+// @Success 200 {file} file
+// @Failure 403 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Router /api/v1/files/object/{key} [get]
+func (fc *FileController) DownloadObject(c *gin.Context) {
+	key := strings.TrimPrefix(c.Param("key"), "/")
+
+	file, err := fc.uploadService.OpenSigned(c.Request.Context(), key, c.Query("expires"), c.Query("signature"))
+	if err != nil {
+		apperror.Write(c, err)
+		return
+	}
+	defer file.Close()
+
+	contentType := mime.TypeByExtension(filepath.Ext(key))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	c.DataFromReader(http.StatusOK, -1, contentType, file, nil)
+}
+
+// UploadObject 以簽名連結直傳文件（本機存儲）
+// @Summary 以簽名連結直傳文件
+// @Description 本機存儲的直傳網址，由 /api/v1/files/presign 生成，Content-Type 必須與申請時一致
+// @Tags files
+// @Accept octet-stream
+// @Param key path string true "文件鍵"
+// @Param expires query int true "到期時間（Unix 秒）"
+// @Param signature query string true "簽名"
+// @Success 204
+// @Failure 403 {object} apperror.Problem
+// @Failure 413 {object} apperror.Problem
+// @Router /api/v1/files/object/{key} [put]
+func (fc *FileController) UploadObject(c *gin.Context) {
+	key := strings.TrimPrefix(c.Param("key"), "/")
+
+	err := fc.uploadService.PutSigned(c.Request.Context(), key, c.ContentType(), c.Query("expires"), c.Query("signature"),
+		c.Request.Body, c.Request.ContentLength)
+	if err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
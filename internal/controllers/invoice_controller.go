@@ -0,0 +1,151 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"tennis-platform/backend/internal/apperror"
+	"tennis-platform/backend/internal/dto"
+	"tennis-platform/backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InvoiceUsecaseInterface 發票用例接口
+type InvoiceUsecaseInterface interface {
+	ListInvoices(ctx context.Context, userID string, req *dto.InvoiceListRequest) (*dto.InvoiceListResponse, error)
+	GetInvoice(ctx context.Context, userID, invoiceID string) (*models.Invoice, error)
+	DownloadInvoice(ctx context.Context, userID, invoiceID string) (*models.Invoice, []byte, error)
+	ResendInvoice(ctx context.Context, userID, invoiceID string) (*models.Invoice, error)
+}
+
+// InvoiceController 發票控制器
+type InvoiceController struct {
+	invoiceUsecase InvoiceUsecaseInterface
+}
+
+// NewInvoiceController 創建新的發票控制器
+func NewInvoiceController(invoiceUsecase InvoiceUsecaseInterface) *InvoiceController {
+	return &InvoiceController{
+		invoiceUsecase: invoiceUsecase,
+	}
+}
+
+// ListInvoices 獲取發票列表
+// @Summary 獲取發票列表
+// @Description 列出自己付款的發票及折讓單；role=seller 時列出自己的場地、課程或俱樂部開立的發票
+// @Tags invoices
+// @Produce json
+// @Security BearerAuth
+// @Param role query string false "buyer 或 seller，默認 buyer" Enums(buyer, seller)
+// @Param paymentId query string false "付款ID"
+// @Param kind query string false "發票類型" Enums(invoice, credit_note)
+// @Param page query int false "頁碼"
+// @Param pageSize query int false "每頁數量"
+// @Success 200 {object} dto.InvoiceListResponse
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Router /api/v1/invoices [get]
+func (ic *InvoiceController) ListInvoices(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
+		return
+	}
+
+	var req dto.InvoiceListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		apperror.Write(c, apperror.Validation(err))
+		return
+	}
+
+	response, err := ic.invoiceUsecase.ListInvoices(c.Request.Context(), userID.(string), &req)
+	if err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetInvoice 獲取發票詳情
+// @Summary 獲取發票詳情
+// @Description 付款人及開立方可以查看發票的項目及金額
+// @Tags invoices
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "發票ID"
+// @Success 200 {object} models.Invoice
+// @Failure 401 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Router /api/v1/invoices/{id} [get]
+func (ic *InvoiceController) GetInvoice(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
+		return
+	}
+
+	invoice, err := ic.invoiceUsecase.GetInvoice(c.Request.Context(), userID.(string), c.Param("id"))
+	if err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, invoice)
+}
+
+// DownloadInvoice 下載發票 PDF
+// @Summary 下載發票 PDF
+// @Description 下載發票或折讓單的 PDF，文件名為發票號碼
+// @Tags invoices
+// @Produce application/pdf
+// @Security BearerAuth
+// @Param id path string true "發票ID"
+// @Success 200 {file} file "PDF 文件"
+// @Failure 401 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Router /api/v1/invoices/{id}/pdf [get]
+func (ic *InvoiceController) DownloadInvoice(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
+		return
+	}
+
+	invoice, data, err := ic.invoiceUsecase.DownloadInvoice(c.Request.Context(), userID.(string), c.Param("id"))
+	if err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.pdf"`, invoice.Number))
+	c.Data(http.StatusOK, "application/pdf", data)
+}
+
+// ResendInvoice 重新寄送發票
+// @Summary 重新寄送發票
+// @Description 將發票的 PDF 重新寄到付款人的電子郵件
+// @Tags invoices
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "發票ID"
+// @Success 200 {object} models.Invoice
+// @Failure 401 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Router /api/v1/invoices/{id}/resend [post]
+func (ic *InvoiceController) ResendInvoice(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
+		return
+	}
+
+	invoice, err := ic.invoiceUsecase.ResendInvoice(c.Request.Context(), userID.(string), c.Param("id"))
+	if err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, invoice)
+}
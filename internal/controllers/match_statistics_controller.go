@@ -3,8 +3,10 @@ package controllers
 import (
 	"net/http"
 	"strconv"
+	"tennis-platform/backend/internal/apperror"
 	"tennis-platform/backend/internal/dto"
 	"tennis-platform/backend/internal/models"
+	"tennis-platform/backend/internal/services"
 	"tennis-platform/backend/internal/usecases"
 
 	"github.com/gin-gonic/gin"
@@ -17,9 +19,9 @@ type MatchStatisticsController struct {
 }
 
 // NewMatchStatisticsController 創建新的配對統計控制器
-func NewMatchStatisticsController(db *gorm.DB) *MatchStatisticsController {
+func NewMatchStatisticsController(db *gorm.DB, eventBus *services.EventBus) *MatchStatisticsController {
 	return &MatchStatisticsController{
-		matchStatisticsUseCase: usecases.NewMatchStatisticsUseCase(db),
+		matchStatisticsUseCase: usecases.NewMatchStatisticsUseCase(db, eventBus),
 	}
 }
 
@@ -31,18 +33,15 @@ func NewMatchStatisticsController(db *gorm.DB) *MatchStatisticsController {
 // @Produce json
 // @Param userId path string true "用戶ID"
 // @Success 200 {object} models.MatchStatistics
-// @Failure 400 {object} map[string]interface{}
-// @Failure 403 {object} map[string]interface{}
-// @Failure 404 {object} map[string]interface{}
-// @Failure 500 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
 // @Router /api/v1/match-statistics/users/{userId} [get]
 func (msc *MatchStatisticsController) GetUserMatchStatistics(c *gin.Context) {
 	userID := c.Param("userId")
 	if userID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "INVALID_USER_ID",
-			"message": "用戶ID不能為空",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeMissingParam).With("name", "userId"))
 		return
 	}
 
@@ -54,19 +53,7 @@ func (msc *MatchStatisticsController) GetUserMatchStatistics(c *gin.Context) {
 
 	stats, err := msc.matchStatisticsUseCase.GetUserMatchStatistics(userID, requestingUserID.(string))
 	if err != nil {
-		if err.Error() == "match statistics are private" {
-			c.JSON(http.StatusForbidden, gin.H{
-				"error":   "PRIVATE_STATISTICS",
-				"message": "用戶統計資訊為私人設定",
-			})
-			return
-		}
-
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "GET_STATISTICS_FAILED",
-			"message": "獲取統計資訊失敗",
-			"details": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
@@ -83,17 +70,14 @@ func (msc *MatchStatisticsController) GetUserMatchStatistics(c *gin.Context) {
 // @Param limit query int false "返回數量限制" default(20)
 // @Param offset query int false "偏移量" default(0)
 // @Success 200 {array} models.Match
-// @Failure 400 {object} map[string]interface{}
-// @Failure 403 {object} map[string]interface{}
-// @Failure 500 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
 // @Router /api/v1/match-statistics/users/{userId}/history [get]
 func (msc *MatchStatisticsController) GetUserMatchHistory(c *gin.Context) {
 	userID := c.Param("userId")
 	if userID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "INVALID_USER_ID",
-			"message": "用戶ID不能為空",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeMissingParam).With("name", "userId"))
 		return
 	}
 
@@ -119,19 +103,7 @@ func (msc *MatchStatisticsController) GetUserMatchHistory(c *gin.Context) {
 
 	matches, err := msc.matchStatisticsUseCase.GetUserMatchHistory(userID, requestingUserID.(string), limit, offset)
 	if err != nil {
-		if err.Error() == "match history is private" {
-			c.JSON(http.StatusForbidden, gin.H{
-				"error":   "PRIVATE_HISTORY",
-				"message": "用戶配對歷史為私人設定",
-			})
-			return
-		}
-
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "GET_HISTORY_FAILED",
-			"message": "獲取配對歷史失敗",
-			"details": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
@@ -147,55 +119,33 @@ func (msc *MatchStatisticsController) GetUserMatchHistory(c *gin.Context) {
 // @Param matchId path string true "比賽ID"
 // @Param request body dto.RecordMatchResultRequest true "比賽結果請求"
 // @Success 200 {object} map[string]interface{}
-// @Failure 400 {object} map[string]interface{}
-// @Failure 403 {object} map[string]interface{}
-// @Failure 500 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
 // @Router /api/v1/match-statistics/matches/{matchId}/result [post]
 func (msc *MatchStatisticsController) RecordMatchResult(c *gin.Context) {
 	matchID := c.Param("matchId")
 	if matchID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "INVALID_MATCH_ID",
-			"message": "比賽ID不能為空",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeMissingParam).With("name", "matchId"))
 		return
 	}
 
 	// 獲取記錄者ID
 	recordedBy, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error":   "UNAUTHORIZED",
-			"message": "未授權的請求",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
 		return
 	}
 
 	var req dto.RecordMatchResultRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "INVALID_REQUEST",
-			"message": "請求參數無效",
-			"details": err.Error(),
-		})
+		apperror.Write(c, apperror.Validation(err))
 		return
 	}
 
 	err := msc.matchStatisticsUseCase.RecordMatchResult(matchID, req.WinnerID, req.LoserID, req.Score, recordedBy.(string))
 	if err != nil {
-		if err.Error() == "only match participants can record results" {
-			c.JSON(http.StatusForbidden, gin.H{
-				"error":   "NOT_PARTICIPANT",
-				"message": "只有比賽參與者可以記錄結果",
-			})
-			return
-		}
-
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "RECORD_RESULT_FAILED",
-			"message": "記錄比賽結果失敗",
-			"details": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
@@ -212,45 +162,27 @@ func (msc *MatchStatisticsController) RecordMatchResult(c *gin.Context) {
 // @Produce json
 // @Param resultId path string true "比賽結果ID"
 // @Success 200 {object} map[string]interface{}
-// @Failure 400 {object} map[string]interface{}
-// @Failure 403 {object} map[string]interface{}
-// @Failure 500 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
 // @Router /api/v1/match-statistics/results/{resultId}/confirm [post]
 func (msc *MatchStatisticsController) ConfirmMatchResult(c *gin.Context) {
 	resultID := c.Param("resultId")
 	if resultID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "INVALID_RESULT_ID",
-			"message": "結果ID不能為空",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeMissingParam).With("name", "resultId"))
 		return
 	}
 
 	// 獲取確認者ID
 	userID, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error":   "UNAUTHORIZED",
-			"message": "未授權的請求",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
 		return
 	}
 
 	err := msc.matchStatisticsUseCase.ConfirmMatchResult(resultID, userID.(string))
 	if err != nil {
-		if err.Error() == "user not authorized to confirm this result" {
-			c.JSON(http.StatusForbidden, gin.H{
-				"error":   "NOT_AUTHORIZED",
-				"message": "無權限確認此結果",
-			})
-			return
-		}
-
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "CONFIRM_RESULT_FAILED",
-			"message": "確認比賽結果失敗",
-			"details": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
@@ -267,17 +199,14 @@ func (msc *MatchStatisticsController) ConfirmMatchResult(c *gin.Context) {
 // @Produce json
 // @Param userId path string true "用戶ID"
 // @Success 200 {array} models.SkillLevelRecord
-// @Failure 400 {object} map[string]interface{}
-// @Failure 403 {object} map[string]interface{}
-// @Failure 500 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
 // @Router /api/v1/match-statistics/users/{userId}/skill-progression [get]
 func (msc *MatchStatisticsController) GetSkillLevelProgression(c *gin.Context) {
 	userID := c.Param("userId")
 	if userID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "INVALID_USER_ID",
-			"message": "用戶ID不能為空",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeMissingParam).With("name", "userId"))
 		return
 	}
 
@@ -289,19 +218,7 @@ func (msc *MatchStatisticsController) GetSkillLevelProgression(c *gin.Context) {
 
 	skillRecords, err := msc.matchStatisticsUseCase.GetSkillLevelProgression(userID, requestingUserID.(string))
 	if err != nil {
-		if err.Error() == "skill progression is private" {
-			c.JSON(http.StatusForbidden, gin.H{
-				"error":   "PRIVATE_PROGRESSION",
-				"message": "技術等級進展為私人設定",
-			})
-			return
-		}
-
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "GET_PROGRESSION_FAILED",
-			"message": "獲取技術等級進展失敗",
-			"details": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
@@ -317,46 +234,32 @@ func (msc *MatchStatisticsController) GetSkillLevelProgression(c *gin.Context) {
 // @Param userId path string true "用戶ID"
 // @Param request body dto.ManuallyAdjustSkillLevelRequest true "調整等級請求"
 // @Success 200 {object} map[string]interface{}
-// @Failure 400 {object} map[string]interface{}
-// @Failure 500 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
 // @Router /api/v1/match-statistics/users/{userId}/adjust-skill-level [post]
 func (msc *MatchStatisticsController) ManuallyAdjustSkillLevel(c *gin.Context) {
 	userID := c.Param("userId")
 	if userID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "INVALID_USER_ID",
-			"message": "用戶ID不能為空",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeMissingParam).With("name", "userId"))
 		return
 	}
 
 	// 獲取調整者ID
 	adjustedBy, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error":   "UNAUTHORIZED",
-			"message": "未授權的請求",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
 		return
 	}
 
 	var req dto.ManuallyAdjustSkillLevelRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "INVALID_REQUEST",
-			"message": "請求參數無效",
-			"details": err.Error(),
-		})
+		apperror.Write(c, apperror.Validation(err))
 		return
 	}
 
 	err := msc.matchStatisticsUseCase.ManuallyAdjustSkillLevel(userID, req.NewLevel, req.Reason, adjustedBy.(string))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "ADJUST_LEVEL_FAILED",
-			"message": "調整技術等級失敗",
-			"details": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
@@ -372,27 +275,20 @@ func (msc *MatchStatisticsController) ManuallyAdjustSkillLevel(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Success 200 {object} models.UserPrivacySettings
-// @Failure 401 {object} map[string]interface{}
-// @Failure 500 {object} map[string]interface{}
+// @Failure 401 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
 // @Router /api/v1/match-statistics/privacy-settings [get]
 func (msc *MatchStatisticsController) GetUserPrivacySettings(c *gin.Context) {
 	// 獲取用戶ID
 	userID, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error":   "UNAUTHORIZED",
-			"message": "未授權的請求",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
 		return
 	}
 
 	settings, err := msc.matchStatisticsUseCase.GetUserPrivacySettings(userID.(string))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "GET_SETTINGS_FAILED",
-			"message": "獲取隱私設定失敗",
-			"details": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
@@ -407,38 +303,27 @@ func (msc *MatchStatisticsController) GetUserPrivacySettings(c *gin.Context) {
 // @Produce json
 // @Param request body models.UserPrivacySettings true "隱私設定"
 // @Success 200 {object} map[string]interface{}
-// @Failure 400 {object} map[string]interface{}
-// @Failure 401 {object} map[string]interface{}
-// @Failure 500 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
 // @Router /api/v1/match-statistics/privacy-settings [put]
 func (msc *MatchStatisticsController) UpdateUserPrivacySettings(c *gin.Context) {
 	// 獲取用戶ID
 	userID, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error":   "UNAUTHORIZED",
-			"message": "未授權的請求",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
 		return
 	}
 
 	var settings models.UserPrivacySettings
 	if err := c.ShouldBindJSON(&settings); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "INVALID_REQUEST",
-			"message": "請求參數無效",
-			"details": err.Error(),
-		})
+		apperror.Write(c, apperror.Validation(err))
 		return
 	}
 
 	err := msc.matchStatisticsUseCase.UpdateUserPrivacySettings(userID.(string), &settings)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "UPDATE_SETTINGS_FAILED",
-			"message": "更新隱私設定失敗",
-			"details": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
@@ -454,27 +339,20 @@ func (msc *MatchStatisticsController) UpdateUserPrivacySettings(c *gin.Context)
 // @Accept json
 // @Produce json
 // @Success 200 {array} models.MatchResult
-// @Failure 401 {object} map[string]interface{}
-// @Failure 500 {object} map[string]interface{}
+// @Failure 401 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
 // @Router /api/v1/match-statistics/pending-confirmations [get]
 func (msc *MatchStatisticsController) GetMatchResultsForConfirmation(c *gin.Context) {
 	// 獲取用戶ID
 	userID, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error":   "UNAUTHORIZED",
-			"message": "未授權的請求",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
 		return
 	}
 
 	matchResults, err := msc.matchStatisticsUseCase.GetMatchResultsForConfirmation(userID.(string))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "GET_CONFIRMATIONS_FAILED",
-			"message": "獲取待確認結果失敗",
-			"details": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
@@ -489,17 +367,14 @@ func (msc *MatchStatisticsController) GetMatchResultsForConfirmation(c *gin.Cont
 // @Produce json
 // @Param userId path string true "用戶ID"
 // @Success 200 {object} models.ReputationScore
-// @Failure 400 {object} map[string]interface{}
-// @Failure 403 {object} map[string]interface{}
-// @Failure 500 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
 // @Router /api/v1/match-statistics/users/{userId}/reputation [get]
 func (msc *MatchStatisticsController) GetReputationScoreWithPrivacy(c *gin.Context) {
 	userID := c.Param("userId")
 	if userID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "INVALID_USER_ID",
-			"message": "用戶ID不能為空",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeMissingParam).With("name", "userId"))
 		return
 	}
 
@@ -511,19 +386,7 @@ func (msc *MatchStatisticsController) GetReputationScoreWithPrivacy(c *gin.Conte
 
 	reputation, err := msc.matchStatisticsUseCase.GetReputationScoreWithPrivacy(userID, requestingUserID.(string))
 	if err != nil {
-		if err.Error() == "reputation score is private" {
-			c.JSON(http.StatusForbidden, gin.H{
-				"error":   "PRIVATE_REPUTATION",
-				"message": "信譽分數為私人設定",
-			})
-			return
-		}
-
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "GET_REPUTATION_FAILED",
-			"message": "獲取信譽分數失敗",
-			"details": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
@@ -537,27 +400,20 @@ func (msc *MatchStatisticsController) GetReputationScoreWithPrivacy(c *gin.Conte
 // @Accept json
 // @Produce json
 // @Success 200 {object} map[string]interface{}
-// @Failure 401 {object} map[string]interface{}
-// @Failure 500 {object} map[string]interface{}
+// @Failure 401 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
 // @Router /api/v1/match-statistics/summary [get]
 func (msc *MatchStatisticsController) GetMatchStatisticsSummary(c *gin.Context) {
 	// 獲取用戶ID
 	userID, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error":   "UNAUTHORIZED",
-			"message": "未授權的請求",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
 		return
 	}
 
 	summary, err := msc.matchStatisticsUseCase.GetMatchStatisticsSummary(userID.(string))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "GET_SUMMARY_FAILED",
-			"message": "獲取統計摘要失敗",
-			"details": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
@@ -4,7 +4,9 @@ import (
 	"net/http"
 	"strconv"
 
+	"tennis-platform/backend/internal/apperror"
 	"tennis-platform/backend/internal/dto"
+	"tennis-platform/backend/internal/pagination"
 	"tennis-platform/backend/internal/services"
 	"tennis-platform/backend/internal/usecases"
 
@@ -31,24 +33,20 @@ func NewMatchesController(matchingUsecase *usecases.MatchingUsecase) *MatchesCon
 // @Produce json
 // @Param request body dto.FindCompetitiveMatchesRequest true "對手篩選條件"
 // @Success 200 {object} map[string]interface{} "對手列表"
-// @Failure 400 {object} map[string]interface{} "請求錯誤"
-// @Failure 401 {object} map[string]interface{} "未授權"
-// @Failure 500 {object} map[string]interface{} "伺服器錯誤"
+// @Failure 400 {object} apperror.Problem "請求錯誤"
+// @Failure 401 {object} apperror.Problem "未授權"
+// @Failure 500 {object} apperror.Problem "伺服器錯誤"
 // @Router /api/v1/matches/find [post]
 func (c *MatchesController) FindMatches(ctx *gin.Context) {
 	userID, exists := ctx.Get("userID")
 	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{
-			"error": "User not authenticated",
-		})
+		apperror.Write(ctx, apperror.New(apperror.CodeUnauthorized))
 		return
 	}
 
 	var req dto.FindCompetitiveMatchesRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid request format",
-		})
+		apperror.Write(ctx, apperror.Validation(err))
 		return
 	}
 
@@ -72,9 +70,7 @@ func (c *MatchesController) FindMatches(ctx *gin.Context) {
 	// 尋找對手
 	results, err := c.matchingUsecase.FindCompetitiveMatches(ctx, userID.(string), criteria, req.Limit)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to find matches",
-		})
+		apperror.Write(ctx, err)
 		return
 	}
 
@@ -142,65 +138,41 @@ func (c *MatchesController) FindMatches(ctx *gin.Context) {
 // @Description 獲取過往的競賽對戰記錄
 // @Tags matches
 // @Produce json
+// @Param cursor query string false "分頁游標，提供時忽略 page"
 // @Param page query int false "頁碼" default(1)
 // @Param limit query int false "每頁數量" default(10)
 // @Success 200 {object} map[string]interface{} "對戰歷史"
-// @Failure 401 {object} map[string]interface{} "未授權"
-// @Failure 500 {object} map[string]interface{} "伺服器錯誤"
+// @Failure 401 {object} apperror.Problem "未授權"
+// @Failure 500 {object} apperror.Problem "伺服器錯誤"
 // @Router /api/v1/matches/history [get]
 func (c *MatchesController) GetMatchHistory(ctx *gin.Context) {
 	userID, exists := ctx.Get("userID")
 	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{
-			"error": "User not authenticated",
-		})
+		apperror.Write(ctx, apperror.New(apperror.CodeUnauthorized))
 		return
 	}
 
 	// 獲取分頁參數
-	pageStr := ctx.DefaultQuery("page", "1")
-	limitStr := ctx.DefaultQuery("limit", "10")
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "10"))
 
-	page, err := strconv.Atoi(pageStr)
-	if err != nil || page <= 0 {
-		page = 1
+	pageQuery := pagination.Query{
+		Limit:  limit,
+		Page:   page,
+		Cursor: ctx.Query("cursor"),
 	}
-
-	limit, err := strconv.Atoi(limitStr)
-	if err != nil || limit <= 0 {
-		limit = 10
-	}
-	if limit > 50 {
-		limit = 50
-	}
-
-	offset := (page - 1) * limit
+	pageQuery.Normalize(10, 50)
 
 	// 獲取配對歷史（篩選競賽類型）
-	matches, err := c.matchingUsecase.GetMatchingHistory(ctx, userID.(string), limit*2, offset)
+	matches, pageInfo, err := c.matchingUsecase.GetMatchingHistory(ctx, userID.(string), []string{"tournament", "competitive"}, &pageQuery)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to get match history",
-		})
+		apperror.Write(ctx, err)
 		return
 	}
 
-	// 篩選競賽類型的配對
-	var competitiveMatches []interface{}
-	for _, match := range matches {
-		if match.Type == "tournament" || match.Type == "competitive" {
-			competitiveMatches = append(competitiveMatches, match)
-			if len(competitiveMatches) >= limit {
-				break
-			}
-		}
-	}
-
 	ctx.JSON(http.StatusOK, gin.H{
-		"matches": competitiveMatches,
-		"page":    page,
-		"limit":   limit,
-		"total":   len(competitiveMatches),
+		"matches":    matches,
+		"pagination": pageInfo,
 	})
 }
 
@@ -212,24 +184,20 @@ func (c *MatchesController) GetMatchHistory(ctx *gin.Context) {
 // @Produce json
 // @Param request body dto.CreateMatchRequest true "配對資訊"
 // @Success 201 {object} map[string]interface{} "創建成功"
-// @Failure 400 {object} map[string]interface{} "請求錯誤"
-// @Failure 401 {object} map[string]interface{} "未授權"
-// @Failure 500 {object} map[string]interface{} "伺服器錯誤"
+// @Failure 400 {object} apperror.Problem "請求錯誤"
+// @Failure 401 {object} apperror.Problem "未授權"
+// @Failure 500 {object} apperror.Problem "伺服器錯誤"
 // @Router /api/v1/matches/create [post]
 func (c *MatchesController) CreateMatch(ctx *gin.Context) {
 	userID, exists := ctx.Get("userID")
 	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{
-			"error": "User not authenticated",
-		})
+		apperror.Write(ctx, apperror.New(apperror.CodeUnauthorized))
 		return
 	}
 
 	var req dto.CreateMatchRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid request format",
-		})
+		apperror.Write(ctx, apperror.Validation(err))
 		return
 	}
 
@@ -239,9 +207,7 @@ func (c *MatchesController) CreateMatch(ctx *gin.Context) {
 		"competitive": true,
 	}
 	if !validTypes[req.MatchType] {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid match type for competitive match. Use 'tournament' or 'competitive'",
-		})
+		apperror.Write(ctx, apperror.New(apperror.CodeMatchInvalidType))
 		return
 	}
 
@@ -260,9 +226,7 @@ func (c *MatchesController) CreateMatch(ctx *gin.Context) {
 		nil,
 	)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to create competitive match",
-		})
+		apperror.Write(ctx, err)
 		return
 	}
 
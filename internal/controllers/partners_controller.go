@@ -5,8 +5,10 @@ import (
 	"net/http"
 	"strconv"
 
+	"tennis-platform/backend/internal/apperror"
 	"tennis-platform/backend/internal/dto"
 	"tennis-platform/backend/internal/models"
+	"tennis-platform/backend/internal/pagination"
 	"tennis-platform/backend/internal/services"
 	"tennis-platform/backend/internal/usecases"
 
@@ -33,24 +35,20 @@ func NewPartnersController(matchingUsecase *usecases.MatchingUsecase) *PartnersC
 // @Produce json
 // @Param request body dto.FindPartnersRequest true "球友篩選條件"
 // @Success 200 {object} map[string]interface{} "球友列表"
-// @Failure 400 {object} map[string]interface{} "請求錯誤"
-// @Failure 401 {object} map[string]interface{} "未授權"
-// @Failure 500 {object} map[string]interface{} "伺服器錯誤"
+// @Failure 400 {object} apperror.Problem "請求錯誤"
+// @Failure 401 {object} apperror.Problem "未授權"
+// @Failure 500 {object} apperror.Problem "伺服器錯誤"
 // @Router /api/v1/partners/find [post]
 func (c *PartnersController) FindPartners(ctx *gin.Context) {
 	userID, exists := ctx.Get("userID")
 	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{
-			"error": "User not authenticated",
-		})
+		apperror.Write(ctx, apperror.New(apperror.CodeUnauthorized))
 		return
 	}
 
 	var req dto.FindPartnersRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid request format",
-		})
+		apperror.Write(ctx, apperror.Validation(err))
 		return
 	}
 
@@ -76,9 +74,7 @@ func (c *PartnersController) FindPartners(ctx *gin.Context) {
 	// 尋找球友請求
 	matches, err := c.matchingUsecase.FindPartnerRequests(ctx, userID.(string), criteria, req.Limit)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to find partners",
-		})
+		apperror.Write(ctx, err)
 		return
 	}
 
@@ -165,65 +161,41 @@ func (c *PartnersController) FindPartners(ctx *gin.Context) {
 // @Description 獲取過往的練習球友記錄
 // @Tags partners
 // @Produce json
+// @Param cursor query string false "分頁游標，提供時忽略 page"
 // @Param page query int false "頁碼" default(1)
 // @Param limit query int false "每頁數量" default(10)
 // @Success 200 {object} map[string]interface{} "球友歷史"
-// @Failure 401 {object} map[string]interface{} "未授權"
-// @Failure 500 {object} map[string]interface{} "伺服器錯誤"
+// @Failure 401 {object} apperror.Problem "未授權"
+// @Failure 500 {object} apperror.Problem "伺服器錯誤"
 // @Router /api/v1/partners/history [get]
 func (c *PartnersController) GetPartnerHistory(ctx *gin.Context) {
 	userID, exists := ctx.Get("userID")
 	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{
-			"error": "User not authenticated",
-		})
+		apperror.Write(ctx, apperror.New(apperror.CodeUnauthorized))
 		return
 	}
 
 	// 獲取分頁參數
-	pageStr := ctx.DefaultQuery("page", "1")
-	limitStr := ctx.DefaultQuery("limit", "10")
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "10"))
 
-	page, err := strconv.Atoi(pageStr)
-	if err != nil || page <= 0 {
-		page = 1
+	pageQuery := pagination.Query{
+		Limit:  limit,
+		Page:   page,
+		Cursor: ctx.Query("cursor"),
 	}
-
-	limit, err := strconv.Atoi(limitStr)
-	if err != nil || limit <= 0 {
-		limit = 10
-	}
-	if limit > 50 {
-		limit = 50
-	}
-
-	offset := (page - 1) * limit
+	pageQuery.Normalize(10, 50)
 
 	// 獲取配對歷史（篩選練習類型）
-	matches, err := c.matchingUsecase.GetMatchingHistory(ctx, userID.(string), limit*2, offset)
+	matches, pageInfo, err := c.matchingUsecase.GetMatchingHistory(ctx, userID.(string), []string{"practice", "casual"}, &pageQuery)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to get partner history",
-		})
+		apperror.Write(ctx, err)
 		return
 	}
 
-	// 篩選練習類型的配對
-	var practiceMatches []interface{}
-	for _, match := range matches {
-		if match.Type == "practice" || match.Type == "casual" {
-			practiceMatches = append(practiceMatches, match)
-			if len(practiceMatches) >= limit {
-				break
-			}
-		}
-	}
-
 	ctx.JSON(http.StatusOK, gin.H{
-		"matches": practiceMatches,
-		"page":    page,
-		"limit":   limit,
-		"total":   len(practiceMatches),
+		"matches":    matches,
+		"pagination": pageInfo,
 	})
 }
 
@@ -235,24 +207,20 @@ func (c *PartnersController) GetPartnerHistory(ctx *gin.Context) {
 // @Produce json
 // @Param request body dto.CreateMatchRequest true "配對資訊"
 // @Success 201 {object} map[string]interface{} "創建成功"
-// @Failure 400 {object} map[string]interface{} "請求錯誤"
-// @Failure 401 {object} map[string]interface{} "未授權"
-// @Failure 500 {object} map[string]interface{} "伺服器錯誤"
+// @Failure 400 {object} apperror.Problem "請求錯誤"
+// @Failure 401 {object} apperror.Problem "未授權"
+// @Failure 500 {object} apperror.Problem "伺服器錯誤"
 // @Router /api/v1/partners/create [post]
 func (c *PartnersController) CreatePartnerMatch(ctx *gin.Context) {
 	userID, exists := ctx.Get("userID")
 	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{
-			"error": "User not authenticated",
-		})
+		apperror.Write(ctx, apperror.New(apperror.CodeUnauthorized))
 		return
 	}
 
 	var req dto.CreateMatchRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid request format",
-		})
+		apperror.Write(ctx, apperror.Validation(err))
 		return
 	}
 
@@ -291,9 +259,7 @@ func (c *PartnersController) CreatePartnerMatch(ctx *gin.Context) {
 	if err != nil {
 		// Add logging to see the actual error
 		println("Error creating partner match:", err.Error())
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to create partner match: " + err.Error(),
-		})
+		apperror.Write(ctx, err)
 		return
 	}
 
@@ -0,0 +1,183 @@
+package controllers
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"tennis-platform/backend/internal/apperror"
+	"tennis-platform/backend/internal/dto"
+	"tennis-platform/backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxPaymentWebhookSize 付款通知內容的大小上限
+const maxPaymentWebhookSize = 1 << 20
+
+// PaymentUsecaseInterface 付款用例接口
+type PaymentUsecaseInterface interface {
+	CreatePayment(ctx context.Context, userID string, req *dto.CreatePaymentRequest) (*models.Payment, error)
+	GetPayment(ctx context.Context, userID, paymentID string) (*models.Payment, error)
+	CapturePayment(ctx context.Context, userID, paymentID string) (*models.Payment, error)
+	CancelPayment(ctx context.Context, userID, paymentID string) (*models.Payment, error)
+	HandleWebhook(ctx context.Context, provider string, payload []byte, header http.Header) error
+}
+
+// PaymentController 付款控制器
+type PaymentController struct {
+	paymentUsecase PaymentUsecaseInterface
+}
+
+// NewPaymentController 創建新的付款控制器
+func NewPaymentController(paymentUsecase PaymentUsecaseInterface) *PaymentController {
+	return &PaymentController{
+		paymentUsecase: paymentUsecase,
+	}
+}
+
+// CreatePayment 發起付款
+// @Summary 發起付款
+// @Description 為待付款的預訂、課程、活動報名或俱樂部會費建立付款，前端以 clientSecret 完成付款；同一項目已有進行中的付款時返回該付款
+// @Tags payments
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.CreatePaymentRequest true "付款項目"
+// @Success 201 {object} models.Payment
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Failure 409 {object} apperror.Problem
+// @Failure 502 {object} apperror.Problem
+// @Router /api/v1/payments [post]
+func (pc *PaymentController) CreatePayment(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
+		return
+	}
+
+	var req dto.CreatePaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperror.Write(c, apperror.Validation(err))
+		return
+	}
+
+	payment, err := pc.paymentUsecase.CreatePayment(c.Request.Context(), userID.(string), &req)
+	if err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, payment)
+}
+
+// GetPayment 獲取付款詳情
+// @Summary 獲取付款詳情
+// @Description 獲取自己的付款記錄及目前狀態
+// @Tags payments
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "付款ID"
+// @Success 200 {object} models.Payment
+// @Failure 401 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Router /api/v1/payments/{id} [get]
+func (pc *PaymentController) GetPayment(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
+		return
+	}
+
+	payment, err := pc.paymentUsecase.GetPayment(c.Request.Context(), userID.(string), c.Param("id"))
+	if err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, payment)
+}
+
+// CapturePayment 扣款
+// @Summary 扣款
+// @Description 付款人完成授權後扣款，成功後預訂轉為 confirmed，課程、活動報名及俱樂部會員記錄 paymentId；保留已逾期時款項自動退還
+// @Tags payments
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "付款ID"
+// @Success 200 {object} models.Payment
+// @Failure 401 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Failure 409 {object} apperror.Problem
+// @Failure 502 {object} apperror.Problem
+// @Router /api/v1/payments/{id}/capture [post]
+func (pc *PaymentController) CapturePayment(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
+		return
+	}
+
+	payment, err := pc.paymentUsecase.CapturePayment(c.Request.Context(), userID.(string), c.Param("id"))
+	if err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, payment)
+}
+
+// CancelPayment 取消付款
+// @Summary 取消付款
+// @Description 放棄尚未扣款的付款，項目保持待付款，可在付款期限前重新發起付款
+// @Tags payments
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "付款ID"
+// @Success 200 {object} models.Payment
+// @Failure 401 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Failure 409 {object} apperror.Problem
+// @Failure 502 {object} apperror.Problem
+// @Router /api/v1/payments/{id}/cancel [post]
+func (pc *PaymentController) CancelPayment(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
+		return
+	}
+
+	payment, err := pc.paymentUsecase.CancelPayment(c.Request.Context(), userID.(string), c.Param("id"))
+	if err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, payment)
+}
+
+// HandleWebhook 接收付款服務商的通知
+// @Summary 接收付款服務商的通知
+// @Description 由付款服務商呼叫，以簽名驗證來源；重複或過時的通知返回 204 且不重複處理
+// @Tags payments
+// @Accept json
+// @Param provider path string true "付款服務商"
+// @Success 204
+// @Failure 400 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Router /api/v1/payments/webhooks/{provider} [post]
+func (pc *PaymentController) HandleWebhook(c *gin.Context) {
+	payload, err := io.ReadAll(io.LimitReader(c.Request.Body, maxPaymentWebhookSize))
+	if err != nil {
+		apperror.Write(c, apperror.Wrap(apperror.CodeValidation, err))
+		return
+	}
+
+	if err := pc.paymentUsecase.HandleWebhook(c.Request.Context(), c.Param("provider"), payload, c.Request.Header); err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
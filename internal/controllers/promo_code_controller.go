@@ -0,0 +1,209 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"tennis-platform/backend/internal/apperror"
+	"tennis-platform/backend/internal/dto"
+	"tennis-platform/backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PromoCodeUsecaseInterface 優惠碼用例接口
+type PromoCodeUsecaseInterface interface {
+	CreatePromoCode(ctx context.Context, userID string, req *dto.PromoCodeRequest) (*models.PromoCode, error)
+	ListPromoCodes(ctx context.Context, userID string, req *dto.PromoCodeListRequest) (*dto.PromoCodeListResponse, error)
+	GetPromoCode(ctx context.Context, userID, promoCodeID string) (*models.PromoCode, error)
+	UpdatePromoCode(ctx context.Context, userID, promoCodeID string, req *dto.PromoCodeRequest) (*models.PromoCode, error)
+	ListRedemptions(ctx context.Context, userID, promoCodeID string, req *dto.PromoRedemptionListRequest) (*dto.PromoRedemptionListResponse, error)
+}
+
+// PromoCodeController 優惠碼控制器
+type PromoCodeController struct {
+	promoCodeUsecase PromoCodeUsecaseInterface
+}
+
+// NewPromoCodeController 創建新的優惠碼控制器
+func NewPromoCodeController(promoCodeUsecase PromoCodeUsecaseInterface) *PromoCodeController {
+	return &PromoCodeController{
+		promoCodeUsecase: promoCodeUsecase,
+	}
+}
+
+// CreatePromoCode 創建優惠碼
+// @Summary 創建優惠碼
+// @Description 場地擁有者為自己的場地、教練為自己的課程創建百分比或固定金額的優惠碼，可限定有效期、時段、課程類型、俱樂部會員及使用次數
+// @Tags promo-codes
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.PromoCodeRequest true "優惠碼"
+// @Success 201 {object} models.PromoCode
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Failure 409 {object} apperror.Problem
+// @Router /api/v1/promo-codes [post]
+func (pc *PromoCodeController) CreatePromoCode(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
+		return
+	}
+
+	var req dto.PromoCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperror.Write(c, apperror.Validation(err))
+		return
+	}
+
+	promo, err := pc.promoCodeUsecase.CreatePromoCode(c.Request.Context(), userID.(string), &req)
+	if err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, promo)
+}
+
+// ListPromoCodes 獲取優惠碼列表
+// @Summary 獲取優惠碼列表
+// @Description 列出自己創建的優惠碼，按創建時間由新到舊排列
+// @Tags promo-codes
+// @Produce json
+// @Security BearerAuth
+// @Param courtId query string false "場地ID"
+// @Param coachId query string false "教練ID"
+// @Param isActive query bool false "是否啟用"
+// @Param page query int false "頁碼"
+// @Param pageSize query int false "每頁數量"
+// @Success 200 {object} dto.PromoCodeListResponse
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Router /api/v1/promo-codes [get]
+func (pc *PromoCodeController) ListPromoCodes(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
+		return
+	}
+
+	var req dto.PromoCodeListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		apperror.Write(c, apperror.Validation(err))
+		return
+	}
+
+	response, err := pc.promoCodeUsecase.ListPromoCodes(c.Request.Context(), userID.(string), &req)
+	if err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetPromoCode 獲取優惠碼詳情
+// @Summary 獲取優惠碼詳情
+// @Description 創建者可以查看優惠碼的設定及目前的使用次數
+// @Tags promo-codes
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "優惠碼ID"
+// @Success 200 {object} models.PromoCode
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Router /api/v1/promo-codes/{id} [get]
+func (pc *PromoCodeController) GetPromoCode(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
+		return
+	}
+
+	promo, err := pc.promoCodeUsecase.GetPromoCode(c.Request.Context(), userID.(string), c.Param("id"))
+	if err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, promo)
+}
+
+// UpdatePromoCode 更新優惠碼
+// @Summary 更新優惠碼
+// @Description 以請求內容替換整個優惠碼，已套用的折扣及使用次數不受影響；停用請設置 isActive 為 false
+// @Tags promo-codes
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "優惠碼ID"
+// @Param request body dto.PromoCodeRequest true "優惠碼"
+// @Success 200 {object} models.PromoCode
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Failure 409 {object} apperror.Problem
+// @Router /api/v1/promo-codes/{id} [put]
+func (pc *PromoCodeController) UpdatePromoCode(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
+		return
+	}
+
+	var req dto.PromoCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperror.Write(c, apperror.Validation(err))
+		return
+	}
+
+	promo, err := pc.promoCodeUsecase.UpdatePromoCode(c.Request.Context(), userID.(string), c.Param("id"), &req)
+	if err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, promo)
+}
+
+// ListRedemptions 獲取優惠碼的使用記錄
+// @Summary 獲取優惠碼的使用記錄
+// @Description 列出使用此優惠碼的預訂及課程，包含因取消而退回的記錄
+// @Tags promo-codes
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "優惠碼ID"
+// @Param status query string false "使用狀態" Enums(applied, reversed)
+// @Param page query int false "頁碼"
+// @Param pageSize query int false "每頁數量"
+// @Success 200 {object} dto.PromoRedemptionListResponse
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Router /api/v1/promo-codes/{id}/redemptions [get]
+func (pc *PromoCodeController) ListRedemptions(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
+		return
+	}
+
+	var req dto.PromoRedemptionListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		apperror.Write(c, apperror.Validation(err))
+		return
+	}
+
+	response, err := pc.promoCodeUsecase.ListRedemptions(c.Request.Context(), userID.(string), c.Param("id"), &req)
+	if err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
@@ -2,7 +2,9 @@ package controllers
 
 import (
 	"net/http"
+	"tennis-platform/backend/internal/apperror"
 	"tennis-platform/backend/internal/dto"
+	"tennis-platform/backend/internal/etag"
 	"tennis-platform/backend/internal/models"
 	"tennis-platform/backend/internal/services"
 
@@ -13,7 +15,7 @@ import (
 type RacketUsecaseInterface interface {
 	CreateRacket(req *dto.CreateRacketRequest) (*models.Racket, error)
 	GetRacketByID(racketID string) (*models.Racket, error)
-	UpdateRacket(racketID string, req *dto.UpdateRacketRequest) (*models.Racket, error)
+	UpdateRacket(racketID string, req *dto.UpdateRacketRequest, expectedVersion *int64) (*models.Racket, error)
 	DeleteRacket(racketID string) error
 	SearchRackets(req *dto.RacketSearchRequest) (*dto.RacketSearchResponse, error)
 	GetAvailableBrands() ([]string, error)
@@ -72,33 +74,20 @@ func NewRacketController(
 // @Produce json
 // @Param racket body dto.CreateRacketRequest true "球拍資訊"
 // @Success 201 {object} models.Racket
-// @Failure 400 {object} map[string]interface{}
-// @Failure 409 {object} map[string]interface{}
-// @Failure 500 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
+// @Failure 409 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
 // @Router /api/v1/rackets [post]
 func (c *RacketController) CreateRacket(ctx *gin.Context) {
 	var req dto.CreateRacketRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request format",
-			"details": err.Error(),
-		})
+		apperror.Write(ctx, apperror.Validation(err))
 		return
 	}
 
 	racket, err := c.racketUsecase.CreateRacket(&req)
 	if err != nil {
-		if err.Error() == "racket with same brand and model already exists" {
-			ctx.JSON(http.StatusConflict, gin.H{
-				"error":   "Racket already exists",
-				"message": "A racket with the same brand and model already exists",
-			})
-			return
-		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to create racket",
-			"message": err.Error(),
-		})
+		apperror.Write(ctx, err)
 		return
 	}
 
@@ -112,36 +101,26 @@ func (c *RacketController) CreateRacket(ctx *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param id path string true "球拍ID"
+// @Param If-None-Match header string false "先前取得的 ETag，相符時返回 304"
 // @Success 200 {object} models.Racket
-// @Failure 404 {object} map[string]interface{}
-// @Failure 500 {object} map[string]interface{}
+// @Success 304
+// @Failure 404 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
 // @Router /api/v1/rackets/{id} [get]
 func (c *RacketController) GetRacket(ctx *gin.Context) {
 	racketID := ctx.Param("id")
 	if racketID == "" {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "Racket ID is required",
-		})
+		apperror.Write(ctx, apperror.New(apperror.CodeMissingParam).With("name", "id"))
 		return
 	}
 
 	racket, err := c.racketUsecase.GetRacketByID(racketID)
 	if err != nil {
-		if err.Error() == "racket not found" {
-			ctx.JSON(http.StatusNotFound, gin.H{
-				"error":   "Racket not found",
-				"message": "The requested racket does not exist",
-			})
-			return
-		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to get racket",
-			"message": err.Error(),
-		})
+		apperror.Write(ctx, err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, racket)
+	etag.JSON(ctx, http.StatusOK, racket.Version, racket)
 }
 
 // UpdateRacket 更新球拍
@@ -152,54 +131,40 @@ func (c *RacketController) GetRacket(ctx *gin.Context) {
 // @Produce json
 // @Param id path string true "球拍ID"
 // @Param racket body dto.UpdateRacketRequest true "更新的球拍資訊"
+// @Param If-Match header string false "資源的 ETag，不符時返回 412"
 // @Success 200 {object} models.Racket
-// @Failure 400 {object} map[string]interface{}
-// @Failure 404 {object} map[string]interface{}
-// @Failure 409 {object} map[string]interface{}
-// @Failure 500 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Failure 409 {object} apperror.Problem
+// @Failure 412 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
 // @Router /api/v1/rackets/{id} [put]
 func (c *RacketController) UpdateRacket(ctx *gin.Context) {
 	racketID := ctx.Param("id")
 	if racketID == "" {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "Racket ID is required",
-		})
+		apperror.Write(ctx, apperror.New(apperror.CodeMissingParam).With("name", "id"))
+		return
+	}
+
+	expectedVersion, err := etag.IfMatchVersion(ctx)
+	if err != nil {
+		apperror.Write(ctx, err)
 		return
 	}
 
 	var req dto.UpdateRacketRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request format",
-			"details": err.Error(),
-		})
+		apperror.Write(ctx, apperror.Validation(err))
 		return
 	}
 
-	racket, err := c.racketUsecase.UpdateRacket(racketID, &req)
+	racket, err := c.racketUsecase.UpdateRacket(racketID, &req, expectedVersion)
 	if err != nil {
-		if err.Error() == "racket not found" {
-			ctx.JSON(http.StatusNotFound, gin.H{
-				"error":   "Racket not found",
-				"message": "The requested racket does not exist",
-			})
-			return
-		}
-		if err.Error() == "racket with same brand and model already exists" {
-			ctx.JSON(http.StatusConflict, gin.H{
-				"error":   "Racket already exists",
-				"message": "A racket with the same brand and model already exists",
-			})
-			return
-		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to update racket",
-			"message": err.Error(),
-		})
+		apperror.Write(ctx, err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, racket)
+	etag.JSON(ctx, http.StatusOK, racket.Version, racket)
 }
 
 // DeleteRacket 刪除球拍
@@ -210,31 +175,19 @@ func (c *RacketController) UpdateRacket(ctx *gin.Context) {
 // @Produce json
 // @Param id path string true "球拍ID"
 // @Success 204
-// @Failure 404 {object} map[string]interface{}
-// @Failure 500 {object} map[string]interface{}
+// @Failure 404 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
 // @Router /api/v1/rackets/{id} [delete]
 func (c *RacketController) DeleteRacket(ctx *gin.Context) {
 	racketID := ctx.Param("id")
 	if racketID == "" {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "Racket ID is required",
-		})
+		apperror.Write(ctx, apperror.New(apperror.CodeMissingParam).With("name", "id"))
 		return
 	}
 
 	err := c.racketUsecase.DeleteRacket(racketID)
 	if err != nil {
-		if err.Error() == "racket not found" {
-			ctx.JSON(http.StatusNotFound, gin.H{
-				"error":   "Racket not found",
-				"message": "The requested racket does not exist",
-			})
-			return
-		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to delete racket",
-			"message": err.Error(),
-		})
+		apperror.Write(ctx, err)
 		return
 	}
 
@@ -262,28 +215,23 @@ func (c *RacketController) DeleteRacket(ctx *gin.Context) {
 // @Param minRating query number false "最低評分"
 // @Param sortBy query string false "排序欄位" Enums(brand, model, price, rating, popularity)
 // @Param sortOrder query string false "排序順序" Enums(asc, desc)
+// @Param cursor query string false "分頁游標，提供時忽略 page"
 // @Param page query int false "頁碼"
 // @Param pageSize query int false "每頁數量"
 // @Success 200 {object} dto.RacketSearchResponse
-// @Failure 400 {object} map[string]interface{}
-// @Failure 500 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
 // @Router /api/v1/rackets [get]
 func (c *RacketController) SearchRackets(ctx *gin.Context) {
 	var req dto.RacketSearchRequest
 	if err := ctx.ShouldBindQuery(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid query parameters",
-			"details": err.Error(),
-		})
+		apperror.Write(ctx, apperror.Validation(err))
 		return
 	}
 
 	response, err := c.racketUsecase.SearchRackets(&req)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to search rackets",
-			"message": err.Error(),
-		})
+		apperror.Write(ctx, err)
 		return
 	}
 
@@ -297,15 +245,12 @@ func (c *RacketController) SearchRackets(ctx *gin.Context) {
 // @Accept json
 // @Produce json
 // @Success 200 {object} map[string]interface{}
-// @Failure 500 {object} map[string]interface{}
+// @Failure 500 {object} apperror.Problem
 // @Router /api/v1/rackets/brands [get]
 func (c *RacketController) GetAvailableBrands(ctx *gin.Context) {
 	brands, err := c.racketUsecase.GetAvailableBrands()
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to get available brands",
-			"message": err.Error(),
-		})
+		apperror.Write(ctx, err)
 		return
 	}
 
@@ -335,24 +280,19 @@ func (c *RacketController) GetRacketSpecifications(ctx *gin.Context) {
 // @Produce json
 // @Param images formData file true "圖片文件"
 // @Success 200 {object} map[string]interface{}
-// @Failure 400 {object} map[string]interface{}
-// @Failure 500 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
 // @Router /api/v1/rackets/images [post]
 func (c *RacketController) UploadRacketImages(ctx *gin.Context) {
 	form, err := ctx.MultipartForm()
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Failed to parse multipart form",
-			"message": err.Error(),
-		})
+		apperror.Write(ctx, apperror.Wrap(apperror.CodeUploadInvalidForm, err))
 		return
 	}
 
 	files := form.File["images"]
 	if len(files) == 0 {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "No images provided",
-		})
+		apperror.Write(ctx, apperror.New(apperror.CodeUploadMissingFile))
 		return
 	}
 
@@ -360,10 +300,7 @@ func (c *RacketController) UploadRacketImages(ctx *gin.Context) {
 	for _, file := range files {
 		result, err := c.uploadService.UploadFile(file, "rackets")
 		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{
-				"error":   "Failed to upload image",
-				"message": err.Error(),
-			})
+			apperror.Write(ctx, err)
 			return
 		}
 		imageURLs = append(imageURLs, result.URL)
@@ -382,41 +319,26 @@ func (c *RacketController) UploadRacketImages(ctx *gin.Context) {
 // @Produce json
 // @Param id path string true "球拍ID"
 // @Success 200 {object} map[string]interface{}
-// @Failure 404 {object} map[string]interface{}
-// @Failure 500 {object} map[string]interface{}
+// @Failure 404 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
 // @Router /api/v1/rackets/{id}/prices [get]
 func (c *RacketController) GetRacketPrices(ctx *gin.Context) {
 	racketID := ctx.Param("id")
 	if racketID == "" {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "Racket ID is required",
-		})
+		apperror.Write(ctx, apperror.New(apperror.CodeMissingParam).With("name", "id"))
 		return
 	}
 
 	// 檢查球拍是否存在
 	_, err := c.racketUsecase.GetRacketByID(racketID)
 	if err != nil {
-		if err.Error() == "racket not found" {
-			ctx.JSON(http.StatusNotFound, gin.H{
-				"error":   "Racket not found",
-				"message": "The requested racket does not exist",
-			})
-			return
-		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to verify racket",
-			"message": err.Error(),
-		})
+		apperror.Write(ctx, err)
 		return
 	}
 
 	prices, err := c.priceUsecase.GetRacketPrices(racketID)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to get racket prices",
-			"message": err.Error(),
-		})
+		apperror.Write(ctx, err)
 		return
 	}
 
@@ -438,25 +360,20 @@ func (c *RacketController) GetRacketPrices(ctx *gin.Context) {
 // @Param id path string true "球拍ID"
 // @Param price body dto.CreateRacketPriceRequest true "價格資訊"
 // @Success 201 {object} models.RacketPrice
-// @Failure 400 {object} map[string]interface{}
-// @Failure 404 {object} map[string]interface{}
-// @Failure 500 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
 // @Router /api/v1/rackets/{id}/prices [post]
 func (c *RacketController) CreateRacketPrice(ctx *gin.Context) {
 	racketID := ctx.Param("id")
 	if racketID == "" {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "Racket ID is required",
-		})
+		apperror.Write(ctx, apperror.New(apperror.CodeMissingParam).With("name", "id"))
 		return
 	}
 
 	var req dto.CreateRacketPriceRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request format",
-			"details": err.Error(),
-		})
+		apperror.Write(ctx, apperror.Validation(err))
 		return
 	}
 
@@ -465,17 +382,7 @@ func (c *RacketController) CreateRacketPrice(ctx *gin.Context) {
 
 	price, err := c.priceUsecase.CreateRacketPrice(&req)
 	if err != nil {
-		if err.Error() == "racket not found" {
-			ctx.JSON(http.StatusNotFound, gin.H{
-				"error":   "Racket not found",
-				"message": "The requested racket does not exist",
-			})
-			return
-		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to create racket price",
-			"message": err.Error(),
-		})
+		apperror.Write(ctx, err)
 		return
 	}
 
@@ -491,41 +398,26 @@ func (c *RacketController) CreateRacketPrice(ctx *gin.Context) {
 // @Param priceId path string true "價格ID"
 // @Param price body dto.UpdateRacketPriceRequest true "更新的價格資訊"
 // @Success 200 {object} models.RacketPrice
-// @Failure 400 {object} map[string]interface{}
-// @Failure 404 {object} map[string]interface{}
-// @Failure 500 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
 // @Router /api/v1/racket-prices/{priceId} [put]
 func (c *RacketController) UpdateRacketPrice(ctx *gin.Context) {
 	priceID := ctx.Param("priceId")
 	if priceID == "" {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "Price ID is required",
-		})
+		apperror.Write(ctx, apperror.New(apperror.CodeMissingParam).With("name", "priceId"))
 		return
 	}
 
 	var req dto.UpdateRacketPriceRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request format",
-			"details": err.Error(),
-		})
+		apperror.Write(ctx, apperror.Validation(err))
 		return
 	}
 
 	price, err := c.priceUsecase.UpdateRacketPrice(priceID, &req)
 	if err != nil {
-		if err.Error() == "price not found" {
-			ctx.JSON(http.StatusNotFound, gin.H{
-				"error":   "Price not found",
-				"message": "The requested price does not exist",
-			})
-			return
-		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to update racket price",
-			"message": err.Error(),
-		})
+		apperror.Write(ctx, err)
 		return
 	}
 
@@ -540,31 +432,19 @@ func (c *RacketController) UpdateRacketPrice(ctx *gin.Context) {
 // @Produce json
 // @Param priceId path string true "價格ID"
 // @Success 204
-// @Failure 404 {object} map[string]interface{}
-// @Failure 500 {object} map[string]interface{}
+// @Failure 404 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
 // @Router /api/v1/racket-prices/{priceId} [delete]
 func (c *RacketController) DeleteRacketPrice(ctx *gin.Context) {
 	priceID := ctx.Param("priceId")
 	if priceID == "" {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "Price ID is required",
-		})
+		apperror.Write(ctx, apperror.New(apperror.CodeMissingParam).With("name", "priceId"))
 		return
 	}
 
 	err := c.priceUsecase.DeleteRacketPrice(priceID)
 	if err != nil {
-		if err.Error() == "price not found" {
-			ctx.JSON(http.StatusNotFound, gin.H{
-				"error":   "Price not found",
-				"message": "The requested price does not exist",
-			})
-			return
-		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to delete racket price",
-			"message": err.Error(),
-		})
+		apperror.Write(ctx, err)
 		return
 	}
 
@@ -580,16 +460,14 @@ func (c *RacketController) DeleteRacketPrice(ctx *gin.Context) {
 // @Param priceId path string true "價格ID"
 // @Param availability body map[string]bool true "可用性狀態"
 // @Success 200 {object} map[string]interface{}
-// @Failure 400 {object} map[string]interface{}
-// @Failure 404 {object} map[string]interface{}
-// @Failure 500 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
 // @Router /api/v1/racket-prices/{priceId}/availability [put]
 func (c *RacketController) UpdatePriceAvailability(ctx *gin.Context) {
 	priceID := ctx.Param("priceId")
 	if priceID == "" {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "Price ID is required",
-		})
+		apperror.Write(ctx, apperror.New(apperror.CodeMissingParam).With("name", "priceId"))
 		return
 	}
 
@@ -597,26 +475,13 @@ func (c *RacketController) UpdatePriceAvailability(ctx *gin.Context) {
 		IsAvailable bool `json:"isAvailable"`
 	}
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request format",
-			"details": err.Error(),
-		})
+		apperror.Write(ctx, apperror.Validation(err))
 		return
 	}
 
 	err := c.priceUsecase.UpdatePriceAvailability(priceID, req.IsAvailable)
 	if err != nil {
-		if err.Error() == "price not found" {
-			ctx.JSON(http.StatusNotFound, gin.H{
-				"error":   "Price not found",
-				"message": "The requested price does not exist",
-			})
-			return
-		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to update price availability",
-			"message": err.Error(),
-		})
+		apperror.Write(ctx, err)
 		return
 	}
 
@@ -638,24 +503,19 @@ func (c *RacketController) UpdatePriceAvailability(ctx *gin.Context) {
 // @Param sortBy query string false "排序欄位" Enums(rating, date, helpful)
 // @Param sortOrder query string false "排序順序" Enums(asc, desc)
 // @Success 200 {object} dto.RacketReviewListResponse
-// @Failure 400 {object} map[string]interface{}
-// @Failure 500 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
 // @Router /api/v1/rackets/{id}/reviews [get]
 func (c *RacketController) GetRacketReviews(ctx *gin.Context) {
 	racketID := ctx.Param("id")
 	if racketID == "" {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "Racket ID is required",
-		})
+		apperror.Write(ctx, apperror.New(apperror.CodeMissingParam).With("name", "id"))
 		return
 	}
 
 	var req dto.RacketReviewListRequest
 	if err := ctx.ShouldBindQuery(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid query parameters",
-			"details": err.Error(),
-		})
+		apperror.Write(ctx, apperror.Validation(err))
 		return
 	}
 
@@ -664,10 +524,7 @@ func (c *RacketController) GetRacketReviews(ctx *gin.Context) {
 
 	response, err := c.reviewUsecase.GetRacketReviews(&req)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to get racket reviews",
-			"message": err.Error(),
-		})
+		apperror.Write(ctx, err)
 		return
 	}
 
@@ -683,33 +540,26 @@ func (c *RacketController) GetRacketReviews(ctx *gin.Context) {
 // @Param id path string true "球拍ID"
 // @Param review body dto.CreateRacketReviewRequest true "評價資訊"
 // @Success 201 {object} models.RacketReview
-// @Failure 400 {object} map[string]interface{}
-// @Failure 404 {object} map[string]interface{}
-// @Failure 500 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
 // @Router /api/v1/rackets/{id}/reviews [post]
 func (c *RacketController) CreateRacketReview(ctx *gin.Context) {
 	racketID := ctx.Param("id")
 	if racketID == "" {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "Racket ID is required",
-		})
+		apperror.Write(ctx, apperror.New(apperror.CodeMissingParam).With("name", "id"))
 		return
 	}
 
 	userID, exists := ctx.Get("userID")
 	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{
-			"error": "User not authenticated",
-		})
+		apperror.Write(ctx, apperror.New(apperror.CodeUnauthorized))
 		return
 	}
 
 	var req dto.CreateRacketReviewRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request format",
-			"details": err.Error(),
-		})
+		apperror.Write(ctx, apperror.Validation(err))
 		return
 	}
 
@@ -718,17 +568,7 @@ func (c *RacketController) CreateRacketReview(ctx *gin.Context) {
 
 	review, err := c.reviewUsecase.CreateRacketReview(userID.(string), &req)
 	if err != nil {
-		if err.Error() == "racket not found" {
-			ctx.JSON(http.StatusNotFound, gin.H{
-				"error":   "Racket not found",
-				"message": "The requested racket does not exist",
-			})
-			return
-		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to create racket review",
-			"message": err.Error(),
-		})
+		apperror.Write(ctx, err)
 		return
 	}
 
@@ -743,31 +583,19 @@ func (c *RacketController) CreateRacketReview(ctx *gin.Context) {
 // @Produce json
 // @Param id path string true "球拍ID"
 // @Success 200 {object} dto.RacketReviewStatistics
-// @Failure 404 {object} map[string]interface{}
-// @Failure 500 {object} map[string]interface{}
+// @Failure 404 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
 // @Router /api/v1/rackets/{id}/reviews/statistics [get]
 func (c *RacketController) GetRacketReviewStatistics(ctx *gin.Context) {
 	racketID := ctx.Param("id")
 	if racketID == "" {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "Racket ID is required",
-		})
+		apperror.Write(ctx, apperror.New(apperror.CodeMissingParam).With("name", "id"))
 		return
 	}
 
 	statistics, err := c.reviewUsecase.GetRacketReviewStatistics(racketID)
 	if err != nil {
-		if err.Error() == "racket not found" {
-			ctx.JSON(http.StatusNotFound, gin.H{
-				"error":   "Racket not found",
-				"message": "The requested racket does not exist",
-			})
-			return
-		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to get review statistics",
-			"message": err.Error(),
-		})
+		apperror.Write(ctx, err)
 		return
 	}
 
@@ -783,24 +611,20 @@ func (c *RacketController) GetRacketReviewStatistics(ctx *gin.Context) {
 // @Param reviewId path string true "評價ID"
 // @Param helpful body map[string]bool true "是否有用"
 // @Success 200 {object} map[string]interface{}
-// @Failure 400 {object} map[string]interface{}
-// @Failure 404 {object} map[string]interface{}
-// @Failure 500 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
 // @Router /api/v1/racket-reviews/{reviewId}/helpful [post]
 func (c *RacketController) MarkRacketReviewHelpful(ctx *gin.Context) {
 	reviewID := ctx.Param("reviewId")
 	if reviewID == "" {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "Review ID is required",
-		})
+		apperror.Write(ctx, apperror.New(apperror.CodeMissingParam).With("name", "reviewId"))
 		return
 	}
 
 	userID, exists := ctx.Get("userID")
 	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{
-			"error": "User not authenticated",
-		})
+		apperror.Write(ctx, apperror.New(apperror.CodeUnauthorized))
 		return
 	}
 
@@ -808,26 +632,13 @@ func (c *RacketController) MarkRacketReviewHelpful(ctx *gin.Context) {
 		Helpful bool `json:"helpful"`
 	}
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request format",
-			"details": err.Error(),
-		})
+		apperror.Write(ctx, apperror.Validation(err))
 		return
 	}
 
 	err := c.reviewUsecase.MarkReviewHelpful(reviewID, userID.(string), req.Helpful)
 	if err != nil {
-		if err.Error() == "review not found" {
-			ctx.JSON(http.StatusNotFound, gin.H{
-				"error":   "Review not found",
-				"message": "The requested review does not exist",
-			})
-			return
-		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to mark review helpful",
-			"message": err.Error(),
-		})
+		apperror.Write(ctx, err)
 		return
 	}
 
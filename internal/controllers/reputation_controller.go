@@ -3,6 +3,7 @@ package controllers
 import (
 	"net/http"
 	"strconv"
+	"tennis-platform/backend/internal/apperror"
 	"tennis-platform/backend/internal/dto"
 	"tennis-platform/backend/internal/usecases"
 
@@ -30,27 +31,20 @@ func NewReputationController(db *gorm.DB) *ReputationController {
 // @Produce json
 // @Param userId path string true "用戶ID"
 // @Success 200 {object} models.ReputationScore
-// @Failure 400 {object} map[string]interface{}
-// @Failure 404 {object} map[string]interface{}
-// @Failure 500 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
 // @Router /api/reputation/users/{userId}/score [get]
 func (rc *ReputationController) GetUserReputationScore(c *gin.Context) {
 	userID := c.Param("userId")
 	if userID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "INVALID_USER_ID",
-			"message": "用戶ID不能為空",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeMissingParam).With("name", "userId"))
 		return
 	}
 
 	reputation, err := rc.reputationUseCase.GetUserReputationScore(userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "GET_REPUTATION_FAILED",
-			"message": "獲取信譽分數失敗",
-			"details": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
@@ -65,27 +59,20 @@ func (rc *ReputationController) GetUserReputationScore(c *gin.Context) {
 // @Produce json
 // @Param userId path string true "用戶ID"
 // @Success 200 {object} models.ReputationHistory
-// @Failure 400 {object} map[string]interface{}
-// @Failure 404 {object} map[string]interface{}
-// @Failure 500 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
 // @Router /api/reputation/users/{userId}/history [get]
 func (rc *ReputationController) GetUserReputationHistory(c *gin.Context) {
 	userID := c.Param("userId")
 	if userID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "INVALID_USER_ID",
-			"message": "用戶ID不能為空",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeMissingParam).With("name", "userId"))
 		return
 	}
 
 	history, err := rc.reputationUseCase.GetUserReputationHistory(userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "GET_HISTORY_FAILED",
-			"message": "獲取信譽歷史失敗",
-			"details": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
@@ -101,36 +88,25 @@ func (rc *ReputationController) GetUserReputationHistory(c *gin.Context) {
 // @Param userId path string true "用戶ID"
 // @Param request body dto.RecordMatchAttendanceRequest true "出席記錄請求"
 // @Success 200 {object} map[string]interface{}
-// @Failure 400 {object} map[string]interface{}
-// @Failure 500 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
 // @Router /api/reputation/users/{userId}/attendance [post]
 func (rc *ReputationController) RecordMatchAttendance(c *gin.Context) {
 	userID := c.Param("userId")
 	if userID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "INVALID_USER_ID",
-			"message": "用戶ID不能為空",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeMissingParam).With("name", "userId"))
 		return
 	}
 
 	var req dto.RecordMatchAttendanceRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "INVALID_REQUEST",
-			"message": "請求參數無效",
-			"details": err.Error(),
-		})
+		apperror.Write(c, apperror.Validation(err))
 		return
 	}
 
 	err := rc.reputationUseCase.RecordMatchAttendance(userID, req.MatchID, req.Status)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "RECORD_ATTENDANCE_FAILED",
-			"message": "記錄出席情況失敗",
-			"details": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
@@ -148,36 +124,25 @@ func (rc *ReputationController) RecordMatchAttendance(c *gin.Context) {
 // @Param userId path string true "用戶ID"
 // @Param request body dto.RecordMatchPunctualityRequest true "準時記錄請求"
 // @Success 200 {object} map[string]interface{}
-// @Failure 400 {object} map[string]interface{}
-// @Failure 500 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
 // @Router /api/reputation/users/{userId}/punctuality [post]
 func (rc *ReputationController) RecordMatchPunctuality(c *gin.Context) {
 	userID := c.Param("userId")
 	if userID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "INVALID_USER_ID",
-			"message": "用戶ID不能為空",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeMissingParam).With("name", "userId"))
 		return
 	}
 
 	var req dto.RecordMatchPunctualityRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "INVALID_REQUEST",
-			"message": "請求參數無效",
-			"details": err.Error(),
-		})
+		apperror.Write(c, apperror.Validation(err))
 		return
 	}
 
 	err := rc.reputationUseCase.RecordMatchPunctuality(userID, req.MatchID, req.ArrivalTime)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "RECORD_PUNCTUALITY_FAILED",
-			"message": "記錄準時情況失敗",
-			"details": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
@@ -195,36 +160,25 @@ func (rc *ReputationController) RecordMatchPunctuality(c *gin.Context) {
 // @Param userId path string true "用戶ID"
 // @Param request body dto.RecordSkillLevelAccuracyRequest true "技術準確度記錄請求"
 // @Success 200 {object} map[string]interface{}
-// @Failure 400 {object} map[string]interface{}
-// @Failure 500 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
 // @Router /api/reputation/users/{userId}/skill-accuracy [post]
 func (rc *ReputationController) RecordSkillLevelAccuracy(c *gin.Context) {
 	userID := c.Param("userId")
 	if userID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "INVALID_USER_ID",
-			"message": "用戶ID不能為空",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeMissingParam).With("name", "userId"))
 		return
 	}
 
 	var req dto.RecordSkillLevelAccuracyRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "INVALID_REQUEST",
-			"message": "請求參數無效",
-			"details": err.Error(),
-		})
+		apperror.Write(c, apperror.Validation(err))
 		return
 	}
 
 	err := rc.reputationUseCase.RecordSkillLevelAccuracy(userID, req.MatchID, req.ReportedLevel, req.ObservedLevel)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "RECORD_SKILL_ACCURACY_FAILED",
-			"message": "記錄技術準確度失敗",
-			"details": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
@@ -242,36 +196,26 @@ func (rc *ReputationController) RecordSkillLevelAccuracy(c *gin.Context) {
 // @Param userId path string true "被評價用戶ID"
 // @Param request body dto.SubmitBehaviorReviewRequest true "行為評價請求"
 // @Success 200 {object} map[string]interface{}
-// @Failure 400 {object} map[string]interface{}
-// @Failure 500 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
 // @Router /api/reputation/users/{userId}/behavior-review [post]
 func (rc *ReputationController) SubmitBehaviorReview(c *gin.Context) {
 	userID := c.Param("userId")
 	if userID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "INVALID_USER_ID",
-			"message": "用戶ID不能為空",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeMissingParam).With("name", "userId"))
 		return
 	}
 
 	// 從JWT中獲取評價者ID
 	reviewerID, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error":   "UNAUTHORIZED",
-			"message": "未授權的請求",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
 		return
 	}
 
 	var req dto.SubmitBehaviorReviewRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "INVALID_REQUEST",
-			"message": "請求參數無效",
-			"details": err.Error(),
-		})
+		apperror.Write(c, apperror.Validation(err))
 		return
 	}
 
@@ -284,11 +228,7 @@ func (rc *ReputationController) SubmitBehaviorReview(c *gin.Context) {
 		req.Tags,
 	)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "SUBMIT_REVIEW_FAILED",
-			"message": "提交評價失敗",
-			"details": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
@@ -305,8 +245,8 @@ func (rc *ReputationController) SubmitBehaviorReview(c *gin.Context) {
 // @Produce json
 // @Param limit query int false "返回數量限制" default(50)
 // @Success 200 {array} models.ReputationScore
-// @Failure 400 {object} map[string]interface{}
-// @Failure 500 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
 // @Router /api/reputation/leaderboard [get]
 func (rc *ReputationController) GetReputationLeaderboard(c *gin.Context) {
 	limitStr := c.DefaultQuery("limit", "50")
@@ -317,11 +257,7 @@ func (rc *ReputationController) GetReputationLeaderboard(c *gin.Context) {
 
 	leaderboard, err := rc.reputationUseCase.GetReputationLeaderboard(limit)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "GET_LEADERBOARD_FAILED",
-			"message": "獲取排行榜失敗",
-			"details": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
@@ -335,16 +271,12 @@ func (rc *ReputationController) GetReputationLeaderboard(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Success 200 {object} usecases.ReputationStats
-// @Failure 500 {object} map[string]interface{}
+// @Failure 500 {object} apperror.Problem
 // @Router /api/reputation/stats [get]
 func (rc *ReputationController) GetReputationStats(c *gin.Context) {
 	stats, err := rc.reputationUseCase.GetReputationStats()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "GET_STATS_FAILED",
-			"message": "獲取統計信息失敗",
-			"details": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
@@ -359,26 +291,19 @@ func (rc *ReputationController) GetReputationStats(c *gin.Context) {
 // @Produce json
 // @Param userId path string true "用戶ID"
 // @Success 200 {object} map[string]interface{}
-// @Failure 400 {object} map[string]interface{}
-// @Failure 500 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
 // @Router /api/reputation/users/{userId}/update-ntrp [post]
 func (rc *ReputationController) UpdateUserNTRPLevel(c *gin.Context) {
 	userID := c.Param("userId")
 	if userID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "INVALID_USER_ID",
-			"message": "用戶ID不能為空",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeMissingParam).With("name", "userId"))
 		return
 	}
 
 	err := rc.reputationUseCase.UpdateUserNTRPLevel(userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "UPDATE_NTRP_FAILED",
-			"message": "更新NTRP等級失敗",
-			"details": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
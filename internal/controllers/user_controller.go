@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"net/http"
+	"tennis-platform/backend/internal/apperror"
 	"tennis-platform/backend/internal/dto"
 	"tennis-platform/backend/internal/models"
 	"tennis-platform/backend/internal/services"
@@ -41,23 +42,19 @@ func NewUserController(userUsecase UserUsecaseInterface, uploadService *services
 // @Produce json
 // @Security BearerAuth
 // @Success 200 {object} models.User
-// @Failure 401 {object} map[string]interface{}
-// @Failure 404 {object} map[string]interface{}
+// @Failure 401 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
 // @Router /api/v1/users/profile [get]
 func (uc *UserController) GetProfile(c *gin.Context) {
 	userID, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "未找到用戶信息",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
 		return
 	}
 
 	user, err := uc.userUsecase.GetUserByID(userID.(string))
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
@@ -73,32 +70,25 @@ func (uc *UserController) GetProfile(c *gin.Context) {
 // @Security BearerAuth
 // @Param request body dto.UpdateProfileRequest true "用戶檔案更新請求"
 // @Success 200 {object} models.User
-// @Failure 400 {object} map[string]interface{}
-// @Failure 401 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
 // @Router /api/v1/users/profile [put]
 func (uc *UserController) UpdateProfile(c *gin.Context) {
 	userID, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "未找到用戶信息",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
 		return
 	}
 
 	var profileUpdate dto.UpdateProfileRequest
 	if err := c.ShouldBindJSON(&profileUpdate); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "請求參數錯誤",
-			"details": err.Error(),
-		})
+		apperror.Write(c, apperror.Validation(err))
 		return
 	}
 
 	user, err := uc.userUsecase.UpdateUserProfile(userID.(string), &profileUpdate)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
@@ -114,32 +104,25 @@ func (uc *UserController) UpdateProfile(c *gin.Context) {
 // @Security BearerAuth
 // @Param request body dto.CreateProfileRequest true "用戶檔案創建請求"
 // @Success 201 {object} models.User
-// @Failure 400 {object} map[string]interface{}
-// @Failure 401 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
 // @Router /api/v1/users/profile [post]
 func (uc *UserController) CreateProfile(c *gin.Context) {
 	userID, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "未找到用戶信息",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
 		return
 	}
 
 	var profileCreate dto.CreateProfileRequest
 	if err := c.ShouldBindJSON(&profileCreate); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "請求參數錯誤",
-			"details": err.Error(),
-		})
+		apperror.Write(c, apperror.Validation(err))
 		return
 	}
 
 	user, err := uc.userUsecase.CreateUserProfile(userID.(string), &profileCreate)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
@@ -155,32 +138,25 @@ func (uc *UserController) CreateProfile(c *gin.Context) {
 // @Security BearerAuth
 // @Param request body dto.UserPreferencesRequest true "用戶偏好設定請求"
 // @Success 200 {object} models.User
-// @Failure 400 {object} map[string]interface{}
-// @Failure 401 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
 // @Router /api/v1/users/preferences [put]
 func (uc *UserController) UpdatePreferences(c *gin.Context) {
 	userID, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "未找到用戶信息",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
 		return
 	}
 
 	var preferencesUpdate dto.UserPreferencesRequest
 	if err := c.ShouldBindJSON(&preferencesUpdate); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "請求參數錯誤",
-			"details": err.Error(),
-		})
+		apperror.Write(c, apperror.Validation(err))
 		return
 	}
 
 	user, err := uc.userUsecase.UpdateUserPreferences(userID.(string), &preferencesUpdate)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
@@ -196,32 +172,25 @@ func (uc *UserController) UpdatePreferences(c *gin.Context) {
 // @Security BearerAuth
 // @Param request body dto.LocationUpdateRequest true "位置更新請求"
 // @Success 200 {object} models.User
-// @Failure 400 {object} map[string]interface{}
-// @Failure 401 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
 // @Router /api/v1/users/location [put]
 func (uc *UserController) UpdateLocation(c *gin.Context) {
 	userID, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "未找到用戶信息",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
 		return
 	}
 
 	var locationUpdate dto.LocationUpdateRequest
 	if err := c.ShouldBindJSON(&locationUpdate); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "請求參數錯誤",
-			"details": err.Error(),
-		})
+		apperror.Write(c, apperror.Validation(err))
 		return
 	}
 
 	user, err := uc.userUsecase.UpdateUserLocation(userID.(string), &locationUpdate)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
@@ -237,42 +206,34 @@ func (uc *UserController) UpdateLocation(c *gin.Context) {
 // @Security BearerAuth
 // @Param avatar formData file true "頭像文件"
 // @Success 200 {object} map[string]interface{}
-// @Failure 400 {object} map[string]interface{}
-// @Failure 401 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
 // @Router /api/v1/users/avatar [post]
 func (uc *UserController) UploadAvatar(c *gin.Context) {
 	userID, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "未找到用戶信息",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
 		return
 	}
 
 	// 獲取上傳的文件
 	file, err := c.FormFile("avatar")
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "未找到上傳文件",
-		})
+		apperror.Write(c, apperror.New(apperror.CodeUploadMissingFile))
 		return
 	}
 
 	// 上傳文件
 	uploadResult, err := uc.uploadService.UploadAvatar(file, userID.(string))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
 	// 更新用戶檔案中的頭像URL
 	user, err := uc.userUsecase.UpdateUserAvatar(userID.(string), uploadResult.URL)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		apperror.Write(c, err)
 		return
 	}
 
@@ -77,7 +77,7 @@ func setupTestController() (*UserController, *MockUserUsecase) {
 	}
 
 	mockUsecase := new(MockUserUsecase)
-	uploadService := services.NewUploadService(cfg)
+	uploadService := services.NewUploadService(cfg, services.NewLocalStorage(cfg.Upload.UploadPath, "test-signing-secret"))
 	controller := NewUserController(mockUsecase, uploadService)
 
 	return controller, mockUsecase
@@ -364,7 +364,8 @@ func TestUploadAvatar_MissingFile(t *testing.T) {
 	var response map[string]interface{}
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	assert.NoError(t, err)
-	assert.Contains(t, response["error"], "未找到上傳文件")
+	assert.Equal(t, "upload.missing_file", response["code"])
+	assert.Equal(t, "未找到上傳文件", response["detail"])
 }
 
 // TestValidationErrors 測試驗證錯誤
@@ -395,5 +396,6 @@ func TestCreateProfile_ValidationError(t *testing.T) {
 	var response map[string]interface{}
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	assert.NoError(t, err)
-	assert.Contains(t, response["error"], "請求參數錯誤")
+	assert.Equal(t, "validation_failed", response["code"])
+	assert.NotEmpty(t, response["errors"])
 }
@@ -0,0 +1,282 @@
+package controllers
+
+import (
+	"net/http"
+	"tennis-platform/backend/internal/apperror"
+	"tennis-platform/backend/internal/dto"
+	"tennis-platform/backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WebhookUsecaseInterface Webhook 用例接口
+type WebhookUsecaseInterface interface {
+	CreateSubscription(ownerID string, req *dto.CreateWebhookRequest) (*dto.WebhookSecretResponse, error)
+	GetSubscriptions(ownerID string) ([]models.WebhookSubscription, error)
+	GetSubscription(subscriptionID, ownerID string) (*models.WebhookSubscription, error)
+	UpdateSubscription(subscriptionID, ownerID string, req *dto.UpdateWebhookRequest) (*models.WebhookSubscription, error)
+	DeleteSubscription(subscriptionID, ownerID string) error
+	RotateSecret(subscriptionID, ownerID string) (*dto.WebhookSecretResponse, error)
+	GetDeliveries(subscriptionID, ownerID string, req *dto.WebhookDeliveryListRequest) (*dto.WebhookDeliveryListResponse, error)
+	Redeliver(subscriptionID, deliveryID, ownerID string) (*models.WebhookDelivery, error)
+}
+
+// WebhookController Webhook 控制器
+type WebhookController struct {
+	webhookUsecase WebhookUsecaseInterface
+}
+
+// NewWebhookController 創建新的 Webhook 控制器
+func NewWebhookController(webhookUsecase WebhookUsecaseInterface) *WebhookController {
+	return &WebhookController{
+		webhookUsecase: webhookUsecase,
+	}
+}
+
+// CreateWebhook 創建 Webhook 訂閱
+// @Summary 創建 Webhook 訂閱
+// @Description 為當前用戶擁有的場地事件創建 Webhook 訂閱，簽名密鑰僅在此回應中返回一次
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.CreateWebhookRequest true "訂閱信息"
+// @Success 201 {object} dto.WebhookSecretResponse
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Router /api/v1/webhooks [post]
+func (wc *WebhookController) CreateWebhook(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
+		return
+	}
+
+	var req dto.CreateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperror.Write(c, apperror.Validation(err))
+		return
+	}
+
+	resp, err := wc.webhookUsecase.CreateSubscription(userID.(string), &req)
+	if err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, resp)
+}
+
+// GetWebhooks 獲取 Webhook 訂閱列表
+// @Summary 獲取 Webhook 訂閱列表
+// @Description 獲取當前用戶的所有 Webhook 訂閱
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} apperror.Problem
+// @Router /api/v1/webhooks [get]
+func (wc *WebhookController) GetWebhooks(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
+		return
+	}
+
+	subscriptions, err := wc.webhookUsecase.GetSubscriptions(userID.(string))
+	if err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"webhooks": subscriptions,
+	})
+}
+
+// GetWebhook 獲取 Webhook 訂閱詳情
+// @Summary 獲取 Webhook 訂閱詳情
+// @Description 獲取指定 Webhook 訂閱的詳細信息
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "訂閱ID"
+// @Success 200 {object} models.WebhookSubscription
+// @Failure 401 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Router /api/v1/webhooks/{id} [get]
+func (wc *WebhookController) GetWebhook(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
+		return
+	}
+
+	subscription, err := wc.webhookUsecase.GetSubscription(c.Param("id"), userID.(string))
+	if err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, subscription)
+}
+
+// UpdateWebhook 更新 Webhook 訂閱
+// @Summary 更新 Webhook 訂閱
+// @Description 更新 Webhook 地址、事件類型或啟用狀態；重新啟用會清除連續失敗計數
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "訂閱ID"
+// @Param request body dto.UpdateWebhookRequest true "更新信息"
+// @Success 200 {object} models.WebhookSubscription
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Router /api/v1/webhooks/{id} [put]
+func (wc *WebhookController) UpdateWebhook(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
+		return
+	}
+
+	var req dto.UpdateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperror.Write(c, apperror.Validation(err))
+		return
+	}
+
+	subscription, err := wc.webhookUsecase.UpdateSubscription(c.Param("id"), userID.(string), &req)
+	if err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, subscription)
+}
+
+// DeleteWebhook 刪除 Webhook 訂閱
+// @Summary 刪除 Webhook 訂閱
+// @Description 刪除指定的 Webhook 訂閱
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "訂閱ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Router /api/v1/webhooks/{id} [delete]
+func (wc *WebhookController) DeleteWebhook(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
+		return
+	}
+
+	if err := wc.webhookUsecase.DeleteSubscription(c.Param("id"), userID.(string)); err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Webhook 訂閱刪除成功",
+	})
+}
+
+// RotateWebhookSecret 輪換 Webhook 簽名密鑰
+// @Summary 輪換 Webhook 簽名密鑰
+// @Description 生成新的簽名密鑰，舊密鑰立即失效
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "訂閱ID"
+// @Success 200 {object} dto.WebhookSecretResponse
+// @Failure 401 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Router /api/v1/webhooks/{id}/rotate-secret [post]
+func (wc *WebhookController) RotateWebhookSecret(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
+		return
+	}
+
+	resp, err := wc.webhookUsecase.RotateSecret(c.Param("id"), userID.(string))
+	if err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetWebhookDeliveries 獲取 Webhook 投遞記錄
+// @Summary 獲取 Webhook 投遞記錄
+// @Description 分頁獲取指定訂閱的投遞記錄
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "訂閱ID"
+// @Param status query string false "投遞狀態" Enums(pending,succeeded,failed)
+// @Param page query int false "頁碼" default(1)
+// @Param pageSize query int false "每頁數量" default(20)
+// @Success 200 {object} dto.WebhookDeliveryListResponse
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Router /api/v1/webhooks/{id}/deliveries [get]
+func (wc *WebhookController) GetWebhookDeliveries(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
+		return
+	}
+
+	var req dto.WebhookDeliveryListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		apperror.Write(c, apperror.Validation(err))
+		return
+	}
+
+	resp, err := wc.webhookUsecase.GetDeliveries(c.Param("id"), userID.(string), &req)
+	if err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// RedeliverWebhook 重新投遞 Webhook
+// @Summary 重新投遞 Webhook
+// @Description 以原始內容重新投遞指定記錄，並返回新的投遞結果
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "訂閱ID"
+// @Param deliveryId path string true "投遞記錄ID"
+// @Success 200 {object} models.WebhookDelivery
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Router /api/v1/webhooks/{id}/deliveries/{deliveryId}/redeliver [post]
+func (wc *WebhookController) RedeliverWebhook(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
+		return
+	}
+
+	delivery, err := wc.webhookUsecase.Redeliver(c.Param("id"), c.Param("deliveryId"), userID.(string))
+	if err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, delivery)
+}
@@ -81,6 +81,111 @@ func (m *MigrationManager) RunMigrations() error {
 			description: "Add lesson types table and update lessons table",
 			up:          m.migration007AddLessonTypes,
 		},
+		{
+			version:     "008_add_outbox",
+			description: "Add transactional outbox and processed event tables",
+			up:          m.migration008AddOutbox,
+		},
+		{
+			version:     "009_add_webhooks",
+			description: "Add webhook subscriptions and delivery log tables",
+			up:          m.migration009AddWebhooks,
+		},
+		{
+			version:     "010_add_resource_versions",
+			description: "Add optimistic locking version columns",
+			up:          m.migration010AddResourceVersions,
+		},
+		{
+			version:     "011_add_image_assets",
+			description: "Add processed image metadata table",
+			up:          m.migration011AddImageAssets,
+		},
+		{
+			version:     "012_add_calendar_feeds",
+			description: "Add iCalendar feed token table",
+			up:          m.migration012AddCalendarFeeds,
+		},
+		{
+			version:     "013_add_coach_calendar_sources",
+			description: "Add coach external calendar sources and busy blocks",
+			up:          m.migration013AddCoachCalendarSources,
+		},
+		{
+			version:     "014_add_payments",
+			description: "Add payments table and payment deadlines",
+			up:          m.migration014AddPayments,
+		},
+		{
+			version:     "015_add_cancellation_policies",
+			description: "Add cancellation policies, overrides and cancellation records",
+			up:          m.migration015AddCancellationPolicies,
+		},
+		{
+			version:     "016_add_court_price_rules",
+			description: "Add court price rules and booking price breakdowns",
+			up:          m.migration016AddCourtPriceRules,
+		},
+		{
+			version:     "017_add_court_units",
+			description: "Add bookable court units within venues",
+			up:          m.migration017AddCourtUnits,
+		},
+		{
+			version:     "018_add_booking_overlap_guards",
+			description: "Add slot holds and booking overlap exclusion constraints",
+			up:          m.migration018AddBookingOverlapGuards,
+		},
+		{
+			version:     "019_add_booking_series",
+			description: "Add recurring booking series",
+			up:          m.migration019AddBookingSeries,
+		},
+		{
+			version:     "020_add_booking_waitlist",
+			description: "Add waitlist for fully booked court slots",
+			up:          m.migration020AddBookingWaitlist,
+		},
+		{
+			version:     "021_add_court_closures",
+			description: "Add dated court closures, altered hours and blackouts",
+			up:          m.migration021AddCourtClosures,
+		},
+		{
+			version:     "022_add_booking_payment_splits",
+			description: "Add split payment of bookings among co-payers",
+			up:          m.migration022AddBookingPaymentSplits,
+		},
+		{
+			version:     "023_add_court_slot_demands",
+			description: "Add unmet slot demand counters for court analytics",
+			up:          m.migration023AddCourtSlotDemands,
+		},
+		{
+			version:     "024_add_booking_check_in",
+			description: "Add booking check-in and no-show tracking",
+			up:          m.migration024AddBookingCheckIn,
+		},
+		{
+			version:     "025_add_invoices",
+			description: "Add numbered invoices and credit notes for payments",
+			up:          m.migration025AddInvoices,
+		},
+		{
+			version:     "026_add_promo_codes",
+			description: "Add promo codes and redemptions for bookings and lessons",
+			up:          m.migration026AddPromoCodes,
+		},
+		{
+			version:     "027_add_court_equipment",
+			description: "Add rentable court equipment and booking add-ons",
+			up:          m.migration027AddCourtEquipment,
+		},
+		{
+			version:     "028_add_upload_sessions",
+			description: "Record the uploader of presigned uploads",
+			up:          m.migration028AddUploadSessions,
+		},
 	}
 
 	// 執行遷移
@@ -648,6 +753,613 @@ func (m *MigrationManager) migration007AddLessonTypes(tx *gorm.DB) error {
 	return nil
 }
 
+// migration008AddOutbox 添加領域事件發件箱表
+func (m *MigrationManager) migration008AddOutbox(tx *gorm.DB) error {
+	if err := tx.AutoMigrate(&models.OutboxEvent{}, &models.ProcessedEvent{}); err != nil {
+		return fmt.Errorf("failed to create outbox tables: %w", err)
+	}
+
+	// 添加派送查詢索引
+	outboxIndexes := []string{
+		"CREATE INDEX IF NOT EXISTS idx_outbox_events_status_available ON outbox_events(status, available_at) WHERE status = 'pending'",
+		"CREATE INDEX IF NOT EXISTS idx_outbox_events_aggregate ON outbox_events(aggregate_type, aggregate_id, created_at)",
+	}
+
+	for _, indexSQL := range outboxIndexes {
+		if err := tx.Exec(indexSQL).Error; err != nil {
+			log.Printf("Warning: Failed to create outbox index: %s, Error: %v", indexSQL, err)
+		}
+	}
+
+	// 添加約束
+	outboxConstraints := []string{
+		"ALTER TABLE outbox_events ADD CONSTRAINT IF NOT EXISTS check_outbox_event_status CHECK (status IN ('pending', 'dispatched', 'failed'))",
+	}
+
+	for _, constraintSQL := range outboxConstraints {
+		if err := tx.Exec(constraintSQL).Error; err != nil {
+			log.Printf("Warning: Failed to add outbox constraint: %s, Error: %v", constraintSQL, err)
+		}
+	}
+
+	// 添加註釋
+	comments := []string{
+		"COMMENT ON TABLE outbox_events IS '領域事件發件箱表'",
+		"COMMENT ON COLUMN outbox_events.status IS '派送狀態：pending=待派送，dispatched=已派送，failed=超過重試次數'",
+		"COMMENT ON COLUMN outbox_events.available_at IS '下次可派送時間（重試退避與領取租約）'",
+		"COMMENT ON TABLE processed_events IS '訂閱者已處理事件記錄（去重用）'",
+	}
+
+	for _, commentSQL := range comments {
+		if err := tx.Exec(commentSQL).Error; err != nil {
+			log.Printf("Warning: Failed to add comment: %s, Error: %v", commentSQL, err)
+		}
+	}
+
+	return nil
+}
+
+// migration009AddWebhooks 添加 Webhook 訂閱及投遞記錄表
+func (m *MigrationManager) migration009AddWebhooks(tx *gorm.DB) error {
+	if err := tx.AutoMigrate(&models.WebhookSubscription{}, &models.WebhookDelivery{}); err != nil {
+		return fmt.Errorf("failed to create webhook tables: %w", err)
+	}
+
+	// 添加索引
+	webhookIndexes := []string{
+		"CREATE INDEX IF NOT EXISTS idx_webhook_subscriptions_owner_active ON webhook_subscriptions(owner_id, is_active) WHERE deleted_at IS NULL",
+		"CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries(status, next_attempt_at) WHERE status = 'pending'",
+		"CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_subscription_created ON webhook_deliveries(subscription_id, created_at DESC)",
+	}
+
+	for _, indexSQL := range webhookIndexes {
+		if err := tx.Exec(indexSQL).Error; err != nil {
+			log.Printf("Warning: Failed to create webhook index: %s, Error: %v", indexSQL, err)
+		}
+	}
+
+	// 添加約束
+	webhookConstraints := []string{
+		"ALTER TABLE webhook_deliveries ADD CONSTRAINT IF NOT EXISTS check_webhook_delivery_status CHECK (status IN ('pending', 'succeeded', 'failed'))",
+	}
+
+	for _, constraintSQL := range webhookConstraints {
+		if err := tx.Exec(constraintSQL).Error; err != nil {
+			log.Printf("Warning: Failed to add webhook constraint: %s, Error: %v", constraintSQL, err)
+		}
+	}
+
+	// 添加註釋
+	comments := []string{
+		"COMMENT ON TABLE webhook_subscriptions IS 'Webhook 訂閱表'",
+		"COMMENT ON COLUMN webhook_subscriptions.secret IS 'HMAC-SHA256 簽名密鑰'",
+		"COMMENT ON COLUMN webhook_subscriptions.consecutive_failures IS '連續投遞失敗次數，達到門檻後自動停用'",
+		"COMMENT ON TABLE webhook_deliveries IS 'Webhook 投遞記錄表'",
+		"COMMENT ON COLUMN webhook_deliveries.redelivery_of IS '手動重送時指向原投遞記錄'",
+	}
+
+	for _, commentSQL := range comments {
+		if err := tx.Exec(commentSQL).Error; err != nil {
+			log.Printf("Warning: Failed to add comment: %s, Error: %v", commentSQL, err)
+		}
+	}
+
+	return nil
+}
+
+// migration010AddResourceVersions 為可編輯資源添加樂觀鎖版本號
+func (m *MigrationManager) migration010AddResourceVersions(tx *gorm.DB) error {
+	tables := []string{"courts", "bookings", "lessons", "rackets"}
+
+	for _, table := range tables {
+		fieldSQL := fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1", table)
+		if err := tx.Exec(fieldSQL).Error; err != nil {
+			return fmt.Errorf("failed to add version column to %s: %w", table, err)
+		}
+
+		commentSQL := fmt.Sprintf("COMMENT ON COLUMN %s.version IS '樂觀鎖版本號，每次更新遞增，用於 ETag'", table)
+		if err := tx.Exec(commentSQL).Error; err != nil {
+			log.Printf("Warning: Failed to add comment: %s, Error: %v", commentSQL, err)
+		}
+	}
+
+	return nil
+}
+
+// migration011AddImageAssets 添加已處理圖片的尺寸、變體及佔位資訊表
+func (m *MigrationManager) migration011AddImageAssets(tx *gorm.DB) error {
+	if err := tx.AutoMigrate(&models.ImageAsset{}); err != nil {
+		return fmt.Errorf("failed to create image_assets table: %w", err)
+	}
+
+	// 添加註釋
+	comments := []string{
+		"COMMENT ON TABLE image_assets IS '已處理圖片的尺寸、變體及佔位資訊'",
+		"COMMENT ON COLUMN image_assets.variants IS '縮圖等變體，以變體名稱為鍵'",
+		"COMMENT ON COLUMN image_assets.blurhash IS 'BlurHash 佔位字串'",
+	}
+
+	for _, commentSQL := range comments {
+		if err := tx.Exec(commentSQL).Error; err != nil {
+			log.Printf("Warning: Failed to add comment: %s, Error: %v", commentSQL, err)
+		}
+	}
+
+	return nil
+}
+
+// migration012AddCalendarFeeds 添加 iCalendar 訂閱令牌表
+func (m *MigrationManager) migration012AddCalendarFeeds(tx *gorm.DB) error {
+	if err := tx.AutoMigrate(&models.CalendarFeed{}); err != nil {
+		return fmt.Errorf("failed to create calendar_feeds table: %w", err)
+	}
+
+	commentSQL := "COMMENT ON COLUMN calendar_feeds.token IS '訂閱網址中的密鑰，輪換後舊網址失效'"
+	if err := tx.Exec(commentSQL).Error; err != nil {
+		log.Printf("Warning: Failed to add comment: %s, Error: %v", commentSQL, err)
+	}
+
+	return nil
+}
+
+// migration013AddCoachCalendarSources 添加教練外部行事曆及忙碌時段表
+func (m *MigrationManager) migration013AddCoachCalendarSources(tx *gorm.DB) error {
+	if err := tx.AutoMigrate(&models.CoachCalendarSource{}, &models.CoachBusyBlock{}); err != nil {
+		return fmt.Errorf("failed to create coach calendar tables: %w", err)
+	}
+
+	comments := []string{
+		"COMMENT ON COLUMN coach_calendar_sources.content IS '上傳的 .ics 內容，與 url 二選一'",
+		"COMMENT ON COLUMN coach_calendar_sources.next_sync_at IS '下次同步時間，同步失敗時保留上次的忙碌時段'",
+		"COMMENT ON TABLE coach_busy_blocks IS '外部行事曆展開後的忙碌時段，從教練可用時間中扣除'",
+	}
+	for _, commentSQL := range comments {
+		if err := tx.Exec(commentSQL).Error; err != nil {
+			log.Printf("Warning: Failed to add comment: %s, Error: %v", commentSQL, err)
+		}
+	}
+
+	return nil
+}
+
+// migration014AddPayments 添加付款表及預訂、課程、活動報名的付款期限
+func (m *MigrationManager) migration014AddPayments(tx *gorm.DB) error {
+	if err := tx.AutoMigrate(&models.Payment{}); err != nil {
+		return fmt.Errorf("failed to create payments table: %w", err)
+	}
+
+	// 已有的記錄保持 NULL，不會因為部署而被自動取消
+	for _, table := range []string{"bookings", "lessons", "club_event_participants"} {
+		fieldSQL := fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS payment_due_at TIMESTAMPTZ", table)
+		if err := tx.Exec(fieldSQL).Error; err != nil {
+			return fmt.Errorf("failed to add payment_due_at column to %s: %w", table, err)
+		}
+	}
+
+	comments := []string{
+		"COMMENT ON TABLE payments IS '付款記錄，狀態依狀態機轉換，扣款成功後確認對應的預訂、課程或活動報名'",
+		"COMMENT ON COLUMN payments.expires_at IS '付款期限，逾期未扣款的付款會被取消'",
+		"COMMENT ON COLUMN bookings.payment_due_at IS '付款期限，逾期未付款自動取消'",
+		"COMMENT ON COLUMN lessons.payment_due_at IS '付款期限，逾期未付款自動取消'",
+		"COMMENT ON COLUMN club_event_participants.payment_due_at IS '付款期限，逾期未付款自動取消'",
+	}
+	for _, commentSQL := range comments {
+		if err := tx.Exec(commentSQL).Error; err != nil {
+			log.Printf("Warning: Failed to add comment: %s, Error: %v", commentSQL, err)
+		}
+	}
+
+	return nil
+}
+
+// migration015AddCancellationPolicies 添加取消政策、取消例外及取消記錄表
+func (m *MigrationManager) migration015AddCancellationPolicies(tx *gorm.DB) error {
+	if err := tx.AutoMigrate(&models.CancellationPolicy{}, &models.CancellationOverride{}, &models.Cancellation{}); err != nil {
+		return fmt.Errorf("failed to create cancellation tables: %w", err)
+	}
+
+	comments := []string{
+		"COMMENT ON COLUMN cancellation_policies.tiers IS '退款級距，開始前至少 minHoursBefore 小時取消可退還 refundPercent%'",
+		"COMMENT ON COLUMN cancellation_policies.cutoff_hours IS '開始前多少小時內不可取消，0 表示可取消至開始'",
+		"COMMENT ON TABLE cancellation_overrides IS '天候或不可抗力的取消例外，時段內開始的預訂及課程依指定比例退款'",
+		"COMMENT ON TABLE cancellations IS '取消記錄，保存取消當下套用的政策、退款金額及退款結果'",
+	}
+	for _, commentSQL := range comments {
+		if err := tx.Exec(commentSQL).Error; err != nil {
+			log.Printf("Warning: Failed to add comment: %s, Error: %v", commentSQL, err)
+		}
+	}
+
+	return nil
+}
+
+// migration016AddCourtPriceRules 添加場地價格規則表及預訂的價格明細
+func (m *MigrationManager) migration016AddCourtPriceRules(tx *gorm.DB) error {
+	if err := tx.AutoMigrate(&models.CourtPriceRule{}); err != nil {
+		return fmt.Errorf("failed to create court_price_rules table: %w", err)
+	}
+
+	if err := tx.Exec("ALTER TABLE bookings ADD COLUMN IF NOT EXISTS price_breakdown JSONB").Error; err != nil {
+		return fmt.Errorf("failed to add price_breakdown column: %w", err)
+	}
+
+	comments := []string{
+		"COMMENT ON TABLE court_price_rules IS '場地價格規則，rate 取代每小時價格（取優先級最高者），surcharge 在其上加收'",
+		"COMMENT ON COLUMN court_price_rules.days_of_week IS '適用的星期，0 為星期日，空表示每天'",
+		"COMMENT ON COLUMN court_price_rules.audience IS '適用對象：all、member（俱樂部會員）、public（非會員）'",
+		"COMMENT ON COLUMN bookings.price_breakdown IS '預訂時按價格規則分段計價的明細'",
+	}
+	for _, commentSQL := range comments {
+		if err := tx.Exec(commentSQL).Error; err != nil {
+			log.Printf("Warning: Failed to add comment: %s, Error: %v", commentSQL, err)
+		}
+	}
+
+	return nil
+}
+
+// migration017AddCourtUnits 添加場地內可單獨預訂的球場
+func (m *MigrationManager) migration017AddCourtUnits(tx *gorm.DB) error {
+	if err := tx.AutoMigrate(&models.CourtUnit{}); err != nil {
+		return fmt.Errorf("failed to create court_units table: %w", err)
+	}
+
+	statements := []string{
+		"CREATE UNIQUE INDEX IF NOT EXISTS idx_court_units_number ON court_units(court_id, number) WHERE deleted_at IS NULL",
+		"ALTER TABLE bookings ADD COLUMN IF NOT EXISTS court_unit_id UUID",
+		"CREATE INDEX IF NOT EXISTS idx_bookings_court_unit_id ON bookings(court_unit_id)",
+	}
+	for _, stmt := range statements {
+		if err := tx.Exec(stmt).Error; err != nil {
+			return fmt.Errorf("failed to add court units: %w", err)
+		}
+	}
+	if !tx.Migrator().HasConstraint(&models.Booking{}, "CourtUnit") {
+		if err := tx.Migrator().CreateConstraint(&models.Booking{}, "CourtUnit"); err != nil {
+			return fmt.Errorf("failed to add court unit constraint: %w", err)
+		}
+	}
+
+	comments := []string{
+		"COMMENT ON TABLE court_units IS '場地內可單獨預訂的球場'",
+		"COMMENT ON COLUMN bookings.court_unit_id IS '預訂的球場，為空表示包下整個場地（新增球場前的預訂）'",
+	}
+	for _, commentSQL := range comments {
+		if err := tx.Exec(commentSQL).Error; err != nil {
+			log.Printf("Warning: Failed to add comment: %s, Error: %v", commentSQL, err)
+		}
+	}
+
+	return nil
+}
+
+// migration018AddBookingOverlapGuards 添加時段保留表及防止有效預訂重疊的排除約束
+//
+// 同一球場或未設置球場的同一場地，時間重疊的有效預訂無法並存；包下整個場地的預訂與
+// 球場預訂之間的衝突由創建預訂時鎖定場地列保證。已有重疊預訂時遷移會失敗，需先人工處理。
+func (m *MigrationManager) migration018AddBookingOverlapGuards(tx *gorm.DB) error {
+	if err := tx.AutoMigrate(&models.SlotHold{}); err != nil {
+		return fmt.Errorf("failed to create slot_holds table: %w", err)
+	}
+
+	if err := tx.Exec("CREATE EXTENSION IF NOT EXISTS btree_gist").Error; err != nil {
+		return fmt.Errorf("failed to enable btree_gist: %w", err)
+	}
+
+	constraints := map[string]string{
+		"bookings_court_unit_no_overlap": "ALTER TABLE bookings ADD CONSTRAINT bookings_court_unit_no_overlap EXCLUDE USING gist " +
+			"(court_unit_id WITH =, tstzrange(start_time, end_time, '[)') WITH &&) " +
+			"WHERE (court_unit_id IS NOT NULL AND status IN ('pending', 'confirmed') AND deleted_at IS NULL)",
+		"bookings_court_no_overlap": "ALTER TABLE bookings ADD CONSTRAINT bookings_court_no_overlap EXCLUDE USING gist " +
+			"(court_id WITH =, tstzrange(start_time, end_time, '[)') WITH &&) " +
+			"WHERE (court_unit_id IS NULL AND status IN ('pending', 'confirmed') AND deleted_at IS NULL)",
+	}
+	for name, stmt := range constraints {
+		if tx.Migrator().HasConstraint(&models.Booking{}, name) {
+			continue
+		}
+		if err := tx.Exec(stmt).Error; err != nil {
+			return fmt.Errorf("failed to add constraint %s: %w", name, err)
+		}
+	}
+
+	statements := []string{
+		"CREATE INDEX IF NOT EXISTS idx_slot_holds_court_time ON slot_holds(court_id, start_time, end_time)",
+	}
+	for _, stmt := range statements {
+		if err := tx.Exec(stmt).Error; err != nil {
+			return fmt.Errorf("failed to add slot hold index: %w", err)
+		}
+	}
+
+	comments := []string{
+		"COMMENT ON TABLE slot_holds IS '結帳期間暫時保留的時段，逾期自動失效'",
+		"COMMENT ON CONSTRAINT bookings_court_unit_no_overlap ON bookings IS '同一球場的有效預訂時間不可重疊'",
+		"COMMENT ON CONSTRAINT bookings_court_no_overlap ON bookings IS '未指定球場的同一場地有效預訂時間不可重疊'",
+	}
+	for _, commentSQL := range comments {
+		if err := tx.Exec(commentSQL).Error; err != nil {
+			log.Printf("Warning: Failed to add comment: %s, Error: %v", commentSQL, err)
+		}
+	}
+
+	return nil
+}
+
+// migration019AddBookingSeries 添加重複預訂表及預訂所屬的重複預訂
+func (m *MigrationManager) migration019AddBookingSeries(tx *gorm.DB) error {
+	if err := tx.AutoMigrate(&models.BookingSeries{}); err != nil {
+		return fmt.Errorf("failed to create booking_series table: %w", err)
+	}
+
+	statements := []string{
+		"ALTER TABLE bookings ADD COLUMN IF NOT EXISTS series_id UUID",
+		"CREATE INDEX IF NOT EXISTS idx_bookings_series_id ON bookings(series_id)",
+		"CREATE INDEX IF NOT EXISTS idx_booking_series_payment_due ON booking_series(payment_due_at) WHERE status = 'active' AND payment_id IS NULL",
+	}
+	for _, stmt := range statements {
+		if err := tx.Exec(stmt).Error; err != nil {
+			return fmt.Errorf("failed to add booking series: %w", err)
+		}
+	}
+	if !tx.Migrator().HasConstraint(&models.BookingSeries{}, "Bookings") {
+		if err := tx.Migrator().CreateConstraint(&models.BookingSeries{}, "Bookings"); err != nil {
+			return fmt.Errorf("failed to add booking series constraint: %w", err)
+		}
+	}
+
+	comments := []string{
+		"COMMENT ON TABLE booking_series IS '重複預訂，依重複規則展開為多筆預訂'",
+		"COMMENT ON COLUMN booking_series.billing_mode IS '計費方式：per_occurrence（每次分別付款）、aggregate（整組一次付款）'",
+		"COMMENT ON COLUMN bookings.series_id IS '所屬的重複預訂，為空表示單次預訂'",
+	}
+	for _, commentSQL := range comments {
+		if err := tx.Exec(commentSQL).Error; err != nil {
+			log.Printf("Warning: Failed to add comment: %s, Error: %v", commentSQL, err)
+		}
+	}
+
+	return nil
+}
+
+// migration020AddBookingWaitlist 添加已滿時段的候補表
+func (m *MigrationManager) migration020AddBookingWaitlist(tx *gorm.DB) error {
+	if err := tx.AutoMigrate(&models.BookingWaitlistEntry{}); err != nil {
+		return fmt.Errorf("failed to create booking_waitlist_entries table: %w", err)
+	}
+
+	statements := []string{
+		"CREATE INDEX IF NOT EXISTS idx_booking_waitlist_waiting ON booking_waitlist_entries(court_id, start_time, end_time, created_at) WHERE status = 'waiting'",
+		"CREATE INDEX IF NOT EXISTS idx_booking_waitlist_offer_expires ON booking_waitlist_entries(offer_expires_at) WHERE status = 'offered'",
+	}
+	for _, stmt := range statements {
+		if err := tx.Exec(stmt).Error; err != nil {
+			return fmt.Errorf("failed to add booking waitlist index: %w", err)
+		}
+	}
+
+	comments := []string{
+		"COMMENT ON TABLE booking_waitlist_entries IS '已滿時段的候補，時段釋出時依加入順序提供給候補者'",
+		"COMMENT ON COLUMN booking_waitlist_entries.status IS '狀態：waiting、offered、claimed、expired、cancelled'",
+		"COMMENT ON COLUMN booking_waitlist_entries.hold_id IS '為候補者保留提供時段的保留'",
+	}
+	for _, commentSQL := range comments {
+		if err := tx.Exec(commentSQL).Error; err != nil {
+			log.Printf("Warning: Failed to add comment: %s, Error: %v", commentSQL, err)
+		}
+	}
+
+	return nil
+}
+
+// migration021AddCourtClosures 添加場地休館、更改營業時間及封鎖時段的例外表
+func (m *MigrationManager) migration021AddCourtClosures(tx *gorm.DB) error {
+	if err := tx.AutoMigrate(&models.CourtClosure{}); err != nil {
+		return fmt.Errorf("failed to create court_closures table: %w", err)
+	}
+
+	if err := tx.Exec("CREATE INDEX IF NOT EXISTS idx_court_closures_dates ON court_closures(court_id, start_date, end_date)").Error; err != nil {
+		return fmt.Errorf("failed to add court closure index: %w", err)
+	}
+
+	comments := []string{
+		"COMMENT ON TABLE court_closures IS '場地在指定日期的例外，優先於每週的營業時間'",
+		"COMMENT ON COLUMN court_closures.kind IS '類型：closed（整天休館）、hours（更改營業時間）、block（封鎖部分時段）'",
+		"COMMENT ON COLUMN court_closures.reason IS '原因：maintenance、holiday、tournament、weather、other'",
+	}
+	for _, commentSQL := range comments {
+		if err := tx.Exec(commentSQL).Error; err != nil {
+			log.Printf("Warning: Failed to add comment: %s, Error: %v", commentSQL, err)
+		}
+	}
+
+	return nil
+}
+
+// migration022AddBookingPaymentSplits 添加預訂分攤付款及各付款人的分攤表
+func (m *MigrationManager) migration022AddBookingPaymentSplits(tx *gorm.DB) error {
+	if err := tx.AutoMigrate(&models.BookingPaymentSplit{}, &models.BookingPaymentShare{}); err != nil {
+		return fmt.Errorf("failed to create booking payment split tables: %w", err)
+	}
+
+	// 每筆預訂同時只能有一個進行中的分攤付款
+	if err := tx.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_booking_payment_splits_open ON booking_payment_splits(booking_id) WHERE status = 'open'").Error; err != nil {
+		return fmt.Errorf("failed to add booking payment split index: %w", err)
+	}
+
+	comments := []string{
+		"COMMENT ON TABLE booking_payment_splits IS '預訂的分攤付款，全部付清後確認預訂'",
+		"COMMENT ON COLUMN booking_payment_splits.fallback IS '期限前未付清時的處理：cancel（取消預訂）、charge_owner（改由預訂者支付）'",
+		"COMMENT ON TABLE booking_payment_shares IS '分攤付款中各付款人的金額，以 booking_share 為付款目標'",
+		"COMMENT ON COLUMN booking_payment_shares.status IS '狀態：pending、paid、reassigned、cancelled'",
+	}
+	for _, commentSQL := range comments {
+		if err := tx.Exec(commentSQL).Error; err != nil {
+			log.Printf("Warning: Failed to add comment: %s, Error: %v", commentSQL, err)
+		}
+	}
+
+	return nil
+}
+
+// migration023AddCourtSlotDemands 添加場地時段需求計數表
+func (m *MigrationManager) migration023AddCourtSlotDemands(tx *gorm.DB) error {
+	if err := tx.AutoMigrate(&models.CourtSlotDemand{}); err != nil {
+		return fmt.Errorf("failed to create court slot demands table: %w", err)
+	}
+
+	comments := []string{
+		"COMMENT ON TABLE court_slot_demands IS '場地每小時時段未能滿足的需求，用於需求熱度分析'",
+		"COMMENT ON COLUMN court_slot_demands.slot_start IS '時段所在整點（UTC）'",
+		"COMMENT ON COLUMN court_slot_demands.unavailable_views IS '查詢可用時段時該時段已無空位的次數'",
+		"COMMENT ON COLUMN court_slot_demands.rejected_bookings IS '預訂或保留時段因已被預訂而失敗的次數'",
+	}
+	for _, commentSQL := range comments {
+		if err := tx.Exec(commentSQL).Error; err != nil {
+			log.Printf("Warning: Failed to add comment: %s, Error: %v", commentSQL, err)
+		}
+	}
+
+	return nil
+}
+
+// migration024AddBookingCheckIn 添加預訂的報到時間及場地是否需要報到
+func (m *MigrationManager) migration024AddBookingCheckIn(tx *gorm.DB) error {
+	statements := []string{
+		"ALTER TABLE bookings ADD COLUMN IF NOT EXISTS checked_in_at TIMESTAMPTZ",
+		"ALTER TABLE bookings ADD COLUMN IF NOT EXISTS checked_in_by UUID",
+		"ALTER TABLE courts ADD COLUMN IF NOT EXISTS check_in_required BOOLEAN NOT NULL DEFAULT false",
+		// 結束後尚未結算出席的預訂
+		"CREATE INDEX IF NOT EXISTS idx_bookings_attendance_due ON bookings(end_time) WHERE status = 'confirmed' AND deleted_at IS NULL",
+	}
+	for _, stmt := range statements {
+		if err := tx.Exec(stmt).Error; err != nil {
+			return fmt.Errorf("failed to add booking check-in: %w", err)
+		}
+	}
+
+	comments := []string{
+		"COMMENT ON COLUMN bookings.checked_in_at IS '在場地掃描報到碼的時間'",
+		"COMMENT ON COLUMN bookings.checked_in_by IS '掃描報到碼的場地人員'",
+		"COMMENT ON COLUMN courts.check_in_required IS '需要報到，結束後未報到的預訂標記為 no_show'",
+	}
+	for _, commentSQL := range comments {
+		if err := tx.Exec(commentSQL).Error; err != nil {
+			log.Printf("Warning: Failed to add comment: %s, Error: %v", commentSQL, err)
+		}
+	}
+
+	return nil
+}
+
+// migration025AddInvoices 添加發票、折讓單及發票號碼的流水號表
+func (m *MigrationManager) migration025AddInvoices(tx *gorm.DB) error {
+	if err := tx.AutoMigrate(&models.Invoice{}, &models.InvoiceSequence{}); err != nil {
+		return fmt.Errorf("failed to create invoice tables: %w", err)
+	}
+
+	comments := []string{
+		"COMMENT ON TABLE invoices IS '付款的發票及退款的折讓單，保存開立時的買賣雙方及項目快照'",
+		"COMMENT ON COLUMN invoices.kind IS '類型：invoice（發票）、credit_note（折讓單）'",
+		"COMMENT ON COLUMN invoices.source_key IS '開立來源，重複處理同一扣款或退款時不重複開立'",
+		"COMMENT ON COLUMN invoices.seller_type IS '開立方：court（場地）、coach（教練）、club（俱樂部）'",
+		"COMMENT ON COLUMN invoices.file_key IS 'PDF 的存儲鍵，為空表示尚未生成'",
+		"COMMENT ON TABLE invoice_sequences IS '發票號碼的流水號，每種字軌每年從 1 開始'",
+	}
+	for _, commentSQL := range comments {
+		if err := tx.Exec(commentSQL).Error; err != nil {
+			log.Printf("Warning: Failed to add comment: %s, Error: %v", commentSQL, err)
+		}
+	}
+
+	return nil
+}
+
+// migration026AddPromoCodes 添加優惠碼及使用記錄，課程記錄折扣金額
+func (m *MigrationManager) migration026AddPromoCodes(tx *gorm.DB) error {
+	if err := tx.AutoMigrate(&models.PromoCode{}, &models.PromoRedemption{}); err != nil {
+		return fmt.Errorf("failed to create promo code tables: %w", err)
+	}
+
+	statements := []string{
+		"ALTER TABLE lessons ADD COLUMN IF NOT EXISTS discount_amount NUMERIC NOT NULL DEFAULT 0",
+		"ALTER TABLE lessons ADD COLUMN IF NOT EXISTS promo_code_id UUID",
+		"ALTER TABLE promo_codes ADD CONSTRAINT chk_promo_codes_redemption_count CHECK (redemption_count >= 0)",
+	}
+	for _, stmt := range statements {
+		if err := tx.Exec(stmt).Error; err != nil {
+			return fmt.Errorf("failed to add promo code columns: %w", err)
+		}
+	}
+
+	comments := []string{
+		"COMMENT ON TABLE promo_codes IS '場地及教練的優惠碼，範圍欄位為空表示不限'",
+		"COMMENT ON COLUMN promo_codes.discount_type IS '折扣類型：percent（百分比）、fixed（固定金額）'",
+		"COMMENT ON COLUMN promo_codes.redemption_count IS '目前有效的使用次數，預訂或課程取消時退回'",
+		"COMMENT ON TABLE promo_redemptions IS '優惠碼的使用記錄，每個預訂或課程最多一筆'",
+		"COMMENT ON COLUMN promo_redemptions.status IS '狀態：applied（已套用）、reversed（已退回）'",
+		"COMMENT ON COLUMN lessons.discount_amount IS '優惠碼折扣金額，price 為扣除後的應付金額'",
+	}
+	for _, commentSQL := range comments {
+		if err := tx.Exec(commentSQL).Error; err != nil {
+			log.Printf("Warning: Failed to add comment: %s, Error: %v", commentSQL, err)
+		}
+	}
+
+	return nil
+}
+
+// migration027AddCourtEquipment 添加場地的租借器材及預訂的租借項目
+func (m *MigrationManager) migration027AddCourtEquipment(tx *gorm.DB) error {
+	if err := tx.AutoMigrate(&models.CourtEquipment{}, &models.BookingAddOn{}); err != nil {
+		return fmt.Errorf("failed to create court equipment tables: %w", err)
+	}
+
+	statements := []string{
+		"ALTER TABLE court_equipment ADD CONSTRAINT chk_court_equipment_quantity CHECK (quantity >= 0)",
+		"ALTER TABLE booking_add_ons ADD CONSTRAINT chk_booking_add_ons_quantity CHECK (quantity > 0)",
+	}
+	for _, stmt := range statements {
+		if err := tx.Exec(stmt).Error; err != nil {
+			return fmt.Errorf("failed to add court equipment constraints: %w", err)
+		}
+	}
+
+	comments := []string{
+		"COMMENT ON TABLE court_equipment IS '場地可租借的器材，quantity 為庫存總數'",
+		"COMMENT ON COLUMN court_equipment.price_unit IS '計價方式：per_booking（每次預訂）、per_hour（每小時）'",
+		"COMMENT ON TABLE booking_add_ons IS '預訂租借的器材，保存租借時的名稱及價格'",
+		"COMMENT ON COLUMN booking_add_ons.start_time IS '預訂的開始時間，用於檢查重疊時段的庫存'",
+		"COMMENT ON COLUMN booking_add_ons.status IS '狀態：reserved（佔用庫存）、returned（已退回庫存）'",
+	}
+	for _, commentSQL := range comments {
+		if err := tx.Exec(commentSQL).Error; err != nil {
+			log.Printf("Warning: Failed to add comment: %s, Error: %v", commentSQL, err)
+		}
+	}
+
+	return nil
+}
+
+// migration028AddUploadSessions 添加直傳上傳的申請記錄，完成上傳時確認上傳者
+func (m *MigrationManager) migration028AddUploadSessions(tx *gorm.DB) error {
+	if err := tx.AutoMigrate(&models.UploadSession{}); err != nil {
+		return fmt.Errorf("failed to create upload_sessions table: %w", err)
+	}
+
+	comments := []string{
+		"COMMENT ON TABLE upload_sessions IS '直傳上傳的申請記錄，只有申請者可以完成上傳及獲取下載網址'",
+		"COMMENT ON COLUMN upload_sessions.completed_at IS '完成圖片處理的時間，為空表示尚未完成'",
+	}
+	for _, commentSQL := range comments {
+		if err := tx.Exec(commentSQL).Error; err != nil {
+			log.Printf("Warning: Failed to add comment: %s, Error: %v", commentSQL, err)
+		}
+	}
+
+	return nil
+}
+
 // RollbackMigration 回滾遷移（僅用於開發環境）
 func (m *MigrationManager) RollbackMigration(version string) error {
 	return m.db.Where("version = ?", version).Delete(&Migration{}).Error
@@ -0,0 +1,80 @@
+package dto
+
+import "time"
+
+// ===== 行事曆相關 =====
+
+// 行事曆項目類型
+const (
+	CalendarItemBooking   = "booking"
+	CalendarItemLesson    = "lesson"
+	CalendarItemMatch     = "match"
+	CalendarItemClubEvent = "club_event"
+)
+
+// CalendarRequest 行事曆查詢請求，時間為 RFC3339 格式
+type CalendarRequest struct {
+	From             *time.Time `form:"from"`
+	To               *time.Time `form:"to"`
+	Types            []string   `form:"type" binding:"omitempty,dive,oneof=booking lesson match club_event"`
+	IncludeCancelled bool       `form:"includeCancelled"`
+}
+
+// CalendarItem 行事曆項目
+type CalendarItem struct {
+	ID        string    `json:"id"` // {type}:{sourceId}
+	Type      string    `json:"type"`
+	SourceID  string    `json:"sourceId"`
+	Role      string    `json:"role"` // booker, student, coach, player, organizer, participant
+	Title     string    `json:"title"`
+	Status    string    `json:"status"`
+	StartTime time.Time `json:"startTime"`
+	EndTime   time.Time `json:"endTime"`
+	CourtID   *string   `json:"courtId,omitempty"`
+	ClubID    *string   `json:"clubId,omitempty"`
+	Location  *string   `json:"location,omitempty"`
+	Cancelled bool      `json:"cancelled"`
+	Overlaps  []string  `json:"overlaps"` // 時間重疊的其他項目 ID
+}
+
+// CalendarResponse 行事曆回應
+type CalendarResponse struct {
+	From         time.Time      `json:"from"`
+	To           time.Time      `json:"to"`
+	Items        []CalendarItem `json:"items"`
+	OverlapCount int            `json:"overlapCount"` // 有時間重疊的項目數
+}
+
+// FreeBusyRequest 空閒/忙碌查詢請求
+type FreeBusyRequest struct {
+	UserIDs []string   `form:"userId" binding:"required,min=1,max=10,dive,uuid"`
+	From    *time.Time `form:"from"`
+	To      *time.Time `form:"to"`
+}
+
+// BusyPeriod 忙碌時段
+type BusyPeriod struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// UserFreeBusy 單一用戶的忙碌時段
+type UserFreeBusy struct {
+	UserID string       `json:"userId"`
+	Hidden bool         `json:"hidden"` // 用戶未公開檔案時不提供忙碌時段
+	Busy   []BusyPeriod `json:"busy"`
+}
+
+// FreeBusyResponse 空閒/忙碌查詢回應
+type FreeBusyResponse struct {
+	From  time.Time      `json:"from"`
+	To    time.Time      `json:"to"`
+	Users []UserFreeBusy `json:"users"`
+}
+
+// CalendarFeedResponse 行事曆訂閱網址回應
+type CalendarFeedResponse struct {
+	URL       string    `json:"url"`       // https 網址，可直接下載 .ics
+	WebcalURL string    `json:"webcalUrl"` // webcal:// 網址，點擊後由行事曆應用程式訂閱
+	CreatedAt time.Time `json:"createdAt"`
+}
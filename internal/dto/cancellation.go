@@ -0,0 +1,47 @@
+package dto
+
+import (
+	"tennis-platform/backend/internal/models"
+	"time"
+)
+
+// ===== 取消政策相關 =====
+
+// CancellationTierRequest 退款級距
+type CancellationTierRequest struct {
+	MinHoursBefore float64 `json:"minHoursBefore" binding:"min=0,max=720"`
+	RefundPercent  int     `json:"refundPercent" binding:"min=0,max=100"`
+}
+
+// UpdateCancellationPolicyRequest 設置取消政策請求
+type UpdateCancellationPolicyRequest struct {
+	Tiers       []CancellationTierRequest `json:"tiers" binding:"required,min=1,max=10,dive"`
+	CutoffHours float64                   `json:"cutoffHours" binding:"min=0,max=720"`
+}
+
+// CreateCancellationOverrideRequest 創建取消例外請求
+type CreateCancellationOverrideRequest struct {
+	StartTime     time.Time `json:"startTime" binding:"required"`
+	EndTime       time.Time `json:"endTime" binding:"required"`
+	Reason        string    `json:"reason" binding:"required,oneof=weather force_majeure"`
+	RefundPercent *int      `json:"refundPercent" binding:"omitempty,min=0,max=100"` // 默認 100
+	Note          *string   `json:"note" binding:"omitempty,max=500"`
+}
+
+// CancelBookingRequest 取消預訂請求，請求體可省略
+type CancelBookingRequest struct {
+	Reason               *string  `json:"reason" binding:"omitempty,max=500"`
+	ExpectedRefundAmount *float64 `json:"expectedRefundAmount" binding:"omitempty,min=0"` // 提供時與取消當下的退款金額不符則拒絕取消
+}
+
+// CancelBookingResponse 取消預訂響應
+type CancelBookingResponse struct {
+	Message      string               `json:"message"`
+	Cancellation *models.Cancellation `json:"cancellation"`
+}
+
+// CancelLessonResponse 取消課程響應，在課程欄位之外附上取消記錄
+type CancelLessonResponse struct {
+	*models.Lesson
+	Cancellation *models.Cancellation `json:"cancellation"`
+}
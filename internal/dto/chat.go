@@ -1,6 +1,9 @@
 package dto
 
-import "time"
+import (
+	"tennis-platform/backend/internal/pagination"
+	"time"
+)
 
 // ===== 聊天室相關 =====
 
@@ -22,6 +25,7 @@ type SendMessageRequest struct {
 // GetMessagesRequest 獲取訊息請求
 type GetMessagesRequest struct {
 	ChatRoomID string     `json:"chatRoomId" binding:"required"`
+	Cursor     string     `json:"cursor,omitempty"` // 游標分頁，提供時忽略 page
 	Page       int        `json:"page,omitempty"`
 	Limit      int        `json:"limit,omitempty"`
 	Before     *time.Time `json:"before,omitempty"` // 獲取此時間之前的訊息
@@ -55,6 +59,12 @@ type ChatMessageResponse struct {
 	Sender      *UserInfo `json:"sender,omitempty"`
 }
 
+// ChatMessageListResponse 訊息列表響應
+type ChatMessageListResponse struct {
+	Messages   []ChatMessageResponse `json:"messages"`
+	Pagination pagination.PageInfo   `json:"pagination"`
+}
+
 // ChatParticipantResponse 聊天室參與者響應
 type ChatParticipantResponse struct {
 	ID         string     `json:"id"`
@@ -106,6 +106,7 @@ type CreateLessonRequest struct {
 	Currency     string    `json:"currency" binding:"omitempty,oneof=TWD USD EUR"`
 	ScheduledAt  time.Time `json:"scheduledAt" binding:"required"`
 	Notes        *string   `json:"notes" binding:"omitempty,max=500"`
+	PromoCode    *string   `json:"promoCode" binding:"omitempty,max=50"`
 }
 
 // UpdateLessonRequest 更新課程請求
@@ -113,12 +114,13 @@ type UpdateLessonRequest struct {
 	CourtID     *string    `json:"courtId"`
 	ScheduledAt *time.Time `json:"scheduledAt"`
 	Notes       *string    `json:"notes" binding:"omitempty,max=500"`
-	Status      *string    `json:"status" binding:"omitempty,oneof=scheduled in_progress completed cancelled"`
+	Status      *string    `json:"status" binding:"omitempty,oneof=scheduled in_progress completed"` // 取消需使用取消課程
 }
 
 // CancelLessonRequest 取消課程請求
 type CancelLessonRequest struct {
-	Reason string `json:"reason" binding:"required,min=1,max=500"`
+	Reason               string   `json:"reason" binding:"required,min=1,max=500"`
+	ExpectedRefundAmount *float64 `json:"expectedRefundAmount" binding:"omitempty,min=0"` // 提供時與取消當下的退款金額不符則拒絕取消
 }
 
 // GetLessonsRequest 獲取課程列表請求
@@ -223,6 +225,7 @@ type CoachReviewSearchRequest struct {
 	Rating     *int     `json:"rating" form:"rating" binding:"omitempty,min=1,max=5"`
 	HasComment *bool    `json:"hasComment" form:"hasComment"`
 	Tags       []string `json:"tags" form:"tags"`
+	Cursor     *string  `json:"cursor" form:"cursor"` // 游標分頁，提供時忽略 page
 	Page       int      `json:"page" form:"page" binding:"omitempty,min=1"`
 	Limit      int      `json:"limit" form:"limit" binding:"omitempty,min=1,max=100"`
 	SortBy     string   `json:"sortBy" form:"sortBy" binding:"omitempty,oneof=rating createdAt isHelpful"`
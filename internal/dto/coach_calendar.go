@@ -0,0 +1,9 @@
+package dto
+
+// ===== 教練外部行事曆相關 =====
+
+// CreateCoachCalendarRequest 連結外部行事曆訂閱網址請求
+type CreateCoachCalendarRequest struct {
+	Name string `json:"name" binding:"required,max=100"`
+	URL  string `json:"url" binding:"required,max=1000"` // 支援 https、http 及 webcal
+}
@@ -2,6 +2,7 @@ package dto
 
 import (
 	"tennis-platform/backend/internal/models"
+	"tennis-platform/backend/internal/pagination"
 	"time"
 )
 
@@ -9,40 +10,42 @@ import (
 
 // CreateCourtRequest 創建場地請求
 type CreateCourtRequest struct {
-	Name           string            `json:"name" binding:"required,min=1,max=200"`
-	Description    *string           `json:"description" binding:"omitempty,max=1000"`
-	Address        string            `json:"address" binding:"required,min=1,max=500"`
-	Latitude       float64           `json:"latitude" binding:"required,min=-90,max=90"`
-	Longitude      float64           `json:"longitude" binding:"required,min=-180,max=180"`
-	Facilities     []string          `json:"facilities"`
-	CourtType      string            `json:"courtType" binding:"required,oneof=hard clay grass indoor outdoor"`
-	PricePerHour   float64           `json:"pricePerHour" binding:"required,min=0"`
-	Currency       string            `json:"currency" binding:"omitempty,oneof=TWD USD EUR"`
-	Images         []string          `json:"images"`
-	OperatingHours map[string]string `json:"operatingHours"`
-	ContactPhone   *string           `json:"contactPhone" binding:"omitempty,max=20"`
-	ContactEmail   *string           `json:"contactEmail" binding:"omitempty,email"`
-	Website        *string           `json:"website" binding:"omitempty,url"`
-	OwnerID        *string           `json:"ownerId"`
+	Name            string            `json:"name" binding:"required,min=1,max=200"`
+	Description     *string           `json:"description" binding:"omitempty,max=1000"`
+	Address         string            `json:"address" binding:"required,min=1,max=500"`
+	Latitude        float64           `json:"latitude" binding:"required,min=-90,max=90"`
+	Longitude       float64           `json:"longitude" binding:"required,min=-180,max=180"`
+	Facilities      []string          `json:"facilities"`
+	CourtType       string            `json:"courtType" binding:"required,oneof=hard clay grass indoor outdoor"`
+	PricePerHour    float64           `json:"pricePerHour" binding:"required,min=0"`
+	Currency        string            `json:"currency" binding:"omitempty,oneof=TWD USD EUR"`
+	Images          []string          `json:"images"`
+	OperatingHours  map[string]string `json:"operatingHours"`
+	ContactPhone    *string           `json:"contactPhone" binding:"omitempty,max=20"`
+	ContactEmail    *string           `json:"contactEmail" binding:"omitempty,email"`
+	Website         *string           `json:"website" binding:"omitempty,url"`
+	OwnerID         *string           `json:"ownerId"`
+	CheckInRequired bool              `json:"checkInRequired"` // 結束後未報到的預訂標記為未到場
 }
 
 // UpdateCourtRequest 更新場地請求
 type UpdateCourtRequest struct {
-	Name           *string           `json:"name" binding:"omitempty,min=1,max=200"`
-	Description    *string           `json:"description" binding:"omitempty,max=1000"`
-	Address        *string           `json:"address" binding:"omitempty,min=1,max=500"`
-	Latitude       *float64          `json:"latitude" binding:"omitempty,min=-90,max=90"`
-	Longitude      *float64          `json:"longitude" binding:"omitempty,min=-180,max=180"`
-	Facilities     []string          `json:"facilities"`
-	CourtType      *string           `json:"courtType" binding:"omitempty,oneof=hard clay grass indoor outdoor"`
-	PricePerHour   *float64          `json:"pricePerHour" binding:"omitempty,min=0"`
-	Currency       *string           `json:"currency" binding:"omitempty,oneof=TWD USD EUR"`
-	Images         []string          `json:"images"`
-	OperatingHours map[string]string `json:"operatingHours"`
-	ContactPhone   *string           `json:"contactPhone" binding:"omitempty,max=20"`
-	ContactEmail   *string           `json:"contactEmail" binding:"omitempty,email"`
-	Website        *string           `json:"website" binding:"omitempty,url"`
-	IsActive       *bool             `json:"isActive"`
+	Name            *string           `json:"name" binding:"omitempty,min=1,max=200"`
+	Description     *string           `json:"description" binding:"omitempty,max=1000"`
+	Address         *string           `json:"address" binding:"omitempty,min=1,max=500"`
+	Latitude        *float64          `json:"latitude" binding:"omitempty,min=-90,max=90"`
+	Longitude       *float64          `json:"longitude" binding:"omitempty,min=-180,max=180"`
+	Facilities      []string          `json:"facilities"`
+	CourtType       *string           `json:"courtType" binding:"omitempty,oneof=hard clay grass indoor outdoor"`
+	PricePerHour    *float64          `json:"pricePerHour" binding:"omitempty,min=0"`
+	Currency        *string           `json:"currency" binding:"omitempty,oneof=TWD USD EUR"`
+	Images          []string          `json:"images"`
+	OperatingHours  map[string]string `json:"operatingHours"`
+	ContactPhone    *string           `json:"contactPhone" binding:"omitempty,max=20"`
+	ContactEmail    *string           `json:"contactEmail" binding:"omitempty,email"`
+	Website         *string           `json:"website" binding:"omitempty,url"`
+	IsActive        *bool             `json:"isActive"`
+	CheckInRequired *bool             `json:"checkInRequired"`
 }
 
 // CourtSearchRequest 場地搜尋請求
@@ -77,57 +80,184 @@ type CourtWithDistance struct {
 	Distance *float64 `json:"distance,omitempty"` // 公里
 }
 
+// ===== 場地球場相關 =====
+
+// CourtUnitRequest 創建或更新場地球場請求，更新時整筆替換
+type CourtUnitRequest struct {
+	Number    int     `json:"number" binding:"required,min=1,max=999"`
+	Name      *string `json:"name" binding:"omitempty,max=50"`
+	Surface   string  `json:"surface" binding:"required,oneof=hard clay grass carpet"`
+	IsIndoor  bool    `json:"isIndoor"`
+	HasLights bool    `json:"hasLights"`
+	IsActive  *bool   `json:"isActive"` // 默認 true
+}
+
+// ===== 租借器材相關 =====
+
+// CourtEquipmentRequest 創建或更新租借器材請求，更新時整筆替換
+type CourtEquipmentRequest struct {
+	Name        string   `json:"name" binding:"required,max=100"`
+	Description *string  `json:"description" binding:"omitempty,max=500"`
+	Category    string   `json:"category" binding:"required,oneof=racket ball_basket ball_machine other"`
+	Quantity    *int     `json:"quantity" binding:"required,min=0,max=999"`
+	Price       *float64 `json:"price" binding:"required,min=0"`
+	PriceUnit   string   `json:"priceUnit" binding:"omitempty,oneof=per_booking per_hour"` // 默認 per_booking
+	IsActive    *bool    `json:"isActive"`                                                 // 默認 true
+}
+
+// CourtEquipmentListRequest 租借器材列表請求，提供時段時計算各器材的可租借數量
+type CourtEquipmentListRequest struct {
+	StartTime *time.Time `form:"startTime"`
+	EndTime   *time.Time `form:"endTime"`
+}
+
+// CourtEquipmentResponse 租借器材及時段內的可租借數量
+type CourtEquipmentResponse struct {
+	models.CourtEquipment
+	Available *int `json:"available,omitempty"` // 只在提供時段時返回
+}
+
+// BookingAddOnRequest 預訂租借的器材
+type BookingAddOnRequest struct {
+	EquipmentID string `json:"equipmentId" binding:"required,uuid"`
+	Quantity    int    `json:"quantity" binding:"required,min=1,max=99"`
+}
+
+// UpdateBookingAddOnsRequest 修改預訂租借器材請求，以請求內容替換全部租借項目
+type UpdateBookingAddOnsRequest struct {
+	AddOns []BookingAddOnRequest `json:"addOns" binding:"omitempty,max=20,dive"`
+}
+
+// ===== 場地例外相關 =====
+
+// CourtClosureRequest 創建場地例外請求
+type CourtClosureRequest struct {
+	CourtUnitID *string `json:"courtUnitId" binding:"omitempty,uuid"` // 只影響指定的球場，為空表示整個場地
+	Kind        string  `json:"kind" binding:"required,oneof=closed hours block"`
+	StartDate   string  `json:"startDate" binding:"required"` // YYYY-MM-DD
+	EndDate     string  `json:"endDate" binding:"required"`   // YYYY-MM-DD，包含當天
+	StartTime   *string `json:"startTime"`                    // HH:MM，hours 及 block 必填
+	EndTime     *string `json:"endTime"`                      // HH:MM，可為 24:00
+	Reason      string  `json:"reason" binding:"required,oneof=maintenance holiday tournament weather other"`
+	Note        *string `json:"note" binding:"omitempty,max=500"`
+}
+
+// CourtClosureListRequest 場地例外列表請求，默認列出尚未結束的例外
+type CourtClosureListRequest struct {
+	From *string `form:"from"` // YYYY-MM-DD，只列出結束日期不早於此日期的例外
+	To   *string `form:"to"`   // YYYY-MM-DD，只列出開始日期不晚於此日期的例外
+}
+
+// AffectedBooking 受場地例外影響的預訂
+type AffectedBooking struct {
+	BookingID    string    `json:"bookingId"`
+	UserID       string    `json:"userId"`
+	CourtUnitID  *string   `json:"courtUnitId"`
+	StartTime    time.Time `json:"startTime"`
+	EndTime      time.Time `json:"endTime"`
+	Status       string    `json:"status"`
+	PaidAmount   float64   `json:"paidAmount"`
+	RefundAmount float64   `json:"refundAmount"` // 因場地例外取消一律全額退款
+}
+
+// CourtClosureImpact 場地例外對現有預訂的影響
+type CourtClosureImpact struct {
+	Bookings     []AffectedBooking `json:"bookings"`
+	RefundAmount float64           `json:"refundAmount"`
+}
+
+// CourtClosureResponse 創建場地例外回應，包含因例外而取消的預訂
+type CourtClosureResponse struct {
+	Closure       *models.CourtClosure  `json:"closure"`
+	Cancellations []models.Cancellation `json:"cancellations"`
+}
+
 // ===== 預訂相關 =====
 
 // CreateBookingRequest 創建預訂請求
 type CreateBookingRequest struct {
-	CourtID   string    `json:"courtId" binding:"required,uuid"`
-	StartTime time.Time `json:"startTime" binding:"required"`
-	EndTime   time.Time `json:"endTime" binding:"required"`
-	Notes     *string   `json:"notes" binding:"omitempty,max=500"`
+	CourtID     string                `json:"courtId" binding:"required,uuid"`
+	CourtUnitID *string               `json:"courtUnitId" binding:"omitempty,uuid"` // 為空時自動分配可用的球場
+	StartTime   time.Time             `json:"startTime" binding:"required"`
+	EndTime     time.Time             `json:"endTime" binding:"required"`
+	Notes       *string               `json:"notes" binding:"omitempty,max=500"`
+	HoldID      *string               `json:"holdId" binding:"omitempty,uuid"` // 結帳前保留的時段，提供時使用保留的球場
+	PromoCode   *string               `json:"promoCode" binding:"omitempty,max=50"`
+	AddOns      []BookingAddOnRequest `json:"addOns" binding:"omitempty,max=20,dive"` // 租借的器材
+}
+
+// CreateSlotHoldRequest 保留時段請求
+type CreateSlotHoldRequest struct {
+	CourtID     string    `json:"courtId" binding:"required,uuid"`
+	CourtUnitID *string   `json:"courtUnitId" binding:"omitempty,uuid"` // 為空時自動分配可用的球場
+	StartTime   time.Time `json:"startTime" binding:"required"`
+	EndTime     time.Time `json:"endTime" binding:"required"`
+}
+
+// AlternativeSlot 時段已被預訂時建議的替代時段
+type AlternativeSlot struct {
+	StartTime   time.Time `json:"startTime"`
+	EndTime     time.Time `json:"endTime"`
+	CourtUnitID *string   `json:"courtUnitId,omitempty"` // 空閒的球場，場地未設置球場時省略
+	Price       float64   `json:"price"`
 }
 
 // UpdateBookingRequest 更新預訂請求
+//
+// 預訂狀態不能直接修改：付款完成後由付款服務確認，取消需經取消預訂套用取消政策。
 type UpdateBookingRequest struct {
 	StartTime *time.Time `json:"startTime"`
 	EndTime   *time.Time `json:"endTime"`
 	Notes     *string    `json:"notes" binding:"omitempty,max=500"`
-	Status    *string    `json:"status" binding:"omitempty,oneof=pending confirmed cancelled completed"`
+	Status    *string    `json:"status"` // 只用於拒絕舊客戶端修改狀態的請求
 }
 
 // BookingListRequest 預訂列表請求
 type BookingListRequest struct {
 	CourtID   *string    `form:"courtId" binding:"omitempty,uuid"`
 	UserID    *string    `form:"userId" binding:"omitempty,uuid"`
-	Status    *string    `form:"status" binding:"omitempty,oneof=pending confirmed cancelled completed"`
+	Status    *string    `form:"status" binding:"omitempty,oneof=pending confirmed cancelled completed no_show"`
 	StartDate *time.Time `form:"startDate"`
 	EndDate   *time.Time `form:"endDate"`
+	Cursor    *string    `form:"cursor"` // 游標分頁，提供時忽略 page
 	Page      int        `form:"page" binding:"omitempty,min=1"`
 	PageSize  int        `form:"pageSize" binding:"omitempty,min=1,max=100"`
 }
 
 // BookingListResponse 預訂列表回應
 type BookingListResponse struct {
-	Bookings   []models.Booking `json:"bookings"`
-	Total      int64            `json:"total"`
-	Page       int              `json:"page"`
-	PageSize   int              `json:"pageSize"`
-	TotalPages int              `json:"totalPages"`
+	Bookings   []models.Booking    `json:"bookings"`
+	Pagination pagination.PageInfo `json:"pagination"`
+	Total      int64               `json:"total"`      // 僅頁碼分頁
+	Page       int                 `json:"page"`       // 僅頁碼分頁
+	PageSize   int                 `json:"pageSize"`   // 僅頁碼分頁
+	TotalPages int                 `json:"totalPages"` // 僅頁碼分頁
 }
 
 // AvailabilityRequest 可用時間查詢請求
 type AvailabilityRequest struct {
-	CourtID  string    `form:"courtId" binding:"required,uuid"`
-	Date     time.Time `form:"date" binding:"required"`
-	Duration int       `form:"duration" binding:"omitempty,min=30,max=480"` // 分鐘，默認60分鐘
+	CourtID     string    `form:"courtId" binding:"required,uuid"`
+	CourtUnitID *string   `form:"courtUnitId" binding:"omitempty,uuid"` // 只查詢指定球場
+	Date        time.Time `form:"date" binding:"required"`
+	Duration    int       `form:"duration" binding:"omitempty,min=30,max=480"` // 分鐘，默認60分鐘
 }
 
 // TimeSlot 時間段
 type TimeSlot struct {
-	StartTime time.Time `json:"startTime"`
-	EndTime   time.Time `json:"endTime"`
-	Available bool      `json:"available"`
-	Price     float64   `json:"price"`
+	StartTime      time.Time             `json:"startTime"`
+	EndTime        time.Time             `json:"endTime"`
+	Available      bool                  `json:"available"`
+	Price          float64               `json:"price"`
+	Segments       []models.PriceSegment `json:"segments"`        // 套用的價格規則，跨越規則邊界時有多段
+	AvailableUnits int                   `json:"availableUnits"`  // 可預訂的球場數，場地未設置球場時為 0 或 1
+	Units          []UnitTimeSlot        `json:"units,omitempty"` // 各球場是否可預訂，場地未設置球場時省略
+}
+
+// UnitTimeSlot 單一球場在時間段內是否可預訂
+type UnitTimeSlot struct {
+	CourtUnitID string `json:"courtUnitId"`
+	Number      int    `json:"number"`
+	Available   bool   `json:"available"`
 }
 
 // AvailabilityResponse 可用時間回應
@@ -137,6 +267,210 @@ type AvailabilityResponse struct {
 	TimeSlots []TimeSlot `json:"timeSlots"`
 }
 
+// ===== 重複預訂相關 =====
+
+// CreateBookingSeriesRequest 創建重複預訂請求
+type CreateBookingSeriesRequest struct {
+	CourtID       string     `json:"courtId" binding:"required,uuid"`
+	CourtUnitID   *string    `json:"courtUnitId" binding:"omitempty,uuid"` // 為空時每次分別分配可用的球場
+	StartTime     time.Time  `json:"startTime" binding:"required"`         // 第一次的開始時間，每次的時刻與時區相同
+	EndTime       time.Time  `json:"endTime" binding:"required"`
+	RRule         string     `json:"rrule" binding:"required,max=200"` // 例如 FREQ=WEEKLY;BYDAY=TU，不含 COUNT 及 UNTIL
+	Until         *time.Time `json:"until"`                            // 最後一次開始時間的上限，與 count 擇一
+	Count         *int       `json:"count" binding:"omitempty,min=1"`
+	BillingMode   string     `json:"billingMode" binding:"omitempty,oneof=per_occurrence aggregate"` // 默認 per_occurrence
+	SkipConflicts bool       `json:"skipConflicts"`                                                  // 為 true 時略過無法預訂的日期，只預訂其餘日期
+	Notes         *string    `json:"notes" binding:"omitempty,max=500"`
+}
+
+// UpdateBookingSeriesRequest 修改重複預訂請求
+//
+// startTime、endTime 為所選預訂的新時間，範圍內其他預訂按相同的位移調整。
+type UpdateBookingSeriesRequest struct {
+	Scope     string     `json:"scope" binding:"required,oneof=this following all"`
+	BookingID string     `json:"bookingId" binding:"required_unless=Scope all,omitempty,uuid"` // scope 為 all 時可省略，默認為下一次
+	StartTime *time.Time `json:"startTime"`
+	EndTime   *time.Time `json:"endTime"`
+	Notes     *string    `json:"notes" binding:"omitempty,max=500"`
+}
+
+// CancelBookingSeriesRequest 取消重複預訂請求
+type CancelBookingSeriesRequest struct {
+	Scope                string   `json:"scope" binding:"required,oneof=this following all"`
+	BookingID            string   `json:"bookingId" binding:"required_unless=Scope all,omitempty,uuid"`
+	Reason               *string  `json:"reason" binding:"omitempty,max=500"`
+	ExpectedRefundAmount *float64 `json:"expectedRefundAmount" binding:"omitempty,min=0"` // 提供時與各次退款總額不符則拒絕取消
+}
+
+// SeriesOccurrenceFailure 無法預訂、修改或取消的一次
+type SeriesOccurrenceFailure struct {
+	BookingID *string   `json:"bookingId,omitempty"` // 創建時無法預訂的日期沒有預訂 ID
+	StartTime time.Time `json:"startTime"`
+	EndTime   time.Time `json:"endTime"`
+	Code      string    `json:"code"`
+}
+
+// BookingSeriesResponse 重複預訂詳情
+type BookingSeriesResponse struct {
+	models.BookingSeries
+	TotalPrice float64                   `json:"totalPrice"`        // 未取消的各次金額總和
+	Skipped    []SeriesOccurrenceFailure `json:"skipped,omitempty"` // 創建時略過的日期
+}
+
+// CancelBookingSeriesResponse 取消重複預訂響應
+type CancelBookingSeriesResponse struct {
+	Cancellations []models.Cancellation     `json:"cancellations"`
+	Skipped       []SeriesOccurrenceFailure `json:"skipped"` // 已過取消期限等無法取消的預訂
+}
+
+// ===== 候補相關 =====
+
+// JoinWaitlistRequest 加入候補請求
+//
+// startTime、endTime 為可接受的時間範圍，duration 為預訂時長，範圍內任一時段釋出時皆可提供。
+type JoinWaitlistRequest struct {
+	CourtID     string    `json:"courtId" binding:"required,uuid"`
+	CourtUnitID *string   `json:"courtUnitId" binding:"omitempty,uuid"` // 為空時任一球場皆可
+	StartTime   time.Time `json:"startTime" binding:"required"`
+	EndTime     time.Time `json:"endTime" binding:"required"`
+	Duration    int       `json:"duration" binding:"omitempty,min=30,max=480"` // 分鐘，默認為整個時間範圍
+}
+
+// WaitlistListRequest 候補列表請求
+type WaitlistListRequest struct {
+	Status *string `form:"status" binding:"omitempty,oneof=waiting offered claimed expired cancelled"` // 默認只列出等待中及已提供的候補
+}
+
+// ===== 分攤付款相關 =====
+
+// CreateBookingSplitRequest 邀請其他用戶分攤預訂費用請求
+//
+// 付款人為 userIds 及 matchId 比賽中已接受的參與者，預訂者自己也會分攤一份。
+// mode 為 custom 時以 shares 指定每人的金額（包含預訂者），總和需等於預訂金額。
+type CreateBookingSplitRequest struct {
+	UserIDs  []string             `json:"userIds" binding:"omitempty,max=20,dive,uuid"`
+	MatchID  *string              `json:"matchId" binding:"omitempty,uuid"`
+	Mode     string               `json:"mode" binding:"omitempty,oneof=equal custom"` // 默認 equal
+	Shares   []BookingShareAmount `json:"shares" binding:"required_if=Mode custom,omitempty,dive"`
+	DueAt    *time.Time           `json:"dueAt"`                                                  // 默認為 24 小時後，不晚於預訂開始
+	Fallback string               `json:"fallback" binding:"omitempty,oneof=cancel charge_owner"` // 默認 cancel
+}
+
+// BookingShareAmount 自訂分攤時一位付款人的金額
+type BookingShareAmount struct {
+	UserID string  `json:"userId" binding:"required,uuid"`
+	Amount float64 `json:"amount" binding:"gt=0"`
+}
+
+// PaymentShareListRequest 用戶的分攤列表請求
+type PaymentShareListRequest struct {
+	Status *string `form:"status" binding:"omitempty,oneof=pending paid reassigned cancelled"` // 默認只列出待付款的分攤
+}
+
+// BookingSplitResponse 分攤付款詳情及各分攤的付款狀態
+type BookingSplitResponse struct {
+	models.BookingPaymentSplit
+	PaidAmount        float64 `json:"paidAmount"`        // 已付款的分攤總和
+	OutstandingAmount float64 `json:"outstandingAmount"` // 尚未付款的分攤總和
+}
+
+// ===== 報到相關 =====
+
+// CheckInPassResponse 預訂的報到碼，前端將 payload 顯示為 QR code
+type CheckInPassResponse struct {
+	BookingID   string     `json:"bookingId"`
+	Payload     string     `json:"payload"`
+	NotBefore   time.Time  `json:"notBefore"`   // 開放報到的時間
+	ExpiresAt   time.Time  `json:"expiresAt"`   // 預訂結束後失效
+	CheckedInAt *time.Time `json:"checkedInAt"` // 已報到時的報到時間
+}
+
+// CheckInRequest 場地人員或自助報到機掃描報到碼的請求
+type CheckInRequest struct {
+	Payload string `json:"payload" binding:"required,max=200"`
+}
+
+// ===== 場地分析相關 =====
+
+// CourtAnalyticsRequest 場地分析請求，默認為最近 30 天
+type CourtAnalyticsRequest struct {
+	From     *string `form:"from"`                                            // YYYY-MM-DD
+	To       *string `form:"to"`                                              // YYYY-MM-DD，包含當天
+	Timezone string  `form:"tz"`                                              // IANA 時區，如 Asia/Taipei，默認 UTC
+	Period   string  `form:"period" binding:"omitempty,oneof=day week month"` // 營收的統計週期，默認 day
+}
+
+// CourtAnalyticsExportRequest 場地分析 CSV 匯出請求
+type CourtAnalyticsExportRequest struct {
+	CourtAnalyticsRequest
+	Report string `form:"report" binding:"required,oneof=summary occupancy revenue demand"`
+}
+
+// CourtAnalyticsResponse 場地分析回應
+type CourtAnalyticsResponse struct {
+	CourtID   string                `json:"courtId"`
+	From      string                `json:"from"`
+	To        string                `json:"to"`
+	Timezone  string                `json:"timezone"`
+	Period    string                `json:"period"`
+	Currency  string                `json:"currency"`
+	Summary   CourtAnalyticsSummary `json:"summary"`
+	Occupancy []OccupancyCell       `json:"occupancy"` // 有營業時間的星期及小時
+	Revenue   []RevenuePeriod       `json:"revenue"`   // 範圍內的每個週期，沒有營收時為 0
+}
+
+// CourtAnalyticsSummary 場地在範圍內的預訂指標，比例為百分比
+type CourtAnalyticsSummary struct {
+	Bookings           int64   `json:"bookings"`           // 開始時間在範圍內的預訂數
+	Cancelled          int64   `json:"cancelled"`          // 其中已取消的預訂數
+	NoShows            int64   `json:"noShows"`            // 其中未到場的預訂數
+	CancellationRate   float64 `json:"cancellationRate"`   // 已取消 / 預訂數
+	NoShowRate         float64 `json:"noShowRate"`         // 未到場 / 已結束且成立的預訂數
+	OccupancyRate      float64 `json:"occupancyRate"`      // 已預訂時數 / 可預訂時數
+	Revenue            float64 `json:"revenue"`            // 成立預訂的金額及取消手續費
+	AverageLeadHours   float64 `json:"averageLeadHours"`   // 成立預訂從建立到開始的平均時數
+	Customers          int64   `json:"customers"`          // 有成立預訂的用戶數
+	RepeatCustomers    int64   `json:"repeatCustomers"`    // 其中到範圍結束為止在此場地有兩筆以上成立預訂的用戶數
+	RepeatCustomerRate float64 `json:"repeatCustomerRate"` // 回頭客 / 用戶數
+}
+
+// OccupancyCell 星期及小時的使用率，星期 0 為週日
+type OccupancyCell struct {
+	Weekday         int     `json:"weekday"`
+	Hour            int     `json:"hour"`
+	BookedMinutes   int64   `json:"bookedMinutes"`   // 各球場已預訂的分鐘數總和
+	CapacityMinutes int64   `json:"capacityMinutes"` // 各球場可預訂的分鐘數總和
+	Rate            float64 `json:"rate"`
+}
+
+// RevenuePeriod 週期的營收，週期以開始日期表示，週從週一開始
+type RevenuePeriod struct {
+	Period           string  `json:"period"`
+	Bookings         int64   `json:"bookings"` // 成立的預訂數
+	BookingRevenue   float64 `json:"bookingRevenue"`
+	CancellationFees float64 `json:"cancellationFees"`
+	Revenue          float64 `json:"revenue"`
+}
+
+// CourtDemandResponse 場地未能滿足的需求熱度
+type CourtDemandResponse struct {
+	CourtID               string       `json:"courtId"`
+	From                  string       `json:"from"`
+	To                    string       `json:"to"`
+	Timezone              string       `json:"timezone"`
+	TotalUnavailableViews int64        `json:"totalUnavailableViews"`
+	TotalRejectedBookings int64        `json:"totalRejectedBookings"`
+	Cells                 []DemandCell `json:"cells"` // 有需求記錄的星期及小時
+}
+
+// DemandCell 星期及小時未能滿足的需求，星期 0 為週日
+type DemandCell struct {
+	Weekday          int   `json:"weekday"`
+	Hour             int   `json:"hour"`
+	UnavailableViews int64 `json:"unavailableViews"` // 查詢時已無空位的次數
+	RejectedBookings int64 `json:"rejectedBookings"` // 預訂或保留時段因已被預訂而失敗的次數
+}
+
 // ===== 評價相關 =====
 
 // CreateReviewRequest 創建評價請求
@@ -167,6 +501,7 @@ type ReviewListRequest struct {
 	Rating    *int    `form:"rating" binding:"omitempty,min=1,max=5"`
 	SortBy    *string `form:"sortBy" binding:"omitempty,oneof=rating created_at helpful"`
 	SortOrder *string `form:"sortOrder" binding:"omitempty,oneof=asc desc"`
+	Cursor    *string `form:"cursor"` // 游標分頁，提供時忽略 page
 	Page      int     `form:"page" binding:"omitempty,min=1"`
 	PageSize  int     `form:"pageSize" binding:"omitempty,min=1,max=50"`
 }
@@ -174,10 +509,11 @@ type ReviewListRequest struct {
 // ReviewListResponse 評價列表回應
 type ReviewListResponse struct {
 	Reviews    []models.CourtReview `json:"reviews"`
-	Total      int64                `json:"total"`
-	Page       int                  `json:"page"`
-	PageSize   int                  `json:"pageSize"`
-	TotalPages int                  `json:"totalPages"`
+	Pagination pagination.PageInfo  `json:"pagination"`
+	Total      int64                `json:"total"`      // 僅頁碼分頁
+	Page       int                  `json:"page"`       // 僅頁碼分頁
+	PageSize   int                  `json:"pageSize"`   // 僅頁碼分頁
+	TotalPages int                  `json:"totalPages"` // 僅頁碼分頁
 }
 
 // ReviewStatistics 評價統計
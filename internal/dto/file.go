@@ -0,0 +1,33 @@
+package dto
+
+import "time"
+
+// ===== 文件存儲相關 =====
+
+// SignedURLRequest 簽名下載網址請求
+type SignedURLRequest struct {
+	Key string `form:"key" binding:"required,max=500"`
+}
+
+// SignedURLResponse 簽名下載網址回應
+type SignedURLResponse struct {
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// PresignUploadRequest 直傳上傳請求
+type PresignUploadRequest struct {
+	Directory string `json:"directory" binding:"required,oneof=avatars courts reviews rackets"`
+	FileName  string `json:"fileName" binding:"required,max=255"`
+	Size      int64  `json:"size" binding:"required,min=1"`
+}
+
+// CompleteUploadRequest 直傳完成請求
+type CompleteUploadRequest struct {
+	Key string `json:"key" binding:"required,max=500"`
+}
+
+// ImageAssetsRequest 圖片資訊查詢請求
+type ImageAssetsRequest struct {
+	URLs []string `form:"url" binding:"required,min=1,max=50"`
+}
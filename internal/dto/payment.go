@@ -0,0 +1,31 @@
+package dto
+
+import "tennis-platform/backend/internal/models"
+
+// ===== 付款相關 =====
+
+// CreatePaymentRequest 發起付款請求
+type CreatePaymentRequest struct {
+	TargetType string `json:"targetType" binding:"required,oneof=booking booking_series lesson club_event_registration booking_share club_membership"`
+	TargetID   string `json:"targetId" binding:"required,uuid"`
+}
+
+// ===== 發票相關 =====
+
+// InvoiceListRequest 發票列表查詢
+type InvoiceListRequest struct {
+	Role      string `form:"role" binding:"omitempty,oneof=buyer seller"` // buyer（默認）為自己付款的發票，seller 為自己的場地、課程或俱樂部開立的發票
+	PaymentID string `form:"paymentId" binding:"omitempty,uuid"`
+	Kind      string `form:"kind" binding:"omitempty,oneof=invoice credit_note"`
+	Page      int    `form:"page" binding:"omitempty,min=1"`
+	PageSize  int    `form:"pageSize" binding:"omitempty,min=1,max=100"`
+}
+
+// InvoiceListResponse 發票列表回應
+type InvoiceListResponse struct {
+	Invoices   []models.Invoice `json:"invoices"`
+	Total      int64            `json:"total"`
+	Page       int              `json:"page"`
+	PageSize   int              `json:"pageSize"`
+	TotalPages int              `json:"totalPages"`
+}
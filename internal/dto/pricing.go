@@ -0,0 +1,19 @@
+package dto
+
+// ===== 場地價格規則相關 =====
+
+// CourtPriceRuleRequest 創建或更新場地價格規則請求，更新時整條規則替換
+type CourtPriceRuleRequest struct {
+	Name         string   `json:"name" binding:"required,min=1,max=100"`
+	Kind         string   `json:"kind" binding:"omitempty,oneof=rate surcharge"` // 默認 rate
+	PricePerHour *float64 `json:"pricePerHour" binding:"required,min=0"`
+	DaysOfWeek   []int    `json:"daysOfWeek" binding:"omitempty,max=7,dive,min=0,max=6"` // 0 為星期日，空表示每天
+	StartTime    *string  `json:"startTime"`                                             // HH:MM，需與 endTime 同時提供
+	EndTime      *string  `json:"endTime"`                                               // HH:MM，可為 24:00
+	StartDate    *string  `json:"startDate"`                                             // YYYY-MM-DD
+	EndDate      *string  `json:"endDate"`                                               // YYYY-MM-DD，包含當天
+	Audience     string   `json:"audience" binding:"omitempty,oneof=all member public"`  // 默認 all
+	ClubID       *string  `json:"clubId" binding:"omitempty,uuid"`                       // audience 為 member 或 public 時必填
+	Priority     int      `json:"priority" binding:"min=0,max=1000"`
+	IsActive     *bool    `json:"isActive"` // 默認 true
+}
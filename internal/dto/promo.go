@@ -0,0 +1,65 @@
+package dto
+
+import (
+	"tennis-platform/backend/internal/models"
+	"time"
+)
+
+// ===== 優惠碼相關 =====
+
+// PromoCodeRequest 創建或更新優惠碼請求，更新時整個優惠碼替換
+type PromoCodeRequest struct {
+	Code                  string     `json:"code" binding:"required,min=3,max=50,alphanum"` // 英數字，不分大小寫
+	Description           *string    `json:"description" binding:"omitempty,max=500"`
+	CourtID               *string    `json:"courtId" binding:"omitempty,uuid"`      // 自己的場地，與 coachId 二選一
+	CoachID               *string    `json:"coachId" binding:"omitempty,uuid"`      // 自己的教練檔案
+	LessonTypeID          *string    `json:"lessonTypeId" binding:"omitempty,uuid"` // 限定課程類型，需屬於 coachId
+	ClubID                *string    `json:"clubId" binding:"omitempty,uuid"`       // 限定俱樂部的有效會員
+	DiscountType          string     `json:"discountType" binding:"required,oneof=percent fixed"`
+	DiscountValue         *float64   `json:"discountValue" binding:"required"`                      // percent 為百分比，fixed 為金額
+	MaxDiscount           *float64   `json:"maxDiscount" binding:"omitempty,gt=0"`                  // 百分比折扣的金額上限
+	MinSpend              float64    `json:"minSpend" binding:"min=0"`                              // 折扣前的最低消費
+	StartsAt              *time.Time `json:"startsAt"`                                              // 空表示立即生效
+	EndsAt                *time.Time `json:"endsAt"`                                                // 空表示不限
+	DaysOfWeek            []int      `json:"daysOfWeek" binding:"omitempty,max=7,dive,min=0,max=6"` // 0 為星期日，空表示每天
+	StartTime             *string    `json:"startTime"`                                             // HH:MM，需與 endTime 同時提供
+	EndTime               *string    `json:"endTime"`                                               // HH:MM，可為 24:00
+	MaxRedemptions        *int       `json:"maxRedemptions" binding:"omitempty,min=1"`
+	MaxRedemptionsPerUser *int       `json:"maxRedemptionsPerUser" binding:"omitempty,min=1"`
+	FirstPurchaseOnly     bool       `json:"firstPurchaseOnly"`
+	IsActive              *bool      `json:"isActive"` // 默認 true
+}
+
+// PromoCodeListRequest 優惠碼列表查詢
+type PromoCodeListRequest struct {
+	CourtID  string `form:"courtId" binding:"omitempty,uuid"`
+	CoachID  string `form:"coachId" binding:"omitempty,uuid"`
+	IsActive *bool  `form:"isActive"`
+	Page     int    `form:"page" binding:"omitempty,min=1"`
+	PageSize int    `form:"pageSize" binding:"omitempty,min=1,max=100"`
+}
+
+// PromoCodeListResponse 優惠碼列表回應
+type PromoCodeListResponse struct {
+	PromoCodes []models.PromoCode `json:"promoCodes"`
+	Total      int64              `json:"total"`
+	Page       int                `json:"page"`
+	PageSize   int                `json:"pageSize"`
+	TotalPages int                `json:"totalPages"`
+}
+
+// PromoRedemptionListRequest 優惠碼使用記錄查詢
+type PromoRedemptionListRequest struct {
+	Status   string `form:"status" binding:"omitempty,oneof=applied reversed"`
+	Page     int    `form:"page" binding:"omitempty,min=1"`
+	PageSize int    `form:"pageSize" binding:"omitempty,min=1,max=100"`
+}
+
+// PromoRedemptionListResponse 優惠碼使用記錄回應
+type PromoRedemptionListResponse struct {
+	Redemptions []models.PromoRedemption `json:"redemptions"`
+	Total       int64                    `json:"total"`
+	Page        int                      `json:"page"`
+	PageSize    int                      `json:"pageSize"`
+	TotalPages  int                      `json:"totalPages"`
+}
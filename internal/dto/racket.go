@@ -1,6 +1,9 @@
 package dto
 
-import "tennis-platform/backend/internal/models"
+import (
+	"tennis-platform/backend/internal/models"
+	"tennis-platform/backend/internal/pagination"
+)
 
 // ===== 球拍相關 =====
 
@@ -68,17 +71,19 @@ type RacketSearchRequest struct {
 	MinRating      *float64 `form:"minRating" binding:"omitempty,min=0,max=5"`
 	SortBy         *string  `form:"sortBy" binding:"omitempty,oneof=brand model price rating popularity"`
 	SortOrder      *string  `form:"sortOrder" binding:"omitempty,oneof=asc desc"`
+	Cursor         *string  `form:"cursor"` // 游標分頁，提供時忽略 page（按價格排序時不支援）
 	Page           int      `form:"page" binding:"omitempty,min=1"`
 	PageSize       int      `form:"pageSize" binding:"omitempty,min=1,max=100"`
 }
 
 // RacketSearchResponse 球拍搜尋回應
 type RacketSearchResponse struct {
-	Rackets    []models.Racket `json:"rackets"`
-	Total      int64           `json:"total"`
-	Page       int             `json:"page"`
-	PageSize   int             `json:"pageSize"`
-	TotalPages int             `json:"totalPages"`
+	Rackets    []models.Racket     `json:"rackets"`
+	Pagination pagination.PageInfo `json:"pagination"`
+	Total      int64               `json:"total"`      // 僅頁碼分頁
+	Page       int                 `json:"page"`       // 僅頁碼分頁
+	PageSize   int                 `json:"pageSize"`   // 僅頁碼分頁
+	TotalPages int                 `json:"totalPages"` // 僅頁碼分頁
 }
 
 // ===== 球拍價格相關 =====
@@ -0,0 +1,42 @@
+package dto
+
+import "tennis-platform/backend/internal/models"
+
+// ===== Webhook 相關 =====
+
+// CreateWebhookRequest 創建 Webhook 訂閱請求
+type CreateWebhookRequest struct {
+	URL         string   `json:"url" binding:"required,url,max=500"`
+	EventTypes  []string `json:"eventTypes" binding:"required,min=1"`
+	Description *string  `json:"description" binding:"omitempty,max=200"`
+}
+
+// UpdateWebhookRequest 更新 Webhook 訂閱請求
+type UpdateWebhookRequest struct {
+	URL         *string  `json:"url" binding:"omitempty,url,max=500"`
+	EventTypes  []string `json:"eventTypes" binding:"omitempty,min=1"`
+	Description *string  `json:"description" binding:"omitempty,max=200"`
+	IsActive    *bool    `json:"isActive"`
+}
+
+// WebhookSecretResponse 含簽名密鑰的訂閱回應（僅在創建及輪換密鑰時返回）
+type WebhookSecretResponse struct {
+	Subscription *models.WebhookSubscription `json:"subscription"`
+	Secret       string                      `json:"secret"`
+}
+
+// WebhookDeliveryListRequest 投遞記錄列表請求
+type WebhookDeliveryListRequest struct {
+	Status   *string `form:"status" binding:"omitempty,oneof=pending succeeded failed"`
+	Page     int     `form:"page" binding:"omitempty,min=1"`
+	PageSize int     `form:"pageSize" binding:"omitempty,min=1,max=100"`
+}
+
+// WebhookDeliveryListResponse 投遞記錄列表回應
+type WebhookDeliveryListResponse struct {
+	Deliveries []models.WebhookDelivery `json:"deliveries"`
+	Total      int64                    `json:"total"`
+	Page       int                      `json:"page"`
+	PageSize   int                      `json:"pageSize"`
+	TotalPages int                      `json:"totalPages"`
+}
@@ -0,0 +1,100 @@
+package etag
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"tennis-platform/backend/internal/apperror"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrPreconditionFailed If-Match 與資源目前版本不符
+var ErrPreconditionFailed = apperror.New(apperror.CodePreconditionFailed)
+
+// Tag 產生強 ETag，格式為 "<version>-<hash>"
+//
+// 版本號用於 If-Match 樂觀鎖；hash 取自回應內容，
+// 讓關聯資料（例如場地的最新評價）變更時 If-None-Match 也能正確失效。
+func Tag(version int64, data []byte) string {
+	sum := sha256.Sum256(data)
+	return fmt.Sprintf(`"%d-%s"`, version, hex.EncodeToString(sum[:8]))
+}
+
+// parseVersion 從 ETag 取出版本號，弱 ETag 不可用於 If-Match
+func parseVersion(tag string) (int64, bool) {
+	tag = strings.TrimSpace(tag)
+	if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+		return 0, false
+	}
+
+	value := tag[1 : len(tag)-1]
+	if i := strings.Index(value, "-"); i >= 0 {
+		value = value[:i]
+	}
+	version, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return version, true
+}
+
+// IfMatchVersion 解析 If-Match 標頭，返回客戶端預期的版本號
+//
+// 未帶標頭或為 "*" 時返回 nil，不做版本檢查；
+// 標籤無法解析或多個標籤指向不同版本時返回 ErrPreconditionFailed。
+func IfMatchVersion(c *gin.Context) (*int64, error) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" || header == "*" {
+		return nil, nil
+	}
+
+	var expected *int64
+	for _, tag := range strings.Split(header, ",") {
+		version, ok := parseVersion(tag)
+		if !ok || (expected != nil && *expected != version) {
+			return nil, ErrPreconditionFailed
+		}
+		expected = &version
+	}
+	return expected, nil
+}
+
+// noneMatch 判斷 If-None-Match 是否包含目前的 ETag（弱比較）
+func noneMatch(header, current string) bool {
+	if strings.TrimSpace(header) == "*" {
+		return true
+	}
+	for _, tag := range strings.Split(header, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(tag), "W/") == current {
+			return true
+		}
+	}
+	return false
+}
+
+// JSON 輸出帶 ETag 的 JSON 回應
+//
+// GET/HEAD 請求的 If-None-Match 與目前 ETag 相符時返回 304 Not Modified。
+func JSON(c *gin.Context, status int, version int64, obj interface{}) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		apperror.Write(c, err)
+		return
+	}
+
+	tag := Tag(version, data)
+	c.Header("ETag", tag)
+
+	method := c.Request.Method
+	if (method == http.MethodGet || method == http.MethodHead) && noneMatch(c.GetHeader("If-None-Match"), tag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	c.Data(status, "application/json; charset=utf-8", data)
+}
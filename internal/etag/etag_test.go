@@ -0,0 +1,72 @@
+package etag
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"tennis-platform/backend/internal/apperror"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func newContext(method string, headers map[string]string) (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(method, "/api/v1/courts/123", nil)
+	for key, value := range headers {
+		c.Request.Header.Set(key, value)
+	}
+	return c, w
+}
+
+func TestIfMatchVersion(t *testing.T) {
+	c, _ := newContext(http.MethodPut, nil)
+	version, err := IfMatchVersion(c)
+	assert.NoError(t, err)
+	assert.Nil(t, version, "未帶 If-Match 時不檢查版本")
+
+	c, _ = newContext(http.MethodPut, map[string]string{"If-Match": "*"})
+	version, err = IfMatchVersion(c)
+	assert.NoError(t, err)
+	assert.Nil(t, version)
+
+	c, _ = newContext(http.MethodPut, map[string]string{"If-Match": Tag(3, []byte(`{}`))})
+	version, err = IfMatchVersion(c)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), *version)
+
+	for _, header := range []string{`W/"3-abc"`, `"abc"`, `"3-a", "4-b"`} {
+		c, _ = newContext(http.MethodPut, map[string]string{"If-Match": header})
+		_, err = IfMatchVersion(c)
+		assert.True(t, apperror.HasCode(err, apperror.CodePreconditionFailed), header)
+	}
+}
+
+func TestJSON_NotModified(t *testing.T) {
+	body := gin.H{"id": "123", "name": "中央網球場"}
+
+	c, w := newContext(http.MethodGet, nil)
+	JSON(c, http.StatusOK, 2, body)
+	assert.Equal(t, http.StatusOK, w.Code)
+	tag := w.Header().Get("ETag")
+	assert.Regexp(t, `^"2-[0-9a-f]{16}"$`, tag)
+
+	c, w = newContext(http.MethodGet, map[string]string{"If-None-Match": "W/" + tag})
+	JSON(c, http.StatusOK, 2, body)
+	c.Writer.WriteHeaderNow()
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, w.Body.String())
+
+	// 內容變更後 ETag 隨之改變
+	c, w = newContext(http.MethodGet, map[string]string{"If-None-Match": tag})
+	JSON(c, http.StatusOK, 2, gin.H{"id": "123", "name": "河濱網球場"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotEqual(t, tag, w.Header().Get("ETag"))
+
+	// 非 GET 請求不返回 304
+	c, w = newContext(http.MethodPut, map[string]string{"If-None-Match": tag})
+	JSON(c, http.StatusOK, 2, body)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
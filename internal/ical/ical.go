@@ -0,0 +1,160 @@
+// Package ical 產生 RFC 5545 iCalendar 文件
+package ical
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ContentType iCalendar 的 MIME 類型
+const ContentType = "text/calendar; charset=utf-8"
+
+// 事件狀態
+const (
+	StatusConfirmed = "CONFIRMED"
+	StatusTentative = "TENTATIVE"
+	StatusCancelled = "CANCELLED"
+)
+
+// maxLineOctets 每行最多 75 個字節，超過時折行
+const maxLineOctets = 75
+
+// Calendar 行事曆
+type Calendar struct {
+	ProdID string
+	Name   string // X-WR-CALNAME，訂閱時顯示的名稱
+	// RefreshInterval 建議客戶端的更新間隔，0 表示不指定
+	RefreshInterval time.Duration
+	Events          []Event
+}
+
+// Event 行事曆事件
+type Event struct {
+	UID          string    // 同一事件在改期、取消後保持不變
+	Sequence     int       // 每次修改遞增，客戶端據此判斷是否更新
+	Stamp        time.Time // 留空時使用輸出時間
+	Start        time.Time
+	End          time.Time
+	Summary      string
+	Description  string
+	Location     string
+	Geo          *Geo
+	Status       string
+	URL          string
+	LastModified time.Time
+}
+
+// Geo 地理位置
+type Geo struct {
+	Latitude  float64
+	Longitude float64
+}
+
+// Encode 輸出 iCalendar 文件
+func (c *Calendar) Encode() []byte {
+	w := &writer{}
+	w.line("BEGIN", "VCALENDAR")
+	w.line("VERSION", "2.0")
+	w.line("PRODID", c.ProdID)
+	w.line("CALSCALE", "GREGORIAN")
+	w.line("METHOD", "PUBLISH")
+	if c.Name != "" {
+		w.line("X-WR-CALNAME", escape(c.Name))
+	}
+	if c.RefreshInterval > 0 {
+		duration := formatDuration(c.RefreshInterval)
+		w.line("REFRESH-INTERVAL;VALUE=DURATION", duration)
+		w.line("X-PUBLISHED-TTL", duration)
+	}
+
+	now := time.Now()
+	for _, event := range c.Events {
+		stamp := event.Stamp
+		if stamp.IsZero() {
+			stamp = now
+		}
+
+		w.line("BEGIN", "VEVENT")
+		w.line("UID", event.UID)
+		w.line("SEQUENCE", strconv.Itoa(event.Sequence))
+		w.line("DTSTAMP", formatTime(stamp))
+		w.line("DTSTART", formatTime(event.Start))
+		w.line("DTEND", formatTime(event.End))
+		w.line("SUMMARY", escape(event.Summary))
+		if event.Description != "" {
+			w.line("DESCRIPTION", escape(event.Description))
+		}
+		if event.Location != "" {
+			w.line("LOCATION", escape(event.Location))
+		}
+		if event.Geo != nil {
+			w.line("GEO", fmt.Sprintf("%.6f;%.6f", event.Geo.Latitude, event.Geo.Longitude))
+		}
+		if event.Status != "" {
+			w.line("STATUS", event.Status)
+		}
+		if event.URL != "" {
+			w.line("URL", event.URL)
+		}
+		if !event.LastModified.IsZero() {
+			w.line("LAST-MODIFIED", formatTime(event.LastModified))
+		}
+		w.line("END", "VEVENT")
+	}
+
+	w.line("END", "VCALENDAR")
+	return w.buf.Bytes()
+}
+
+type writer struct {
+	buf bytes.Buffer
+}
+
+// line 寫入一行內容，超過 75 字節時以 CRLF 加空白折行，不拆開 UTF-8 字元
+func (w *writer) line(name, value string) {
+	content := name + ":" + value
+	width := 0
+	for len(content) > 0 {
+		_, size := utf8.DecodeRuneInString(content)
+		if width+size > maxLineOctets {
+			w.buf.WriteString("\r\n ")
+			width = 1
+		}
+		w.buf.WriteString(content[:size])
+		width += size
+		content = content[size:]
+	}
+	w.buf.WriteString("\r\n")
+}
+
+var textEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`)
+
+// escape 轉義 TEXT 類型的值
+func escape(value string) string {
+	return textEscaper.Replace(value)
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+// formatDuration 輸出 RFC 5545 的 DURATION，精確到秒
+func formatDuration(d time.Duration) string {
+	seconds := int64(d / time.Second)
+	var b strings.Builder
+	b.WriteString("PT")
+	if h := seconds / 3600; h > 0 {
+		b.WriteString(strconv.FormatInt(h, 10) + "H")
+	}
+	if m := seconds % 3600 / 60; m > 0 {
+		b.WriteString(strconv.FormatInt(m, 10) + "M")
+	}
+	if s := seconds % 60; s > 0 || seconds == 0 {
+		b.WriteString(strconv.FormatInt(s, 10) + "S")
+	}
+	return b.String()
+}
@@ -0,0 +1,57 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalendar_Encode(t *testing.T) {
+	start := time.Date(2025, 5, 4, 18, 0, 0, 0, time.FixedZone("CST", 8*3600))
+	calendar := &Calendar{
+		ProdID:          "-//Tennis Platform//Calendar//ZH",
+		Name:            "我的網球行程",
+		RefreshInterval: time.Hour,
+		Events: []Event{{
+			UID:         "booking-1@tennis-platform",
+			Sequence:    2,
+			Stamp:       start,
+			Start:       start,
+			End:         start.Add(90 * time.Minute),
+			Summary:     "場地預訂, 大安網球場; 2 號場",
+			Description: "備註\n帶球",
+			Location:    "台北市大安區" + strings.Repeat("新生南路", 10),
+			Geo:         &Geo{Latitude: 25.0330, Longitude: 121.5654},
+			Status:      StatusCancelled,
+		}},
+	}
+
+	output := string(calendar.Encode())
+
+	assert.True(t, strings.HasPrefix(output, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n"))
+	assert.True(t, strings.HasSuffix(output, "END:VEVENT\r\nEND:VCALENDAR\r\n"))
+	assert.Contains(t, output, "REFRESH-INTERVAL;VALUE=DURATION:PT1H\r\n")
+	assert.Contains(t, output, "SEQUENCE:2\r\n")
+	assert.Contains(t, output, "DTSTART:20250504T100000Z\r\n")
+	assert.Contains(t, output, "DTEND:20250504T113000Z\r\n")
+	assert.Contains(t, output, `SUMMARY:場地預訂\, 大安網球場\; 2 號場`)
+	assert.Contains(t, output, `DESCRIPTION:備註\n帶球`)
+	assert.Contains(t, output, "GEO:25.033000;121.565400\r\n")
+	assert.Contains(t, output, "STATUS:CANCELLED\r\n")
+
+	// 每行不超過 75 字節，折行後還原為原本的內容
+	for _, line := range strings.Split(strings.TrimSuffix(output, "\r\n"), "\r\n") {
+		assert.LessOrEqual(t, len(line), 75)
+	}
+	unfolded := strings.ReplaceAll(output, "\r\n ", "")
+	assert.Contains(t, unfolded, "LOCATION:台北市大安區"+strings.Repeat("新生南路", 10)+"\r\n")
+}
+
+func TestFormatDuration(t *testing.T) {
+	assert.Equal(t, "PT1H", formatDuration(time.Hour))
+	assert.Equal(t, "PT1H30M", formatDuration(90*time.Minute))
+	assert.Equal(t, "PT45S", formatDuration(45*time.Second))
+	assert.Equal(t, "PT0S", formatDuration(0))
+}
@@ -0,0 +1,320 @@
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNoCalendar 內容不是 iCalendar 文件
+var ErrNoCalendar = errors.New("ical: missing VCALENDAR")
+
+// VEvent 解析後的事件，只保留判斷忙碌時段所需的欄位
+type VEvent struct {
+	UID          string
+	Start        time.Time
+	End          time.Time
+	AllDay       bool
+	RRule        string
+	ExDates      []time.Time
+	RecurrenceID *time.Time // 覆寫重複事件中的某一次
+	Cancelled    bool
+	Transparent  bool // TRANSP:TRANSPARENT，不佔用時間
+}
+
+// Period 時間區間
+type Period struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Parse 解析 iCalendar 文件中的 VEVENT
+//
+// 帶 TZID 的時間以 IANA 時區名稱解析，無法識別的時區及浮動時間視為 UTC；VTIMEZONE 定義不解析。
+func Parse(r io.Reader) ([]VEvent, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		events   []VEvent
+		current  *VEvent
+		found    bool
+		depth    int // VEVENT 內的子元件（例如 VALARM）
+		duration string
+	)
+
+	for i, line := range lines {
+		name, params, value, ok := splitProperty(line)
+		if !ok {
+			continue
+		}
+
+		switch {
+		case name == "BEGIN" && strings.EqualFold(value, "VCALENDAR"):
+			found = true
+			continue
+		case name == "BEGIN" && strings.EqualFold(value, "VEVENT"):
+			current = &VEvent{}
+			duration = ""
+			continue
+		case current == nil:
+			continue
+		case name == "BEGIN":
+			depth++
+			continue
+		case name == "END" && depth > 0:
+			depth--
+			continue
+		case depth > 0:
+			continue
+		case name == "END" && strings.EqualFold(value, "VEVENT"):
+			if current.Start.IsZero() {
+				return nil, fmt.Errorf("ical: line %d: VEVENT without DTSTART", i+1)
+			}
+			if current.End.IsZero() {
+				current.End = defaultEnd(current, duration)
+			}
+			events = append(events, *current)
+			current = nil
+			continue
+		}
+
+		switch name {
+		case "UID":
+			current.UID = value
+		case "DTSTART":
+			current.Start, current.AllDay, err = parseDateTime(value, params)
+		case "DTEND":
+			current.End, _, err = parseDateTime(value, params)
+		case "DURATION":
+			duration = value
+		case "RRULE":
+			current.RRule = value
+		case "EXDATE":
+			for _, v := range strings.Split(value, ",") {
+				var exdate time.Time
+				if exdate, _, err = parseDateTime(v, params); err != nil {
+					break
+				}
+				current.ExDates = append(current.ExDates, exdate)
+			}
+		case "RECURRENCE-ID":
+			var recurrenceID time.Time
+			if recurrenceID, _, err = parseDateTime(value, params); err == nil {
+				current.RecurrenceID = &recurrenceID
+			}
+		case "STATUS":
+			current.Cancelled = strings.EqualFold(value, "CANCELLED")
+		case "TRANSP":
+			current.Transparent = strings.EqualFold(value, "TRANSPARENT")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("ical: line %d: %w", i+1, err)
+		}
+	}
+
+	if !found {
+		return nil, ErrNoCalendar
+	}
+	return events, nil
+}
+
+// Expand 將事件展開為與 [from, to) 有交集的忙碌時段，依開始時間排序
+//
+// 已取消及標記為空閒的事件不計入；重複事件依 RRULE 展開，並套用 EXDATE 及 RECURRENCE-ID 覆寫。
+// 無法解析的 RRULE（例如 BYSETPOS、HOURLY）只計入第一次，不因單一事件導致整份行事曆失敗。
+func Expand(events []VEvent, from, to time.Time) []Period {
+	// 以 UID 及原始開始時間記錄被覆寫的重複事件
+	overridden := map[string]bool{}
+	for _, event := range events {
+		if event.RecurrenceID != nil {
+			overridden[occurrenceKey(event.UID, *event.RecurrenceID)] = true
+		}
+	}
+
+	var periods []Period
+	add := func(start, end time.Time) {
+		if start.Before(to) && end.After(from) {
+			periods = append(periods, Period{Start: start, End: end})
+		}
+	}
+
+	for _, event := range events {
+		if event.Cancelled || event.Transparent || !event.End.After(event.Start) {
+			continue
+		}
+		length := event.End.Sub(event.Start)
+
+		if event.RRule == "" || event.RecurrenceID != nil {
+			add(event.Start, event.End)
+			continue
+		}
+
+		rule, err := parseRRule(event.RRule, event.Start.Location())
+		if err != nil {
+			add(event.Start, event.End)
+			continue
+		}
+		excluded := make(map[int64]bool, len(event.ExDates))
+		for _, exdate := range event.ExDates {
+			excluded[exdate.Unix()] = true
+		}
+
+		rule.each(event.Start, to.Add(length), func(start time.Time) bool {
+			if excluded[start.Unix()] || overridden[occurrenceKey(event.UID, start)] {
+				return true
+			}
+			add(start, start.Add(length))
+			return true
+		})
+	}
+
+	sort.Slice(periods, func(i, j int) bool { return periods[i].Start.Before(periods[j].Start) })
+	return periods
+}
+
+func occurrenceKey(uid string, start time.Time) string {
+	return uid + "|" + strconv.FormatInt(start.Unix(), 10)
+}
+
+// defaultEnd 沒有 DTEND 時依 DURATION 計算；全天事件默認一天，其他事件默認沒有時長
+func defaultEnd(event *VEvent, duration string) time.Time {
+	if duration != "" {
+		if d, err := parseDuration(duration); err == nil {
+			return event.Start.Add(d)
+		}
+	}
+	if event.AllDay {
+		return event.Start.AddDate(0, 0, 1)
+	}
+	return event.Start
+}
+
+// unfold 讀取所有內容行並還原折行
+func unfold(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var lines []string
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if len(line) > 0 && (line[0] == ' ' || line[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("ical: %w", err)
+	}
+	return lines, nil
+}
+
+// splitProperty 拆分內容行為名稱、參數及值，參數值可以用雙引號包住
+func splitProperty(line string) (string, map[string]string, string, bool) {
+	inQuotes := false
+	colon := -1
+	for i, c := range line {
+		if c == '"' {
+			inQuotes = !inQuotes
+		} else if c == ':' && !inQuotes {
+			colon = i
+			break
+		}
+	}
+	if colon <= 0 {
+		return "", nil, "", false
+	}
+
+	parts := strings.Split(line[:colon], ";")
+	params := make(map[string]string, len(parts)-1)
+	for _, part := range parts[1:] {
+		if key, value, ok := strings.Cut(part, "="); ok {
+			params[strings.ToUpper(key)] = strings.Trim(value, `"`)
+		}
+	}
+	return strings.ToUpper(parts[0]), params, line[colon+1:], true
+}
+
+// parseDateTime 解析 DATE 或 DATE-TIME，返回是否為全天（DATE）
+func parseDateTime(value string, params map[string]string) (time.Time, bool, error) {
+	value = strings.TrimSpace(value)
+	loc := time.UTC
+	if tzid := params["TZID"]; tzid != "" {
+		if l, err := time.LoadLocation(tzid); err == nil {
+			loc = l
+		}
+	}
+
+	if params["VALUE"] == "DATE" || len(value) == 8 {
+		t, err := time.ParseInLocation("20060102", value, loc)
+		if err != nil {
+			return time.Time{}, false, fmt.Errorf("invalid date %q", value)
+		}
+		return t, true, nil
+	}
+
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse("20060102T150405Z", value)
+		if err != nil {
+			return time.Time{}, false, fmt.Errorf("invalid date-time %q", value)
+		}
+		return t, false, nil
+	}
+
+	t, err := time.ParseInLocation("20060102T150405", value, loc)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("invalid date-time %q", value)
+	}
+	return t, false, nil
+}
+
+// parseDuration 解析 RFC 5545 的 DURATION，例如 PT1H30M、P1D、P2W
+func parseDuration(value string) (time.Duration, error) {
+	sign := time.Duration(1)
+	switch {
+	case strings.HasPrefix(value, "-"):
+		sign = -1
+		value = value[1:]
+	case strings.HasPrefix(value, "+"):
+		value = value[1:]
+	}
+	if !strings.HasPrefix(value, "P") || len(value) < 3 {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+
+	var total time.Duration
+	number := 0
+	inTime := false
+	for _, c := range value[1:] {
+		switch {
+		case c >= '0' && c <= '9':
+			number = number*10 + int(c-'0')
+			continue
+		case c == 'T':
+			inTime = true
+			continue
+		case c == 'W' && !inTime:
+			total += time.Duration(number) * 7 * 24 * time.Hour
+		case c == 'D' && !inTime:
+			total += time.Duration(number) * 24 * time.Hour
+		case c == 'H' && inTime:
+			total += time.Duration(number) * time.Hour
+		case c == 'M' && inTime:
+			total += time.Duration(number) * time.Minute
+		case c == 'S' && inTime:
+			total += time.Duration(number) * time.Second
+		default:
+			return 0, fmt.Errorf("invalid duration %q", value)
+		}
+		number = 0
+	}
+	return sign * total, nil
+}
@@ -0,0 +1,199 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const sampleCalendar = "BEGIN:VCALENDAR\r\n" +
+	"VERSION:2.0\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:weekly@example.com\r\n" +
+	"DTSTART;TZID=Asia/Taipei:20250505T090000\r\n" +
+	"DTEND;TZID=Asia/Taipei:20250505T100000\r\n" +
+	"RRULE:FREQ=WEEKLY;BYDAY=MO,WE;COUNT=6\r\n" +
+	"EXDATE;TZID=Asia/Taipei:20250507T090000\r\n" +
+	"SUMMARY:很長的摘要會被折\r\n" +
+	" 行\r\n" +
+	"BEGIN:VALARM\r\n" +
+	"TRIGGER:-PT15M\r\n" +
+	"DTSTART:20250101T000000Z\r\n" +
+	"END:VALARM\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:weekly@example.com\r\n" +
+	"RECURRENCE-ID;TZID=Asia/Taipei:20250512T090000\r\n" +
+	"DTSTART;TZID=Asia/Taipei:20250512T140000\r\n" +
+	"DURATION:PT2H\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:holiday@example.com\r\n" +
+	"DTSTART;VALUE=DATE:20250510\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:free@example.com\r\n" +
+	"DTSTART:20250506T010000Z\r\n" +
+	"DTEND:20250506T020000Z\r\n" +
+	"TRANSP:TRANSPARENT\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:cancelled@example.com\r\n" +
+	"DTSTART:20250506T030000Z\r\n" +
+	"DTEND:20250506T040000Z\r\n" +
+	"STATUS:CANCELLED\r\n" +
+	"END:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+func TestParse(t *testing.T) {
+	events, err := Parse(strings.NewReader(sampleCalendar))
+	require.NoError(t, err)
+	require.Len(t, events, 5)
+
+	taipei, err := time.LoadLocation("Asia/Taipei")
+	require.NoError(t, err)
+
+	weekly := events[0]
+	assert.Equal(t, "weekly@example.com", weekly.UID)
+	assert.True(t, weekly.Start.Equal(time.Date(2025, 5, 5, 9, 0, 0, 0, taipei)))
+	assert.True(t, weekly.End.Equal(time.Date(2025, 5, 5, 10, 0, 0, 0, taipei)))
+	assert.Len(t, weekly.ExDates, 1)
+
+	override := events[1]
+	require.NotNil(t, override.RecurrenceID)
+	assert.Equal(t, 2*time.Hour, override.End.Sub(override.Start))
+
+	holiday := events[2]
+	assert.True(t, holiday.AllDay)
+	assert.Equal(t, 24*time.Hour, holiday.End.Sub(holiday.Start))
+
+	assert.True(t, events[3].Transparent)
+	assert.True(t, events[4].Cancelled)
+
+	_, err = Parse(strings.NewReader("not a calendar"))
+	assert.ErrorIs(t, err, ErrNoCalendar)
+
+	_, err = Parse(strings.NewReader("BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nUID:x\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"))
+	assert.Error(t, err)
+}
+
+func TestExpand(t *testing.T) {
+	events, err := Parse(strings.NewReader(sampleCalendar))
+	require.NoError(t, err)
+
+	from := time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	periods := Expand(events, from, to)
+
+	var starts []string
+	for _, p := range periods {
+		starts = append(starts, p.Start.UTC().Format("01-02 15:04")+"/"+p.End.Sub(p.Start).String())
+	}
+	assert.Equal(t, []string{
+		"05-05 01:00/1h0m0s",
+		// 05-07 由 EXDATE 排除，05-12 的那次改到下午；沒有時區的全天事件視為 UTC
+		"05-10 00:00/24h0m0s",
+		"05-12 06:00/2h0m0s",
+		"05-14 01:00/1h0m0s",
+		"05-19 01:00/1h0m0s",
+		"05-21 01:00/1h0m0s",
+	}, starts)
+
+	// 只返回與範圍有交集的時段
+	periods = Expand(events, time.Date(2025, 5, 13, 0, 0, 0, 0, time.UTC), time.Date(2025, 5, 15, 0, 0, 0, 0, time.UTC))
+	require.Len(t, periods, 1)
+	assert.Equal(t, 14, periods[0].Start.Day())
+}
+
+func TestRRule(t *testing.T) {
+	tests := []struct {
+		name     string
+		dtstart  time.Time
+		rule     string
+		expected []string
+	}{
+		{
+			name:     "每兩天直到指定日期",
+			dtstart:  time.Date(2025, 1, 30, 9, 0, 0, 0, time.UTC),
+			rule:     "FREQ=DAILY;INTERVAL=2;UNTIL=20250205T090000Z",
+			expected: []string{"2025-01-30", "2025-02-01", "2025-02-03", "2025-02-05"},
+		},
+		{
+			name:     "每月最後一個星期五",
+			dtstart:  time.Date(2025, 1, 31, 18, 0, 0, 0, time.UTC),
+			rule:     "FREQ=MONTHLY;BYDAY=-1FR;COUNT=3",
+			expected: []string{"2025-01-31", "2025-02-28", "2025-03-28"},
+		},
+		{
+			name:     "每月 31 日，沒有 31 日的月份略過",
+			dtstart:  time.Date(2025, 1, 31, 8, 0, 0, 0, time.UTC),
+			rule:     "FREQ=MONTHLY;COUNT=3",
+			expected: []string{"2025-01-31", "2025-03-31", "2025-05-31"},
+		},
+		{
+			name:     "每月倒數第一天",
+			dtstart:  time.Date(2024, 1, 31, 8, 0, 0, 0, time.UTC),
+			rule:     "FREQ=MONTHLY;BYMONTHDAY=-1;COUNT=2",
+			expected: []string{"2024-01-31", "2024-02-29"},
+		},
+		{
+			name:     "每年十一月第四個星期四",
+			dtstart:  time.Date(2024, 11, 28, 12, 0, 0, 0, time.UTC),
+			rule:     "FREQ=YEARLY;BYMONTH=11;BYDAY=4TH;COUNT=2",
+			expected: []string{"2024-11-28", "2025-11-27"},
+		},
+		{
+			name:     "隔週二四",
+			dtstart:  time.Date(2025, 5, 6, 7, 0, 0, 0, time.UTC),
+			rule:     "FREQ=WEEKLY;INTERVAL=2;BYDAY=TU,TH;COUNT=4",
+			expected: []string{"2025-05-06", "2025-05-08", "2025-05-20", "2025-05-22"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := parseRRule(tt.rule, tt.dtstart.Location())
+			require.NoError(t, err)
+
+			var dates []string
+			rule.each(tt.dtstart, tt.dtstart.AddDate(3, 0, 0), func(start time.Time) bool {
+				assert.Equal(t, tt.dtstart.Hour(), start.Hour())
+				dates = append(dates, start.Format("2006-01-02"))
+				return true
+			})
+			assert.Equal(t, tt.expected, dates)
+		})
+	}
+
+	_, err := parseRRule("FREQ=HOURLY", time.UTC)
+	assert.Error(t, err)
+	_, err = parseRRule("FREQ=MONTHLY;BYSETPOS=-1;BYDAY=MO,TU,WE,TH,FR", time.UTC)
+	assert.Error(t, err)
+}
+
+func TestOccurrences(t *testing.T) {
+	// 每週二晚上七點，依 dtstart 的時區展開
+	taipei := time.FixedZone("Asia/Taipei", 8*3600)
+	dtstart := time.Date(2025, 9, 2, 19, 0, 0, 0, taipei)
+
+	starts, err := Occurrences("FREQ=WEEKLY;BYDAY=TU", dtstart, dtstart.AddDate(1, 0, 0), 3)
+	require.NoError(t, err)
+	require.Len(t, starts, 3)
+	for i, start := range starts {
+		assert.Equal(t, dtstart.AddDate(0, 0, 7*i), start)
+	}
+
+	// limit 之後及 UNTIL 之後不再返回
+	starts, err = Occurrences("FREQ=WEEKLY;UNTIL=20250916T110000Z", dtstart, dtstart.AddDate(1, 0, 0), 10)
+	require.NoError(t, err)
+	assert.Len(t, starts, 3)
+	starts, err = Occurrences("FREQ=DAILY", dtstart, dtstart.AddDate(0, 0, 2), 10)
+	require.NoError(t, err)
+	assert.Len(t, starts, 2)
+
+	_, err = Occurrences("FREQ=HOURLY", dtstart, dtstart.AddDate(1, 0, 0), 10)
+	assert.Error(t, err)
+}
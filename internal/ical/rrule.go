@@ -0,0 +1,329 @@
+package ical
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxRRulePeriods 展開重複規則時最多迭代的週期數，避免永遠不會命中的規則造成無限迴圈
+const maxRRulePeriods = 50000
+
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// weekdayNum BYDAY 的值，例如 2TU 表示第二個星期二，-1FR 表示最後一個星期五，n 為 0 時不限第幾個
+type weekdayNum struct {
+	n   int
+	day time.Weekday
+}
+
+// rrule 支援 FREQ 為 DAILY、WEEKLY、MONTHLY、YEARLY，以及 INTERVAL、COUNT、UNTIL、BYDAY、BYMONTHDAY、BYMONTH、WKST
+type rrule struct {
+	freq       string
+	interval   int
+	count      int
+	until      *time.Time
+	byDay      []weekdayNum
+	byMonthDay []int
+	byMonth    []time.Month
+	wkst       time.Weekday
+}
+
+func parseRRule(value string, loc *time.Location) (*rrule, error) {
+	rule := &rrule{interval: 1, wkst: time.Monday}
+
+	for _, part := range strings.Split(value, ";") {
+		key, val, ok := strings.Cut(part, "=")
+		if !ok {
+			continue
+		}
+
+		var err error
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			rule.freq = strings.ToUpper(val)
+		case "INTERVAL":
+			rule.interval, err = strconv.Atoi(val)
+			if err == nil && rule.interval < 1 {
+				err = fmt.Errorf("invalid interval")
+			}
+		case "COUNT":
+			rule.count, err = strconv.Atoi(val)
+		case "UNTIL":
+			var until time.Time
+			var allDay bool
+			until, allDay, err = parseDateTime(val, map[string]string{})
+			if err == nil {
+				if allDay {
+					// 只有日期時包含當天
+					until = time.Date(until.Year(), until.Month(), until.Day(), 23, 59, 59, 0, loc)
+				} else if !strings.HasSuffix(val, "Z") {
+					until = time.Date(until.Year(), until.Month(), until.Day(), until.Hour(), until.Minute(), until.Second(), 0, loc)
+				}
+				rule.until = &until
+			}
+		case "BYDAY":
+			for _, v := range strings.Split(val, ",") {
+				v = strings.ToUpper(strings.TrimSpace(v))
+				if len(v) < 2 {
+					return nil, fmt.Errorf("ical: invalid BYDAY %q", val)
+				}
+				day, ok := weekdays[v[len(v)-2:]]
+				if !ok {
+					return nil, fmt.Errorf("ical: invalid BYDAY %q", val)
+				}
+				n := 0
+				if prefix := v[:len(v)-2]; prefix != "" {
+					if n, err = strconv.Atoi(prefix); err != nil {
+						return nil, fmt.Errorf("ical: invalid BYDAY %q", val)
+					}
+				}
+				rule.byDay = append(rule.byDay, weekdayNum{n: n, day: day})
+			}
+		case "BYMONTHDAY":
+			for _, v := range strings.Split(val, ",") {
+				var day int
+				if day, err = strconv.Atoi(v); err != nil || day == 0 || day < -31 || day > 31 {
+					return nil, fmt.Errorf("ical: invalid BYMONTHDAY %q", val)
+				}
+				rule.byMonthDay = append(rule.byMonthDay, day)
+			}
+		case "BYMONTH":
+			for _, v := range strings.Split(val, ",") {
+				var month int
+				if month, err = strconv.Atoi(v); err != nil || month < 1 || month > 12 {
+					return nil, fmt.Errorf("ical: invalid BYMONTH %q", val)
+				}
+				rule.byMonth = append(rule.byMonth, time.Month(month))
+			}
+		case "WKST":
+			day, ok := weekdays[strings.ToUpper(val)]
+			if !ok {
+				return nil, fmt.Errorf("ical: invalid WKST %q", val)
+			}
+			rule.wkst = day
+		default:
+			// BYSETPOS、BYHOUR 等規則無法正確展開，不猜測
+			return nil, fmt.Errorf("ical: unsupported RRULE part %s", key)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("ical: invalid RRULE %s: %w", key, err)
+		}
+	}
+
+	switch rule.freq {
+	case "DAILY", "WEEKLY", "MONTHLY", "YEARLY":
+	default:
+		return nil, fmt.Errorf("ical: unsupported FREQ %q", rule.freq)
+	}
+	return rule, nil
+}
+
+// Occurrences 依 RRULE 返回從 dtstart 起、limit 之前的開始時間，最多 max 次
+//
+// 規則中的 COUNT 及 UNTIL 同樣生效；不支援的規則返回錯誤，不猜測。
+func Occurrences(value string, dtstart, limit time.Time, max int) ([]time.Time, error) {
+	rule, err := parseRRule(value, dtstart.Location())
+	if err != nil {
+		return nil, err
+	}
+
+	var starts []time.Time
+	rule.each(dtstart, limit, func(start time.Time) bool {
+		starts = append(starts, start)
+		return len(starts) < max
+	})
+	return starts, nil
+}
+
+// each 依序回調 dtstart 之後、limit 之前的每次開始時間，fn 返回 false 時停止
+//
+// 每次的時刻與 dtstart 相同，以 dtstart 的時區計算，跨越夏令時間時保持當地時刻不變。
+func (r *rrule) each(dtstart, limit time.Time, fn func(time.Time) bool) {
+	hour, minute, second := dtstart.Clock()
+	loc := dtstart.Location()
+	at := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, hour, minute, second, 0, loc)
+	}
+
+	emitted := 0
+	for period := 0; period < maxRRulePeriods; period++ {
+		periodStart, candidates := r.candidates(dtstart, period, at)
+		if !periodStart.Before(limit) || (r.until != nil && periodStart.After(*r.until)) {
+			return
+		}
+
+		for _, candidate := range candidates {
+			if candidate.Before(dtstart) {
+				continue
+			}
+			if !candidate.Before(limit) || (r.until != nil && candidate.After(*r.until)) {
+				return
+			}
+			if r.count > 0 && emitted >= r.count {
+				return
+			}
+			emitted++
+			if !fn(candidate) {
+				return
+			}
+		}
+	}
+}
+
+// candidates 返回第 period 個週期的開始時間及其中符合規則的時間，依時間排序
+func (r *rrule) candidates(dtstart time.Time, period int, at func(int, time.Month, int) time.Time) (time.Time, []time.Time) {
+	year, month, day := dtstart.Date()
+	step := period * r.interval
+	var result []time.Time
+
+	switch r.freq {
+	case "DAILY":
+		date := at(year, month, day+step)
+		if r.matchMonth(date.Month()) && r.matchMonthDay(date) && r.matchWeekday(date.Weekday()) {
+			result = append(result, date)
+		}
+		return date, result
+
+	case "WEEKLY":
+		offset := (int(dtstart.Weekday()) - int(r.wkst) + 7) % 7
+		weekStart := at(year, month, day-offset+step*7)
+		days := r.byDay
+		if len(days) == 0 {
+			days = []weekdayNum{{day: dtstart.Weekday()}}
+		}
+		for i := 0; i < 7; i++ {
+			date := at(weekStart.Year(), weekStart.Month(), weekStart.Day()+i)
+			for _, d := range days {
+				if d.day == date.Weekday() && r.matchMonth(date.Month()) {
+					result = append(result, date)
+					break
+				}
+			}
+		}
+		return weekStart, result
+
+	case "MONTHLY":
+		first := at(year, month+time.Month(step), 1)
+		if r.matchMonth(first.Month()) {
+			result = r.daysInMonth(first, dtstart.Day(), at)
+		}
+		return first, result
+
+	default: // YEARLY
+		first := at(year+step, time.January, 1)
+		months := r.byMonth
+		if len(months) == 0 {
+			months = []time.Month{month}
+		}
+		for _, m := range months {
+			result = append(result, r.daysInMonth(at(first.Year(), m, 1), dtstart.Day(), at)...)
+		}
+		sort.Slice(result, func(i, j int) bool { return result[i].Before(result[j]) })
+		return first, result
+	}
+}
+
+// daysInMonth 返回某月符合 BYMONTHDAY、BYDAY 的日期，兩者都沒有時使用 dtstart 的日期（該月沒有這一天時略過）
+func (r *rrule) daysInMonth(first time.Time, defaultDay int, at func(int, time.Month, int) time.Time) []time.Time {
+	year, month := first.Year(), first.Month()
+	last := at(year, month+1, 0).Day()
+
+	matched := make([]bool, last+1)
+	switch {
+	case len(r.byMonthDay) > 0:
+		for _, d := range r.byMonthDay {
+			if d < 0 {
+				d = last + d + 1
+			}
+			if d >= 1 && d <= last {
+				matched[d] = true
+			}
+		}
+		if len(r.byDay) > 0 {
+			for d := 1; d <= last; d++ {
+				if matched[d] && !r.matchWeekday(at(year, month, d).Weekday()) {
+					matched[d] = false
+				}
+			}
+		}
+
+	case len(r.byDay) > 0:
+		for _, wd := range r.byDay {
+			var days []int
+			for d := 1; d <= last; d++ {
+				if at(year, month, d).Weekday() == wd.day {
+					days = append(days, d)
+				}
+			}
+			switch {
+			case wd.n == 0:
+				for _, d := range days {
+					matched[d] = true
+				}
+			case wd.n > 0 && wd.n <= len(days):
+				matched[days[wd.n-1]] = true
+			case wd.n < 0 && -wd.n <= len(days):
+				matched[days[len(days)+wd.n]] = true
+			}
+		}
+
+	case defaultDay <= last:
+		matched[defaultDay] = true
+	}
+
+	var result []time.Time
+	for d := 1; d <= last; d++ {
+		if matched[d] {
+			result = append(result, at(year, month, d))
+		}
+	}
+	return result
+}
+
+func (r *rrule) matchMonth(month time.Month) bool {
+	if len(r.byMonth) == 0 {
+		return true
+	}
+	for _, m := range r.byMonth {
+		if m == month {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *rrule) matchMonthDay(date time.Time) bool {
+	if len(r.byMonthDay) == 0 {
+		return true
+	}
+	last := time.Date(date.Year(), date.Month()+1, 0, 0, 0, 0, 0, date.Location()).Day()
+	for _, d := range r.byMonthDay {
+		if d == date.Day() || (d < 0 && last+d+1 == date.Day()) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *rrule) matchWeekday(day time.Weekday) bool {
+	if len(r.byDay) == 0 {
+		return true
+	}
+	for _, wd := range r.byDay {
+		if wd.day == day {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,320 @@
+// Package imageproc 處理用戶上傳的圖片
+//
+// 上傳的圖片一律解碼後重新編碼，因此 EXIF（含 GPS 位置）、ICC 等中繼資料及夾帶的其他內容都不會保存；
+// 解碼時依 EXIF 方向校正圖片，並生成縮圖及佔位資訊。
+package imageproc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"tennis-platform/backend/internal/apperror"
+
+	"github.com/disintegration/imaging"
+)
+
+// MaxPixels 允許的最大像素數，避免解壓縮炸彈耗盡記憶體
+const MaxPixels = 40_000_000
+
+// Spec 變體規格，Crop 為 true 時裁切填滿，否則等比縮放至不超過寬高
+type Spec struct {
+	Name   string
+	Width  int
+	Height int
+	Crop   bool
+}
+
+// DefaultVariants 默認生成的變體
+var DefaultVariants = []Spec{
+	{Name: "thumbnail", Width: 200, Height: 200, Crop: true},
+	{Name: "medium", Width: 800, Height: 800},
+	{Name: "large", Width: 1600, Height: 1600},
+}
+
+// 編碼品質
+const (
+	originalQuality = 90
+	variantQuality  = 82
+)
+
+// Variant 生成的圖片變體
+type Variant struct {
+	Name        string
+	Width       int
+	Height      int
+	ContentType string
+	Ext         string
+	Data        []byte
+}
+
+// Result 處理結果
+type Result struct {
+	Format        string // jpeg、png 或 gif
+	ContentType   string
+	Width         int
+	Height        int
+	Data          []byte // 校正方向並去除中繼資料後的原圖
+	Variants      []Variant
+	Blurhash      string
+	DominantColor string // #rrggbb
+}
+
+// formats 支援的格式及對應的擴展名
+var formats = map[string][]string{
+	"jpeg": {".jpg", ".jpeg"},
+	"png":  {".png"},
+	"gif":  {".gif"},
+}
+
+// IsImageExt 判斷擴展名是否為支援的圖片格式
+func IsImageExt(ext string) bool {
+	ext = strings.ToLower(ext)
+	for _, exts := range formats {
+		for _, allowed := range exts {
+			if ext == allowed {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// invalidImage 圖片內容無效或與擴展名不符
+func invalidImage(reason string) error {
+	return apperror.Wrap(apperror.CodeUploadInvalidImage, fmt.Errorf("%s", reason))
+}
+
+// Sniff 依文件內容判斷圖片格式，並確認與文件名的擴展名一致
+func Sniff(data []byte, filename string) (string, error) {
+	var format string
+	switch http.DetectContentType(data) {
+	case "image/jpeg":
+		format = "jpeg"
+	case "image/png":
+		format = "png"
+	case "image/gif":
+		format = "gif"
+	default:
+		return "", invalidImage("unrecognized image content")
+	}
+
+	ext := strings.ToLower(filepath.Ext(filename))
+	for _, allowed := range formats[format] {
+		if ext == allowed {
+			return format, nil
+		}
+	}
+	return "", invalidImage(fmt.Sprintf("content is %s but extension is %s", format, ext))
+}
+
+// Process 驗證並處理上傳的圖片
+//
+// 拒絕內容與擴展名不符、結構結束後仍夾帶其他內容（polyglot）及像素過多的圖片。
+func Process(data []byte, filename string) (*Result, error) {
+	format, err := Sniff(data, filename)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkTrailingData(format, data); err != nil {
+		return nil, err
+	}
+
+	config, decodedFormat, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || decodedFormat != format {
+		return nil, invalidImage("cannot decode image header")
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > MaxPixels {
+		return nil, invalidImage(fmt.Sprintf("image dimensions %dx%d not allowed", config.Width, config.Height))
+	}
+
+	img, err := imaging.Decode(bytes.NewReader(data), imaging.AutoOrientation(true))
+	if err != nil {
+		return nil, invalidImage("cannot decode image")
+	}
+
+	result := &Result{
+		Format:      format,
+		ContentType: "image/" + format,
+		Width:       img.Bounds().Dx(),
+		Height:      img.Bounds().Dy(),
+	}
+
+	if result.Data, err = encode(img, format, originalQuality); err != nil {
+		return nil, err
+	}
+
+	for _, spec := range DefaultVariants {
+		var resized *image.NRGBA
+		if spec.Crop {
+			resized = imaging.Fill(img, spec.Width, spec.Height, imaging.Center, imaging.Lanczos)
+		} else {
+			resized = imaging.Fit(img, spec.Width, spec.Height, imaging.Lanczos)
+		}
+
+		variantData, err := encode(flatten(resized), "jpeg", variantQuality)
+		if err != nil {
+			return nil, err
+		}
+		result.Variants = append(result.Variants, Variant{
+			Name:        spec.Name,
+			Width:       resized.Bounds().Dx(),
+			Height:      resized.Bounds().Dy(),
+			ContentType: "image/jpeg",
+			Ext:         ".jpg",
+			Data:        variantData,
+		})
+	}
+
+	// 以小圖計算佔位資訊
+	small := imaging.Fit(img, 32, 32, imaging.Box)
+	result.Blurhash = Blurhash(small, 4, 3)
+	result.DominantColor = averageColor(small)
+
+	return result, nil
+}
+
+// encode 以指定格式編碼，不寫入任何中繼資料
+func encode(img image.Image, format string, quality int) ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+	switch format {
+	case "jpeg":
+		err = imaging.Encode(&buf, img, imaging.JPEG, imaging.JPEGQuality(quality))
+	case "png":
+		err = imaging.Encode(&buf, img, imaging.PNG)
+	case "gif":
+		err = gif.Encode(&buf, img, nil)
+	default:
+		err = fmt.Errorf("unsupported format %s", format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("編碼圖片失敗: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// flatten 將透明區域合成到白色背景，JPEG 不支援透明度
+func flatten(img image.Image) image.Image {
+	bounds := img.Bounds()
+	background := imaging.New(bounds.Dx(), bounds.Dy(), color.White)
+	return imaging.Overlay(background, img, image.Pt(0, 0), 1.0)
+}
+
+// averageColor 計算平均顏色
+func averageColor(img image.Image) string {
+	bounds := img.Bounds()
+	var r, g, b, count uint64
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			r += uint64(c.R)
+			g += uint64(c.G)
+			b += uint64(c.B)
+			count++
+		}
+	}
+	if count == 0 {
+		return "#000000"
+	}
+	return fmt.Sprintf("#%02x%02x%02x", r/count, g/count, b/count)
+}
+
+// checkTrailingData 檢查圖片結構結束後是否夾帶其他內容
+func checkTrailingData(format string, data []byte) error {
+	var end int
+	var ok bool
+	switch format {
+	case "jpeg":
+		end, ok = jpegEnd(data)
+	case "png":
+		end, ok = pngEnd(data)
+	default:
+		// GIF 重新編碼後只保留第一幀的像素，夾帶的內容不會保存
+		return nil
+	}
+
+	if !ok {
+		return invalidImage("malformed image structure")
+	}
+	if len(bytes.TrimRight(data[end:], "\x00\r\n\t ")) > 0 {
+		return invalidImage("unexpected data after end of image")
+	}
+	return nil
+}
+
+// jpegEnd 逐段解析 JPEG，返回 EOI 標記之後的位置
+func jpegEnd(data []byte) (int, bool) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 0, false
+	}
+
+	i := 2
+	for i+4 <= len(data) {
+		if data[i] != 0xFF {
+			return 0, false
+		}
+		marker := data[i+1]
+		switch {
+		case marker == 0xFF:
+			// 填充字節
+			i++
+			continue
+		case marker == 0xD9:
+			return i + 2, true
+		case marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7):
+			i += 2
+			continue
+		}
+
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		if length < 2 || i+2+length > len(data) {
+			return 0, false
+		}
+		i += 2 + length
+
+		// SOS 之後是壓縮數據，直到下一個非 RST 標記
+		if marker == 0xDA {
+			for i+1 < len(data) {
+				if data[i] == 0xFF && data[i+1] != 0x00 && !(data[i+1] >= 0xD0 && data[i+1] <= 0xD7) {
+					break
+				}
+				i++
+			}
+		}
+	}
+
+	if i+2 <= len(data) && data[i] == 0xFF && data[i+1] == 0xD9 {
+		return i + 2, true
+	}
+	return 0, false
+}
+
+// pngEnd 逐塊解析 PNG，返回 IEND 塊之後的位置
+func pngEnd(data []byte) (int, bool) {
+	signature := []byte("\x89PNG\r\n\x1a\n")
+	if !bytes.HasPrefix(data, signature) {
+		return 0, false
+	}
+
+	i := len(signature)
+	for i+12 <= len(data) {
+		length := int(binary.BigEndian.Uint32(data[i:]))
+		chunkType := string(data[i+4 : i+8])
+		if length < 0 || i+12+length > len(data) {
+			return 0, false
+		}
+		i += 12 + length
+		if chunkType == "IEND" {
+			return i, true
+		}
+	}
+	return 0, false
+}
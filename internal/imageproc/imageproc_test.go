@@ -0,0 +1,181 @@
+package imageproc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"tennis-platform/backend/internal/apperror"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testImage(width, height int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x * 4), G: uint8(y * 4), B: 100, A: 255})
+		}
+	}
+	return img
+}
+
+func encodeJPEG(img image.Image) []byte {
+	var buf bytes.Buffer
+	jpeg.Encode(&buf, img, nil)
+	return buf.Bytes()
+}
+
+func encodePNG(img image.Image) []byte {
+	var buf bytes.Buffer
+	png.Encode(&buf, img)
+	return buf.Bytes()
+}
+
+// withExif 在 SOI 之後插入帶有方向及描述的 EXIF 區段
+func withExif(data []byte, orientation uint16, description string) []byte {
+	desc := append([]byte(description), 0)
+
+	tiff := &bytes.Buffer{}
+	tiff.WriteString("MM\x00\x2a")
+	binary.Write(tiff, binary.BigEndian, uint32(8))
+	binary.Write(tiff, binary.BigEndian, uint16(2))
+	// ImageDescription，內容在 IFD 之後
+	binary.Write(tiff, binary.BigEndian, []uint16{0x010e, 2})
+	binary.Write(tiff, binary.BigEndian, []uint32{uint32(len(desc)), 8 + 2 + 24 + 4})
+	// Orientation
+	binary.Write(tiff, binary.BigEndian, []uint16{0x0112, 3})
+	binary.Write(tiff, binary.BigEndian, uint32(1))
+	binary.Write(tiff, binary.BigEndian, []uint16{orientation, 0})
+	binary.Write(tiff, binary.BigEndian, uint32(0))
+	tiff.Write(desc)
+
+	payload := append([]byte("Exif\x00\x00"), tiff.Bytes()...)
+	segment := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(payload)+2))
+
+	result := append([]byte{}, data[:2]...)
+	result = append(result, segment...)
+	result = append(result, payload...)
+	return append(result, data[2:]...)
+}
+
+func TestProcess_StripsExifAndNormalizesOrientation(t *testing.T) {
+	location := "GPS 25.0330N 121.5654E"
+	data := withExif(encodeJPEG(testImage(64, 48)), 6, location)
+	require.Contains(t, string(data), location)
+
+	result, err := Process(data, "photo.JPG")
+	require.NoError(t, err)
+
+	// 方向 6 需順時針旋轉 90 度
+	assert.Equal(t, 48, result.Width)
+	assert.Equal(t, 64, result.Height)
+	assert.Equal(t, "image/jpeg", result.ContentType)
+
+	for _, output := range append([][]byte{result.Data}, result.Variants[0].Data, result.Variants[1].Data) {
+		assert.NotContains(t, string(output), location)
+		assert.NotContains(t, string(output), "Exif")
+	}
+
+	decoded, _, err := image.DecodeConfig(bytes.NewReader(result.Data))
+	require.NoError(t, err)
+	assert.Equal(t, 48, decoded.Width)
+
+	names := []string{}
+	for _, variant := range result.Variants {
+		names = append(names, variant.Name)
+		assert.Equal(t, ".jpg", variant.Ext)
+	}
+	assert.Equal(t, []string{"thumbnail", "medium", "large"}, names)
+	assert.Equal(t, 200, result.Variants[0].Width)
+	assert.Equal(t, 200, result.Variants[0].Height)
+}
+
+func TestProcess_RejectsInvalidContent(t *testing.T) {
+	jpegData := encodeJPEG(testImage(16, 16))
+	pngData := encodePNG(testImage(16, 16))
+
+	tests := []struct {
+		name     string
+		data     []byte
+		filename string
+	}{
+		{"非圖片", []byte("<html><script>alert(1)</script></html>"), "x.jpg"},
+		{"擴展名不符", pngData, "x.jpg"},
+		{"JPEG 後夾帶 HTML", append(append([]byte{}, jpegData...), []byte("<script>alert(1)</script>")...), "x.jpg"},
+		{"PNG 後夾帶 ZIP", append(append([]byte{}, pngData...), []byte("PK\x03\x04polyglot")...), "x.png"},
+		{"截斷的 JPEG", jpegData[:len(jpegData)/2], "x.jpg"},
+		{"像素過多", hugePNG(20000, 20000), "x.png"},
+	}
+
+	for _, test := range tests {
+		_, err := Process(test.data, test.filename)
+		assert.True(t, apperror.HasCode(err, apperror.CodeUploadInvalidImage), test.name)
+	}
+
+	// 結尾的換行等空白不視為夾帶內容
+	_, err := Process(append(append([]byte{}, pngData...), '\n'), "x.png")
+	assert.NoError(t, err)
+}
+
+// hugePNG 只有檔頭的 PNG，宣稱的尺寸超過限制
+func hugePNG(width, height uint32) []byte {
+	chunk := func(chunkType string, data []byte) []byte {
+		buf := &bytes.Buffer{}
+		binary.Write(buf, binary.BigEndian, uint32(len(data)))
+		buf.WriteString(chunkType)
+		buf.Write(data)
+		binary.Write(buf, binary.BigEndian, crc32.ChecksumIEEE(append([]byte(chunkType), data...)))
+		return buf.Bytes()
+	}
+
+	ihdr := make([]byte, 13)
+	binary.BigEndian.PutUint32(ihdr[0:], width)
+	binary.BigEndian.PutUint32(ihdr[4:], height)
+	ihdr[8], ihdr[9] = 8, 2
+
+	data := []byte("\x89PNG\r\n\x1a\n")
+	data = append(data, chunk("IHDR", ihdr)...)
+	return append(data, chunk("IEND", nil)...)
+}
+
+func TestProcess_TransparentPNG(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 10, 10))
+	result, err := Process(encodePNG(img), "logo.png")
+	require.NoError(t, err)
+	assert.Equal(t, "image/png", result.ContentType)
+
+	// 變體為 JPEG，透明區域合成白色背景
+	thumbnail, err := jpeg.Decode(bytes.NewReader(result.Variants[0].Data))
+	require.NoError(t, err)
+	r, g, b, _ := thumbnail.At(100, 100).RGBA()
+	assert.Greater(t, r>>8, uint32(240))
+	assert.Greater(t, g>>8, uint32(240))
+	assert.Greater(t, b>>8, uint32(240))
+}
+
+func TestBlurhash(t *testing.T) {
+	solid := image.NewRGBA(image.Rect(0, 0, 32, 32))
+	for i := range solid.Pix {
+		solid.Pix[i] = []uint8{0x33, 0x66, 0x99, 0xff}[i%4]
+	}
+
+	hash := Blurhash(solid, 4, 3)
+	assert.Len(t, hash, 28)
+	assert.Equal(t, "L", hash[:1], "4x3 分量的大小標記")
+
+	// DC 分量還原為原本的顏色
+	dc := 0
+	for _, c := range hash[2:6] {
+		dc = dc*83 + strings.IndexRune(base83Chars, c)
+	}
+	assert.Equal(t, 0x336699, dc)
+	assert.Equal(t, "#336699", averageColor(solid))
+}
@@ -1,8 +1,8 @@
 package middleware
 
 import (
-	"net/http"
 	"strings"
+	"tennis-platform/backend/internal/apperror"
 	"tennis-platform/backend/internal/services"
 
 	"github.com/gin-gonic/gin"
@@ -14,30 +14,21 @@ func AuthMiddleware(jwtService *services.JWTService) gin.HandlerFunc {
 		// 從 Authorization header 獲取令牌
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": "缺少認證令牌",
-			})
-			c.Abort()
+			apperror.Abort(c, apperror.New(apperror.CodeMissingToken))
 			return
 		}
 
 		// 檢查 Bearer 前綴
 		tokenParts := strings.Split(authHeader, " ")
 		if len(tokenParts) != 2 || tokenParts[0] != "Bearer" {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": "無效的認證令牌格式",
-			})
-			c.Abort()
+			apperror.Abort(c, apperror.New(apperror.CodeInvalidTokenFormat))
 			return
 		}
 
 		// 驗證令牌
 		claims, err := jwtService.ValidateToken(tokenParts[1])
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": "無效的認證令牌",
-			})
-			c.Abort()
+			apperror.Abort(c, apperror.New(apperror.CodeInvalidToken))
 			return
 		}
 
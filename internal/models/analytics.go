@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// CourtSlotDemand 場地每小時時段未能滿足的需求，用於場地擁有者的需求熱度分析
+//
+// SlotStart 為被查詢或預訂的時段所在整點（UTC），同一時段的計數累加在同一列。
+type CourtSlotDemand struct {
+	CourtID          string    `json:"courtId" gorm:"type:uuid;primaryKey"`
+	SlotStart        time.Time `json:"slotStart" gorm:"primaryKey"`
+	UnavailableViews int64     `json:"unavailableViews" gorm:"not null;default:0"` // 查詢可用時段時該時段已無空位的次數
+	RejectedBookings int64     `json:"rejectedBookings" gorm:"not null;default:0"` // 預訂或保留時段因已被預訂而失敗的次數
+	UpdatedAt        time.Time `json:"updatedAt"`
+
+	// 關聯
+	Court *Court `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+}
+
+// TableName 指定表名
+func (CourtSlotDemand) TableName() string {
+	return "court_slot_demands"
+}
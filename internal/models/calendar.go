@@ -0,0 +1,65 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CalendarFeed 用戶的 iCalendar 訂閱令牌，持有令牌即可讀取行程，不需要登入
+type CalendarFeed struct {
+	UserID    string    `json:"userId" gorm:"type:uuid;primaryKey"`
+	Token     string    `json:"-" gorm:"not null;uniqueIndex"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	// 關聯
+	User *User `json:"user,omitempty" gorm:"constraint:OnDelete:CASCADE"`
+}
+
+// CoachCalendarSource 教練連結的外部行事曆，來源為 ICS 訂閱網址或上傳的 .ics 文件
+type CoachCalendarSource struct {
+	ID           string     `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	CoachID      string     `json:"coachId" gorm:"type:uuid;not null;index"`
+	Name         string     `json:"name" gorm:"not null"`
+	URL          *string    `json:"url"`                // 訂閱網址，上傳文件時為空
+	Content      *string    `json:"-" gorm:"type:text"` // 上傳的 .ics 內容，定期重新展開重複事件
+	BlockCount   int        `json:"blockCount"`         // 最近一次同步得到的忙碌時段數
+	LastSyncedAt *time.Time `json:"lastSyncedAt"`       // 最近一次成功同步的時間
+	LastError    *string    `json:"lastError" gorm:"type:text"`
+	NextSyncAt   time.Time  `json:"nextSyncAt" gorm:"not null;index"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	UpdatedAt    time.Time  `json:"updatedAt"`
+
+	// 關聯
+	Coach *Coach `json:"coach,omitempty" gorm:"constraint:OnDelete:CASCADE"`
+}
+
+// CoachBusyBlock 外部行事曆展開後的忙碌時段，同步時整批替換
+type CoachBusyBlock struct {
+	ID        string    `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	SourceID  string    `json:"sourceId" gorm:"type:uuid;not null;index"`
+	CoachID   string    `json:"coachId" gorm:"type:uuid;not null;index:idx_coach_busy_blocks_coach_start"`
+	StartTime time.Time `json:"startTime" gorm:"not null;index:idx_coach_busy_blocks_coach_start"`
+	EndTime   time.Time `json:"endTime" gorm:"not null"`
+
+	// 關聯
+	Source *CoachCalendarSource `json:"source,omitempty" gorm:"constraint:OnDelete:CASCADE"`
+}
+
+// BeforeCreate 創建前的鉤子
+func (s *CoachCalendarSource) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == "" {
+		s.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// BeforeCreate 創建前的鉤子
+func (b *CoachBusyBlock) BeforeCreate(tx *gorm.DB) error {
+	if b.ID == "" {
+		b.ID = uuid.New().String()
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CancellationTier 取消政策的退款級距：開始前至少 MinHoursBefore 小時取消可退還 RefundPercent%
+type CancellationTier struct {
+	MinHoursBefore float64 `json:"minHoursBefore"`
+	RefundPercent  int     `json:"refundPercent"`
+}
+
+// CancellationTiers 退款級距列表
+type CancellationTiers []CancellationTier
+
+// Value 實現 driver.Valuer 接口
+func (ct CancellationTiers) Value() (driver.Value, error) {
+	if ct == nil {
+		return "[]", nil
+	}
+	data, err := json.Marshal(ct)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan 實現 sql.Scanner 接口
+func (ct *CancellationTiers) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*ct = CancellationTiers{}
+		return nil
+	case []byte:
+		return json.Unmarshal(v, ct)
+	case string:
+		return json.Unmarshal([]byte(v), ct)
+	default:
+		return errors.New("type assertion to []byte failed")
+	}
+}
+
+// CancellationPolicy 場地或教練的取消政策，未設置時使用默認政策
+type CancellationPolicy struct {
+	ID          string            `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	OwnerType   string            `json:"ownerType" gorm:"not null;uniqueIndex:idx_cancellation_policies_owner"` // court, coach
+	OwnerID     string            `json:"ownerId" gorm:"type:uuid;not null;uniqueIndex:idx_cancellation_policies_owner"`
+	Tiers       CancellationTiers `json:"tiers" gorm:"type:jsonb;not null"`
+	CutoffHours float64           `json:"cutoffHours" gorm:"not null;default:0"` // 開始前多少小時內不可取消，0 表示可取消至開始
+	CreatedAt   time.Time         `json:"createdAt"`
+	UpdatedAt   time.Time         `json:"updatedAt"`
+}
+
+// CancellationOverride 天候或不可抗力的取消例外，時段內開始的預訂及課程不受取消期限限制並依指定比例退款
+type CancellationOverride struct {
+	ID            string    `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	OwnerType     string    `json:"ownerType" gorm:"not null;index:idx_cancellation_overrides_owner"` // court, coach
+	OwnerID       string    `json:"ownerId" gorm:"type:uuid;not null;index:idx_cancellation_overrides_owner"`
+	StartTime     time.Time `json:"startTime" gorm:"not null"`
+	EndTime       time.Time `json:"endTime" gorm:"not null"`
+	Reason        string    `json:"reason" gorm:"not null"` // weather, force_majeure
+	RefundPercent int       `json:"refundPercent" gorm:"not null;default:100"`
+	Note          *string   `json:"note" gorm:"type:text"`
+	CreatedBy     string    `json:"createdBy" gorm:"type:uuid;not null"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+// Cancellation 取消記錄，保存取消當下套用的政策、退款金額及退款結果
+type Cancellation struct {
+	ID             string    `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	TargetType     string    `json:"targetType" gorm:"not null;index:idx_cancellations_target"` // booking, lesson
+	TargetID       string    `json:"targetId" gorm:"type:uuid;not null;index:idx_cancellations_target"`
+	PaymentID      *string   `json:"paymentId" gorm:"type:uuid;index"`
+	CancelledBy    string    `json:"cancelledBy" gorm:"type:uuid;not null"`
+	Initiator      string    `json:"initiator" gorm:"not null"` // customer, provider
+	Reason         *string   `json:"reason" gorm:"type:text"`
+	PolicyID       *string   `json:"policyId" gorm:"type:uuid"` // 為空表示使用默認政策
+	OverrideID     *string   `json:"overrideId" gorm:"type:uuid"`
+	HoursBefore    float64   `json:"hoursBefore"`
+	RefundPercent  int       `json:"refundPercent"`
+	PaidAmount     float64   `json:"paidAmount" gorm:"type:numeric;not null;default:0"`
+	RefundAmount   float64   `json:"refundAmount" gorm:"type:numeric;not null;default:0"`
+	FeeAmount      float64   `json:"feeAmount" gorm:"type:numeric;not null;default:0"`
+	Currency       string    `json:"currency" gorm:"not null;default:'TWD'"`
+	RefundStatus   string    `json:"refundStatus" gorm:"not null;default:'none';index"` // none, pending, succeeded, failed
+	RefundError    *string   `json:"refundError" gorm:"type:text"`
+	RefundAttempts int       `json:"refundAttempts" gorm:"not null;default:0"`
+	CreatedAt      time.Time `json:"createdAt"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+}
+
+// BeforeCreate 創建前的鉤子
+func (p *CancellationPolicy) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == "" {
+		p.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// BeforeCreate 創建前的鉤子
+func (o *CancellationOverride) BeforeCreate(tx *gorm.DB) error {
+	if o.ID == "" {
+		o.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// BeforeCreate 創建前的鉤子
+func (c *Cancellation) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == "" {
+		c.ID = uuid.New().String()
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// 場地例外類型
+const (
+	CourtClosureClosed = "closed" // 整天休館
+	CourtClosureHours  = "hours"  // 更改當天的營業時間
+	CourtClosureBlock  = "block"  // 封鎖當天的部分時段
+)
+
+// 場地例外原因
+const (
+	CourtClosureReasonMaintenance = "maintenance" // 維護、重新鋪面
+	CourtClosureReasonHoliday     = "holiday"     // 國定假日
+	CourtClosureReasonTournament  = "tournament"  // 比賽、活動包場
+	CourtClosureReasonWeather     = "weather"     // 天候
+	CourtClosureReasonOther       = "other"
+)
+
+// CourtClosure 場地在指定日期的例外，優先於每週的營業時間
+//
+// closed 整天休館；hours 以 startTime、endTime 取代當天的營業時間；block 封鎖每天 startTime 至 endTime 的時段。
+// 指定球場時只有該球場休館或封鎖。日期及時間以預訂時間所在時區的當地時間判斷，與營業時間一致。
+type CourtClosure struct {
+	ID          string    `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	CourtID     string    `json:"courtId" gorm:"type:uuid;not null;index"`
+	CourtUnitID *string   `json:"courtUnitId" gorm:"type:uuid"`      // 只影響指定的球場，為空表示整個場地
+	Kind        string    `json:"kind" gorm:"not null"`              // closed, hours, block
+	StartDate   string    `json:"startDate" gorm:"size:10;not null"` // YYYY-MM-DD
+	EndDate     string    `json:"endDate" gorm:"size:10;not null"`   // YYYY-MM-DD，包含當天
+	StartTime   *string   `json:"startTime"`                         // HH:MM，hours 為開館時間，block 為封鎖開始時間
+	EndTime     *string   `json:"endTime"`                           // HH:MM，可為 24:00
+	Reason      string    `json:"reason" gorm:"not null"`            // maintenance, holiday, tournament, weather, other
+	Note        *string   `json:"note" gorm:"type:text"`
+	CreatedBy   string    `json:"createdBy" gorm:"type:uuid;not null"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+
+	// 關聯
+	Court     *Court     `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	CourtUnit *CourtUnit `json:"courtUnit,omitempty" gorm:"constraint:OnDelete:CASCADE"`
+}
+
+// BeforeCreate 創建前的鉤子
+func (c *CourtClosure) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == "" {
+		c.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// TableName 指定表名
+func (CourtClosure) TableName() string {
+	return "court_closures"
+}
@@ -21,7 +21,7 @@ type Club struct {
 	Website        *string            `json:"website"`
 	Images         pq.StringArray     `json:"images" gorm:"type:text[]" swaggertype:"array,string"`
 	Facilities     pq.StringArray     `json:"facilities" gorm:"type:text[]" swaggertype:"array,string"`
-	MembershipFees map[string]float64 `json:"membershipFees" gorm:"type:jsonb"` // {"monthly": 2000, "yearly": 20000}
+	MembershipFees map[string]float64 `json:"membershipFees" gorm:"type:jsonb;serializer:json"` // {"monthly": 2000, "yearly": 20000}
 	Currency       string             `json:"currency" gorm:"default:'TWD'"`
 	MaxMembers     *int               `json:"maxMembers"`
 	CurrentMembers int                `json:"currentMembers" gorm:"default:0"`
@@ -91,6 +91,7 @@ type ClubEventParticipant struct {
 	UserID       string         `json:"userId" gorm:"type:uuid;not null"`
 	Status       string         `json:"status" gorm:"default:'registered'"` // registered, attended, no_show, cancelled
 	PaymentID    *string        `json:"paymentId"`
+	PaymentDueAt *time.Time     `json:"paymentDueAt"` // 付款期限，逾期未付款自動取消
 	RegisteredAt time.Time      `json:"registeredAt" gorm:"default:CURRENT_TIMESTAMP"`
 	CreatedAt    time.Time      `json:"createdAt"`
 	DeletedAt    gorm.DeletedAt `json:"-" gorm:"index"`
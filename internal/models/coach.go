@@ -80,24 +80,28 @@ type CoachReview struct {
 
 // Lesson 課程
 type Lesson struct {
-	ID           string         `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
-	CoachID      string         `json:"coachId" gorm:"type:uuid;not null"`
-	StudentID    string         `json:"studentId" gorm:"type:uuid;not null"`
-	LessonTypeID *string        `json:"lessonTypeId" gorm:"type:uuid"`
-	CourtID      *string        `json:"courtId" gorm:"type:uuid"`
-	Type         string         `json:"type" gorm:"not null"`     // individual, group, clinic
-	Level        string         `json:"level"`                    // beginner, intermediate, advanced
-	Duration     int            `json:"duration" gorm:"not null"` // 分鐘
-	Price        float64        `json:"price" gorm:"not null"`
-	Currency     string         `json:"currency" gorm:"default:'TWD'"`
-	ScheduledAt  time.Time      `json:"scheduledAt" gorm:"not null"`
-	Status       string         `json:"status" gorm:"default:'scheduled'"` // scheduled, in_progress, completed, cancelled
-	Notes        *string        `json:"notes" gorm:"type:text"`
-	PaymentID    *string        `json:"paymentId"`
-	CancelReason *string        `json:"cancelReason" gorm:"type:text"`
-	CreatedAt    time.Time      `json:"createdAt"`
-	UpdatedAt    time.Time      `json:"updatedAt"`
-	DeletedAt    gorm.DeletedAt `json:"-" gorm:"index"`
+	ID             string         `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	CoachID        string         `json:"coachId" gorm:"type:uuid;not null"`
+	StudentID      string         `json:"studentId" gorm:"type:uuid;not null"`
+	LessonTypeID   *string        `json:"lessonTypeId" gorm:"type:uuid"`
+	CourtID        *string        `json:"courtId" gorm:"type:uuid"`
+	Type           string         `json:"type" gorm:"not null"`     // individual, group, clinic
+	Level          string         `json:"level"`                    // beginner, intermediate, advanced
+	Duration       int            `json:"duration" gorm:"not null"` // 分鐘
+	Price          float64        `json:"price" gorm:"not null"`    // 應付金額，已扣除優惠碼折扣
+	DiscountAmount float64        `json:"discountAmount" gorm:"type:numeric;not null;default:0"`
+	PromoCodeID    *string        `json:"promoCodeId" gorm:"type:uuid"`
+	Currency       string         `json:"currency" gorm:"default:'TWD'"`
+	ScheduledAt    time.Time      `json:"scheduledAt" gorm:"not null"`
+	Status         string         `json:"status" gorm:"default:'scheduled'"` // scheduled, in_progress, completed, cancelled
+	Notes          *string        `json:"notes" gorm:"type:text"`
+	PaymentID      *string        `json:"paymentId"`
+	PaymentDueAt   *time.Time     `json:"paymentDueAt"` // 付款期限，逾期未付款自動取消
+	CancelReason   *string        `json:"cancelReason" gorm:"type:text"`
+	Version        int64          `json:"version" gorm:"not null;default:1"` // 樂觀鎖版本號
+	CreatedAt      time.Time      `json:"createdAt"`
+	UpdatedAt      time.Time      `json:"updatedAt"`
+	DeletedAt      gorm.DeletedAt `json:"-" gorm:"index"`
 
 	// 關聯
 	Coach      *Coach       `json:"coach,omitempty" gorm:"constraint:OnDelete:CASCADE"`
@@ -40,25 +40,27 @@ func (oh *OperatingHours) Scan(value interface{}) error {
 
 // Court 網球場地
 type Court struct {
-	ID             string         `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
-	Name           string         `json:"name" gorm:"type:text;not null"`
-	Description    *string        `json:"description" gorm:"type:text"`
-	Address        string         `json:"address" gorm:"type:text;not null"`
-	Latitude       float64        `json:"latitude" gorm:"type:numeric;not null"`
-	Longitude      float64        `json:"longitude" gorm:"type:numeric;not null"`
-	Facilities     pq.StringArray `json:"facilities" gorm:"type:text[]" swaggertype:"array,string"`
-	CourtType      string         `json:"courtType" gorm:"type:text"`
-	PricePerHour   float64        `json:"pricePerHour" gorm:"type:numeric;not null"`
-	Currency       string         `json:"currency" gorm:"type:text;default:'TWD'"`
-	Images         pq.StringArray `json:"images" gorm:"type:text[]" swaggertype:"array,string"`
-	OperatingHours datatypes.JSON `json:"operatingHours" gorm:"type:jsonb" swaggertype:"object"`
-	ContactPhone   *string        `json:"contactPhone" gorm:"type:text"`
-	ContactEmail   *string        `json:"contactEmail" gorm:"type:text"`
-	Website        *string        `json:"website" gorm:"type:text"`
-	AverageRating  float64        `json:"averageRating" gorm:"type:numeric;default:0"`
-	TotalReviews   int64          `json:"totalReviews" gorm:"type:bigint;default:0"`
-	IsActive       bool           `json:"isActive" gorm:"default:true"`
-	OwnerID        *string        `json:"ownerId" gorm:"type:uuid"`
+	ID              string         `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	Name            string         `json:"name" gorm:"type:text;not null"`
+	Description     *string        `json:"description" gorm:"type:text"`
+	Address         string         `json:"address" gorm:"type:text;not null"`
+	Latitude        float64        `json:"latitude" gorm:"type:numeric;not null"`
+	Longitude       float64        `json:"longitude" gorm:"type:numeric;not null"`
+	Facilities      pq.StringArray `json:"facilities" gorm:"type:text[]" swaggertype:"array,string"`
+	CourtType       string         `json:"courtType" gorm:"type:text"`
+	PricePerHour    float64        `json:"pricePerHour" gorm:"type:numeric;not null"`
+	Currency        string         `json:"currency" gorm:"type:text;default:'TWD'"`
+	Images          pq.StringArray `json:"images" gorm:"type:text[]" swaggertype:"array,string"`
+	OperatingHours  datatypes.JSON `json:"operatingHours" gorm:"type:jsonb" swaggertype:"object"`
+	ContactPhone    *string        `json:"contactPhone" gorm:"type:text"`
+	ContactEmail    *string        `json:"contactEmail" gorm:"type:text"`
+	Website         *string        `json:"website" gorm:"type:text"`
+	AverageRating   float64        `json:"averageRating" gorm:"type:numeric;default:0"`
+	TotalReviews    int64          `json:"totalReviews" gorm:"type:bigint;default:0"`
+	IsActive        bool           `json:"isActive" gorm:"default:true"`
+	OwnerID         *string        `json:"ownerId" gorm:"type:uuid"`
+	CheckInRequired bool           `json:"checkInRequired" gorm:"not null;default:false"` // 需要報到，結束後未報到的預訂標記為未到場
+	Version         int64          `json:"version" gorm:"not null;default:1"`             // 樂觀鎖版本號
 
 	CreatedAt time.Time      `json:"createdAt" gorm:"type:timestamptz"`
 	UpdatedAt time.Time      `json:"updatedAt" gorm:"type:timestamptz"`
@@ -69,6 +71,21 @@ type Court struct {
 	Bookings []Booking     `json:"bookings,omitempty" gorm:"foreignKey:CourtID;constraint:OnDelete:CASCADE"`
 }
 
+// CourtUnit 場地內可單獨預訂的球場
+type CourtUnit struct {
+	ID        string         `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	CourtID   string         `json:"courtId" gorm:"type:uuid;not null;index"`
+	Number    int            `json:"number" gorm:"not null"`            // 球場編號，同一場地內不可重複
+	Name      *string        `json:"name" gorm:"type:text"`             // 顯示名稱，如「中央球場」
+	Surface   string         `json:"surface" gorm:"type:text;not null"` // hard, clay, grass, carpet
+	IsIndoor  bool           `json:"isIndoor" gorm:"not null"`
+	HasLights bool           `json:"hasLights" gorm:"not null"`
+	IsActive  bool           `json:"isActive" gorm:"not null"` // 停用的球場不開放預訂
+	CreatedAt time.Time      `json:"createdAt"`
+	UpdatedAt time.Time      `json:"updatedAt"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
 // CourtReview 場地評價
 type CourtReview struct {
 	ID          string         `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
@@ -112,22 +129,120 @@ type ReviewReport struct {
 
 // Booking 場地預訂
 type Booking struct {
-	ID         string         `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
-	CourtID    string         `json:"courtId" gorm:"type:uuid;not null"`
-	UserID     string         `json:"userId" gorm:"type:uuid;not null"`
-	StartTime  time.Time      `json:"startTime" gorm:"not null"`
-	EndTime    time.Time      `json:"endTime" gorm:"not null"`
-	TotalPrice float64        `json:"totalPrice" gorm:"not null"`
-	Status     string         `json:"status" gorm:"default:'pending'"` // pending, confirmed, cancelled, completed
-	PaymentID  *string        `json:"paymentId"`
-	Notes      *string        `json:"notes" gorm:"type:text"`
-	CreatedAt  time.Time      `json:"createdAt"`
-	UpdatedAt  time.Time      `json:"updatedAt"`
-	DeletedAt  gorm.DeletedAt `json:"-" gorm:"index"`
+	ID             string          `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	CourtID        string          `json:"courtId" gorm:"type:uuid;not null"`
+	CourtUnitID    *string         `json:"courtUnitId" gorm:"type:uuid;index"` // 預訂的球場，為空表示包下整個場地
+	SeriesID       *string         `json:"seriesId" gorm:"type:uuid;index"`    // 所屬的重複預訂
+	UserID         string          `json:"userId" gorm:"type:uuid;not null"`
+	StartTime      time.Time       `json:"startTime" gorm:"not null"`
+	EndTime        time.Time       `json:"endTime" gorm:"not null"`
+	TotalPrice     float64         `json:"totalPrice" gorm:"not null"`
+	PriceBreakdown *PriceBreakdown `json:"priceBreakdown,omitempty" gorm:"type:jsonb"` // 按價格規則分段計價的明細
+	Status         string          `json:"status" gorm:"default:'pending'"`            // pending, confirmed, cancelled, completed, no_show
+	PaymentID      *string         `json:"paymentId"`
+	PaymentDueAt   *time.Time      `json:"paymentDueAt"`                 // 付款期限，逾期未付款自動取消
+	CheckedInAt    *time.Time      `json:"checkedInAt"`                  // 在場地掃描報到碼的時間
+	CheckedInBy    *string         `json:"checkedInBy" gorm:"type:uuid"` // 掃描報到碼的場地人員
+	Notes          *string         `json:"notes" gorm:"type:text"`
+	Version        int64           `json:"version" gorm:"not null;default:1"` // 樂觀鎖版本號
+	CreatedAt      time.Time       `json:"createdAt"`
+	UpdatedAt      time.Time       `json:"updatedAt"`
+	DeletedAt      gorm.DeletedAt  `json:"-" gorm:"index"`
+
+	// 關聯
+	Court     *Court         `json:"court,omitempty" gorm:"constraint:OnDelete:CASCADE"`
+	CourtUnit *CourtUnit     `json:"courtUnit,omitempty"`
+	User      *User          `json:"user,omitempty" gorm:"constraint:OnDelete:CASCADE"`
+	AddOns    []BookingAddOn `json:"addOns,omitempty" gorm:"foreignKey:BookingID;constraint:OnDelete:CASCADE"` // 租借的器材
+}
+
+// 重複預訂的計費方式
+const (
+	BookingSeriesBillingPerOccurrence = "per_occurrence" // 每次分別付款
+	BookingSeriesBillingAggregate     = "aggregate"      // 整組一次付款
+)
+
+// BookingSeries 重複預訂，依重複規則展開為多筆預訂
+//
+// 各次預訂以 SeriesID 關聯，可單獨修改或取消；整組付款時付款期限記錄在重複預訂上。
+type BookingSeries struct {
+	ID           string         `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	CourtID      string         `json:"courtId" gorm:"type:uuid;not null;index"`
+	CourtUnitID  *string        `json:"courtUnitId" gorm:"type:uuid"` // 指定的球場，為空時每次分別分配
+	UserID       string         `json:"userId" gorm:"type:uuid;not null;index"`
+	RRule        string         `json:"rrule" gorm:"column:rrule;not null"`                   // 重複規則，例如 FREQ=WEEKLY;BYDAY=TU
+	StartTime    time.Time      `json:"startTime" gorm:"not null"`                            // 第一次的開始時間
+	EndTime      time.Time      `json:"endTime" gorm:"not null"`                              // 第一次的結束時間
+	Until        *time.Time     `json:"until"`                                                // 最後一次開始時間的上限
+	Count        *int           `json:"count"`                                                // 重複次數
+	BillingMode  string         `json:"billingMode" gorm:"not null;default:'per_occurrence'"` // per_occurrence, aggregate
+	Status       string         `json:"status" gorm:"not null;default:'active'"`              // active, cancelled
+	PaymentID    *string        `json:"paymentId"`                                            // 整組付款的付款
+	PaymentDueAt *time.Time     `json:"paymentDueAt"`                                         // 整組付款的期限，逾期未付款自動取消
+	Notes        *string        `json:"notes" gorm:"type:text"`
+	Version      int64          `json:"version" gorm:"not null;default:1"` // 樂觀鎖版本號
+	CreatedAt    time.Time      `json:"createdAt"`
+	UpdatedAt    time.Time      `json:"updatedAt"`
+	DeletedAt    gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// 關聯
+	Court    *Court    `json:"court,omitempty" gorm:"constraint:OnDelete:CASCADE"`
+	User     *User     `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	Bookings []Booking `json:"bookings,omitempty" gorm:"foreignKey:SeriesID"`
+}
+
+// 候補狀態
+const (
+	WaitlistStatusWaiting   = "waiting"   // 等待時段釋出
+	WaitlistStatusOffered   = "offered"   // 已為用戶保留釋出的時段，等待認領
+	WaitlistStatusClaimed   = "claimed"   // 已認領並創建預訂
+	WaitlistStatusExpired   = "expired"   // 逾期未認領
+	WaitlistStatusCancelled = "cancelled" // 用戶退出候補
+)
+
+// BookingWaitlistEntry 已滿時段的候補
+//
+// 時段因取消而釋出時依加入順序以保留提供給候補者，逾期未認領則改為提供給下一位。
+type BookingWaitlistEntry struct {
+	ID             string     `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	CourtID        string     `json:"courtId" gorm:"type:uuid;not null;index"`
+	CourtUnitID    *string    `json:"courtUnitId" gorm:"type:uuid"` // 指定的球場，為空時任一球場皆可
+	UserID         string     `json:"userId" gorm:"type:uuid;not null;index"`
+	StartTime      time.Time  `json:"startTime" gorm:"not null"`                      // 可接受時間範圍的開始
+	EndTime        time.Time  `json:"endTime" gorm:"not null"`                        // 可接受時間範圍的結束
+	Duration       int        `json:"duration" gorm:"not null"`                       // 預訂時長（分鐘）
+	Status         string     `json:"status" gorm:"not null;default:'waiting';index"` // waiting, offered, claimed, expired, cancelled
+	HoldID         *string    `json:"holdId" gorm:"type:uuid"`                        // 提供時段的保留
+	OfferStartTime *time.Time `json:"offerStartTime"`                                 // 提供時段的開始時間
+	OfferEndTime   *time.Time `json:"offerEndTime"`                                   // 提供時段的結束時間
+	OfferedAt      *time.Time `json:"offeredAt"`
+	OfferExpiresAt *time.Time `json:"offerExpiresAt"`             // 認領期限
+	BookingID      *string    `json:"bookingId" gorm:"type:uuid"` // 認領後創建的預訂
+	CreatedAt      time.Time  `json:"createdAt"`
+	UpdatedAt      time.Time  `json:"updatedAt"`
 
 	// 關聯
-	Court *Court `json:"court,omitempty" gorm:"constraint:OnDelete:CASCADE"`
-	User  *User  `json:"user,omitempty" gorm:"constraint:OnDelete:CASCADE"`
+	Court     *Court     `json:"court,omitempty" gorm:"constraint:OnDelete:CASCADE"`
+	CourtUnit *CourtUnit `json:"courtUnit,omitempty" gorm:"constraint:OnDelete:CASCADE"`
+	User      *User      `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+}
+
+// SlotHold 結帳期間暫時保留的時段
+//
+// 保留期間其他用戶無法預訂或保留重疊的時段，逾期自動失效，以保留創建預訂後刪除。
+type SlotHold struct {
+	ID          string    `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	CourtID     string    `json:"courtId" gorm:"type:uuid;not null;index"`
+	CourtUnitID *string   `json:"courtUnitId" gorm:"type:uuid"` // 保留的球場，為空表示場地未設置球場
+	UserID      string    `json:"userId" gorm:"type:uuid;not null;index"`
+	StartTime   time.Time `json:"startTime" gorm:"not null"`
+	EndTime     time.Time `json:"endTime" gorm:"not null"`
+	ExpiresAt   time.Time `json:"expiresAt" gorm:"not null;index"`
+	CreatedAt   time.Time `json:"createdAt"`
+
+	// 關聯
+	Court     *Court     `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	CourtUnit *CourtUnit `json:"courtUnit,omitempty" gorm:"constraint:OnDelete:CASCADE"`
 }
 
 // BeforeCreate 創建前的鉤子
@@ -146,6 +261,14 @@ func (cr *CourtReview) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
+// BeforeCreate 創建前的鉤子
+func (cu *CourtUnit) BeforeCreate(tx *gorm.DB) error {
+	if cu.ID == "" {
+		cu.ID = uuid.New().String()
+	}
+	return nil
+}
+
 // BeforeCreate 創建前的鉤子
 func (b *Booking) BeforeCreate(tx *gorm.DB) error {
 	if b.ID == "" {
@@ -154,6 +277,30 @@ func (b *Booking) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
+// BeforeCreate 創建前的鉤子
+func (bs *BookingSeries) BeforeCreate(tx *gorm.DB) error {
+	if bs.ID == "" {
+		bs.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// BeforeCreate 創建前的鉤子
+func (h *SlotHold) BeforeCreate(tx *gorm.DB) error {
+	if h.ID == "" {
+		h.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// BeforeCreate 創建前的鉤子
+func (w *BookingWaitlistEntry) BeforeCreate(tx *gorm.DB) error {
+	if w.ID == "" {
+		w.ID = uuid.New().String()
+	}
+	return nil
+}
+
 // BeforeCreate 創建前的鉤子
 func (rr *ReviewReport) BeforeCreate(tx *gorm.DB) error {
 	if rr.ID == "" {
@@ -177,6 +324,21 @@ func (Booking) TableName() string {
 	return "bookings"
 }
 
+// TableName 指定表名
+func (BookingSeries) TableName() string {
+	return "booking_series"
+}
+
+// TableName 指定表名
+func (SlotHold) TableName() string {
+	return "slot_holds"
+}
+
+// TableName 指定表名
+func (BookingWaitlistEntry) TableName() string {
+	return "booking_waitlist_entries"
+}
+
 // TableName 指定表名
 func (ReviewReport) TableName() string {
 	return "review_reports"
@@ -0,0 +1,87 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// 租借器材計價方式
+const (
+	EquipmentPricePerBooking = "per_booking" // 每次預訂收費一次
+	EquipmentPricePerHour    = "per_hour"    // 按預訂時長計價
+)
+
+// 租借項目狀態
+const (
+	BookingAddOnReserved = "reserved" // 預訂期間佔用庫存
+	BookingAddOnReturned = "returned" // 預訂取消，已退回庫存
+)
+
+// CourtEquipment 場地可租借的器材，例如球拍、球籃及發球機
+//
+// Quantity 為庫存總數，同一時段內所有預訂租借的數量合計不能超過庫存。
+type CourtEquipment struct {
+	ID          string         `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	CourtID     string         `json:"courtId" gorm:"type:uuid;not null;index"`
+	Name        string         `json:"name" gorm:"not null"`
+	Description *string        `json:"description" gorm:"type:text"`
+	Category    string         `json:"category" gorm:"not null;default:'other'"` // racket, ball_basket, ball_machine, other
+	Quantity    int            `json:"quantity" gorm:"not null"`
+	Price       float64        `json:"price" gorm:"type:numeric;not null"`
+	PriceUnit   string         `json:"priceUnit" gorm:"not null;default:'per_booking'"` // per_booking, per_hour
+	IsActive    bool           `json:"isActive" gorm:"not null"`                        // 停用的器材不開放租借，已租借的不受影響
+	CreatedAt   time.Time      `json:"createdAt"`
+	UpdatedAt   time.Time      `json:"updatedAt"`
+	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// BeforeCreate 創建前的鉤子
+func (e *CourtEquipment) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == "" {
+		e.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// TableName 指定表名
+func (CourtEquipment) TableName() string {
+	return "court_equipment"
+}
+
+// BookingAddOn 預訂租借的器材
+//
+// 保存租借時的名稱及價格快照，並複製預訂的時間以便檢查重疊時段的庫存。
+type BookingAddOn struct {
+	ID          string     `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	BookingID   string     `json:"bookingId" gorm:"type:uuid;not null;index"`
+	EquipmentID string     `json:"equipmentId" gorm:"type:uuid;not null;index:idx_booking_add_ons_equipment_time"`
+	Name        string     `json:"name" gorm:"not null"`
+	Quantity    int        `json:"quantity" gorm:"not null"`
+	UnitPrice   float64    `json:"unitPrice" gorm:"type:numeric;not null"`
+	PriceUnit   string     `json:"priceUnit" gorm:"not null"`
+	Amount      float64    `json:"amount" gorm:"type:numeric;not null"`
+	StartTime   time.Time  `json:"startTime" gorm:"not null;index:idx_booking_add_ons_equipment_time"`
+	EndTime     time.Time  `json:"endTime" gorm:"not null"`
+	Status      string     `json:"status" gorm:"not null;default:'reserved'"` // reserved, returned
+	ReturnedAt  *time.Time `json:"returnedAt"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+
+	// 關聯
+	Equipment *CourtEquipment `json:"-" gorm:"foreignKey:EquipmentID;constraint:OnDelete:RESTRICT"`
+}
+
+// BeforeCreate 創建前的鉤子
+func (a *BookingAddOn) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == "" {
+		a.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// TableName 指定表名
+func (BookingAddOn) TableName() string {
+	return "booking_add_ons"
+}
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// ImageAsset 已處理圖片的尺寸、變體及佔位資訊，以存儲鍵識別
+type ImageAsset struct {
+	Key           string        `json:"key" gorm:"primaryKey"`
+	URL           string        `json:"url" gorm:"not null;uniqueIndex"`
+	ContentType   string        `json:"contentType" gorm:"not null"`
+	Width         int           `json:"width"`
+	Height        int           `json:"height"`
+	Variants      ImageVariants `json:"variants" gorm:"type:jsonb"`
+	Blurhash      string        `json:"blurhash"`
+	DominantColor string        `json:"dominantColor"` // #rrggbb
+	CreatedAt     time.Time     `json:"createdAt"`
+}
+
+// ImageVariant 圖片變體
+type ImageVariant struct {
+	Key         string `json:"key"`
+	URL         string `json:"url"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+	ContentType string `json:"contentType"`
+}
+
+// ImageVariants 以變體名稱（thumbnail、medium、large）為鍵
+type ImageVariants map[string]ImageVariant
+
+// Value 實現 driver.Valuer 接口
+func (iv ImageVariants) Value() (driver.Value, error) {
+	if iv == nil {
+		return nil, nil
+	}
+	return json.Marshal(iv)
+}
+
+// Scan 實現 sql.Scanner 接口
+func (iv *ImageVariants) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*iv = make(ImageVariants)
+		return nil
+	case []byte:
+		return json.Unmarshal(v, iv)
+	case string:
+		return json.Unmarshal([]byte(v), iv)
+	default:
+		return errors.New("type assertion to []byte failed")
+	}
+}
+
+// TableName 指定表名
+func (ImageAsset) TableName() string {
+	return "image_assets"
+}
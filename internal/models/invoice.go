@@ -0,0 +1,122 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// 發票類型
+const (
+	InvoiceKindInvoice    = "invoice"     // 扣款後開立的發票
+	InvoiceKindCreditNote = "credit_note" // 退款時開立的折讓單
+)
+
+// 開立方類型
+const (
+	InvoiceSellerCourt = "court" // 場地，用於預訂及分攤付款
+	InvoiceSellerCoach = "coach" // 教練，用於課程
+	InvoiceSellerClub  = "club"  // 俱樂部，用於會費及活動報名
+)
+
+// InvoiceLine 發票的一個項目
+type InvoiceLine struct {
+	Description string  `json:"description"`
+	Quantity    float64 `json:"quantity"`
+	UnitPrice   float64 `json:"unitPrice"`
+	Amount      float64 `json:"amount"`
+}
+
+// InvoiceLines 發票項目列表
+type InvoiceLines []InvoiceLine
+
+// Value 實現 driver.Valuer 接口
+func (l InvoiceLines) Value() (driver.Value, error) {
+	data, err := json.Marshal(l)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan 實現 sql.Scanner 接口
+func (l *InvoiceLines) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*l = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, l)
+	case string:
+		return json.Unmarshal([]byte(v), l)
+	default:
+		return errors.New("type assertion to []byte failed")
+	}
+}
+
+// Invoice 付款的發票或退款的折讓單
+//
+// 開立時保存買賣雙方及項目的快照，之後場地改名或預訂變更不影響已開立的發票。
+// 金額為含稅價，TaxAmount 為其中的稅額。PDF 保存在存儲中，FileKey 為空表示尚未生成。
+type Invoice struct {
+	ID                string       `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	Number            string       `json:"number" gorm:"not null;uniqueIndex"` // INV-2024-000001，折讓單為 CN-2024-000001
+	Kind              string       `json:"kind" gorm:"not null"`               // invoice, credit_note
+	SourceKey         string       `json:"-" gorm:"not null;uniqueIndex"`      // 開立來源，重複處理同一扣款或退款時不重複開立
+	PaymentID         string       `json:"paymentId" gorm:"type:uuid;not null;index"`
+	OriginalInvoiceID *string      `json:"originalInvoiceId" gorm:"type:uuid"` // 折讓單對應的發票
+	TargetType        string       `json:"targetType" gorm:"not null"`
+	TargetID          string       `json:"targetId" gorm:"type:uuid;not null"`
+	BuyerID           string       `json:"buyerId" gorm:"type:uuid;not null;index"`
+	BuyerName         string       `json:"buyerName" gorm:"not null"`
+	BuyerEmail        string       `json:"buyerEmail" gorm:"not null"`
+	SellerType        string       `json:"sellerType" gorm:"not null"` // court, coach, club
+	SellerID          string       `json:"sellerId" gorm:"type:uuid;not null"`
+	SellerUserID      *string      `json:"-" gorm:"type:uuid;index"` // 場地擁有者、教練或俱樂部擁有者，可查看開立的發票
+	SellerName        string       `json:"sellerName" gorm:"not null"`
+	SellerAddress     string       `json:"sellerAddress"`
+	Lines             InvoiceLines `json:"lines" gorm:"type:jsonb;not null"`
+	Subtotal          float64      `json:"subtotal" gorm:"type:numeric;not null"`  // 未稅金額
+	TaxRate           float64      `json:"taxRate" gorm:"type:numeric;not null"`   // 稅率（百分比）
+	TaxAmount         float64      `json:"taxAmount" gorm:"type:numeric;not null"` // 稅額
+	Total             float64      `json:"total" gorm:"type:numeric;not null"`     // 含稅金額
+	Currency          string       `json:"currency" gorm:"not null;default:'TWD'"`
+	IssuedAt          time.Time    `json:"issuedAt" gorm:"not null"`
+	FileKey           string       `json:"-"`
+	EmailedAt         *time.Time   `json:"emailedAt"` // 最近一次寄出的時間
+	CreatedAt         time.Time    `json:"createdAt"`
+	UpdatedAt         time.Time    `json:"updatedAt"`
+
+	// 關聯
+	Payment *Payment `json:"-" gorm:"constraint:OnDelete:RESTRICT"`
+	Buyer   *User    `json:"-" gorm:"foreignKey:BuyerID;constraint:OnDelete:RESTRICT"`
+}
+
+// BeforeCreate 創建前的鉤子
+func (i *Invoice) BeforeCreate(tx *gorm.DB) error {
+	if i.ID == "" {
+		i.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// TableName 指定表名
+func (Invoice) TableName() string {
+	return "invoices"
+}
+
+// InvoiceSequence 發票號碼的流水號，每種字軌每年從 1 開始
+type InvoiceSequence struct {
+	Prefix     string `gorm:"primaryKey"`
+	Year       int    `gorm:"primaryKey;autoIncrement:false"`
+	LastNumber int64  `gorm:"not null;default:0"`
+}
+
+// TableName 指定表名
+func (InvoiceSequence) TableName() string {
+	return "invoice_sequences"
+}
@@ -11,9 +11,18 @@ func AllModels() []interface{} {
 
 		// 場地相關
 		&Court{},
+		&CourtUnit{},
 		&CourtReview{},
 		&ReviewReport{},
 		&Booking{},
+		&BookingSeries{},
+		&SlotHold{},
+		&BookingWaitlistEntry{},
+		&CourtPriceRule{},
+		&CourtClosure{},
+		&CourtSlotDemand{},
+		&CourtEquipment{},
+		&BookingAddOn{},
 
 		// 配對和聊天相關
 		&Match{},
@@ -50,5 +59,34 @@ func AllModels() []interface{} {
 		&ClubEvent{},
 		&ClubEventParticipant{},
 		&ClubReview{},
+
+		// 事件相關
+		&OutboxEvent{},
+		&ProcessedEvent{},
+
+		// Webhook 相關
+		&WebhookSubscription{},
+		&WebhookDelivery{},
+
+		// 圖片相關
+		&ImageAsset{},
+		&UploadSession{},
+
+		// 行事曆相關
+		&CalendarFeed{},
+		&CoachCalendarSource{},
+		&CoachBusyBlock{},
+
+		// 付款相關
+		&Payment{},
+		&BookingPaymentSplit{},
+		&BookingPaymentShare{},
+		&CancellationPolicy{},
+		&CancellationOverride{},
+		&Cancellation{},
+		&Invoice{},
+		&InvoiceSequence{},
+		&PromoCode{},
+		&PromoRedemption{},
 	}
 }
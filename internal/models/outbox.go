@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// OutboxEvent 領域事件發件箱
+type OutboxEvent struct {
+	ID            string         `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	EventType     string         `json:"eventType" gorm:"not null;index"`   // booking.created, lesson.cancelled, ...
+	AggregateType string         `json:"aggregateType" gorm:"not null"`     // booking, lesson, match_result, match
+	AggregateID   string         `json:"aggregateId" gorm:"not null;index"` // 聚合根ID
+	Payload       datatypes.JSON `json:"payload" gorm:"type:jsonb"`         // 事件內容（JSON格式）
+	Status        string         `json:"status" gorm:"default:'pending'"`   // pending, dispatched, failed
+	Attempts      int            `json:"attempts" gorm:"default:0"`         // 已嘗試派送次數
+	LastError     *string        `json:"lastError" gorm:"type:text"`        // 最近一次派送錯誤
+	AvailableAt   time.Time      `json:"availableAt" gorm:"not null"`       // 下次可派送時間
+	DispatchedAt  *time.Time     `json:"dispatchedAt"`                      // 派送完成時間
+	CreatedAt     time.Time      `json:"createdAt"`
+	UpdatedAt     time.Time      `json:"updatedAt"`
+}
+
+// ProcessedEvent 訂閱者已處理事件記錄（用於去重）
+type ProcessedEvent struct {
+	EventID     string    `json:"eventId" gorm:"type:uuid;primaryKey"`
+	Subscriber  string    `json:"subscriber" gorm:"primaryKey"`
+	ProcessedAt time.Time `json:"processedAt" gorm:"not null"`
+}
+
+// BeforeCreate 創建前的鉤子
+func (oe *OutboxEvent) BeforeCreate(tx *gorm.DB) error {
+	if oe.ID == "" {
+		oe.ID = uuid.New().String()
+	}
+	if oe.AvailableAt.IsZero() {
+		oe.AvailableAt = time.Now()
+	}
+	return nil
+}
+
+// TableName 指定表名
+func (OutboxEvent) TableName() string {
+	return "outbox_events"
+}
+
+// TableName 指定表名
+func (ProcessedEvent) TableName() string {
+	return "processed_events"
+}
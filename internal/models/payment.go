@@ -0,0 +1,139 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Payment 付款記錄，對應付款服務商的一筆付款意圖
+//
+// 付款目標為預訂、課程、活動報名或俱樂部會費，扣款成功後目標的 payment_id 指向此記錄。
+type Payment struct {
+	ID                string     `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	UserID            string     `json:"userId" gorm:"type:uuid;not null;index"`
+	TargetType        string     `json:"targetType" gorm:"not null;index:idx_payments_target"` // booking, booking_series, lesson, club_event_registration, booking_share, club_membership
+	TargetID          string     `json:"targetId" gorm:"type:uuid;not null;index:idx_payments_target"`
+	Amount            float64    `json:"amount" gorm:"type:numeric;not null"`
+	Currency          string     `json:"currency" gorm:"not null;default:'TWD'"`
+	Status            string     `json:"status" gorm:"not null;default:'pending';index"` // pending, authorized, captured, failed, cancelled, expired, partially_refunded, refunded
+	Provider          string     `json:"provider" gorm:"not null"`
+	ProviderPaymentID string     `json:"providerPaymentId" gorm:"not null;uniqueIndex"`
+	ClientSecret      string     `json:"clientSecret,omitempty"` // 前端以此完成付款，僅返回給付款人
+	RefundedAmount    float64    `json:"refundedAmount" gorm:"type:numeric;not null;default:0"`
+	FailureReason     *string    `json:"failureReason" gorm:"type:text"`
+	ExpiresAt         *time.Time `json:"expiresAt" gorm:"index"` // 付款期限，與目標的 payment_due_at 一致
+	AuthorizedAt      *time.Time `json:"authorizedAt"`
+	CapturedAt        *time.Time `json:"capturedAt"`
+	CancelledAt       *time.Time `json:"cancelledAt"`
+	CreatedAt         time.Time  `json:"createdAt"`
+	UpdatedAt         time.Time  `json:"updatedAt"`
+
+	// 關聯
+	User *User `json:"user,omitempty" gorm:"constraint:OnDelete:CASCADE"`
+}
+
+// BeforeCreate 創建前的鉤子
+func (p *Payment) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == "" {
+		p.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// 分攤付款狀態
+const (
+	PaymentSplitOpen      = "open"      // 等待各分攤付款
+	PaymentSplitCompleted = "completed" // 全部付清，預訂已確認
+	PaymentSplitCancelled = "cancelled" // 預訂者取消分攤，或預訂已取消
+)
+
+// 分攤計算方式
+const (
+	PaymentSplitModeEqual  = "equal"  // 平均分攤，無法整除的差額由預訂者負擔
+	PaymentSplitModeCustom = "custom" // 自訂每人的金額
+)
+
+// 期限前未付清時的處理方式
+const (
+	PaymentSplitFallbackCancel      = "cancel"       // 取消預訂並全額退還已付的分攤
+	PaymentSplitFallbackChargeOwner = "charge_owner" // 未付的金額改由預訂者支付
+)
+
+// 分攤狀態
+const (
+	PaymentShareStatusPending    = "pending"
+	PaymentShareStatusPaid       = "paid"
+	PaymentShareStatusReassigned = "reassigned" // 逾期未付，改由預訂者支付
+	PaymentShareStatusCancelled  = "cancelled"
+)
+
+// BookingPaymentSplit 預訂的分攤付款，預訂者邀請其他用戶各自支付一部分金額
+//
+// 所有分攤付清後預訂轉為 confirmed；期限前未付清時依 Fallback 取消預訂或改由預訂者支付餘額。
+type BookingPaymentSplit struct {
+	ID             string     `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	BookingID      string     `json:"bookingId" gorm:"type:uuid;not null;index"`
+	OwnerID        string     `json:"ownerId" gorm:"type:uuid;not null"`
+	MatchID        *string    `json:"matchId" gorm:"type:uuid"`           // 邀請參與者的比賽
+	Mode           string     `json:"mode" gorm:"not null"`               // equal, custom
+	Fallback       string     `json:"fallback" gorm:"not null"`           // cancel, charge_owner
+	Status         string     `json:"status" gorm:"not null;index"`       // open, completed, cancelled
+	DueAt          time.Time  `json:"dueAt" gorm:"not null;index"`        // 分攤的付款期限
+	OwnerChargedAt *time.Time `json:"ownerChargedAt"`                     // 未付的金額改由預訂者支付的時間
+	Total          float64    `json:"total" gorm:"type:numeric;not null"` // 建立時的預訂金額
+	Currency       string     `json:"currency" gorm:"not null;default:'TWD'"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	UpdatedAt      time.Time  `json:"updatedAt"`
+
+	// 關聯
+	Booking *Booking              `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	Shares  []BookingPaymentShare `json:"shares,omitempty" gorm:"foreignKey:SplitID"`
+}
+
+// BookingPaymentShare 分攤付款中一位付款人的金額，以 booking_share 為付款目標各自付款
+type BookingPaymentShare struct {
+	ID        string     `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	SplitID   string     `json:"splitId" gorm:"type:uuid;not null;index"`
+	BookingID string     `json:"bookingId" gorm:"type:uuid;not null;index"`
+	UserID    string     `json:"userId" gorm:"type:uuid;not null;index"`
+	IsOwner   bool       `json:"isOwner" gorm:"not null;default:false"` // 預訂者自己的分攤，包含改由預訂者支付的餘額
+	Amount    float64    `json:"amount" gorm:"type:numeric;not null"`
+	Status    string     `json:"status" gorm:"not null"` // pending, paid, reassigned, cancelled
+	PaymentID *string    `json:"paymentId" gorm:"type:uuid"`
+	PaidAt    *time.Time `json:"paidAt"`
+	CreatedAt time.Time  `json:"createdAt"`
+	UpdatedAt time.Time  `json:"updatedAt"`
+
+	// 關聯
+	Split   *BookingPaymentSplit `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	Booking *Booking             `json:"booking,omitempty"`
+	User    *User                `json:"user,omitempty" gorm:"constraint:OnDelete:CASCADE"`
+}
+
+// BeforeCreate 創建前的鉤子
+func (s *BookingPaymentSplit) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == "" {
+		s.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// BeforeCreate 創建前的鉤子
+func (s *BookingPaymentShare) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == "" {
+		s.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// TableName 指定表名
+func (BookingPaymentSplit) TableName() string {
+	return "booking_payment_splits"
+}
+
+// TableName 指定表名
+func (BookingPaymentShare) TableName() string {
+	return "booking_payment_shares"
+}
@@ -0,0 +1,114 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
+
+// CourtPriceRule 場地價格規則
+//
+// rate 規則取代場地的每小時價格，多個符合時取優先級最高者；surcharge 規則（如夜間燈光）在每小時價格之上加收，符合的全部累加。
+// 時段及日期以預訂時間所在時區的當地時間判斷，與營業時間一致。
+type CourtPriceRule struct {
+	ID           string        `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	CourtID      string        `json:"courtId" gorm:"type:uuid;not null;index"`
+	Name         string        `json:"name" gorm:"not null"`
+	Kind         string        `json:"kind" gorm:"not null;default:'rate'"` // rate, surcharge
+	PricePerHour float64       `json:"pricePerHour" gorm:"type:numeric;not null"`
+	DaysOfWeek   pq.Int64Array `json:"daysOfWeek" gorm:"type:integer[]" swaggertype:"array,integer"` // 0 為星期日，空表示每天
+	StartTime    *string       `json:"startTime"`                                                    // HH:MM，空表示全天
+	EndTime      *string       `json:"endTime"`                                                      // HH:MM，可為 24:00
+	StartDate    *string       `json:"startDate" gorm:"size:10"`                                     // YYYY-MM-DD，空表示不限
+	EndDate      *string       `json:"endDate" gorm:"size:10"`                                       // YYYY-MM-DD，包含當天
+	Audience     string        `json:"audience" gorm:"not null;default:'all'"`                       // all, member, public
+	ClubID       *string       `json:"clubId" gorm:"type:uuid"`                                      // audience 為 member 或 public 時判斷會員資格的俱樂部
+	Priority     int           `json:"priority" gorm:"not null;default:0"`
+	IsActive     bool          `json:"isActive" gorm:"not null"`
+	CreatedAt    time.Time     `json:"createdAt"`
+	UpdatedAt    time.Time     `json:"updatedAt"`
+}
+
+// PriceSurcharge 價格明細中的附加費
+type PriceSurcharge struct {
+	RuleID       string  `json:"ruleId"`
+	RuleName     string  `json:"ruleName"`
+	PricePerHour float64 `json:"pricePerHour"`
+}
+
+// PriceSegment 價格明細中套用同一價格的時段
+type PriceSegment struct {
+	StartTime    time.Time        `json:"startTime"`
+	EndTime      time.Time        `json:"endTime"`
+	Hours        float64          `json:"hours"`
+	PricePerHour float64          `json:"pricePerHour"` // 不含附加費
+	RuleID       *string          `json:"ruleId"`       // 為空表示場地的每小時價格
+	RuleName     *string          `json:"ruleName"`
+	Surcharges   []PriceSurcharge `json:"surcharges,omitempty"`
+	Amount       float64          `json:"amount"`
+}
+
+// PriceDiscount 價格明細中的優惠碼折扣
+type PriceDiscount struct {
+	PromoCodeID string  `json:"promoCodeId"`
+	Code        string  `json:"code"`
+	Amount      float64 `json:"amount"`
+}
+
+// PriceAddOn 價格明細中的租借器材
+type PriceAddOn struct {
+	EquipmentID string  `json:"equipmentId"`
+	Name        string  `json:"name"`
+	Quantity    int     `json:"quantity"`
+	UnitPrice   float64 `json:"unitPrice"`
+	PriceUnit   string  `json:"priceUnit"`
+	Amount      float64 `json:"amount"`
+}
+
+// PriceBreakdown 預訂的價格明細，跨越規則邊界的時段按比例分段計價
+type PriceBreakdown struct {
+	Currency         string         `json:"currency"`
+	BasePricePerHour float64        `json:"basePricePerHour"` // 場地的每小時價格
+	Segments         []PriceSegment `json:"segments"`
+	AddOns           []PriceAddOn   `json:"addOns,omitempty"`   // 租借的器材，金額計入 total
+	Subtotal         float64        `json:"subtotal,omitempty"` // 折扣前金額，只在使用優惠碼時提供
+	Discount         *PriceDiscount `json:"discount,omitempty"`
+	Total            float64        `json:"total"` // 應付金額，已扣除折扣
+}
+
+// Value 實現 driver.Valuer 接口
+func (pb PriceBreakdown) Value() (driver.Value, error) {
+	data, err := json.Marshal(pb)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan 實現 sql.Scanner 接口
+func (pb *PriceBreakdown) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*pb = PriceBreakdown{}
+		return nil
+	case []byte:
+		return json.Unmarshal(v, pb)
+	case string:
+		return json.Unmarshal([]byte(v), pb)
+	default:
+		return errors.New("type assertion to []byte failed")
+	}
+}
+
+// BeforeCreate 創建前的鉤子
+func (r *CourtPriceRule) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == "" {
+		r.ID = uuid.New().String()
+	}
+	return nil
+}
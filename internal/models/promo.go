@@ -0,0 +1,102 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
+
+// 優惠碼折扣類型
+const (
+	PromoDiscountPercent = "percent" // 按百分比折扣，可設上限
+	PromoDiscountFixed   = "fixed"   // 折抵固定金額，不超過原價
+)
+
+// 優惠碼使用目標
+const (
+	PromoTargetBooking = "booking" // 場地預訂
+	PromoTargetLesson  = "lesson"  // 課程
+)
+
+// 優惠碼使用記錄狀態
+const (
+	PromoRedemptionApplied  = "applied"  // 已套用
+	PromoRedemptionReversed = "reversed" // 預訂或課程取消，使用次數已退回
+)
+
+// PromoCode 優惠碼
+//
+// 範圍欄位（場地、教練、課程類型、俱樂部）為空表示不限，設置多個時需全部符合；設置俱樂部時只有有效會員可以使用。
+// 有效期以使用時間判斷；星期及時段與價格規則一致，以預訂或上課時間所在時區的當地時間判斷。
+type PromoCode struct {
+	ID                    string        `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	Code                  string        `json:"code" gorm:"not null;uniqueIndex"` // 大寫保存，使用時不分大小寫
+	Description           *string       `json:"description" gorm:"type:text"`
+	CreatedBy             string        `json:"createdBy" gorm:"type:uuid;not null;index"`
+	CourtID               *string       `json:"courtId" gorm:"type:uuid;index"`
+	CoachID               *string       `json:"coachId" gorm:"type:uuid;index"`
+	LessonTypeID          *string       `json:"lessonTypeId" gorm:"type:uuid"`
+	ClubID                *string       `json:"clubId" gorm:"type:uuid"`
+	DiscountType          string        `json:"discountType" gorm:"not null"` // percent, fixed
+	DiscountValue         float64       `json:"discountValue" gorm:"type:numeric;not null"`
+	MaxDiscount           *float64      `json:"maxDiscount" gorm:"type:numeric"` // 百分比折扣的金額上限
+	MinSpend              float64       `json:"minSpend" gorm:"type:numeric;not null;default:0"`
+	StartsAt              *time.Time    `json:"startsAt"`
+	EndsAt                *time.Time    `json:"endsAt"`
+	DaysOfWeek            pq.Int64Array `json:"daysOfWeek" gorm:"type:integer[]" swaggertype:"array,integer"` // 0 為星期日，空表示每天
+	StartTime             *string       `json:"startTime"`                                                    // HH:MM，空表示全天
+	EndTime               *string       `json:"endTime"`                                                      // HH:MM，可為 24:00
+	MaxRedemptions        *int          `json:"maxRedemptions"`                                               // 總使用次數上限，空表示不限
+	MaxRedemptionsPerUser *int          `json:"maxRedemptionsPerUser"`                                        // 每位用戶的使用次數上限
+	RedemptionCount       int           `json:"redemptionCount" gorm:"not null;default:0"`                    // 目前有效的使用次數，取消時退回
+	FirstPurchaseOnly     bool          `json:"firstPurchaseOnly" gorm:"not null;default:false"`              // 只限首次預訂此範圍的場地或課程
+	IsActive              bool          `json:"isActive" gorm:"not null"`
+	CreatedAt             time.Time     `json:"createdAt"`
+	UpdatedAt             time.Time     `json:"updatedAt"`
+}
+
+// BeforeCreate 創建前的鉤子
+func (p *PromoCode) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == "" {
+		p.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// TableName 指定表名
+func (PromoCode) TableName() string {
+	return "promo_codes"
+}
+
+// PromoRedemption 優惠碼的使用記錄，每個預訂或課程最多一筆
+type PromoRedemption struct {
+	ID             string     `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	PromoCodeID    string     `json:"promoCodeId" gorm:"type:uuid;not null;index"`
+	UserID         string     `json:"userId" gorm:"type:uuid;not null;index"`
+	TargetType     string     `json:"targetType" gorm:"not null;uniqueIndex:idx_promo_redemptions_target"` // booking, lesson
+	TargetID       string     `json:"targetId" gorm:"type:uuid;not null;uniqueIndex:idx_promo_redemptions_target"`
+	OriginalAmount float64    `json:"originalAmount" gorm:"type:numeric;not null"`
+	DiscountAmount float64    `json:"discountAmount" gorm:"type:numeric;not null"`
+	Currency       string     `json:"currency" gorm:"not null;default:'TWD'"`
+	Status         string     `json:"status" gorm:"not null;default:'applied'"` // applied, reversed
+	ReversedAt     *time.Time `json:"reversedAt"`
+	CreatedAt      time.Time  `json:"createdAt"`
+
+	// 關聯
+	PromoCode *PromoCode `json:"-" gorm:"constraint:OnDelete:RESTRICT"`
+}
+
+// BeforeCreate 創建前的鉤子
+func (r *PromoRedemption) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == "" {
+		r.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// TableName 指定表名
+func (PromoRedemption) TableName() string {
+	return "promo_redemptions"
+}
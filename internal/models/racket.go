@@ -33,6 +33,7 @@ type Racket struct {
 	AverageRating  float64        `json:"averageRating" gorm:"default:0"`
 	TotalReviews   int            `json:"totalReviews" gorm:"default:0"`
 	IsActive       bool           `json:"isActive" gorm:"default:true"`
+	Version        int64          `json:"version" gorm:"not null;default:1"` // 樂觀鎖版本號
 	CreatedAt      time.Time      `json:"createdAt"`
 	UpdatedAt      time.Time      `json:"updatedAt"`
 	DeletedAt      gorm.DeletedAt `json:"-" gorm:"index"`
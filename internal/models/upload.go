@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// UploadSession 直傳上傳的申請記錄，以存儲鍵識別，完成上傳及獲取下載網址時用於確認上傳者
+type UploadSession struct {
+	Key         string     `json:"key" gorm:"primaryKey"`
+	UserID      string     `json:"userId" gorm:"type:uuid;not null;index"`
+	ContentType string     `json:"contentType" gorm:"not null"`
+	CompletedAt *time.Time `json:"completedAt"`
+	CreatedAt   time.Time  `json:"createdAt"`
+}
+
+// TableName 指定表名
+func (UploadSession) TableName() string {
+	return "upload_sessions"
+}
@@ -0,0 +1,82 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// WebhookSubscription Webhook 訂閱
+type WebhookSubscription struct {
+	ID                  string         `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	OwnerID             string         `json:"ownerId" gorm:"type:uuid;not null;index"`
+	URL                 string         `json:"url" gorm:"not null"`
+	Description         *string        `json:"description"`
+	EventTypes          StringArray    `json:"eventTypes" gorm:"type:text[]"`
+	Secret              string         `json:"-" gorm:"not null"` // HMAC 簽名密鑰
+	IsActive            bool           `json:"isActive" gorm:"default:true"`
+	ConsecutiveFailures int            `json:"consecutiveFailures" gorm:"default:0"`
+	DisabledAt          *time.Time     `json:"disabledAt"`
+	DisabledReason      *string        `json:"disabledReason"`
+	LastDeliveryAt      *time.Time     `json:"lastDeliveryAt"`
+	CreatedAt           time.Time      `json:"createdAt"`
+	UpdatedAt           time.Time      `json:"updatedAt"`
+	DeletedAt           gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// 關聯
+	Owner *User `json:"owner,omitempty" gorm:"foreignKey:OwnerID;constraint:OnDelete:CASCADE"`
+}
+
+// WebhookDelivery Webhook 投遞記錄
+type WebhookDelivery struct {
+	ID             string         `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	SubscriptionID string         `json:"subscriptionId" gorm:"type:uuid;not null;index"`
+	EventID        string         `json:"eventId" gorm:"type:uuid;not null;index"`
+	EventType      string         `json:"eventType" gorm:"not null"`
+	Payload        datatypes.JSON `json:"payload" gorm:"type:jsonb"`       // 已序列化的請求內容
+	Status         string         `json:"status" gorm:"default:'pending'"` // pending, succeeded, failed
+	Attempts       int            `json:"attempts" gorm:"default:0"`
+	NextAttemptAt  time.Time      `json:"nextAttemptAt" gorm:"not null"`
+	ResponseStatus *int           `json:"responseStatus"`
+	ResponseBody   *string        `json:"responseBody" gorm:"type:text"` // 截斷後的回應內容
+	LastError      *string        `json:"lastError" gorm:"type:text"`
+	DurationMs     *int64         `json:"durationMs"`
+	DeliveredAt    *time.Time     `json:"deliveredAt"`
+	RedeliveryOf   *string        `json:"redeliveryOf" gorm:"type:uuid"` // 手動重送時指向原投遞記錄
+	CreatedAt      time.Time      `json:"createdAt"`
+	UpdatedAt      time.Time      `json:"updatedAt"`
+
+	// 關聯
+	Subscription *WebhookSubscription `json:"subscription,omitempty" gorm:"constraint:OnDelete:CASCADE"`
+}
+
+// BeforeCreate 創建前的鉤子
+func (ws *WebhookSubscription) BeforeCreate(tx *gorm.DB) error {
+	if ws.ID == "" {
+		ws.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// BeforeCreate 創建前的鉤子
+func (wd *WebhookDelivery) BeforeCreate(tx *gorm.DB) error {
+	if wd.ID == "" {
+		wd.ID = uuid.New().String()
+	}
+	if wd.NextAttemptAt.IsZero() {
+		wd.NextAttemptAt = time.Now()
+	}
+	return nil
+}
+
+// TableName 指定表名
+func (WebhookSubscription) TableName() string {
+	return "webhook_subscriptions"
+}
+
+// TableName 指定表名
+func (WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
@@ -0,0 +1,225 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"tennis-platform/backend/internal/apperror"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrInvalidCursor 游標無法解析或與目前排序不符
+var ErrInvalidCursor = apperror.New(apperror.CodeInvalidCursor)
+
+// Kind 排序鍵的值類型，用於還原游標中的值
+type Kind int
+
+const (
+	KindString Kind = iota
+	KindTime
+	KindNumber
+)
+
+// Key 排序鍵
+type Key struct {
+	Column string // SQL 欄位，可帶表名，例如 "matches.created_at"
+	Kind   Kind
+}
+
+// Query 分頁查詢描述
+//
+// 提供 Cursor 時使用 keyset 分頁：依 Keys 排序並取 (Keys) 在游標之後的資料；
+// 否則回退至 Page/Limit 的 OFFSET 分頁。Keys 的最後一個必須是唯一鍵（通常為 id）；
+// Keys 為空時僅支援頁碼分頁，排序由呼叫方自行處理。
+type Query struct {
+	Keys   []Key
+	Desc   bool
+	Limit  int
+	Cursor string
+	Page   int
+}
+
+// PageInfo 統一的分頁回應
+type PageInfo struct {
+	Limit      int     `json:"limit"`
+	HasNext    bool    `json:"hasNext"`
+	HasPrev    bool    `json:"hasPrev"`
+	NextCursor *string `json:"nextCursor,omitempty"`
+	Page       int     `json:"page,omitempty"`       // 僅頁碼分頁
+	Total      *int64  `json:"total,omitempty"`      // 僅頁碼分頁
+	TotalPages int     `json:"totalPages,omitempty"` // 僅頁碼分頁
+}
+
+// cursorPayload 游標內容
+type cursorPayload struct {
+	Sort   string            `json:"s"`
+	Values []json.RawMessage `json:"v"`
+}
+
+// Normalize 套用默認值與上限
+func (q *Query) Normalize(defaultLimit, maxLimit int) {
+	if q.Limit <= 0 {
+		q.Limit = defaultLimit
+	}
+	if q.Limit > maxLimit {
+		q.Limit = maxLimit
+	}
+	if q.Page <= 0 {
+		q.Page = 1
+	}
+}
+
+// IsCursor 是否使用游標分頁
+func (q *Query) IsCursor() bool {
+	return q.Cursor != ""
+}
+
+// Offset 頁碼分頁的偏移量
+func (q *Query) Offset() int {
+	return (q.Page - 1) * q.Limit
+}
+
+// Apply 套用排序與分頁條件，會多取一筆以判斷是否還有下一頁
+func (q *Query) Apply(db *gorm.DB) (*gorm.DB, error) {
+	direction, operator := "ASC", ">"
+	if q.Desc {
+		direction, operator = "DESC", "<"
+	}
+
+	columns := make([]string, len(q.Keys))
+	for i, key := range q.Keys {
+		columns[i] = key.Column
+		db = db.Order(key.Column + " " + direction)
+	}
+
+	if q.IsCursor() {
+		if len(q.Keys) == 0 {
+			return nil, ErrInvalidCursor
+		}
+		values, err := q.DecodeCursor()
+		if err != nil {
+			return nil, err
+		}
+
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(values)), ", ")
+		condition := fmt.Sprintf("(%s) %s (%s)", strings.Join(columns, ", "), operator, placeholders)
+		db = db.Where(condition, values...)
+	} else {
+		db = db.Offset(q.Offset())
+	}
+
+	return db.Limit(q.Limit + 1), nil
+}
+
+// EncodeCursor 將排序鍵的值編碼為游標
+func (q *Query) EncodeCursor(values ...interface{}) (string, error) {
+	if len(q.Keys) != len(values) {
+		return "", ErrInvalidCursor
+	}
+
+	payload := cursorPayload{Sort: q.signature()}
+	for _, value := range values {
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return "", fmt.Errorf("failed to encode cursor: %w", err)
+		}
+		payload.Values = append(payload.Values, raw)
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode cursor: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// DecodeCursor 解析游標並依排序鍵類型還原值
+func (q *Query) DecodeCursor() ([]interface{}, error) {
+	keys := q.Keys
+	data, err := base64.RawURLEncoding.DecodeString(q.Cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var payload cursorPayload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	if payload.Sort != q.signature() || len(payload.Values) != len(keys) {
+		return nil, ErrInvalidCursor
+	}
+
+	values := make([]interface{}, len(keys))
+	for i, key := range keys {
+		var err error
+		switch key.Kind {
+		case KindTime:
+			var t time.Time
+			err = json.Unmarshal(payload.Values[i], &t)
+			values[i] = t
+		case KindNumber:
+			var n float64
+			err = json.Unmarshal(payload.Values[i], &n)
+			values[i] = n
+		default:
+			var s string
+			err = json.Unmarshal(payload.Values[i], &s)
+			values[i] = s
+		}
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+	}
+
+	return values, nil
+}
+
+// Build 截斷多取的一筆並生成分頁信息；total 僅在頁碼分頁時提供
+func Build[T any](items []T, q *Query, total *int64, keyValues func(item *T) []interface{}) ([]T, PageInfo, error) {
+	info := PageInfo{
+		Limit:   q.Limit,
+		HasNext: len(items) > q.Limit,
+	}
+
+	if info.HasNext {
+		items = items[:q.Limit]
+	}
+
+	if info.HasNext && len(items) > 0 && len(q.Keys) > 0 {
+		cursor, err := q.EncodeCursor(keyValues(&items[len(items)-1])...)
+		if err != nil {
+			return nil, PageInfo{}, err
+		}
+		info.NextCursor = &cursor
+	}
+
+	if q.IsCursor() {
+		info.HasPrev = true
+	} else {
+		info.Page = q.Page
+		info.HasPrev = q.Page > 1
+		info.Total = total
+		if total != nil {
+			info.TotalPages = int((*total + int64(q.Limit) - 1) / int64(q.Limit))
+		}
+	}
+
+	return items, info, nil
+}
+
+// signature 排序簽名，防止游標被用在不同排序的查詢上
+func (q *Query) signature() string {
+	columns := make([]string, len(q.Keys))
+	for i, key := range q.Keys {
+		columns[i] = key.Column
+	}
+	if q.Desc {
+		return strings.Join(columns, ",") + ":desc"
+	}
+	return strings.Join(columns, ",") + ":asc"
+}
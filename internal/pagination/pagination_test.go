@@ -0,0 +1,143 @@
+package pagination
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type testItem struct {
+	ID        string
+	CreatedAt time.Time
+}
+
+func setupTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		panic("failed to connect database")
+	}
+
+	// 手動創建表結構
+	db.Exec(`CREATE TABLE test_items (
+		id TEXT PRIMARY KEY,
+		created_at DATETIME
+	)`)
+
+	// 每兩筆共用同一時間，驗證唯一鍵能打破平手
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 7; i++ {
+		item := testItem{
+			ID:        fmt.Sprintf("item-%02d", i),
+			CreatedAt: base.Add(time.Duration(i/2) * time.Hour),
+		}
+		assert.NoError(t, db.Create(&item).Error)
+	}
+
+	return db
+}
+
+func newTestQuery(cursor string) *Query {
+	q := &Query{
+		Keys: []Key{
+			{Column: "created_at", Kind: KindTime},
+			{Column: "id", Kind: KindString},
+		},
+		Desc:   true,
+		Limit:  3,
+		Cursor: cursor,
+	}
+	q.Normalize(20, 100)
+	return q
+}
+
+func fetchPage(t *testing.T, db *gorm.DB, q *Query) ([]testItem, PageInfo) {
+	query, err := q.Apply(db.Model(&testItem{}))
+	assert.NoError(t, err)
+
+	var items []testItem
+	assert.NoError(t, query.Find(&items).Error)
+
+	items, info, err := Build(items, q, nil, func(item *testItem) []interface{} {
+		return []interface{}{item.CreatedAt, item.ID}
+	})
+	assert.NoError(t, err)
+	return items, info
+}
+
+func TestCursorPagination_WalkAll(t *testing.T) {
+	db := setupTestDB(t)
+
+	var seen []string
+	cursor := ""
+	for pages := 0; pages < 10; pages++ {
+		items, info := fetchPage(t, db, newTestQuery(cursor))
+		for _, item := range items {
+			seen = append(seen, item.ID)
+		}
+		if !info.HasNext {
+			assert.Nil(t, info.NextCursor)
+			break
+		}
+		cursor = *info.NextCursor
+	}
+
+	assert.Equal(t, []string{"item-06", "item-05", "item-04", "item-03", "item-02", "item-01", "item-00"}, seen)
+}
+
+func TestCursorPagination_StableAfterInsert(t *testing.T) {
+	db := setupTestDB(t)
+
+	items, info := fetchPage(t, db, newTestQuery(""))
+	assert.Equal(t, "item-04", items[len(items)-1].ID)
+
+	// 第一頁之後插入較新的資料，下一頁不應重複或跳過
+	assert.NoError(t, db.Create(&testItem{ID: "item-99", CreatedAt: time.Now()}).Error)
+
+	items, _ = fetchPage(t, db, newTestQuery(*info.NextCursor))
+	assert.Equal(t, "item-03", items[0].ID)
+}
+
+func TestCursorPagination_InvalidCursor(t *testing.T) {
+	db := setupTestDB(t)
+
+	_, err := newTestQuery("not-a-cursor").Apply(db)
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+
+	// 游標不能用於不同排序的查詢
+	_, info := fetchPage(t, db, newTestQuery(""))
+	q := newTestQuery(*info.NextCursor)
+	q.Desc = false
+	_, err = q.Apply(db)
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+}
+
+func TestOffsetPagination_Fallback(t *testing.T) {
+	db := setupTestDB(t)
+
+	var total int64
+	db.Model(&testItem{}).Count(&total)
+
+	q := newTestQuery("")
+	q.Page = 3
+	query, err := q.Apply(db.Model(&testItem{}))
+	assert.NoError(t, err)
+
+	var items []testItem
+	assert.NoError(t, query.Find(&items).Error)
+	items, info, err := Build(items, q, &total, func(item *testItem) []interface{} {
+		return []interface{}{item.CreatedAt, item.ID}
+	})
+	assert.NoError(t, err)
+
+	assert.Len(t, items, 1)
+	assert.Equal(t, "item-00", items[0].ID)
+	assert.False(t, info.HasNext)
+	assert.True(t, info.HasPrev)
+	assert.Equal(t, 3, info.Page)
+	assert.Equal(t, 3, info.TotalPages)
+	assert.Equal(t, int64(7), *info.Total)
+}
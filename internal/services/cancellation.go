@@ -0,0 +1,419 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"tennis-platform/backend/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 取消政策所屬對象
+const (
+	CancellationOwnerCourt = "court"
+	CancellationOwnerCoach = "coach"
+)
+
+// 取消發起方
+const (
+	CancellationInitiatorCustomer = "customer" // 預訂人或學生
+	CancellationInitiatorProvider = "provider" // 教練或場地，取消一律全額退款
+)
+
+// 取消例外原因
+const (
+	CancellationOverrideWeather      = "weather"
+	CancellationOverrideForceMajeure = "force_majeure"
+)
+
+// 取消記錄的退款狀態
+const (
+	RefundStatusNone       = "none"
+	RefundStatusPending    = "pending"
+	RefundStatusProcessing = "processing"
+	RefundStatusSucceeded  = "succeeded"
+	RefundStatusFailed     = "failed"
+)
+
+// maxRefundAttempts 退款失敗後的最大重試次數，超過後需人工處理
+const maxRefundAttempts = 5
+
+// defaultCourtCutoffHours 場地默認政策的取消期限，沿用原本「預訂開始前 2 小時內不可取消」的規則
+const defaultCourtCutoffHours = 2
+
+// DefaultCancellationPolicy 返回未設置政策時使用的默認政策：期限前取消全額退款
+//
+// 場地沿用開始前 2 小時內不可取消的規則，課程原本沒有取消期限，默認可取消至開始。
+func DefaultCancellationPolicy(ownerType, ownerID string) *models.CancellationPolicy {
+	policy := &models.CancellationPolicy{
+		OwnerType: ownerType,
+		OwnerID:   ownerID,
+		Tiers:     models.CancellationTiers{{MinHoursBefore: 0, RefundPercent: 100}},
+	}
+	if ownerType == CancellationOwnerCourt {
+		policy.CutoffHours = defaultCourtCutoffHours
+	}
+	return policy
+}
+
+// CancellationTarget 要取消的預訂或課程
+type CancellationTarget struct {
+	TargetType string // booking, lesson
+	TargetID   string
+	OwnerType  string // 套用誰的取消政策
+	OwnerID    string
+	StartTime  time.Time
+	EndTime    time.Time
+	PaymentID  *string  // 已扣款的付款
+	AmountCap  *float64 // 付款涵蓋多個項目時此目標最多可退還的已付金額，例如整組付款的重複預訂
+	Currency   string
+}
+
+// CancellationQuote 取消報價，說明此刻取消是否允許及可退還的金額
+type CancellationQuote struct {
+	Allowed        bool      `json:"allowed"`
+	Initiator      string    `json:"initiator"`
+	PolicyID       *string   `json:"policyId"` // 為空表示使用默認政策
+	OverrideID     *string   `json:"overrideId"`
+	OverrideReason *string   `json:"overrideReason"`
+	HoursBefore    float64   `json:"hoursBefore"`
+	CutoffHours    float64   `json:"cutoffHours"`
+	RefundPercent  int       `json:"refundPercent"`
+	PaymentID      *string   `json:"paymentId"`
+	PaidAmount     float64   `json:"paidAmount"`
+	RefundAmount   float64   `json:"refundAmount"`
+	FeeAmount      float64   `json:"feeAmount"`
+	Currency       string    `json:"currency"`
+	QuotedAt       time.Time `json:"quotedAt"`
+}
+
+// CancellationService 取消政策服務
+//
+// 在取消時評估場地或教練的取消政策及天候、不可抗力例外，計算退款金額並記錄取消結果。
+// 退款在取消事務提交後才向付款服務商發起，失敗時由 Start 定期重試。
+type CancellationService struct {
+	db             *gorm.DB
+	paymentService *PaymentService
+	PollInterval   time.Duration // 重試退款的間隔
+	BatchSize      int           // 每次重試的退款數量
+}
+
+// NewCancellationService 創建新的取消政策服務，paymentService 為空時只記錄退款金額而不發起退款
+func NewCancellationService(db *gorm.DB, paymentService *PaymentService) *CancellationService {
+	return &CancellationService{
+		db:             db,
+		paymentService: paymentService,
+		PollInterval:   time.Minute,
+		BatchSize:      50,
+	}
+}
+
+// Policy 獲取取消政策，未設置時返回默認政策
+func (cs *CancellationService) Policy(ctx context.Context, ownerType, ownerID string) (*models.CancellationPolicy, error) {
+	var policy models.CancellationPolicy
+	err := cs.db.WithContext(ctx).Where("owner_type = ? AND owner_id = ?", ownerType, ownerID).First(&policy).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return DefaultCancellationPolicy(ownerType, ownerID), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load cancellation policy: %w", err)
+	}
+	return &policy, nil
+}
+
+// Quote 計算此刻取消的退款報價
+func (cs *CancellationService) Quote(ctx context.Context, target *CancellationTarget, initiator string, now time.Time) (*CancellationQuote, error) {
+	policy, err := cs.Policy(ctx, target.OwnerType, target.OwnerID)
+	if err != nil {
+		return nil, err
+	}
+	override, err := cs.findOverride(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+
+	hoursBefore := target.StartTime.Sub(now).Hours()
+	allowed, percent := evaluateCancellation(policy, override, initiator, hoursBefore)
+
+	quote := &CancellationQuote{
+		Allowed:       allowed,
+		Initiator:     initiator,
+		HoursBefore:   math.Round(hoursBefore*100) / 100,
+		CutoffHours:   policy.CutoffHours,
+		RefundPercent: percent,
+		Currency:      target.Currency,
+		QuotedAt:      now,
+	}
+	if policy.ID != "" {
+		quote.PolicyID = &policy.ID
+	}
+	if override != nil {
+		quote.OverrideID = &override.ID
+		quote.OverrideReason = &override.Reason
+	}
+
+	if target.PaymentID != nil {
+		var payment models.Payment
+		if err := cs.db.WithContext(ctx).Where("id = ?", *target.PaymentID).First(&payment).Error; err != nil {
+			return nil, fmt.Errorf("failed to load payment: %w", err)
+		}
+		if payment.Status == PaymentStatusCaptured || payment.Status == PaymentStatusPartiallyRefunded {
+			quote.PaymentID = &payment.ID
+			quote.PaidAmount = payment.Amount - payment.RefundedAmount
+			if target.AmountCap != nil && quote.PaidAmount > *target.AmountCap {
+				quote.PaidAmount = *target.AmountCap
+			}
+			if payment.Currency != "" {
+				quote.Currency = payment.Currency
+			}
+		}
+	}
+
+	if quote.Currency == "" {
+		quote.Currency = "TWD"
+	}
+	quote.RefundAmount = math.Round(quote.PaidAmount*float64(percent)) / 100
+	quote.FeeAmount = math.Round((quote.PaidAmount-quote.RefundAmount)*100) / 100
+	return quote, nil
+}
+
+// evaluateCancellation 依政策、例外及發起方決定是否允許取消及退款比例
+//
+// 提供方取消一律全額退款；例外時段內一律允許取消並依例外比例退款；
+// 其餘情況在取消期限內不允許取消，否則套用門檻最高且已達到的級距，沒有符合的級距時不退款。
+func evaluateCancellation(policy *models.CancellationPolicy, override *models.CancellationOverride, initiator string, hoursBefore float64) (bool, int) {
+	if initiator == CancellationInitiatorProvider {
+		return true, 100
+	}
+	if override != nil {
+		return true, override.RefundPercent
+	}
+	if hoursBefore <= 0 || hoursBefore < policy.CutoffHours {
+		return false, 0
+	}
+
+	tiers := make(models.CancellationTiers, len(policy.Tiers))
+	copy(tiers, policy.Tiers)
+	sort.Slice(tiers, func(i, j int) bool { return tiers[i].MinHoursBefore > tiers[j].MinHoursBefore })
+	for _, tier := range tiers {
+		if hoursBefore >= tier.MinHoursBefore {
+			return true, tier.RefundPercent
+		}
+	}
+	return true, 0
+}
+
+// findOverride 查找與目標時段重疊的例外，有多個時取退款比例最高者
+func (cs *CancellationService) findOverride(ctx context.Context, target *CancellationTarget) (*models.CancellationOverride, error) {
+	var override models.CancellationOverride
+	err := cs.db.WithContext(ctx).
+		Where("owner_type = ? AND owner_id = ? AND start_time < ? AND end_time > ?",
+			target.OwnerType, target.OwnerID, target.EndTime, target.StartTime).
+		Order("refund_percent DESC").
+		First(&override).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load cancellation override: %w", err)
+	}
+	return &override, nil
+}
+
+// Record 在取消事務中保存取消記錄，需要退款時標記為 pending 待事務提交後 Settle
+func (cs *CancellationService) Record(tx *gorm.DB, target *CancellationTarget, quote *CancellationQuote, cancelledBy string, reason *string) (*models.Cancellation, error) {
+	cancellation := models.Cancellation{
+		TargetType:    target.TargetType,
+		TargetID:      target.TargetID,
+		PaymentID:     quote.PaymentID,
+		CancelledBy:   cancelledBy,
+		Initiator:     quote.Initiator,
+		Reason:        reason,
+		PolicyID:      quote.PolicyID,
+		OverrideID:    quote.OverrideID,
+		HoursBefore:   quote.HoursBefore,
+		RefundPercent: quote.RefundPercent,
+		PaidAmount:    quote.PaidAmount,
+		RefundAmount:  quote.RefundAmount,
+		FeeAmount:     quote.FeeAmount,
+		Currency:      quote.Currency,
+		RefundStatus:  RefundStatusNone,
+	}
+	if quote.PaymentID != nil && quote.RefundAmount > 0 {
+		cancellation.RefundStatus = RefundStatusPending
+	}
+
+	if err := tx.Create(&cancellation).Error; err != nil {
+		return nil, fmt.Errorf("failed to record cancellation: %w", err)
+	}
+	return &cancellation, nil
+}
+
+// ReleasePayments 取消目標尚未扣款的付款，避免付款人為已取消的項目完成付款
+func (cs *CancellationService) ReleasePayments(ctx context.Context, targetType, targetID string) {
+	if cs.paymentService == nil {
+		return
+	}
+
+	var payments []models.Payment
+	if err := cs.db.WithContext(ctx).
+		Where("target_type = ? AND target_id = ? AND status IN ?", targetType, targetID,
+			[]string{PaymentStatusPending, PaymentStatusAuthorized}).
+		Find(&payments).Error; err != nil {
+		log.Printf("Failed to load payments of cancelled %s %s: %v", targetType, targetID, err)
+		return
+	}
+	for i := range payments {
+		if err := cs.paymentService.Cancel(ctx, &payments[i]); err != nil {
+			log.Printf("Failed to cancel payment %s of cancelled %s %s: %v", payments[i].ID, targetType, targetID, err)
+		}
+	}
+}
+
+// VoidPayments 在事務中作廢目標尚未扣款的付款，用於目標金額變更後舊金額的付款不能再扣款
+// 返回作廢的付款，事務提交後以 CancelVoidedPayments 取消服務商的付款意圖
+func (cs *CancellationService) VoidPayments(tx *gorm.DB, targetType, targetID string) ([]models.Payment, error) {
+	open := []string{PaymentStatusPending, PaymentStatusAuthorized}
+	var payments []models.Payment
+	if err := tx.Where("target_type = ? AND target_id = ? AND status IN ?", targetType, targetID, open).
+		Find(&payments).Error; err != nil {
+		return nil, fmt.Errorf("failed to load open payments: %w", err)
+	}
+	if len(payments) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]string, len(payments))
+	for i := range payments {
+		ids[i] = payments[i].ID
+	}
+	if err := tx.Model(&models.Payment{}).
+		Where("id IN ? AND status IN ?", ids, open).
+		Updates(map[string]interface{}{
+			"status":       PaymentStatusCancelled,
+			"cancelled_at": time.Now(),
+		}).Error; err != nil {
+		return nil, fmt.Errorf("failed to void payments: %w", err)
+	}
+	return payments, nil
+}
+
+// CancelVoidedPayments 取消已作廢付款在服務商的付款意圖
+// 並發完成的扣款無法取消，確認目標時因金額不符而自動退款
+func (cs *CancellationService) CancelVoidedPayments(ctx context.Context, payments []models.Payment) {
+	if cs.paymentService == nil {
+		return
+	}
+	for i := range payments {
+		if err := cs.paymentService.Provider().Cancel(ctx, payments[i].ProviderPaymentID); err != nil {
+			log.Printf("Failed to cancel voided payment %s: %v", payments[i].ID, err)
+		}
+	}
+}
+
+// Settle 依取消記錄向付款服務商退款並更新退款狀態
+//
+// 先以條件更新將記錄標記為 processing，並發的重試只會有一個發起退款；
+// 處理中斷而停留在 processing 的記錄不會自動重試，以免重複退款。
+func (cs *CancellationService) Settle(ctx context.Context, cancellation *models.Cancellation) error {
+	if cs.paymentService == nil || cancellation.PaymentID == nil {
+		return nil
+	}
+	if cancellation.RefundStatus != RefundStatusPending && cancellation.RefundStatus != RefundStatusFailed {
+		return nil
+	}
+
+	db := cs.db.WithContext(ctx)
+	result := db.Model(&models.Cancellation{}).
+		Where("id = ? AND refund_status = ?", cancellation.ID, cancellation.RefundStatus).
+		Updates(map[string]interface{}{
+			"refund_status":   RefundStatusProcessing,
+			"refund_attempts": gorm.Expr("refund_attempts + 1"),
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to claim refund: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil
+	}
+	cancellation.RefundStatus = RefundStatusProcessing
+	cancellation.RefundAttempts++
+
+	refundErr := cs.refund(ctx, cancellation)
+
+	updates := map[string]interface{}{"refund_status": RefundStatusSucceeded, "refund_error": nil}
+	if refundErr != nil {
+		message := refundErr.Error()
+		updates["refund_status"] = RefundStatusFailed
+		updates["refund_error"] = message
+		cancellation.RefundError = &message
+	} else {
+		cancellation.RefundError = nil
+	}
+	cancellation.RefundStatus = updates["refund_status"].(string)
+
+	if err := db.Model(&models.Cancellation{}).Where("id = ?", cancellation.ID).Updates(updates).Error; err != nil {
+		return fmt.Errorf("failed to update refund status: %w", err)
+	}
+	return refundErr
+}
+
+// refund 向付款服務商退還取消記錄的退款金額
+func (cs *CancellationService) refund(ctx context.Context, cancellation *models.Cancellation) error {
+	var payment models.Payment
+	if err := cs.db.WithContext(ctx).Where("id = ?", *cancellation.PaymentID).First(&payment).Error; err != nil {
+		return fmt.Errorf("failed to load payment: %w", err)
+	}
+	return cs.paymentService.Refund(ctx, &payment, cancellation.RefundAmount)
+}
+
+// Start 啟動退款重試循環，直到 ctx 結束
+func (cs *CancellationService) Start(ctx context.Context) {
+	ticker := time.NewTicker(cs.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := cs.RetryRefunds(ctx); err != nil {
+			log.Printf("Cancellation refund retry error: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RetryRefunds 重試待處理及失敗的退款，返回成功數量
+func (cs *CancellationService) RetryRefunds(ctx context.Context) (int, error) {
+	if cs.paymentService == nil {
+		return 0, nil
+	}
+
+	var cancellations []models.Cancellation
+	if err := cs.db.WithContext(ctx).
+		Where("refund_status IN ? AND refund_attempts < ?", []string{RefundStatusPending, RefundStatusFailed}, maxRefundAttempts).
+		Order("created_at").
+		Limit(cs.BatchSize).
+		Find(&cancellations).Error; err != nil {
+		return 0, fmt.Errorf("failed to load pending refunds: %w", err)
+	}
+
+	settled := 0
+	for i := range cancellations {
+		if err := cs.Settle(ctx, &cancellations[i]); err != nil {
+			log.Printf("Failed to refund cancellation %s: %v", cancellations[i].ID, err)
+			continue
+		}
+		if cancellations[i].RefundStatus == RefundStatusSucceeded {
+			settled++
+		}
+	}
+	return settled, nil
+}
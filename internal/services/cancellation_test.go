@@ -0,0 +1,215 @@
+package services
+
+import (
+	"context"
+	"tennis-platform/backend/internal/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func setupCancellationTestDB(t *testing.T) *gorm.DB {
+	db := setupPaymentTestDB(t)
+
+	// 手動創建表結構，只包含取消流程需要的欄位
+	for _, stmt := range []string{
+		`CREATE TABLE cancellation_policies (id TEXT PRIMARY KEY, owner_type TEXT NOT NULL, owner_id TEXT NOT NULL, tiers TEXT NOT NULL, cutoff_hours REAL NOT NULL DEFAULT 0, created_at DATETIME, updated_at DATETIME)`,
+		`CREATE TABLE cancellation_overrides (id TEXT PRIMARY KEY, owner_type TEXT NOT NULL, owner_id TEXT NOT NULL, start_time DATETIME NOT NULL, end_time DATETIME NOT NULL, reason TEXT NOT NULL, refund_percent INTEGER NOT NULL DEFAULT 100, note TEXT, created_by TEXT NOT NULL, created_at DATETIME)`,
+		`CREATE TABLE cancellations (id TEXT PRIMARY KEY, target_type TEXT NOT NULL, target_id TEXT NOT NULL, payment_id TEXT, cancelled_by TEXT NOT NULL, initiator TEXT NOT NULL, reason TEXT, policy_id TEXT, override_id TEXT, hours_before REAL, refund_percent INTEGER, paid_amount REAL NOT NULL DEFAULT 0, refund_amount REAL NOT NULL DEFAULT 0, fee_amount REAL NOT NULL DEFAULT 0, currency TEXT NOT NULL DEFAULT 'TWD', refund_status TEXT NOT NULL DEFAULT 'none', refund_error TEXT, refund_attempts INTEGER NOT NULL DEFAULT 0, created_at DATETIME, updated_at DATETIME)`,
+	} {
+		require.NoError(t, db.Exec(stmt).Error)
+	}
+	return db
+}
+
+// createCapturedPayment 建立已扣款的付款
+func createCapturedPayment(t *testing.T, db *gorm.DB, provider *LocalPaymentProvider, targetID string, amount float64) *models.Payment {
+	ctx := context.Background()
+	intent, err := provider.CreateIntent(ctx, PaymentIntentParams{Amount: amount, Currency: "TWD"})
+	require.NoError(t, err)
+	require.NoError(t, provider.Capture(ctx, intent.ProviderPaymentID))
+
+	payment := &models.Payment{
+		UserID:            "user-1",
+		TargetType:        PaymentTargetBooking,
+		TargetID:          targetID,
+		Amount:            amount,
+		Currency:          "TWD",
+		Status:            PaymentStatusCaptured,
+		Provider:          provider.Name(),
+		ProviderPaymentID: intent.ProviderPaymentID,
+	}
+	require.NoError(t, db.Create(payment).Error)
+	return payment
+}
+
+func TestEvaluateCancellation(t *testing.T) {
+	policy := &models.CancellationPolicy{
+		Tiers: models.CancellationTiers{
+			{MinHoursBefore: 2, RefundPercent: 50},
+			{MinHoursBefore: 24, RefundPercent: 100},
+		},
+		CutoffHours: 1,
+	}
+	weather := &models.CancellationOverride{Reason: CancellationOverrideWeather, RefundPercent: 80}
+
+	tests := []struct {
+		name        string
+		override    *models.CancellationOverride
+		initiator   string
+		hoursBefore float64
+		allowed     bool
+		percent     int
+	}{
+		{"超過 24 小時全額退款", nil, CancellationInitiatorCustomer, 30, true, 100},
+		{"剛好 24 小時全額退款", nil, CancellationInitiatorCustomer, 24, true, 100},
+		{"24 小時內退一半", nil, CancellationInitiatorCustomer, 10, true, 50},
+		{"2 小時內不退款", nil, CancellationInitiatorCustomer, 1.5, true, 0},
+		{"取消期限內不可取消", nil, CancellationInitiatorCustomer, 0.5, false, 0},
+		{"已開始不可取消", nil, CancellationInitiatorCustomer, -1, false, 0},
+		{"天候例外不受期限限制", weather, CancellationInitiatorCustomer, 0.5, true, 80},
+		{"提供方取消全額退款", weather, CancellationInitiatorProvider, -1, true, 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			allowed, percent := evaluateCancellation(policy, tt.override, tt.initiator, tt.hoursBefore)
+			assert.Equal(t, tt.allowed, allowed)
+			assert.Equal(t, tt.percent, percent)
+		})
+	}
+
+	// 場地默認政策沿用開始前 2 小時內不可取消
+	allowed, percent := evaluateCancellation(DefaultCancellationPolicy(CancellationOwnerCourt, "court-1"), nil, CancellationInitiatorCustomer, 3)
+	assert.True(t, allowed)
+	assert.Equal(t, 100, percent)
+	allowed, _ = evaluateCancellation(DefaultCancellationPolicy(CancellationOwnerCourt, "court-1"), nil, CancellationInitiatorCustomer, 1.9)
+	assert.False(t, allowed)
+
+	// 課程原本沒有取消期限
+	allowed, percent = evaluateCancellation(DefaultCancellationPolicy(CancellationOwnerCoach, "coach-1"), nil, CancellationInitiatorCustomer, 0.5)
+	assert.True(t, allowed)
+	assert.Equal(t, 100, percent)
+}
+
+func TestCancellationService_QuoteAndSettle(t *testing.T) {
+	db := setupCancellationTestDB(t)
+	provider := NewLocalPaymentProvider("secret")
+	service := NewCancellationService(db, NewPaymentService(db, provider, nil, 0))
+	ctx := context.Background()
+
+	require.NoError(t, db.Create(&models.CancellationPolicy{
+		OwnerType:   CancellationOwnerCourt,
+		OwnerID:     "court-1",
+		Tiers:       models.CancellationTiers{{MinHoursBefore: 0, RefundPercent: 0}, {MinHoursBefore: 2, RefundPercent: 50}, {MinHoursBefore: 24, RefundPercent: 100}},
+		CutoffHours: 0,
+	}).Error)
+
+	payment := createCapturedPayment(t, db, provider, "booking-1", 999)
+	now := time.Now()
+	target := &CancellationTarget{
+		TargetType: PaymentTargetBooking,
+		TargetID:   "booking-1",
+		OwnerType:  CancellationOwnerCourt,
+		OwnerID:    "court-1",
+		StartTime:  now.Add(10 * time.Hour),
+		EndTime:    now.Add(12 * time.Hour),
+		PaymentID:  &payment.ID,
+	}
+
+	quote, err := service.Quote(ctx, target, CancellationInitiatorCustomer, now)
+	require.NoError(t, err)
+	assert.True(t, quote.Allowed)
+	require.NotNil(t, quote.PolicyID)
+	assert.Nil(t, quote.OverrideID)
+	assert.Equal(t, 50, quote.RefundPercent)
+	assert.InDelta(t, 999, quote.PaidAmount, 0.001)
+	assert.InDelta(t, 499.5, quote.RefundAmount, 0.001)
+	assert.InDelta(t, 499.5, quote.FeeAmount, 0.001)
+	assert.Equal(t, "TWD", quote.Currency)
+
+	cancellation, err := service.Record(db, target, quote, "user-1", nil)
+	require.NoError(t, err)
+	assert.Equal(t, RefundStatusPending, cancellation.RefundStatus)
+
+	require.NoError(t, service.Settle(ctx, cancellation))
+	assert.Equal(t, RefundStatusSucceeded, cancellation.RefundStatus)
+
+	var stored models.Cancellation
+	require.NoError(t, db.First(&stored, "id = ?", cancellation.ID).Error)
+	assert.Equal(t, RefundStatusSucceeded, stored.RefundStatus)
+	assert.Equal(t, 1, stored.RefundAttempts)
+
+	var refunded models.Payment
+	require.NoError(t, db.First(&refunded, "id = ?", payment.ID).Error)
+	assert.Equal(t, PaymentStatusPartiallyRefunded, refunded.Status)
+	assert.InDelta(t, 499.5, refunded.RefundedAmount, 0.001)
+
+	// 已完成的退款不會重複發起
+	require.NoError(t, service.Settle(ctx, &stored))
+	settled, err := service.RetryRefunds(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, settled)
+	require.NoError(t, db.First(&refunded, "id = ?", payment.ID).Error)
+	assert.InDelta(t, 499.5, refunded.RefundedAmount, 0.001)
+}
+
+func TestCancellationService_OverrideAndRetry(t *testing.T) {
+	db := setupCancellationTestDB(t)
+	provider := NewLocalPaymentProvider("secret")
+	service := NewCancellationService(db, NewPaymentService(db, provider, nil, 0))
+	ctx := context.Background()
+
+	now := time.Now()
+	require.NoError(t, db.Create(&models.CancellationOverride{
+		OwnerType:     CancellationOwnerCourt,
+		OwnerID:       "court-1",
+		StartTime:     now,
+		EndTime:       now.Add(6 * time.Hour),
+		Reason:        CancellationOverrideWeather,
+		RefundPercent: 100,
+		CreatedBy:     "owner-1",
+	}).Error)
+
+	payment := createCapturedPayment(t, db, provider, "booking-1", 800)
+	target := &CancellationTarget{
+		TargetType: PaymentTargetBooking,
+		TargetID:   "booking-1",
+		OwnerType:  CancellationOwnerCourt,
+		OwnerID:    "court-1",
+		StartTime:  now.Add(time.Hour),
+		EndTime:    now.Add(2 * time.Hour),
+		PaymentID:  &payment.ID,
+	}
+
+	// 默認政策在 2 小時內不可取消，天候例外仍允許並全額退款
+	quote, err := service.Quote(ctx, target, CancellationInitiatorCustomer, now)
+	require.NoError(t, err)
+	assert.True(t, quote.Allowed)
+	assert.Nil(t, quote.PolicyID)
+	require.NotNil(t, quote.OverrideReason)
+	assert.Equal(t, CancellationOverrideWeather, *quote.OverrideReason)
+	assert.InDelta(t, 800, quote.RefundAmount, 0.001)
+
+	// 付款狀態被並發修改時退款失敗，記錄為 failed 並由重試完成
+	cancellation, err := service.Record(db, target, quote, "user-1", nil)
+	require.NoError(t, err)
+	require.NoError(t, db.Model(&models.Payment{}).Where("id = ?", payment.ID).Update("status", PaymentStatusPending).Error)
+	assert.Error(t, service.Settle(ctx, cancellation))
+	assert.Equal(t, RefundStatusFailed, cancellation.RefundStatus)
+	require.NotNil(t, cancellation.RefundError)
+
+	require.NoError(t, db.Model(&models.Payment{}).Where("id = ?", payment.ID).Update("status", PaymentStatusCaptured).Error)
+	settled, err := service.RetryRefunds(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, settled)
+
+	var stored models.Cancellation
+	require.NoError(t, db.First(&stored, "id = ?", cancellation.ID).Error)
+	assert.Equal(t, RefundStatusSucceeded, stored.RefundStatus)
+	assert.Nil(t, stored.RefundError)
+	assert.Equal(t, 2, stored.RefundAttempts)
+}
@@ -0,0 +1,88 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"tennis-platform/backend/internal/apperror"
+	"tennis-platform/backend/internal/models"
+	"time"
+)
+
+// checkInPassVersion 報到碼格式版本，變更格式時遞增
+const checkInPassVersion = "cp1"
+
+// CheckInPass 預訂的報到碼，QR code 的內容為 Payload
+type CheckInPass struct {
+	BookingID string
+	Payload   string
+	NotBefore time.Time
+	ExpiresAt time.Time
+}
+
+// CheckInSigner 簽發及驗證預訂的報到碼
+//
+// 報到碼的格式為 cp1.{bookingId}.{notBefore}.{expiresAt}.{signature}，時間為 Unix 秒，
+// 簽名為 HMAC-SHA256(secret, "{bookingId}.{notBefore}.{expiresAt}")。
+// 有效期間為預訂開始前 OpensBefore 至預訂結束，預訂改期後舊的報到碼失效。
+type CheckInSigner struct {
+	secret      []byte
+	OpensBefore time.Duration
+}
+
+// NewCheckInSigner 創建新的報到碼簽發服務
+func NewCheckInSigner(secret string, opensBefore time.Duration) *CheckInSigner {
+	return &CheckInSigner{secret: []byte(secret), OpensBefore: opensBefore}
+}
+
+// Issue 簽發預訂目前時段的報到碼
+func (s *CheckInSigner) Issue(booking *models.Booking) *CheckInPass {
+	notBefore := booking.StartTime.Add(-s.OpensBefore).Truncate(time.Second)
+	expiresAt := booking.EndTime.Truncate(time.Second)
+	message := fmt.Sprintf("%s.%d.%d", booking.ID, notBefore.Unix(), expiresAt.Unix())
+	return &CheckInPass{
+		BookingID: booking.ID,
+		Payload:   checkInPassVersion + "." + message + "." + s.sign(message),
+		NotBefore: notBefore,
+		ExpiresAt: expiresAt,
+	}
+}
+
+// Parse 驗證報到碼的簽名並返回內容，不檢查有效期間；格式或簽名不符時返回 CodeCheckInInvalidPass
+func (s *CheckInSigner) Parse(payload string) (*CheckInPass, error) {
+	parts := strings.Split(strings.TrimSpace(payload), ".")
+	if len(parts) != 5 || parts[0] != checkInPassVersion {
+		return nil, apperror.New(apperror.CodeCheckInInvalidPass)
+	}
+
+	message := strings.Join(parts[1:4], ".")
+	if !hmac.Equal([]byte(s.sign(message)), []byte(parts[4])) {
+		return nil, apperror.New(apperror.CodeCheckInInvalidPass)
+	}
+
+	notBefore, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return nil, apperror.New(apperror.CodeCheckInInvalidPass)
+	}
+	expiresAt, err := strconv.ParseInt(parts[3], 10, 64)
+	if err != nil {
+		return nil, apperror.New(apperror.CodeCheckInInvalidPass)
+	}
+
+	return &CheckInPass{
+		BookingID: parts[1],
+		Payload:   payload,
+		NotBefore: time.Unix(notBefore, 0),
+		ExpiresAt: time.Unix(expiresAt, 0),
+	}, nil
+}
+
+// sign 計算簽名
+func (s *CheckInSigner) sign(message string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(message))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,237 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+	"tennis-platform/backend/internal/ical"
+	"tennis-platform/backend/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// MaxCoachCalendarSize 外部行事曆內容的大小上限
+const MaxCoachCalendarSize = 5 * 1024 * 1024
+
+// errPrivateAddress 訂閱網址指向內部網路
+var errPrivateAddress = errors.New("calendar url resolves to a private address")
+
+// CoachCalendarService 教練外部行事曆同步服務
+//
+// 定期下載 ICS 訂閱網址（上傳的文件則使用保存的內容），展開重複事件後整批替換該來源的忙碌時段。
+// 同步失敗時保留上次的忙碌時段並記錄錯誤，避免暫時無法連線時教練的時間被誤判為空閒。
+type CoachCalendarService struct {
+	db           *gorm.DB
+	client       *http.Client
+	PollInterval time.Duration // 檢查到期來源的間隔
+	SyncInterval time.Duration // 每個來源的同步間隔
+	Lookback     time.Duration // 保留現在之前多久的忙碌時段
+	Horizon      time.Duration // 展開到現在之後多久
+	BatchSize    int           // 每次檢查同步的來源數量
+}
+
+// NewCoachCalendarService 創建新的教練外部行事曆同步服務
+func NewCoachCalendarService(db *gorm.DB) *CoachCalendarService {
+	dialer := &net.Dialer{
+		Timeout: 10 * time.Second,
+		// 訂閱網址由教練填寫，禁止連線到內部網路
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+				ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() {
+				return errPrivateAddress
+			}
+			return nil
+		},
+	}
+
+	return &CoachCalendarService{
+		db: db,
+		client: &http.Client{
+			Timeout:   30 * time.Second,
+			Transport: &http.Transport{DialContext: dialer.DialContext, Proxy: http.ProxyFromEnvironment},
+		},
+		PollInterval: time.Minute,
+		SyncInterval: 30 * time.Minute,
+		Lookback:     24 * time.Hour,
+		Horizon:      180 * 24 * time.Hour,
+		BatchSize:    20,
+	}
+}
+
+// Start 啟動同步循環，直到 ctx 結束
+func (cs *CoachCalendarService) Start(ctx context.Context) {
+	ticker := time.NewTicker(cs.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := cs.SyncDue(ctx); err != nil {
+			log.Printf("Coach calendar sync error: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// SyncDue 同步一批到期的來源，返回本次處理數量
+func (cs *CoachCalendarService) SyncDue(ctx context.Context) (int, error) {
+	var sources []models.CoachCalendarSource
+	if err := cs.db.WithContext(ctx).
+		Where("next_sync_at <= ?", time.Now()).
+		Order("next_sync_at ASC").
+		Limit(cs.BatchSize).
+		Find(&sources).Error; err != nil {
+		return 0, fmt.Errorf("failed to load coach calendar sources: %w", err)
+	}
+
+	for i := range sources {
+		if err := cs.Sync(ctx, &sources[i]); err != nil {
+			log.Printf("Failed to sync coach calendar %s: %v", sources[i].ID, err)
+		}
+	}
+
+	return len(sources), nil
+}
+
+// Sync 同步單一來源並更新同步狀態，失敗原因同時記錄在 source.LastError
+func (cs *CoachCalendarService) Sync(ctx context.Context, source *models.CoachCalendarSource) error {
+	now := time.Now()
+	periods, syncErr := cs.load(ctx, source, now)
+
+	source.NextSyncAt = now.Add(cs.SyncInterval)
+	updates := map[string]interface{}{"next_sync_at": source.NextSyncAt}
+
+	if syncErr != nil {
+		message := syncErr.Error()
+		source.LastError = &message
+		updates["last_error"] = message
+		if err := cs.db.WithContext(ctx).Model(&models.CoachCalendarSource{}).Where("id = ?", source.ID).Updates(updates).Error; err != nil {
+			log.Printf("Failed to update coach calendar %s: %v", source.ID, err)
+		}
+		return syncErr
+	}
+
+	blocks := make([]models.CoachBusyBlock, 0, len(periods))
+	for _, period := range periods {
+		blocks = append(blocks, models.CoachBusyBlock{
+			SourceID:  source.ID,
+			CoachID:   source.CoachID,
+			StartTime: period.Start.UTC(),
+			EndTime:   period.End.UTC(),
+		})
+	}
+
+	source.BlockCount = len(blocks)
+	source.LastSyncedAt = &now
+	source.LastError = nil
+	updates["block_count"] = source.BlockCount
+	updates["last_synced_at"] = now
+	updates["last_error"] = nil
+
+	return cs.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("source_id = ?", source.ID).Delete(&models.CoachBusyBlock{}).Error; err != nil {
+			return fmt.Errorf("failed to delete coach busy blocks: %w", err)
+		}
+		if len(blocks) > 0 {
+			if err := tx.CreateInBatches(&blocks, 500).Error; err != nil {
+				return fmt.Errorf("failed to create coach busy blocks: %w", err)
+			}
+		}
+		if err := tx.Model(&models.CoachCalendarSource{}).Where("id = ?", source.ID).Updates(updates).Error; err != nil {
+			return fmt.Errorf("failed to update coach calendar source: %w", err)
+		}
+		return nil
+	})
+}
+
+// load 讀取來源內容並展開為忙碌時段
+func (cs *CoachCalendarService) load(ctx context.Context, source *models.CoachCalendarSource, now time.Time) ([]ical.Period, error) {
+	var content []byte
+	switch {
+	case source.Content != nil:
+		content = []byte(*source.Content)
+	case source.URL != nil:
+		var err error
+		if content, err = cs.fetch(ctx, *source.URL); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errors.New("calendar source has neither url nor content")
+	}
+
+	events, err := ical.Parse(bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+	return ical.Expand(events, now.Add(-cs.Lookback), now.Add(cs.Horizon)), nil
+}
+
+// fetch 下載訂閱網址的內容，webcal:// 視為 https://
+func (cs *CoachCalendarService) fetch(ctx context.Context, url string) ([]byte, error) {
+	if strings.HasPrefix(strings.ToLower(url), "webcal://") {
+		url = "https://" + url[len("webcal://"):]
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid calendar url: %w", err)
+	}
+	req.Header.Set("Accept", ical.ContentType)
+	req.Header.Set("User-Agent", "TennisPlatform-Calendar/1")
+
+	resp, err := cs.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("calendar request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("calendar url returned status %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, MaxCoachCalendarSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read calendar: %w", err)
+	}
+	if len(body) > MaxCoachCalendarSize {
+		return nil, fmt.Errorf("calendar exceeds %d MB", MaxCoachCalendarSize/(1024*1024))
+	}
+	return body, nil
+}
+
+// LoadCoachBusyBlocks 獲取教練外部行事曆中與 [from, to) 有交集的忙碌時段
+func LoadCoachBusyBlocks(db *gorm.DB, coachID string, from, to time.Time) ([]models.CoachBusyBlock, error) {
+	var blocks []models.CoachBusyBlock
+	if err := db.Where("coach_id = ? AND start_time < ? AND end_time > ?", coachID, to, from).
+		Order("start_time ASC").
+		Find(&blocks).Error; err != nil {
+		return nil, err
+	}
+	return blocks, nil
+}
+
+// OverlapsBusyBlock 檢查時間區間是否與任一忙碌時段重疊，首尾相接不算重疊
+func OverlapsBusyBlock(start, end time.Time, blocks []models.CoachBusyBlock) bool {
+	for _, block := range blocks {
+		if block.StartTime.Before(end) && block.EndTime.After(start) {
+			return true
+		}
+	}
+	return false
+}
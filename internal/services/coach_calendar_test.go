@@ -0,0 +1,118 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"tennis-platform/backend/internal/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+const testCoachID = "coach-1"
+
+func setupCoachCalendarTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+
+	// 手動創建表結構
+	for _, stmt := range []string{
+		`CREATE TABLE coach_calendar_sources (id TEXT PRIMARY KEY, coach_id TEXT NOT NULL, name TEXT NOT NULL, url TEXT, content TEXT, block_count INTEGER DEFAULT 0, last_synced_at DATETIME, last_error TEXT, next_sync_at DATETIME NOT NULL, created_at DATETIME, updated_at DATETIME)`,
+		`CREATE TABLE coach_busy_blocks (id TEXT PRIMARY KEY, source_id TEXT NOT NULL, coach_id TEXT NOT NULL, start_time DATETIME NOT NULL, end_time DATETIME NOT NULL)`,
+		`CREATE TABLE lesson_schedules (id TEXT PRIMARY KEY, coach_id TEXT, day_of_week INTEGER, start_time TEXT, end_time TEXT, is_active BOOLEAN, created_at DATETIME, updated_at DATETIME)`,
+		`CREATE TABLE lessons (id TEXT PRIMARY KEY, coach_id TEXT, duration INTEGER, scheduled_at DATETIME, status TEXT, deleted_at DATETIME)`,
+	} {
+		require.NoError(t, db.Exec(stmt).Error)
+	}
+	return db
+}
+
+// weeklyCalendar 每週一 10:00-11:30 (UTC) 的重複事件
+func weeklyCalendar(day time.Time) string {
+	return "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n" +
+		"BEGIN:VEVENT\r\nUID:other-club@example.com\r\n" +
+		"DTSTART:" + day.Add(10*time.Hour).Format("20060102T150405Z") + "\r\n" +
+		"DTEND:" + day.Add(11*time.Hour+30*time.Minute).Format("20060102T150405Z") + "\r\n" +
+		"RRULE:FREQ=WEEKLY\r\n" +
+		"END:VEVENT\r\nEND:VCALENDAR\r\n"
+}
+
+func TestCoachCalendarService_Sync(t *testing.T) {
+	db := setupCoachCalendarTestDB(t)
+	ctx := context.Background()
+
+	// 下一個星期一
+	monday := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 1)
+	for monday.Weekday() != time.Monday {
+		monday = monday.AddDate(0, 0, 1)
+	}
+
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		w.Write([]byte(weeklyCalendar(monday)))
+	}))
+	defer server.Close()
+
+	feedURL := server.URL + "/calendar.ics"
+	source := models.CoachCalendarSource{CoachID: testCoachID, Name: "其他球場", URL: &feedURL, NextSyncAt: time.Now()}
+	require.NoError(t, db.Create(&source).Error)
+
+	// 默認客戶端拒絕連線到內部網路
+	service := NewCoachCalendarService(db)
+	err := service.Sync(ctx, &source)
+	assert.ErrorIs(t, err, errPrivateAddress)
+	require.NotNil(t, source.LastError)
+
+	service.client = server.Client()
+	require.NoError(t, service.Sync(ctx, &source))
+	assert.Nil(t, source.LastError)
+	assert.NotNil(t, source.LastSyncedAt)
+	// 180 天內約 26 個星期一
+	assert.InDelta(t, 26, source.BlockCount, 1)
+
+	blocks, err := LoadCoachBusyBlocks(db, testCoachID, monday, monday.Add(24*time.Hour))
+	require.NoError(t, err)
+	require.Len(t, blocks, 1)
+	assert.True(t, blocks[0].StartTime.Equal(monday.Add(10*time.Hour)))
+
+	// 同步失敗時保留上次的忙碌時段
+	status = http.StatusInternalServerError
+	assert.Error(t, service.Sync(ctx, &source))
+	var count int64
+	db.Model(&models.CoachBusyBlock{}).Where("source_id = ?", source.ID).Count(&count)
+	assert.Equal(t, int64(source.BlockCount), count)
+
+	// 到期的來源由背景任務同步
+	status = http.StatusOK
+	db.Model(&models.CoachCalendarSource{}).Where("id = ?", source.ID).Update("next_sync_at", time.Now().Add(-time.Minute))
+	processed, err := service.SyncDue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, processed)
+	processed, err = service.SyncDue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, processed)
+}
+
+func TestIntelligentScheduling_AvailabilityExcludesBusyBlocks(t *testing.T) {
+	db := setupCoachCalendarTestDB(t)
+	monday := time.Date(2030, 5, 6, 0, 0, 0, 0, time.UTC)
+
+	require.NoError(t, db.Exec(`INSERT INTO lesson_schedules (id, coach_id, day_of_week, start_time, end_time, is_active) VALUES ('schedule-1', ?, 1, '09:00', '13:00', true)`, testCoachID).Error)
+	require.NoError(t, db.Exec(`INSERT INTO coach_busy_blocks (id, source_id, coach_id, start_time, end_time) VALUES ('block-1', 'source-1', ?, ?, ?)`,
+		testCoachID, monday.Add(10*time.Hour), monday.Add(11*time.Hour+30*time.Minute)).Error)
+
+	slots, err := NewIntelligentSchedulingService(db).getCoachAvailabilityForDate(testCoachID, monday)
+	require.NoError(t, err)
+
+	var starts []string
+	for _, slot := range slots {
+		starts = append(starts, slot.StartTime)
+	}
+	assert.Equal(t, []string{"09:00", "12:00"}, starts)
+}
@@ -4,6 +4,7 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"fmt"
+	"io"
 	"tennis-platform/backend/internal/config"
 
 	"gopkg.in/gomail.v2"
@@ -100,6 +101,28 @@ func (e *EmailService) SendEmail(to, subject, body string) error {
 	return e.dialer.DialAndSend(m)
 }
 
+// SendEmailWithAttachment 發送附帶文件的郵件
+func (e *EmailService) SendEmailWithAttachment(to, subject, body, fileName string, data []byte) error {
+	m := gomail.NewMessage()
+	m.SetHeader("From", "noreply@tennis-platform.com")
+	m.SetHeader("To", to)
+	m.SetHeader("Subject", subject)
+	m.SetBody("text/plain", body)
+	m.Attach(fileName, gomail.SetCopyFunc(func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	}))
+
+	// 在開發環境中，我們只是記錄郵件內容而不實際發送
+	if e.config.Env == "development" {
+		fmt.Printf("Email would be sent to %s with subject: %s\n", to, subject)
+		fmt.Printf("Attachment: %s (%d bytes)\n", fileName, len(data))
+		return nil
+	}
+
+	return e.dialer.DialAndSend(m)
+}
+
 // GenerateToken 生成隨機令牌
 func (e *EmailService) GenerateToken() (string, error) {
 	bytes := make([]byte, 32)
@@ -0,0 +1,248 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"tennis-platform/backend/internal/apperror"
+	"tennis-platform/backend/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AddOnItem 預訂時要租借的器材及數量
+type AddOnItem struct {
+	EquipmentID string
+	Quantity    int
+}
+
+// EquipmentService 租借器材服務
+//
+// 預訂的租借項目複製預訂的時間，庫存以重疊時段內仍有效的預訂同時租借的最大數量計算。
+// 同一場地的預訂在鎖定場地的事務中依序寫入，Reserve 在同一事務中檢查庫存，並發的租借不會超過庫存。
+// 取消的預訂不再佔用庫存，背景處理取消事件時 Return 將租借項目標記為已退回。
+type EquipmentService struct {
+	db *gorm.DB
+}
+
+// NewEquipmentService 創建新的租借器材服務
+func NewEquipmentService(db *gorm.DB) *EquipmentService {
+	return &EquipmentService{db: db}
+}
+
+// Prepare 驗證場地的器材可以租借並計算金額，同一器材的數量會合併
+func (s *EquipmentService) Prepare(ctx context.Context, courtID string, items []AddOnItem, start, end time.Time) ([]models.BookingAddOn, error) {
+	if len(items) == 0 {
+		return nil, nil
+	}
+
+	quantities := make(map[string]int, len(items))
+	var ids []string
+	for _, item := range items {
+		if _, ok := quantities[item.EquipmentID]; !ok {
+			ids = append(ids, item.EquipmentID)
+		}
+		quantities[item.EquipmentID] += item.Quantity
+	}
+
+	var equipment []models.CourtEquipment
+	if err := s.db.WithContext(ctx).Where("court_id = ? AND id IN ?", courtID, ids).Find(&equipment).Error; err != nil {
+		return nil, fmt.Errorf("獲取租借器材失敗: %w", err)
+	}
+	byID := make(map[string]*models.CourtEquipment, len(equipment))
+	for i := range equipment {
+		byID[equipment[i].ID] = &equipment[i]
+	}
+
+	addOns := make([]models.BookingAddOn, 0, len(ids))
+	for _, id := range ids {
+		item, ok := byID[id]
+		if !ok {
+			return nil, apperror.New(apperror.CodeEquipmentNotFound)
+		}
+		if !item.IsActive {
+			return nil, apperror.New(apperror.CodeEquipmentUnavailable).With("name", item.Name)
+		}
+		if quantities[id] > item.Quantity {
+			return nil, apperror.New(apperror.CodeEquipmentInsufficient).With("name", item.Name).With("available", item.Quantity)
+		}
+		addOns = append(addOns, models.BookingAddOn{
+			EquipmentID: item.ID,
+			Name:        item.Name,
+			Quantity:    quantities[id],
+			UnitPrice:   item.Price,
+			PriceUnit:   item.PriceUnit,
+		})
+	}
+	return Reschedule(addOns, start, end), nil
+}
+
+// Reschedule 以新的預訂時間重新計算租借項目的金額，沿用租借時的單價
+func Reschedule(addOns []models.BookingAddOn, start, end time.Time) []models.BookingAddOn {
+	rescheduled := make([]models.BookingAddOn, len(addOns))
+	for i, addOn := range addOns {
+		amount := addOn.UnitPrice * float64(addOn.Quantity)
+		if addOn.PriceUnit == models.EquipmentPricePerHour {
+			amount *= end.Sub(start).Hours()
+		}
+		rescheduled[i] = models.BookingAddOn{
+			EquipmentID: addOn.EquipmentID,
+			Name:        addOn.Name,
+			Quantity:    addOn.Quantity,
+			UnitPrice:   addOn.UnitPrice,
+			PriceUnit:   addOn.PriceUnit,
+			Amount:      math.Round(amount*100) / 100,
+			StartTime:   start,
+			EndTime:     end,
+			Status:      models.BookingAddOnReserved,
+		}
+	}
+	return rescheduled
+}
+
+// ApplyAddOns 將租借項目加入價格明細並計入總價
+func ApplyAddOns(breakdown *models.PriceBreakdown, addOns []models.BookingAddOn) {
+	breakdown.AddOns = nil
+	total := breakdown.Total
+	for _, addOn := range addOns {
+		breakdown.AddOns = append(breakdown.AddOns, models.PriceAddOn{
+			EquipmentID: addOn.EquipmentID,
+			Name:        addOn.Name,
+			Quantity:    addOn.Quantity,
+			UnitPrice:   addOn.UnitPrice,
+			PriceUnit:   addOn.PriceUnit,
+			Amount:      addOn.Amount,
+		})
+		total += addOn.Amount
+	}
+	breakdown.Total = math.Round(total*100) / 100
+}
+
+// Reserve 在寫入預訂的事務中檢查庫存並建立租借項目
+func (s *EquipmentService) Reserve(tx *gorm.DB, bookingID string, addOns []models.BookingAddOn) error {
+	for i := range addOns {
+		addOn := &addOns[i]
+
+		var item models.CourtEquipment
+		if err := tx.Where("id = ?", addOn.EquipmentID).First(&item).Error; err != nil {
+			return fmt.Errorf("獲取租借器材失敗: %w", err)
+		}
+		available, err := s.available(tx, &item, addOn.StartTime, addOn.EndTime, bookingID)
+		if err != nil {
+			return err
+		}
+		if addOn.Quantity > available {
+			return apperror.New(apperror.CodeEquipmentInsufficient).With("name", item.Name).With("available", available)
+		}
+
+		addOn.BookingID = bookingID
+		if err := tx.Create(addOn).Error; err != nil {
+			return fmt.Errorf("建立租借項目失敗: %w", err)
+		}
+	}
+	return nil
+}
+
+// Replace 以新的租借項目取代預訂原有的租借項目，用於改期或修改租借
+func (s *EquipmentService) Replace(tx *gorm.DB, bookingID string, addOns []models.BookingAddOn) error {
+	if err := tx.Where("booking_id = ? AND status = ?", bookingID, models.BookingAddOnReserved).
+		Delete(&models.BookingAddOn{}).Error; err != nil {
+		return fmt.Errorf("刪除租借項目失敗: %w", err)
+	}
+	return s.Reserve(tx, bookingID, addOns)
+}
+
+// Availability 計算場地各器材在時段內還可以租借的數量
+func (s *EquipmentService) Availability(ctx context.Context, equipment []models.CourtEquipment, start, end time.Time) (map[string]int, error) {
+	availability := make(map[string]int, len(equipment))
+	for i := range equipment {
+		available, err := s.available(s.db.WithContext(ctx), &equipment[i], start, end, "")
+		if err != nil {
+			return nil, err
+		}
+		availability[equipment[i].ID] = available
+	}
+	return availability, nil
+}
+
+// Return 預訂取消後將租借項目標記為已退回，已退回時不做任何事
+func (s *EquipmentService) Return(ctx context.Context, bookingID string) error {
+	now := time.Now()
+	return s.db.WithContext(ctx).Model(&models.BookingAddOn{}).
+		Where("booking_id = ? AND status = ?", bookingID, models.BookingAddOnReserved).
+		Updates(map[string]interface{}{
+			"status":      models.BookingAddOnReturned,
+			"returned_at": now,
+			"updated_at":  now,
+		}).Error
+}
+
+// available 計算器材在時段內扣除其他預訂同時租借的最大數量後的可租借數量
+//
+// 只計算待確認及已確認的預訂，取消的預訂在 Return 處理前也不會佔用庫存。
+func (s *EquipmentService) available(db *gorm.DB, item *models.CourtEquipment, start, end time.Time, excludeBookingID string) (int, error) {
+	query := db.Model(&models.BookingAddOn{}).
+		Select("booking_add_ons.quantity", "booking_add_ons.start_time", "booking_add_ons.end_time").
+		Joins("JOIN bookings ON bookings.id = booking_add_ons.booking_id").
+		Where("booking_add_ons.equipment_id = ? AND booking_add_ons.status = ? AND booking_add_ons.start_time < ? AND booking_add_ons.end_time > ?",
+			item.ID, models.BookingAddOnReserved, end, start).
+		Where("bookings.status IN (?, ?) AND bookings.deleted_at IS NULL", "pending", "confirmed")
+	if excludeBookingID != "" {
+		query = query.Where("booking_add_ons.booking_id <> ?", excludeBookingID)
+	}
+
+	var reservations []models.BookingAddOn
+	if err := query.Find(&reservations).Error; err != nil {
+		return 0, fmt.Errorf("查詢租借項目失敗: %w", err)
+	}
+
+	available := item.Quantity - peakQuantity(reservations)
+	if available < 0 {
+		available = 0
+	}
+	return available, nil
+}
+
+// peakQuantity 計算租借項目在同一時刻租借數量的最大值，結束時間等於另一項的開始時間不算重疊
+func peakQuantity(reservations []models.BookingAddOn) int {
+	type change struct {
+		at    time.Time
+		delta int
+	}
+	changes := make([]change, 0, len(reservations)*2)
+	for _, reservation := range reservations {
+		changes = append(changes,
+			change{at: reservation.StartTime, delta: reservation.Quantity},
+			change{at: reservation.EndTime, delta: -reservation.Quantity})
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		if !changes[i].at.Equal(changes[j].at) {
+			return changes[i].at.Before(changes[j].at)
+		}
+		return changes[i].delta < changes[j].delta
+	})
+
+	peak, current := 0, 0
+	for _, c := range changes {
+		current += c.delta
+		if current > peak {
+			peak = current
+		}
+	}
+	return peak
+}
+
+// RegisterEquipmentSubscribers 註冊租借器材的事件訂閱者，預訂取消時退回租借的器材
+func RegisterEquipmentSubscribers(bus *EventBus, equipmentService *EquipmentService) {
+	const subscriber = "equipment"
+
+	bus.Subscribe(EventBookingCancelled, subscriber, func(ctx context.Context, event *DomainEvent) error {
+		var payload BookingEventPayload
+		if err := event.Decode(&payload); err != nil {
+			return fmt.Errorf("failed to decode event payload: %w", err)
+		}
+		return equipmentService.Return(ctx, payload.BookingID)
+	})
+}
@@ -0,0 +1,284 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"tennis-platform/backend/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 領域事件類型
+const (
+	EventBookingCreated            = "booking.created"
+	EventBookingStatusChanged      = "booking.status_changed"
+	EventBookingCancelled          = "booking.cancelled"
+	EventWaitlistOffered           = "booking.waitlist_offered"
+	EventPaymentShareRequested     = "booking.payment_share_requested"
+	EventBookingAttendanceRecorded = "booking.attendance_recorded"
+	EventPaymentCaptured           = "payment.captured"
+	EventPaymentRefunded           = "payment.refunded"
+	EventLessonCancelled           = "lesson.cancelled"
+	EventRegistrationConfirmed     = "club_event.registration_confirmed"
+	EventRegistrationCancelled     = "club_event.registration_cancelled"
+	EventMatchResultConfirmed      = "match_result.confirmed"
+	EventCardMatched               = "card.matched"
+)
+
+// 發件箱事件狀態
+const (
+	OutboxStatusPending    = "pending"
+	OutboxStatusDispatched = "dispatched"
+	OutboxStatusFailed     = "failed"
+)
+
+// DomainEvent 領域事件
+type DomainEvent struct {
+	ID            string          `json:"id"`
+	Type          string          `json:"type"`
+	AggregateType string          `json:"aggregateType"`
+	AggregateID   string          `json:"aggregateId"`
+	Payload       json.RawMessage `json:"payload"`
+	OccurredAt    time.Time       `json:"occurredAt"`
+}
+
+// Decode 將事件內容解析到指定結構
+func (e *DomainEvent) Decode(v interface{}) error {
+	return json.Unmarshal(e.Payload, v)
+}
+
+// EventHandler 事件處理函數
+type EventHandler func(ctx context.Context, event *DomainEvent) error
+
+// eventSubscription 事件訂閱
+type eventSubscription struct {
+	name    string
+	handler EventHandler
+}
+
+// EventBus 進程內領域事件總線
+//
+// 事件由業務事務寫入 outbox_events 表，再由派送循環至少一次地投遞給訂閱者；
+// 每個訂閱者處理成功後寫入 processed_events，重新投遞時據此去重。
+type EventBus struct {
+	db           *gorm.DB
+	subscribers  map[string][]eventSubscription
+	notify       chan struct{}
+	mu           sync.RWMutex
+	PollInterval time.Duration // 輪詢間隔
+	BatchSize    int           // 每批派送數量
+	MaxAttempts  int           // 最大嘗試次數，超過後標記為 failed
+	LeaseTimeout time.Duration // 領取事件後的租約時間，逾時未完成將重新派送
+}
+
+// NewEventBus 創建新的事件總線
+func NewEventBus(db *gorm.DB) *EventBus {
+	return &EventBus{
+		db:           db,
+		subscribers:  make(map[string][]eventSubscription),
+		notify:       make(chan struct{}, 1),
+		PollInterval: 2 * time.Second,
+		BatchSize:    50,
+		MaxAttempts:  10,
+		LeaseTimeout: time.Minute,
+	}
+}
+
+// Subscribe 訂閱事件，name 作為去重鍵，同一事件類型下需唯一
+func (eb *EventBus) Subscribe(eventType, name string, handler EventHandler) {
+	eb.mu.Lock()
+	defer eb.mu.Unlock()
+
+	eb.subscribers[eventType] = append(eb.subscribers[eventType], eventSubscription{
+		name:    name,
+		handler: handler,
+	})
+}
+
+// Publish 在給定事務中寫入發件箱，與業務數據一併提交
+func (eb *EventBus) Publish(tx *gorm.DB, eventType, aggregateType, aggregateID string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event payload: %w", err)
+	}
+
+	event := models.OutboxEvent{
+		EventType:     eventType,
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		Payload:       data,
+		Status:        OutboxStatusPending,
+		AvailableAt:   time.Now(),
+	}
+
+	if err := tx.Create(&event).Error; err != nil {
+		return fmt.Errorf("failed to write outbox event: %w", err)
+	}
+
+	return nil
+}
+
+// Notify 通知派送循環盡快處理（應在事務提交後呼叫）
+func (eb *EventBus) Notify() {
+	select {
+	case eb.notify <- struct{}{}:
+	default:
+	}
+}
+
+// Start 啟動派送循環，直到 ctx 結束
+func (eb *EventBus) Start(ctx context.Context) {
+	ticker := time.NewTicker(eb.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := eb.DispatchPending(ctx); err != nil {
+			log.Printf("Event dispatch error: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-eb.notify:
+		}
+	}
+}
+
+// DispatchPending 派送一批到期的待處理事件，返回本次處理的事件數
+func (eb *EventBus) DispatchPending(ctx context.Context) (int, error) {
+	events, err := eb.claimBatch(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := range events {
+		eb.dispatch(ctx, &events[i])
+	}
+
+	return len(events), nil
+}
+
+// claimBatch 領取一批事件並延後其可用時間作為租約，避免多個實例重複處理
+func (eb *EventBus) claimBatch(ctx context.Context) ([]models.OutboxEvent, error) {
+	var events []models.OutboxEvent
+
+	err := eb.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND available_at <= ?", OutboxStatusPending, now).
+			Order("created_at ASC").
+			Limit(eb.BatchSize).
+			Find(&events).Error; err != nil {
+			return err
+		}
+
+		if len(events) == 0 {
+			return nil
+		}
+
+		ids := make([]string, len(events))
+		for i, event := range events {
+			ids[i] = event.ID
+		}
+
+		return tx.Model(&models.OutboxEvent{}).
+			Where("id IN ?", ids).
+			Update("available_at", now.Add(eb.LeaseTimeout)).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim outbox events: %w", err)
+	}
+
+	return events, nil
+}
+
+// dispatch 將單一事件投遞給所有訂閱者並更新發件箱狀態
+func (eb *EventBus) dispatch(ctx context.Context, record *models.OutboxEvent) {
+	event := &DomainEvent{
+		ID:            record.ID,
+		Type:          record.EventType,
+		AggregateType: record.AggregateType,
+		AggregateID:   record.AggregateID,
+		Payload:       json.RawMessage(record.Payload),
+		OccurredAt:    record.CreatedAt,
+	}
+
+	eb.mu.RLock()
+	subscriptions := append([]eventSubscription(nil), eb.subscribers[record.EventType]...)
+	eb.mu.RUnlock()
+
+	var dispatchErr error
+	for _, sub := range subscriptions {
+		if err := eb.deliver(ctx, sub, event); err != nil {
+			log.Printf("Event %s (%s) failed for subscriber %s: %v", event.ID, event.Type, sub.name, err)
+			dispatchErr = err
+		}
+	}
+
+	attempts := record.Attempts + 1
+	updates := map[string]interface{}{
+		"attempts": attempts,
+	}
+
+	now := time.Now()
+	switch {
+	case dispatchErr == nil:
+		updates["status"] = OutboxStatusDispatched
+		updates["dispatched_at"] = now
+		updates["last_error"] = nil
+	case attempts >= eb.MaxAttempts:
+		updates["status"] = OutboxStatusFailed
+		updates["last_error"] = dispatchErr.Error()
+	default:
+		updates["available_at"] = now.Add(eventRetryBackoff(attempts))
+		updates["last_error"] = dispatchErr.Error()
+	}
+
+	if err := eb.db.WithContext(ctx).Model(&models.OutboxEvent{}).Where("id = ?", record.ID).Updates(updates).Error; err != nil {
+		log.Printf("Failed to update outbox event %s: %v", record.ID, err)
+	}
+}
+
+// deliver 投遞事件給單一訂閱者，已處理過的事件直接跳過
+func (eb *EventBus) deliver(ctx context.Context, sub eventSubscription, event *DomainEvent) error {
+	var count int64
+	if err := eb.db.WithContext(ctx).Model(&models.ProcessedEvent{}).
+		Where("event_id = ? AND subscriber = ?", event.ID, sub.name).
+		Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to check processed event: %w", err)
+	}
+	if count > 0 {
+		return nil
+	}
+
+	if err := sub.handler(ctx, event); err != nil {
+		return err
+	}
+
+	processed := models.ProcessedEvent{
+		EventID:     event.ID,
+		Subscriber:  sub.name,
+		ProcessedAt: time.Now(),
+	}
+	if err := eb.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&processed).Error; err != nil {
+		return fmt.Errorf("failed to record processed event: %w", err)
+	}
+
+	return nil
+}
+
+// eventRetryBackoff 計算重試延遲（指數退避，上限 10 分鐘）
+func eventRetryBackoff(attempts int) time.Duration {
+	delay := time.Duration(math.Pow(2, float64(attempts))) * time.Second
+	if delay > 10*time.Minute {
+		delay = 10 * time.Minute
+	}
+	return delay
+}
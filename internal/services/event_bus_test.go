@@ -0,0 +1,166 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"tennis-platform/backend/internal/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupEventBusTestDB() *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		panic("failed to connect database")
+	}
+
+	// 手動創建表結構
+	db.Exec(`CREATE TABLE outbox_events (
+		id TEXT PRIMARY KEY,
+		event_type TEXT NOT NULL,
+		aggregate_type TEXT NOT NULL,
+		aggregate_id TEXT NOT NULL,
+		payload TEXT,
+		status TEXT DEFAULT 'pending',
+		attempts INTEGER DEFAULT 0,
+		last_error TEXT,
+		available_at DATETIME NOT NULL,
+		dispatched_at DATETIME,
+		created_at DATETIME,
+		updated_at DATETIME
+	)`)
+
+	db.Exec(`CREATE TABLE processed_events (
+		event_id TEXT NOT NULL,
+		subscriber TEXT NOT NULL,
+		processed_at DATETIME NOT NULL,
+		PRIMARY KEY (event_id, subscriber)
+	)`)
+
+	return db
+}
+
+func publishTestEvent(t *testing.T, db *gorm.DB, bus *EventBus) {
+	tx := db.Begin()
+	err := bus.Publish(tx, EventBookingCreated, "booking", "booking-1", BookingEventPayload{
+		BookingID: "booking-1",
+		Status:    "pending",
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, tx.Commit().Error)
+}
+
+func TestEventBus_PublishRolledBack(t *testing.T) {
+	db := setupEventBusTestDB()
+	bus := NewEventBus(db)
+
+	tx := db.Begin()
+	err := bus.Publish(tx, EventBookingCreated, "booking", "booking-1", BookingEventPayload{BookingID: "booking-1"})
+	assert.NoError(t, err)
+	tx.Rollback()
+
+	var count int64
+	db.Model(&models.OutboxEvent{}).Count(&count)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestEventBus_DispatchPending(t *testing.T) {
+	db := setupEventBusTestDB()
+	bus := NewEventBus(db)
+
+	var received []BookingEventPayload
+	bus.Subscribe(EventBookingCreated, "test", func(ctx context.Context, event *DomainEvent) error {
+		var payload BookingEventPayload
+		if err := event.Decode(&payload); err != nil {
+			return err
+		}
+		received = append(received, payload)
+		return nil
+	})
+
+	publishTestEvent(t, db, bus)
+
+	n, err := bus.DispatchPending(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Len(t, received, 1)
+	assert.Equal(t, "booking-1", received[0].BookingID)
+
+	var event models.OutboxEvent
+	assert.NoError(t, db.First(&event).Error)
+	assert.Equal(t, OutboxStatusDispatched, event.Status)
+	assert.NotNil(t, event.DispatchedAt)
+
+	// 已派送的事件不會再次處理
+	n, err = bus.DispatchPending(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, n)
+	assert.Len(t, received, 1)
+}
+
+func TestEventBus_RetryOnlyFailedSubscribers(t *testing.T) {
+	db := setupEventBusTestDB()
+	bus := NewEventBus(db)
+
+	okCalls := 0
+	failCalls := 0
+	bus.Subscribe(EventBookingCreated, "ok", func(ctx context.Context, event *DomainEvent) error {
+		okCalls++
+		return nil
+	})
+	bus.Subscribe(EventBookingCreated, "flaky", func(ctx context.Context, event *DomainEvent) error {
+		failCalls++
+		if failCalls == 1 {
+			return errors.New("temporary failure")
+		}
+		return nil
+	})
+
+	publishTestEvent(t, db, bus)
+
+	_, err := bus.DispatchPending(context.Background())
+	assert.NoError(t, err)
+
+	var event models.OutboxEvent
+	assert.NoError(t, db.First(&event).Error)
+	assert.Equal(t, OutboxStatusPending, event.Status)
+	assert.Equal(t, 1, event.Attempts)
+	assert.NotNil(t, event.LastError)
+	assert.True(t, event.AvailableAt.After(time.Now()))
+
+	// 模擬退避時間已過
+	db.Model(&models.OutboxEvent{}).Where("id = ?", event.ID).Update("available_at", time.Now().Add(-time.Second))
+
+	_, err = bus.DispatchPending(context.Background())
+	assert.NoError(t, err)
+
+	assert.Equal(t, 1, okCalls)
+	assert.Equal(t, 2, failCalls)
+
+	assert.NoError(t, db.First(&event).Error)
+	assert.Equal(t, OutboxStatusDispatched, event.Status)
+	assert.Equal(t, 2, event.Attempts)
+}
+
+func TestEventBus_MarkFailedAfterMaxAttempts(t *testing.T) {
+	db := setupEventBusTestDB()
+	bus := NewEventBus(db)
+	bus.MaxAttempts = 1
+
+	bus.Subscribe(EventBookingCreated, "broken", func(ctx context.Context, event *DomainEvent) error {
+		return errors.New("permanent failure")
+	})
+
+	publishTestEvent(t, db, bus)
+
+	_, err := bus.DispatchPending(context.Background())
+	assert.NoError(t, err)
+
+	var event models.OutboxEvent
+	assert.NoError(t, db.First(&event).Error)
+	assert.Equal(t, OutboxStatusFailed, event.Status)
+}
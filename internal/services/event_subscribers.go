@@ -0,0 +1,266 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"tennis-platform/backend/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// BookingEventPayload 預訂事件內容
+type BookingEventPayload struct {
+	BookingID string    `json:"bookingId"`
+	CourtID   string    `json:"courtId"`
+	UserID    string    `json:"userId"`
+	StartTime time.Time `json:"startTime"`
+	EndTime   time.Time `json:"endTime"`
+	Status    string    `json:"status"`
+	OldStatus string    `json:"oldStatus,omitempty"`
+	ClosureID string    `json:"closureId,omitempty"` // 因場地例外取消時的例外
+}
+
+// WaitlistEventPayload 候補事件內容
+type WaitlistEventPayload struct {
+	EntryID   string    `json:"entryId"`
+	CourtID   string    `json:"courtId"`
+	UserID    string    `json:"userId"`
+	StartTime time.Time `json:"startTime"` // 提供時段的開始時間
+	EndTime   time.Time `json:"endTime"`
+	ExpiresAt time.Time `json:"expiresAt"` // 認領期限
+}
+
+// PaymentShareEventPayload 分攤付款事件內容
+type PaymentShareEventPayload struct {
+	ShareID   string    `json:"shareId"`
+	SplitID   string    `json:"splitId"`
+	BookingID string    `json:"bookingId"`
+	UserID    string    `json:"userId"`
+	Amount    float64   `json:"amount"`
+	DueAt     time.Time `json:"dueAt"` // 分攤的付款期限
+}
+
+// PaymentEventPayload 付款事件內容
+type PaymentEventPayload struct {
+	PaymentID      string  `json:"paymentId"`
+	UserID         string  `json:"userId"`
+	TargetType     string  `json:"targetType"`
+	TargetID       string  `json:"targetId"`
+	Amount         float64 `json:"amount"`
+	Currency       string  `json:"currency"`
+	RefundedAmount float64 `json:"refundedAmount"`         // 累計退款金額
+	RefundAmount   float64 `json:"refundAmount,omitempty"` // 本次退款金額
+}
+
+// LessonEventPayload 課程事件內容
+type LessonEventPayload struct {
+	LessonID    string    `json:"lessonId"`
+	CoachID     string    `json:"coachId"`
+	StudentID   string    `json:"studentId"`
+	ScheduledAt time.Time `json:"scheduledAt"`
+	Status      string    `json:"status"`
+	Reason      string    `json:"reason,omitempty"`
+}
+
+// RegistrationEventPayload 俱樂部活動報名事件內容
+type RegistrationEventPayload struct {
+	ParticipantID string `json:"participantId"`
+	EventID       string `json:"eventId"`
+	ClubID        string `json:"clubId"`
+	UserID        string `json:"userId"`
+	Status        string `json:"status"`
+	Reason        string `json:"reason,omitempty"`
+}
+
+// MatchResultEventPayload 比賽結果事件內容
+type MatchResultEventPayload struct {
+	MatchResultID string `json:"matchResultId"`
+	MatchID       string `json:"matchId"`
+	WinnerID      string `json:"winnerId"`
+	LoserID       string `json:"loserId"`
+}
+
+// CardMatchedEventPayload 抽卡配對成功事件內容
+type CardMatchedEventPayload struct {
+	MatchID         string   `json:"matchId"`
+	ChatRoomID      string   `json:"chatRoomId"`
+	UserIDs         []string `json:"userIds"`
+	NotificationIDs []string `json:"notificationIds"`
+}
+
+// RegisterNotificationSubscribers 註冊通知相關的事件訂閱者
+func RegisterNotificationSubscribers(bus *EventBus, db *gorm.DB, notificationService NotificationService) {
+	const subscriber = "notification"
+
+	bus.Subscribe(EventBookingCreated, subscriber, func(ctx context.Context, event *DomainEvent) error {
+		booking, _, err := loadBookingForEvent(ctx, db, event)
+		if err != nil {
+			return err
+		}
+		return notificationService.SendBookingConfirmation(booking)
+	})
+
+	bus.Subscribe(EventBookingStatusChanged, subscriber, func(ctx context.Context, event *DomainEvent) error {
+		booking, payload, err := loadBookingForEvent(ctx, db, event)
+		if err != nil {
+			return err
+		}
+		// 以事件發生時的狀態為準，避免之後的變更影響通知內容
+		booking.Status = payload.Status
+		return notificationService.SendBookingStatusUpdate(booking, payload.OldStatus)
+	})
+
+	bus.Subscribe(EventBookingCancelled, subscriber, func(ctx context.Context, event *DomainEvent) error {
+		booking, payload, err := loadBookingForEvent(ctx, db, event)
+		if err != nil {
+			return err
+		}
+		if payload.ClosureID == "" {
+			return notificationService.SendBookingCancellation(booking)
+		}
+
+		// 因場地例外取消時說明原因及全額退款
+		var closure models.CourtClosure
+		if err := db.WithContext(ctx).Where("id = ?", payload.ClosureID).First(&closure).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return notificationService.SendBookingCancellation(booking)
+			}
+			return fmt.Errorf("failed to load court closure: %w", err)
+		}
+		return notificationService.SendBookingClosureCancellation(booking, &closure)
+	})
+
+	bus.Subscribe(EventBookingAttendanceRecorded, subscriber, func(ctx context.Context, event *DomainEvent) error {
+		booking, payload, err := loadBookingForEvent(ctx, db, event)
+		if err != nil {
+			return err
+		}
+		// 只通知未到場，正常完成不另行通知
+		if payload.Status != "no_show" {
+			return nil
+		}
+		booking.Status = payload.Status
+		return notificationService.SendBookingStatusUpdate(booking, payload.OldStatus)
+	})
+
+	bus.Subscribe(EventWaitlistOffered, subscriber, func(ctx context.Context, event *DomainEvent) error {
+		var entry models.BookingWaitlistEntry
+		if err := db.WithContext(ctx).Preload("Court").Preload("CourtUnit").Preload("User").
+			Where("id = ?", event.AggregateID).First(&entry).Error; err != nil {
+			return fmt.Errorf("failed to load waitlist entry: %w", err)
+		}
+		// 派送時已認領、逾期或退出的提供不再通知
+		if entry.Status != models.WaitlistStatusOffered {
+			return nil
+		}
+		return notificationService.SendWaitlistOffer(&entry)
+	})
+
+	bus.Subscribe(EventPaymentShareRequested, subscriber, func(ctx context.Context, event *DomainEvent) error {
+		var share models.BookingPaymentShare
+		if err := db.WithContext(ctx).Preload("Split").Preload("Booking.Court").Preload("User").
+			Where("id = ?", event.AggregateID).First(&share).Error; err != nil {
+			return fmt.Errorf("failed to load payment share: %w", err)
+		}
+		// 派送時已付款或已取消的分攤不再通知
+		if share.Status != models.PaymentShareStatusPending {
+			return nil
+		}
+		return notificationService.SendPaymentShareRequest(&share)
+	})
+
+	bus.Subscribe(EventLessonCancelled, subscriber, func(ctx context.Context, event *DomainEvent) error {
+		var lesson models.Lesson
+		if err := db.WithContext(ctx).Preload("Coach.User").Preload("Student").
+			Where("id = ?", event.AggregateID).First(&lesson).Error; err != nil {
+			return fmt.Errorf("failed to load lesson: %w", err)
+		}
+
+		if lesson.Student != nil {
+			if err := notificationService.SendLessonCancellation(&lesson, lesson.Student); err != nil {
+				return err
+			}
+		}
+		if lesson.Coach != nil && lesson.Coach.User != nil {
+			if err := notificationService.SendLessonCancellation(&lesson, lesson.Coach.User); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	bus.Subscribe(EventCardMatched, subscriber, func(ctx context.Context, event *DomainEvent) error {
+		var payload CardMatchedEventPayload
+		if err := event.Decode(&payload); err != nil {
+			return fmt.Errorf("failed to decode event payload: %w", err)
+		}
+
+		var notifications []models.MatchNotification
+		if err := db.WithContext(ctx).Preload("User").
+			Where("id IN ?", payload.NotificationIDs).Find(&notifications).Error; err != nil {
+			return fmt.Errorf("failed to load match notifications: %w", err)
+		}
+
+		for i := range notifications {
+			if err := notificationService.SendMatchNotification(notifications[i].User, &notifications[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// RegisterReputationSubscribers 註冊信譽與技術等級相關的事件訂閱者
+func RegisterReputationSubscribers(bus *EventBus, db *gorm.DB) {
+	matchStatisticsService := NewMatchStatisticsService(db, bus)
+	reputationService := NewReputationService(db)
+
+	bus.Subscribe(EventMatchResultConfirmed, "skill_level", func(ctx context.Context, event *DomainEvent) error {
+		var payload MatchResultEventPayload
+		if err := event.Decode(&payload); err != nil {
+			return fmt.Errorf("failed to decode event payload: %w", err)
+		}
+
+		if err := matchStatisticsService.AutoAdjustSkillLevel(payload.WinnerID); err != nil {
+			return fmt.Errorf("failed to auto adjust winner skill level: %w", err)
+		}
+		if err := matchStatisticsService.AutoAdjustSkillLevel(payload.LoserID); err != nil {
+			return fmt.Errorf("failed to auto adjust loser skill level: %w", err)
+		}
+		return nil
+	})
+
+	bus.Subscribe(EventBookingAttendanceRecorded, "attendance", func(ctx context.Context, event *DomainEvent) error {
+		booking, payload, err := loadBookingForEvent(ctx, db, event)
+		if err != nil {
+			return err
+		}
+
+		// 未到場降低出席率；已報到的預訂計為出席，不需要報到的場地不影響出席率
+		switch {
+		case payload.Status == "no_show":
+			return reputationService.UpdateAttendanceRate(booking.UserID, "no_show")
+		case payload.Status == "completed" && booking.CheckedInAt != nil:
+			return reputationService.UpdateAttendanceRate(booking.UserID, "completed")
+		}
+		return nil
+	})
+}
+
+// loadBookingForEvent 載入事件對應的預訂及其關聯數據
+func loadBookingForEvent(ctx context.Context, db *gorm.DB, event *DomainEvent) (*models.Booking, *BookingEventPayload, error) {
+	var payload BookingEventPayload
+	if err := event.Decode(&payload); err != nil {
+		return nil, nil, fmt.Errorf("failed to decode event payload: %w", err)
+	}
+
+	var booking models.Booking
+	if err := db.WithContext(ctx).Preload("Court").Preload("User").
+		Where("id = ?", payload.BookingID).First(&booking).Error; err != nil {
+		return nil, nil, fmt.Errorf("failed to load booking: %w", err)
+	}
+
+	return &booking, &payload, nil
+}
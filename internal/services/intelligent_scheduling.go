@@ -4,6 +4,7 @@ import (
 	"errors"
 	"math"
 	"sort"
+	"tennis-platform/backend/internal/apperror"
 	"tennis-platform/backend/internal/models"
 	"time"
 
@@ -431,12 +432,18 @@ func (iss *IntelligentSchedulingService) getCoachAvailabilityForDate(coachID str
 		return nil, err
 	}
 
+	// 獲取外部行事曆的忙碌時段
+	busyBlocks, err := LoadCoachBusyBlocks(iss.db, coachID, startOfDay, endOfDay)
+	if err != nil {
+		return nil, err
+	}
+
 	// 生成可用時間段
 	var availableSlots []models.TimeSlot
 	for _, schedule := range schedules {
 		slots := iss.generateTimeSlots(schedule.StartTime, schedule.EndTime, 60) // 60分鐘間隔
 		for _, slot := range slots {
-			isBooked := iss.isTimeSlotBooked(slot, bookedLessons, date)
+			isBooked := iss.isTimeSlotBooked(slot, bookedLessons, busyBlocks, date)
 			if !isBooked {
 				availableSlots = append(availableSlots, slot)
 			}
@@ -504,8 +511,8 @@ func (iss *IntelligentSchedulingService) generateTimeSlots(startTime, endTime st
 	return slots
 }
 
-// isTimeSlotBooked 檢查時間段是否已預訂
-func (iss *IntelligentSchedulingService) isTimeSlotBooked(slot models.TimeSlot, bookedLessons []models.Lesson, targetDate time.Time) bool {
+// isTimeSlotBooked 檢查時間段是否已預訂或與外部行事曆的忙碌時段重疊
+func (iss *IntelligentSchedulingService) isTimeSlotBooked(slot models.TimeSlot, bookedLessons []models.Lesson, busyBlocks []models.CoachBusyBlock, targetDate time.Time) bool {
 	slotStart, _ := time.Parse("15:04", slot.StartTime)
 	slotEnd, _ := time.Parse("15:04", slot.EndTime)
 
@@ -524,7 +531,7 @@ func (iss *IntelligentSchedulingService) isTimeSlotBooked(slot models.TimeSlot,
 		}
 	}
 
-	return false
+	return OverlapsBusyBlock(slotStartTime, slotEndTime, busyBlocks)
 }
 
 // containsInt 檢查整數數組是否包含特定值
@@ -543,7 +550,7 @@ func (iss *IntelligentSchedulingService) FindOptimalLessonTime(coachID, studentI
 	var coach models.Coach
 	if err := iss.db.Preload("User").Preload("User.Profile").
 		Where("id = ? AND is_active = ? AND is_verified = ?", coachID, true, true).First(&coach).Error; err != nil {
-		return nil, errors.New("教練不存在或未認證")
+		return nil, apperror.New(apperror.CodeCoachNotFound)
 	}
 
 	// 生成推薦
@@ -553,7 +560,7 @@ func (iss *IntelligentSchedulingService) FindOptimalLessonTime(coachID, studentI
 	}
 
 	if len(recommendations) == 0 {
-		return nil, errors.New("未找到合適的課程時間")
+		return nil, apperror.New(apperror.CodeSchedulingNoAvailableTime)
 	}
 
 	// 返回最佳推薦
@@ -586,7 +593,7 @@ func (iss *IntelligentSchedulingService) ResolveSchedulingConflict(conflictingLe
 	// 檢查新時間是否有衝突
 	var lesson models.Lesson
 	if err := iss.db.Where("id = ?", conflictingLessonID).First(&lesson).Error; err != nil {
-		return errors.New("課程不存在")
+		return apperror.New(apperror.CodeLessonNotFound)
 	}
 
 	conflicts, err := iss.DetectSchedulingConflicts(lesson.CoachID, newScheduledAt, lesson.Duration, &conflictingLessonID)
@@ -595,11 +602,14 @@ func (iss *IntelligentSchedulingService) ResolveSchedulingConflict(conflictingLe
 	}
 
 	if len(conflicts) > 0 {
-		return errors.New("新時間仍有衝突")
+		return apperror.New(apperror.CodeLessonTimeConflict)
 	}
 
 	// 更新課程時間
-	if err := iss.db.Model(&lesson).Update("scheduled_at", newScheduledAt).Error; err != nil {
+	if err := iss.db.Model(&lesson).Updates(map[string]interface{}{
+		"scheduled_at": newScheduledAt,
+		"version":      gorm.Expr("version + 1"),
+	}).Error; err != nil {
 		return errors.New("更新課程時間失敗")
 	}
 
@@ -0,0 +1,566 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"tennis-platform/backend/internal/config"
+	"tennis-platform/backend/internal/models"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 發票號碼的字軌
+const (
+	invoiceNumberPrefix    = "INV"
+	creditNoteNumberPrefix = "CN"
+)
+
+// 會籍類型的發票項目名稱
+var membershipTypeNames = map[string]string{
+	"monthly":  "月費",
+	"yearly":   "年費",
+	"lifetime": "終身會籍",
+}
+
+// InvoiceService 開立付款的發票及退款的折讓單，生成 PDF 並寄給付款人
+//
+// 發票號碼按字軌及年份遞增，例如 INV-2024-000001，折讓單的字軌為 CN。
+// 號碼在開立的事務中分配，開立失敗時回滾，不會產生跳號。
+type InvoiceService struct {
+	db       *gorm.DB
+	uploads  *UploadService
+	notifier NotificationService
+	taxRate  float64
+	font     []byte // 支援中文的 TrueType 字型，為空時以英文標籤及內建字型輸出
+}
+
+// NewInvoiceService 創建新的發票服務，字型無法載入時改用內建字型
+func NewInvoiceService(db *gorm.DB, uploads *UploadService, notifier NotificationService, cfg config.InvoiceConfig) *InvoiceService {
+	service := &InvoiceService{
+		db:       db,
+		uploads:  uploads,
+		notifier: notifier,
+		taxRate:  cfg.TaxRate,
+	}
+	if cfg.FontPath != "" {
+		font, err := os.ReadFile(cfg.FontPath)
+		if err != nil {
+			log.Printf("Failed to load invoice font %s, using built-in font: %v", cfg.FontPath, err)
+		} else {
+			service.font = font
+		}
+	}
+	return service
+}
+
+// IssueForPayment 為已扣款的付款開立發票，已開立時返回原發票
+func (s *InvoiceService) IssueForPayment(ctx context.Context, paymentID string) (*models.Invoice, error) {
+	sourceKey := "payment:" + paymentID
+	existing, err := s.findBySource(ctx, sourceKey)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return existing, s.ensureFile(ctx, existing)
+	}
+
+	var payment models.Payment
+	if err := s.db.WithContext(ctx).Where("id = ?", paymentID).First(&payment).Error; err != nil {
+		return nil, fmt.Errorf("failed to load payment: %w", err)
+	}
+	if payment.CapturedAt == nil {
+		return nil, fmt.Errorf("payment %s has not been captured", paymentID)
+	}
+
+	invoice := &models.Invoice{
+		Kind:       models.InvoiceKindInvoice,
+		SourceKey:  sourceKey,
+		PaymentID:  payment.ID,
+		TargetType: payment.TargetType,
+		TargetID:   payment.TargetID,
+		Currency:   payment.Currency,
+		IssuedAt:   time.Now(),
+	}
+	if err := s.fillBuyer(ctx, invoice, payment.UserID); err != nil {
+		return nil, err
+	}
+	if err := s.fillSeller(ctx, invoice, &payment); err != nil {
+		return nil, err
+	}
+	s.applyTotal(invoice, payment.Amount)
+
+	if err := s.create(ctx, invoice, invoiceNumberPrefix); err != nil {
+		return nil, err
+	}
+	return invoice, s.ensureFile(ctx, invoice)
+}
+
+// IssueCreditNote 為付款的一次退款開立折讓單，已開立時返回原折讓單
+//
+// refundedTotal 為此次退款後的累計退款金額，用於識別同一次退款。付款尚未開立發票時先開立發票。
+func (s *InvoiceService) IssueCreditNote(ctx context.Context, paymentID string, refundAmount, refundedTotal float64) (*models.Invoice, error) {
+	sourceKey := fmt.Sprintf("refund:%s:%d", paymentID, int64(math.Round(refundedTotal*100)))
+	existing, err := s.findBySource(ctx, sourceKey)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return existing, s.ensureFile(ctx, existing)
+	}
+
+	original, err := s.IssueForPayment(ctx, paymentID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 買賣雙方沿用原發票的快照
+	note := &models.Invoice{
+		Kind:              models.InvoiceKindCreditNote,
+		SourceKey:         sourceKey,
+		PaymentID:         original.PaymentID,
+		OriginalInvoiceID: &original.ID,
+		TargetType:        original.TargetType,
+		TargetID:          original.TargetID,
+		BuyerID:           original.BuyerID,
+		BuyerName:         original.BuyerName,
+		BuyerEmail:        original.BuyerEmail,
+		SellerType:        original.SellerType,
+		SellerID:          original.SellerID,
+		SellerUserID:      original.SellerUserID,
+		SellerName:        original.SellerName,
+		SellerAddress:     original.SellerAddress,
+		Lines: models.InvoiceLines{
+			singleLine(fmt.Sprintf("退款（發票 %s）", original.Number), refundAmount),
+		},
+		Currency: original.Currency,
+		IssuedAt: time.Now(),
+	}
+	s.applyTotal(note, refundAmount)
+
+	if err := s.create(ctx, note, creditNoteNumberPrefix); err != nil {
+		return nil, err
+	}
+	return note, s.ensureFile(ctx, note)
+}
+
+// PDF 返回發票的 PDF，尚未生成時先生成並保存
+func (s *InvoiceService) PDF(ctx context.Context, invoice *models.Invoice) ([]byte, error) {
+	if err := s.ensureFile(ctx, invoice); err != nil {
+		return nil, err
+	}
+	file, err := s.uploads.OpenFile(ctx, invoice.FileKey)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return io.ReadAll(file)
+}
+
+// Send 將發票的 PDF 寄給付款人並記錄寄出時間
+func (s *InvoiceService) Send(ctx context.Context, invoice *models.Invoice) error {
+	data, err := s.PDF(ctx, invoice)
+	if err != nil {
+		return err
+	}
+	if err := s.notifier.SendInvoice(invoice, data); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if err := s.db.WithContext(ctx).Model(&models.Invoice{}).
+		Where("id = ?", invoice.ID).
+		Update("emailed_at", now).Error; err != nil {
+		return fmt.Errorf("failed to record invoice email: %w", err)
+	}
+	invoice.EmailedAt = &now
+	return nil
+}
+
+// findBySource 按開立來源查找發票，不存在時返回 nil
+func (s *InvoiceService) findBySource(ctx context.Context, sourceKey string) (*models.Invoice, error) {
+	var invoice models.Invoice
+	if err := s.db.WithContext(ctx).Where("source_key = ?", sourceKey).First(&invoice).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to load invoice: %w", err)
+	}
+	return &invoice, nil
+}
+
+// create 分配號碼並保存發票；同一來源同時開立時唯一索引使其中一方失敗，重試時返回已開立的發票
+func (s *InvoiceService) create(ctx context.Context, invoice *models.Invoice, prefix string) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		number, err := nextInvoiceNumber(tx, prefix, invoice.IssuedAt.Year())
+		if err != nil {
+			return err
+		}
+		invoice.Number = number
+		if err := tx.Create(invoice).Error; err != nil {
+			return fmt.Errorf("failed to create invoice: %w", err)
+		}
+		return nil
+	})
+}
+
+// nextInvoiceNumber 遞增字軌當年的流水號，行鎖持續到事務結束，同時開立的發票依序取號
+func nextInvoiceNumber(tx *gorm.DB, prefix string, year int) (string, error) {
+	sequence := models.InvoiceSequence{Prefix: prefix, Year: year, LastNumber: 1}
+	if err := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "prefix"}, {Name: "year"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"last_number": gorm.Expr("invoice_sequences.last_number + 1")}),
+	}).Create(&sequence).Error; err != nil {
+		return "", fmt.Errorf("failed to allocate invoice number: %w", err)
+	}
+	if err := tx.Where("prefix = ? AND year = ?", prefix, year).First(&sequence).Error; err != nil {
+		return "", fmt.Errorf("failed to allocate invoice number: %w", err)
+	}
+	return fmt.Sprintf("%s-%d-%06d", prefix, year, sequence.LastNumber), nil
+}
+
+// applyTotal 以含稅金額計算未稅金額及稅額
+func (s *InvoiceService) applyTotal(invoice *models.Invoice, total float64) {
+	invoice.Total = math.Round(total*100) / 100
+	invoice.TaxRate = s.taxRate
+	invoice.TaxAmount = math.Round(invoice.Total*s.taxRate/(100+s.taxRate)*100) / 100
+	invoice.Subtotal = math.Round((invoice.Total-invoice.TaxAmount)*100) / 100
+}
+
+// ensureFile 生成尚未保存的 PDF，文件名為發票 ID，無法從號碼推測
+func (s *InvoiceService) ensureFile(ctx context.Context, invoice *models.Invoice) error {
+	if invoice.FileKey != "" {
+		return nil
+	}
+
+	data, err := s.Render(invoice)
+	if err != nil {
+		return err
+	}
+	result, err := s.uploads.SaveDocument(ctx, "invoices", invoice.ID+".pdf", data)
+	if err != nil {
+		return fmt.Errorf("failed to store invoice pdf: %w", err)
+	}
+	if err := s.db.WithContext(ctx).Model(&models.Invoice{}).
+		Where("id = ?", invoice.ID).
+		Update("file_key", result.Path).Error; err != nil {
+		return fmt.Errorf("failed to record invoice file: %w", err)
+	}
+	invoice.FileKey = result.Path
+	return nil
+}
+
+// fillBuyer 填入付款人的姓名及電子郵件
+func (s *InvoiceService) fillBuyer(ctx context.Context, invoice *models.Invoice, userID string) error {
+	var user models.User
+	if err := s.db.WithContext(ctx).Preload("Profile").Where("id = ?", userID).First(&user).Error; err != nil {
+		return fmt.Errorf("failed to load buyer: %w", err)
+	}
+	invoice.BuyerID = user.ID
+	invoice.BuyerEmail = user.Email
+	invoice.BuyerName = user.Email
+	if user.Profile != nil {
+		if name := strings.TrimSpace(user.Profile.FirstName + " " + user.Profile.LastName); name != "" {
+			invoice.BuyerName = name
+		}
+	}
+	return nil
+}
+
+// fillSeller 依付款目標填入開立方及發票項目
+func (s *InvoiceService) fillSeller(ctx context.Context, invoice *models.Invoice, payment *models.Payment) error {
+	db := s.db.WithContext(ctx).Unscoped()
+
+	switch payment.TargetType {
+	case PaymentTargetBooking:
+		var booking models.Booking
+		if err := db.Preload("Court").Preload("CourtUnit").Where("id = ?", payment.TargetID).First(&booking).Error; err != nil {
+			return fmt.Errorf("failed to load booking: %w", err)
+		}
+		if booking.Court == nil {
+			return fmt.Errorf("court of booking %s not found", booking.ID)
+		}
+		fillCourtSeller(invoice, booking.Court)
+		invoice.Lines = models.InvoiceLines{singleLine(bookingLineDescription(&booking, "場地預訂"), payment.Amount)}
+
+	case PaymentTargetBookingSeries:
+		var series models.BookingSeries
+		if err := db.Preload("Court").Where("id = ?", payment.TargetID).First(&series).Error; err != nil {
+			return fmt.Errorf("failed to load booking series: %w", err)
+		}
+		if series.Court == nil {
+			return fmt.Errorf("court of booking series %s not found", series.ID)
+		}
+		fillCourtSeller(invoice, series.Court)
+
+		// 每次預訂一個項目；付款未確認任何預訂或金額不符時以一個項目開立
+		var bookings []models.Booking
+		if err := db.Preload("Court").Preload("CourtUnit").
+			Where("series_id = ? AND payment_id = ?", series.ID, payment.ID).
+			Order("start_time ASC").
+			Find(&bookings).Error; err != nil {
+			return fmt.Errorf("failed to load series bookings: %w", err)
+		}
+		var sum float64
+		lines := make(models.InvoiceLines, 0, len(bookings))
+		for i := range bookings {
+			sum += bookings[i].TotalPrice
+			lines = append(lines, singleLine(bookingLineDescription(&bookings[i], "重複預訂"), bookings[i].TotalPrice))
+		}
+		if len(lines) == 0 || math.Abs(sum-payment.Amount) > 0.005 {
+			lines = models.InvoiceLines{singleLine("重複預訂 "+series.Court.Name, payment.Amount)}
+		}
+		invoice.Lines = lines
+
+	case PaymentTargetBookingShare:
+		var share models.BookingPaymentShare
+		if err := db.Preload("Booking.Court").Preload("Booking.CourtUnit").Where("id = ?", payment.TargetID).First(&share).Error; err != nil {
+			return fmt.Errorf("failed to load payment share: %w", err)
+		}
+		if share.Booking == nil || share.Booking.Court == nil {
+			return fmt.Errorf("booking of payment share %s not found", share.ID)
+		}
+		fillCourtSeller(invoice, share.Booking.Court)
+		invoice.Lines = models.InvoiceLines{singleLine(bookingLineDescription(share.Booking, "分攤場地費用"), payment.Amount)}
+
+	case PaymentTargetLesson:
+		var lesson models.Lesson
+		if err := db.Preload("Coach.User.Profile").Preload("Court").Where("id = ?", payment.TargetID).First(&lesson).Error; err != nil {
+			return fmt.Errorf("failed to load lesson: %w", err)
+		}
+		if lesson.Coach == nil || lesson.Coach.User == nil {
+			return fmt.Errorf("coach of lesson %s not found", lesson.ID)
+		}
+		invoice.SellerType = models.InvoiceSellerCoach
+		invoice.SellerID = lesson.Coach.ID
+		invoice.SellerUserID = &lesson.Coach.UserID
+		invoice.SellerName = lesson.Coach.User.Email
+		if profile := lesson.Coach.User.Profile; profile != nil {
+			if name := strings.TrimSpace(profile.FirstName + " " + profile.LastName); name != "" {
+				invoice.SellerName = name
+			}
+		}
+		if lesson.Court != nil {
+			invoice.SellerAddress = lesson.Court.Address
+		}
+		description := fmt.Sprintf("網球課程 %s %d 分鐘", lesson.ScheduledAt.Format("2006-01-02 15:04"), lesson.Duration)
+		invoice.Lines = models.InvoiceLines{singleLine(description, payment.Amount)}
+
+	case PaymentTargetClubMembership:
+		var member models.ClubMember
+		if err := db.Preload("Club").Where("id = ?", payment.TargetID).First(&member).Error; err != nil {
+			return fmt.Errorf("failed to load club member: %w", err)
+		}
+		if member.Club == nil {
+			return fmt.Errorf("club of member %s not found", member.ID)
+		}
+		fillClubSeller(invoice, member.Club)
+		description := fmt.Sprintf("俱樂部會費 %s", member.Club.Name)
+		if name, ok := membershipTypeNames[member.MembershipType]; ok {
+			description += "（" + name + "）"
+		}
+		invoice.Lines = models.InvoiceLines{singleLine(description, payment.Amount)}
+
+	case PaymentTargetClubEventRegistration:
+		var participant models.ClubEventParticipant
+		if err := db.Preload("Event.Club").Where("id = ?", payment.TargetID).First(&participant).Error; err != nil {
+			return fmt.Errorf("failed to load event registration: %w", err)
+		}
+		if participant.Event == nil || participant.Event.Club == nil {
+			return fmt.Errorf("club of event registration %s not found", participant.ID)
+		}
+		fillClubSeller(invoice, participant.Event.Club)
+		description := fmt.Sprintf("活動報名 %s %s", participant.Event.Title, participant.Event.StartTime.Format("2006-01-02 15:04"))
+		invoice.Lines = models.InvoiceLines{singleLine(description, payment.Amount)}
+
+	default:
+		return fmt.Errorf("unknown payment target type: %s", payment.TargetType)
+	}
+	return nil
+}
+
+// fillCourtSeller 以場地為開立方
+func fillCourtSeller(invoice *models.Invoice, court *models.Court) {
+	invoice.SellerType = models.InvoiceSellerCourt
+	invoice.SellerID = court.ID
+	invoice.SellerUserID = court.OwnerID
+	invoice.SellerName = court.Name
+	invoice.SellerAddress = court.Address
+}
+
+// fillClubSeller 以俱樂部為開立方
+func fillClubSeller(invoice *models.Invoice, club *models.Club) {
+	invoice.SellerType = models.InvoiceSellerClub
+	invoice.SellerID = club.ID
+	invoice.SellerUserID = club.OwnerID
+	invoice.SellerName = club.Name
+	invoice.SellerAddress = club.Address
+}
+
+// bookingLineDescription 預訂項目的說明，包含球場及時段
+func bookingLineDescription(booking *models.Booking, label string) string {
+	description := label
+	if booking.Court != nil {
+		description += " " + booking.Court.Name
+	}
+	if unit := booking.CourtUnit; unit != nil {
+		if unit.Name != nil && *unit.Name != "" {
+			description += " " + *unit.Name
+		} else {
+			description += fmt.Sprintf(" %d 號場", unit.Number)
+		}
+	}
+	return fmt.Sprintf("%s %s-%s", description, booking.StartTime.Format("2006-01-02 15:04"), booking.EndTime.Format("15:04"))
+}
+
+// singleLine 數量為 1 的發票項目
+func singleLine(description string, amount float64) models.InvoiceLine {
+	amount = math.Round(amount*100) / 100
+	return models.InvoiceLine{Description: description, Quantity: 1, UnitPrice: amount, Amount: amount}
+}
+
+// invoiceLabels PDF 的欄位標籤
+type invoiceLabels struct {
+	Invoice, CreditNote, Number, IssuedAt, Seller, Buyer           string
+	Description, Quantity, UnitPrice, Amount, Subtotal, Tax, Total string
+}
+
+var (
+	invoiceLabelsZH = invoiceLabels{
+		Invoice: "發票", CreditNote: "折讓單", Number: "號碼", IssuedAt: "開立日期", Seller: "開立方", Buyer: "買受人",
+		Description: "項目", Quantity: "數量", UnitPrice: "單價", Amount: "金額", Subtotal: "未稅金額", Tax: "稅額", Total: "總計",
+	}
+	invoiceLabelsEN = invoiceLabels{
+		Invoice: "Invoice", CreditNote: "Credit Note", Number: "Number", IssuedAt: "Issued", Seller: "Seller", Buyer: "Bill to",
+		Description: "Description", Quantity: "Qty", UnitPrice: "Unit price", Amount: "Amount", Subtotal: "Subtotal", Tax: "Tax", Total: "Total",
+	}
+)
+
+// Render 生成發票的 PDF
+//
+// 配置了中文字型時以中文標籤輸出；否則使用內建字型及英文標籤，無法以 cp1252 表示的字元不會顯示。
+func (s *InvoiceService) Render(invoice *models.Invoice) ([]byte, error) {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetCreationDate(invoice.IssuedAt)
+	pdf.SetTitle(invoice.Number, true)
+
+	family := "Helvetica"
+	labels := invoiceLabelsEN
+	text := pdf.UnicodeTranslatorFromDescriptor("")
+	if len(s.font) > 0 {
+		family = "invoice"
+		labels = invoiceLabelsZH
+		text = func(value string) string { return value }
+		pdf.AddUTF8FontFromBytes(family, "", s.font)
+	}
+
+	pdf.AddPage()
+	title := labels.Invoice
+	if invoice.Kind == models.InvoiceKindCreditNote {
+		title = labels.CreditNote
+	}
+	pdf.SetFont(family, "", 18)
+	pdf.CellFormat(0, 12, text(title), "", 1, "L", false, 0, "")
+
+	pdf.SetFont(family, "", 10)
+	field := func(label, value string) {
+		pdf.CellFormat(30, 6, text(label), "", 0, "L", false, 0, "")
+		pdf.CellFormat(0, 6, text(value), "", 1, "L", false, 0, "")
+	}
+	field(labels.Number, invoice.Number)
+	field(labels.IssuedAt, invoice.IssuedAt.Format("2006-01-02"))
+	pdf.Ln(4)
+	field(labels.Seller, invoice.SellerName)
+	if invoice.SellerAddress != "" {
+		field("", invoice.SellerAddress)
+	}
+	field(labels.Buyer, invoice.BuyerName)
+	field("", invoice.BuyerEmail)
+	pdf.Ln(6)
+
+	widths := []float64{100, 15, 35, 40}
+	header := []string{labels.Description, labels.Quantity, labels.UnitPrice, labels.Amount}
+	aligns := []string{"L", "R", "R", "R"}
+	for i, label := range header {
+		pdf.CellFormat(widths[i], 8, text(label), "B", 0, aligns[i], false, 0, "")
+	}
+	pdf.Ln(-1)
+	for _, line := range invoice.Lines {
+		cells := []string{
+			line.Description,
+			strconv.FormatFloat(line.Quantity, 'f', -1, 64),
+			formatInvoiceAmount(line.UnitPrice),
+			formatInvoiceAmount(line.Amount),
+		}
+		for i, cell := range cells {
+			pdf.CellFormat(widths[i], 7, text(cell), "", 0, aligns[i], false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+	pdf.Ln(4)
+
+	total := func(label, value string) {
+		pdf.CellFormat(widths[0]+widths[1]+widths[2], 7, text(label), "", 0, "R", false, 0, "")
+		pdf.CellFormat(widths[3], 7, text(value), "", 1, "R", false, 0, "")
+	}
+	total(labels.Subtotal, formatInvoiceAmount(invoice.Subtotal))
+	total(fmt.Sprintf("%s (%s%%)", labels.Tax, strconv.FormatFloat(invoice.TaxRate, 'f', -1, 64)), formatInvoiceAmount(invoice.TaxAmount))
+	total(labels.Total, invoice.Currency+" "+formatInvoiceAmount(invoice.Total))
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, fmt.Errorf("failed to render invoice pdf: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// formatInvoiceAmount 金額取至小數點後兩位
+func formatInvoiceAmount(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', 2, 64)
+}
+
+// RegisterInvoiceSubscribers 訂閱付款事件，扣款後開立發票、退款後開立折讓單，並寄給付款人
+func RegisterInvoiceSubscribers(bus *EventBus, invoiceService *InvoiceService) {
+	const subscriber = "invoice"
+
+	bus.Subscribe(EventPaymentCaptured, subscriber, func(ctx context.Context, event *DomainEvent) error {
+		var payload PaymentEventPayload
+		if err := event.Decode(&payload); err != nil {
+			return fmt.Errorf("failed to decode event payload: %w", err)
+		}
+		invoice, err := invoiceService.IssueForPayment(ctx, payload.PaymentID)
+		if err != nil {
+			return err
+		}
+		// 重試時已寄出的發票不再寄送
+		if invoice.EmailedAt != nil {
+			return nil
+		}
+		return invoiceService.Send(ctx, invoice)
+	})
+
+	bus.Subscribe(EventPaymentRefunded, subscriber, func(ctx context.Context, event *DomainEvent) error {
+		var payload PaymentEventPayload
+		if err := event.Decode(&payload); err != nil {
+			return fmt.Errorf("failed to decode event payload: %w", err)
+		}
+		note, err := invoiceService.IssueCreditNote(ctx, payload.PaymentID, payload.RefundAmount, payload.RefundedAmount)
+		if err != nil {
+			return err
+		}
+		if note.EmailedAt != nil {
+			return nil
+		}
+		return invoiceService.Send(ctx, note)
+	})
+}
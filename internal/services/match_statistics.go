@@ -1,8 +1,10 @@
 package services
 
 import (
+	"errors"
 	"fmt"
 	"math"
+	"tennis-platform/backend/internal/apperror"
 	"tennis-platform/backend/internal/models"
 	"time"
 
@@ -11,13 +13,15 @@ import (
 
 // MatchStatisticsService 配對統計服務
 type MatchStatisticsService struct {
-	db *gorm.DB
+	db       *gorm.DB
+	eventBus *EventBus
 }
 
 // NewMatchStatisticsService 創建新的配對統計服務
-func NewMatchStatisticsService(db *gorm.DB) *MatchStatisticsService {
+func NewMatchStatisticsService(db *gorm.DB, eventBus *EventBus) *MatchStatisticsService {
 	return &MatchStatisticsService{
-		db: db,
+		db:       db,
+		eventBus: eventBus,
 	}
 }
 
@@ -354,22 +358,25 @@ func (mss *MatchStatisticsService) ConfirmMatchResult(matchResultID, userID stri
 
 	err := mss.db.Where("id = ?", matchResultID).First(&matchResult).Error
 	if err != nil {
-		return fmt.Errorf("match result not found: %w", err)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apperror.New(apperror.CodeMatchResultNotFound)
+		}
+		return fmt.Errorf("failed to get match result: %w", err)
 	}
 
 	// 檢查用戶是否有權限確認
 	if matchResult.WinnerID == nil || matchResult.LoserID == nil {
-		return fmt.Errorf("invalid match result")
+		return apperror.New(apperror.CodeMatchResultInvalid)
 	}
 
 	if userID != *matchResult.WinnerID && userID != *matchResult.LoserID {
-		return fmt.Errorf("user not authorized to confirm this result")
+		return apperror.New(apperror.CodeMatchResultForbidden)
 	}
 
 	// 檢查是否已經確認過
 	for _, confirmedBy := range matchResult.ConfirmedBy {
 		if confirmedBy == userID {
-			return fmt.Errorf("user has already confirmed this result")
+			return apperror.New(apperror.CodeMatchResultAlreadyConfirmed)
 		}
 	}
 
@@ -377,11 +384,46 @@ func (mss *MatchStatisticsService) ConfirmMatchResult(matchResultID, userID stri
 	matchResult.ConfirmedBy = append(matchResult.ConfirmedBy, userID)
 
 	// 如果雙方都確認了，標記為已確認
+	wasConfirmed := matchResult.IsConfirmed
 	if len(matchResult.ConfirmedBy) >= 2 {
 		matchResult.IsConfirmed = true
 	}
 
-	return mss.db.Save(&matchResult).Error
+	tx := mss.db.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err := tx.Save(&matchResult).Error; err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to save match result: %w", err)
+	}
+
+	// 結果首次被雙方確認時發布事件
+	if mss.eventBus != nil && matchResult.IsConfirmed && !wasConfirmed {
+		payload := MatchResultEventPayload{
+			MatchResultID: matchResult.ID,
+			MatchID:       matchResult.MatchID,
+			WinnerID:      *matchResult.WinnerID,
+			LoserID:       *matchResult.LoserID,
+		}
+		if err := mss.eventBus.Publish(tx, EventMatchResultConfirmed, "match_result", matchResult.ID, payload); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return fmt.Errorf("failed to commit match result: %w", err)
+	}
+
+	if mss.eventBus != nil && matchResult.IsConfirmed && !wasConfirmed {
+		mss.eventBus.Notify()
+	}
+
+	return nil
 }
 
 // AutoAdjustSkillLevel 自動調整技術等級
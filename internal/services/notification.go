@@ -13,6 +13,11 @@ type NotificationService interface {
 	SendBookingCancellation(booking *models.Booking) error
 	SendBookingStatusUpdate(booking *models.Booking, oldStatus string) error
 	SendMatchNotification(user *models.User, notification *models.MatchNotification) error
+	SendLessonCancellation(lesson *models.Lesson, recipient *models.User) error
+	SendWaitlistOffer(entry *models.BookingWaitlistEntry) error
+	SendBookingClosureCancellation(booking *models.Booking, closure *models.CourtClosure) error
+	SendPaymentShareRequest(share *models.BookingPaymentShare) error
+	SendInvoice(invoice *models.Invoice, pdf []byte) error
 }
 
 // EmailNotificationService 郵件通知服務實現
@@ -139,6 +144,7 @@ func (ns *EmailNotificationService) SendBookingStatusUpdate(booking *models.Book
 		"confirmed": "已確認",
 		"cancelled": "已取消",
 		"completed": "已完成",
+		"no_show":   "未到場",
 	}
 
 	oldStatusText := statusMap[oldStatus]
@@ -261,3 +267,232 @@ func (mns *MockNotificationService) SendMatchNotification(user *models.User, not
 	fmt.Printf("Mock: Sending match notification to user %s: %s\n", user.ID, notification.Message)
 	return nil
 }
+
+// SendLessonCancellation 發送課程取消通知
+func (ns *EmailNotificationService) SendLessonCancellation(lesson *models.Lesson, recipient *models.User) error {
+	if recipient == nil {
+		return fmt.Errorf("recipient information is missing")
+	}
+
+	reason := "未提供"
+	if lesson.CancelReason != nil && *lesson.CancelReason != "" {
+		reason = *lesson.CancelReason
+	}
+
+	subject := "課程取消通知"
+
+	body := fmt.Sprintf(`
+親愛的用戶，
+
+以下課程已被取消。
+
+課程詳情：
+- 時間：%s
+- 時長：%d 分鐘
+- 取消原因：%s
+- 課程編號：%s
+
+如有疑問，請聯繫我們。
+
+網球平台團隊
+	`,
+		lesson.ScheduledAt.Format("2006-01-02 15:04"),
+		lesson.Duration,
+		reason,
+		lesson.ID,
+	)
+
+	return ns.emailService.SendEmail(recipient.Email, subject, body)
+}
+
+// SendLessonCancellation 模擬發送課程取消通知
+func (mns *MockNotificationService) SendLessonCancellation(lesson *models.Lesson, recipient *models.User) error {
+	fmt.Printf("Mock: Sending lesson cancellation for lesson %s to user %s\n", lesson.ID, recipient.ID)
+	return nil
+}
+
+// SendWaitlistOffer 發送候補時段釋出通知，附上限時認領連結
+func (ns *EmailNotificationService) SendWaitlistOffer(entry *models.BookingWaitlistEntry) error {
+	if entry.User == nil || entry.Court == nil {
+		return fmt.Errorf("waitlist entry user or court information is missing")
+	}
+	if entry.OfferStartTime == nil || entry.OfferEndTime == nil || entry.OfferExpiresAt == nil {
+		return fmt.Errorf("waitlist entry has no offered slot")
+	}
+
+	subject := "候補時段已釋出 - " + entry.Court.Name
+	claimURL := fmt.Sprintf("%s/waitlist/%s/claim", ns.emailService.config.FrontendURL, entry.ID)
+
+	body := fmt.Sprintf(`
+親愛的用戶，
+
+您候補的時段已釋出，我們已為您暫時保留：
+
+時段詳情：
+- 場地：%s
+- 地址：%s
+- 時間：%s 至 %s
+
+請在 %s 前點擊以下連結完成預訂，逾期將提供給下一位候補者：
+%s
+
+網球平台團隊
+	`,
+		entry.Court.Name,
+		entry.Court.Address,
+		entry.OfferStartTime.Format("2006-01-02 15:04"),
+		entry.OfferEndTime.Format("2006-01-02 15:04"),
+		entry.OfferExpiresAt.Format("2006-01-02 15:04"),
+		claimURL,
+	)
+
+	return ns.emailService.SendEmail(entry.User.Email, subject, body)
+}
+
+// SendWaitlistOffer 模擬發送候補時段釋出通知
+func (mns *MockNotificationService) SendWaitlistOffer(entry *models.BookingWaitlistEntry) error {
+	fmt.Printf("Mock: Sending waitlist offer for entry %s to user %s\n", entry.ID, entry.UserID)
+	return nil
+}
+
+// closureReasonLabels 場地例外原因的顯示名稱
+var closureReasonLabels = map[string]string{
+	models.CourtClosureReasonMaintenance: "場地維護",
+	models.CourtClosureReasonHoliday:     "國定假日休館",
+	models.CourtClosureReasonTournament:  "比賽活動包場",
+	models.CourtClosureReasonWeather:     "天候因素",
+	models.CourtClosureReasonOther:       "場地因素",
+}
+
+// SendBookingClosureCancellation 發送因場地例外取消預訂的通知，說明原因及全額退款
+func (ns *EmailNotificationService) SendBookingClosureCancellation(booking *models.Booking, closure *models.CourtClosure) error {
+	if booking.User == nil || booking.Court == nil {
+		return fmt.Errorf("booking user or court information is missing")
+	}
+
+	reason, ok := closureReasonLabels[closure.Reason]
+	if !ok {
+		reason = closureReasonLabels[models.CourtClosureReasonOther]
+	}
+	if closure.Note != nil && *closure.Note != "" {
+		reason += "：" + *closure.Note
+	}
+
+	subject := "預訂因場地暫停開放而取消 - " + booking.Court.Name
+
+	body := fmt.Sprintf(`
+親愛的用戶，
+
+很抱歉，因%s，場地在您預訂的時段暫停開放，您的預訂已由場地取消。
+
+取消的預訂詳情：
+- 場地：%s
+- 原定時間：%s 至 %s
+- 預訂編號：%s
+
+已付款項將全額退還至原付款方式。歡迎重新預訂其他時段。
+
+網球平台團隊
+	`,
+		reason,
+		booking.Court.Name,
+		booking.StartTime.Format("2006-01-02 15:04"),
+		booking.EndTime.Format("2006-01-02 15:04"),
+		booking.ID,
+	)
+
+	return ns.emailService.SendEmail(booking.User.Email, subject, body)
+}
+
+// SendBookingClosureCancellation 模擬發送因場地例外取消預訂的通知
+func (mns *MockNotificationService) SendBookingClosureCancellation(booking *models.Booking, closure *models.CourtClosure) error {
+	fmt.Printf("Mock: Sending closure cancellation for booking %s (closure %s)\n", booking.ID, closure.ID)
+	return nil
+}
+
+// SendPaymentShareRequest 發送分攤付款邀請，附上付款連結及期限
+func (ns *EmailNotificationService) SendPaymentShareRequest(share *models.BookingPaymentShare) error {
+	if share.User == nil || share.Booking == nil || share.Booking.Court == nil || share.Split == nil {
+		return fmt.Errorf("payment share user, booking or split information is missing")
+	}
+
+	booking := share.Booking
+	subject := "分攤付款邀請 - " + booking.Court.Name
+	payURL := fmt.Sprintf("%s/bookings/%s/split", ns.emailService.config.FrontendURL, booking.ID)
+
+	body := fmt.Sprintf(`
+親愛的用戶，
+
+您的球友邀請您分攤以下預訂的場地費用：
+
+預訂詳情：
+- 場地：%s
+- 地址：%s
+- 時間：%s 至 %s
+- 您的分攤金額：%.2f %s
+
+請在 %s 前點擊以下連結完成付款：
+%s
+
+網球平台團隊
+	`,
+		booking.Court.Name,
+		booking.Court.Address,
+		booking.StartTime.Format("2006-01-02 15:04"),
+		booking.EndTime.Format("2006-01-02 15:04"),
+		share.Amount,
+		share.Split.Currency,
+		share.Split.DueAt.Format("2006-01-02 15:04"),
+		payURL,
+	)
+
+	return ns.emailService.SendEmail(share.User.Email, subject, body)
+}
+
+// SendPaymentShareRequest 模擬發送分攤付款邀請
+func (mns *MockNotificationService) SendPaymentShareRequest(share *models.BookingPaymentShare) error {
+	fmt.Printf("Mock: Sending payment share request %s to user %s\n", share.ID, share.UserID)
+	return nil
+}
+
+// SendInvoice 發送發票或折讓單，PDF 作為附件
+func (ns *EmailNotificationService) SendInvoice(invoice *models.Invoice, pdf []byte) error {
+	title, intro := "發票", "感謝您的付款"
+	if invoice.Kind == models.InvoiceKindCreditNote {
+		title, intro = "折讓單", "您的退款已處理"
+	}
+	subject := fmt.Sprintf("%s %s - %s", title, invoice.Number, invoice.SellerName)
+
+	body := fmt.Sprintf(`
+親愛的 %s，
+
+%s，附件為您的%s：
+
+- 號碼：%s
+- 開立方：%s
+- 開立日期：%s
+- 金額：%.2f %s（含稅 %.2f）
+
+您也可以在平台的發票頁面隨時下載。
+
+網球平台團隊
+	`,
+		invoice.BuyerName,
+		intro,
+		title,
+		invoice.Number,
+		invoice.SellerName,
+		invoice.IssuedAt.Format("2006-01-02"),
+		invoice.Total,
+		invoice.Currency,
+		invoice.TaxAmount,
+	)
+
+	return ns.emailService.SendEmailWithAttachment(invoice.BuyerEmail, subject, body, invoice.Number+".pdf", pdf)
+}
+
+// SendInvoice 模擬發送發票
+func (mns *MockNotificationService) SendInvoice(invoice *models.Invoice, pdf []byte) error {
+	fmt.Printf("Mock: Sending invoice %s to %s (%d bytes)\n", invoice.Number, invoice.BuyerEmail, len(pdf))
+	return nil
+}
@@ -3,10 +3,10 @@ package services
 import (
 	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"tennis-platform/backend/internal/apperror"
 	"tennis-platform/backend/internal/config"
 
 	"golang.org/x/oauth2"
@@ -124,7 +124,7 @@ func (o *OAuthService) GetAuthURL(provider, state string) (string, error) {
 	case "apple":
 		config = o.GetAppleOAuthConfig()
 	default:
-		return "", errors.New("不支援的 OAuth 提供商")
+		return "", apperror.New(apperror.CodeOAuthUnsupportedProvider)
 	}
 
 	return config.AuthCodeURL(state, oauth2.AccessTypeOffline), nil
@@ -142,7 +142,7 @@ func (o *OAuthService) ExchangeCodeForToken(provider, code string) (*oauth2.Toke
 	case "apple":
 		config = o.GetAppleOAuthConfig()
 	default:
-		return nil, errors.New("不支援的 OAuth 提供商")
+		return nil, apperror.New(apperror.CodeOAuthUnsupportedProvider)
 	}
 
 	return config.Exchange(context.Background(), code)
@@ -158,7 +158,7 @@ func (o *OAuthService) GetUserInfo(provider string, token *oauth2.Token) (*OAuth
 	case "apple":
 		return o.getAppleUserInfo(token)
 	default:
-		return nil, errors.New("不支援的 OAuth 提供商")
+		return nil, apperror.New(apperror.CodeOAuthUnsupportedProvider)
 	}
 }
 
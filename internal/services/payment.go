@@ -0,0 +1,968 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"tennis-platform/backend/internal/apperror"
+	"tennis-platform/backend/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 付款狀態
+const (
+	PaymentStatusPending           = "pending"
+	PaymentStatusAuthorized        = "authorized"
+	PaymentStatusCaptured          = "captured"
+	PaymentStatusFailed            = "failed"
+	PaymentStatusCancelled         = "cancelled"
+	PaymentStatusExpired           = "expired"
+	PaymentStatusPartiallyRefunded = "partially_refunded"
+	PaymentStatusRefunded          = "refunded"
+)
+
+// 付款目標類型
+const (
+	PaymentTargetBooking               = "booking"
+	PaymentTargetBookingSeries         = "booking_series" // 整組付款的重複預訂
+	PaymentTargetLesson                = "lesson"
+	PaymentTargetClubEventRegistration = "club_event_registration"
+	PaymentTargetBookingShare          = "booking_share"   // 分攤付款中一位付款人的分攤
+	PaymentTargetClubMembership        = "club_membership" // 俱樂部會員的會費
+)
+
+// 逾期未付款取消課程時記錄的原因
+const paymentExpiredReason = "付款逾時"
+
+// 分攤付款逾期取消預訂時記錄的原因
+const paymentSplitExpiredReason = "分攤付款逾時"
+
+// 金額比較的容許誤差
+const amountEpsilon = 0.005
+
+// noOpenSplit 排除有進行中分攤付款的預訂
+const noOpenSplit = "NOT EXISTS (SELECT 1 FROM booking_payment_splits WHERE booking_payment_splits.booking_id = bookings.id AND booking_payment_splits.status = ?)"
+
+// paymentTransitions 付款狀態機允許的轉換
+var paymentTransitions = map[string][]string{
+	PaymentStatusPending:           {PaymentStatusAuthorized, PaymentStatusCaptured, PaymentStatusFailed, PaymentStatusCancelled, PaymentStatusExpired},
+	PaymentStatusAuthorized:        {PaymentStatusCaptured, PaymentStatusFailed, PaymentStatusCancelled, PaymentStatusExpired},
+	PaymentStatusCaptured:          {PaymentStatusPartiallyRefunded, PaymentStatusRefunded},
+	PaymentStatusPartiallyRefunded: {PaymentStatusPartiallyRefunded, PaymentStatusRefunded},
+}
+
+// CanTransitionPayment 檢查付款狀態能否從 from 轉換到 to
+func CanTransitionPayment(from, to string) bool {
+	for _, next := range paymentTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// IsPaymentActive 付款是否仍在進行中（尚未扣款且未結束）
+func IsPaymentActive(status string) bool {
+	return status == PaymentStatusPending || status == PaymentStatusAuthorized
+}
+
+// PaymentService 付款服務
+//
+// 負責付款狀態機、扣款後確認預訂、課程及活動報名，以及定期取消逾期未付款的保留。
+// 狀態轉換以「WHERE status = 舊狀態」的條件更新，並發的扣款、通知及逾期處理只會有一個生效。
+type PaymentService struct {
+	db           *gorm.DB
+	provider     PaymentProvider
+	eventBus     *EventBus
+	HoldDuration time.Duration // 未付款的保留時間，0 表示不限時
+	PollInterval time.Duration // 檢查逾期保留的間隔
+	BatchSize    int           // 每次處理的逾期數量
+}
+
+// NewPaymentService 創建新的付款服務
+func NewPaymentService(db *gorm.DB, provider PaymentProvider, eventBus *EventBus, holdDuration time.Duration) *PaymentService {
+	return &PaymentService{
+		db:           db,
+		provider:     provider,
+		eventBus:     eventBus,
+		HoldDuration: holdDuration,
+		PollInterval: 30 * time.Second,
+		BatchSize:    50,
+	}
+}
+
+// Provider 返回使用中的付款服務商
+func (ps *PaymentService) Provider() PaymentProvider {
+	return ps.provider
+}
+
+// Start 啟動逾期處理循環，直到 ctx 結束
+func (ps *PaymentService) Start(ctx context.Context) {
+	ticker := time.NewTicker(ps.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := ps.ExpireDue(ctx); err != nil {
+			log.Printf("Payment expiry error: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ExpireDue 取消逾期的付款及未付款的保留，返回本次處理數量
+func (ps *PaymentService) ExpireDue(ctx context.Context) (int, error) {
+	now := time.Now()
+
+	payments, err := ps.expirePayments(ctx, now)
+	if err != nil {
+		return payments, err
+	}
+
+	targets, err := ps.expireTargets(ctx, now)
+	return payments + targets, err
+}
+
+// expirePayments 在服務商取消逾期的付款意圖並標記為 expired
+func (ps *PaymentService) expirePayments(ctx context.Context, now time.Time) (int, error) {
+	var payments []models.Payment
+	if err := ps.db.WithContext(ctx).
+		Where("status IN ? AND expires_at <= ?", []string{PaymentStatusPending, PaymentStatusAuthorized}, now).
+		Order("expires_at ASC").
+		Limit(ps.BatchSize).
+		Find(&payments).Error; err != nil {
+		return 0, fmt.Errorf("failed to load expired payments: %w", err)
+	}
+
+	processed := 0
+	for i := range payments {
+		payment := &payments[i]
+		// 服務商無法取消時（例如已扣款）保留原狀態，等待通知處理
+		if err := ps.provider.Cancel(ctx, payment.ProviderPaymentID); err != nil {
+			log.Printf("Failed to cancel expired payment %s: %v", payment.ID, err)
+			continue
+		}
+		if err := ps.transition(ps.db.WithContext(ctx), payment, PaymentStatusExpired, map[string]interface{}{"cancelled_at": now}); err != nil {
+			log.Printf("Failed to expire payment %s: %v", payment.ID, err)
+			continue
+		}
+		processed++
+	}
+
+	return processed, nil
+}
+
+// expireTargets 取消逾期未付款的預訂、重複預訂、課程及活動報名並處理逾期的分攤付款，仍有進行中付款的保留會等付款逾期後再處理
+func (ps *PaymentService) expireTargets(ctx context.Context, now time.Time) (int, error) {
+	db := ps.db.WithContext(ctx)
+	activeStatuses := []string{PaymentStatusPending, PaymentStatusAuthorized}
+	noActivePayment := func(table, targetType string) (string, []interface{}) {
+		return fmt.Sprintf("NOT EXISTS (SELECT 1 FROM payments WHERE payments.target_type = ? AND payments.target_id = %s.id AND payments.status IN ?)", table),
+			[]interface{}{targetType, activeStatuses}
+	}
+
+	processed := 0
+
+	// 進行中的分攤付款由下方依分攤期限處理
+	var bookings []models.Booking
+	cond, args := noActivePayment("bookings", PaymentTargetBooking)
+	if err := db.Where("status = ? AND payment_id IS NULL AND payment_due_at <= ?", "pending", now).
+		Where(cond, args...).
+		Where(noOpenSplit, models.PaymentSplitOpen).
+		Limit(ps.BatchSize).
+		Find(&bookings).Error; err != nil {
+		return processed, fmt.Errorf("failed to load unpaid bookings: %w", err)
+	}
+	for i := range bookings {
+		if err := ps.expireBooking(ctx, &bookings[i]); err != nil {
+			log.Printf("Failed to expire booking %s: %v", bookings[i].ID, err)
+			continue
+		}
+		processed++
+	}
+
+	var splits []models.BookingPaymentSplit
+	if err := db.Where("status = ? AND due_at <= ?", models.PaymentSplitOpen, now).
+		Where("NOT EXISTS (SELECT 1 FROM payments JOIN booking_payment_shares ON booking_payment_shares.id = payments.target_id WHERE booking_payment_shares.split_id = booking_payment_splits.id AND payments.target_type = ? AND payments.status IN ?)",
+			PaymentTargetBookingShare, activeStatuses).
+		Limit(ps.BatchSize).
+		Find(&splits).Error; err != nil {
+		return processed, fmt.Errorf("failed to load overdue payment splits: %w", err)
+	}
+	for i := range splits {
+		if err := ps.expireBookingSplit(ctx, &splits[i], now); err != nil {
+			log.Printf("Failed to expire payment split %s: %v", splits[i].ID, err)
+			continue
+		}
+		processed++
+	}
+
+	var series []models.BookingSeries
+	cond, args = noActivePayment("booking_series", PaymentTargetBookingSeries)
+	if err := db.Where("status = ? AND payment_id IS NULL AND payment_due_at <= ?", "active", now).
+		Where(cond, args...).
+		Limit(ps.BatchSize).
+		Find(&series).Error; err != nil {
+		return processed, fmt.Errorf("failed to load unpaid booking series: %w", err)
+	}
+	for i := range series {
+		if err := ps.expireBookingSeries(ctx, &series[i]); err != nil {
+			log.Printf("Failed to expire booking series %s: %v", series[i].ID, err)
+			continue
+		}
+		processed++
+	}
+
+	var lessons []models.Lesson
+	cond, args = noActivePayment("lessons", PaymentTargetLesson)
+	if err := db.Where("status = ? AND payment_id IS NULL AND payment_due_at <= ?", "scheduled", now).
+		Where(cond, args...).
+		Limit(ps.BatchSize).
+		Find(&lessons).Error; err != nil {
+		return processed, fmt.Errorf("failed to load unpaid lessons: %w", err)
+	}
+	for i := range lessons {
+		if err := ps.expireLesson(ctx, &lessons[i]); err != nil {
+			log.Printf("Failed to expire lesson %s: %v", lessons[i].ID, err)
+			continue
+		}
+		processed++
+	}
+
+	var participants []models.ClubEventParticipant
+	cond, args = noActivePayment("club_event_participants", PaymentTargetClubEventRegistration)
+	if err := db.Where("status = ? AND payment_id IS NULL AND payment_due_at <= ?", "registered", now).
+		Where(cond, args...).
+		Limit(ps.BatchSize).
+		Find(&participants).Error; err != nil {
+		return processed, fmt.Errorf("failed to load unpaid registrations: %w", err)
+	}
+	for i := range participants {
+		if err := ps.expireRegistration(ctx, &participants[i]); err != nil {
+			log.Printf("Failed to expire registration %s: %v", participants[i].ID, err)
+			continue
+		}
+		processed++
+	}
+
+	if processed > 0 {
+		ps.notifyEventBus()
+	}
+
+	return processed, nil
+}
+
+// expireBooking 取消逾期未付款的預訂
+func (ps *PaymentService) expireBooking(ctx context.Context, booking *models.Booking) error {
+	return ps.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Booking{}).
+			Where("id = ? AND status = ? AND payment_id IS NULL", booking.ID, "pending").
+			Where(noOpenSplit, models.PaymentSplitOpen).
+			Updates(map[string]interface{}{
+				"status":  "cancelled",
+				"version": gorm.Expr("version + 1"),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		booking.Status = "cancelled"
+		return ps.publish(tx, EventBookingCancelled, "booking", booking.ID, BookingEventPayload{
+			BookingID: booking.ID,
+			CourtID:   booking.CourtID,
+			UserID:    booking.UserID,
+			StartTime: booking.StartTime,
+			EndTime:   booking.EndTime,
+			Status:    booking.Status,
+			OldStatus: "pending",
+		})
+	})
+}
+
+// expireBookingSplit 處理逾期未付清的分攤付款
+//
+// 選擇由預訂者支付時，未付的分攤改為預訂者的一筆分攤，並給予新的付款期限（不晚於預訂開始）；
+// 否則或預訂者也逾期未付時取消分攤及預訂，已付的分攤記錄為全額退款，由退款重試循環處理。
+func (ps *PaymentService) expireBookingSplit(ctx context.Context, split *models.BookingPaymentSplit, now time.Time) error {
+	return ps.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var booking models.Booking
+		if err := tx.Where("id = ?", split.BookingID).First(&booking).Error; err != nil {
+			return err
+		}
+
+		if split.Fallback == models.PaymentSplitFallbackChargeOwner && split.OwnerChargedAt == nil {
+			dueAt := booking.StartTime
+			if ps.HoldDuration > 0 && now.Add(ps.HoldDuration).Before(dueAt) {
+				dueAt = now.Add(ps.HoldDuration)
+			}
+			if dueAt.After(now) {
+				return ps.chargeSplitOwner(tx, split, dueAt, now)
+			}
+		}
+
+		result := tx.Model(&models.BookingPaymentSplit{}).
+			Where("id = ? AND status = ?", split.ID, models.PaymentSplitOpen).
+			Update("status", models.PaymentSplitCancelled)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		if err := tx.Model(&models.BookingPaymentShare{}).
+			Where("split_id = ? AND status = ?", split.ID, models.PaymentShareStatusPending).
+			Update("status", models.PaymentShareStatusCancelled).Error; err != nil {
+			return err
+		}
+
+		var paid []models.BookingPaymentShare
+		if err := tx.Where("split_id = ? AND status = ?", split.ID, models.PaymentShareStatusPaid).Find(&paid).Error; err != nil {
+			return err
+		}
+		reason := paymentSplitExpiredReason
+		for _, share := range paid {
+			if err := tx.Create(&models.Cancellation{
+				TargetType:    PaymentTargetBookingShare,
+				TargetID:      share.ID,
+				PaymentID:     share.PaymentID,
+				CancelledBy:   split.OwnerID,
+				Initiator:     CancellationInitiatorProvider,
+				Reason:        &reason,
+				RefundPercent: 100,
+				PaidAmount:    share.Amount,
+				RefundAmount:  share.Amount,
+				Currency:      split.Currency,
+				RefundStatus:  RefundStatusPending,
+			}).Error; err != nil {
+				return fmt.Errorf("failed to record share cancellation: %w", err)
+			}
+		}
+
+		result = tx.Model(&models.Booking{}).
+			Where("id = ? AND status = ? AND payment_id IS NULL", booking.ID, "pending").
+			Updates(map[string]interface{}{
+				"status":  "cancelled",
+				"version": gorm.Expr("version + 1"),
+			})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		return ps.publish(tx, EventBookingCancelled, "booking", booking.ID, BookingEventPayload{
+			BookingID: booking.ID,
+			CourtID:   booking.CourtID,
+			UserID:    booking.UserID,
+			StartTime: booking.StartTime,
+			EndTime:   booking.EndTime,
+			Status:    "cancelled",
+			OldStatus: "pending",
+		})
+	})
+}
+
+// chargeSplitOwner 將逾期未付的分攤合併為預訂者的一筆分攤，並延長付款期限
+func (ps *PaymentService) chargeSplitOwner(tx *gorm.DB, split *models.BookingPaymentSplit, dueAt, now time.Time) error {
+	result := tx.Model(&models.BookingPaymentSplit{}).
+		Where("id = ? AND status = ? AND owner_charged_at IS NULL", split.ID, models.PaymentSplitOpen).
+		Updates(map[string]interface{}{
+			"owner_charged_at": now,
+			"due_at":           dueAt,
+		})
+	if result.Error != nil || result.RowsAffected == 0 {
+		return result.Error
+	}
+
+	var pending []models.BookingPaymentShare
+	if err := tx.Where("split_id = ? AND status = ?", split.ID, models.PaymentShareStatusPending).Find(&pending).Error; err != nil {
+		return err
+	}
+	remainder := 0.0
+	ids := make([]string, 0, len(pending))
+	for _, share := range pending {
+		remainder += share.Amount
+		ids = append(ids, share.ID)
+	}
+	if len(ids) > 0 {
+		if err := tx.Model(&models.BookingPaymentShare{}).Where("id IN ?", ids).
+			Update("status", models.PaymentShareStatusReassigned).Error; err != nil {
+			return err
+		}
+	}
+
+	share := models.BookingPaymentShare{
+		SplitID:   split.ID,
+		BookingID: split.BookingID,
+		UserID:    split.OwnerID,
+		IsOwner:   true,
+		Amount:    math.Round(remainder*100) / 100,
+		Status:    models.PaymentShareStatusPending,
+	}
+	if err := tx.Create(&share).Error; err != nil {
+		return fmt.Errorf("failed to create owner share: %w", err)
+	}
+	if err := tx.Model(&models.Booking{}).Where("id = ?", split.BookingID).
+		Update("payment_due_at", dueAt).Error; err != nil {
+		return err
+	}
+
+	return ps.publish(tx, EventPaymentShareRequested, "booking_payment_share", share.ID, PaymentShareEventPayload{
+		ShareID:   share.ID,
+		SplitID:   split.ID,
+		BookingID: split.BookingID,
+		UserID:    share.UserID,
+		Amount:    share.Amount,
+		DueAt:     dueAt,
+	})
+}
+
+// expireBookingSeries 取消逾期未付款的整組付款重複預訂及其未付款的各次預訂
+func (ps *PaymentService) expireBookingSeries(ctx context.Context, series *models.BookingSeries) error {
+	return ps.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.BookingSeries{}).
+			Where("id = ? AND status = ? AND payment_id IS NULL", series.ID, "active").
+			Updates(map[string]interface{}{
+				"status":  "cancelled",
+				"version": gorm.Expr("version + 1"),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		var bookings []models.Booking
+		if err := tx.Where("series_id = ? AND status = ? AND payment_id IS NULL", series.ID, "pending").Find(&bookings).Error; err != nil {
+			return err
+		}
+		for i := range bookings {
+			booking := &bookings[i]
+			if err := tx.Model(booking).Updates(map[string]interface{}{
+				"status":  "cancelled",
+				"version": gorm.Expr("version + 1"),
+			}).Error; err != nil {
+				return err
+			}
+			if err := ps.publish(tx, EventBookingCancelled, "booking", booking.ID, BookingEventPayload{
+				BookingID: booking.ID,
+				CourtID:   booking.CourtID,
+				UserID:    booking.UserID,
+				StartTime: booking.StartTime,
+				EndTime:   booking.EndTime,
+				Status:    "cancelled",
+				OldStatus: "pending",
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// expireLesson 取消逾期未付款的課程
+func (ps *PaymentService) expireLesson(ctx context.Context, lesson *models.Lesson) error {
+	return ps.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Lesson{}).
+			Where("id = ? AND status = ? AND payment_id IS NULL", lesson.ID, "scheduled").
+			Updates(map[string]interface{}{
+				"status":        "cancelled",
+				"cancel_reason": paymentExpiredReason,
+				"version":       gorm.Expr("version + 1"),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		return ps.publish(tx, EventLessonCancelled, "lesson", lesson.ID, LessonEventPayload{
+			LessonID:    lesson.ID,
+			CoachID:     lesson.CoachID,
+			StudentID:   lesson.StudentID,
+			ScheduledAt: lesson.ScheduledAt,
+			Status:      "cancelled",
+			Reason:      paymentExpiredReason,
+		})
+	})
+}
+
+// expireRegistration 取消逾期未付款的活動報名並釋出名額
+func (ps *PaymentService) expireRegistration(ctx context.Context, participant *models.ClubEventParticipant) error {
+	return ps.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.ClubEventParticipant{}).
+			Where("id = ? AND status = ? AND payment_id IS NULL", participant.ID, "registered").
+			Update("status", "cancelled")
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		if err := tx.Model(&models.ClubEvent{}).
+			Where("id = ? AND current_participants > 0", participant.EventID).
+			Update("current_participants", gorm.Expr("current_participants - 1")).Error; err != nil {
+			return err
+		}
+		return ps.publishRegistration(tx, EventRegistrationCancelled, participant, "cancelled", paymentExpiredReason)
+	})
+}
+
+// publishRegistration 發布活動報名事件，內容包含俱樂部以便投遞給俱樂部管理者
+func (ps *PaymentService) publishRegistration(tx *gorm.DB, eventType string, participant *models.ClubEventParticipant, status, reason string) error {
+	if ps.eventBus == nil {
+		return nil
+	}
+
+	var event models.ClubEvent
+	if err := tx.Unscoped().Select("id", "club_id").Where("id = ?", participant.EventID).First(&event).Error; err != nil {
+		return fmt.Errorf("failed to load club event: %w", err)
+	}
+	return ps.publish(tx, eventType, "club_event_registration", participant.ID, RegistrationEventPayload{
+		ParticipantID: participant.ID,
+		EventID:       participant.EventID,
+		ClubID:        event.ClubID,
+		UserID:        participant.UserID,
+		Status:        status,
+		Reason:        reason,
+	})
+}
+
+// MarkAuthorized 記錄付款已授權，已進入其他狀態時不處理
+func (ps *PaymentService) MarkAuthorized(ctx context.Context, payment *models.Payment) error {
+	if payment.Status != PaymentStatusPending {
+		return nil
+	}
+	return ps.transition(ps.db.WithContext(ctx), payment, PaymentStatusAuthorized, map[string]interface{}{"authorized_at": time.Now()})
+}
+
+// MarkCaptured 記錄扣款成功並在同一事務中確認付款目標
+//
+// 目標已無法確認時（例如保留已逾期取消）自動退還全部金額，並返回 confirmed = false。
+// 重複的扣款通知不會重複處理。
+func (ps *PaymentService) MarkCaptured(ctx context.Context, payment *models.Payment) (bool, error) {
+	if !IsPaymentActive(payment.Status) {
+		if payment.Status == PaymentStatusCaptured {
+			return true, nil
+		}
+		return false, apperror.New(apperror.CodePaymentInvalidState).With("status", payment.Status)
+	}
+
+	confirmed := false
+	err := ps.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := ps.transition(tx, payment, PaymentStatusCaptured, map[string]interface{}{"captured_at": time.Now()}); err != nil {
+			return err
+		}
+
+		var err error
+		confirmed, err = ps.confirmTarget(tx, payment)
+		if err != nil {
+			return err
+		}
+		// 無法確認的目標隨後全額退款，仍開立發票及折讓單以對應實際的扣款
+		return ps.publish(tx, EventPaymentCaptured, "payment", payment.ID, paymentEventPayload(payment, 0))
+	})
+	if err != nil {
+		return false, err
+	}
+	ps.notifyEventBus()
+
+	if !confirmed {
+		log.Printf("Payment %s captured but %s %s can no longer be confirmed, refunding", payment.ID, payment.TargetType, payment.TargetID)
+		if err := ps.Refund(ctx, payment, payment.Amount-payment.RefundedAmount); err != nil {
+			return false, err
+		}
+	}
+
+	return confirmed, nil
+}
+
+// confirmTarget 將付款關聯到目標並確認，目標已取消、已付款或金額已與付款不符時返回 false
+func (ps *PaymentService) confirmTarget(tx *gorm.DB, payment *models.Payment) (bool, error) {
+	switch payment.TargetType {
+	case PaymentTargetBooking:
+		// 付款期間改為分攤付款或改期後金額已變更時不再確認，由呼叫端退款
+		result := tx.Model(&models.Booking{}).
+			Where("id = ? AND status = ? AND payment_id IS NULL AND total_price = ?", payment.TargetID, "pending", payment.Amount).
+			Where(noOpenSplit, models.PaymentSplitOpen).
+			Updates(map[string]interface{}{
+				"status":     "confirmed",
+				"payment_id": payment.ID,
+				"version":    gorm.Expr("version + 1"),
+			})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return false, result.Error
+		}
+
+		var booking models.Booking
+		if err := tx.Where("id = ?", payment.TargetID).First(&booking).Error; err != nil {
+			return false, err
+		}
+		return true, ps.publish(tx, EventBookingStatusChanged, "booking", booking.ID, BookingEventPayload{
+			BookingID: booking.ID,
+			CourtID:   booking.CourtID,
+			UserID:    booking.UserID,
+			StartTime: booking.StartTime,
+			EndTime:   booking.EndTime,
+			Status:    booking.Status,
+			OldStatus: "pending",
+		})
+
+	case PaymentTargetBookingSeries:
+		// 整組付款確認重複預訂中所有未付款的預訂，全部已取消時視為無法確認
+		var bookings []models.Booking
+		if err := tx.Where("series_id = ? AND status = ? AND payment_id IS NULL", payment.TargetID, "pending").
+			Order("start_time").Find(&bookings).Error; err != nil || len(bookings) == 0 {
+			return false, err
+		}
+		// 發起付款後有預訂改期或取消時，總額與付款金額不符
+		var total float64
+		for _, booking := range bookings {
+			total += booking.TotalPrice
+		}
+		if math.Abs(total-payment.Amount) > amountEpsilon {
+			return false, nil
+		}
+		result := tx.Model(&models.BookingSeries{}).
+			Where("id = ? AND status = ? AND payment_id IS NULL", payment.TargetID, "active").
+			Updates(map[string]interface{}{
+				"payment_id": payment.ID,
+				"version":    gorm.Expr("version + 1"),
+			})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return false, result.Error
+		}
+
+		for i := range bookings {
+			booking := &bookings[i]
+			if err := tx.Model(booking).Updates(map[string]interface{}{
+				"status":     "confirmed",
+				"payment_id": payment.ID,
+				"version":    gorm.Expr("version + 1"),
+			}).Error; err != nil {
+				return false, err
+			}
+			if err := ps.publish(tx, EventBookingStatusChanged, "booking", booking.ID, BookingEventPayload{
+				BookingID: booking.ID,
+				CourtID:   booking.CourtID,
+				UserID:    booking.UserID,
+				StartTime: booking.StartTime,
+				EndTime:   booking.EndTime,
+				Status:    "confirmed",
+				OldStatus: "pending",
+			}); err != nil {
+				return false, err
+			}
+		}
+		return true, nil
+
+	case PaymentTargetLesson:
+		// 課程建立後即為 scheduled，付款完成以 payment_id 表示
+		result := tx.Model(&models.Lesson{}).
+			Where("id = ? AND status = ? AND payment_id IS NULL AND price = ?", payment.TargetID, "scheduled", payment.Amount).
+			Updates(map[string]interface{}{
+				"payment_id": payment.ID,
+				"version":    gorm.Expr("version + 1"),
+			})
+		return result.RowsAffected > 0, result.Error
+
+	case PaymentTargetClubEventRegistration:
+		result := tx.Model(&models.ClubEventParticipant{}).
+			Where("id = ? AND status = ? AND payment_id IS NULL", payment.TargetID, "registered").
+			Where("event_id IN (?)", tx.Model(&models.ClubEvent{}).Select("id").Where("registration_fee = ?", payment.Amount)).
+			Update("payment_id", payment.ID)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return false, result.Error
+		}
+
+		var participant models.ClubEventParticipant
+		if err := tx.Where("id = ?", payment.TargetID).First(&participant).Error; err != nil {
+			return false, err
+		}
+		if err := ps.publishRegistration(tx, EventRegistrationConfirmed, &participant, "registered", ""); err != nil {
+			return false, err
+		}
+		return true, nil
+
+	case PaymentTargetBookingShare:
+		return ps.confirmBookingShare(tx, payment)
+
+	case PaymentTargetClubMembership:
+		result := tx.Model(&models.ClubMember{}).
+			Where("id = ? AND status = ? AND payment_id IS NULL", payment.TargetID, "active").
+			Update("payment_id", payment.ID)
+		return result.RowsAffected > 0, result.Error
+
+	default:
+		return false, fmt.Errorf("unknown payment target type: %s", payment.TargetType)
+	}
+}
+
+// confirmBookingShare 記錄分攤已付款，最後一筆分攤付清時完成分攤並確認預訂
+func (ps *PaymentService) confirmBookingShare(tx *gorm.DB, payment *models.Payment) (bool, error) {
+	var share models.BookingPaymentShare
+	if err := tx.Where("id = ?", payment.TargetID).First(&share).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	// 先更新分攤付款取得列鎖，並發的分攤扣款依序處理，才能正確判斷是否全部付清
+	now := time.Now()
+	result := tx.Model(&models.BookingPaymentSplit{}).
+		Where("id = ? AND status = ?", share.SplitID, models.PaymentSplitOpen).
+		Update("updated_at", now)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return false, result.Error
+	}
+
+	result = tx.Model(&models.BookingPaymentShare{}).
+		Where("id = ? AND status = ? AND payment_id IS NULL", share.ID, models.PaymentShareStatusPending).
+		Updates(map[string]interface{}{
+			"status":     models.PaymentShareStatusPaid,
+			"payment_id": payment.ID,
+			"paid_at":    now,
+		})
+	if result.Error != nil || result.RowsAffected == 0 {
+		return false, result.Error
+	}
+
+	var remaining int64
+	if err := tx.Model(&models.BookingPaymentShare{}).
+		Where("split_id = ? AND status = ?", share.SplitID, models.PaymentShareStatusPending).
+		Count(&remaining).Error; err != nil {
+		return false, err
+	}
+	if remaining > 0 {
+		return true, nil
+	}
+
+	if err := tx.Model(&models.BookingPaymentSplit{}).Where("id = ?", share.SplitID).
+		Update("status", models.PaymentSplitCompleted).Error; err != nil {
+		return false, err
+	}
+
+	// 分攤付清的預訂沒有單一付款，payment_id 保持為空
+	result = tx.Model(&models.Booking{}).
+		Where("id = ? AND status = ?", share.BookingID, "pending").
+		Updates(map[string]interface{}{
+			"status":  "confirmed",
+			"version": gorm.Expr("version + 1"),
+		})
+	if result.Error != nil || result.RowsAffected == 0 {
+		return true, result.Error
+	}
+
+	var booking models.Booking
+	if err := tx.Where("id = ?", share.BookingID).First(&booking).Error; err != nil {
+		return false, err
+	}
+	return true, ps.publish(tx, EventBookingStatusChanged, "booking", booking.ID, BookingEventPayload{
+		BookingID: booking.ID,
+		CourtID:   booking.CourtID,
+		UserID:    booking.UserID,
+		StartTime: booking.StartTime,
+		EndTime:   booking.EndTime,
+		Status:    booking.Status,
+		OldStatus: "pending",
+	})
+}
+
+// MarkFailed 記錄付款失敗，目標保持未付款狀態，可重新發起付款
+func (ps *PaymentService) MarkFailed(ctx context.Context, payment *models.Payment, reason string) error {
+	if payment.Status == PaymentStatusFailed {
+		return nil
+	}
+	return ps.transition(ps.db.WithContext(ctx), payment, PaymentStatusFailed, map[string]interface{}{"failure_reason": reason})
+}
+
+// Cancel 在服務商取消尚未扣款的付款
+func (ps *PaymentService) Cancel(ctx context.Context, payment *models.Payment) error {
+	if payment.Status == PaymentStatusCancelled {
+		return nil
+	}
+	if !IsPaymentActive(payment.Status) {
+		return apperror.New(apperror.CodePaymentInvalidState).With("status", payment.Status)
+	}
+	if err := ps.provider.Cancel(ctx, payment.ProviderPaymentID); err != nil {
+		return apperror.Wrap(apperror.CodePaymentProviderError, err)
+	}
+	return ps.transition(ps.db.WithContext(ctx), payment, PaymentStatusCancelled, map[string]interface{}{"cancelled_at": time.Now()})
+}
+
+// Refund 退還已扣款的部分或全部金額
+func (ps *PaymentService) Refund(ctx context.Context, payment *models.Payment, amount float64) error {
+	if payment.Status != PaymentStatusCaptured && payment.Status != PaymentStatusPartiallyRefunded {
+		return apperror.New(apperror.CodePaymentInvalidState).With("status", payment.Status)
+	}
+	if amount <= 0 || amount > payment.Amount-payment.RefundedAmount+amountEpsilon {
+		return fmt.Errorf("refund amount %.2f exceeds remaining amount %.2f", amount, payment.Amount-payment.RefundedAmount)
+	}
+
+	if err := ps.provider.Refund(ctx, payment.ProviderPaymentID, amount); err != nil {
+		return apperror.Wrap(apperror.CodePaymentProviderError, err)
+	}
+
+	return ps.recordRefund(ps.db.WithContext(ctx), payment, payment.RefundedAmount+amount)
+}
+
+// recordRefund 更新累計退款金額，全部退還時轉為 refunded
+func (ps *PaymentService) recordRefund(db *gorm.DB, payment *models.Payment, total float64) error {
+	if total <= payment.RefundedAmount+amountEpsilon {
+		return nil
+	}
+
+	status := PaymentStatusPartiallyRefunded
+	if total >= payment.Amount-amountEpsilon {
+		status = PaymentStatusRefunded
+		total = payment.Amount
+	}
+	if !CanTransitionPayment(payment.Status, status) {
+		return apperror.New(apperror.CodePaymentInvalidState).With("status", payment.Status)
+	}
+
+	updated := *payment
+	updated.Status = status
+	updated.RefundedAmount = total
+	err := db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Payment{}).
+			Where("id = ? AND status = ? AND refunded_amount = ?", payment.ID, payment.Status, payment.RefundedAmount).
+			Updates(map[string]interface{}{
+				"status":          status,
+				"refunded_amount": total,
+			})
+		if result.Error != nil {
+			return fmt.Errorf("failed to record refund: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ps.conflict(tx, payment)
+		}
+		return ps.publish(tx, EventPaymentRefunded, "payment", payment.ID, paymentEventPayload(&updated, total-payment.RefundedAmount))
+	})
+	if err != nil {
+		return err
+	}
+	payment.Status = status
+	payment.RefundedAmount = total
+	ps.notifyEventBus()
+	return nil
+}
+
+// paymentEventPayload 以付款目前的狀態建立事件內容
+func paymentEventPayload(payment *models.Payment, refundAmount float64) PaymentEventPayload {
+	return PaymentEventPayload{
+		PaymentID:      payment.ID,
+		UserID:         payment.UserID,
+		TargetType:     payment.TargetType,
+		TargetID:       payment.TargetID,
+		Amount:         payment.Amount,
+		Currency:       payment.Currency,
+		RefundedAmount: payment.RefundedAmount,
+		RefundAmount:   refundAmount,
+	}
+}
+
+// HandleWebhook 處理服務商的付款通知
+//
+// 通知可能重複或亂序送達，已處理過或過時的通知直接忽略，避免服務商不斷重試。
+func (ps *PaymentService) HandleWebhook(ctx context.Context, payload []byte, header http.Header) error {
+	event, err := ps.provider.ParseWebhook(payload, header)
+	if err != nil {
+		if errors.Is(err, ErrInvalidPaymentSignature) {
+			return apperror.Wrap(apperror.CodePaymentInvalidSignature, err)
+		}
+		return apperror.Wrap(apperror.CodeValidation, err)
+	}
+
+	var payment models.Payment
+	if err := ps.db.WithContext(ctx).
+		Where("provider = ? AND provider_payment_id = ?", ps.provider.Name(), event.ProviderPaymentID).
+		First(&payment).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apperror.New(apperror.CodePaymentNotFound)
+		}
+		return fmt.Errorf("failed to load payment: %w", err)
+	}
+
+	switch event.Type {
+	case PaymentEventAuthorized:
+		err = ps.MarkAuthorized(ctx, &payment)
+	case PaymentEventCaptured:
+		_, err = ps.MarkCaptured(ctx, &payment)
+	case PaymentEventFailed:
+		err = ps.MarkFailed(ctx, &payment, event.FailureReason)
+	case PaymentEventCancelled:
+		if payment.Status != PaymentStatusCancelled {
+			err = ps.transition(ps.db.WithContext(ctx), &payment, PaymentStatusCancelled, map[string]interface{}{"cancelled_at": time.Now()})
+		}
+	case PaymentEventRefunded:
+		err = ps.recordRefund(ps.db.WithContext(ctx), &payment, event.Amount)
+	default:
+		log.Printf("Ignoring payment webhook %s of type %s", event.ID, event.Type)
+		return nil
+	}
+
+	if apperror.HasCode(err, apperror.CodePaymentInvalidState) {
+		log.Printf("Ignoring stale payment webhook %s for payment %s: %v", event.Type, payment.ID, err)
+		return nil
+	}
+	return err
+}
+
+// transition 以比較並交換的方式更新付款狀態
+func (ps *PaymentService) transition(db *gorm.DB, payment *models.Payment, to string, updates map[string]interface{}) error {
+	if !CanTransitionPayment(payment.Status, to) {
+		return apperror.New(apperror.CodePaymentInvalidState).With("status", payment.Status)
+	}
+
+	updates["status"] = to
+	result := db.Model(&models.Payment{}).
+		Where("id = ? AND status = ?", payment.ID, payment.Status).
+		Updates(updates)
+	if result.Error != nil {
+		return fmt.Errorf("failed to update payment status: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ps.conflict(db, payment)
+	}
+
+	payment.Status = to
+	return nil
+}
+
+// conflict 付款已被並發修改，重新讀取後返回目前狀態
+func (ps *PaymentService) conflict(db *gorm.DB, payment *models.Payment) error {
+	var current models.Payment
+	if err := db.Select("status").Where("id = ?", payment.ID).First(&current).Error; err != nil {
+		return fmt.Errorf("failed to reload payment: %w", err)
+	}
+	return apperror.New(apperror.CodePaymentInvalidState).With("status", current.Status)
+}
+
+// publish 在事務中發布領域事件
+func (ps *PaymentService) publish(tx *gorm.DB, eventType, aggregateType, aggregateID string, payload interface{}) error {
+	if ps.eventBus == nil {
+		return nil
+	}
+	return ps.eventBus.Publish(tx, eventType, aggregateType, aggregateID, payload)
+}
+
+// notifyEventBus 事務提交後提示事件派送
+func (ps *PaymentService) notifyEventBus() {
+	if ps.eventBus != nil {
+		ps.eventBus.Notify()
+	}
+}
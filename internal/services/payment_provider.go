@@ -0,0 +1,229 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"tennis-platform/backend/internal/config"
+	"time"
+)
+
+// 付款通知的請求頭
+const (
+	PaymentHeaderTimestamp = "X-Payment-Timestamp"
+	PaymentHeaderSignature = "X-Payment-Signature"
+)
+
+// 付款服務商通知的事件類型
+const (
+	PaymentEventAuthorized = "payment.authorized"
+	PaymentEventCaptured   = "payment.captured"
+	PaymentEventFailed     = "payment.failed"
+	PaymentEventCancelled  = "payment.cancelled"
+	PaymentEventRefunded   = "payment.refunded"
+)
+
+// 付款通知允許的時間誤差
+const paymentWebhookTolerance = 5 * time.Minute
+
+// ErrInvalidPaymentSignature 付款通知簽名無效
+var ErrInvalidPaymentSignature = errors.New("invalid payment webhook signature")
+
+// PaymentIntentParams 建立付款意圖的參數
+type PaymentIntentParams struct {
+	Amount      float64
+	Currency    string
+	Description string
+	Metadata    map[string]string
+}
+
+// PaymentIntent 付款服務商返回的付款意圖
+type PaymentIntent struct {
+	ProviderPaymentID string
+	ClientSecret      string // 前端以此完成付款
+}
+
+// PaymentWebhookEvent 驗證後的付款通知
+type PaymentWebhookEvent struct {
+	ID                string  `json:"id"`
+	Type              string  `json:"type"`
+	ProviderPaymentID string  `json:"paymentId"`
+	Amount            float64 `json:"amount"` // refunded 事件為累計退款金額
+	FailureReason     string  `json:"failureReason,omitempty"`
+}
+
+// PaymentProvider 付款服務商接口
+//
+// 付款先建立意圖，付款人在前端授權後由服務器扣款；授權、扣款、失敗及退款結果也可能經由通知非同步送達。
+type PaymentProvider interface {
+	// Name 服務商名稱，用於付款記錄及通知路由
+	Name() string
+	// CreateIntent 建立付款意圖
+	CreateIntent(ctx context.Context, params PaymentIntentParams) (*PaymentIntent, error)
+	// Capture 扣款
+	Capture(ctx context.Context, providerPaymentID string) error
+	// Cancel 取消尚未扣款的付款意圖
+	Cancel(ctx context.Context, providerPaymentID string) error
+	// Refund 退還已扣款的部分或全部金額
+	Refund(ctx context.Context, providerPaymentID string, amount float64) error
+	// ParseWebhook 驗證並解析付款通知，簽名無效時返回 ErrInvalidPaymentSignature
+	ParseWebhook(payload []byte, header http.Header) (*PaymentWebhookEvent, error)
+}
+
+// NewPaymentProvider 依配置創建付款服務商
+// 本機付款服務商不會實際收款，只在開發及測試環境可用，其他環境未設置服務商時返回錯誤
+func NewPaymentProvider(cfg *config.Config) (PaymentProvider, error) {
+	switch cfg.Payment.Provider {
+	case "", "local":
+		if cfg.Env != "development" && cfg.Env != "test" {
+			return nil, fmt.Errorf("local payment provider is not allowed in %q environment, set PAYMENT_PROVIDER", cfg.Env)
+		}
+		return NewLocalPaymentProvider(cfg.Payment.WebhookSecret), nil
+	default:
+		return nil, fmt.Errorf("unsupported payment provider: %s", cfg.Payment.Provider)
+	}
+}
+
+// localIntent 本機付款意圖
+type localIntent struct {
+	amount   float64
+	status   string
+	refunded float64
+}
+
+// LocalPaymentProvider 本機模擬的付款服務商，僅供開發及測試使用
+//
+// 付款意圖保存在記憶體中，建立後即可直接扣款；服務重啟後尚未完成的意圖會遺失。
+type LocalPaymentProvider struct {
+	secret  string
+	mu      sync.Mutex
+	intents map[string]*localIntent
+}
+
+// NewLocalPaymentProvider 創建本機付款服務商
+func NewLocalPaymentProvider(webhookSecret string) *LocalPaymentProvider {
+	return &LocalPaymentProvider{
+		secret:  webhookSecret,
+		intents: make(map[string]*localIntent),
+	}
+}
+
+// Name 服務商名稱
+func (lp *LocalPaymentProvider) Name() string {
+	return "local"
+}
+
+// CreateIntent 建立付款意圖
+func (lp *LocalPaymentProvider) CreateIntent(ctx context.Context, params PaymentIntentParams) (*PaymentIntent, error) {
+	if params.Amount <= 0 {
+		return nil, errors.New("amount must be positive")
+	}
+
+	buf := make([]byte, 12)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, fmt.Errorf("failed to generate payment id: %w", err)
+	}
+	id := "pi_local_" + hex.EncodeToString(buf)
+
+	lp.mu.Lock()
+	defer lp.mu.Unlock()
+	lp.intents[id] = &localIntent{amount: params.Amount, status: PaymentStatusPending}
+
+	return &PaymentIntent{ProviderPaymentID: id, ClientSecret: id + "_secret"}, nil
+}
+
+// Capture 扣款，重複扣款視為成功
+func (lp *LocalPaymentProvider) Capture(ctx context.Context, providerPaymentID string) error {
+	lp.mu.Lock()
+	defer lp.mu.Unlock()
+
+	intent, ok := lp.intents[providerPaymentID]
+	if !ok {
+		return fmt.Errorf("payment intent %s not found", providerPaymentID)
+	}
+	switch intent.status {
+	case PaymentStatusPending, PaymentStatusAuthorized:
+		intent.status = PaymentStatusCaptured
+	case PaymentStatusCaptured:
+	default:
+		return fmt.Errorf("payment intent %s is %s", providerPaymentID, intent.status)
+	}
+	return nil
+}
+
+// Cancel 取消尚未扣款的付款意圖
+func (lp *LocalPaymentProvider) Cancel(ctx context.Context, providerPaymentID string) error {
+	lp.mu.Lock()
+	defer lp.mu.Unlock()
+
+	intent, ok := lp.intents[providerPaymentID]
+	if !ok {
+		// 服務重啟後意圖已遺失，視為已取消
+		return nil
+	}
+	switch intent.status {
+	case PaymentStatusPending, PaymentStatusAuthorized:
+		intent.status = PaymentStatusCancelled
+	case PaymentStatusCancelled:
+	default:
+		return fmt.Errorf("payment intent %s is %s", providerPaymentID, intent.status)
+	}
+	return nil
+}
+
+// Refund 退款
+func (lp *LocalPaymentProvider) Refund(ctx context.Context, providerPaymentID string, amount float64) error {
+	lp.mu.Lock()
+	defer lp.mu.Unlock()
+
+	intent, ok := lp.intents[providerPaymentID]
+	if !ok {
+		return fmt.Errorf("payment intent %s not found", providerPaymentID)
+	}
+	if intent.status != PaymentStatusCaptured {
+		return fmt.Errorf("payment intent %s is %s", providerPaymentID, intent.status)
+	}
+	if amount <= 0 || intent.refunded+amount > intent.amount+0.005 {
+		return fmt.Errorf("refund amount %.2f exceeds remaining amount", amount)
+	}
+	intent.refunded += amount
+	return nil
+}
+
+// ParseWebhook 驗證並解析付款通知
+func (lp *LocalPaymentProvider) ParseWebhook(payload []byte, header http.Header) (*PaymentWebhookEvent, error) {
+	err := VerifyWebhookSignature(lp.secret, header.Get(PaymentHeaderSignature), header.Get(PaymentHeaderTimestamp), payload, paymentWebhookTolerance)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPaymentSignature, err)
+	}
+
+	var event PaymentWebhookEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return nil, fmt.Errorf("invalid payment webhook payload: %w", err)
+	}
+	if event.Type == "" || event.ProviderPaymentID == "" {
+		return nil, errors.New("invalid payment webhook payload: missing type or paymentId")
+	}
+
+	return &event, nil
+}
+
+// SignedWebhook 產生帶簽名的付款通知（供開發工具及測試模擬服務商通知）
+func (lp *LocalPaymentProvider) SignedWebhook(event PaymentWebhookEvent) ([]byte, http.Header, error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	timestamp := time.Now().Unix()
+	header := http.Header{}
+	header.Set(PaymentHeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	header.Set(PaymentHeaderSignature, SignWebhookPayload(lp.secret, timestamp, payload))
+	return payload, header, nil
+}
@@ -0,0 +1,281 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"tennis-platform/backend/internal/apperror"
+	"tennis-platform/backend/internal/config"
+	"tennis-platform/backend/internal/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupPaymentTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+
+	// 手動創建表結構，只包含付款流程需要的欄位
+	for _, stmt := range []string{
+		`CREATE TABLE payments (id TEXT PRIMARY KEY, user_id TEXT NOT NULL, target_type TEXT NOT NULL, target_id TEXT NOT NULL, amount REAL NOT NULL, currency TEXT, status TEXT NOT NULL, provider TEXT NOT NULL, provider_payment_id TEXT NOT NULL UNIQUE, client_secret TEXT, refunded_amount REAL NOT NULL DEFAULT 0, failure_reason TEXT, expires_at DATETIME, authorized_at DATETIME, captured_at DATETIME, cancelled_at DATETIME, created_at DATETIME, updated_at DATETIME)`,
+		`CREATE TABLE bookings (id TEXT PRIMARY KEY, court_id TEXT, series_id TEXT, user_id TEXT, start_time DATETIME, end_time DATETIME, total_price REAL, status TEXT, payment_id TEXT, payment_due_at DATETIME, version INTEGER DEFAULT 1, updated_at DATETIME, deleted_at DATETIME)`,
+		`CREATE TABLE booking_payment_splits (id TEXT PRIMARY KEY, booking_id TEXT NOT NULL, owner_id TEXT NOT NULL, match_id TEXT, mode TEXT NOT NULL, fallback TEXT NOT NULL, status TEXT NOT NULL, due_at DATETIME NOT NULL, owner_charged_at DATETIME, total REAL NOT NULL, currency TEXT NOT NULL DEFAULT 'TWD', created_at DATETIME, updated_at DATETIME)`,
+		`CREATE TABLE booking_payment_shares (id TEXT PRIMARY KEY, split_id TEXT NOT NULL, booking_id TEXT NOT NULL, user_id TEXT NOT NULL, is_owner BOOLEAN NOT NULL DEFAULT false, amount REAL NOT NULL, status TEXT NOT NULL, payment_id TEXT, paid_at DATETIME, created_at DATETIME, updated_at DATETIME)`,
+		`CREATE TABLE booking_series (id TEXT PRIMARY KEY, user_id TEXT, status TEXT, billing_mode TEXT, payment_id TEXT, payment_due_at DATETIME, version INTEGER DEFAULT 1, updated_at DATETIME, deleted_at DATETIME)`,
+		`CREATE TABLE lessons (id TEXT PRIMARY KEY, coach_id TEXT, student_id TEXT, scheduled_at DATETIME, price REAL, status TEXT, payment_id TEXT, payment_due_at DATETIME, cancel_reason TEXT, version INTEGER DEFAULT 1, updated_at DATETIME, deleted_at DATETIME)`,
+		`CREATE TABLE club_events (id TEXT PRIMARY KEY, current_participants INTEGER DEFAULT 0, updated_at DATETIME, deleted_at DATETIME)`,
+		`CREATE TABLE club_event_participants (id TEXT PRIMARY KEY, event_id TEXT, user_id TEXT, status TEXT, payment_id TEXT, payment_due_at DATETIME, deleted_at DATETIME)`,
+	} {
+		require.NoError(t, db.Exec(stmt).Error)
+	}
+	return db
+}
+
+// createPendingBooking 建立待付款的預訂及對應的付款意圖
+func createPendingBooking(t *testing.T, db *gorm.DB, provider *LocalPaymentProvider, id string, dueAt time.Time) *models.Payment {
+	require.NoError(t, db.Exec(`INSERT INTO bookings (id, user_id, total_price, status, payment_due_at) VALUES (?, 'user-1', 800, 'pending', ?)`, id, dueAt).Error)
+
+	intent, err := provider.CreateIntent(context.Background(), PaymentIntentParams{Amount: 800, Currency: "TWD"})
+	require.NoError(t, err)
+
+	payment := &models.Payment{
+		UserID:            "user-1",
+		TargetType:        PaymentTargetBooking,
+		TargetID:          id,
+		Amount:            800,
+		Currency:          "TWD",
+		Status:            PaymentStatusPending,
+		Provider:          provider.Name(),
+		ProviderPaymentID: intent.ProviderPaymentID,
+		ExpiresAt:         &dueAt,
+	}
+	require.NoError(t, db.Create(payment).Error)
+	return payment
+}
+
+func TestCanTransitionPayment(t *testing.T) {
+	tests := []struct {
+		from, to string
+		want     bool
+	}{
+		{PaymentStatusPending, PaymentStatusCaptured, true},
+		{PaymentStatusAuthorized, PaymentStatusExpired, true},
+		{PaymentStatusCaptured, PaymentStatusRefunded, true},
+		{PaymentStatusPartiallyRefunded, PaymentStatusPartiallyRefunded, true},
+		{PaymentStatusCaptured, PaymentStatusCancelled, false},
+		{PaymentStatusExpired, PaymentStatusCaptured, false},
+		{PaymentStatusRefunded, PaymentStatusPartiallyRefunded, false},
+		{PaymentStatusFailed, PaymentStatusPending, false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, CanTransitionPayment(tt.from, tt.to), "%s -> %s", tt.from, tt.to)
+	}
+}
+
+func TestNewPaymentProvider(t *testing.T) {
+	tests := []struct {
+		env, provider string
+		wantErr       bool
+	}{
+		{"development", "", false},
+		{"test", "local", false},
+		{"production", "", true},
+		{"production", "local", true},
+		{"staging", "local", true},
+		{"development", "unknown", true},
+	}
+
+	for _, tt := range tests {
+		cfg := &config.Config{Env: tt.env, Payment: config.PaymentConfig{Provider: tt.provider}}
+		provider, err := NewPaymentProvider(cfg)
+		if tt.wantErr {
+			assert.Error(t, err, "%s/%s", tt.env, tt.provider)
+			continue
+		}
+		require.NoError(t, err, "%s/%s", tt.env, tt.provider)
+		assert.Equal(t, "local", provider.Name())
+	}
+}
+
+func TestPaymentService_MarkCaptured(t *testing.T) {
+	db := setupPaymentTestDB(t)
+	provider := NewLocalPaymentProvider("secret")
+	service := NewPaymentService(db, provider, nil, 15*time.Minute)
+	ctx := context.Background()
+
+	payment := createPendingBooking(t, db, provider, "booking-1", time.Now().Add(10*time.Minute))
+	require.NoError(t, provider.Capture(ctx, payment.ProviderPaymentID))
+
+	confirmed, err := service.MarkCaptured(ctx, payment)
+	require.NoError(t, err)
+	assert.True(t, confirmed)
+
+	var booking models.Booking
+	require.NoError(t, db.First(&booking, "id = ?", "booking-1").Error)
+	assert.Equal(t, "confirmed", booking.Status)
+	require.NotNil(t, booking.PaymentID)
+	assert.Equal(t, payment.ID, *booking.PaymentID)
+	assert.Equal(t, int64(2), booking.Version)
+
+	// 重複的扣款通知不重複處理
+	confirmed, err = service.MarkCaptured(ctx, payment)
+	require.NoError(t, err)
+	assert.True(t, confirmed)
+
+	// 部分退款後再全額退款
+	require.NoError(t, service.Refund(ctx, payment, 300))
+	assert.Equal(t, PaymentStatusPartiallyRefunded, payment.Status)
+	assert.Error(t, service.Refund(ctx, payment, 600))
+	require.NoError(t, service.Refund(ctx, payment, 500))
+	assert.Equal(t, PaymentStatusRefunded, payment.Status)
+
+	var stored models.Payment
+	require.NoError(t, db.First(&stored, "id = ?", payment.ID).Error)
+	assert.Equal(t, PaymentStatusRefunded, stored.Status)
+	assert.InDelta(t, 800, stored.RefundedAmount, 0.001)
+}
+
+func TestPaymentService_ExpireDue(t *testing.T) {
+	db := setupPaymentTestDB(t)
+	provider := NewLocalPaymentProvider("secret")
+	service := NewPaymentService(db, provider, nil, 15*time.Minute)
+	ctx := context.Background()
+
+	past := time.Now().Add(-time.Minute)
+	future := time.Now().Add(10 * time.Minute)
+
+	// 逾期且付款仍未完成：付款及預訂一併取消
+	expiredPayment := createPendingBooking(t, db, provider, "booking-expired", past)
+	// 未逾期的預訂保持不變
+	createPendingBooking(t, db, provider, "booking-active", future)
+	// 逾期但沒有發起付款
+	require.NoError(t, db.Exec(`INSERT INTO bookings (id, user_id, total_price, status, payment_due_at) VALUES ('booking-unpaid', 'user-1', 800, 'pending', ?)`, past).Error)
+	// 付款期限欄位為空的舊預訂不受影響
+	require.NoError(t, db.Exec(`INSERT INTO bookings (id, user_id, total_price, status) VALUES ('booking-legacy', 'user-1', 800, 'pending')`).Error)
+	require.NoError(t, db.Exec(`INSERT INTO lessons (id, coach_id, student_id, price, status, payment_due_at) VALUES ('lesson-1', 'coach-1', 'user-1', 1200, 'scheduled', ?)`, past).Error)
+	// 整組付款逾期的重複預訂連同未付款的各次預訂一併取消
+	require.NoError(t, db.Exec(`INSERT INTO booking_series (id, user_id, status, billing_mode, payment_due_at) VALUES ('series-1', 'user-1', 'active', 'aggregate', ?)`, past).Error)
+	require.NoError(t, db.Exec(`INSERT INTO bookings (id, series_id, user_id, total_price, status) VALUES ('series-booking-1', 'series-1', 'user-1', 800, 'pending'), ('series-booking-2', 'series-1', 'user-1', 800, 'pending')`).Error)
+	require.NoError(t, db.Exec(`INSERT INTO club_events (id, current_participants) VALUES ('event-1', 3)`).Error)
+	require.NoError(t, db.Exec(`INSERT INTO club_event_participants (id, event_id, user_id, status, payment_due_at) VALUES ('participant-1', 'event-1', 'user-1', 'registered', ?)`, past).Error)
+
+	processed, err := service.ExpireDue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 6, processed)
+
+	statusOf := func(table, id string) string {
+		var status string
+		require.NoError(t, db.Table(table).Select("status").Where("id = ?", id).Scan(&status).Error)
+		return status
+	}
+	assert.Equal(t, PaymentStatusExpired, statusOf("payments", expiredPayment.ID))
+	assert.Equal(t, "cancelled", statusOf("bookings", "booking-expired"))
+	assert.Equal(t, "cancelled", statusOf("bookings", "booking-unpaid"))
+	assert.Equal(t, "pending", statusOf("bookings", "booking-active"))
+	assert.Equal(t, "pending", statusOf("bookings", "booking-legacy"))
+	assert.Equal(t, "cancelled", statusOf("club_event_participants", "participant-1"))
+	assert.Equal(t, "cancelled", statusOf("booking_series", "series-1"))
+	assert.Equal(t, "cancelled", statusOf("bookings", "series-booking-1"))
+	assert.Equal(t, "cancelled", statusOf("bookings", "series-booking-2"))
+
+	var lesson models.Lesson
+	require.NoError(t, db.First(&lesson, "id = ?", "lesson-1").Error)
+	assert.Equal(t, "cancelled", lesson.Status)
+	require.NotNil(t, lesson.CancelReason)
+	assert.Equal(t, paymentExpiredReason, *lesson.CancelReason)
+
+	var participants int
+	require.NoError(t, db.Table("club_events").Select("current_participants").Where("id = ?", "event-1").Scan(&participants).Error)
+	assert.Equal(t, 2, participants)
+
+	processed, err = service.ExpireDue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, processed)
+}
+
+func TestPaymentService_CaptureAfterHoldCancelledRefunds(t *testing.T) {
+	db := setupPaymentTestDB(t)
+	provider := NewLocalPaymentProvider("secret")
+	service := NewPaymentService(db, provider, nil, 15*time.Minute)
+	ctx := context.Background()
+
+	payment := createPendingBooking(t, db, provider, "booking-1", time.Now().Add(10*time.Minute))
+	require.NoError(t, db.Exec(`UPDATE bookings SET status = 'cancelled' WHERE id = 'booking-1'`).Error)
+	require.NoError(t, provider.Capture(ctx, payment.ProviderPaymentID))
+
+	confirmed, err := service.MarkCaptured(ctx, payment)
+	require.NoError(t, err)
+	assert.False(t, confirmed)
+	assert.Equal(t, PaymentStatusRefunded, payment.Status)
+}
+
+func TestPaymentService_CaptureAfterPriceChangedRefunds(t *testing.T) {
+	db := setupPaymentTestDB(t)
+	provider := NewLocalPaymentProvider("secret")
+	service := NewPaymentService(db, provider, nil, 15*time.Minute)
+	ctx := context.Background()
+
+	// 改期後金額變更，以原金額完成的扣款不能確認預訂
+	payment := createPendingBooking(t, db, provider, "booking-1", time.Now().Add(10*time.Minute))
+	require.NoError(t, db.Exec(`UPDATE bookings SET total_price = 1200 WHERE id = 'booking-1'`).Error)
+	require.NoError(t, provider.Capture(ctx, payment.ProviderPaymentID))
+
+	confirmed, err := service.MarkCaptured(ctx, payment)
+	require.NoError(t, err)
+	assert.False(t, confirmed)
+	assert.Equal(t, PaymentStatusRefunded, payment.Status)
+
+	var booking models.Booking
+	require.NoError(t, db.First(&booking, "id = ?", "booking-1").Error)
+	assert.Equal(t, "pending", booking.Status)
+	assert.Nil(t, booking.PaymentID)
+}
+
+func TestPaymentService_HandleWebhook(t *testing.T) {
+	db := setupPaymentTestDB(t)
+	provider := NewLocalPaymentProvider("secret")
+	service := NewPaymentService(db, provider, nil, 15*time.Minute)
+	ctx := context.Background()
+
+	payment := createPendingBooking(t, db, provider, "booking-1", time.Now().Add(10*time.Minute))
+
+	send := func(eventType string, amount float64) error {
+		payload, header, err := provider.SignedWebhook(PaymentWebhookEvent{ID: "evt-" + eventType, Type: eventType, ProviderPaymentID: payment.ProviderPaymentID, Amount: amount})
+		require.NoError(t, err)
+		return service.HandleWebhook(ctx, payload, header)
+	}
+
+	require.NoError(t, send(PaymentEventAuthorized, 0))
+	require.NoError(t, send(PaymentEventCaptured, 0))
+	// 重複及過時的通知直接忽略
+	require.NoError(t, send(PaymentEventCaptured, 0))
+	require.NoError(t, send(PaymentEventAuthorized, 0))
+
+	var booking models.Booking
+	require.NoError(t, db.First(&booking, "id = ?", "booking-1").Error)
+	assert.Equal(t, "confirmed", booking.Status)
+
+	require.NoError(t, send(PaymentEventRefunded, 800))
+	var stored models.Payment
+	require.NoError(t, db.First(&stored, "id = ?", payment.ID).Error)
+	assert.Equal(t, PaymentStatusRefunded, stored.Status)
+	assert.NotNil(t, stored.AuthorizedAt)
+	assert.NotNil(t, stored.CapturedAt)
+
+	// 簽名錯誤
+	payload, header, err := provider.SignedWebhook(PaymentWebhookEvent{ID: "evt-x", Type: PaymentEventFailed, ProviderPaymentID: payment.ProviderPaymentID})
+	require.NoError(t, err)
+	header.Set(PaymentHeaderSignature, "v1=bad")
+	assert.True(t, apperror.HasCode(service.HandleWebhook(ctx, payload, header), apperror.CodePaymentInvalidSignature))
+	assert.True(t, apperror.HasCode(service.HandleWebhook(ctx, payload, http.Header{}), apperror.CodePaymentInvalidSignature))
+
+	// 未知的付款
+	payload, header, err = provider.SignedWebhook(PaymentWebhookEvent{ID: "evt-y", Type: PaymentEventCaptured, ProviderPaymentID: "pi_unknown"})
+	require.NoError(t, err)
+	assert.True(t, apperror.HasCode(service.HandleWebhook(ctx, payload, header), apperror.CodePaymentNotFound))
+}
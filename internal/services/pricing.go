@@ -0,0 +1,305 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"tennis-platform/backend/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 價格規則類型
+const (
+	PriceRuleKindRate      = "rate"      // 取代場地的每小時價格
+	PriceRuleKindSurcharge = "surcharge" // 在每小時價格之上加收，如夜間燈光
+)
+
+// 價格規則適用對象
+const (
+	PriceAudienceAll    = "all"
+	PriceAudienceMember = "member" // 規則指定俱樂部的有效會員
+	PriceAudiencePublic = "public" // 非規則指定俱樂部的會員
+)
+
+// PricingService 場地計價服務
+//
+// 依場地的價格規則把預訂時段切分為套用同一價格的區段，按每段時長比例計價。
+type PricingService struct {
+	db *gorm.DB
+}
+
+// NewPricingService 創建新的場地計價服務
+func NewPricingService(db *gorm.DB) *PricingService {
+	return &PricingService{db: db}
+}
+
+// Rules 獲取場地啟用中的價格規則，按優先級由高到低排序，同優先級先建立者優先
+func (ps *PricingService) Rules(ctx context.Context, courtID string) ([]models.CourtPriceRule, error) {
+	var rules []models.CourtPriceRule
+	if err := ps.db.WithContext(ctx).
+		Where("court_id = ? AND is_active = ?", courtID, true).
+		Order("priority DESC, created_at ASC").
+		Find(&rules).Error; err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// MemberClubs 獲取用戶在指定時間為有效會員的俱樂部，userID 為空時返回空集合
+func (ps *PricingService) MemberClubs(ctx context.Context, userID string, at time.Time) (map[string]bool, error) {
+	clubs := make(map[string]bool)
+	if userID == "" {
+		return clubs, nil
+	}
+
+	var clubIDs []string
+	if err := ps.db.WithContext(ctx).Model(&models.ClubMember{}).
+		Where("user_id = ? AND status = ? AND (expires_at IS NULL OR expires_at > ?)", userID, "active", at).
+		Pluck("club_id", &clubIDs).Error; err != nil {
+		return nil, err
+	}
+	for _, id := range clubIDs {
+		clubs[id] = true
+	}
+	return clubs, nil
+}
+
+// Quote 計算用戶預訂場地時段的價格明細
+func (ps *PricingService) Quote(ctx context.Context, court *models.Court, userID string, start, end time.Time) (*models.PriceBreakdown, error) {
+	rules, err := ps.Rules(ctx, court.ID)
+	if err != nil {
+		return nil, fmt.Errorf("獲取價格規則失敗: %w", err)
+	}
+
+	// 只有會員價或非會員價規則需要查詢會員資格
+	memberClubs := map[string]bool{}
+	for _, rule := range rules {
+		if rule.Audience == PriceAudienceMember || rule.Audience == PriceAudiencePublic {
+			if memberClubs, err = ps.MemberClubs(ctx, userID, start); err != nil {
+				return nil, fmt.Errorf("獲取會員資格失敗: %w", err)
+			}
+			break
+		}
+	}
+
+	return CalculatePrice(court, rules, memberClubs, start, end), nil
+}
+
+// CalculatePrice 按價格規則計算時段的價格明細
+//
+// 時段在午夜及每條規則的開始、結束時間切分，每段以開始時刻判斷適用的規則：
+// rate 規則取優先級最高者，沒有符合時使用場地的每小時價格；surcharge 規則全部累加。
+// 相鄰且價格相同的區段會合併，金額四捨五入至小數點後兩位。
+func CalculatePrice(court *models.Court, rules []models.CourtPriceRule, memberClubs map[string]bool, start, end time.Time) *models.PriceBreakdown {
+	currency := court.Currency
+	if currency == "" {
+		currency = "TWD"
+	}
+	breakdown := &models.PriceBreakdown{
+		Currency:         currency,
+		BasePricePerHour: court.PricePerHour,
+		Segments:         []models.PriceSegment{},
+	}
+	if !end.After(start) {
+		return breakdown
+	}
+
+	ordered := make([]models.CourtPriceRule, len(rules))
+	copy(ordered, rules)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].Priority > ordered[j].Priority
+	})
+
+	for _, bound := range priceBoundaries(ordered, start, end) {
+		segment := priceSegment(court, ordered, memberClubs, bound[0], bound[1])
+
+		// 與上一段套用相同價格時合併
+		if n := len(breakdown.Segments); n > 0 && samePrice(&breakdown.Segments[n-1], &segment) {
+			last := &breakdown.Segments[n-1]
+			last.EndTime = segment.EndTime
+			continue
+		}
+		breakdown.Segments = append(breakdown.Segments, segment)
+	}
+
+	total := 0.0
+	for i := range breakdown.Segments {
+		segment := &breakdown.Segments[i]
+		hours := segment.EndTime.Sub(segment.StartTime).Hours()
+		rate := segment.PricePerHour
+		for _, surcharge := range segment.Surcharges {
+			rate += surcharge.PricePerHour
+		}
+		segment.Hours = math.Round(hours*10000) / 10000
+		segment.Amount = math.Round(hours*rate*100) / 100
+		total += segment.Amount
+	}
+	breakdown.Total = math.Round(total*100) / 100
+
+	return breakdown
+}
+
+// ParseClockMinutes 解析 HH:MM 為當天的分鐘數，允許 24:00 表示當天結束
+func ParseClockMinutes(value string) (int, error) {
+	if len(value) != 5 || value[2] != ':' {
+		return 0, fmt.Errorf("時間格式錯誤: %s", value)
+	}
+	hour, err := strconv.Atoi(value[:2])
+	if err != nil {
+		return 0, fmt.Errorf("時間格式錯誤: %s", value)
+	}
+	minute, err := strconv.Atoi(value[3:])
+	if err != nil {
+		return 0, fmt.Errorf("時間格式錯誤: %s", value)
+	}
+	if hour < 0 || hour > 24 || minute < 0 || minute > 59 || (hour == 24 && minute != 0) {
+		return 0, fmt.Errorf("時間超出範圍: %s", value)
+	}
+	return hour*60 + minute, nil
+}
+
+// priceBoundaries 在午夜及規則的時段邊界切分時段
+func priceBoundaries(rules []models.CourtPriceRule, start, end time.Time) [][2]time.Time {
+	loc := start.Location()
+	points := map[int64]time.Time{start.UnixNano(): start, end.UnixNano(): end}
+	add := func(t time.Time) {
+		if t.After(start) && t.Before(end) {
+			points[t.UnixNano()] = t
+		}
+	}
+
+	for day := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, loc); day.Before(end); day = day.AddDate(0, 0, 1) {
+		add(day)
+		for _, rule := range rules {
+			for _, clock := range []*string{rule.StartTime, rule.EndTime} {
+				if clock == nil {
+					continue
+				}
+				minutes, err := ParseClockMinutes(*clock)
+				if err != nil {
+					continue
+				}
+				add(time.Date(day.Year(), day.Month(), day.Day(), minutes/60, minutes%60, 0, 0, loc))
+			}
+		}
+	}
+
+	sorted := make([]time.Time, 0, len(points))
+	for _, t := range points {
+		sorted = append(sorted, t)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Before(sorted[j]) })
+
+	bounds := make([][2]time.Time, 0, len(sorted)-1)
+	for i := 0; i+1 < len(sorted); i++ {
+		bounds = append(bounds, [2]time.Time{sorted[i], sorted[i+1]})
+	}
+	return bounds
+}
+
+// priceSegment 計算區段開始時刻適用的價格
+func priceSegment(court *models.Court, rules []models.CourtPriceRule, memberClubs map[string]bool, start, end time.Time) models.PriceSegment {
+	segment := models.PriceSegment{
+		StartTime:    start,
+		EndTime:      end,
+		PricePerHour: court.PricePerHour,
+	}
+
+	rated := false
+	for i := range rules {
+		rule := &rules[i]
+		if !priceRuleMatches(rule, memberClubs, start) {
+			continue
+		}
+		switch rule.Kind {
+		case PriceRuleKindSurcharge:
+			segment.Surcharges = append(segment.Surcharges, models.PriceSurcharge{
+				RuleID:       rule.ID,
+				RuleName:     rule.Name,
+				PricePerHour: rule.PricePerHour,
+			})
+		default:
+			if !rated {
+				rated = true
+				segment.PricePerHour = rule.PricePerHour
+				segment.RuleID = &rule.ID
+				segment.RuleName = &rule.Name
+			}
+		}
+	}
+	return segment
+}
+
+// priceRuleMatches 判斷規則是否適用於指定時刻
+func priceRuleMatches(rule *models.CourtPriceRule, memberClubs map[string]bool, at time.Time) bool {
+	if !rule.IsActive {
+		return false
+	}
+
+	if len(rule.DaysOfWeek) > 0 {
+		matched := false
+		for _, day := range rule.DaysOfWeek {
+			if day == int64(at.Weekday()) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	date := at.Format("2006-01-02")
+	if rule.StartDate != nil && date < *rule.StartDate {
+		return false
+	}
+	if rule.EndDate != nil && date > *rule.EndDate {
+		return false
+	}
+
+	if rule.StartTime != nil && rule.EndTime != nil {
+		startMinutes, err := ParseClockMinutes(*rule.StartTime)
+		if err != nil {
+			return false
+		}
+		endMinutes, err := ParseClockMinutes(*rule.EndTime)
+		if err != nil {
+			return false
+		}
+		minutes := at.Hour()*60 + at.Minute()
+		if minutes < startMinutes || minutes >= endMinutes {
+			return false
+		}
+	}
+
+	switch rule.Audience {
+	case PriceAudienceMember:
+		return rule.ClubID != nil && memberClubs[*rule.ClubID]
+	case PriceAudiencePublic:
+		return rule.ClubID == nil || !memberClubs[*rule.ClubID]
+	}
+	return true
+}
+
+// samePrice 判斷兩個相鄰區段是否套用相同價格
+func samePrice(a, b *models.PriceSegment) bool {
+	if !a.EndTime.Equal(b.StartTime) || a.PricePerHour != b.PricePerHour {
+		return false
+	}
+	if (a.RuleID == nil) != (b.RuleID == nil) || (a.RuleID != nil && *a.RuleID != *b.RuleID) {
+		return false
+	}
+	if len(a.Surcharges) != len(b.Surcharges) {
+		return false
+	}
+	for i := range a.Surcharges {
+		if a.Surcharges[i] != b.Surcharges[i] {
+			return false
+		}
+	}
+	return true
+}
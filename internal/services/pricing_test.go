@@ -0,0 +1,131 @@
+package services
+
+import (
+	"context"
+	"tennis-platform/backend/internal/models"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// 輔助函數
+func stringPtr(s string) *string {
+	return &s
+}
+
+func TestCalculatePrice_NoRules(t *testing.T) {
+	court := &models.Court{ID: "court-1", PricePerHour: 500}
+	start := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+
+	breakdown := CalculatePrice(court, nil, nil, start, start.Add(90*time.Minute))
+	assert.Equal(t, "TWD", breakdown.Currency)
+	require.Len(t, breakdown.Segments, 1)
+	assert.Nil(t, breakdown.Segments[0].RuleID)
+	assert.InDelta(t, 1.5, breakdown.Segments[0].Hours, 0.0001)
+	assert.InDelta(t, 750, breakdown.Total, 0.001)
+}
+
+func TestCalculatePrice_ProratesAcrossRules(t *testing.T) {
+	court := &models.Court{ID: "court-1", PricePerHour: 400, Currency: "TWD"}
+	rules := []models.CourtPriceRule{
+		{ID: "evening", Name: "平日晚間", Kind: PriceRuleKindRate, PricePerHour: 600, DaysOfWeek: pq.Int64Array{1, 2, 3, 4, 5}, StartTime: stringPtr("18:00"), EndTime: stringPtr("22:00"), Audience: PriceAudienceAll, Priority: 10, IsActive: true},
+		{ID: "lights", Name: "燈光", Kind: PriceRuleKindSurcharge, PricePerHour: 100, StartTime: stringPtr("18:30"), EndTime: stringPtr("24:00"), Audience: PriceAudienceAll, IsActive: true},
+		{ID: "weekend", Name: "週末", Kind: PriceRuleKindRate, PricePerHour: 700, DaysOfWeek: pq.Int64Array{0, 6}, Audience: PriceAudienceAll, Priority: 5, IsActive: true},
+	}
+
+	// 星期一 17:00-19:00：一小時基本價、半小時晚間價、半小時晚間價加燈光
+	start := time.Date(2024, 1, 15, 17, 0, 0, 0, time.UTC)
+	breakdown := CalculatePrice(court, rules, nil, start, start.Add(2*time.Hour))
+	require.Len(t, breakdown.Segments, 3)
+
+	assert.Nil(t, breakdown.Segments[0].RuleID)
+	assert.InDelta(t, 400, breakdown.Segments[0].Amount, 0.001)
+
+	require.NotNil(t, breakdown.Segments[1].RuleID)
+	assert.Equal(t, "evening", *breakdown.Segments[1].RuleID)
+	assert.InDelta(t, 0.5, breakdown.Segments[1].Hours, 0.0001)
+	assert.InDelta(t, 300, breakdown.Segments[1].Amount, 0.001)
+
+	require.Len(t, breakdown.Segments[2].Surcharges, 1)
+	assert.Equal(t, "lights", breakdown.Segments[2].Surcharges[0].RuleID)
+	assert.InDelta(t, 350, breakdown.Segments[2].Amount, 0.001)
+	assert.InDelta(t, 1050, breakdown.Total, 0.001)
+
+	// 星期六 21:00 至星期日 01:00：週末價在午夜切分後合併，燈光只到午夜
+	start = time.Date(2024, 1, 20, 21, 0, 0, 0, time.UTC)
+	breakdown = CalculatePrice(court, rules, nil, start, start.Add(4*time.Hour))
+	require.Len(t, breakdown.Segments, 2)
+	assert.Equal(t, "weekend", *breakdown.Segments[0].RuleID)
+	assert.Len(t, breakdown.Segments[0].Surcharges, 1)
+	assert.InDelta(t, 2400, breakdown.Segments[0].Amount, 0.001)
+	assert.Equal(t, "weekend", *breakdown.Segments[1].RuleID)
+	assert.Empty(t, breakdown.Segments[1].Surcharges)
+	assert.InDelta(t, 700, breakdown.Segments[1].Amount, 0.001)
+	assert.InDelta(t, 3100, breakdown.Total, 0.001)
+}
+
+func TestCalculatePrice_PriorityDatesAndAudience(t *testing.T) {
+	court := &models.Court{ID: "court-1", PricePerHour: 400}
+	clubID := "club-1"
+	rules := []models.CourtPriceRule{
+		{ID: "holiday", Name: "春節", Kind: PriceRuleKindRate, PricePerHour: 900, StartDate: stringPtr("2024-02-08"), EndDate: stringPtr("2024-02-14"), Audience: PriceAudienceAll, Priority: 100, IsActive: true},
+		{ID: "member", Name: "會員價", Kind: PriceRuleKindRate, PricePerHour: 300, Audience: PriceAudienceMember, ClubID: &clubID, Priority: 50, IsActive: true},
+		{ID: "public", Name: "非會員價", Kind: PriceRuleKindRate, PricePerHour: 450, Audience: PriceAudiencePublic, ClubID: &clubID, Priority: 50, IsActive: true},
+		{ID: "disabled", Name: "停用", Kind: PriceRuleKindRate, PricePerHour: 1, Priority: 1000, IsActive: false},
+	}
+	members := map[string]bool{clubID: true}
+
+	start := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	assert.InDelta(t, 300, CalculatePrice(court, rules, members, start, start.Add(time.Hour)).Total, 0.001)
+	assert.InDelta(t, 450, CalculatePrice(court, rules, nil, start, start.Add(time.Hour)).Total, 0.001)
+
+	// 優先級較高的假日價格蓋過會員價，結束日期當天仍適用
+	start = time.Date(2024, 2, 14, 10, 0, 0, 0, time.UTC)
+	breakdown := CalculatePrice(court, rules, members, start, start.Add(time.Hour))
+	assert.Equal(t, "holiday", *breakdown.Segments[0].RuleID)
+	assert.InDelta(t, 900, breakdown.Total, 0.001)
+
+	start = time.Date(2024, 2, 15, 10, 0, 0, 0, time.UTC)
+	assert.InDelta(t, 300, CalculatePrice(court, rules, members, start, start.Add(time.Hour)).Total, 0.001)
+}
+
+func TestPricingService_QuoteMemberRate(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+
+	// 手動創建表結構，只包含計價需要的欄位
+	for _, stmt := range []string{
+		`CREATE TABLE court_price_rules (id TEXT PRIMARY KEY, court_id TEXT NOT NULL, name TEXT NOT NULL, kind TEXT NOT NULL, price_per_hour REAL NOT NULL, days_of_week TEXT, start_time TEXT, end_time TEXT, start_date TEXT, end_date TEXT, audience TEXT NOT NULL, club_id TEXT, priority INTEGER NOT NULL DEFAULT 0, is_active BOOLEAN NOT NULL, created_at DATETIME, updated_at DATETIME)`,
+		`CREATE TABLE club_members (id TEXT PRIMARY KEY, club_id TEXT, user_id TEXT, status TEXT, expires_at DATETIME, deleted_at DATETIME)`,
+	} {
+		require.NoError(t, db.Exec(stmt).Error)
+	}
+
+	clubID := "club-1"
+	require.NoError(t, db.Create(&models.CourtPriceRule{CourtID: "court-1", Name: "會員價", Kind: PriceRuleKindRate, PricePerHour: 300, Audience: PriceAudienceMember, ClubID: &clubID, IsActive: true}).Error)
+	require.NoError(t, db.Create(&models.CourtPriceRule{CourtID: "court-1", Name: "停用", Kind: PriceRuleKindRate, PricePerHour: 1, Audience: PriceAudienceAll, Priority: 10, IsActive: false}).Error)
+	require.NoError(t, db.Exec(`INSERT INTO club_members (id, club_id, user_id, status) VALUES ('m1', ?, 'member-1', 'active')`, clubID).Error)
+	require.NoError(t, db.Exec(`INSERT INTO club_members (id, club_id, user_id, status, expires_at) VALUES ('m2', ?, 'expired-1', 'active', ?)`, clubID, time.Now().Add(-time.Hour)).Error)
+
+	service := NewPricingService(db)
+	court := &models.Court{ID: "court-1", PricePerHour: 400}
+	start := time.Now().Add(24 * time.Hour)
+	ctx := context.Background()
+
+	breakdown, err := service.Quote(ctx, court, "member-1", start, start.Add(time.Hour))
+	require.NoError(t, err)
+	assert.InDelta(t, 300, breakdown.Total, 0.001)
+
+	breakdown, err = service.Quote(ctx, court, "expired-1", start, start.Add(time.Hour))
+	require.NoError(t, err)
+	assert.InDelta(t, 400, breakdown.Total, 0.001)
+
+	breakdown, err = service.Quote(ctx, court, "", start, start.Add(time.Hour))
+	require.NoError(t, err)
+	assert.InDelta(t, 400, breakdown.Total, 0.001)
+}
@@ -0,0 +1,346 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"tennis-platform/backend/internal/apperror"
+	"tennis-platform/backend/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PromoPurchase 套用優惠碼的預訂或課程
+type PromoPurchase struct {
+	UserID       string
+	TargetType   string // booking, lesson
+	CourtID      string // 預訂的場地
+	CoachID      string // 課程的教練
+	LessonTypeID string // 課程類型，可為空
+	Amount       float64
+	Currency     string
+	At           time.Time // 預訂或上課的開始時間，判斷星期及時段
+}
+
+// PromoDiscount 優惠碼的折扣計算結果
+type PromoDiscount struct {
+	PromoCode *models.PromoCode
+	Amount    float64 // 折扣金額
+	Total     float64 // 扣除折扣後的應付金額
+}
+
+// PriceDiscount 轉為價格明細中的折扣
+func (d *PromoDiscount) PriceDiscount() *models.PriceDiscount {
+	return &models.PriceDiscount{
+		PromoCodeID: d.PromoCode.ID,
+		Code:        d.PromoCode.Code,
+		Amount:      d.Amount,
+	}
+}
+
+// PromoService 優惠碼服務
+//
+// Quote 在預訂或課程建立前驗證優惠碼並計算折扣；Redeem 在建立預訂或課程的事務中扣除使用次數並記錄使用，
+// 以條件更新扣除總次數，同一優惠碼的並發使用由行鎖依序處理，不會超過上限。取消時 Reverse 退回使用次數。
+type PromoService struct {
+	db      *gorm.DB
+	pricing *PricingService
+}
+
+// NewPromoService 創建新的優惠碼服務
+func NewPromoService(db *gorm.DB) *PromoService {
+	return &PromoService{
+		db:      db,
+		pricing: NewPricingService(db),
+	}
+}
+
+// NormalizePromoCode 優惠碼不分大小寫，統一以大寫保存及查詢
+func NormalizePromoCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// Quote 驗證優惠碼可用於此預訂或課程，並計算折扣
+func (s *PromoService) Quote(ctx context.Context, code string, purchase *PromoPurchase) (*PromoDiscount, error) {
+	var promo models.PromoCode
+	if err := s.db.WithContext(ctx).Where("code = ?", NormalizePromoCode(code)).First(&promo).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.New(apperror.CodePromoCodeInvalid)
+		}
+		return nil, fmt.Errorf("獲取優惠碼失敗: %w", err)
+	}
+	if !promo.IsActive {
+		return nil, apperror.New(apperror.CodePromoCodeInvalid)
+	}
+
+	now := time.Now()
+	if (promo.StartsAt != nil && now.Before(*promo.StartsAt)) || (promo.EndsAt != nil && !now.Before(*promo.EndsAt)) {
+		return nil, apperror.New(apperror.CodePromoCodeExpired)
+	}
+
+	applicable, err := s.applies(ctx, &promo, purchase)
+	if err != nil {
+		return nil, err
+	}
+	if !applicable || purchase.Amount <= 0 {
+		return nil, apperror.New(apperror.CodePromoCodeNotApplicable)
+	}
+	if purchase.Amount < promo.MinSpend {
+		return nil, apperror.New(apperror.CodePromoCodeMinSpend).With("amount", promo.MinSpend)
+	}
+
+	if promo.MaxRedemptions != nil && promo.RedemptionCount >= *promo.MaxRedemptions {
+		return nil, apperror.New(apperror.CodePromoCodeExhausted)
+	}
+	if promo.MaxRedemptionsPerUser != nil {
+		used, err := s.userRedemptions(s.db.WithContext(ctx), promo.ID, purchase.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if used >= int64(*promo.MaxRedemptionsPerUser) {
+			return nil, apperror.New(apperror.CodePromoCodeUserLimit)
+		}
+	}
+	if promo.FirstPurchaseOnly {
+		first, err := s.firstPurchase(ctx, &promo, purchase)
+		if err != nil {
+			return nil, err
+		}
+		if !first {
+			return nil, apperror.New(apperror.CodePromoCodeFirstPurchaseOnly)
+		}
+	}
+
+	amount := CalculatePromoDiscount(&promo, purchase.Amount)
+	return &PromoDiscount{
+		PromoCode: &promo,
+		Amount:    amount,
+		Total:     math.Round((purchase.Amount-amount)*100) / 100,
+	}, nil
+}
+
+// CalculatePromoDiscount 計算優惠碼對金額的折扣，四捨五入至小數點後兩位且不超過原價
+func CalculatePromoDiscount(promo *models.PromoCode, amount float64) float64 {
+	discount := promo.DiscountValue
+	if promo.DiscountType == models.PromoDiscountPercent {
+		discount = math.Round(amount*promo.DiscountValue) / 100
+		if promo.MaxDiscount != nil && discount > *promo.MaxDiscount {
+			discount = *promo.MaxDiscount
+		}
+	}
+	return math.Min(discount, amount)
+}
+
+// Redeem 在建立預訂或課程的事務中使用優惠碼，扣除使用次數並記錄使用
+func (s *PromoService) Redeem(tx *gorm.DB, discount *PromoDiscount, purchase *PromoPurchase, targetID string) (*models.PromoRedemption, error) {
+	promo := discount.PromoCode
+
+	// 條件更新同時鎖定優惠碼，後續的每位用戶次數檢查不會與其他使用交錯
+	result := tx.Model(&models.PromoCode{}).
+		Where("id = ? AND is_active = ? AND (max_redemptions IS NULL OR redemption_count < max_redemptions)", promo.ID, true).
+		Updates(map[string]interface{}{
+			"redemption_count": gorm.Expr("redemption_count + 1"),
+			"updated_at":       time.Now(),
+		})
+	if result.Error != nil {
+		return nil, fmt.Errorf("更新優惠碼使用次數失敗: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, apperror.New(apperror.CodePromoCodeExhausted)
+	}
+
+	if promo.MaxRedemptionsPerUser != nil {
+		used, err := s.userRedemptions(tx, promo.ID, purchase.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if used >= int64(*promo.MaxRedemptionsPerUser) {
+			return nil, apperror.New(apperror.CodePromoCodeUserLimit)
+		}
+	}
+
+	redemption := &models.PromoRedemption{
+		PromoCodeID:    promo.ID,
+		UserID:         purchase.UserID,
+		TargetType:     purchase.TargetType,
+		TargetID:       targetID,
+		OriginalAmount: purchase.Amount,
+		DiscountAmount: discount.Amount,
+		Currency:       purchase.Currency,
+		Status:         models.PromoRedemptionApplied,
+	}
+	if err := tx.Create(redemption).Error; err != nil {
+		return nil, fmt.Errorf("記錄優惠碼使用失敗: %w", err)
+	}
+	return redemption, nil
+}
+
+// Reprice 預訂改期後按新的價格重新計算已套用的折扣，不再檢查使用條件，並更新使用記錄的金額
+func (s *PromoService) Reprice(tx *gorm.DB, targetType, targetID string, applied *models.PriceDiscount, breakdown *models.PriceBreakdown) error {
+	var promo models.PromoCode
+	if err := tx.Where("id = ?", applied.PromoCodeID).First(&promo).Error; err != nil {
+		return fmt.Errorf("獲取優惠碼失敗: %w", err)
+	}
+
+	subtotal := breakdown.Total
+	amount := CalculatePromoDiscount(&promo, subtotal)
+	breakdown.Subtotal = subtotal
+	breakdown.Discount = &models.PriceDiscount{
+		PromoCodeID: promo.ID,
+		Code:        promo.Code,
+		Amount:      amount,
+	}
+	breakdown.Total = math.Round((subtotal-amount)*100) / 100
+
+	return tx.Model(&models.PromoRedemption{}).
+		Where("target_type = ? AND target_id = ? AND status = ?", targetType, targetID, models.PromoRedemptionApplied).
+		Updates(map[string]interface{}{
+			"original_amount": subtotal,
+			"discount_amount": amount,
+		}).Error
+}
+
+// Reverse 預訂或課程取消時退回優惠碼的使用次數，沒有使用或已退回時不做任何事
+func (s *PromoService) Reverse(ctx context.Context, targetType, targetID string) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var redemption models.PromoRedemption
+		if err := tx.Where("target_type = ? AND target_id = ? AND status = ?", targetType, targetID, models.PromoRedemptionApplied).
+			First(&redemption).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			return err
+		}
+
+		now := time.Now()
+		result := tx.Model(&models.PromoRedemption{}).
+			Where("id = ? AND status = ?", redemption.ID, models.PromoRedemptionApplied).
+			Updates(map[string]interface{}{
+				"status":      models.PromoRedemptionReversed,
+				"reversed_at": now,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		return tx.Model(&models.PromoCode{}).
+			Where("id = ? AND redemption_count > 0", redemption.PromoCodeID).
+			Updates(map[string]interface{}{
+				"redemption_count": gorm.Expr("redemption_count - 1"),
+				"updated_at":       now,
+			}).Error
+	})
+}
+
+// applies 判斷優惠碼的範圍、星期及時段是否符合此預訂或課程
+//
+// 場地優惠碼只用於該場地的預訂，教練優惠碼只用於該教練的課程。
+func (s *PromoService) applies(ctx context.Context, promo *models.PromoCode, purchase *PromoPurchase) (bool, error) {
+	if promo.CourtID != nil && (purchase.TargetType != models.PromoTargetBooking || *promo.CourtID != purchase.CourtID) {
+		return false, nil
+	}
+	if promo.CoachID != nil && (purchase.TargetType != models.PromoTargetLesson || *promo.CoachID != purchase.CoachID) {
+		return false, nil
+	}
+	if promo.LessonTypeID != nil && *promo.LessonTypeID != purchase.LessonTypeID {
+		return false, nil
+	}
+
+	if len(promo.DaysOfWeek) > 0 {
+		matched := false
+		for _, day := range promo.DaysOfWeek {
+			if day == int64(purchase.At.Weekday()) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false, nil
+		}
+	}
+	if promo.StartTime != nil && promo.EndTime != nil {
+		startMinutes, err := ParseClockMinutes(*promo.StartTime)
+		if err != nil {
+			return false, nil
+		}
+		endMinutes, err := ParseClockMinutes(*promo.EndTime)
+		if err != nil {
+			return false, nil
+		}
+		minutes := purchase.At.Hour()*60 + purchase.At.Minute()
+		if minutes < startMinutes || minutes >= endMinutes {
+			return false, nil
+		}
+	}
+
+	if promo.ClubID != nil {
+		clubs, err := s.pricing.MemberClubs(ctx, purchase.UserID, purchase.At)
+		if err != nil {
+			return false, fmt.Errorf("獲取會員資格失敗: %w", err)
+		}
+		if !clubs[*promo.ClubID] {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// userRedemptions 統計用戶對優惠碼仍有效的使用次數
+func (s *PromoService) userRedemptions(db *gorm.DB, promoCodeID, userID string) (int64, error) {
+	var count int64
+	if err := db.Model(&models.PromoRedemption{}).
+		Where("promo_code_id = ? AND user_id = ? AND status = ?", promoCodeID, userID, models.PromoRedemptionApplied).
+		Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("統計優惠碼使用次數失敗: %w", err)
+	}
+	return count, nil
+}
+
+// firstPurchase 判斷用戶是否從未預訂過此場地或上過此教練的課程，已取消的不計
+func (s *PromoService) firstPurchase(ctx context.Context, promo *models.PromoCode, purchase *PromoPurchase) (bool, error) {
+	var count int64
+	var err error
+	if purchase.TargetType == models.PromoTargetBooking {
+		err = s.db.WithContext(ctx).Model(&models.Booking{}).
+			Where("user_id = ? AND court_id = ? AND status <> ?", purchase.UserID, purchase.CourtID, "cancelled").
+			Count(&count).Error
+	} else {
+		query := s.db.WithContext(ctx).Model(&models.Lesson{}).
+			Where("student_id = ? AND coach_id = ? AND status <> ?", purchase.UserID, purchase.CoachID, "cancelled")
+		if promo.LessonTypeID != nil {
+			query = query.Where("lesson_type_id = ?", *promo.LessonTypeID)
+		}
+		err = query.Count(&count).Error
+	}
+	if err != nil {
+		return false, fmt.Errorf("查詢過往紀錄失敗: %w", err)
+	}
+	return count == 0, nil
+}
+
+// RegisterPromoSubscribers 註冊優惠碼的事件訂閱者，預訂或課程取消時退回使用次數
+func RegisterPromoSubscribers(bus *EventBus, promoService *PromoService) {
+	const subscriber = "promo"
+
+	bus.Subscribe(EventBookingCancelled, subscriber, func(ctx context.Context, event *DomainEvent) error {
+		var payload BookingEventPayload
+		if err := event.Decode(&payload); err != nil {
+			return fmt.Errorf("failed to decode event payload: %w", err)
+		}
+		return promoService.Reverse(ctx, models.PromoTargetBooking, payload.BookingID)
+	})
+
+	bus.Subscribe(EventLessonCancelled, subscriber, func(ctx context.Context, event *DomainEvent) error {
+		var payload LessonEventPayload
+		if err := event.Decode(&payload); err != nil {
+			return fmt.Errorf("failed to decode event payload: %w", err)
+		}
+		return promoService.Reverse(ctx, models.PromoTargetLesson, payload.LessonID)
+	})
+}
@@ -85,6 +85,7 @@ func (rs *ReputationService) UpdateAttendanceRate(userID string, matchStatus str
 	case "cancelled":
 		reputation.CancelledMatches++
 	}
+	// 其他狀態（如預訂未到場 no_show）只計入總數
 
 	// 計算出席率 (完成的比賽 / 總比賽數)
 	if reputation.TotalMatches > 0 {
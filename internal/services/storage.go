@@ -0,0 +1,220 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"tennis-platform/backend/internal/apperror"
+	"tennis-platform/backend/internal/config"
+	"time"
+)
+
+// Storage 對象存儲接口
+//
+// 文件以相對鍵（例如 avatars/avatar_xxx.jpg）存取，數據庫中保存的是 URL(key) 的結果。
+type Storage interface {
+	// Put 寫入文件，size 為 -1 時表示長度未知
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Open 讀取文件，文件不存在時返回 CodeUploadNotFound
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete 刪除文件，文件不存在時不視為錯誤
+	Delete(ctx context.Context, key string) error
+	// URL 返回文件的公開訪問網址，key 為空時返回網址前綴
+	URL(key string) string
+	// SignedURL 返回限時有效的下載網址
+	SignedURL(ctx context.Context, key string, expires time.Duration) (string, error)
+	// PresignUpload 返回客戶端直接上傳到存儲的限時網址
+	PresignUpload(ctx context.Context, key, contentType string, expires time.Duration) (*PresignedUpload, error)
+}
+
+// LinkVerifier 由 API 服務器自行處理簽名連結的存儲需實現此接口
+type LinkVerifier interface {
+	VerifySignature(method, key, contentType, expires, signature string) error
+}
+
+// PresignedUpload 直傳上傳資訊，客戶端以 Method 及 Headers 將文件內容送到 URL
+type PresignedUpload struct {
+	Key       string            `json:"key"`
+	Method    string            `json:"method"`
+	URL       string            `json:"url"`
+	Headers   map[string]string `json:"headers"`
+	PublicURL string            `json:"publicUrl"`
+	ExpiresAt time.Time         `json:"expiresAt"`
+}
+
+// NewStorage 依配置創建對象存儲
+func NewStorage(cfg *config.Config) (Storage, error) {
+	switch cfg.Storage.Driver {
+	case "", "local":
+		return NewLocalStorage(cfg.Upload.UploadPath, cfg.Storage.SigningSecret), nil
+	case "s3":
+		return NewS3Storage(cfg.Storage.S3)
+	default:
+		return nil, fmt.Errorf("不支援的存儲驅動: %s", cfg.Storage.Driver)
+	}
+}
+
+// ValidateKey 檢查文件鍵，拒絕絕對路徑及跳出上傳目錄的路徑
+func ValidateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, `\`) || path.Clean(key) != key {
+		return apperror.New(apperror.CodeUploadInvalidKey)
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == ".." || segment == "." {
+			return apperror.New(apperror.CodeUploadInvalidKey)
+		}
+	}
+	return nil
+}
+
+// LocalStorage 本機磁碟存儲
+//
+// 公開文件經由 /uploads 靜態路由訪問；簽名下載及直傳由 /api/v1/files/object 處理，
+// 以 HMAC 簽名保護方法、文件鍵、內容類型及到期時間。
+type LocalStorage struct {
+	root         string
+	secret       []byte
+	PublicPrefix string // 靜態文件路由
+	SignedPrefix string // 簽名連結路由
+}
+
+// NewLocalStorage 創建新的本機存儲
+func NewLocalStorage(root, signingSecret string) *LocalStorage {
+	return &LocalStorage{
+		root:         root,
+		secret:       []byte(signingSecret),
+		PublicPrefix: "/uploads",
+		SignedPrefix: "/api/v1/files/object",
+	}
+}
+
+// Path 返回文件在磁碟上的路徑
+func (s *LocalStorage) Path(key string) string {
+	return filepath.Join(s.root, filepath.FromSlash(key))
+}
+
+// Put 寫入文件
+func (s *LocalStorage) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	if err := ValidateKey(key); err != nil {
+		return err
+	}
+
+	filePath := s.Path(key)
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return fmt.Errorf("創建上傳目錄失敗: %v", err)
+	}
+
+	// 先寫入暫存文件再改名，避免讀取到寫了一半的文件
+	tmp, err := os.CreateTemp(filepath.Dir(filePath), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("創建目標文件失敗: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return fmt.Errorf("保存文件失敗: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("保存文件失敗: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), filePath); err != nil {
+		return fmt.Errorf("保存文件失敗: %v", err)
+	}
+	return nil
+}
+
+// Open 讀取文件
+func (s *LocalStorage) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	if err := ValidateKey(key); err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(s.Path(key))
+	if os.IsNotExist(err) {
+		return nil, apperror.New(apperror.CodeUploadNotFound)
+	}
+	return file, err
+}
+
+// Delete 刪除文件
+func (s *LocalStorage) Delete(ctx context.Context, key string) error {
+	if err := ValidateKey(key); err != nil {
+		return err
+	}
+
+	if err := os.Remove(s.Path(key)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("刪除文件失敗: %v", err)
+	}
+	return nil
+}
+
+// URL 返回靜態文件網址
+func (s *LocalStorage) URL(key string) string {
+	return s.PublicPrefix + "/" + key
+}
+
+// SignedURL 返回簽名下載網址
+func (s *LocalStorage) SignedURL(ctx context.Context, key string, expires time.Duration) (string, error) {
+	if err := ValidateKey(key); err != nil {
+		return "", err
+	}
+	return s.signedURL("GET", key, "", time.Now().Add(expires)), nil
+}
+
+// PresignUpload 返回簽名直傳網址，上傳時 Content-Type 必須與簽名時一致
+func (s *LocalStorage) PresignUpload(ctx context.Context, key, contentType string, expires time.Duration) (*PresignedUpload, error) {
+	if err := ValidateKey(key); err != nil {
+		return nil, err
+	}
+
+	expiresAt := time.Now().Add(expires)
+	return &PresignedUpload{
+		Key:       key,
+		Method:    "PUT",
+		URL:       s.signedURL("PUT", key, contentType, expiresAt),
+		Headers:   map[string]string{"Content-Type": contentType},
+		PublicURL: s.URL(key),
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// VerifySignature 驗證簽名連結，簽名不符或已過期時返回 CodeUploadInvalidLink
+func (s *LocalStorage) VerifySignature(method, key, contentType, expires, signature string) error {
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > expiresAt {
+		return apperror.New(apperror.CodeUploadInvalidLink)
+	}
+
+	expected := s.sign(method, key, contentType, expiresAt)
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return apperror.New(apperror.CodeUploadInvalidLink)
+	}
+	return nil
+}
+
+// signedURL 組合簽名連結
+func (s *LocalStorage) signedURL(method, key, contentType string, expiresAt time.Time) string {
+	query := url.Values{}
+	query.Set("expires", strconv.FormatInt(expiresAt.Unix(), 10))
+	query.Set("signature", s.sign(method, key, contentType, expiresAt.Unix()))
+
+	link := url.URL{Path: s.SignedPrefix + "/" + key, RawQuery: query.Encode()}
+	return link.String()
+}
+
+// sign 計算簽名
+func (s *LocalStorage) sign(method, key, contentType string, expiresAt int64) string {
+	mac := hmac.New(sha256.New, s.secret)
+	fmt.Fprintf(mac, "%s\n%s\n%s\n%d", method, key, contentType, expiresAt)
+	return hex.EncodeToString(mac.Sum(nil))
+}
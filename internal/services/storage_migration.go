@@ -0,0 +1,255 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"tennis-platform/backend/internal/apperror"
+	"tennis-platform/backend/internal/models"
+
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
+
+// imageArrayTables 以 images 陣列保存文件網址的數據表
+var imageArrayTables = []string{"courts", "court_reviews", "rackets", "clubs", "club_events"}
+
+// StorageMigrator 將文件從一個存儲搬到另一個存儲，並改寫數據庫中的網址
+//
+// 只處理以來源存儲網址前綴開頭的網址，外部網址保持不變。
+// 所有數據庫記錄改寫完成後才刪除來源文件，中途失敗可安全重跑。
+type StorageMigrator struct {
+	db           *gorm.DB
+	src          Storage
+	dst          Storage
+	DryRun       bool // 只統計不寫入
+	DeleteSource bool // 完成後刪除來源文件
+	BatchSize    int
+
+	copied  map[string]bool
+	missing map[string]bool
+}
+
+// StorageMigrationResult 遷移結果
+type StorageMigrationResult struct {
+	FilesCopied  int      `json:"filesCopied"`
+	RowsUpdated  int      `json:"rowsUpdated"`
+	MissingFiles []string `json:"missingFiles"` // 來源不存在的文件，網址保持不變
+}
+
+// NewStorageMigrator 創建新的存儲遷移工具
+func NewStorageMigrator(db *gorm.DB, src, dst Storage) *StorageMigrator {
+	return &StorageMigrator{
+		db:        db,
+		src:       src,
+		dst:       dst,
+		BatchSize: 200,
+	}
+}
+
+// Migrate 搬移頭像、圖片及其變體並改寫 AvatarURL、Images 欄位及圖片資訊
+func (m *StorageMigrator) Migrate(ctx context.Context) (*StorageMigrationResult, error) {
+	m.copied = make(map[string]bool)
+	m.missing = make(map[string]bool)
+	result := &StorageMigrationResult{}
+
+	if err := m.migrateAvatars(ctx, result); err != nil {
+		return result, err
+	}
+	for _, table := range imageArrayTables {
+		if err := m.migrateImages(ctx, table, result); err != nil {
+			return result, err
+		}
+	}
+	if err := m.migrateImageAssets(ctx, result); err != nil {
+		return result, err
+	}
+	result.FilesCopied = len(m.copied)
+	for key := range m.missing {
+		result.MissingFiles = append(result.MissingFiles, key)
+	}
+
+	if m.DryRun || !m.DeleteSource {
+		return result, nil
+	}
+	for key := range m.copied {
+		if err := m.src.Delete(ctx, key); err != nil {
+			return result, fmt.Errorf("刪除來源文件 %s 失敗: %v", key, err)
+		}
+	}
+	return result, nil
+}
+
+// migrateAvatars 改寫 user_profiles.avatar_url
+func (m *StorageMigrator) migrateAvatars(ctx context.Context, result *StorageMigrationResult) error {
+	var profiles []struct {
+		UserID    string
+		AvatarURL string
+	}
+	if err := m.db.WithContext(ctx).Table("user_profiles").
+		Select("user_id, avatar_url").
+		Where("avatar_url LIKE ?", m.src.URL("")+"%").
+		Find(&profiles).Error; err != nil {
+		return fmt.Errorf("查詢頭像失敗: %v", err)
+	}
+
+	for _, profile := range profiles {
+		newURL, changed, err := m.move(ctx, profile.AvatarURL)
+		if err != nil {
+			return err
+		}
+		if !changed {
+			continue
+		}
+
+		result.RowsUpdated++
+		if m.DryRun {
+			continue
+		}
+		if err := m.db.WithContext(ctx).Table("user_profiles").
+			Where("user_id = ?", profile.UserID).
+			Update("avatar_url", newURL).Error; err != nil {
+			return fmt.Errorf("更新頭像失敗: %v", err)
+		}
+	}
+	return nil
+}
+
+// imageRow 帶有 images 陣列的記錄
+type imageRow struct {
+	ID     string
+	Images pq.StringArray `gorm:"type:text[]"`
+}
+
+// migrateImages 改寫指定數據表的 images 陣列
+func (m *StorageMigrator) migrateImages(ctx context.Context, table string, result *StorageMigrationResult) error {
+	var rows []imageRow
+	err := m.db.WithContext(ctx).Table(table).
+		Select("id, images").
+		Where("images IS NOT NULL").
+		FindInBatches(&rows, m.BatchSize, func(tx *gorm.DB, batch int) error {
+			for _, row := range rows {
+				images := make(pq.StringArray, len(row.Images))
+				changed := false
+				for i, image := range row.Images {
+					newURL, moved, err := m.move(ctx, image)
+					if err != nil {
+						return err
+					}
+					images[i] = newURL
+					changed = changed || moved
+				}
+				if !changed {
+					continue
+				}
+
+				result.RowsUpdated++
+				if m.DryRun {
+					continue
+				}
+				if err := m.db.WithContext(ctx).Table(table).
+					Where("id = ?", row.ID).
+					Update("images", images).Error; err != nil {
+					return fmt.Errorf("更新 %s 圖片失敗: %v", table, err)
+				}
+			}
+			return nil
+		}).Error
+	if err != nil {
+		return fmt.Errorf("遷移 %s 圖片失敗: %v", table, err)
+	}
+	return nil
+}
+
+// migrateImageAssets 搬移圖片變體並改寫 image_assets 的網址
+func (m *StorageMigrator) migrateImageAssets(ctx context.Context, result *StorageMigrationResult) error {
+	var assets []models.ImageAsset
+	if err := m.db.WithContext(ctx).
+		Where("url LIKE ?", m.src.URL("")+"%").
+		Find(&assets).Error; err != nil {
+		return fmt.Errorf("查詢圖片資訊失敗: %v", err)
+	}
+
+	for _, asset := range assets {
+		newURL, changed, err := m.move(ctx, asset.URL)
+		if err != nil {
+			return err
+		}
+		if !changed {
+			continue
+		}
+
+		variants := make(models.ImageVariants, len(asset.Variants))
+		for name, variant := range asset.Variants {
+			if variant.URL, _, err = m.move(ctx, variant.URL); err != nil {
+				return err
+			}
+			variants[name] = variant
+		}
+
+		result.RowsUpdated++
+		if m.DryRun {
+			continue
+		}
+		if err := m.db.WithContext(ctx).Model(&models.ImageAsset{}).
+			Where("key = ?", asset.Key).
+			Updates(map[string]interface{}{"url": newURL, "variants": variants}).Error; err != nil {
+			return fmt.Errorf("更新圖片資訊失敗: %v", err)
+		}
+	}
+	return nil
+}
+
+// move 複製來源存儲的文件並返回新網址，非來源存儲的網址原樣返回
+func (m *StorageMigrator) move(ctx context.Context, fileURL string) (string, bool, error) {
+	prefix := m.src.URL("")
+	if !strings.HasPrefix(fileURL, prefix) {
+		return fileURL, false, nil
+	}
+
+	key := strings.TrimPrefix(fileURL, prefix)
+	if err := ValidateKey(key); err != nil {
+		return fileURL, false, nil
+	}
+
+	if m.missing[key] {
+		return fileURL, false, nil
+	}
+	if !m.copied[key] {
+		if err := m.copy(ctx, key); err != nil {
+			if apperror.HasCode(err, apperror.CodeUploadNotFound) {
+				m.missing[key] = true
+				return fileURL, false, nil
+			}
+			return "", false, err
+		}
+	}
+	m.copied[key] = true
+	return m.dst.URL(key), true, nil
+}
+
+// copy 複製單個文件，上傳文件受大小限制，整個讀入記憶體以取得長度
+func (m *StorageMigrator) copy(ctx context.Context, key string) error {
+	src, err := m.src.Open(ctx, key)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	// 試運行只確認來源文件存在
+	if m.DryRun {
+		return nil
+	}
+
+	data, err := io.ReadAll(src)
+	if err != nil {
+		return fmt.Errorf("讀取來源文件 %s 失敗: %v", key, err)
+	}
+
+	if err := m.dst.Put(ctx, key, bytes.NewReader(data), int64(len(data)), contentTypeOf(key)); err != nil {
+		return fmt.Errorf("寫入目標文件 %s 失敗: %v", key, err)
+	}
+	return nil
+}
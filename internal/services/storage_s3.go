@@ -0,0 +1,140 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"tennis-platform/backend/internal/apperror"
+	"tennis-platform/backend/internal/config"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// S3Storage S3 相容對象存儲（AWS S3、MinIO 等）
+//
+// 簽名下載及直傳均使用 S3 預簽名網址，客戶端直接與存儲服務交互，不經過 API 服務器。
+type S3Storage struct {
+	client        *minio.Client
+	bucket        string
+	publicBaseURL string
+}
+
+// NewS3Storage 創建新的 S3 存儲
+func NewS3Storage(cfg config.S3Config) (*S3Storage, error) {
+	if cfg.Endpoint == "" || cfg.Bucket == "" {
+		return nil, fmt.Errorf("S3 存儲需要設置 S3_ENDPOINT 及 S3_BUCKET")
+	}
+
+	client, err := minio.New(cfg.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, ""),
+		Secure: cfg.UseSSL,
+		Region: cfg.Region,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("創建 S3 客戶端失敗: %v", err)
+	}
+
+	publicBaseURL := strings.TrimSuffix(cfg.PublicBaseURL, "/")
+	if publicBaseURL == "" {
+		publicBaseURL = fmt.Sprintf("%s/%s", strings.TrimSuffix(client.EndpointURL().String(), "/"), cfg.Bucket)
+	}
+
+	return &S3Storage{
+		client:        client,
+		bucket:        cfg.Bucket,
+		publicBaseURL: publicBaseURL,
+	}, nil
+}
+
+// Put 寫入文件
+func (s *S3Storage) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	if err := ValidateKey(key); err != nil {
+		return err
+	}
+
+	if _, err := s.client.PutObject(ctx, s.bucket, key, r, size, minio.PutObjectOptions{ContentType: contentType}); err != nil {
+		return fmt.Errorf("保存文件失敗: %v", err)
+	}
+	return nil
+}
+
+// Open 讀取文件
+func (s *S3Storage) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	if err := ValidateKey(key); err != nil {
+		return nil, err
+	}
+
+	object, err := s.client.GetObject(ctx, s.bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("讀取文件失敗: %v", err)
+	}
+
+	// GetObject 延遲發送請求，先 Stat 以便及早發現文件不存在
+	if _, err := object.Stat(); err != nil {
+		object.Close()
+		if minio.ToErrorResponse(err).StatusCode == http.StatusNotFound {
+			return nil, apperror.New(apperror.CodeUploadNotFound)
+		}
+		return nil, fmt.Errorf("讀取文件失敗: %v", err)
+	}
+	return object, nil
+}
+
+// Delete 刪除文件
+func (s *S3Storage) Delete(ctx context.Context, key string) error {
+	if err := ValidateKey(key); err != nil {
+		return err
+	}
+
+	if err := s.client.RemoveObject(ctx, s.bucket, key, minio.RemoveObjectOptions{}); err != nil {
+		return fmt.Errorf("刪除文件失敗: %v", err)
+	}
+	return nil
+}
+
+// URL 返回公開訪問網址
+func (s *S3Storage) URL(key string) string {
+	return s.publicBaseURL + "/" + key
+}
+
+// SignedURL 返回預簽名下載網址
+func (s *S3Storage) SignedURL(ctx context.Context, key string, expires time.Duration) (string, error) {
+	if err := ValidateKey(key); err != nil {
+		return "", err
+	}
+
+	link, err := s.client.PresignedGetObject(ctx, s.bucket, key, expires, nil)
+	if err != nil {
+		return "", fmt.Errorf("生成下載網址失敗: %v", err)
+	}
+	return link.String(), nil
+}
+
+// PresignUpload 返回預簽名上傳網址，Content-Type 納入簽名
+func (s *S3Storage) PresignUpload(ctx context.Context, key, contentType string, expires time.Duration) (*PresignedUpload, error) {
+	if err := ValidateKey(key); err != nil {
+		return nil, err
+	}
+
+	headers := http.Header{}
+	headers.Set("Content-Type", contentType)
+
+	expiresAt := time.Now().Add(expires)
+	link, err := s.client.PresignHeader(ctx, http.MethodPut, s.bucket, key, expires, nil, headers)
+	if err != nil {
+		return nil, fmt.Errorf("生成上傳網址失敗: %v", err)
+	}
+
+	return &PresignedUpload{
+		Key:       key,
+		Method:    http.MethodPut,
+		URL:       link.String(),
+		Headers:   map[string]string{"Content-Type": contentType},
+		PublicURL: s.URL(key),
+		ExpiresAt: expiresAt,
+	}, nil
+}
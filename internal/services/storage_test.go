@@ -0,0 +1,337 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"tennis-platform/backend/internal/apperror"
+	"tennis-platform/backend/internal/config"
+	"tennis-platform/backend/internal/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// fakeS3 最小的 S3 相容服務，只支援單一 bucket 的對象讀寫
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	types   map[string]string
+}
+
+func newFakeS3(t *testing.T) (*fakeS3, *httptest.Server) {
+	fake := &fakeS3{objects: map[string][]byte{}, types: map[string]string{}}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	return fake, server
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	key := r.URL.Path
+	switch r.Method {
+	case http.MethodPut:
+		body, err := readS3Body(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		f.objects[key] = body
+		f.types[key] = r.Header.Get("Content-Type")
+		w.Header().Set("ETag", `"fake"`)
+	case http.MethodGet, http.MethodHead:
+		body, ok := f.objects[key]
+		if !ok {
+			w.Header().Set("Content-Type", "application/xml")
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, `<Error><Code>NoSuchKey</Code><Message>not found</Message></Error>`)
+			return
+		}
+		w.Header().Set("Content-Type", f.types[key])
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		w.Header().Set("ETag", `"fake"`)
+		w.Header().Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
+		if r.Method == http.MethodGet {
+			w.Write(body)
+		}
+	case http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// object 返回 bucket 內的對象
+func (f *fakeS3) object(key string) ([]byte, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	body, ok := f.objects["/uploads-bucket/"+key]
+	return body, ok
+}
+
+// readS3Body 讀取請求內容，非 TLS 連線時客戶端以 aws-chunked 分塊簽名上傳
+func readS3Body(r *http.Request) ([]byte, error) {
+	if !strings.HasPrefix(r.Header.Get("X-Amz-Content-Sha256"), "STREAMING-") {
+		return io.ReadAll(r.Body)
+	}
+
+	var body bytes.Buffer
+	reader := bufio.NewReader(r.Body)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		sizeHex, _, _ := strings.Cut(strings.TrimSpace(line), ";")
+		size, err := strconv.ParseInt(sizeHex, 16, 64)
+		if err != nil {
+			return nil, err
+		}
+		if size == 0 {
+			return body.Bytes(), nil
+		}
+		if _, err := io.CopyN(&body, reader, size); err != nil {
+			return nil, err
+		}
+		if _, err := reader.Discard(2); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func newTestS3Storage(t *testing.T) (*S3Storage, *fakeS3) {
+	fake, server := newFakeS3(t)
+	storage, err := NewS3Storage(config.S3Config{
+		Endpoint:  strings.TrimPrefix(server.URL, "http://"),
+		Region:    "us-east-1",
+		Bucket:    "uploads-bucket",
+		AccessKey: "test-access-key",
+		SecretKey: "test-secret-key",
+	})
+	require.NoError(t, err)
+	return storage, fake
+}
+
+func TestValidateKey(t *testing.T) {
+	for _, key := range []string{"avatars/a.jpg", "courts/2024/x_1.png"} {
+		assert.NoError(t, ValidateKey(key), key)
+	}
+	for _, key := range []string{"", "/etc/passwd", "../secret", "courts/../../x", "courts//x.jpg", `courts\x.jpg`, "courts/./x.jpg"} {
+		assert.True(t, apperror.HasCode(ValidateKey(key), apperror.CodeUploadInvalidKey), key)
+	}
+}
+
+func TestLocalStorage_SignedLinks(t *testing.T) {
+	storage := NewLocalStorage(t.TempDir(), "secret")
+	ctx := context.Background()
+
+	require.NoError(t, storage.Put(ctx, "courts/a.jpg", strings.NewReader("image"), 5, "image/jpeg"))
+	assert.Equal(t, "/uploads/courts/a.jpg", storage.URL("courts/a.jpg"))
+
+	link, err := storage.SignedURL(ctx, "courts/a.jpg", time.Minute)
+	require.NoError(t, err)
+	parsed, err := url.Parse(link)
+	require.NoError(t, err)
+	assert.Equal(t, "/api/v1/files/object/courts/a.jpg", parsed.Path)
+
+	expires, signature := parsed.Query().Get("expires"), parsed.Query().Get("signature")
+	assert.NoError(t, storage.VerifySignature("GET", "courts/a.jpg", "", expires, signature))
+
+	// 簽名綁定方法及文件鍵
+	assert.Error(t, storage.VerifySignature("PUT", "courts/a.jpg", "", expires, signature))
+	assert.Error(t, storage.VerifySignature("GET", "courts/b.jpg", "", expires, signature))
+
+	// 過期連結失效
+	expired, err := storage.SignedURL(ctx, "courts/a.jpg", -time.Minute)
+	require.NoError(t, err)
+	parsed, _ = url.Parse(expired)
+	err = storage.VerifySignature("GET", "courts/a.jpg", "", parsed.Query().Get("expires"), parsed.Query().Get("signature"))
+	assert.True(t, apperror.HasCode(err, apperror.CodeUploadInvalidLink))
+
+	// 直傳簽名綁定 Content-Type
+	upload, err := storage.PresignUpload(ctx, "courts/b.png", "image/png", time.Minute)
+	require.NoError(t, err)
+	parsed, _ = url.Parse(upload.URL)
+	expires, signature = parsed.Query().Get("expires"), parsed.Query().Get("signature")
+	assert.NoError(t, storage.VerifySignature("PUT", "courts/b.png", "image/png", expires, signature))
+	assert.Error(t, storage.VerifySignature("PUT", "courts/b.png", "text/html", expires, signature))
+	assert.Equal(t, "/uploads/courts/b.png", upload.PublicURL)
+
+	_, err = storage.Open(ctx, "courts/missing.jpg")
+	assert.True(t, apperror.HasCode(err, apperror.CodeUploadNotFound))
+}
+
+func TestS3Storage_FakeServer(t *testing.T) {
+	storage, fake := newTestS3Storage(t)
+	ctx := context.Background()
+
+	require.NoError(t, storage.Put(ctx, "rackets/r.png", strings.NewReader("racket"), 6, "image/png"))
+	body, ok := fake.object("rackets/r.png")
+	require.True(t, ok)
+	assert.Equal(t, "racket", string(body))
+
+	file, err := storage.Open(ctx, "rackets/r.png")
+	require.NoError(t, err)
+	data, err := io.ReadAll(file)
+	file.Close()
+	require.NoError(t, err)
+	assert.Equal(t, "racket", string(data))
+
+	_, err = storage.Open(ctx, "rackets/missing.png")
+	assert.True(t, apperror.HasCode(err, apperror.CodeUploadNotFound))
+
+	assert.True(t, strings.HasSuffix(storage.URL("rackets/r.png"), "/uploads-bucket/rackets/r.png"))
+
+	link, err := storage.SignedURL(ctx, "rackets/r.png", time.Minute)
+	require.NoError(t, err)
+	assert.Contains(t, link, "X-Amz-Signature=")
+	assert.Contains(t, link, "X-Amz-Expires=60")
+
+	// 以預簽名網址直接上傳
+	upload, err := storage.PresignUpload(ctx, "avatars/a.jpg", "image/jpeg", time.Minute)
+	require.NoError(t, err)
+	assert.Contains(t, upload.URL, "content-type")
+
+	req, err := http.NewRequest(upload.Method, upload.URL, strings.NewReader("avatar"))
+	require.NoError(t, err)
+	for name, value := range upload.Headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	body, ok = fake.object("avatars/a.jpg")
+	require.True(t, ok)
+	assert.Equal(t, "avatar", string(body))
+
+	require.NoError(t, storage.Delete(ctx, "rackets/r.png"))
+	_, ok = fake.object("rackets/r.png")
+	assert.False(t, ok)
+}
+
+func TestUploadService_PresignUpload_Local(t *testing.T) {
+	root := t.TempDir()
+	cfg := &config.Config{Upload: config.UploadConfig{MaxFileSize: 10, AllowedExts: "jpg,png", UploadPath: root}}
+	service := NewUploadService(cfg, NewLocalStorage(root, "secret"))
+	ctx := context.Background()
+
+	_, err := service.PresignUpload(ctx, "user-1", "courts", "x.exe", 5)
+	assert.True(t, apperror.HasCode(err, apperror.CodeUploadUnsupportedType))
+	_, err = service.PresignUpload(ctx, "user-1", "courts", "x.jpg", 11)
+	assert.True(t, apperror.HasCode(err, apperror.CodeUploadTooLarge))
+	_, err = service.PresignUpload(ctx, "user-1", "../etc", "x.jpg", 5)
+	assert.True(t, apperror.HasCode(err, apperror.CodeUploadInvalidKey))
+
+	upload, err := service.PresignUpload(ctx, "user-1", "avatars", "me.png", 5)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(upload.Key, "avatars/avatar_user-1_"))
+	assert.Equal(t, "image/png", upload.Headers["Content-Type"])
+
+	parsed, _ := url.Parse(upload.URL)
+	expires, signature := parsed.Query().Get("expires"), parsed.Query().Get("signature")
+
+	// 長度未知且超過限制時拒絕寫入
+	err = service.PutSigned(ctx, upload.Key, "image/png", expires, signature, strings.NewReader("01234567890"), -1)
+	assert.True(t, apperror.HasCode(err, apperror.CodeUploadTooLarge))
+	_, err = os.Stat(filepath.Join(root, upload.Key))
+	assert.True(t, os.IsNotExist(err))
+
+	require.NoError(t, service.PutSigned(ctx, upload.Key, "image/png", expires, signature, strings.NewReader("image"), 5))
+
+	link, _, err := service.SignedDownloadURL(ctx, "user-1", upload.Key)
+	require.NoError(t, err)
+	parsed, _ = url.Parse(link)
+	file, err := service.OpenSigned(ctx, upload.Key, parsed.Query().Get("expires"), parsed.Query().Get("signature"))
+	require.NoError(t, err)
+	data, _ := io.ReadAll(file)
+	file.Close()
+	assert.Equal(t, "image", string(data))
+}
+
+func TestStorageMigrator_LocalToS3(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	db.Exec(`CREATE TABLE user_profiles (user_id TEXT PRIMARY KEY, avatar_url TEXT)`)
+	require.NoError(t, db.AutoMigrate(&models.ImageAsset{}))
+	for _, table := range imageArrayTables {
+		db.Exec(`CREATE TABLE ` + table + ` (id TEXT PRIMARY KEY, images TEXT)`)
+	}
+
+	ctx := context.Background()
+	src := NewLocalStorage(t.TempDir(), "secret")
+	dst, fake := newTestS3Storage(t)
+	require.NoError(t, src.Put(ctx, "avatars/a.jpg", strings.NewReader("avatar"), 6, "image/jpeg"))
+	require.NoError(t, src.Put(ctx, "courts/c.jpg", strings.NewReader("court"), 5, "image/jpeg"))
+
+	db.Exec(`INSERT INTO user_profiles VALUES ('u1', '/uploads/avatars/a.jpg'), ('u2', 'https://example.com/a.jpg')`)
+	db.Exec(`INSERT INTO courts VALUES ('c1', '{/uploads/courts/c.jpg,https://example.com/c.jpg}'), ('c2', '{/uploads/courts/gone.jpg}')`)
+	db.Exec(`INSERT INTO court_reviews VALUES ('r1', '{/uploads/courts/c.jpg}')`)
+	require.NoError(t, src.Put(ctx, "courts/c_thumbnail.jpg", strings.NewReader("thumb"), 5, "image/jpeg"))
+	db.Create(&models.ImageAsset{
+		Key: "courts/c.jpg", URL: "/uploads/courts/c.jpg", ContentType: "image/jpeg",
+		Variants: models.ImageVariants{"thumbnail": {Key: "courts/c_thumbnail.jpg", URL: "/uploads/courts/c_thumbnail.jpg"}},
+	})
+
+	// 試運行不寫入
+	migrator := NewStorageMigrator(db, src, dst)
+	migrator.DryRun = true
+	result, err := migrator.Migrate(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 4, result.RowsUpdated)
+	_, ok := fake.object("avatars/a.jpg")
+	assert.False(t, ok)
+
+	migrator = NewStorageMigrator(db, src, dst)
+	migrator.DeleteSource = true
+	result, err = migrator.Migrate(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.FilesCopied)
+	assert.Equal(t, 4, result.RowsUpdated)
+	assert.Equal(t, []string{"courts/gone.jpg"}, result.MissingFiles)
+
+	body, ok := fake.object("courts/c.jpg")
+	require.True(t, ok)
+	assert.Equal(t, "court", string(body))
+	_, err = os.Stat(src.Path("courts/c.jpg"))
+	assert.True(t, os.IsNotExist(err), "來源文件應已刪除")
+
+	var avatars []string
+	db.Table("user_profiles").Order("user_id").Pluck("avatar_url", &avatars)
+	assert.Equal(t, []string{dst.URL("avatars/a.jpg"), "https://example.com/a.jpg"}, avatars)
+
+	var court imageRow
+	db.Table("courts").Where("id = ?", "c1").Scan(&court)
+	assert.Equal(t, []string{dst.URL("courts/c.jpg"), "https://example.com/c.jpg"}, []string(court.Images))
+
+	var asset models.ImageAsset
+	db.First(&asset, "key = ?", "courts/c.jpg")
+	assert.Equal(t, dst.URL("courts/c.jpg"), asset.URL)
+	assert.Equal(t, dst.URL("courts/c_thumbnail.jpg"), asset.Variants["thumbnail"].URL)
+	_, ok = fake.object("courts/c_thumbnail.jpg")
+	assert.True(t, ok)
+
+	var missing imageRow
+	db.Table("courts").Where("id = ?", "c2").Scan(&missing)
+	assert.Equal(t, []string{"/uploads/courts/gone.jpg"}, []string(missing.Images))
+
+	// 重跑不會重複處理
+	result, err = NewStorageMigrator(db, src, dst).Migrate(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, result.RowsUpdated)
+}
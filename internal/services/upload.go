@@ -1,64 +1,115 @@
 package services
 
 import (
+	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
+	"mime"
 	"mime/multipart"
-	"os"
 	"path/filepath"
 	"strings"
+	"tennis-platform/backend/internal/apperror"
 	"tennis-platform/backend/internal/config"
+	"tennis-platform/backend/internal/imageproc"
+	"tennis-platform/backend/internal/models"
 	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// 簽名網址有效期
+const (
+	DownloadURLTTL = 15 * time.Minute
+	UploadURLTTL   = 15 * time.Minute
+)
+
+// PublicDirectories 可公開訪問的目錄，其他目錄（例如發票）只能經授權的端點下載
+var PublicDirectories = []string{"avatars", "courts", "reviews", "rackets"}
+
+// 可直傳的目錄
+var presignDirectories = map[string]bool{
+	"avatars": true,
+	"courts":  true,
+	"reviews": true,
+	"rackets": true,
+}
+
 // UploadService 文件上傳服務
 type UploadService struct {
-	config *config.Config
+	config  *config.Config
+	storage Storage
+	db      *gorm.DB
 }
 
 // NewUploadService 創建新的文件上傳服務
-func NewUploadService(cfg *config.Config) *UploadService {
+func NewUploadService(cfg *config.Config, storage Storage) *UploadService {
 	return &UploadService{
-		config: cfg,
+		config:  cfg,
+		storage: storage,
 	}
 }
 
+// UseDB 設置數據庫後保存圖片的尺寸、變體及佔位資訊
+func (us *UploadService) UseDB(db *gorm.DB) {
+	us.db = db
+}
+
 // UploadResult 上傳結果
 type UploadResult struct {
-	FileName     string `json:"fileName"`
-	OriginalName string `json:"originalName"`
-	Size         int64  `json:"size"`
-	URL          string `json:"url"`
-	Path         string `json:"path"`
+	FileName     string             `json:"fileName"`
+	OriginalName string             `json:"originalName"`
+	Size         int64              `json:"size"`
+	URL          string             `json:"url"`
+	Path         string             `json:"path"`            // 存儲鍵，刪除文件時使用
+	Image        *models.ImageAsset `json:"image,omitempty"` // 圖片的尺寸、變體及佔位資訊
+}
+
+// Storage 返回使用中的對象存儲
+func (us *UploadService) Storage() Storage {
+	return us.storage
 }
 
 // UploadAvatar 上傳頭像
 func (us *UploadService) UploadAvatar(file *multipart.FileHeader, userID string) (*UploadResult, error) {
 	// 驗證文件類型
 	if !us.isValidImageType(file.Filename) {
-		return nil, errors.New("不支援的文件類型，僅支援 jpg, jpeg, png, gif")
+		return nil, apperror.New(apperror.CodeUploadUnsupportedType).With("allowed", "jpg, jpeg, png, gif")
 	}
 
 	// 驗證文件大小
 	if file.Size > us.config.Upload.MaxFileSize {
-		return nil, fmt.Errorf("文件大小超過限制，最大允許 %d MB", us.config.Upload.MaxFileSize/(1024*1024))
+		return nil, apperror.New(apperror.CodeUploadTooLarge).With("maxMB", us.config.Upload.MaxFileSize/(1024*1024))
 	}
 
 	// 生成唯一文件名
-	ext := filepath.Ext(file.Filename)
-	fileName := fmt.Sprintf("avatar_%s_%d%s", userID, time.Now().Unix(), ext)
+	fileName := avatarFileName(userID, file.Filename)
+	return us.save(file, "avatars", fileName)
+}
 
-	// 創建上傳目錄
-	avatarDir := filepath.Join(us.config.Upload.UploadPath, "avatars")
-	if err := os.MkdirAll(avatarDir, 0755); err != nil {
-		return nil, fmt.Errorf("創建上傳目錄失敗: %v", err)
+// UploadFile 通用文件上傳
+func (us *UploadService) UploadFile(file *multipart.FileHeader, subDir string) (*UploadResult, error) {
+	// 驗證文件類型
+	if !us.isValidFileType(file.Filename) {
+		return nil, apperror.New(apperror.CodeUploadUnsupportedType).With("allowed", us.config.Upload.AllowedExts)
+	}
+
+	// 驗證文件大小
+	if file.Size > us.config.Upload.MaxFileSize {
+		return nil, apperror.New(apperror.CodeUploadTooLarge).With("maxMB", us.config.Upload.MaxFileSize/(1024*1024))
 	}
 
-	// 完整文件路徑
-	filePath := filepath.Join(avatarDir, fileName)
+	// 生成唯一文件名
+	fileName := uniqueFileName(file.Filename)
+	return us.save(file, subDir, fileName)
+}
+
+// save 將上傳的文件寫入存儲，圖片經處理後保存
+func (us *UploadService) save(file *multipart.FileHeader, subDir, fileName string) (*UploadResult, error) {
+	key := subDir + "/" + fileName
 
 	// 打開上傳的文件
 	src, err := file.Open()
@@ -67,104 +118,242 @@ func (us *UploadService) UploadAvatar(file *multipart.FileHeader, userID string)
 	}
 	defer src.Close()
 
-	// 創建目標文件
-	dst, err := os.Create(filePath)
+	result := &UploadResult{
+		FileName:     fileName,
+		OriginalName: file.Filename,
+		Size:         file.Size,
+		URL:          us.storage.URL(key),
+		Path:         key,
+	}
+
+	if !imageproc.IsImageExt(filepath.Ext(file.Filename)) {
+		if err := us.storage.Put(context.Background(), key, src, file.Size, contentTypeOf(file.Filename)); err != nil {
+			return nil, err
+		}
+		return result, nil
+	}
+
+	data, err := io.ReadAll(src)
 	if err != nil {
-		return nil, fmt.Errorf("創建目標文件失敗: %v", err)
+		return nil, fmt.Errorf("讀取上傳文件失敗: %v", err)
 	}
-	defer dst.Close()
+	if result.Image, err = us.storeImage(context.Background(), key, file.Filename, data); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
 
-	// 複製文件內容
-	if _, err := io.Copy(dst, src); err != nil {
-		return nil, fmt.Errorf("保存文件失敗: %v", err)
+// DeleteFile 刪除文件
+func (us *UploadService) DeleteFile(key string) error {
+	if key == "" {
+		return nil
+	}
+
+	// 確保文件鍵在上傳目錄內
+	if err := ValidateKey(key); err != nil {
+		return err
 	}
 
-	// 生成訪問URL
-	url := fmt.Sprintf("/uploads/avatars/%s", fileName)
+	if err := us.deleteImageVariants(context.Background(), key); err != nil {
+		return err
+	}
+	return us.storage.Delete(context.Background(), key)
+}
 
+// SaveDocument 保存系統生成的文件，例如發票 PDF；文件名需由調用方確保唯一且不可猜測
+func (us *UploadService) SaveDocument(ctx context.Context, subDir, fileName string, data []byte) (*UploadResult, error) {
+	key := subDir + "/" + fileName
+	if err := ValidateKey(key); err != nil {
+		return nil, err
+	}
+	if err := us.storage.Put(ctx, key, bytes.NewReader(data), int64(len(data)), contentTypeOf(fileName)); err != nil {
+		return nil, err
+	}
 	return &UploadResult{
 		FileName:     fileName,
-		OriginalName: file.Filename,
-		Size:         file.Size,
-		URL:          url,
-		Path:         filePath,
+		OriginalName: fileName,
+		Size:         int64(len(data)),
+		URL:          us.storage.URL(key),
+		Path:         key,
 	}, nil
 }
 
-// UploadFile 通用文件上傳
-func (us *UploadService) UploadFile(file *multipart.FileHeader, subDir string) (*UploadResult, error) {
-	// 驗證文件類型
-	if !us.isValidFileType(file.Filename) {
-		return nil, errors.New("不支援的文件類型")
+// OpenFile 讀取存儲中的文件
+func (us *UploadService) OpenFile(ctx context.Context, key string) (io.ReadCloser, error) {
+	if err := ValidateKey(key); err != nil {
+		return nil, err
 	}
+	return us.storage.Open(ctx, key)
+}
 
-	// 驗證文件大小
-	if file.Size > us.config.Upload.MaxFileSize {
-		return nil, fmt.Errorf("文件大小超過限制，最大允許 %d MB", us.config.Upload.MaxFileSize/(1024*1024))
+// SignedDownloadURL 返回限時有效的下載網址
+//
+// 只限公開目錄的文件或用戶自己直傳的文件，發票等私人文件需經其授權的端點下載。
+func (us *UploadService) SignedDownloadURL(ctx context.Context, userID, key string) (string, time.Time, error) {
+	if err := ValidateKey(key); err != nil {
+		return "", time.Time{}, err
+	}
+	if !IsPublicKey(key) {
+		if _, err := us.uploadSession(ctx, userID, key); err != nil {
+			return "", time.Time{}, err
+		}
 	}
 
-	// 生成唯一文件名
-	ext := filepath.Ext(file.Filename)
-	fileName := fmt.Sprintf("%s_%d%s", uuid.New().String(), time.Now().Unix(), ext)
+	expiresAt := time.Now().Add(DownloadURLTTL)
+	link, err := us.storage.SignedURL(ctx, key, DownloadURLTTL)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return link, expiresAt, nil
+}
 
-	// 創建上傳目錄
-	uploadDir := filepath.Join(us.config.Upload.UploadPath, subDir)
-	if err := os.MkdirAll(uploadDir, 0755); err != nil {
-		return nil, fmt.Errorf("創建上傳目錄失敗: %v", err)
+// PresignUpload 為客戶端直傳生成上傳網址，文件類型及大小規則與一般上傳相同
+func (us *UploadService) PresignUpload(ctx context.Context, userID, directory, filename string, size int64) (*PresignedUpload, error) {
+	if !presignDirectories[directory] {
+		return nil, apperror.New(apperror.CodeUploadInvalidKey)
 	}
 
-	// 完整文件路徑
-	filePath := filepath.Join(uploadDir, fileName)
+	var fileName string
+	if directory == "avatars" {
+		if !us.isValidImageType(filename) {
+			return nil, apperror.New(apperror.CodeUploadUnsupportedType).With("allowed", "jpg, jpeg, png, gif")
+		}
+		fileName = avatarFileName(userID, filename)
+	} else {
+		if !us.isValidFileType(filename) {
+			return nil, apperror.New(apperror.CodeUploadUnsupportedType).With("allowed", us.config.Upload.AllowedExts)
+		}
+		fileName = uniqueFileName(filename)
+	}
 
-	// 打開上傳的文件
-	src, err := file.Open()
-	if err != nil {
-		return nil, fmt.Errorf("打開上傳文件失敗: %v", err)
+	if size > us.config.Upload.MaxFileSize {
+		return nil, apperror.New(apperror.CodeUploadTooLarge).With("maxMB", us.config.Upload.MaxFileSize/(1024*1024))
 	}
-	defer src.Close()
 
-	// 創建目標文件
-	dst, err := os.Create(filePath)
+	upload, err := us.storage.PresignUpload(ctx, directory+"/"+fileName, contentTypeOf(filename), UploadURLTTL)
 	if err != nil {
-		return nil, fmt.Errorf("創建目標文件失敗: %v", err)
+		return nil, err
 	}
-	defer dst.Close()
 
-	// 複製文件內容
-	if _, err := io.Copy(dst, src); err != nil {
-		return nil, fmt.Errorf("保存文件失敗: %v", err)
+	// 記錄申請者，完成上傳時只接受同一用戶
+	if us.db != nil {
+		session := models.UploadSession{Key: upload.Key, UserID: userID, ContentType: contentTypeOf(filename)}
+		if err := us.db.WithContext(ctx).Clauses(clause.OnConflict{UpdateAll: true}).Create(&session).Error; err != nil {
+			return nil, fmt.Errorf("保存上傳記錄失敗: %v", err)
+		}
 	}
+	return upload, nil
+}
 
-	// 生成訪問URL
-	url := fmt.Sprintf("/uploads/%s/%s", subDir, fileName)
+// uploadSession 獲取用戶申請的直傳記錄，不是申請者時返回 CodeUploadForbidden
+func (us *UploadService) uploadSession(ctx context.Context, userID, key string) (*models.UploadSession, error) {
+	if us.db == nil {
+		return nil, apperror.New(apperror.CodeUploadForbidden)
+	}
 
-	return &UploadResult{
-		FileName:     fileName,
-		OriginalName: file.Filename,
-		Size:         file.Size,
-		URL:          url,
-		Path:         filePath,
-	}, nil
+	var session models.UploadSession
+	if err := us.db.WithContext(ctx).Where("key = ?", key).First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.New(apperror.CodeUploadForbidden)
+		}
+		return nil, fmt.Errorf("查詢上傳記錄失敗: %v", err)
+	}
+	if session.UserID != userID {
+		return nil, apperror.New(apperror.CodeUploadForbidden)
+	}
+	return &session, nil
 }
 
-// DeleteFile 刪除文件
-func (us *UploadService) DeleteFile(filePath string) error {
-	if filePath == "" {
-		return nil
+// IsPublicKey 判斷存儲鍵是否位於可公開訪問的目錄
+func IsPublicKey(key string) bool {
+	dir, _, found := strings.Cut(key, "/")
+	if !found {
+		return false
 	}
+	for _, public := range PublicDirectories {
+		if dir == public {
+			return true
+		}
+	}
+	return false
+}
+
+// OpenSigned 驗證簽名後讀取文件，僅用於由 API 服務器處理簽名連結的存儲
+func (us *UploadService) OpenSigned(ctx context.Context, key, expires, signature string) (io.ReadCloser, error) {
+	verifier, ok := us.storage.(LinkVerifier)
+	if !ok {
+		return nil, apperror.New(apperror.CodeUploadInvalidLink)
+	}
+	if err := verifier.VerifySignature("GET", key, "", expires, signature); err != nil {
+		return nil, err
+	}
+	return us.storage.Open(ctx, key)
+}
 
-	// 確保文件路徑在上傳目錄內
-	if !strings.HasPrefix(filePath, us.config.Upload.UploadPath) {
-		return errors.New("無效的文件路徑")
+// PutSigned 驗證簽名後寫入直傳的文件，超過大小限制時返回 CodeUploadTooLarge
+func (us *UploadService) PutSigned(ctx context.Context, key, contentType, expires, signature string, r io.Reader, size int64) error {
+	verifier, ok := us.storage.(LinkVerifier)
+	if !ok {
+		return apperror.New(apperror.CodeUploadInvalidLink)
+	}
+	if err := verifier.VerifySignature("PUT", key, contentType, expires, signature); err != nil {
+		return err
 	}
 
-	if err := os.Remove(filePath); err != nil && !os.IsNotExist(err) {
-		return fmt.Errorf("刪除文件失敗: %v", err)
+	maxSize := us.config.Upload.MaxFileSize
+	if size > maxSize {
+		return apperror.New(apperror.CodeUploadTooLarge).With("maxMB", maxSize/(1024*1024))
 	}
 
+	// 長度未知時多讀一個字節以判斷是否超過限制
+	limited := &limitedReader{r: io.LimitReader(r, maxSize+1), max: maxSize}
+	if err := us.storage.Put(ctx, key, limited, size, contentType); err != nil {
+		if limited.exceeded {
+			return apperror.New(apperror.CodeUploadTooLarge).With("maxMB", maxSize/(1024*1024))
+		}
+		return err
+	}
 	return nil
 }
 
+// limitedReader 讀取超過 max 字節時返回錯誤，避免寫入過大的文件
+type limitedReader struct {
+	r        io.Reader
+	max      int64
+	read     int64
+	exceeded bool
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	l.read += int64(n)
+	if l.read > l.max {
+		l.exceeded = true
+		return n, errors.New("文件大小超過限制")
+	}
+	return n, err
+}
+
+// avatarFileName 生成頭像文件名
+func avatarFileName(userID, originalName string) string {
+	return fmt.Sprintf("avatar_%s_%d%s", userID, time.Now().Unix(), filepath.Ext(originalName))
+}
+
+// uniqueFileName 生成唯一文件名
+func uniqueFileName(originalName string) string {
+	return fmt.Sprintf("%s_%d%s", uuid.New().String(), time.Now().Unix(), filepath.Ext(originalName))
+}
+
+// contentTypeOf 依擴展名推斷內容類型
+func contentTypeOf(filename string) string {
+	if contentType := mime.TypeByExtension(strings.ToLower(filepath.Ext(filename))); contentType != "" {
+		// 只保留媒體類型，與請求的 Content-Type 比較時忽略 charset 等參數
+		mediaType, _, _ := strings.Cut(contentType, ";")
+		return mediaType
+	}
+	return "application/octet-stream"
+}
+
 // isValidImageType 檢查是否為有效的圖片類型
 func (us *UploadService) isValidImageType(filename string) bool {
 	ext := strings.ToLower(filepath.Ext(filename))
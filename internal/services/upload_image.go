@@ -0,0 +1,158 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"tennis-platform/backend/internal/apperror"
+	"tennis-platform/backend/internal/imageproc"
+	"tennis-platform/backend/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// variantKey 變體的存儲鍵，例如 courts/abc_123.jpg 的縮圖為 courts/abc_123_thumbnail.jpg
+func variantKey(key, name, ext string) string {
+	return strings.TrimSuffix(key, path.Ext(key)) + "_" + name + ext
+}
+
+// storeImage 處理圖片後寫入原圖及各變體，並保存圖片資訊
+func (us *UploadService) storeImage(ctx context.Context, key, filename string, data []byte) (*models.ImageAsset, error) {
+	processed, err := imageproc.Process(data, filename)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := us.storage.Put(ctx, key, bytes.NewReader(processed.Data), int64(len(processed.Data)), processed.ContentType); err != nil {
+		return nil, err
+	}
+
+	asset := &models.ImageAsset{
+		Key:           key,
+		URL:           us.storage.URL(key),
+		ContentType:   processed.ContentType,
+		Width:         processed.Width,
+		Height:        processed.Height,
+		Variants:      make(models.ImageVariants, len(processed.Variants)),
+		Blurhash:      processed.Blurhash,
+		DominantColor: processed.DominantColor,
+	}
+
+	for _, variant := range processed.Variants {
+		vKey := variantKey(key, variant.Name, variant.Ext)
+		if err := us.storage.Put(ctx, vKey, bytes.NewReader(variant.Data), int64(len(variant.Data)), variant.ContentType); err != nil {
+			return nil, err
+		}
+		asset.Variants[variant.Name] = models.ImageVariant{
+			Key:         vKey,
+			URL:         us.storage.URL(vKey),
+			Width:       variant.Width,
+			Height:      variant.Height,
+			ContentType: variant.ContentType,
+		}
+	}
+
+	if us.db != nil {
+		// 同一文件重新處理時覆蓋舊資訊
+		if err := us.db.WithContext(ctx).Clauses(clause.OnConflict{UpdateAll: true}).Create(asset).Error; err != nil {
+			return nil, fmt.Errorf("保存圖片資訊失敗: %v", err)
+		}
+	}
+
+	return asset, nil
+}
+
+// ProcessUploaded 處理以直傳網址上傳的圖片，與一般上傳一樣去除中繼資料並生成變體
+//
+// 只有申請直傳網址的用戶可以完成上傳；內容無效時刪除已上傳的文件，避免未經處理的圖片被公開訪問。
+func (us *UploadService) ProcessUploaded(ctx context.Context, userID, key string) (*UploadResult, error) {
+	if err := ValidateKey(key); err != nil {
+		return nil, err
+	}
+	dir, _, _ := strings.Cut(key, "/")
+	if !presignDirectories[dir] || !imageproc.IsImageExt(path.Ext(key)) {
+		return nil, apperror.New(apperror.CodeUploadInvalidKey)
+	}
+	session, err := us.uploadSession(ctx, userID, key)
+	if err != nil {
+		return nil, err
+	}
+
+	src, err := us.storage.Open(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(io.LimitReader(src, us.config.Upload.MaxFileSize+1))
+	src.Close()
+	if err != nil {
+		return nil, fmt.Errorf("讀取上傳文件失敗: %v", err)
+	}
+
+	if int64(len(data)) > us.config.Upload.MaxFileSize {
+		us.storage.Delete(ctx, key)
+		return nil, apperror.New(apperror.CodeUploadTooLarge).With("maxMB", us.config.Upload.MaxFileSize/(1024*1024))
+	}
+
+	asset, err := us.storeImage(ctx, key, key, data)
+	if err != nil {
+		if apperror.HasCode(err, apperror.CodeUploadInvalidImage) {
+			us.storage.Delete(ctx, key)
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	if err := us.db.WithContext(ctx).Model(session).Update("completed_at", now).Error; err != nil {
+		return nil, fmt.Errorf("更新上傳記錄失敗: %v", err)
+	}
+
+	return &UploadResult{
+		FileName:     path.Base(key),
+		OriginalName: path.Base(key),
+		Size:         int64(len(data)),
+		URL:          asset.URL,
+		Path:         key,
+		Image:        asset,
+	}, nil
+}
+
+// GetImageAssets 依公開網址查詢圖片資訊，未處理過的網址不返回
+func (us *UploadService) GetImageAssets(ctx context.Context, urls []string) ([]models.ImageAsset, error) {
+	assets := []models.ImageAsset{}
+	if us.db == nil || len(urls) == 0 {
+		return assets, nil
+	}
+
+	if err := us.db.WithContext(ctx).Where("url IN ?", urls).Find(&assets).Error; err != nil {
+		return nil, fmt.Errorf("查詢圖片資訊失敗: %v", err)
+	}
+	return assets, nil
+}
+
+// deleteImageVariants 刪除圖片的變體及圖片資訊
+func (us *UploadService) deleteImageVariants(ctx context.Context, key string) error {
+	if us.db == nil {
+		return nil
+	}
+
+	var asset models.ImageAsset
+	if err := us.db.WithContext(ctx).Where("key = ?", key).First(&asset).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return fmt.Errorf("查詢圖片資訊失敗: %v", err)
+	}
+
+	for _, variant := range asset.Variants {
+		if err := us.storage.Delete(ctx, variant.Key); err != nil {
+			return err
+		}
+	}
+	return us.db.WithContext(ctx).Delete(&asset).Error
+}
@@ -2,14 +2,23 @@ package services
 
 import (
 	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/jpeg"
 	"mime/multipart"
 	"net/textproto"
 	"os"
+	"path/filepath"
 	"strings"
+	"tennis-platform/backend/internal/apperror"
 	"tennis-platform/backend/internal/config"
+	"tennis-platform/backend/internal/models"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
 )
 
 func setupUploadService() *UploadService {
@@ -20,7 +29,7 @@ func setupUploadService() *UploadService {
 			UploadPath:  "./test_uploads",
 		},
 	}
-	return NewUploadService(cfg)
+	return NewUploadService(cfg, NewLocalStorage(cfg.Upload.UploadPath, "test-signing-secret"))
 }
 
 func createTestFileHeader(filename string, content []byte) *multipart.FileHeader {
@@ -136,7 +145,7 @@ func TestUploadService_UploadAvatar_Success(t *testing.T) {
 	defer os.RemoveAll(testDir)
 
 	// 創建有效的圖片文件
-	content := []byte("fake image content")
+	content := testJPEG(64, 48)
 	fileHeader := createTestFileHeader("test.jpg", content)
 
 	result, err := service.UploadAvatar(fileHeader, "test-user-id")
@@ -163,3 +172,102 @@ func TestUploadService_UploadAvatar_Success(t *testing.T) {
 		os.Remove(result.Path)
 	}
 }
+
+func TestUploadService_UploadAvatar_ProcessesImage(t *testing.T) {
+	root := t.TempDir()
+	cfg := &config.Config{Upload: config.UploadConfig{MaxFileSize: 10 * 1024 * 1024, AllowedExts: "jpg,png,pdf", UploadPath: root}}
+	service := NewUploadService(cfg, NewLocalStorage(root, "secret"))
+
+	// 內容不是圖片
+	_, err := service.UploadAvatar(createTestFileHeader("test.jpg", []byte("fake image content")), "test-user-id")
+	assert.True(t, apperror.HasCode(err, apperror.CodeUploadInvalidImage))
+
+	result, err := service.UploadAvatar(createTestFileHeader("test.jpg", testJPEG(640, 480)), "test-user-id")
+	assert.NoError(t, err)
+	assert.NotNil(t, result.Image)
+	assert.Equal(t, 640, result.Image.Width)
+	assert.Equal(t, 200, result.Image.Variants["thumbnail"].Width)
+	assert.Equal(t, 640, result.Image.Variants["medium"].Width, "小於變體尺寸時不放大")
+
+	thumbnail := result.Image.Variants["thumbnail"]
+	assert.Equal(t, strings.TrimSuffix(result.Path, ".jpg")+"_thumbnail.jpg", thumbnail.Key)
+	_, err = os.Stat(filepath.Join(root, thumbnail.Key))
+	assert.NoError(t, err)
+
+	// 非圖片文件原樣保存
+	pdf := []byte("%PDF-1.4 test")
+	result, err = service.UploadFile(createTestFileHeader("doc.pdf", pdf), "courts")
+	assert.NoError(t, err)
+	assert.Nil(t, result.Image)
+	saved, err := os.ReadFile(filepath.Join(root, result.Path))
+	assert.NoError(t, err)
+	assert.Equal(t, pdf, saved)
+}
+
+// testJPEG 生成指定尺寸的 JPEG 圖片
+func testJPEG(width, height int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	jpeg.Encode(&buf, img, nil)
+	return buf.Bytes()
+}
+
+func TestUploadService_ProcessUploaded(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	assert.NoError(t, err)
+	assert.NoError(t, db.AutoMigrate(&models.ImageAsset{}, &models.UploadSession{}))
+
+	root := t.TempDir()
+	cfg := &config.Config{Upload: config.UploadConfig{MaxFileSize: 10 * 1024 * 1024, AllowedExts: "jpg,png", UploadPath: root}}
+	storage := NewLocalStorage(root, "secret")
+	service := NewUploadService(cfg, storage)
+	service.UseDB(db)
+	ctx := context.Background()
+
+	// 直傳的無效內容會被刪除
+	bad, err := service.PresignUpload(ctx, "user-1", "courts", "bad.jpg", 8)
+	assert.NoError(t, err)
+	assert.NoError(t, storage.Put(ctx, bad.Key, strings.NewReader("<script>"), 8, "image/jpeg"))
+	_, err = service.ProcessUploaded(ctx, "user-1", bad.Key)
+	assert.True(t, apperror.HasCode(err, apperror.CodeUploadInvalidImage))
+	_, err = os.Stat(storage.Path(bad.Key))
+	assert.True(t, os.IsNotExist(err))
+
+	// 只有申請直傳網址的用戶可以完成上傳，其他用戶不能刪除或處理文件
+	content := testJPEG(32, 32)
+	assert.NoError(t, storage.Put(ctx, "courts/good.jpg", bytes.NewReader(content), int64(len(content)), "image/jpeg"))
+	_, err = service.ProcessUploaded(ctx, "user-1", "courts/good.jpg")
+	assert.True(t, apperror.HasCode(err, apperror.CodeUploadForbidden))
+	assert.NoError(t, db.Create(&models.UploadSession{Key: "courts/good.jpg", UserID: "user-1", ContentType: "image/jpeg"}).Error)
+	_, err = service.ProcessUploaded(ctx, "user-2", "courts/good.jpg")
+	assert.True(t, apperror.HasCode(err, apperror.CodeUploadForbidden))
+	result, err := service.ProcessUploaded(ctx, "user-1", "courts/good.jpg")
+	assert.NoError(t, err)
+	assert.Equal(t, "courts/good_thumbnail.jpg", result.Image.Variants["thumbnail"].Key)
+	var session models.UploadSession
+	assert.NoError(t, db.First(&session, "key = ?", "courts/good.jpg").Error)
+	assert.NotNil(t, session.CompletedAt)
+
+	// 非公開目錄的文件不提供簽名下載網址
+	_, _, err = service.SignedDownloadURL(ctx, "user-1", "invoices/invoice-1.pdf")
+	assert.True(t, apperror.HasCode(err, apperror.CodeUploadForbidden))
+	_, _, err = service.SignedDownloadURL(ctx, "user-2", "courts/good.jpg")
+	assert.NoError(t, err)
+
+	assets, err := service.GetImageAssets(ctx, []string{"/uploads/courts/good.jpg", "https://example.com/x.jpg"})
+	assert.NoError(t, err)
+	assert.Len(t, assets, 1)
+	assert.NotEmpty(t, assets[0].Blurhash)
+
+	// 刪除時一併刪除變體及圖片資訊
+	assert.NoError(t, service.DeleteFile("courts/good.jpg"))
+	_, err = os.Stat(storage.Path("courts/good_thumbnail.jpg"))
+	assert.True(t, os.IsNotExist(err))
+	assets, _ = service.GetImageAssets(ctx, []string{"/uploads/courts/good.jpg"})
+	assert.Empty(t, assets)
+}
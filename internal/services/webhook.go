@@ -0,0 +1,561 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"syscall"
+	"tennis-platform/backend/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Webhook 版本與請求頭
+const (
+	WebhookPayloadVersion = "1"
+
+	WebhookHeaderID        = "X-Webhook-Id"
+	WebhookHeaderEvent     = "X-Webhook-Event"
+	WebhookHeaderTimestamp = "X-Webhook-Timestamp"
+	WebhookHeaderSignature = "X-Webhook-Signature"
+)
+
+// Webhook 投遞狀態
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryFailed    = "failed"
+)
+
+// WebhookEventTypes 可訂閱的 Webhook 事件類型
+var WebhookEventTypes = []string{
+	EventBookingCreated,
+	EventBookingStatusChanged,
+	EventBookingCancelled,
+	EventBookingAttendanceRecorded,
+	EventRegistrationConfirmed,
+	EventRegistrationCancelled,
+}
+
+// maxWebhookResponseBody 投遞記錄中保存的回應內容上限
+const maxWebhookResponseBody = 2048
+
+// ErrWebhookPrivateAddress Webhook 地址指向內部網絡
+var ErrWebhookPrivateAddress = errors.New("webhook address resolves to a private network")
+
+// blockedWebhookPrefixes 標準庫未分類但同樣不可作為 Webhook 目標的網段
+var blockedWebhookPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),     // 本網絡
+	netip.MustParsePrefix("100.64.0.0/10"), // 電信級 NAT
+	netip.MustParsePrefix("192.0.0.0/24"),  // IETF 協議分配
+	netip.MustParsePrefix("198.18.0.0/15"), // 基準測試
+	netip.MustParsePrefix("64:ff9b::/96"),  // NAT64，可映射到內部 IPv4
+}
+
+// WebhookPayload Webhook 請求內容
+type WebhookPayload struct {
+	ID        string          `json:"id"`
+	Type      string          `json:"type"`
+	Version   string          `json:"version"`
+	CreatedAt time.Time       `json:"createdAt"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// WebhookService Webhook 投遞服務
+type WebhookService struct {
+	db               *gorm.DB
+	client           *http.Client
+	PollInterval     time.Duration // 輪詢間隔
+	BatchSize        int           // 每批投遞數量
+	MaxAttempts      int           // 單次投遞的最大嘗試次數
+	DisableThreshold int           // 連續失敗達到此次數後自動停用訂閱
+	BaseBackoff      time.Duration // 首次重試延遲
+	MaxBackoff       time.Duration // 重試延遲上限
+	LeaseTimeout     time.Duration // 領取投遞後的租約時間，逾時未完成將重新投遞
+
+	// AllowPrivateNetworks 允許投遞到回環及內部網絡地址，僅供本機開發及測試使用
+	AllowPrivateNetworks bool
+}
+
+// NewWebhookService 創建新的 Webhook 服務
+func NewWebhookService(db *gorm.DB) *WebhookService {
+	ws := &WebhookService{
+		db:               db,
+		PollInterval:     5 * time.Second,
+		BatchSize:        50,
+		MaxAttempts:      8,
+		DisableThreshold: 20,
+		BaseBackoff:      30 * time.Second,
+		MaxBackoff:       6 * time.Hour,
+		LeaseTimeout:     time.Minute,
+	}
+
+	// 連線時再次檢查實際連接的地址，避免 DNS 在訂閱後改指向內部網絡；不使用代理，也不跟隨重定向
+	dialer := &net.Dialer{Timeout: 5 * time.Second, Control: ws.checkDialAddress}
+	ws.client = &http.Client{
+		Timeout: 10 * time.Second,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: 5 * time.Second,
+			MaxIdleConnsPerHost: 2,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	return ws
+}
+
+// CheckHost 解析 Webhook 主機，任一地址指向回環、私有、鏈路本地或其他內部網絡時返回 ErrWebhookPrivateAddress
+func (ws *WebhookService) CheckHost(ctx context.Context, host string) error {
+	if ws.AllowPrivateNetworks {
+		return nil
+	}
+
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return fmt.Errorf("failed to resolve webhook host: %w", err)
+	}
+	for _, addr := range addrs {
+		if !isPublicWebhookAddr(addr) {
+			return ErrWebhookPrivateAddress
+		}
+	}
+	return nil
+}
+
+// checkDialAddress 在建立連線前檢查解析後的地址
+func (ws *WebhookService) checkDialAddress(network, address string, _ syscall.RawConn) error {
+	if ws.AllowPrivateNetworks {
+		return nil
+	}
+
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil || !isPublicWebhookAddr(addrPort.Addr()) {
+		return ErrWebhookPrivateAddress
+	}
+	return nil
+}
+
+// isPublicWebhookAddr 判斷地址是否可作為 Webhook 目標
+func isPublicWebhookAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsValid() || addr.IsLoopback() || addr.IsPrivate() || addr.IsUnspecified() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() || addr.IsMulticast() {
+		return false
+	}
+	for _, prefix := range blockedWebhookPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// GenerateWebhookSecret 生成 Webhook 簽名密鑰
+func GenerateWebhookSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return "whsec_" + hex.EncodeToString(buf), nil
+}
+
+// SignWebhookPayload 計算簽名：HMAC-SHA256(secret, "{timestamp}.{body}")
+func SignWebhookPayload(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyWebhookSignature 驗證簽名及時間戳（供接收端及測試使用）
+func VerifyWebhookSignature(secret, signature, timestamp string, body []byte, tolerance time.Duration) error {
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return errors.New("invalid webhook timestamp")
+	}
+
+	if tolerance > 0 {
+		age := time.Since(time.Unix(ts, 0))
+		if age > tolerance || age < -tolerance {
+			return errors.New("webhook timestamp outside tolerance")
+		}
+	}
+
+	expected := SignWebhookPayload(secret, ts, body)
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return errors.New("webhook signature mismatch")
+	}
+
+	return nil
+}
+
+// IsWebhookEventType 檢查事件類型是否可訂閱
+func IsWebhookEventType(eventType string) bool {
+	for _, t := range WebhookEventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// EnqueueForOwner 為擁有者的有效訂閱建立投遞記錄
+func (ws *WebhookService) EnqueueForOwner(ctx context.Context, ownerID string, event *DomainEvent) error {
+	var subscriptions []models.WebhookSubscription
+	if err := ws.db.WithContext(ctx).
+		Where("owner_id = ? AND is_active = ?", ownerID, true).
+		Find(&subscriptions).Error; err != nil {
+		return fmt.Errorf("failed to load webhook subscriptions: %w", err)
+	}
+
+	body, err := json.Marshal(WebhookPayload{
+		ID:        event.ID,
+		Type:      event.Type,
+		Version:   WebhookPayloadVersion,
+		CreatedAt: event.OccurredAt,
+		Data:      event.Payload,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook payload: %w", err)
+	}
+
+	var deliveries []models.WebhookDelivery
+	for _, subscription := range subscriptions {
+		if !subscriptionWants(&subscription, event.Type) {
+			continue
+		}
+		deliveries = append(deliveries, models.WebhookDelivery{
+			SubscriptionID: subscription.ID,
+			EventID:        event.ID,
+			EventType:      event.Type,
+			Payload:        body,
+			Status:         WebhookDeliveryPending,
+			NextAttemptAt:  time.Now(),
+		})
+	}
+
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	if err := ws.db.WithContext(ctx).Create(&deliveries).Error; err != nil {
+		return fmt.Errorf("failed to create webhook deliveries: %w", err)
+	}
+
+	return nil
+}
+
+// Start 啟動投遞循環，直到 ctx 結束
+func (ws *WebhookService) Start(ctx context.Context) {
+	ticker := time.NewTicker(ws.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := ws.DeliverDue(ctx); err != nil {
+			log.Printf("Webhook delivery error: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DeliverDue 投遞一批到期的待處理記錄，返回本次處理數量
+func (ws *WebhookService) DeliverDue(ctx context.Context) (int, error) {
+	deliveries, err := ws.claimDue(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := range deliveries {
+		ws.Attempt(ctx, &deliveries[i])
+	}
+
+	return len(deliveries), nil
+}
+
+// claimDue 領取一批到期的投遞並延後其下次投遞時間作為租約，避免多個實例重複投遞
+func (ws *WebhookService) claimDue(ctx context.Context) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+
+	err := ws.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", WebhookDeliveryPending, now).
+			Order("next_attempt_at ASC").
+			Limit(ws.BatchSize).
+			Find(&deliveries).Error; err != nil {
+			return err
+		}
+
+		if len(deliveries) == 0 {
+			return nil
+		}
+
+		ids := make([]string, len(deliveries))
+		for i, delivery := range deliveries {
+			ids[i] = delivery.ID
+		}
+
+		return tx.Model(&models.WebhookDelivery{}).
+			Where("id IN ?", ids).
+			Update("next_attempt_at", now.Add(ws.LeaseTimeout)).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim webhook deliveries: %w", err)
+	}
+
+	// 租約生效後再載入訂閱，投遞期間不持有行鎖
+	subscriptions := map[string]*models.WebhookSubscription{}
+	for i := range deliveries {
+		delivery := &deliveries[i]
+		if _, ok := subscriptions[delivery.SubscriptionID]; !ok {
+			var subscription models.WebhookSubscription
+			if err := ws.db.WithContext(ctx).Where("id = ?", delivery.SubscriptionID).First(&subscription).Error; err != nil {
+				if !errors.Is(err, gorm.ErrRecordNotFound) {
+					return nil, fmt.Errorf("failed to load webhook subscription: %w", err)
+				}
+				subscriptions[delivery.SubscriptionID] = nil
+			} else {
+				subscriptions[delivery.SubscriptionID] = &subscription
+			}
+		}
+		delivery.Subscription = subscriptions[delivery.SubscriptionID]
+	}
+
+	return deliveries, nil
+}
+
+// Attempt 執行一次投遞並記錄結果
+func (ws *WebhookService) Attempt(ctx context.Context, delivery *models.WebhookDelivery) {
+	subscription := delivery.Subscription
+	if subscription == nil || !subscription.IsActive {
+		reason := "訂閱不存在或已停用"
+		delivery.Status = WebhookDeliveryFailed
+		delivery.LastError = &reason
+		ws.db.WithContext(ctx).Model(&models.WebhookDelivery{}).Where("id = ?", delivery.ID).
+			Updates(map[string]interface{}{"status": delivery.Status, "last_error": reason})
+		return
+	}
+
+	start := time.Now()
+	statusCode, responseBody, err := ws.send(ctx, subscription, delivery)
+	duration := time.Since(start).Milliseconds()
+
+	delivery.Attempts++
+	delivery.DurationMs = &duration
+	updates := map[string]interface{}{
+		"attempts":    delivery.Attempts,
+		"duration_ms": duration,
+	}
+	if statusCode != 0 {
+		delivery.ResponseStatus = &statusCode
+		delivery.ResponseBody = &responseBody
+		updates["response_status"] = statusCode
+		updates["response_body"] = responseBody
+	}
+
+	now := time.Now()
+	if err == nil {
+		delivery.Status = WebhookDeliverySucceeded
+		delivery.DeliveredAt = &now
+		delivery.LastError = nil
+		updates["status"] = delivery.Status
+		updates["delivered_at"] = now
+		updates["last_error"] = nil
+	} else {
+		message := err.Error()
+		delivery.LastError = &message
+		updates["last_error"] = message
+		if delivery.Attempts >= ws.MaxAttempts {
+			delivery.Status = WebhookDeliveryFailed
+			updates["status"] = delivery.Status
+		} else {
+			delivery.NextAttemptAt = now.Add(ws.backoff(delivery.Attempts))
+			updates["next_attempt_at"] = delivery.NextAttemptAt
+		}
+	}
+
+	if dbErr := ws.db.WithContext(ctx).Model(&models.WebhookDelivery{}).Where("id = ?", delivery.ID).Updates(updates).Error; dbErr != nil {
+		log.Printf("Failed to update webhook delivery %s: %v", delivery.ID, dbErr)
+	}
+
+	ws.recordSubscriptionResult(ctx, subscription, err == nil)
+}
+
+// Redeliver 以原始內容建立新的投遞記錄並立即投遞
+func (ws *WebhookService) Redeliver(ctx context.Context, original *models.WebhookDelivery) (*models.WebhookDelivery, error) {
+	delivery := models.WebhookDelivery{
+		SubscriptionID: original.SubscriptionID,
+		EventID:        original.EventID,
+		EventType:      original.EventType,
+		Payload:        original.Payload,
+		Status:         WebhookDeliveryPending,
+		NextAttemptAt:  time.Now(),
+		RedeliveryOf:   &original.ID,
+	}
+
+	if err := ws.db.WithContext(ctx).Create(&delivery).Error; err != nil {
+		return nil, fmt.Errorf("failed to create webhook redelivery: %w", err)
+	}
+
+	if err := ws.db.WithContext(ctx).Where("id = ?", delivery.SubscriptionID).First(&delivery.Subscription).Error; err != nil {
+		return nil, fmt.Errorf("failed to load webhook subscription: %w", err)
+	}
+
+	ws.Attempt(ctx, &delivery)
+	delivery.Subscription = nil
+
+	return &delivery, nil
+}
+
+// send 發送 HTTP 請求，2xx 以外的回應視為失敗
+func (ws *WebhookService) send(ctx context.Context, subscription *models.WebhookSubscription, delivery *models.WebhookDelivery) (int, string, error) {
+	body := []byte(delivery.Payload)
+	timestamp := time.Now().Unix()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, "", fmt.Errorf("invalid webhook request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "TennisPlatform-Webhook/"+WebhookPayloadVersion)
+	req.Header.Set(WebhookHeaderID, delivery.ID)
+	req.Header.Set(WebhookHeaderEvent, delivery.EventType)
+	req.Header.Set(WebhookHeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(WebhookHeaderSignature, SignWebhookPayload(subscription.Secret, timestamp, body))
+
+	resp, err := ws.client.Do(req)
+	if err != nil {
+		return 0, "", fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxWebhookResponseBody))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, string(respBody), fmt.Errorf("webhook endpoint returned status %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, string(respBody), nil
+}
+
+// recordSubscriptionResult 更新訂閱的連續失敗次數，達到門檻後自動停用
+func (ws *WebhookService) recordSubscriptionResult(ctx context.Context, subscription *models.WebhookSubscription, success bool) {
+	now := time.Now()
+	updates := map[string]interface{}{
+		"last_delivery_at": now,
+	}
+
+	if success {
+		subscription.ConsecutiveFailures = 0
+	} else {
+		subscription.ConsecutiveFailures++
+	}
+	updates["consecutive_failures"] = subscription.ConsecutiveFailures
+
+	if !success && subscription.ConsecutiveFailures >= ws.DisableThreshold {
+		reason := fmt.Sprintf("連續投遞失敗 %d 次，已自動停用", subscription.ConsecutiveFailures)
+		subscription.IsActive = false
+		subscription.DisabledAt = &now
+		subscription.DisabledReason = &reason
+		updates["is_active"] = false
+		updates["disabled_at"] = now
+		updates["disabled_reason"] = reason
+	}
+
+	if err := ws.db.WithContext(ctx).Model(&models.WebhookSubscription{}).Where("id = ?", subscription.ID).Updates(updates).Error; err != nil {
+		log.Printf("Failed to update webhook subscription %s: %v", subscription.ID, err)
+	}
+}
+
+// backoff 計算重試延遲（指數退避）
+func (ws *WebhookService) backoff(attempts int) time.Duration {
+	delay := time.Duration(float64(ws.BaseBackoff) * math.Pow(2, float64(attempts-1)))
+	if delay > ws.MaxBackoff || delay <= 0 {
+		delay = ws.MaxBackoff
+	}
+	return delay
+}
+
+// subscriptionWants 檢查訂閱是否包含指定事件類型
+func subscriptionWants(subscription *models.WebhookSubscription, eventType string) bool {
+	for _, t := range subscription.EventTypes {
+		if t == "*" || strings.EqualFold(t, eventType) {
+			return true
+		}
+	}
+	return false
+}
+
+// RegisterWebhookSubscribers 註冊 Webhook 相關的事件訂閱者
+func RegisterWebhookSubscribers(bus *EventBus, db *gorm.DB, webhookService *WebhookService) {
+	for _, eventType := range WebhookEventTypes {
+		bus.Subscribe(eventType, "webhook", func(ctx context.Context, event *DomainEvent) error {
+			ownerID, err := webhookRecipient(ctx, db, event)
+			if err != nil || ownerID == nil {
+				return err
+			}
+			return webhookService.EnqueueForOwner(ctx, *ownerID, event)
+		})
+	}
+}
+
+// webhookRecipient 返回接收事件的擁有者：預訂事件為場地擁有者，活動報名事件為俱樂部擁有者
+func webhookRecipient(ctx context.Context, db *gorm.DB, event *DomainEvent) (*string, error) {
+	switch event.Type {
+	case EventRegistrationConfirmed, EventRegistrationCancelled:
+		var payload RegistrationEventPayload
+		if err := event.Decode(&payload); err != nil {
+			return nil, fmt.Errorf("failed to decode event payload: %w", err)
+		}
+
+		var club models.Club
+		if err := db.WithContext(ctx).Unscoped().Select("id", "owner_id").
+			Where("id = ?", payload.ClubID).First(&club).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("failed to load club: %w", err)
+		}
+		return club.OwnerID, nil
+
+	default:
+		var payload BookingEventPayload
+		if err := event.Decode(&payload); err != nil {
+			return nil, fmt.Errorf("failed to decode event payload: %w", err)
+		}
+
+		var court models.Court
+		if err := db.WithContext(ctx).Unscoped().Select("id", "owner_id").
+			Where("id = ?", payload.CourtID).First(&court).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("failed to load court: %w", err)
+		}
+		return court.OwnerID, nil
+	}
+}
@@ -0,0 +1,328 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"tennis-platform/backend/internal/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+const testWebhookSecret = "whsec_test"
+
+func setupWebhookTestDB() *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		panic("failed to connect database")
+	}
+
+	// 手動創建表結構
+	db.Exec(`CREATE TABLE webhook_subscriptions (
+		id TEXT PRIMARY KEY,
+		owner_id TEXT NOT NULL,
+		url TEXT NOT NULL,
+		description TEXT,
+		event_types TEXT,
+		secret TEXT NOT NULL,
+		is_active BOOLEAN DEFAULT TRUE,
+		consecutive_failures INTEGER DEFAULT 0,
+		disabled_at DATETIME,
+		disabled_reason TEXT,
+		last_delivery_at DATETIME,
+		created_at DATETIME,
+		updated_at DATETIME,
+		deleted_at DATETIME
+	)`)
+
+	db.Exec(`CREATE TABLE webhook_deliveries (
+		id TEXT PRIMARY KEY,
+		subscription_id TEXT NOT NULL,
+		event_id TEXT NOT NULL,
+		event_type TEXT NOT NULL,
+		payload TEXT,
+		status TEXT DEFAULT 'pending',
+		attempts INTEGER DEFAULT 0,
+		next_attempt_at DATETIME NOT NULL,
+		response_status INTEGER,
+		response_body TEXT,
+		last_error TEXT,
+		duration_ms INTEGER,
+		delivered_at DATETIME,
+		redelivery_of TEXT,
+		created_at DATETIME,
+		updated_at DATETIME
+	)`)
+
+	return db
+}
+
+// webhookReceiver 本地 Webhook 接收端
+type webhookReceiver struct {
+	mu       sync.Mutex
+	statuses []int // 依序返回的狀態碼，用完後返回 200
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (wr *webhookReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	wr.mu.Lock()
+	defer wr.mu.Unlock()
+
+	body, _ := io.ReadAll(r.Body)
+	wr.requests = append(wr.requests, r)
+	wr.bodies = append(wr.bodies, body)
+
+	status := http.StatusOK
+	if len(wr.statuses) > 0 {
+		status = wr.statuses[0]
+		wr.statuses = wr.statuses[1:]
+	}
+	w.WriteHeader(status)
+}
+
+func createTestSubscription(t *testing.T, db *gorm.DB, url string) *models.WebhookSubscription {
+	subscription := models.WebhookSubscription{
+		OwnerID:    "owner-1",
+		URL:        url,
+		EventTypes: models.StringArray{EventBookingCreated},
+		Secret:     testWebhookSecret,
+		IsActive:   true,
+	}
+	assert.NoError(t, db.Create(&subscription).Error)
+	return &subscription
+}
+
+func testBookingEvent() *DomainEvent {
+	payload, _ := json.Marshal(BookingEventPayload{BookingID: "booking-1", CourtID: "court-1", Status: "pending"})
+	return &DomainEvent{
+		ID:          "event-1",
+		Type:        EventBookingCreated,
+		AggregateID: "booking-1",
+		Payload:     payload,
+		OccurredAt:  time.Now(),
+	}
+}
+
+func TestWebhookService_SignedDelivery(t *testing.T) {
+	receiver := &webhookReceiver{}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	db := setupWebhookTestDB()
+	service := NewWebhookService(db)
+	service.AllowPrivateNetworks = true // 本地接收端位於回環地址
+	createTestSubscription(t, db, server.URL)
+
+	assert.NoError(t, service.EnqueueForOwner(context.Background(), "owner-1", testBookingEvent()))
+
+	n, err := service.DeliverDue(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Len(t, receiver.requests, 1)
+
+	req := receiver.requests[0]
+	body := receiver.bodies[0]
+	assert.Equal(t, EventBookingCreated, req.Header.Get(WebhookHeaderEvent))
+	assert.NoError(t, VerifyWebhookSignature(testWebhookSecret, req.Header.Get(WebhookHeaderSignature), req.Header.Get(WebhookHeaderTimestamp), body, 5*time.Minute))
+	assert.Error(t, VerifyWebhookSignature("wrong-secret", req.Header.Get(WebhookHeaderSignature), req.Header.Get(WebhookHeaderTimestamp), body, 5*time.Minute))
+
+	var payload WebhookPayload
+	assert.NoError(t, json.Unmarshal(body, &payload))
+	assert.Equal(t, "event-1", payload.ID)
+	assert.Equal(t, WebhookPayloadVersion, payload.Version)
+
+	var delivery models.WebhookDelivery
+	assert.NoError(t, db.First(&delivery).Error)
+	assert.Equal(t, WebhookDeliverySucceeded, delivery.Status)
+	assert.Equal(t, 1, delivery.Attempts)
+	assert.Equal(t, req.Header.Get(WebhookHeaderID), delivery.ID)
+}
+
+func TestWebhookService_SkipsUnsubscribedEvents(t *testing.T) {
+	db := setupWebhookTestDB()
+	service := NewWebhookService(db)
+	createTestSubscription(t, db, "http://127.0.0.1:1")
+
+	event := testBookingEvent()
+	event.Type = EventBookingCancelled
+	assert.NoError(t, service.EnqueueForOwner(context.Background(), "owner-1", event))
+
+	var count int64
+	db.Model(&models.WebhookDelivery{}).Count(&count)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestWebhookService_RetryWithBackoff(t *testing.T) {
+	receiver := &webhookReceiver{statuses: []int{http.StatusInternalServerError}}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	db := setupWebhookTestDB()
+	service := NewWebhookService(db)
+	service.AllowPrivateNetworks = true // 本地接收端位於回環地址
+	createTestSubscription(t, db, server.URL)
+	assert.NoError(t, service.EnqueueForOwner(context.Background(), "owner-1", testBookingEvent()))
+
+	_, err := service.DeliverDue(context.Background())
+	assert.NoError(t, err)
+
+	var delivery models.WebhookDelivery
+	assert.NoError(t, db.First(&delivery).Error)
+	assert.Equal(t, WebhookDeliveryPending, delivery.Status)
+	assert.Equal(t, http.StatusInternalServerError, *delivery.ResponseStatus)
+	assert.True(t, delivery.NextAttemptAt.After(time.Now().Add(service.BaseBackoff/2)))
+
+	// 未到重試時間不會投遞
+	n, _ := service.DeliverDue(context.Background())
+	assert.Equal(t, 0, n)
+
+	db.Model(&models.WebhookDelivery{}).Where("id = ?", delivery.ID).Update("next_attempt_at", time.Now().Add(-time.Second))
+	_, err = service.DeliverDue(context.Background())
+	assert.NoError(t, err)
+
+	assert.NoError(t, db.First(&delivery).Error)
+	assert.Equal(t, WebhookDeliverySucceeded, delivery.Status)
+	assert.Equal(t, 2, delivery.Attempts)
+	assert.Len(t, receiver.requests, 2)
+
+	var subscription models.WebhookSubscription
+	assert.NoError(t, db.First(&subscription).Error)
+	assert.Equal(t, 0, subscription.ConsecutiveFailures)
+}
+
+func TestWebhookService_AutoDisable(t *testing.T) {
+	receiver := &webhookReceiver{statuses: []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway}}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	db := setupWebhookTestDB()
+	service := NewWebhookService(db)
+	service.AllowPrivateNetworks = true // 本地接收端位於回環地址
+	service.DisableThreshold = 2
+	service.BaseBackoff = -time.Second // 立即可重試
+	service.MaxBackoff = 0
+	createTestSubscription(t, db, server.URL)
+	assert.NoError(t, service.EnqueueForOwner(context.Background(), "owner-1", testBookingEvent()))
+
+	service.DeliverDue(context.Background())
+	service.DeliverDue(context.Background())
+
+	var subscription models.WebhookSubscription
+	assert.NoError(t, db.First(&subscription).Error)
+	assert.False(t, subscription.IsActive)
+	assert.NotNil(t, subscription.DisabledAt)
+
+	// 停用後剩餘的投遞不再發送
+	service.DeliverDue(context.Background())
+	assert.Len(t, receiver.requests, 2)
+
+	var delivery models.WebhookDelivery
+	assert.NoError(t, db.First(&delivery).Error)
+	assert.Equal(t, WebhookDeliveryFailed, delivery.Status)
+}
+
+func TestWebhookService_Redeliver(t *testing.T) {
+	receiver := &webhookReceiver{}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	db := setupWebhookTestDB()
+	service := NewWebhookService(db)
+	service.AllowPrivateNetworks = true // 本地接收端位於回環地址
+	createTestSubscription(t, db, server.URL)
+	assert.NoError(t, service.EnqueueForOwner(context.Background(), "owner-1", testBookingEvent()))
+	service.DeliverDue(context.Background())
+
+	var original models.WebhookDelivery
+	assert.NoError(t, db.First(&original).Error)
+
+	redelivery, err := service.Redeliver(context.Background(), &original)
+	assert.NoError(t, err)
+	assert.NotEqual(t, original.ID, redelivery.ID)
+	assert.Equal(t, original.ID, *redelivery.RedeliveryOf)
+	assert.Equal(t, WebhookDeliverySucceeded, redelivery.Status)
+	assert.Len(t, receiver.requests, 2)
+	assert.Equal(t, receiver.bodies[0], receiver.bodies[1])
+}
+
+func TestWebhookService_RejectsPrivateAddresses(t *testing.T) {
+	receiver := &webhookReceiver{}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	db := setupWebhookTestDB()
+	service := NewWebhookService(db)
+	ctx := context.Background()
+
+	for _, host := range []string{"127.0.0.1", "10.1.2.3", "169.254.169.254", "::1", "100.64.0.1", "localhost"} {
+		assert.ErrorIs(t, service.CheckHost(ctx, host), ErrWebhookPrivateAddress, host)
+	}
+	assert.NoError(t, service.CheckHost(ctx, "93.184.216.34"))
+
+	// 訂閱後才指向內部網絡的地址在連線時被拒絕
+	createTestSubscription(t, db, server.URL)
+	assert.NoError(t, service.EnqueueForOwner(ctx, "owner-1", testBookingEvent()))
+	_, err := service.DeliverDue(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, receiver.requests)
+
+	var delivery models.WebhookDelivery
+	assert.NoError(t, db.First(&delivery).Error)
+	assert.Equal(t, WebhookDeliveryPending, delivery.Status)
+	assert.Contains(t, *delivery.LastError, ErrWebhookPrivateAddress.Error())
+}
+
+func TestWebhookService_ClaimLease(t *testing.T) {
+	db := setupWebhookTestDB()
+	service := NewWebhookService(db)
+	createTestSubscription(t, db, "http://127.0.0.1:1")
+	assert.NoError(t, service.EnqueueForOwner(context.Background(), "owner-1", testBookingEvent()))
+
+	// 已領取的投遞在租約到期前不會被其他實例再次領取
+	claimed, err := service.claimDue(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, claimed, 1)
+	assert.NotNil(t, claimed[0].Subscription)
+
+	claimed, err = service.claimDue(context.Background())
+	assert.NoError(t, err)
+	assert.Empty(t, claimed)
+
+	db.Model(&models.WebhookDelivery{}).Where("1 = 1").Update("next_attempt_at", time.Now().Add(-time.Second))
+	claimed, err = service.claimDue(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, claimed, 1)
+}
+
+func TestWebhookRecipient(t *testing.T) {
+	db := setupWebhookTestDB()
+	db.Exec(`CREATE TABLE courts (id TEXT PRIMARY KEY, owner_id TEXT, deleted_at DATETIME)`)
+	db.Exec(`CREATE TABLE clubs (id TEXT PRIMARY KEY, owner_id TEXT, deleted_at DATETIME)`)
+	db.Exec(`INSERT INTO courts (id, owner_id) VALUES ('court-1', 'court-owner')`)
+	db.Exec(`INSERT INTO clubs (id, owner_id) VALUES ('club-1', 'club-owner')`)
+	ctx := context.Background()
+
+	// 預訂事件投遞給場地擁有者
+	ownerID, err := webhookRecipient(ctx, db, testBookingEvent())
+	assert.NoError(t, err)
+	assert.Equal(t, "court-owner", *ownerID)
+
+	// 活動報名事件投遞給俱樂部擁有者
+	payload, _ := json.Marshal(RegistrationEventPayload{ParticipantID: "participant-1", EventID: "event-1", ClubID: "club-1", Status: "registered"})
+	ownerID, err = webhookRecipient(ctx, db, &DomainEvent{ID: "event-2", Type: EventRegistrationConfirmed, Payload: payload})
+	assert.NoError(t, err)
+	assert.Equal(t, "club-owner", *ownerID)
+
+	payload, _ = json.Marshal(RegistrationEventPayload{ClubID: "missing"})
+	ownerID, err = webhookRecipient(ctx, db, &DomainEvent{ID: "event-3", Type: EventRegistrationCancelled, Payload: payload})
+	assert.NoError(t, err)
+	assert.Nil(t, ownerID)
+}
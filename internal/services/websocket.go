@@ -1,9 +1,11 @@
 package services
 
 import (
+	"context"
 	"log"
 	"net/http"
 	"sync"
+	"tennis-platform/backend/internal/apperror"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -47,6 +49,7 @@ type Hub struct {
 	register    chan *Client
 	unregister  chan *Client
 	broadcast   chan WebSocketMessage
+	backplane   *RedisBackplane // 跨實例轉發，單機部署時為 nil
 	mu          sync.RWMutex
 }
 
@@ -91,7 +94,7 @@ func NewWebSocketService() *WebSocketService {
 func (ws *WebSocketService) HandleWebSocket(c *gin.Context) {
 	userID := c.GetString("userID")
 	if userID == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		apperror.Write(c, apperror.New(apperror.CodeUnauthorized))
 		return
 	}
 
@@ -117,44 +120,35 @@ func (ws *WebSocketService) HandleWebSocket(c *gin.Context) {
 	go client.readPump()
 }
 
+// UseBackplane 啟用跨實例的訊息轉發及在線狀態，需在 Start 之前調用
+func (ws *WebSocketService) UseBackplane(backplane *RedisBackplane) {
+	ws.hub.backplane = backplane
+}
+
+// Start 啟動跨實例轉發，阻塞直到 ctx 結束；未啟用 backplane 時直接返回
+func (ws *WebSocketService) Start(ctx context.Context) {
+	if ws.hub.backplane == nil {
+		return
+	}
+	ws.hub.backplane.run(ctx, ws.hub)
+}
+
 // BroadcastToRoom 向聊天室廣播訊息
 func (ws *WebSocketService) BroadcastToRoom(roomID string, message WebSocketMessage) {
 	message.ChatRoomID = roomID
 	message.Timestamp = time.Now()
 
-	ws.hub.mu.RLock()
-	clients, exists := ws.hub.rooms[roomID]
-	ws.hub.mu.RUnlock()
-
-	if !exists {
-		return
-	}
-
-	for _, client := range clients {
-		select {
-		case client.Send <- message:
-		default:
-			close(client.Send)
-			delete(clients, client.ID)
-		}
+	ws.hub.deliverToRoom(roomID, message)
+	if ws.hub.backplane != nil {
+		ws.hub.backplane.publish(backplaneEnvelope{RoomID: roomID, Message: message})
 	}
 }
 
 // SendToUser 向特定用戶發送訊息
 func (ws *WebSocketService) SendToUser(userID string, message WebSocketMessage) {
-	ws.hub.mu.RLock()
-	client, exists := ws.hub.userClients[userID]
-	ws.hub.mu.RUnlock()
-
-	if !exists {
-		return
-	}
-
-	select {
-	case client.Send <- message:
-	default:
-		close(client.Send)
-		delete(ws.hub.userClients, userID)
+	ws.hub.deliverToUser(userID, message)
+	if ws.hub.backplane != nil {
+		ws.hub.backplane.publish(backplaneEnvelope{UserID: userID, Message: message})
 	}
 }
 
@@ -178,6 +172,8 @@ func (ws *WebSocketService) JoinRoom(userID, roomID string) {
 	client.mu.Lock()
 	client.RoomIDs[roomID] = true
 	client.mu.Unlock()
+
+	ws.hub.presenceChanged()
 }
 
 // LeaveRoom 離開聊天室
@@ -201,10 +197,20 @@ func (ws *WebSocketService) LeaveRoom(userID, roomID string) {
 	client.mu.Lock()
 	delete(client.RoomIDs, roomID)
 	client.mu.Unlock()
+
+	ws.hub.presenceChanged()
 }
 
-// GetOnlineUsers 獲取在線用戶列表
+// GetOnlineUsers 獲取在線用戶列表，啟用 backplane 時返回所有實例的在線用戶
 func (ws *WebSocketService) GetOnlineUsers() []string {
+	if ws.hub.backplane != nil {
+		users, err := ws.hub.backplane.onlineUsers(context.Background())
+		if err == nil {
+			return users
+		}
+		log.Printf("Failed to load cluster presence, falling back to local: %v", err)
+	}
+
 	ws.hub.mu.RLock()
 	defer ws.hub.mu.RUnlock()
 
@@ -215,8 +221,16 @@ func (ws *WebSocketService) GetOnlineUsers() []string {
 	return users
 }
 
-// GetRoomUsers 獲取聊天室用戶列表
+// GetRoomUsers 獲取聊天室用戶列表，啟用 backplane 時返回所有實例的用戶
 func (ws *WebSocketService) GetRoomUsers(roomID string) []string {
+	if ws.hub.backplane != nil {
+		users, err := ws.hub.backplane.roomUsers(context.Background(), roomID)
+		if err == nil {
+			return users
+		}
+		log.Printf("Failed to load cluster room presence, falling back to local: %v", err)
+	}
+
 	ws.hub.mu.RLock()
 	defer ws.hub.mu.RUnlock()
 
@@ -232,6 +246,69 @@ func (ws *WebSocketService) GetRoomUsers(roomID string) []string {
 	return users
 }
 
+// deliverToRoom 將訊息投遞給本機聊天室內的連線
+func (h *Hub) deliverToRoom(roomID string, message WebSocketMessage) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, client := range h.rooms[roomID] {
+		select {
+		case client.Send <- message:
+		default:
+			h.dropClient(client)
+		}
+	}
+}
+
+// deliverToUser 將訊息投遞給本機的用戶連線
+func (h *Hub) deliverToUser(userID string, message WebSocketMessage) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	client, exists := h.userClients[userID]
+	if !exists {
+		return
+	}
+
+	select {
+	case client.Send <- message:
+	default:
+		h.dropClient(client)
+	}
+}
+
+// dropClient 移除發送緩衝已滿的連線，調用方需持有寫鎖
+func (h *Hub) dropClient(client *Client) {
+	if _, ok := h.clients[client.ID]; !ok {
+		return
+	}
+
+	delete(h.clients, client.ID)
+	if h.userClients[client.UserID] == client {
+		delete(h.userClients, client.UserID)
+	}
+	client.mu.RLock()
+	for roomID := range client.RoomIDs {
+		if clients, exists := h.rooms[roomID]; exists {
+			delete(clients, client.ID)
+			if len(clients) == 0 {
+				delete(h.rooms, roomID)
+			}
+		}
+	}
+	client.mu.RUnlock()
+	close(client.Send)
+
+	h.presenceChanged()
+}
+
+// presenceChanged 通知 backplane 重新同步本機在線狀態
+func (h *Hub) presenceChanged() {
+	if h.backplane != nil {
+		h.backplane.markDirty()
+	}
+}
+
 // Hub 運行方法
 func (h *Hub) run() {
 	for {
@@ -241,13 +318,17 @@ func (h *Hub) run() {
 			h.clients[client.ID] = client
 			h.userClients[client.UserID] = client
 			h.mu.Unlock()
+			h.presenceChanged()
 			log.Printf("Client connected: %s (User: %s)", client.ID, client.UserID)
 
 		case client := <-h.unregister:
 			h.mu.Lock()
 			if _, ok := h.clients[client.ID]; ok {
 				delete(h.clients, client.ID)
-				delete(h.userClients, client.UserID)
+				// 同一用戶重新連線時不移除新的連線
+				if h.userClients[client.UserID] == client {
+					delete(h.userClients, client.UserID)
+				}
 				close(client.Send)
 
 				// 從所有聊天室移除
@@ -263,6 +344,7 @@ func (h *Hub) run() {
 				client.mu.RUnlock()
 			}
 			h.mu.Unlock()
+			h.presenceChanged()
 			log.Printf("Client disconnected: %s (User: %s)", client.ID, client.UserID)
 
 		case message := <-h.broadcast:
@@ -355,6 +437,7 @@ func (c *Client) handleMessage(message WebSocketMessage) {
 			c.RoomIDs[roomID] = true
 			c.mu.Unlock()
 			c.Hub.mu.Unlock()
+			c.Hub.presenceChanged()
 		}
 
 	case "leave_room":
@@ -370,6 +453,7 @@ func (c *Client) handleMessage(message WebSocketMessage) {
 			delete(c.RoomIDs, roomID)
 			c.mu.Unlock()
 			c.Hub.mu.Unlock()
+			c.Hub.presenceChanged()
 		}
 
 	case "ping":
@@ -0,0 +1,264 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// backplaneEnvelope 跨實例轉發的訊息，RoomID 與 UserID 擇一
+type backplaneEnvelope struct {
+	Origin  string           `json:"origin"`
+	RoomID  string           `json:"roomId,omitempty"`
+	UserID  string           `json:"userId,omitempty"`
+	Message WebSocketMessage `json:"message"`
+}
+
+// RedisBackplane 透過 Redis pub/sub 在多個 API 實例間轉發 WebSocket 訊息並共享在線狀態
+//
+// 訊息先投遞給本機連線再發布到 Redis，其他實例只投遞給自己的連線並忽略自己發出的訊息，
+// 因此每個連線只會收到一次。在線狀態由每個實例以自己的 Redis 集合記錄，
+// 狀態變更及每次心跳時整份重寫並續期，實例停止心跳後其記錄在 PresenceTTL 後失效。
+type RedisBackplane struct {
+	client            *redis.Client
+	instanceID        string
+	dirty             chan struct{}
+	Channel           string        // 轉發頻道
+	KeyPrefix         string        // 在線狀態鍵前綴
+	HeartbeatInterval time.Duration // 心跳間隔
+	PresenceTTL       time.Duration // 在線狀態有效期，需大於心跳間隔
+}
+
+// NewRedisBackplane 創建新的 Redis backplane，每個實例使用隨機的實例 ID
+func NewRedisBackplane(client *redis.Client) *RedisBackplane {
+	return &RedisBackplane{
+		client:            client,
+		instanceID:        uuid.NewString(),
+		dirty:             make(chan struct{}, 1),
+		Channel:           "ws:messages",
+		KeyPrefix:         "ws:presence:",
+		HeartbeatInterval: 10 * time.Second,
+		PresenceTTL:       30 * time.Second,
+	}
+}
+
+// InstanceID 返回本實例的 ID
+func (b *RedisBackplane) InstanceID() string {
+	return b.instanceID
+}
+
+// instancesKey 記錄各實例最後心跳時間的有序集合
+func (b *RedisBackplane) instancesKey() string {
+	return b.KeyPrefix + "instances"
+}
+
+// usersKey 實例的在線用戶集合
+func (b *RedisBackplane) usersKey(instanceID string) string {
+	return fmt.Sprintf("%s%s:users", b.KeyPrefix, instanceID)
+}
+
+// roomKey 實例在聊天室內的用戶集合
+func (b *RedisBackplane) roomKey(instanceID, roomID string) string {
+	return fmt.Sprintf("%s%s:rooms:%s", b.KeyPrefix, instanceID, roomID)
+}
+
+// markDirty 通知同步協程重寫在線狀態，不阻塞調用方
+func (b *RedisBackplane) markDirty() {
+	select {
+	case b.dirty <- struct{}{}:
+	default:
+	}
+}
+
+// publish 發布訊息給其他實例
+func (b *RedisBackplane) publish(envelope backplaneEnvelope) {
+	envelope.Origin = b.instanceID
+
+	payload, err := json.Marshal(envelope)
+	if err != nil {
+		log.Printf("Failed to marshal backplane message: %v", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := b.client.Publish(ctx, b.Channel, payload).Err(); err != nil {
+		log.Printf("Failed to publish backplane message: %v", err)
+	}
+}
+
+// run 訂閱轉發頻道並維持心跳，阻塞直到 ctx 結束
+func (b *RedisBackplane) run(ctx context.Context, hub *Hub) {
+	pubsub := b.client.Subscribe(ctx, b.Channel)
+	defer pubsub.Close()
+
+	// 等待訂閱確認，避免啟動後的訊息遺失
+	if _, err := pubsub.Receive(ctx); err != nil {
+		log.Printf("Failed to subscribe backplane channel: %v", err)
+	}
+
+	go b.syncPresence(ctx, hub)
+
+	messages := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+			b.handle(hub, msg.Payload)
+		}
+	}
+}
+
+// handle 投遞其他實例轉發的訊息
+func (b *RedisBackplane) handle(hub *Hub, payload string) {
+	var envelope backplaneEnvelope
+	if err := json.Unmarshal([]byte(payload), &envelope); err != nil {
+		log.Printf("Invalid backplane message: %v", err)
+		return
+	}
+
+	// 本機連線已在發布前收到
+	if envelope.Origin == b.instanceID {
+		return
+	}
+
+	switch {
+	case envelope.RoomID != "":
+		hub.deliverToRoom(envelope.RoomID, envelope.Message)
+	case envelope.UserID != "":
+		hub.deliverToUser(envelope.UserID, envelope.Message)
+	}
+}
+
+// syncPresence 在狀態變更及心跳時同步在線狀態，結束時清除本實例的記錄
+func (b *RedisBackplane) syncPresence(ctx context.Context, hub *Hub) {
+	ticker := time.NewTicker(b.HeartbeatInterval)
+	defer ticker.Stop()
+
+	rooms := make(map[string]bool)
+	for {
+		var err error
+		if rooms, err = b.writePresence(ctx, hub, rooms); err != nil && ctx.Err() == nil {
+			log.Printf("Failed to sync websocket presence: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			b.clearPresence(rooms)
+			return
+		case <-ticker.C:
+		case <-b.dirty:
+		}
+	}
+}
+
+// writePresence 以本機連線重寫本實例的在線狀態，返回目前有用戶的聊天室
+func (b *RedisBackplane) writePresence(ctx context.Context, hub *Hub, previousRooms map[string]bool) (map[string]bool, error) {
+	hub.mu.RLock()
+	users := make([]interface{}, 0, len(hub.userClients))
+	for userID := range hub.userClients {
+		users = append(users, userID)
+	}
+	roomUsers := make(map[string][]interface{}, len(hub.rooms))
+	for roomID, clients := range hub.rooms {
+		for _, client := range clients {
+			roomUsers[roomID] = append(roomUsers[roomID], client.UserID)
+		}
+	}
+	hub.mu.RUnlock()
+
+	now := time.Now()
+	pipe := b.client.TxPipeline()
+
+	usersKey := b.usersKey(b.instanceID)
+	pipe.Del(ctx, usersKey)
+	if len(users) > 0 {
+		pipe.SAdd(ctx, usersKey, users...)
+		pipe.Expire(ctx, usersKey, b.PresenceTTL)
+	}
+
+	rooms := make(map[string]bool, len(roomUsers))
+	for roomID, members := range roomUsers {
+		key := b.roomKey(b.instanceID, roomID)
+		pipe.Del(ctx, key)
+		pipe.SAdd(ctx, key, members...)
+		pipe.Expire(ctx, key, b.PresenceTTL)
+		rooms[roomID] = true
+	}
+	for roomID := range previousRooms {
+		if !rooms[roomID] {
+			pipe.Del(ctx, b.roomKey(b.instanceID, roomID))
+		}
+	}
+
+	pipe.ZAdd(ctx, b.instancesKey(), redis.Z{Score: float64(now.Unix()), Member: b.instanceID})
+	pipe.ZRemRangeByScore(ctx, b.instancesKey(), "-inf", "("+strconv.FormatInt(now.Add(-b.PresenceTTL).Unix(), 10))
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return previousRooms, err
+	}
+	return rooms, nil
+}
+
+// clearPresence 實例停止時移除自己的在線狀態
+func (b *RedisBackplane) clearPresence(rooms map[string]bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	keys := []string{b.usersKey(b.instanceID)}
+	for roomID := range rooms {
+		keys = append(keys, b.roomKey(b.instanceID, roomID))
+	}
+
+	pipe := b.client.TxPipeline()
+	pipe.Del(ctx, keys...)
+	pipe.ZRem(ctx, b.instancesKey(), b.instanceID)
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Printf("Failed to clear websocket presence: %v", err)
+	}
+}
+
+// liveInstances 返回心跳未逾時的實例
+func (b *RedisBackplane) liveInstances(ctx context.Context) ([]string, error) {
+	minScore := strconv.FormatInt(time.Now().Add(-b.PresenceTTL).Unix(), 10)
+	return b.client.ZRangeByScore(ctx, b.instancesKey(), &redis.ZRangeBy{Min: minScore, Max: "+inf"}).Result()
+}
+
+// onlineUsers 返回所有實例的在線用戶
+func (b *RedisBackplane) onlineUsers(ctx context.Context) ([]string, error) {
+	instances, err := b.liveInstances(ctx)
+	if err != nil || len(instances) == 0 {
+		return []string{}, err
+	}
+
+	keys := make([]string, 0, len(instances))
+	for _, instanceID := range instances {
+		keys = append(keys, b.usersKey(instanceID))
+	}
+	return b.client.SUnion(ctx, keys...).Result()
+}
+
+// roomUsers 返回所有實例在聊天室內的用戶
+func (b *RedisBackplane) roomUsers(ctx context.Context, roomID string) ([]string, error) {
+	instances, err := b.liveInstances(ctx)
+	if err != nil || len(instances) == 0 {
+		return []string{}, err
+	}
+
+	keys := make([]string, 0, len(instances))
+	for _, instanceID := range instances {
+		keys = append(keys, b.roomKey(instanceID, roomID))
+	}
+	return b.client.SUnion(ctx, keys...).Result()
+}
@@ -0,0 +1,167 @@
+package services
+
+import (
+	"context"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startTestHub 啟動一個連接到共用 Redis 的 WebSocket 服務及對應的 HTTP 服務器
+func startTestHub(t *testing.T, ctx context.Context, mr *miniredis.Miniredis) (*WebSocketService, *httptest.Server) {
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	ws := NewWebSocketService()
+	backplane := NewRedisBackplane(client)
+	backplane.HeartbeatInterval = 50 * time.Millisecond
+	ws.UseBackplane(backplane)
+	go ws.Start(ctx)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/ws", func(c *gin.Context) {
+		c.Set("userID", c.Query("userId"))
+		ws.HandleWebSocket(c)
+	})
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+
+	return ws, server
+}
+
+// testConn 測試用的 WebSocket 連線，收到的訊息寫入 messages
+type testConn struct {
+	conn     *websocket.Conn
+	messages chan WebSocketMessage
+}
+
+func dialTestHub(t *testing.T, server *httptest.Server, userID string) *testConn {
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws?userId=" + userID
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	tc := &testConn{conn: conn, messages: make(chan WebSocketMessage, 16)}
+	go func() {
+		for {
+			var message WebSocketMessage
+			if err := conn.ReadJSON(&message); err != nil {
+				return
+			}
+			tc.messages <- message
+		}
+	}()
+	return tc
+}
+
+// receive 返回 wait 時間內收到的所有訊息
+func (tc *testConn) receive(wait time.Duration) []WebSocketMessage {
+	var messages []WebSocketMessage
+	timeout := time.After(wait)
+	for {
+		select {
+		case message := <-tc.messages:
+			messages = append(messages, message)
+		case <-timeout:
+			return messages
+		}
+	}
+}
+
+func TestRedisBackplane_MultiHub(t *testing.T) {
+	mr := miniredis.RunT(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	hubA, serverA := startTestHub(t, ctx, mr)
+	hubB, serverB := startTestHub(t, ctx, mr)
+
+	// 兩個實例都完成訂閱後才廣播
+	require.Eventually(t, func() bool {
+		return mr.PubSubNumSub("ws:messages")["ws:messages"] == 2
+	}, time.Second, 10*time.Millisecond)
+
+	alice := dialTestHub(t, serverA, "alice")
+	bob := dialTestHub(t, serverB, "bob")
+
+	// 在線狀態跨實例可見
+	require.Eventually(t, func() bool {
+		users := hubA.GetOnlineUsers()
+		sort.Strings(users)
+		return strings.Join(users, ",") == "alice,bob"
+	}, time.Second, 10*time.Millisecond)
+
+	hubA.JoinRoom("alice", "room-1")
+	hubB.JoinRoom("bob", "room-1")
+	require.Eventually(t, func() bool {
+		return len(hubB.GetRoomUsers("room-1")) == 2
+	}, time.Second, 10*time.Millisecond)
+
+	// 聊天室訊息送達兩個實例的成員，且每人只收到一次
+	hubA.BroadcastToRoom("room-1", WebSocketMessage{Type: "new_message", Data: "hello"})
+
+	aliceMessages := alice.receive(300 * time.Millisecond)
+	bobMessages := bob.receive(300 * time.Millisecond)
+	require.Len(t, aliceMessages, 1)
+	require.Len(t, bobMessages, 1)
+	assert.Equal(t, "room-1", bobMessages[0].ChatRoomID)
+	assert.Equal(t, "hello", bobMessages[0].Data)
+
+	// 私人訊息只送達目標用戶
+	hubA.SendToUser("bob", WebSocketMessage{Type: "notification", Data: "ping"})
+	assert.Len(t, bob.receive(300*time.Millisecond), 1)
+	assert.Empty(t, alice.receive(100*time.Millisecond))
+
+	// 斷線後在線狀態同步移除
+	bob.conn.Close()
+	require.Eventually(t, func() bool {
+		users := hubA.GetOnlineUsers()
+		return len(users) == 1 && users[0] == "alice"
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"alice"}, hubA.GetRoomUsers("room-1"))
+}
+
+func TestRedisBackplane_PresenceLifecycle(t *testing.T) {
+	mr := miniredis.RunT(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	observer := NewWebSocketService()
+	observer.UseBackplane(NewRedisBackplane(redis.NewClient(&redis.Options{Addr: mr.Addr()})))
+
+	// 正常停止的實例立即清除自己的在線狀態
+	_, server := startTestHub(t, ctx, mr)
+	dialTestHub(t, server, "alice")
+	require.Eventually(t, func() bool {
+		return len(observer.GetOnlineUsers()) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	cancel()
+	require.Eventually(t, func() bool {
+		return len(observer.GetOnlineUsers()) == 0
+	}, time.Second, 10*time.Millisecond)
+
+	// 異常終止的實例停止心跳，記錄在 PresenceTTL 後失效
+	crashed := NewWebSocketService()
+	backplane := NewRedisBackplane(redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+	client := &Client{ID: "client-1", UserID: "bob", Send: make(chan WebSocketMessage, 1), RoomIDs: map[string]bool{}}
+	crashed.hub.clients[client.ID] = client
+	crashed.hub.userClients[client.UserID] = client
+
+	_, err := backplane.writePresence(context.Background(), crashed.hub, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"bob"}, observer.GetOnlineUsers())
+
+	mr.FastForward(backplane.PresenceTTL + time.Second)
+	assert.Empty(t, observer.GetOnlineUsers())
+}
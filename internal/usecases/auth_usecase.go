@@ -2,6 +2,7 @@ package usecases
 
 import (
 	"errors"
+	"tennis-platform/backend/internal/apperror"
 	"tennis-platform/backend/internal/config"
 	"tennis-platform/backend/internal/dto"
 	"tennis-platform/backend/internal/models"
@@ -38,7 +39,7 @@ func (au *AuthUsecase) Register(req *dto.RegisterRequest) (*dto.AuthResponse, er
 	// 檢查用戶是否已存在
 	var existingUser models.User
 	if err := au.db.Where("email = ?", req.Email).First(&existingUser).Error; err == nil {
-		return nil, errors.New("用戶已存在")
+		return nil, apperror.New(apperror.CodeUserAlreadyExists)
 	}
 
 	// 加密密碼
@@ -122,17 +123,17 @@ func (au *AuthUsecase) Login(req *dto.LoginRequest) (*dto.AuthResponse, error) {
 	// 查找用戶
 	var user models.User
 	if err := au.db.Preload("Profile").Where("email = ?", req.Email).First(&user).Error; err != nil {
-		return nil, errors.New("用戶不存在或密碼錯誤")
+		return nil, apperror.New(apperror.CodeInvalidCredentials)
 	}
 
 	// 檢查用戶是否啟用
 	if !user.IsActive {
-		return nil, errors.New("帳號已被停用")
+		return nil, apperror.New(apperror.CodeAccountDisabled)
 	}
 
 	// 驗證密碼
 	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
-		return nil, errors.New("用戶不存在或密碼錯誤")
+		return nil, apperror.New(apperror.CodeInvalidCredentials)
 	}
 
 	// 更新最後登入時間
@@ -174,25 +175,25 @@ func (au *AuthUsecase) RefreshToken(req *dto.RefreshTokenRequest) (*dto.AuthResp
 	// 驗證刷新令牌
 	userID, err := au.jwtService.ValidateRefreshToken(req.RefreshToken)
 	if err != nil {
-		return nil, errors.New("無效的刷新令牌")
+		return nil, apperror.Wrap(apperror.CodeInvalidRefreshToken, err)
 	}
 
 	// 檢查刷新令牌是否存在且未被撤銷
 	var refreshToken models.RefreshToken
 	if err := au.db.Where("token = ? AND user_id = ? AND is_revoked = false AND expires_at > ?",
 		req.RefreshToken, userID, time.Now()).First(&refreshToken).Error; err != nil {
-		return nil, errors.New("刷新令牌不存在或已過期")
+		return nil, apperror.New(apperror.CodeRefreshTokenExpired)
 	}
 
 	// 查找用戶
 	var user models.User
 	if err := au.db.Preload("Profile").Where("id = ?", userID).First(&user).Error; err != nil {
-		return nil, errors.New("用戶不存在")
+		return nil, apperror.New(apperror.CodeUserNotFound)
 	}
 
 	// 檢查用戶是否啟用
 	if !user.IsActive {
-		return nil, errors.New("帳號已被停用")
+		return nil, apperror.New(apperror.CodeAccountDisabled)
 	}
 
 	// 撤銷舊的刷新令牌
@@ -284,19 +285,19 @@ func (au *AuthUsecase) GetOAuthAuthURL(provider string) (string, error) {
 func (au *AuthUsecase) OAuthLogin(req *dto.OAuthLoginRequest) (*dto.AuthResponse, error) {
 	// 驗證狀態參數
 	if !au.oauthService.ValidateState(req.State) {
-		return nil, errors.New("無效的狀態參數")
+		return nil, apperror.New(apperror.CodeOAuthInvalidState)
 	}
 
 	// 交換授權碼獲取令牌
 	token, err := au.oauthService.ExchangeCodeForToken(req.Provider, req.Code)
 	if err != nil {
-		return nil, errors.New("OAuth 令牌交換失敗")
+		return nil, apperror.Wrap(apperror.CodeOAuthExchangeFailed, err)
 	}
 
 	// 獲取用戶資訊
 	oauthUser, err := au.oauthService.GetUserInfo(req.Provider, token)
 	if err != nil {
-		return nil, errors.New("獲取 OAuth 用戶資訊失敗")
+		return nil, apperror.Wrap(apperror.CodeOAuthUserInfoFailed, err)
 	}
 
 	// 檢查是否已存在 OAuth 帳號
@@ -308,7 +309,7 @@ func (au *AuthUsecase) OAuthLogin(req *dto.OAuthLoginRequest) (*dto.AuthResponse
 		// OAuth 帳號已存在，直接登入
 		user := oauthAccount.User
 		if !user.IsActive {
-			return nil, errors.New("帳號已被停用")
+			return nil, apperror.New(apperror.CodeAccountDisabled)
 		}
 
 		// 更新最後登入時間
@@ -437,19 +438,19 @@ func (au *AuthUsecase) createUserFromOAuth(oauthUser *services.OAuthUserInfo, to
 func (au *AuthUsecase) LinkOAuthAccount(userID string, req *dto.LinkOAuthAccountRequest) error {
 	// 驗證狀態參數
 	if !au.oauthService.ValidateState(req.State) {
-		return errors.New("無效的狀態參數")
+		return apperror.New(apperror.CodeOAuthInvalidState)
 	}
 
 	// 交換授權碼獲取令牌
 	token, err := au.oauthService.ExchangeCodeForToken(req.Provider, req.Code)
 	if err != nil {
-		return errors.New("OAuth 令牌交換失敗")
+		return apperror.Wrap(apperror.CodeOAuthExchangeFailed, err)
 	}
 
 	// 獲取用戶資訊
 	oauthUser, err := au.oauthService.GetUserInfo(req.Provider, token)
 	if err != nil {
-		return errors.New("獲取 OAuth 用戶資訊失敗")
+		return apperror.Wrap(apperror.CodeOAuthUserInfoFailed, err)
 	}
 
 	// 檢查該 OAuth 帳號是否已被其他用戶關聯
@@ -457,16 +458,16 @@ func (au *AuthUsecase) LinkOAuthAccount(userID string, req *dto.LinkOAuthAccount
 	err = au.db.Where("provider = ? AND provider_id = ?", req.Provider, oauthUser.ID).First(&existingOAuth).Error
 	if err == nil {
 		if existingOAuth.UserID != userID {
-			return errors.New("該 OAuth 帳號已被其他用戶關聯")
+			return apperror.New(apperror.CodeOAuthAccountTaken)
 		}
-		return errors.New("該 OAuth 帳號已關聯到您的帳號")
+		return apperror.New(apperror.CodeOAuthAccountLinked)
 	}
 
 	// 檢查用戶是否已關聯該提供商的帳號
 	var userOAuth models.OAuthAccount
 	err = au.db.Where("user_id = ? AND provider = ?", userID, req.Provider).First(&userOAuth).Error
 	if err == nil {
-		return errors.New("您已關聯該提供商的帳號")
+		return apperror.New(apperror.CodeOAuthProviderLinked)
 	}
 
 	// 創建新的 OAuth 關聯
@@ -490,7 +491,7 @@ func (au *AuthUsecase) UnlinkOAuthAccount(userID string, req *dto.UnlinkOAuthAcc
 	// 檢查用戶是否有密碼（如果沒有密碼且只有一個 OAuth 帳號，不允許解除關聯）
 	var user models.User
 	if err := au.db.Where("id = ?", userID).First(&user).Error; err != nil {
-		return errors.New("用戶不存在")
+		return apperror.New(apperror.CodeUserNotFound)
 	}
 
 	// 檢查用戶的 OAuth 帳號數量
@@ -500,7 +501,7 @@ func (au *AuthUsecase) UnlinkOAuthAccount(userID string, req *dto.UnlinkOAuthAcc
 	// 如果用戶沒有設置密碼且只有一個 OAuth 帳號，不允許解除關聯
 	if user.PasswordHash == "" || user.PasswordHash == "oauth-user-no-password" {
 		if oauthCount <= 1 {
-			return errors.New("無法解除關聯：您需要設置密碼或關聯其他登入方式")
+			return apperror.New(apperror.CodeOAuthLastLoginMethod)
 		}
 	}
 
@@ -511,7 +512,7 @@ func (au *AuthUsecase) UnlinkOAuthAccount(userID string, req *dto.UnlinkOAuthAcc
 	}
 
 	if result.RowsAffected == 0 {
-		return errors.New("未找到要解除關聯的帳號")
+		return apperror.New(apperror.CodeOAuthAccountNotFound)
 	}
 
 	return nil
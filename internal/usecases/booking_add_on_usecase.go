@@ -0,0 +1,141 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"math"
+	"tennis-platform/backend/internal/apperror"
+	"tennis-platform/backend/internal/dto"
+	"tennis-platform/backend/internal/models"
+	"tennis-platform/backend/internal/services"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// UpdateBookingAddOns 以請求內容替換預訂租借的器材，並重新計算總價
+//
+// 只有預訂者可以修改未付款的單次預訂；器材按目前的價格計算，已套用的優惠碼按新的金額重新計算折扣。
+// 金額變更後，取消以舊金額發起但尚未完成的付款。
+func (bu *BookingUsecase) UpdateBookingAddOns(bookingID, userID string, req *dto.UpdateBookingAddOnsRequest) (*models.Booking, error) {
+	ctx := context.Background()
+
+	var booking models.Booking
+	if err := bu.db.Where("id = ? AND deleted_at IS NULL", bookingID).First(&booking).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.New(apperror.CodeBookingNotFound)
+		}
+		return nil, errors.New("獲取預訂失敗")
+	}
+	if booking.UserID != userID {
+		return nil, apperror.New(apperror.CodeBookingModifyForbidden)
+	}
+	if booking.Status != "pending" || booking.PaymentID != nil || booking.SeriesID != nil {
+		return nil, apperror.New(apperror.CodeBookingAddOnsLocked)
+	}
+
+	addOns, err := bu.equipment.Prepare(ctx, booking.CourtID, bookingAddOnItems(req.AddOns), booking.StartTime, booking.EndTime)
+	if err != nil {
+		var appErr *apperror.Error
+		if errors.As(err, &appErr) {
+			return nil, err
+		}
+		return nil, errors.New("驗證租借器材失敗")
+	}
+
+	breakdown, err := bu.courtPrice(ctx, &booking)
+	if err != nil {
+		return nil, errors.New("計算價格失敗")
+	}
+	services.ApplyAddOns(breakdown, addOns)
+
+	err = bu.withCourtLock(booking.CourtID, func(tx *gorm.DB) error {
+		var splits int64
+		if err := tx.Model(&models.BookingPaymentSplit{}).
+			Where("booking_id = ? AND status = ?", booking.ID, models.PaymentSplitOpen).
+			Count(&splits).Error; err != nil {
+			return err
+		}
+		if splits > 0 {
+			return apperror.New(apperror.CodeBookingSplitExists)
+		}
+
+		if err := bu.equipment.Replace(tx, booking.ID, addOns); err != nil {
+			return err
+		}
+		if booking.PriceBreakdown != nil && booking.PriceBreakdown.Discount != nil {
+			if err := bu.promos.Reprice(tx, models.PromoTargetBooking, booking.ID, booking.PriceBreakdown.Discount, breakdown); err != nil {
+				return err
+			}
+		}
+
+		updates := map[string]interface{}{
+			"total_price":     breakdown.Total,
+			"price_breakdown": breakdown,
+			"version":         gorm.Expr("version + 1"),
+		}
+		if bu.paymentHold > 0 && booking.PaymentDueAt == nil && breakdown.Total > 0 {
+			updates["payment_due_at"] = time.Now().Add(bu.paymentHold)
+		}
+
+		// 以版本號作為條件，避免預訂在此期間被付款、修改或取消
+		result := tx.Model(&models.Booking{}).
+			Where("id = ? AND status = ? AND payment_id IS NULL AND version = ?", booking.ID, "pending", booking.Version).
+			Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return apperror.New(apperror.CodePreconditionFailed)
+		}
+		return nil
+	})
+	if err != nil {
+		var appErr *apperror.Error
+		if errors.As(err, &appErr) {
+			return nil, err
+		}
+		return nil, errors.New("更新租借器材失敗")
+	}
+
+	bu.cancellations.ReleasePayments(ctx, services.PaymentTargetBooking, booking.ID)
+
+	if err := bu.db.Preload("Court").Preload("CourtUnit").Preload("User").Preload("AddOns").First(&booking, "id = ?", booking.ID).Error; err != nil {
+		return nil, errors.New("載入預訂數據失敗")
+	}
+	return &booking, nil
+}
+
+// courtPrice 獲取預訂的場地價格明細，不含租借器材及折扣
+//
+// 沿用預訂時計算的分段價格，沒有價格明細的舊預訂按目前的價格規則重新計算。
+func (bu *BookingUsecase) courtPrice(ctx context.Context, booking *models.Booking) (*models.PriceBreakdown, error) {
+	if booking.PriceBreakdown == nil {
+		var court models.Court
+		if err := bu.db.WithContext(ctx).Where("id = ?", booking.CourtID).First(&court).Error; err != nil {
+			return nil, err
+		}
+		return bu.pricing.Quote(ctx, &court, booking.UserID, booking.StartTime, booking.EndTime)
+	}
+
+	breakdown := &models.PriceBreakdown{
+		Currency:         booking.PriceBreakdown.Currency,
+		BasePricePerHour: booking.PriceBreakdown.BasePricePerHour,
+		Segments:         booking.PriceBreakdown.Segments,
+	}
+	total := 0.0
+	for _, segment := range breakdown.Segments {
+		total += segment.Amount
+	}
+	breakdown.Total = math.Round(total*100) / 100
+	return breakdown, nil
+}
+
+// bookingAddOnItems 轉換請求中的租借器材
+func bookingAddOnItems(requests []dto.BookingAddOnRequest) []services.AddOnItem {
+	items := make([]services.AddOnItem, len(requests))
+	for i, req := range requests {
+		items[i] = services.AddOnItem{EquipmentID: req.EquipmentID, Quantity: req.Quantity}
+	}
+	return items
+}
@@ -0,0 +1,181 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"log"
+	"tennis-platform/backend/internal/apperror"
+	"tennis-platform/backend/internal/dto"
+	"tennis-platform/backend/internal/models"
+	"tennis-platform/backend/internal/services"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DefaultNoShowGrace 預訂結束後結算出席前的默認寬限時間
+const DefaultNoShowGrace = 15 * time.Minute
+
+// 出席結算的處理參數
+const (
+	attendancePollInterval = time.Minute // 檢查已結束預訂的間隔
+	attendanceBatchSize    = 100         // 每次結算的預訂數量
+)
+
+// GetCheckInPass 獲取已確認預訂的報到碼，只有預訂者可以獲取
+func (bu *BookingUsecase) GetCheckInPass(bookingID, userID string) (*dto.CheckInPassResponse, error) {
+	if bu.checkIn == nil {
+		return nil, apperror.New(apperror.CodeCheckInDisabled)
+	}
+
+	var booking models.Booking
+	if err := bu.db.Where("id = ? AND user_id = ? AND deleted_at IS NULL", bookingID, userID).First(&booking).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.New(apperror.CodeBookingNotFound)
+		}
+		return nil, apperror.Wrap(apperror.CodeInternal, err)
+	}
+	if booking.Status != "confirmed" && booking.CheckedInAt == nil {
+		return nil, apperror.New(apperror.CodeCheckInNotAllowed)
+	}
+
+	pass := bu.checkIn.Issue(&booking)
+	return &dto.CheckInPassResponse{
+		BookingID:   booking.ID,
+		Payload:     pass.Payload,
+		NotBefore:   pass.NotBefore,
+		ExpiresAt:   pass.ExpiresAt,
+		CheckedInAt: booking.CheckedInAt,
+	}, nil
+}
+
+// CheckIn 場地人員或自助報到機掃描報到碼，記錄預訂的報到時間
+//
+// 只有場地擁有者可以報到；報到碼需為預訂目前時段簽發，且在預訂開始前的開放時間至預訂結束之間使用。
+func (bu *BookingUsecase) CheckIn(userID string, req *dto.CheckInRequest) (*models.Booking, error) {
+	if bu.checkIn == nil {
+		return nil, apperror.New(apperror.CodeCheckInDisabled)
+	}
+
+	pass, err := bu.checkIn.Parse(req.Payload)
+	if err != nil {
+		return nil, err
+	}
+
+	var booking models.Booking
+	if err := bu.db.Preload("Court").Where("id = ? AND deleted_at IS NULL", pass.BookingID).First(&booking).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.New(apperror.CodeCheckInInvalidPass)
+		}
+		return nil, apperror.Wrap(apperror.CodeInternal, err)
+	}
+	if booking.Court == nil || booking.Court.OwnerID == nil || *booking.Court.OwnerID != userID {
+		return nil, apperror.New(apperror.CodeCheckInForbidden)
+	}
+	if booking.CheckedInAt != nil {
+		return nil, apperror.New(apperror.CodeCheckInAlreadyDone)
+	}
+	if booking.Status != "confirmed" {
+		return nil, apperror.New(apperror.CodeCheckInNotAllowed)
+	}
+
+	// 預訂改期後舊的報到碼失效
+	current := bu.checkIn.Issue(&booking)
+	if current.NotBefore.Unix() != pass.NotBefore.Unix() || current.ExpiresAt.Unix() != pass.ExpiresAt.Unix() {
+		return nil, apperror.New(apperror.CodeCheckInInvalidPass)
+	}
+	now := time.Now()
+	if now.Before(current.NotBefore) {
+		return nil, apperror.New(apperror.CodeCheckInNotOpen).With("minutes", int(bu.checkIn.OpensBefore/time.Minute))
+	}
+	if now.After(current.ExpiresAt) {
+		return nil, apperror.New(apperror.CodeCheckInClosed)
+	}
+
+	// 以狀態及尚未報到為條件，同一報到碼重複掃描時只記錄第一次
+	result := bu.db.Model(&models.Booking{}).
+		Where("id = ? AND status = ? AND checked_in_at IS NULL", booking.ID, "confirmed").
+		Updates(map[string]interface{}{
+			"checked_in_at": now,
+			"checked_in_by": userID,
+			"version":       gorm.Expr("version + 1"),
+		})
+	if result.Error != nil {
+		return nil, apperror.Wrap(apperror.CodeInternal, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, apperror.New(apperror.CodeCheckInAlreadyDone)
+	}
+
+	if err := bu.db.Preload("Court").Preload("CourtUnit").Preload("User").First(&booking, "id = ?", booking.ID).Error; err != nil {
+		return nil, apperror.Wrap(apperror.CodeInternal, err)
+	}
+	return &booking, nil
+}
+
+// StartAttendance 定期結算已結束預訂的出席，直到 ctx 結束
+func (bu *BookingUsecase) StartAttendance(ctx context.Context) {
+	ticker := time.NewTicker(attendancePollInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := bu.SettleAttendance(ctx); err != nil {
+			log.Printf("Attendance settlement error: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// SettleAttendance 結算結束超過寬限時間的已確認預訂，返回本次處理數量
+//
+// 已報到或場地不需要報到的預訂轉為 completed；場地需要報到而未報到的預訂轉為 no_show。
+// 每筆預訂發布 booking.attendance_recorded 事件，由信譽服務更新出席率。
+func (bu *BookingUsecase) SettleAttendance(ctx context.Context) (int, error) {
+	var bookings []models.Booking
+	if err := bu.db.WithContext(ctx).Preload("Court").
+		Where("status = ? AND deleted_at IS NULL AND end_time <= ?", "confirmed", time.Now().Add(-bu.noShowGrace)).
+		Order("end_time ASC").
+		Limit(attendanceBatchSize).
+		Find(&bookings).Error; err != nil {
+		return 0, err
+	}
+
+	processed := 0
+	for i := range bookings {
+		booking := &bookings[i]
+		status := "completed"
+		if booking.CheckedInAt == nil && booking.Court != nil && booking.Court.CheckInRequired {
+			status = "no_show"
+		}
+
+		err := bu.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			// 以讀取時的版本號作為條件，期間被取消或修改的預訂留待下次處理
+			result := tx.Model(&models.Booking{}).
+				Where("id = ? AND status = ? AND version = ?", booking.ID, "confirmed", booking.Version).
+				Updates(map[string]interface{}{
+					"status":  status,
+					"version": gorm.Expr("version + 1"),
+				})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return nil
+			}
+			processed++
+			booking.Status = status
+			return bu.publishBookingEvent(tx, services.EventBookingAttendanceRecorded, booking, "confirmed")
+		})
+		if err != nil {
+			log.Printf("Failed to settle attendance for booking %s: %v", booking.ID, err)
+		}
+	}
+	bu.notifyEventBus()
+
+	return processed, nil
+}
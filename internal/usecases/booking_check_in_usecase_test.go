@@ -0,0 +1,147 @@
+package usecases
+
+import (
+	"context"
+	"strings"
+	"tennis-platform/backend/internal/apperror"
+	"tennis-platform/backend/internal/dto"
+	"tennis-platform/backend/internal/models"
+	"tennis-platform/backend/internal/services"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBookingUsecase_CheckIn(t *testing.T) {
+	db := setupBookingTestDB(t)
+	bookings := NewBookingUsecase(db, nil)
+	_, err := bookings.GetCheckInPass("c1000000-0000-0000-0000-000000000001", bookingTestUserID)
+	assert.True(t, apperror.HasCode(err, apperror.CodeCheckInDisabled))
+	bookings.UseCheckIn(services.NewCheckInSigner("check-in-secret", 30*time.Minute), DefaultNoShowGrace)
+	courtID := "66666666-6666-6666-6666-666666666666"
+
+	addBooking := func(id string, start time.Time, status string) {
+		require.NoError(t, db.Create(&models.Booking{
+			ID: id, CourtID: courtID, UserID: bookingTestUserID, StartTime: start, EndTime: start.Add(time.Hour),
+			TotalPrice: 400, Status: status, Version: 1,
+		}).Error)
+	}
+	now := time.Now().UTC().Truncate(time.Second)
+	addBooking("c1000000-0000-0000-0000-000000000001", now.Add(-10*time.Minute), "confirmed")
+	addBooking("c1000000-0000-0000-0000-000000000002", now.Add(2*time.Hour), "confirmed")
+	addBooking("c1000000-0000-0000-0000-000000000003", now.Add(-10*time.Minute), "pending")
+
+	_, err = bookings.GetCheckInPass("c1000000-0000-0000-0000-000000000001", bookingTestOwnerID)
+	assert.True(t, apperror.HasCode(err, apperror.CodeBookingNotFound))
+	_, err = bookings.GetCheckInPass("c1000000-0000-0000-0000-000000000003", bookingTestUserID)
+	assert.True(t, apperror.HasCode(err, apperror.CodeCheckInNotAllowed))
+
+	pass, err := bookings.GetCheckInPass("c1000000-0000-0000-0000-000000000001", bookingTestUserID)
+	require.NoError(t, err)
+	assert.True(t, pass.NotBefore.Equal(now.Add(-40*time.Minute)))
+	assert.Nil(t, pass.CheckedInAt)
+
+	// 只有場地擁有者可以報到，且報到碼不能被竄改
+	_, err = bookings.CheckIn(bookingTestUserID, &dto.CheckInRequest{Payload: pass.Payload})
+	assert.True(t, apperror.HasCode(err, apperror.CodeCheckInForbidden))
+	tampered := strings.Replace(pass.Payload, "c1000000-0000-0000-0000-000000000001", "c1000000-0000-0000-0000-000000000003", 1)
+	_, err = bookings.CheckIn(bookingTestOwnerID, &dto.CheckInRequest{Payload: tampered})
+	assert.True(t, apperror.HasCode(err, apperror.CodeCheckInInvalidPass))
+	_, err = bookings.CheckIn(bookingTestOwnerID, &dto.CheckInRequest{Payload: "not-a-pass"})
+	assert.True(t, apperror.HasCode(err, apperror.CodeCheckInInvalidPass))
+
+	// 其他密鑰簽發的報到碼無效
+	other := services.NewCheckInSigner("other-secret", 30*time.Minute)
+	var booking models.Booking
+	require.NoError(t, db.First(&booking, "id = ?", "c1000000-0000-0000-0000-000000000001").Error)
+	_, err = bookings.CheckIn(bookingTestOwnerID, &dto.CheckInRequest{Payload: other.Issue(&booking).Payload})
+	assert.True(t, apperror.HasCode(err, apperror.CodeCheckInInvalidPass))
+
+	checkedIn, err := bookings.CheckIn(bookingTestOwnerID, &dto.CheckInRequest{Payload: pass.Payload})
+	require.NoError(t, err)
+	require.NotNil(t, checkedIn.CheckedInAt)
+	require.NotNil(t, checkedIn.CheckedInBy)
+	assert.Equal(t, bookingTestOwnerID, *checkedIn.CheckedInBy)
+	assert.Equal(t, int64(2), checkedIn.Version)
+	_, err = bookings.CheckIn(bookingTestOwnerID, &dto.CheckInRequest{Payload: pass.Payload})
+	assert.True(t, apperror.HasCode(err, apperror.CodeCheckInAlreadyDone))
+
+	pass, err = bookings.GetCheckInPass("c1000000-0000-0000-0000-000000000001", bookingTestUserID)
+	require.NoError(t, err)
+	assert.NotNil(t, pass.CheckedInAt)
+
+	// 開放時間前不能報到
+	later, err := bookings.GetCheckInPass("c1000000-0000-0000-0000-000000000002", bookingTestUserID)
+	require.NoError(t, err)
+	_, err = bookings.CheckIn(bookingTestOwnerID, &dto.CheckInRequest{Payload: later.Payload})
+	assert.True(t, apperror.HasCode(err, apperror.CodeCheckInNotOpen))
+
+	// 預訂改期後舊的報到碼失效
+	require.NoError(t, db.Model(&models.Booking{}).Where("id = ?", "c1000000-0000-0000-0000-000000000002").
+		Updates(map[string]interface{}{"start_time": now.Add(-5 * time.Minute), "end_time": now.Add(55 * time.Minute)}).Error)
+	_, err = bookings.CheckIn(bookingTestOwnerID, &dto.CheckInRequest{Payload: later.Payload})
+	assert.True(t, apperror.HasCode(err, apperror.CodeCheckInInvalidPass))
+
+	// 預訂結束後不能報到
+	require.NoError(t, db.Model(&models.Booking{}).Where("id = ?", "c1000000-0000-0000-0000-000000000002").
+		Updates(map[string]interface{}{"start_time": now.Add(-2 * time.Hour), "end_time": now.Add(-time.Hour)}).Error)
+	var ended models.Booking
+	require.NoError(t, db.First(&ended, "id = ?", "c1000000-0000-0000-0000-000000000002").Error)
+	expired := services.NewCheckInSigner("check-in-secret", 30*time.Minute).Issue(&ended)
+	_, err = bookings.CheckIn(bookingTestOwnerID, &dto.CheckInRequest{Payload: expired.Payload})
+	assert.True(t, apperror.HasCode(err, apperror.CodeCheckInClosed))
+}
+
+func TestBookingUsecase_SettleAttendance(t *testing.T) {
+	db := setupBookingTestDB(t)
+	bookings := NewBookingUsecase(db, nil)
+	bookings.UseCheckIn(services.NewCheckInSigner("check-in-secret", 30*time.Minute), 15*time.Minute)
+	ctx := context.Background()
+	courtID := "66666666-6666-6666-6666-666666666666"
+
+	now := time.Now().UTC()
+	checkedInAt := now.Add(-2 * time.Hour)
+	addBooking := func(id string, end time.Time, status string, checkedIn *time.Time) {
+		require.NoError(t, db.Create(&models.Booking{
+			ID: id, CourtID: courtID, UserID: bookingTestUserID, StartTime: end.Add(-time.Hour), EndTime: end,
+			TotalPrice: 400, Status: status, CheckedInAt: checkedIn, Version: 1,
+		}).Error)
+	}
+	addBooking("c2000000-0000-0000-0000-000000000001", now.Add(-time.Hour), "confirmed", nil)
+	addBooking("c2000000-0000-0000-0000-000000000002", now.Add(-time.Hour), "confirmed", &checkedInAt)
+	// 寬限時間內及未確認的預訂不結算
+	addBooking("c2000000-0000-0000-0000-000000000003", now.Add(-5*time.Minute), "confirmed", nil)
+	addBooking("c2000000-0000-0000-0000-000000000004", now.Add(-time.Hour), "pending", nil)
+
+	statusOf := func(id string) string {
+		var booking models.Booking
+		require.NoError(t, db.First(&booking, "id = ?", id).Error)
+		return booking.Status
+	}
+
+	// 場地不需要報到時，結束的預訂均為完成
+	processed, err := bookings.SettleAttendance(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, processed)
+	assert.Equal(t, "completed", statusOf("c2000000-0000-0000-0000-000000000001"))
+	assert.Equal(t, "completed", statusOf("c2000000-0000-0000-0000-000000000002"))
+	assert.Equal(t, "confirmed", statusOf("c2000000-0000-0000-0000-000000000003"))
+	assert.Equal(t, "pending", statusOf("c2000000-0000-0000-0000-000000000004"))
+
+	// 場地需要報到時，未報到的預訂記為未到場
+	require.NoError(t, db.Exec(`UPDATE courts SET check_in_required = true WHERE id = ?`, courtID).Error)
+	addBooking("c2000000-0000-0000-0000-000000000005", now.Add(-time.Hour), "confirmed", nil)
+	addBooking("c2000000-0000-0000-0000-000000000006", now.Add(-time.Hour), "confirmed", &checkedInAt)
+
+	processed, err = bookings.SettleAttendance(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, processed)
+	assert.Equal(t, "no_show", statusOf("c2000000-0000-0000-0000-000000000005"))
+	assert.Equal(t, "completed", statusOf("c2000000-0000-0000-0000-000000000006"))
+
+	// 未到場的預訂不能取消
+	_, err = bookings.CancelBooking("c2000000-0000-0000-0000-000000000005", bookingTestUserID, &dto.CancelBookingRequest{})
+	assert.True(t, apperror.HasCode(err, apperror.CodeBookingAlreadyCompleted))
+}
@@ -0,0 +1,134 @@
+package usecases
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"tennis-platform/backend/internal/apperror"
+	"tennis-platform/backend/internal/db"
+	"tennis-platform/backend/internal/dto"
+	"tennis-platform/backend/internal/models"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/datatypes"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// setupBookingPostgresDB 連接 TEST_DATABASE_URL 指定的 PostgreSQL 並執行遷移，未設置時跳過測試
+// 應指向可隨意寫入的測試數據庫，測試結束時刪除建立的場地及用戶
+func setupBookingPostgresDB(t *testing.T) *gorm.DB {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set, skipping PostgreSQL test")
+	}
+
+	gdb, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	sqlDB, err := gdb.DB()
+	require.NoError(t, err)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	require.NoError(t, db.NewMigrationManager(gdb).RunMigrations())
+	return gdb
+}
+
+// TestBookingUsecase_SlotConflictsOnPostgres 在 PostgreSQL 上同時預訂同一時段，驗證場地鎖及排除約束
+func TestBookingUsecase_SlotConflictsOnPostgres(t *testing.T) {
+	gdb := setupBookingPostgresDB(t)
+	bookings := NewBookingUsecase(gdb, nil)
+
+	ownerID := uuid.NewString()
+	hours := datatypes.JSON(`{"Monday":"00:00-23:59","Tuesday":"00:00-23:59","Wednesday":"00:00-23:59","Thursday":"00:00-23:59","Friday":"00:00-23:59","Saturday":"00:00-23:59","Sunday":"00:00-23:59"}`)
+	court := &models.Court{
+		ID:             uuid.NewString(),
+		Name:           "並發測試場地",
+		Address:        "台北市",
+		PricePerHour:   400,
+		Currency:       "TWD",
+		OperatingHours: hours,
+		IsActive:       true,
+		OwnerID:        &ownerID,
+	}
+	require.NoError(t, gdb.Create(court).Error)
+
+	const n = 10
+	userIDs := make([]string, n)
+	for i := range userIDs {
+		user := &models.User{ID: uuid.NewString(), PasswordHash: "x"}
+		user.Email = fmt.Sprintf("%s@example.com", user.ID)
+		require.NoError(t, gdb.Create(user).Error)
+		userIDs[i] = user.ID
+	}
+	t.Cleanup(func() {
+		gdb.Unscoped().Where("court_id = ?", court.ID).Delete(&models.Booking{})
+		gdb.Where("court_id = ?", court.ID).Delete(&models.SlotHold{})
+		gdb.Unscoped().Delete(court)
+		gdb.Unscoped().Where("id IN ?", userIDs).Delete(&models.User{})
+	})
+
+	// 多個連線同時預訂同一時段，只有一筆成功
+	start := bookingTestStart()
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var created []*models.Booking
+	taken := 0
+	for _, userID := range userIDs {
+		wg.Add(1)
+		go func(userID string) {
+			defer wg.Done()
+			booking, err := bookings.CreateBooking(userID, &dto.CreateBookingRequest{
+				CourtID:   court.ID,
+				StartTime: start,
+				EndTime:   start.Add(time.Hour),
+			})
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				assert.True(t, apperror.HasCode(err, apperror.CodeBookingSlotTaken), "unexpected error: %v", err)
+				taken++
+				return
+			}
+			created = append(created, booking)
+		}(userID)
+	}
+	wg.Wait()
+	require.Len(t, created, 1)
+	assert.Equal(t, n-1, taken)
+
+	// 繞過應用層檢查直接寫入重疊的預訂，由排除約束拒絕
+	overlapping := func(userID string) *models.Booking {
+		return &models.Booking{
+			CourtID:    court.ID,
+			UserID:     userID,
+			StartTime:  start.Add(30 * time.Minute),
+			EndTime:    start.Add(90 * time.Minute),
+			TotalPrice: 400,
+			Status:     "confirmed",
+		}
+	}
+	err := gdb.Create(overlapping(userIDs[1])).Error
+	require.Error(t, err)
+	assert.True(t, isOverlapViolation(err), "expected 23P01, got: %v", err)
+
+	// 在場地鎖的事務中違反排除約束時返回時段已被預訂
+	err = bookings.withCourtLock(court.ID, func(tx *gorm.DB) error {
+		return tx.Create(overlapping(userIDs[2])).Error
+	})
+	assert.True(t, apperror.HasCode(err, apperror.CodeBookingSlotTaken), "unexpected error: %v", err)
+
+	// 已取消的預訂不受排除約束限制
+	cancelled := overlapping(userIDs[3])
+	cancelled.Status = "cancelled"
+	assert.NoError(t, gdb.Create(cancelled).Error)
+
+	var count int64
+	require.NoError(t, gdb.Model(&models.Booking{}).Where("court_id = ? AND status IN ?", court.ID, []string{"pending", "confirmed"}).Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+}
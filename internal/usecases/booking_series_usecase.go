@@ -0,0 +1,608 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"tennis-platform/backend/internal/apperror"
+	"tennis-platform/backend/internal/dto"
+	"tennis-platform/backend/internal/ical"
+	"tennis-platform/backend/internal/models"
+	"tennis-platform/backend/internal/services"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 重複預訂的限制
+const (
+	maxSeriesOccurrences = 52  // 一組最多的次數，約一年的每週預訂
+	maxSeriesDaysAhead   = 180 // 最後一次最晚可在幾天後開始
+)
+
+// seriesOccurrencePaymentLead 每次分別付款時，付款期限最晚延至開始前 24 小時
+const seriesOccurrencePaymentLead = 24 * time.Hour
+
+// 修改或取消重複預訂的範圍
+const (
+	seriesScopeThis      = "this"      // 只有所選的一次
+	seriesScopeFollowing = "following" // 所選的一次及之後
+	seriesScopeAll       = "all"       // 所有尚未開始的預訂
+)
+
+// seriesOccurrence 重複預訂中的一次
+type seriesOccurrence struct {
+	booking   *models.Booking // 修改或取消的現有預訂，創建時為空
+	start     time.Time
+	end       time.Time
+	breakdown *models.PriceBreakdown
+	err       error // 這一次無法預訂或修改的原因
+}
+
+// CreateBookingSeries 創建重複預訂，依重複規則一次預訂所有日期
+//
+// 所有日期在同一事務中檢查衝突；有日期無法預訂時返回各日期的失敗原因且不預訂任何日期，
+// skipConflicts 為 true 時略過這些日期只預訂其餘日期。
+func (bu *BookingUsecase) CreateBookingSeries(userID string, req *dto.CreateBookingSeriesRequest) (*dto.BookingSeriesResponse, error) {
+	if err := validateBookingDuration(req.StartTime, req.EndTime); err != nil {
+		return nil, err
+	}
+	starts, err := seriesStarts(req.RRule, req.StartTime, req.Until, req.Count)
+	if err != nil {
+		return nil, err
+	}
+
+	var court models.Court
+	if err := bu.db.Where("id = ? AND deleted_at IS NULL AND is_active = true", req.CourtID).First(&court).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.New(apperror.CodeCourtUnavailable)
+		}
+		return nil, errors.New("查詢場地失敗")
+	}
+
+	// 與單次預訂相同，在鎖定場地前檢查營業時間並計算各次價格
+	duration := req.EndTime.Sub(req.StartTime)
+	occurrences := make([]seriesOccurrence, len(starts))
+	for i, start := range starts {
+		occurrences[i] = seriesOccurrence{start: start, end: start.Add(duration)}
+		if err := bu.quoteOccurrence(&court, userID, &occurrences[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	series := models.BookingSeries{
+		CourtID:     req.CourtID,
+		CourtUnitID: req.CourtUnitID,
+		UserID:      userID,
+		RRule:       req.RRule,
+		StartTime:   starts[0],
+		EndTime:     starts[0].Add(duration),
+		Until:       req.Until,
+		Count:       req.Count,
+		BillingMode: req.BillingMode,
+		Status:      "active",
+		Notes:       req.Notes,
+	}
+	if series.BillingMode == "" {
+		series.BillingMode = models.BookingSeriesBillingPerOccurrence
+	}
+
+	var skipped []dto.SeriesOccurrenceFailure
+	err = bu.withCourtLock(req.CourtID, func(tx *gorm.DB) error {
+		if err := tx.Create(&series).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		booked, total := 0, 0.0
+		for i := range occurrences {
+			occurrence := &occurrences[i]
+			var courtUnitID *string
+			if occurrence.err == nil {
+				courtUnitID, occurrence.err = bu.assignCourtUnit(tx, userID, req.CourtID, req.CourtUnitID, occurrence.start, occurrence.end)
+			}
+			if occurrence.err != nil {
+				failure, ok := occurrenceFailure(occurrence)
+				if !ok {
+					return occurrence.err
+				}
+				skipped = append(skipped, failure)
+				continue
+			}
+
+			booking := models.Booking{
+				CourtID:        req.CourtID,
+				CourtUnitID:    courtUnitID,
+				SeriesID:       &series.ID,
+				UserID:         userID,
+				StartTime:      occurrence.start,
+				EndTime:        occurrence.end,
+				TotalPrice:     occurrence.breakdown.Total,
+				PriceBreakdown: occurrence.breakdown,
+				Status:         "pending",
+				Notes:          req.Notes,
+			}
+			if series.BillingMode == models.BookingSeriesBillingPerOccurrence && bu.paymentHold > 0 && booking.TotalPrice > 0 {
+				booking.PaymentDueAt = bu.occurrencePaymentDue(now, booking.StartTime)
+			}
+
+			if err := bu.releaseOwnHolds(tx, userID, req.CourtID, booking.StartTime, booking.EndTime); err != nil {
+				return err
+			}
+			if err := tx.Create(&booking).Error; err != nil {
+				return err
+			}
+			if err := bu.publishBookingEvent(tx, services.EventBookingCreated, &booking, ""); err != nil {
+				return err
+			}
+			booked++
+			total += booking.TotalPrice
+		}
+
+		if len(skipped) > 0 && (!req.SkipConflicts || booked == 0) {
+			return apperror.New(apperror.CodeBookingSeriesConflict).With("count", len(skipped)).WithExtension("failures", skipped)
+		}
+
+		// 整組付款的期限記錄在重複預訂上，各次預訂不單獨逾期
+		if series.BillingMode == models.BookingSeriesBillingAggregate && bu.paymentHold > 0 && total > 0 {
+			return tx.Model(&series).Update("payment_due_at", now.Add(bu.paymentHold)).Error
+		}
+		return nil
+	})
+	if err != nil {
+		var appErr *apperror.Error
+		if errors.As(err, &appErr) {
+			return nil, err
+		}
+		return nil, errors.New("創建重複預訂失敗")
+	}
+	bu.notifyEventBus()
+
+	return bu.seriesResponse(series.ID, skipped)
+}
+
+// GetBookingSeries 獲取重複預訂及各次預訂
+func (bu *BookingUsecase) GetBookingSeries(seriesID, userID string) (*dto.BookingSeriesResponse, error) {
+	series, err := bu.getOwnedSeries(seriesID, userID)
+	if err != nil {
+		return nil, err
+	}
+	return bu.seriesResponse(series.ID, nil)
+}
+
+// UpdateBookingSeries 修改重複預訂中範圍內的預訂
+//
+// 變更時間時以所選預訂的新時間計算位移，範圍內各次按相同位移調整並重新計價；
+// 所有預訂先檢查衝突，任何一次無法修改時返回各次的失敗原因且不修改任何預訂。
+func (bu *BookingUsecase) UpdateBookingSeries(seriesID, userID string, req *dto.UpdateBookingSeriesRequest) (*dto.BookingSeriesResponse, error) {
+	series, err := bu.getActiveSeries(seriesID, userID)
+	if err != nil {
+		return nil, err
+	}
+	targets, anchor, err := bu.seriesTargets(series, req.Scope, req.BookingID)
+	if err != nil {
+		return nil, err
+	}
+	if len(targets) == 0 {
+		return bu.seriesResponse(series.ID, nil)
+	}
+
+	reschedule := req.StartTime != nil || req.EndTime != nil
+	var startShift, endShift time.Duration
+	if reschedule {
+		startTime, endTime := anchor.StartTime, anchor.EndTime
+		if req.StartTime != nil {
+			startTime = *req.StartTime
+		}
+		if req.EndTime != nil {
+			endTime = *req.EndTime
+		}
+		startShift = startTime.Sub(anchor.StartTime)
+		endShift = endTime.Sub(anchor.EndTime)
+	}
+
+	var court models.Court
+	if err := bu.db.Where("id = ?", series.CourtID).First(&court).Error; err != nil {
+		return nil, errors.New("獲取場地信息失敗")
+	}
+
+	horizon := time.Now().AddDate(0, 0, maxSeriesDaysAhead)
+	occurrences := make([]seriesOccurrence, len(targets))
+	moving := make(map[string]bool, len(targets))
+	for i := range targets {
+		booking := &targets[i]
+		occurrence := &occurrences[i]
+		*occurrence = seriesOccurrence{booking: booking, start: booking.StartTime.Add(startShift), end: booking.EndTime.Add(endShift)}
+		moving[booking.ID] = true
+		if !reschedule {
+			continue
+		}
+
+		// 與單次預訂相同，只有待付款的預訂可以變更時間
+		if booking.Status != "pending" {
+			occurrence.err = apperror.New(apperror.CodeBookingNotPending)
+		} else if err := validateBookingDuration(occurrence.start, occurrence.end); err != nil {
+			occurrence.err = err
+		} else if occurrence.start.After(horizon) {
+			occurrence.err = apperror.New(apperror.CodeBookingSeriesTooFarAhead).With("days", maxSeriesDaysAhead)
+		} else if err := bu.quoteOccurrence(&court, userID, occurrence); err != nil {
+			return nil, err
+		}
+	}
+
+	err = bu.withCourtLock(series.CourtID, func(tx *gorm.DB) error {
+		if reschedule {
+			var failures []dto.SeriesOccurrenceFailure
+			for i := range occurrences {
+				occurrence := &occurrences[i]
+				if occurrence.err == nil {
+					occurrence.err = bu.checkSeriesConflict(tx, occurrence, moving, userID)
+				}
+				if occurrence.err != nil {
+					failure, ok := occurrenceFailure(occurrence)
+					if !ok {
+						return occurrence.err
+					}
+					failures = append(failures, failure)
+				}
+			}
+			if len(failures) > 0 {
+				return apperror.New(apperror.CodeBookingSeriesConflict).With("count", len(failures)).WithExtension("failures", failures)
+			}
+		}
+
+		for i := range occurrences {
+			occurrence := &occurrences[i]
+			updates := map[string]interface{}{"version": gorm.Expr("version + 1")}
+			if reschedule {
+				updates["start_time"] = occurrence.start
+				updates["end_time"] = occurrence.end
+				updates["total_price"] = occurrence.breakdown.Total
+				updates["price_breakdown"] = occurrence.breakdown
+			}
+			if req.Notes != nil {
+				updates["notes"] = *req.Notes
+			}
+
+			// 以讀取時的版本號作為條件，避免覆蓋並發的修改
+			result := tx.Model(occurrence.booking).Where("version = ?", occurrence.booking.Version).Updates(updates)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return apperror.New(apperror.CodePreconditionFailed)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		var appErr *apperror.Error
+		if errors.As(err, &appErr) {
+			return nil, err
+		}
+		return nil, errors.New("修改重複預訂失敗")
+	}
+
+	// 整組金額已改變，取消以舊金額發起且尚未完成的付款
+	if reschedule && series.BillingMode == models.BookingSeriesBillingAggregate && series.PaymentID == nil {
+		bu.cancellations.ReleasePayments(context.Background(), services.PaymentTargetBookingSeries, series.ID)
+	}
+
+	return bu.seriesResponse(series.ID, nil)
+}
+
+// CancelBookingSeries 依場地取消政策取消重複預訂中範圍內的預訂
+//
+// 已過取消期限的預訂略過並在 skipped 中說明，其餘預訂取消並退還可退金額；
+// 全部無法取消時返回錯誤。重複預訂不再有尚未開始的預訂時一併標記為取消。
+func (bu *BookingUsecase) CancelBookingSeries(seriesID, userID string, req *dto.CancelBookingSeriesRequest) (*dto.CancelBookingSeriesResponse, error) {
+	series, err := bu.getActiveSeries(seriesID, userID)
+	if err != nil {
+		return nil, err
+	}
+	targets, _, err := bu.seriesTargets(series, req.Scope, req.BookingID)
+	if err != nil {
+		return nil, err
+	}
+
+	type cancellable struct {
+		booking *models.Booking
+		target  *services.CancellationTarget
+		quote   *services.CancellationQuote
+	}
+
+	ctx := context.Background()
+	now := time.Now()
+	var allowed []cancellable
+	skipped := []dto.SeriesOccurrenceFailure{}
+	refundAmount := 0.0
+	var windowPassed error
+	for i := range targets {
+		booking := &targets[i]
+		target := bookingCancellationTarget(booking)
+		quote, err := bu.cancellations.Quote(ctx, target, services.CancellationInitiatorCustomer, now)
+		if err != nil {
+			return nil, errors.New("計算退款金額失敗")
+		}
+		if !quote.Allowed {
+			if windowPassed == nil {
+				windowPassed = apperror.New(apperror.CodeBookingCancelWindowPassed).With("hours", quote.CutoffHours)
+			}
+			failure, _ := occurrenceFailure(&seriesOccurrence{booking: booking, start: booking.StartTime, end: booking.EndTime, err: windowPassed})
+			skipped = append(skipped, failure)
+			continue
+		}
+		allowed = append(allowed, cancellable{booking: booking, target: target, quote: quote})
+		refundAmount += quote.RefundAmount
+	}
+	if len(allowed) == 0 && windowPassed != nil {
+		return nil, windowPassed
+	}
+	refundAmount = math.Round(refundAmount*100) / 100
+	if req.ExpectedRefundAmount != nil && math.Abs(*req.ExpectedRefundAmount-refundAmount) >= 0.005 {
+		return nil, apperror.New(apperror.CodeCancellationQuoteChanged).With("refundAmount", refundAmount)
+	}
+
+	cancellations := make([]models.Cancellation, 0, len(allowed))
+	err = bu.db.Transaction(func(tx *gorm.DB) error {
+		for _, item := range allowed {
+			cancellation, err := bu.cancelBooking(tx, item.booking, item.target, item.quote, userID, req.Reason, nil)
+			if err != nil {
+				return err
+			}
+			cancellations = append(cancellations, *cancellation)
+		}
+
+		var remaining int64
+		if err := tx.Model(&models.Booking{}).
+			Where("series_id = ? AND status IN ? AND start_time > ?", series.ID, []string{"pending", "confirmed"}, now).
+			Count(&remaining).Error; err != nil {
+			return err
+		}
+		if remaining > 0 {
+			return nil
+		}
+		return tx.Model(series).Updates(map[string]interface{}{
+			"status":  "cancelled",
+			"version": gorm.Expr("version + 1"),
+		}).Error
+	})
+	if err != nil {
+		var appErr *apperror.Error
+		if errors.As(err, &appErr) {
+			return nil, err
+		}
+		return nil, errors.New("取消重複預訂失敗")
+	}
+	bu.notifyEventBus()
+
+	for i, item := range allowed {
+		bu.settleCancellation(ctx, item.booking, &cancellations[i])
+		bu.offerWaitlist(item.booking.CourtID, item.booking.StartTime, item.booking.EndTime)
+	}
+	// 整組金額已改變，取消以舊金額發起且尚未完成的付款
+	if series.BillingMode == models.BookingSeriesBillingAggregate && series.PaymentID == nil {
+		bu.cancellations.ReleasePayments(ctx, services.PaymentTargetBookingSeries, series.ID)
+	}
+
+	return &dto.CancelBookingSeriesResponse{
+		Cancellations: cancellations,
+		Skipped:       skipped,
+	}, nil
+}
+
+// seriesStarts 依重複規則及結束條件展開各次的開始時間
+//
+// 結束條件以 until 或 count 提供且兩者擇一，規則本身不能包含 COUNT 或 UNTIL；
+// 最多 maxSeriesOccurrences 次，最後一次不能超過 maxSeriesDaysAhead 天後。
+func seriesStarts(rule string, start time.Time, until *time.Time, count *int) ([]time.Time, error) {
+	if (until == nil) == (count == nil) {
+		return nil, apperror.New(apperror.CodeBookingSeriesEndRequired)
+	}
+	for _, part := range strings.Split(rule, ";") {
+		key, _, _ := strings.Cut(part, "=")
+		switch strings.ToUpper(strings.TrimSpace(key)) {
+		case "COUNT", "UNTIL":
+			return nil, apperror.New(apperror.CodeBookingSeriesInvalidRule)
+		}
+	}
+	if count != nil && *count > maxSeriesOccurrences {
+		return nil, apperror.New(apperror.CodeBookingSeriesTooMany).With("max", maxSeriesOccurrences)
+	}
+
+	horizon := time.Now().AddDate(0, 0, maxSeriesDaysAhead)
+	if until != nil {
+		if until.After(horizon) {
+			return nil, apperror.New(apperror.CodeBookingSeriesTooFarAhead).With("days", maxSeriesDaysAhead)
+		}
+		rule += ";UNTIL=" + until.UTC().Format("20060102T150405Z")
+	}
+
+	// 多展開一次以判斷是否超過次數上限
+	starts, err := ical.Occurrences(rule, start, horizon, maxSeriesOccurrences+1)
+	if err != nil {
+		return nil, apperror.Wrap(apperror.CodeBookingSeriesInvalidRule, err)
+	}
+	if len(starts) == 0 {
+		return nil, apperror.New(apperror.CodeBookingSeriesInvalidRule)
+	}
+	if len(starts) > maxSeriesOccurrences {
+		return nil, apperror.New(apperror.CodeBookingSeriesTooMany).With("max", maxSeriesOccurrences)
+	}
+	if count != nil {
+		// 範圍內不足指定次數表示最後幾次超過可預訂範圍
+		if len(starts) < *count {
+			return nil, apperror.New(apperror.CodeBookingSeriesTooFarAhead).With("days", maxSeriesDaysAhead)
+		}
+		starts = starts[:*count]
+	}
+	return starts, nil
+}
+
+// quoteOccurrence 檢查一次的營業時間並按價格規則計價，不在營業時間內時記錄在 occurrence.err
+func (bu *BookingUsecase) quoteOccurrence(court *models.Court, userID string, occurrence *seriesOccurrence) error {
+	if err := bu.checkOperatingHours(bu.db, court, occurrence.start, occurrence.end); err != nil {
+		var appErr *apperror.Error
+		if !errors.As(err, &appErr) {
+			return err
+		}
+		occurrence.err = err
+		return nil
+	}
+
+	breakdown, err := bu.pricing.Quote(context.Background(), court, userID, occurrence.start, occurrence.end)
+	if err != nil {
+		return errors.New("計算價格失敗")
+	}
+	occurrence.breakdown = breakdown
+	return nil
+}
+
+// occurrencePaymentDue 每次分別付款的期限：開始前 24 小時，但至少保留 paymentHold 的付款時間
+func (bu *BookingUsecase) occurrencePaymentDue(now, startTime time.Time) *time.Time {
+	dueAt := now.Add(bu.paymentHold)
+	if lead := startTime.Add(-seriesOccurrencePaymentLead); lead.After(dueAt) {
+		dueAt = lead
+	}
+	return &dueAt
+}
+
+// checkSeriesConflict 檢查一次的新時段是否與其他預訂或保留衝突，同一批調整的預訂不計入
+func (bu *BookingUsecase) checkSeriesConflict(tx *gorm.DB, occurrence *seriesOccurrence, moving map[string]bool, userID string) error {
+	occupied, err := bu.occupiedSlots(tx, occurrence.booking.CourtID, userID, "", occurrence.start, occurrence.end)
+	if err != nil {
+		return errors.New("檢查時間衝突失敗")
+	}
+	for _, slot := range occupied {
+		if !moving[slot.ID] && unitsOverlap(occurrence.booking.CourtUnitID, slot.CourtUnitID) {
+			return apperror.New(apperror.CodeBookingSlotTaken)
+		}
+	}
+	return nil
+}
+
+// occurrenceFailure 將一次的錯誤轉為失敗原因，非業務錯誤時返回 false
+func occurrenceFailure(occurrence *seriesOccurrence) (dto.SeriesOccurrenceFailure, bool) {
+	var appErr *apperror.Error
+	if !errors.As(occurrence.err, &appErr) {
+		return dto.SeriesOccurrenceFailure{}, false
+	}
+	failure := dto.SeriesOccurrenceFailure{
+		StartTime: occurrence.start,
+		EndTime:   occurrence.end,
+		Code:      string(appErr.Code),
+	}
+	if occurrence.booking != nil {
+		failure.BookingID = &occurrence.booking.ID
+	}
+	return failure, true
+}
+
+// getOwnedSeries 獲取用戶的重複預訂
+func (bu *BookingUsecase) getOwnedSeries(seriesID, userID string) (*models.BookingSeries, error) {
+	var series models.BookingSeries
+	if err := bu.db.Where("id = ?", seriesID).First(&series).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.New(apperror.CodeBookingSeriesNotFound)
+		}
+		return nil, errors.New("獲取重複預訂失敗")
+	}
+	if series.UserID != userID {
+		return nil, apperror.New(apperror.CodeBookingSeriesForbidden)
+	}
+	return &series, nil
+}
+
+// getActiveSeries 獲取用戶尚未取消的重複預訂
+func (bu *BookingUsecase) getActiveSeries(seriesID, userID string) (*models.BookingSeries, error) {
+	series, err := bu.getOwnedSeries(seriesID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if series.Status == "cancelled" {
+		return nil, apperror.New(apperror.CodeBookingSeriesCancelled)
+	}
+	return series, nil
+}
+
+// seriesTargets 返回範圍內的有效預訂（按開始時間排序）及所選的預訂
+//
+// this 只包含所選的預訂；following 包含所選及之後的預訂；all 包含所有尚未開始的預訂，
+// 未指定所選預訂時以下一次為準。
+func (bu *BookingUsecase) seriesTargets(series *models.BookingSeries, scope, bookingID string) ([]models.Booking, *models.Booking, error) {
+	active := bu.db.Where("series_id = ? AND status IN ?", series.ID, []string{"pending", "confirmed"}).Order("start_time ASC")
+
+	if bookingID == "" {
+		if scope != seriesScopeAll {
+			return nil, nil, apperror.New(apperror.CodeMissingParam).With("name", "bookingId")
+		}
+		var bookings []models.Booking
+		if err := active.Where("start_time > ?", time.Now()).Find(&bookings).Error; err != nil {
+			return nil, nil, errors.New("獲取預訂失敗")
+		}
+		if len(bookings) == 0 {
+			return bookings, nil, nil
+		}
+		return bookings, &bookings[0], nil
+	}
+
+	var anchor models.Booking
+	if err := bu.db.Where("id = ? AND series_id = ?", bookingID, series.ID).First(&anchor).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, apperror.New(apperror.CodeBookingSeriesOccurrenceNotFound)
+		}
+		return nil, nil, errors.New("獲取預訂失敗")
+	}
+	switch anchor.Status {
+	case "cancelled":
+		return nil, nil, apperror.New(apperror.CodeBookingAlreadyCancelled)
+	case "completed", "no_show":
+		return nil, nil, apperror.New(apperror.CodeBookingAlreadyCompleted)
+	}
+
+	var bookings []models.Booking
+	switch scope {
+	case seriesScopeThis:
+		bookings = []models.Booking{anchor}
+	case seriesScopeFollowing:
+		if err := active.Where("start_time >= ?", anchor.StartTime).Find(&bookings).Error; err != nil {
+			return nil, nil, errors.New("獲取預訂失敗")
+		}
+	default:
+		if err := active.Where("start_time > ? OR id = ?", time.Now(), anchor.ID).Find(&bookings).Error; err != nil {
+			return nil, nil, errors.New("獲取預訂失敗")
+		}
+	}
+
+	for i := range bookings {
+		if bookings[i].ID == anchor.ID {
+			return bookings, &bookings[i], nil
+		}
+	}
+	return bookings, &anchor, nil
+}
+
+// seriesResponse 載入重複預訂及按開始時間排序的各次預訂
+func (bu *BookingUsecase) seriesResponse(seriesID string, skipped []dto.SeriesOccurrenceFailure) (*dto.BookingSeriesResponse, error) {
+	var series models.BookingSeries
+	if err := bu.db.Preload("Court").
+		Preload("Bookings", func(db *gorm.DB) *gorm.DB { return db.Order("start_time ASC") }).
+		Preload("Bookings.CourtUnit").
+		First(&series, "id = ?", seriesID).Error; err != nil {
+		return nil, errors.New("載入重複預訂數據失敗")
+	}
+
+	total := 0.0
+	for _, booking := range series.Bookings {
+		if booking.Status == "pending" || booking.Status == "confirmed" {
+			total += booking.TotalPrice
+		}
+	}
+
+	return &dto.BookingSeriesResponse{
+		BookingSeries: series,
+		TotalPrice:    math.Round(total*100) / 100,
+		Skipped:       skipped,
+	}, nil
+}
@@ -0,0 +1,256 @@
+package usecases
+
+import (
+	"context"
+	"tennis-platform/backend/internal/apperror"
+	"tennis-platform/backend/internal/dto"
+	"tennis-platform/backend/internal/models"
+	"tennis-platform/backend/internal/services"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func timePtr(v time.Time) *time.Time {
+	return &v
+}
+
+func TestBookingUsecase_CreateBookingSeries(t *testing.T) {
+	db := setupBookingTestDB(t)
+	bookings := NewBookingUsecase(db, nil)
+	courtID := "66666666-6666-6666-6666-666666666666"
+	otherUserID := "77777777-7777-7777-7777-777777777777"
+	start := bookingTestStart()
+
+	req := dto.CreateBookingSeriesRequest{
+		CourtID:   courtID,
+		StartTime: start,
+		EndTime:   start.Add(time.Hour),
+		RRule:     "FREQ=WEEKLY",
+		Count:     intPtr(4),
+	}
+
+	// 驗證重複規則及結束條件
+	invalid := req
+	invalid.Until = &start
+	_, err := bookings.CreateBookingSeries(bookingTestUserID, &invalid)
+	assert.True(t, apperror.HasCode(err, apperror.CodeBookingSeriesEndRequired))
+	invalid = req
+	invalid.RRule = "FREQ=WEEKLY;COUNT=4"
+	_, err = bookings.CreateBookingSeries(bookingTestUserID, &invalid)
+	assert.True(t, apperror.HasCode(err, apperror.CodeBookingSeriesInvalidRule))
+	invalid = req
+	invalid.RRule = "FREQ=HOURLY"
+	_, err = bookings.CreateBookingSeries(bookingTestUserID, &invalid)
+	assert.True(t, apperror.HasCode(err, apperror.CodeBookingSeriesInvalidRule))
+	invalid = req
+	invalid.RRule = "FREQ=DAILY"
+	invalid.Count = intPtr(60)
+	_, err = bookings.CreateBookingSeries(bookingTestUserID, &invalid)
+	assert.True(t, apperror.HasCode(err, apperror.CodeBookingSeriesTooMany))
+	invalid = req
+	invalid.Count = intPtr(30)
+	_, err = bookings.CreateBookingSeries(bookingTestUserID, &invalid)
+	assert.True(t, apperror.HasCode(err, apperror.CodeBookingSeriesTooFarAhead))
+
+	// 第三次已被其他用戶預訂，整組不預訂並說明失敗的日期
+	taken, err := bookings.CreateBooking(otherUserID, &dto.CreateBookingRequest{
+		CourtID:   courtID,
+		StartTime: start.AddDate(0, 0, 14),
+		EndTime:   start.AddDate(0, 0, 14).Add(time.Hour),
+	})
+	require.NoError(t, err)
+
+	_, err = bookings.CreateBookingSeries(bookingTestUserID, &req)
+	require.True(t, apperror.HasCode(err, apperror.CodeBookingSeriesConflict))
+	failures, ok := apperror.From(err).Extensions["failures"].([]dto.SeriesOccurrenceFailure)
+	require.True(t, ok)
+	require.Len(t, failures, 1)
+	assert.True(t, failures[0].StartTime.Equal(taken.StartTime))
+	assert.Equal(t, string(apperror.CodeBookingSlotTaken), failures[0].Code)
+
+	var count int64
+	require.NoError(t, db.Model(&models.Booking{}).Where("user_id = ?", bookingTestUserID).Count(&count).Error)
+	assert.Zero(t, count)
+	require.NoError(t, db.Model(&models.BookingSeries{}).Count(&count).Error)
+	assert.Zero(t, count)
+
+	// 略過衝突時只預訂其餘日期
+	req.SkipConflicts = true
+	series, err := bookings.CreateBookingSeries(bookingTestUserID, &req)
+	require.NoError(t, err)
+	assert.Equal(t, models.BookingSeriesBillingPerOccurrence, series.BillingMode)
+	require.Len(t, series.Bookings, 3)
+	require.Len(t, series.Skipped, 1)
+	assert.InDelta(t, 1200, series.TotalPrice, 0.001)
+	for i, week := range []int{0, 1, 3} {
+		booking := series.Bookings[i]
+		assert.True(t, booking.StartTime.Equal(start.AddDate(0, 0, 7*week)))
+		require.NotNil(t, booking.SeriesID)
+		assert.Equal(t, series.ID, *booking.SeriesID)
+	}
+
+	// 只有預訂者可以查看
+	_, err = bookings.GetBookingSeries(series.ID, otherUserID)
+	assert.True(t, apperror.HasCode(err, apperror.CodeBookingSeriesForbidden))
+}
+
+func TestBookingUsecase_UpdateAndCancelBookingSeries(t *testing.T) {
+	db := setupBookingTestDB(t)
+	bookings := NewBookingUsecase(db, nil)
+	courtID := "66666666-6666-6666-6666-666666666666"
+	otherUserID := "77777777-7777-7777-7777-777777777777"
+	start := bookingTestStart()
+
+	series, err := bookings.CreateBookingSeries(bookingTestUserID, &dto.CreateBookingSeriesRequest{
+		CourtID:   courtID,
+		StartTime: start,
+		EndTime:   start.Add(time.Hour),
+		RRule:     "FREQ=WEEKLY",
+		Count:     intPtr(4),
+	})
+	require.NoError(t, err)
+	require.Len(t, series.Bookings, 4)
+	ids := make([]string, 0, 4)
+	for _, booking := range series.Bookings {
+		ids = append(ids, booking.ID)
+	}
+
+	// 第二次及之後延後一小時，第一次不變
+	newStart := start.AddDate(0, 0, 7).Add(time.Hour)
+	newEnd := newStart.Add(90 * time.Minute)
+	updated, err := bookings.UpdateBookingSeries(series.ID, bookingTestUserID, &dto.UpdateBookingSeriesRequest{
+		Scope:     seriesScopeFollowing,
+		BookingID: ids[1],
+		StartTime: &newStart,
+		EndTime:   &newEnd,
+	})
+	require.NoError(t, err)
+	assert.True(t, updated.Bookings[0].StartTime.Equal(start))
+	for week := 1; week < 4; week++ {
+		booking := updated.Bookings[week]
+		assert.True(t, booking.StartTime.Equal(start.AddDate(0, 0, 7*week).Add(time.Hour)))
+		assert.Equal(t, 90*time.Minute, booking.EndTime.Sub(booking.StartTime))
+		assert.InDelta(t, 600, booking.TotalPrice, 0.001)
+	}
+
+	// 整組再延後一小時時第四次與其他預訂衝突，所有預訂都不修改
+	blocked := start.AddDate(0, 0, 21).Add(2 * time.Hour)
+	_, err = bookings.CreateBooking(otherUserID, &dto.CreateBookingRequest{CourtID: courtID, StartTime: blocked.Add(time.Hour), EndTime: blocked.Add(2 * time.Hour)})
+	require.NoError(t, err)
+	laterStart, laterEnd := start.Add(time.Hour), start.Add(2*time.Hour)
+	_, err = bookings.UpdateBookingSeries(series.ID, bookingTestUserID, &dto.UpdateBookingSeriesRequest{
+		Scope:     seriesScopeAll,
+		BookingID: ids[0],
+		StartTime: &laterStart,
+		EndTime:   &laterEnd,
+	})
+	require.True(t, apperror.HasCode(err, apperror.CodeBookingSeriesConflict))
+	failures := apperror.From(err).Extensions["failures"].([]dto.SeriesOccurrenceFailure)
+	require.Len(t, failures, 1)
+	assert.Equal(t, ids[3], *failures[0].BookingID)
+	current, err := bookings.GetBookingSeries(series.ID, bookingTestUserID)
+	require.NoError(t, err)
+	assert.True(t, current.Bookings[0].StartTime.Equal(start))
+
+	// 同一批調整的預訂不與彼此原本的時段衝突
+	nextWeekStart, nextWeekEnd := newStart.AddDate(0, 0, 7), newEnd.AddDate(0, 0, 7)
+	updated, err = bookings.UpdateBookingSeries(series.ID, bookingTestUserID, &dto.UpdateBookingSeriesRequest{
+		Scope:     seriesScopeFollowing,
+		BookingID: ids[1],
+		StartTime: &nextWeekStart,
+		EndTime:   &nextWeekEnd,
+	})
+	require.NoError(t, err)
+	assert.True(t, updated.Bookings[1].StartTime.Equal(nextWeekStart))
+	assert.True(t, updated.Bookings[3].StartTime.Equal(start.AddDate(0, 0, 28).Add(time.Hour)))
+
+	_, err = bookings.UpdateBookingSeries(series.ID, bookingTestUserID, &dto.UpdateBookingSeriesRequest{Scope: seriesScopeThis})
+	assert.True(t, apperror.HasCode(err, apperror.CodeMissingParam))
+
+	// 取消單次、之後及全部
+	result, err := bookings.CancelBookingSeries(series.ID, bookingTestUserID, &dto.CancelBookingSeriesRequest{Scope: seriesScopeThis, BookingID: ids[1]})
+	require.NoError(t, err)
+	require.Len(t, result.Cancellations, 1)
+	assert.Equal(t, ids[1], result.Cancellations[0].TargetID)
+
+	_, err = bookings.CancelBookingSeries(series.ID, bookingTestUserID, &dto.CancelBookingSeriesRequest{Scope: seriesScopeFollowing, BookingID: ids[1]})
+	assert.True(t, apperror.HasCode(err, apperror.CodeBookingAlreadyCancelled))
+
+	result, err = bookings.CancelBookingSeries(series.ID, bookingTestUserID, &dto.CancelBookingSeriesRequest{Scope: seriesScopeFollowing, BookingID: ids[2]})
+	require.NoError(t, err)
+	assert.Len(t, result.Cancellations, 2)
+
+	current, err = bookings.GetBookingSeries(series.ID, bookingTestUserID)
+	require.NoError(t, err)
+	assert.Equal(t, "active", current.Status)
+	assert.InDelta(t, 400, current.TotalPrice, 0.001)
+
+	result, err = bookings.CancelBookingSeries(series.ID, bookingTestUserID, &dto.CancelBookingSeriesRequest{Scope: seriesScopeAll})
+	require.NoError(t, err)
+	assert.Len(t, result.Cancellations, 1)
+
+	current, err = bookings.GetBookingSeries(series.ID, bookingTestUserID)
+	require.NoError(t, err)
+	assert.Equal(t, "cancelled", current.Status)
+	assert.Zero(t, current.TotalPrice)
+
+	_, err = bookings.CancelBookingSeries(series.ID, bookingTestUserID, &dto.CancelBookingSeriesRequest{Scope: seriesScopeAll})
+	assert.True(t, apperror.HasCode(err, apperror.CodeBookingSeriesCancelled))
+}
+
+func TestBookingUsecase_BookingSeriesAggregatePayment(t *testing.T) {
+	db := setupBookingTestDB(t)
+	paymentService := services.NewPaymentService(db, services.NewLocalPaymentProvider("secret"), nil, 15*time.Minute)
+	payments := NewPaymentUsecase(db, paymentService)
+	bookings := NewBookingUsecase(db, nil)
+	bookings.UsePaymentHold(paymentService.HoldDuration)
+	ctx := context.Background()
+	start := bookingTestStart()
+
+	series, err := bookings.CreateBookingSeries(bookingTestUserID, &dto.CreateBookingSeriesRequest{
+		CourtID:     "66666666-6666-6666-6666-666666666666",
+		StartTime:   start,
+		EndTime:     start.Add(time.Hour),
+		RRule:       "FREQ=WEEKLY",
+		Until:       timePtr(start.AddDate(0, 0, 14)),
+		BillingMode: models.BookingSeriesBillingAggregate,
+	})
+	require.NoError(t, err)
+	require.Len(t, series.Bookings, 3)
+	require.NotNil(t, series.PaymentDueAt)
+	for _, booking := range series.Bookings {
+		assert.Nil(t, booking.PaymentDueAt)
+	}
+
+	// 各次預訂不能單獨付款，整組付款金額為各次總和
+	_, err = payments.CreatePayment(ctx, bookingTestUserID, &dto.CreatePaymentRequest{TargetType: services.PaymentTargetBooking, TargetID: series.Bookings[0].ID})
+	assert.True(t, apperror.HasCode(err, apperror.CodePaymentNotRequired))
+	payment, err := payments.CreatePayment(ctx, bookingTestUserID, &dto.CreatePaymentRequest{TargetType: services.PaymentTargetBookingSeries, TargetID: series.ID})
+	require.NoError(t, err)
+	assert.InDelta(t, 1200, payment.Amount, 0.001)
+
+	_, err = payments.CapturePayment(ctx, bookingTestUserID, payment.ID)
+	require.NoError(t, err)
+	paid, err := bookings.GetBookingSeries(series.ID, bookingTestUserID)
+	require.NoError(t, err)
+	require.NotNil(t, paid.PaymentID)
+	for _, booking := range paid.Bookings {
+		assert.Equal(t, "confirmed", booking.Status)
+		require.NotNil(t, booking.PaymentID)
+		assert.Equal(t, payment.ID, *booking.PaymentID)
+	}
+
+	// 取消其中一次只退還該次的金額
+	result, err := bookings.CancelBookingSeries(series.ID, bookingTestUserID, &dto.CancelBookingSeriesRequest{Scope: seriesScopeThis, BookingID: paid.Bookings[1].ID})
+	require.NoError(t, err)
+	require.Len(t, result.Cancellations, 1)
+	assert.InDelta(t, 400, result.Cancellations[0].PaidAmount, 0.001)
+	assert.InDelta(t, 400, result.Cancellations[0].RefundAmount, 0.001)
+}
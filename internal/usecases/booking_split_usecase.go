@@ -0,0 +1,484 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math"
+	"tennis-platform/backend/internal/apperror"
+	"tennis-platform/backend/internal/dto"
+	"tennis-platform/backend/internal/models"
+	"tennis-platform/backend/internal/services"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// defaultSplitDuration 未指定期限時分攤付款的默認期限
+const defaultSplitDuration = 24 * time.Hour
+
+// CreateBookingSplit 邀請其他用戶或比賽參與者分攤尚未付款的預訂
+//
+// 平均分攤時每人金額取至分，差額由預訂者負擔。建立後預訂改由各分攤付款，
+// 所有分攤付清後預訂轉為 confirmed，期限前未付清時由付款服務依 fallback 處理。
+func (bu *BookingUsecase) CreateBookingSplit(bookingID, userID string, req *dto.CreateBookingSplitRequest) (*dto.BookingSplitResponse, error) {
+	var booking models.Booking
+	if err := bu.db.Preload("Court").Where("id = ? AND deleted_at IS NULL", bookingID).First(&booking).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.New(apperror.CodeBookingNotFound)
+		}
+		return nil, errors.New("獲取預訂失敗")
+	}
+	if booking.UserID != userID {
+		return nil, apperror.New(apperror.CodeBookingSplitForbidden)
+	}
+	if booking.Status != "pending" || booking.PaymentID != nil || booking.SeriesID != nil || booking.TotalPrice <= 0 {
+		return nil, apperror.New(apperror.CodeBookingSplitNotAllowed)
+	}
+
+	payers, err := bu.splitPayers(userID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	mode := req.Mode
+	if mode == "" {
+		mode = models.PaymentSplitModeEqual
+	}
+	amounts, err := splitAmounts(mode, booking.TotalPrice, userID, payers, req.Shares)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	dueAt := now.Add(defaultSplitDuration)
+	if req.DueAt != nil {
+		dueAt = *req.DueAt
+	} else if dueAt.After(booking.StartTime) {
+		dueAt = booking.StartTime
+	}
+	if !dueAt.After(now) || dueAt.After(booking.StartTime) {
+		return nil, apperror.New(apperror.CodeBookingSplitInvalidDeadline)
+	}
+
+	fallback := req.Fallback
+	if fallback == "" {
+		fallback = models.PaymentSplitFallbackCancel
+	}
+	currency := "TWD"
+	if booking.Court != nil && booking.Court.Currency != "" {
+		currency = booking.Court.Currency
+	}
+
+	split := models.BookingPaymentSplit{
+		BookingID: booking.ID,
+		OwnerID:   userID,
+		MatchID:   req.MatchID,
+		Mode:      mode,
+		Fallback:  fallback,
+		Status:    models.PaymentSplitOpen,
+		DueAt:     dueAt,
+		Total:     booking.TotalPrice,
+		Currency:  currency,
+	}
+	err = bu.db.Transaction(func(tx *gorm.DB) error {
+		// 以版本號作為條件，避免預訂在此期間被付款、修改或取消
+		result := tx.Model(&models.Booking{}).
+			Where("id = ? AND status = ? AND payment_id IS NULL AND version = ?", booking.ID, "pending", booking.Version).
+			Updates(map[string]interface{}{
+				"payment_due_at": dueAt,
+				"version":        gorm.Expr("version + 1"),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return apperror.New(apperror.CodePreconditionFailed)
+		}
+
+		var open int64
+		if err := tx.Model(&models.BookingPaymentSplit{}).
+			Where("booking_id = ? AND status = ?", booking.ID, models.PaymentSplitOpen).
+			Count(&open).Error; err != nil {
+			return err
+		}
+		if open > 0 {
+			return apperror.New(apperror.CodeBookingSplitExists)
+		}
+
+		if err := tx.Create(&split).Error; err != nil {
+			return err
+		}
+		for _, payer := range payers {
+			share := models.BookingPaymentShare{
+				SplitID:   split.ID,
+				BookingID: booking.ID,
+				UserID:    payer,
+				IsOwner:   payer == userID,
+				Amount:    amounts[payer],
+				Status:    models.PaymentShareStatusPending,
+			}
+			if share.Amount <= 0 {
+				continue
+			}
+			if err := tx.Create(&share).Error; err != nil {
+				return err
+			}
+			if share.IsOwner || bu.eventBus == nil {
+				continue
+			}
+			if err := bu.eventBus.Publish(tx, services.EventPaymentShareRequested, "booking_payment_share", share.ID, services.PaymentShareEventPayload{
+				ShareID:   share.ID,
+				SplitID:   split.ID,
+				BookingID: booking.ID,
+				UserID:    share.UserID,
+				Amount:    share.Amount,
+				DueAt:     dueAt,
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		var appErr *apperror.Error
+		if errors.As(err, &appErr) {
+			return nil, err
+		}
+		return nil, errors.New("建立分攤付款失敗")
+	}
+	bu.notifyEventBus()
+
+	// 預訂改由各分攤付款，取消預訂者已發起但尚未完成的付款
+	bu.cancellations.ReleasePayments(context.Background(), services.PaymentTargetBooking, booking.ID)
+
+	return bu.splitResponse(split.ID)
+}
+
+// splitPayers 彙整分攤的付款人，預訂者排在第一位
+func (bu *BookingUsecase) splitPayers(ownerID string, req *dto.CreateBookingSplitRequest) ([]string, error) {
+	payers := []string{ownerID}
+	seen := map[string]bool{ownerID: true}
+
+	for _, id := range req.UserIDs {
+		if seen[id] {
+			return nil, apperror.New(apperror.CodeBookingSplitInvalidPayers)
+		}
+		seen[id] = true
+		payers = append(payers, id)
+	}
+
+	if req.MatchID != nil {
+		var participants []models.MatchParticipant
+		if err := bu.db.Where("match_id = ? AND status = ?", *req.MatchID, "accepted").Find(&participants).Error; err != nil {
+			return nil, errors.New("獲取比賽參與者失敗")
+		}
+		isParticipant := false
+		for _, participant := range participants {
+			if participant.UserID == ownerID {
+				isParticipant = true
+			}
+		}
+		if !isParticipant {
+			return nil, apperror.New(apperror.CodeBookingSplitInvalidMatch)
+		}
+		for _, participant := range participants {
+			if !seen[participant.UserID] {
+				seen[participant.UserID] = true
+				payers = append(payers, participant.UserID)
+			}
+		}
+	}
+
+	// 自訂金額時指定的付款人也加入分攤
+	if req.Mode == models.PaymentSplitModeCustom {
+		for _, share := range req.Shares {
+			if !seen[share.UserID] {
+				seen[share.UserID] = true
+				payers = append(payers, share.UserID)
+			}
+		}
+	}
+
+	if len(payers) < 2 {
+		return nil, apperror.New(apperror.CodeBookingSplitInvalidPayers)
+	}
+	var count int64
+	if err := bu.db.Model(&models.User{}).Where("id IN ?", payers[1:]).Count(&count).Error; err != nil {
+		return nil, errors.New("獲取用戶失敗")
+	}
+	if int(count) != len(payers)-1 {
+		return nil, apperror.New(apperror.CodeBookingSplitInvalidPayers)
+	}
+	return payers, nil
+}
+
+// splitAmounts 計算每位付款人的分攤金額
+func splitAmounts(mode string, total float64, ownerID string, payers []string, shares []dto.BookingShareAmount) (map[string]float64, error) {
+	amounts := make(map[string]float64, len(payers))
+
+	if mode == models.PaymentSplitModeEqual {
+		each := math.Floor(total/float64(len(payers))*100) / 100
+		for _, payer := range payers[1:] {
+			amounts[payer] = each
+		}
+		amounts[ownerID] = math.Round((total-each*float64(len(payers)-1))*100) / 100
+		return amounts, nil
+	}
+
+	sum := 0.0
+	for _, share := range shares {
+		if _, ok := amounts[share.UserID]; ok {
+			return nil, apperror.New(apperror.CodeBookingSplitInvalidPayers)
+		}
+		amounts[share.UserID] = math.Round(share.Amount*100) / 100
+		sum += amounts[share.UserID]
+	}
+	// 每位受邀的付款人都需指定金額，預訂者可不分攤
+	for _, payer := range payers[1:] {
+		if _, ok := amounts[payer]; !ok {
+			return nil, apperror.New(apperror.CodeBookingSplitInvalidShares).With("total", total)
+		}
+	}
+	if math.Abs(sum-total) >= 0.005 {
+		return nil, apperror.New(apperror.CodeBookingSplitInvalidShares).With("total", total)
+	}
+	return amounts, nil
+}
+
+// GetBookingSplit 獲取預訂最近一次的分攤付款，預訂者及分攤的付款人可查看
+func (bu *BookingUsecase) GetBookingSplit(bookingID, userID string) (*dto.BookingSplitResponse, error) {
+	var split models.BookingPaymentSplit
+	if err := bu.db.Where("booking_id = ?", bookingID).Order("created_at DESC").First(&split).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.New(apperror.CodeBookingSplitNotFound)
+		}
+		return nil, errors.New("獲取分攤付款失敗")
+	}
+
+	if split.OwnerID != userID {
+		var count int64
+		if err := bu.db.Model(&models.BookingPaymentShare{}).
+			Where("split_id = ? AND user_id = ?", split.ID, userID).
+			Count(&count).Error; err != nil {
+			return nil, errors.New("獲取分攤付款失敗")
+		}
+		if count == 0 {
+			return nil, apperror.New(apperror.CodeBookingSplitNotFound)
+		}
+	}
+
+	return bu.splitResponse(split.ID)
+}
+
+// CancelBookingSplit 取消進行中的分攤付款，預訂恢復由預訂者一次付款
+//
+// 已有分攤完成付款時不可取消，以免已付款的付款人需要另行退款。
+func (bu *BookingUsecase) CancelBookingSplit(bookingID, userID string) error {
+	var split models.BookingPaymentSplit
+	if err := bu.db.Where("booking_id = ? AND status = ?", bookingID, models.PaymentSplitOpen).First(&split).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apperror.New(apperror.CodeBookingSplitNotFound)
+		}
+		return errors.New("獲取分攤付款失敗")
+	}
+	if split.OwnerID != userID {
+		return apperror.New(apperror.CodeBookingSplitForbidden)
+	}
+
+	var shares []models.BookingPaymentShare
+	err := bu.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.BookingPaymentSplit{}).
+			Where("id = ? AND status = ?", split.ID, models.PaymentSplitOpen).
+			Update("status", models.PaymentSplitCancelled)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return apperror.New(apperror.CodeBookingSplitNotFound)
+		}
+
+		var paid int64
+		if err := tx.Model(&models.BookingPaymentShare{}).
+			Where("split_id = ? AND status = ?", split.ID, models.PaymentShareStatusPaid).
+			Count(&paid).Error; err != nil {
+			return err
+		}
+		if paid > 0 {
+			return apperror.New(apperror.CodeBookingSplitHasPayments)
+		}
+
+		if err := tx.Where("split_id = ? AND status = ?", split.ID, models.PaymentShareStatusPending).Find(&shares).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.BookingPaymentShare{}).
+			Where("split_id = ? AND status = ?", split.ID, models.PaymentShareStatusPending).
+			Update("status", models.PaymentShareStatusCancelled).Error; err != nil {
+			return err
+		}
+
+		// 恢復一般預訂的付款期限
+		var dueAt *time.Time
+		if bu.paymentHold > 0 {
+			due := time.Now().Add(bu.paymentHold)
+			dueAt = &due
+		}
+		return tx.Model(&models.Booking{}).Where("id = ?", bookingID).Update("payment_due_at", dueAt).Error
+	})
+	if err != nil {
+		var appErr *apperror.Error
+		if errors.As(err, &appErr) {
+			return err
+		}
+		return errors.New("取消分攤付款失敗")
+	}
+
+	ctx := context.Background()
+	for _, share := range shares {
+		bu.cancellations.ReleasePayments(ctx, services.PaymentTargetBookingShare, share.ID)
+	}
+	return nil
+}
+
+// GetPaymentShares 獲取用戶需支付的分攤，默認只列出待付款的分攤
+func (bu *BookingUsecase) GetPaymentShares(userID string, req *dto.PaymentShareListRequest) ([]models.BookingPaymentShare, error) {
+	status := models.PaymentShareStatusPending
+	if req.Status != nil {
+		status = *req.Status
+	}
+
+	var shares []models.BookingPaymentShare
+	if err := bu.db.Preload("Booking.Court").
+		Where("user_id = ? AND status = ?", userID, status).
+		Order("created_at DESC").
+		Find(&shares).Error; err != nil {
+		return nil, errors.New("獲取分攤失敗")
+	}
+	return shares, nil
+}
+
+// splitResponse 載入分攤付款及各分攤的付款狀態
+func (bu *BookingUsecase) splitResponse(splitID string) (*dto.BookingSplitResponse, error) {
+	var split models.BookingPaymentSplit
+	if err := bu.db.Preload("Shares", func(db *gorm.DB) *gorm.DB {
+		return db.Order("is_owner DESC, created_at ASC")
+	}).Where("id = ?", splitID).First(&split).Error; err != nil {
+		return nil, errors.New("載入分攤付款失敗")
+	}
+
+	response := &dto.BookingSplitResponse{BookingPaymentSplit: split}
+	for _, share := range split.Shares {
+		switch share.Status {
+		case models.PaymentShareStatusPaid:
+			response.PaidAmount += share.Amount
+		case models.PaymentShareStatusPending:
+			response.OutstandingAmount += share.Amount
+		}
+	}
+	response.PaidAmount = math.Round(response.PaidAmount*100) / 100
+	response.OutstandingAmount = math.Round(response.OutstandingAmount*100) / 100
+	return response, nil
+}
+
+// quoteBookingCancellation 計算取消預訂的退款報價，已付款的分攤按同一比例退款並計入報價
+func (bu *BookingUsecase) quoteBookingCancellation(ctx context.Context, booking *models.Booking, target *services.CancellationTarget, initiator string, now time.Time) (*services.CancellationQuote, error) {
+	quote, err := bu.cancellations.Quote(ctx, target, initiator, now)
+	if err != nil {
+		return nil, err
+	}
+
+	shares, err := paidBookingShares(bu.db.WithContext(ctx), booking.ID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range shares {
+		shareQuote := bookingShareQuote(quote, &shares[i])
+		quote.PaidAmount += shareQuote.PaidAmount
+		quote.RefundAmount += shareQuote.RefundAmount
+		quote.FeeAmount += shareQuote.FeeAmount
+	}
+	quote.PaidAmount = math.Round(quote.PaidAmount*100) / 100
+	quote.RefundAmount = math.Round(quote.RefundAmount*100) / 100
+	quote.FeeAmount = math.Round(quote.FeeAmount*100) / 100
+	return quote, nil
+}
+
+// cancelBookingSplit 在取消預訂的事務中結束分攤付款，已付款的分攤按報價比例各自記錄退款
+func (bu *BookingUsecase) cancelBookingSplit(tx *gorm.DB, booking *models.Booking, quote *services.CancellationQuote, userID string, reason *string) error {
+	shares, err := paidBookingShares(tx, booking.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Model(&models.BookingPaymentSplit{}).
+		Where("booking_id = ? AND status = ?", booking.ID, models.PaymentSplitOpen).
+		Update("status", models.PaymentSplitCancelled).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&models.BookingPaymentShare{}).
+		Where("booking_id = ? AND status = ?", booking.ID, models.PaymentShareStatusPending).
+		Update("status", models.PaymentShareStatusCancelled).Error; err != nil {
+		return err
+	}
+
+	for i := range shares {
+		target := &services.CancellationTarget{
+			TargetType: services.PaymentTargetBookingShare,
+			TargetID:   shares[i].ID,
+		}
+		if _, err := bu.cancellations.Record(tx, target, bookingShareQuote(quote, &shares[i]), userID, reason); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// settleBookingShares 取消預訂後取消分攤尚未扣款的付款，並發起已付款分攤的退款
+func (bu *BookingUsecase) settleBookingShares(ctx context.Context, booking *models.Booking) {
+	var shareIDs []string
+	if err := bu.db.WithContext(ctx).Model(&models.BookingPaymentShare{}).
+		Where("booking_id = ?", booking.ID).Pluck("id", &shareIDs).Error; err != nil {
+		log.Printf("Failed to load payment shares of cancelled booking %s: %v", booking.ID, err)
+		return
+	}
+	if len(shareIDs) == 0 {
+		return
+	}
+
+	for _, id := range shareIDs {
+		bu.cancellations.ReleasePayments(ctx, services.PaymentTargetBookingShare, id)
+	}
+
+	var cancellations []models.Cancellation
+	if err := bu.db.WithContext(ctx).
+		Where("target_type = ? AND target_id IN ? AND refund_status = ?", services.PaymentTargetBookingShare, shareIDs, services.RefundStatusPending).
+		Find(&cancellations).Error; err != nil {
+		log.Printf("Failed to load share refunds of cancelled booking %s: %v", booking.ID, err)
+		return
+	}
+	for i := range cancellations {
+		if err := bu.cancellations.Settle(ctx, &cancellations[i]); err != nil {
+			log.Printf("Failed to refund payment share %s: %v", cancellations[i].TargetID, err)
+		}
+	}
+}
+
+// paidBookingShares 獲取預訂進行中或已完成的分攤付款中已付款的分攤
+func paidBookingShares(db *gorm.DB, bookingID string) ([]models.BookingPaymentShare, error) {
+	var shares []models.BookingPaymentShare
+	err := db.Joins("JOIN booking_payment_splits ON booking_payment_splits.id = booking_payment_shares.split_id").
+		Where("booking_payment_shares.booking_id = ? AND booking_payment_shares.status = ? AND booking_payment_splits.status IN ?",
+			bookingID, models.PaymentShareStatusPaid, []string{models.PaymentSplitOpen, models.PaymentSplitCompleted}).
+		Find(&shares).Error
+	return shares, err
+}
+
+// bookingShareQuote 以預訂的報價計算單一分攤的退款
+func bookingShareQuote(quote *services.CancellationQuote, share *models.BookingPaymentShare) *services.CancellationQuote {
+	shareQuote := *quote
+	shareQuote.PaymentID = share.PaymentID
+	shareQuote.PaidAmount = share.Amount
+	shareQuote.RefundAmount = math.Round(share.Amount*float64(quote.RefundPercent)) / 100
+	shareQuote.FeeAmount = math.Round((shareQuote.PaidAmount-shareQuote.RefundAmount)*100) / 100
+	return &shareQuote
+}
@@ -0,0 +1,210 @@
+package usecases
+
+import (
+	"context"
+	"tennis-platform/backend/internal/apperror"
+	"tennis-platform/backend/internal/dto"
+	"tennis-platform/backend/internal/models"
+	"tennis-platform/backend/internal/services"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+const (
+	splitFriendID  = "88888888-8888-8888-8888-888888888881"
+	splitPartnerID = "88888888-8888-8888-8888-888888888882"
+	splitMatchID   = "99999999-9999-9999-9999-999999999999"
+)
+
+func setupBookingSplitTest(t *testing.T) (*gorm.DB, *BookingUsecase, *PaymentUsecase, *services.PaymentService) {
+	db := setupBookingTestDB(t)
+	require.NoError(t, db.Exec(`INSERT INTO users (id) VALUES (?), (?)`, splitFriendID, splitPartnerID).Error)
+
+	paymentService := services.NewPaymentService(db, services.NewLocalPaymentProvider("secret"), nil, 15*time.Minute)
+	bookings := NewBookingUsecase(db, nil)
+	bookings.UsePaymentHold(paymentService.HoldDuration)
+	bookings.UseCancellations(services.NewCancellationService(db, paymentService))
+	return db, bookings, NewPaymentUsecase(db, paymentService), paymentService
+}
+
+func TestBookingUsecase_SplitPayment(t *testing.T) {
+	db, bookings, payments, _ := setupBookingSplitTest(t)
+	ctx := context.Background()
+	start := bookingTestStart()
+
+	// 400 元的預訂由三人平均分攤，差額由預訂者負擔
+	booking, err := bookings.CreateBooking(bookingTestUserID, &dto.CreateBookingRequest{CourtID: "66666666-6666-6666-6666-666666666666", StartTime: start, EndTime: start.Add(time.Hour)})
+	require.NoError(t, err)
+
+	_, err = bookings.CreateBookingSplit(booking.ID, splitFriendID, &dto.CreateBookingSplitRequest{UserIDs: []string{splitPartnerID}})
+	assert.True(t, apperror.HasCode(err, apperror.CodeBookingSplitForbidden))
+	_, err = bookings.CreateBookingSplit(booking.ID, bookingTestUserID, &dto.CreateBookingSplitRequest{UserIDs: []string{splitFriendID, splitFriendID}})
+	assert.True(t, apperror.HasCode(err, apperror.CodeBookingSplitInvalidPayers))
+	_, err = bookings.CreateBookingSplit(booking.ID, bookingTestUserID, &dto.CreateBookingSplitRequest{
+		Mode:   models.PaymentSplitModeCustom,
+		Shares: []dto.BookingShareAmount{{UserID: splitFriendID, Amount: 100}},
+	})
+	assert.True(t, apperror.HasCode(err, apperror.CodeBookingSplitInvalidShares))
+	_, err = bookings.CreateBookingSplit(booking.ID, bookingTestUserID, &dto.CreateBookingSplitRequest{MatchID: stringPtr(splitMatchID)})
+	assert.True(t, apperror.HasCode(err, apperror.CodeBookingSplitInvalidMatch))
+
+	require.NoError(t, db.Exec(`INSERT INTO match_participants (match_id, user_id, status) VALUES (?, ?, 'accepted'), (?, ?, 'accepted')`,
+		splitMatchID, bookingTestUserID, splitMatchID, splitPartnerID).Error)
+	split, err := bookings.CreateBookingSplit(booking.ID, bookingTestUserID, &dto.CreateBookingSplitRequest{
+		UserIDs: []string{splitFriendID},
+		MatchID: stringPtr(splitMatchID),
+	})
+	require.NoError(t, err)
+	require.Len(t, split.Shares, 3)
+	amounts := map[string]float64{}
+	for _, share := range split.Shares {
+		amounts[share.UserID] = share.Amount
+	}
+	assert.InDelta(t, 133.34, amounts[bookingTestUserID], 0.001)
+	assert.InDelta(t, 133.33, amounts[splitFriendID], 0.001)
+	assert.InDelta(t, 133.33, amounts[splitPartnerID], 0.001)
+	assert.InDelta(t, 400, split.OutstandingAmount, 0.001)
+
+	_, err = bookings.CreateBookingSplit(booking.ID, bookingTestUserID, &dto.CreateBookingSplitRequest{UserIDs: []string{splitFriendID}})
+	assert.True(t, apperror.HasCode(err, apperror.CodeBookingSplitExists))
+
+	// 分攤期間預訂不能整筆付款，各付款人只能支付自己的分攤
+	_, err = payments.CreatePayment(ctx, bookingTestUserID, &dto.CreatePaymentRequest{TargetType: services.PaymentTargetBooking, TargetID: booking.ID})
+	assert.True(t, apperror.HasCode(err, apperror.CodePaymentNotRequired))
+
+	for _, share := range split.Shares {
+		_, err = payments.CreatePayment(ctx, splitFriendID, &dto.CreatePaymentRequest{TargetType: services.PaymentTargetBookingShare, TargetID: share.ID})
+		if share.UserID != splitFriendID {
+			assert.Error(t, err)
+			continue
+		}
+		require.NoError(t, err)
+	}
+
+	for i, share := range split.Shares {
+		payment, err := payments.CreatePayment(ctx, share.UserID, &dto.CreatePaymentRequest{TargetType: services.PaymentTargetBookingShare, TargetID: share.ID})
+		require.NoError(t, err)
+		assert.InDelta(t, share.Amount, payment.Amount, 0.001)
+		_, err = payments.CapturePayment(ctx, share.UserID, payment.ID)
+		require.NoError(t, err)
+
+		var current models.Booking
+		require.NoError(t, db.First(&current, "id = ?", booking.ID).Error)
+		if i < len(split.Shares)-1 {
+			assert.Equal(t, "pending", current.Status)
+		} else {
+			assert.Equal(t, "confirmed", current.Status)
+		}
+	}
+
+	// 付款人可查看各分攤的付款狀態
+	view, err := bookings.GetBookingSplit(booking.ID, splitPartnerID)
+	require.NoError(t, err)
+	assert.Equal(t, models.PaymentSplitCompleted, view.Status)
+	assert.InDelta(t, 400, view.PaidAmount, 0.001)
+	assert.Zero(t, view.OutstandingAmount)
+	_, err = bookings.GetBookingSplit(booking.ID, bookingTestOwnerID)
+	assert.True(t, apperror.HasCode(err, apperror.CodeBookingSplitNotFound))
+
+	// 取消預訂時各分攤分別退款
+	quote, err := bookings.GetCancellationQuote(booking.ID, bookingTestUserID)
+	require.NoError(t, err)
+	assert.InDelta(t, 400, quote.PaidAmount, 0.001)
+	assert.InDelta(t, 400, quote.RefundAmount, 0.001)
+
+	_, err = bookings.CancelBooking(booking.ID, bookingTestUserID, &dto.CancelBookingRequest{})
+	require.NoError(t, err)
+
+	var refunds []models.Cancellation
+	require.NoError(t, db.Where("target_type = ?", services.PaymentTargetBookingShare).Find(&refunds).Error)
+	require.Len(t, refunds, 3)
+	for _, refund := range refunds {
+		assert.Equal(t, services.RefundStatusSucceeded, refund.RefundStatus)
+		var payment models.Payment
+		require.NoError(t, db.First(&payment, "id = ?", *refund.PaymentID).Error)
+		assert.Equal(t, services.PaymentStatusRefunded, payment.Status)
+	}
+}
+
+func TestBookingUsecase_SplitPaymentDeadline(t *testing.T) {
+	db, bookings, payments, paymentService := setupBookingSplitTest(t)
+	ctx := context.Background()
+	start := bookingTestStart()
+	courtID := "66666666-6666-6666-6666-666666666666"
+	overdue := func(bookingID string) {
+		require.NoError(t, db.Model(&models.BookingPaymentSplit{}).Where("booking_id = ?", bookingID).
+			Update("due_at", time.Now().Add(-time.Minute)).Error)
+	}
+
+	// 由預訂者支付餘額：未付的分攤（包含預訂者自己的）合併為預訂者的一筆分攤
+	charged, err := bookings.CreateBooking(bookingTestUserID, &dto.CreateBookingRequest{CourtID: courtID, StartTime: start, EndTime: start.Add(time.Hour)})
+	require.NoError(t, err)
+	split, err := bookings.CreateBookingSplit(charged.ID, bookingTestUserID, &dto.CreateBookingSplitRequest{
+		Mode:     models.PaymentSplitModeCustom,
+		Shares:   []dto.BookingShareAmount{{UserID: splitFriendID, Amount: 300}, {UserID: bookingTestUserID, Amount: 100}},
+		DueAt:    timePtr(start.Add(-time.Hour)),
+		Fallback: models.PaymentSplitFallbackChargeOwner,
+	})
+	require.NoError(t, err)
+	overdue(charged.ID)
+	_, err = paymentService.ExpireDue(ctx)
+	require.NoError(t, err)
+
+	view, err := bookings.GetBookingSplit(charged.ID, bookingTestUserID)
+	require.NoError(t, err)
+	assert.Equal(t, models.PaymentSplitOpen, view.Status)
+	require.NotNil(t, view.OwnerChargedAt)
+	assert.True(t, view.DueAt.After(time.Now()))
+	var owed *models.BookingPaymentShare
+	for i, share := range view.Shares {
+		if share.UserID == splitFriendID {
+			assert.Equal(t, models.PaymentShareStatusReassigned, share.Status)
+		}
+		if share.IsOwner && share.Status == models.PaymentShareStatusPending && share.Amount > 200 {
+			owed = &view.Shares[i]
+		}
+	}
+	require.NotNil(t, owed)
+	assert.InDelta(t, 400, owed.Amount, 0.001)
+	assert.InDelta(t, 400, view.OutstandingAmount, 0.001)
+
+	// 取消分攤：預訂恢復由預訂者一次付款
+	require.NoError(t, bookings.CancelBookingSplit(charged.ID, bookingTestUserID))
+	_, err = payments.CreatePayment(ctx, bookingTestUserID, &dto.CreatePaymentRequest{TargetType: services.PaymentTargetBooking, TargetID: charged.ID})
+	require.NoError(t, err)
+	_, err = payments.CreatePayment(ctx, bookingTestUserID, &dto.CreatePaymentRequest{TargetType: services.PaymentTargetBookingShare, TargetID: split.Shares[0].ID})
+	assert.True(t, apperror.HasCode(err, apperror.CodePaymentNotRequired))
+
+	// 期限前未付清時取消預訂，已付的分攤全額退款
+	cancelled, err := bookings.CreateBooking(bookingTestUserID, &dto.CreateBookingRequest{CourtID: courtID, StartTime: start.Add(2 * time.Hour), EndTime: start.Add(3 * time.Hour)})
+	require.NoError(t, err)
+	split, err = bookings.CreateBookingSplit(cancelled.ID, bookingTestUserID, &dto.CreateBookingSplitRequest{UserIDs: []string{splitFriendID}})
+	require.NoError(t, err)
+	var friendShare models.BookingPaymentShare
+	for _, share := range split.Shares {
+		if share.UserID == splitFriendID {
+			friendShare = share
+		}
+	}
+	payment, err := payments.CreatePayment(ctx, splitFriendID, &dto.CreatePaymentRequest{TargetType: services.PaymentTargetBookingShare, TargetID: friendShare.ID})
+	require.NoError(t, err)
+	_, err = payments.CapturePayment(ctx, splitFriendID, payment.ID)
+	require.NoError(t, err)
+	assert.True(t, apperror.HasCode(bookings.CancelBookingSplit(cancelled.ID, bookingTestUserID), apperror.CodeBookingSplitHasPayments))
+
+	overdue(cancelled.ID)
+	_, err = paymentService.ExpireDue(ctx)
+	require.NoError(t, err)
+
+	var booking models.Booking
+	require.NoError(t, db.First(&booking, "id = ?", cancelled.ID).Error)
+	assert.Equal(t, "cancelled", booking.Status)
+	var refund models.Cancellation
+	require.NoError(t, db.First(&refund, "target_type = ? AND target_id = ?", services.PaymentTargetBookingShare, friendShare.ID).Error)
+	assert.Equal(t, services.RefundStatusPending, refund.RefundStatus)
+	assert.InDelta(t, 200, refund.RefundAmount, 0.001)
+}
@@ -1,87 +1,90 @@
 package usecases
 
 import (
-	"encoding/json"
+	"context"
 	"errors"
 	"fmt"
+	"log"
+	"math"
+	"sort"
+	"tennis-platform/backend/internal/apperror"
 	"tennis-platform/backend/internal/dto"
 	"tennis-platform/backend/internal/models"
+	"tennis-platform/backend/internal/pagination"
 	"tennis-platform/backend/internal/services"
 	"time"
 
+	"github.com/jackc/pgx/v5/pgconn"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// DefaultSlotHoldDuration 結帳期間保留時段的默認時長
+const DefaultSlotHoldDuration = 10 * time.Minute
+
+// maxAlternativeSlots 時段已被預訂時最多建議的替代時段數
+const maxAlternativeSlots = 3
+
 // BookingUsecase 預訂用例
 type BookingUsecase struct {
-	db                  *gorm.DB
-	notificationService services.NotificationService
+	db            *gorm.DB
+	eventBus      *services.EventBus
+	cancellations *services.CancellationService
+	pricing       *services.PricingService
+	promos        *services.PromoService
+	equipment     *services.EquipmentService
+	paymentHold   time.Duration // 未付款預訂的保留時間，0 表示不限時
+	slotHold      time.Duration // 結帳期間保留時段的時長
+	waitlistClaim time.Duration // 候補者認領釋出時段的期限
+	checkIn       *services.CheckInSigner
+	noShowGrace   time.Duration // 預訂結束後結算出席前的寬限時間
 }
 
 // NewBookingUsecase 創建新的預訂用例
-func NewBookingUsecase(db *gorm.DB, notificationService services.NotificationService) *BookingUsecase {
+func NewBookingUsecase(db *gorm.DB, eventBus *services.EventBus) *BookingUsecase {
 	return &BookingUsecase{
-		db:                  db,
-		notificationService: notificationService,
+		db:            db,
+		eventBus:      eventBus,
+		cancellations: services.NewCancellationService(db, nil),
+		pricing:       services.NewPricingService(db),
+		promos:        services.NewPromoService(db),
+		equipment:     services.NewEquipmentService(db),
+		slotHold:      DefaultSlotHoldDuration,
+		waitlistClaim: DefaultWaitlistClaimDuration,
+		noShowGrace:   DefaultNoShowGrace,
 	}
 }
 
-// CreateBookingRequest 創建預訂請求
-type CreateBookingRequest struct {
-	CourtID   string    `json:"courtId" binding:"required,uuid"`
-	StartTime time.Time `json:"startTime" binding:"required"`
-	EndTime   time.Time `json:"endTime" binding:"required"`
-	Notes     *string   `json:"notes" binding:"omitempty,max=500"`
-}
-
-// UpdateBookingRequest 更新預訂請求
-type UpdateBookingRequest struct {
-	StartTime *time.Time `json:"startTime"`
-	EndTime   *time.Time `json:"endTime"`
-	Notes     *string    `json:"notes" binding:"omitempty,max=500"`
-	Status    *string    `json:"status" binding:"omitempty,oneof=pending confirmed cancelled completed"`
-}
-
-// BookingListRequest 預訂列表請求
-type BookingListRequest struct {
-	CourtID   *string    `form:"courtId" binding:"omitempty,uuid"`
-	UserID    *string    `form:"userId" binding:"omitempty,uuid"`
-	Status    *string    `form:"status" binding:"omitempty,oneof=pending confirmed cancelled completed"`
-	StartDate *time.Time `form:"startDate"`
-	EndDate   *time.Time `form:"endDate"`
-	Page      int        `form:"page" binding:"omitempty,min=1"`
-	PageSize  int        `form:"pageSize" binding:"omitempty,min=1,max=100"`
+// UseCancellations 設置取消政策服務，取消時依場地政策計算並發起退款
+func (bu *BookingUsecase) UseCancellations(cancellations *services.CancellationService) {
+	bu.cancellations = cancellations
 }
 
-// BookingListResponse 預訂列表回應
-type BookingListResponse struct {
-	Bookings   []models.Booking `json:"bookings"`
-	Total      int64            `json:"total"`
-	Page       int              `json:"page"`
-	PageSize   int              `json:"pageSize"`
-	TotalPages int              `json:"totalPages"`
+// UsePaymentHold 設置未付款預訂的保留時間，逾期未付款的預訂由付款服務取消
+func (bu *BookingUsecase) UsePaymentHold(hold time.Duration) {
+	bu.paymentHold = hold
 }
 
-// AvailabilityRequest 可用時間查詢請求
-type AvailabilityRequest struct {
-	CourtID  string    `form:"courtId" binding:"required,uuid"`
-	Date     time.Time `form:"date" binding:"required"`
-	Duration int       `form:"duration" binding:"omitempty,min=30,max=480"` // 分鐘，默認60分鐘
+// UseSlotHold 設置結帳期間保留時段的時長
+func (bu *BookingUsecase) UseSlotHold(hold time.Duration) {
+	if hold > 0 {
+		bu.slotHold = hold
+	}
 }
 
-// dto.TimeSlot 時間段
-type TimeSlot struct {
-	StartTime time.Time `json:"startTime"`
-	EndTime   time.Time `json:"endTime"`
-	Available bool      `json:"available"`
-	Price     float64   `json:"price"`
+// UseWaitlistClaim 設置候補者認領釋出時段的期限
+func (bu *BookingUsecase) UseWaitlistClaim(claim time.Duration) {
+	if claim > 0 {
+		bu.waitlistClaim = claim
+	}
 }
 
-// AvailabilityResponse 可用時間回應
-type AvailabilityResponse struct {
-	Date      time.Time      `json:"date"`
-	CourtID   string         `json:"courtId"`
-	TimeSlots []dto.TimeSlot `json:"timeSlots"`
+// UseCheckIn 設置簽發報到碼的服務及預訂結束後結算出席前的寬限時間
+func (bu *BookingUsecase) UseCheckIn(signer *services.CheckInSigner, grace time.Duration) {
+	bu.checkIn = signer
+	if grace >= 0 {
+		bu.noShowGrace = grace
+	}
 }
 
 // CreateBooking 創建預訂
@@ -95,62 +98,381 @@ func (bu *BookingUsecase) CreateBooking(userID string, req *dto.CreateBookingReq
 	var court models.Court
 	if err := bu.db.Where("id = ? AND deleted_at IS NULL AND is_active = true", req.CourtID).First(&court).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("場地不存在或不可用")
+			return nil, apperror.New(apperror.CodeCourtUnavailable)
 		}
 		return nil, errors.New("查詢場地失敗")
 	}
 
-	// 檢查時間衝突
-	if err := bu.checkTimeConflict(req.CourtID, req.StartTime, req.EndTime, ""); err != nil {
+	// 檢查場地營業時間
+	if err := bu.checkOperatingHours(bu.db, &court, req.StartTime, req.EndTime); err != nil {
 		return nil, err
 	}
 
-	// 檢查場地營業時間
-	if err := bu.checkOperatingHours(&court, req.StartTime, req.EndTime); err != nil {
-		return nil, err
+	// 按價格規則計算總價格
+	breakdown, err := bu.pricing.Quote(context.Background(), &court, userID, req.StartTime, req.EndTime)
+	if err != nil {
+		return nil, errors.New("計算價格失敗")
 	}
 
-	// 計算總價格
-	duration := req.EndTime.Sub(req.StartTime).Hours()
-	totalPrice := duration * court.PricePerHour
+	// 租借的器材計入總價，庫存在寫入預訂的事務中檢查
+	addOns, err := bu.equipment.Prepare(context.Background(), court.ID, bookingAddOnItems(req.AddOns), req.StartTime, req.EndTime)
+	if err != nil {
+		var appErr *apperror.Error
+		if errors.As(err, &appErr) {
+			return nil, err
+		}
+		return nil, errors.New("驗證租借器材失敗")
+	}
+	services.ApplyAddOns(breakdown, addOns)
+
+	// 套用優惠碼，使用次數在寫入預訂的事務中扣除
+	var promo *services.PromoDiscount
+	var purchase *services.PromoPurchase
+	if req.PromoCode != nil && *req.PromoCode != "" {
+		purchase = &services.PromoPurchase{
+			UserID:     userID,
+			TargetType: models.PromoTargetBooking,
+			CourtID:    court.ID,
+			Amount:     breakdown.Total,
+			Currency:   breakdown.Currency,
+			At:         req.StartTime,
+		}
+		if promo, err = bu.promos.Quote(context.Background(), *req.PromoCode, purchase); err != nil {
+			var appErr *apperror.Error
+			if errors.As(err, &appErr) {
+				return nil, err
+			}
+			return nil, errors.New("驗證優惠碼失敗")
+		}
+		breakdown.Subtotal = breakdown.Total
+		breakdown.Discount = promo.PriceDiscount()
+		breakdown.Total = promo.Total
+	}
 
 	// 創建預訂
 	booking := models.Booking{
-		CourtID:    req.CourtID,
-		UserID:     userID,
-		StartTime:  req.StartTime,
-		EndTime:    req.EndTime,
-		TotalPrice: totalPrice,
-		Status:     "pending",
-		Notes:      req.Notes,
+		CourtID:        req.CourtID,
+		UserID:         userID,
+		StartTime:      req.StartTime,
+		EndTime:        req.EndTime,
+		TotalPrice:     breakdown.Total,
+		PriceBreakdown: breakdown,
+		Status:         "pending",
+		Notes:          req.Notes,
+	}
+	if bu.paymentHold > 0 && booking.TotalPrice > 0 {
+		dueAt := time.Now().Add(bu.paymentHold)
+		booking.PaymentDueAt = &dueAt
 	}
 
-	if err := bu.db.Create(&booking).Error; err != nil {
+	// 鎖定場地後檢查衝突並寫入，並發的預訂依序執行，重疊的預訂另由數據庫排除約束保證
+	err = bu.withCourtLock(req.CourtID, func(tx *gorm.DB) error {
+		if req.HoldID != nil {
+			hold, err := bu.activeHold(tx, userID, *req.HoldID)
+			if err != nil {
+				return err
+			}
+			if !holdMatches(hold, req) {
+				return apperror.New(apperror.CodeBookingHoldMismatch)
+			}
+			if err := bu.checkTimeConflict(tx, hold.CourtID, hold.CourtUnitID, hold.StartTime, hold.EndTime, "", userID); err != nil {
+				return err
+			}
+			booking.CourtUnitID = hold.CourtUnitID
+		} else {
+			courtUnitID, err := bu.assignCourtUnit(tx, userID, req.CourtID, req.CourtUnitID, req.StartTime, req.EndTime)
+			if err != nil {
+				return err
+			}
+			booking.CourtUnitID = courtUnitID
+		}
+
+		// 預訂成立後釋放用戶在該時段的保留
+		if err := bu.releaseOwnHolds(tx, userID, req.CourtID, req.StartTime, req.EndTime); err != nil {
+			return err
+		}
+
+		if err := tx.Create(&booking).Error; err != nil {
+			return err
+		}
+		if err := bu.equipment.Reserve(tx, booking.ID, addOns); err != nil {
+			return err
+		}
+		if promo != nil {
+			if _, err := bu.promos.Redeem(tx, promo, purchase, booking.ID); err != nil {
+				return err
+			}
+		}
+
+		// 以候補提供的保留預訂時，候補同時轉為已認領
+		if req.HoldID != nil {
+			if err := claimWaitlistOffer(tx, *req.HoldID, booking.ID); err != nil {
+				return err
+			}
+		}
+
+		// 發布預訂創建事件（與預訂同一事務寫入發件箱）
+		return bu.publishBookingEvent(tx, services.EventBookingCreated, &booking, "")
+	})
+	if err != nil {
+		if apperror.HasCode(err, apperror.CodeBookingSlotTaken) {
+			recordSlotDemand(bu.db, court.ID, demandRejectedBookings, [][2]time.Time{{req.StartTime, req.EndTime}})
+			return nil, bu.withAlternatives(err, &court, req.CourtUnitID, userID, req.StartTime, req.EndTime)
+		}
+		var appErr *apperror.Error
+		if errors.As(err, &appErr) {
+			return nil, err
+		}
 		return nil, errors.New("創建預訂失敗")
 	}
+	bu.notifyEventBus()
 
 	// 載入關聯數據
-	if err := bu.db.Preload("Court").Preload("User").First(&booking, booking.ID).Error; err != nil {
+	if err := bu.db.Preload("Court").Preload("CourtUnit").Preload("User").Preload("AddOns").First(&booking, "id = ?", booking.ID).Error; err != nil {
 		return nil, errors.New("載入預訂數據失敗")
 	}
 
-	// 發送預訂確認通知
-	if bu.notificationService != nil {
-		if err := bu.notificationService.SendBookingConfirmation(&booking); err != nil {
-			// 記錄錯誤但不影響主要流程
-			fmt.Printf("Warning: Failed to send booking confirmation notification: %v\n", err)
+	return &booking, nil
+}
+
+// HoldSlot 在結帳期間暫時保留時段
+//
+// 保留期間其他用戶無法預訂或保留重疊的時段，同一用戶在該場地重疊的舊保留會被取代。
+func (bu *BookingUsecase) HoldSlot(userID string, req *dto.CreateSlotHoldRequest) (*models.SlotHold, error) {
+	if err := bu.validateBookingTime(req.StartTime, req.EndTime); err != nil {
+		return nil, err
+	}
+
+	var court models.Court
+	if err := bu.db.Where("id = ? AND deleted_at IS NULL AND is_active = true", req.CourtID).First(&court).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.New(apperror.CodeCourtUnavailable)
 		}
+		return nil, errors.New("查詢場地失敗")
 	}
 
-	return &booking, nil
+	if err := bu.checkOperatingHours(bu.db, &court, req.StartTime, req.EndTime); err != nil {
+		return nil, err
+	}
+
+	hold := models.SlotHold{
+		CourtID:   req.CourtID,
+		UserID:    userID,
+		StartTime: req.StartTime,
+		EndTime:   req.EndTime,
+	}
+	err := bu.withCourtLock(req.CourtID, func(tx *gorm.DB) error {
+		// 順便清理該場地已過期的保留
+		if err := tx.Where("court_id = ? AND expires_at <= ?", req.CourtID, time.Now()).Delete(&models.SlotHold{}).Error; err != nil {
+			return err
+		}
+
+		courtUnitID, err := bu.assignCourtUnit(tx, userID, req.CourtID, req.CourtUnitID, req.StartTime, req.EndTime)
+		if err != nil {
+			return err
+		}
+		if err := bu.releaseOwnHolds(tx, userID, req.CourtID, req.StartTime, req.EndTime); err != nil {
+			return err
+		}
+
+		hold.CourtUnitID = courtUnitID
+		hold.ExpiresAt = time.Now().Add(bu.slotHold)
+		return tx.Create(&hold).Error
+	})
+	if err != nil {
+		if apperror.HasCode(err, apperror.CodeBookingSlotTaken) {
+			recordSlotDemand(bu.db, court.ID, demandRejectedBookings, [][2]time.Time{{req.StartTime, req.EndTime}})
+			return nil, bu.withAlternatives(err, &court, req.CourtUnitID, userID, req.StartTime, req.EndTime)
+		}
+		var appErr *apperror.Error
+		if errors.As(err, &appErr) {
+			return nil, err
+		}
+		return nil, errors.New("保留時段失敗")
+	}
+
+	if err := bu.db.Preload("CourtUnit").First(&hold, "id = ?", hold.ID).Error; err != nil {
+		return nil, errors.New("載入保留數據失敗")
+	}
+
+	return &hold, nil
+}
+
+// ReleaseHold 提前釋放用戶保留的時段
+func (bu *BookingUsecase) ReleaseHold(userID, holdID string) error {
+	result := bu.db.Where("id = ? AND user_id = ?", holdID, userID).Delete(&models.SlotHold{})
+	if result.Error != nil {
+		return errors.New("釋放保留失敗")
+	}
+	if result.RowsAffected == 0 {
+		return apperror.New(apperror.CodeBookingHoldNotFound)
+	}
+	return nil
+}
+
+// activeHold 獲取用戶未過期的保留
+func (bu *BookingUsecase) activeHold(tx *gorm.DB, userID, holdID string) (*models.SlotHold, error) {
+	var hold models.SlotHold
+	if err := tx.Where("id = ? AND user_id = ? AND expires_at > ?", holdID, userID, time.Now()).First(&hold).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.New(apperror.CodeBookingHoldNotFound)
+		}
+		return nil, err
+	}
+	return &hold, nil
+}
+
+// holdMatches 判斷預訂請求與保留的場地、時段及球場是否一致
+func holdMatches(hold *models.SlotHold, req *dto.CreateBookingRequest) bool {
+	if hold.CourtID != req.CourtID || !hold.StartTime.Equal(req.StartTime) || !hold.EndTime.Equal(req.EndTime) {
+		return false
+	}
+	if req.CourtUnitID == nil {
+		return true
+	}
+	return hold.CourtUnitID != nil && *hold.CourtUnitID == *req.CourtUnitID
+}
+
+// releaseOwnHolds 刪除用戶在場地上與時段重疊的保留
+func (bu *BookingUsecase) releaseOwnHolds(tx *gorm.DB, userID, courtID string, startTime, endTime time.Time) error {
+	return tx.Where("court_id = ? AND user_id = ? AND start_time < ? AND end_time > ?", courtID, userID, endTime, startTime).
+		Delete(&models.SlotHold{}).Error
+}
+
+// withCourtLock 在鎖定場地列的事務中執行 fn，使同一場地的預訂及保留依序檢查衝突
+// 違反預訂重疊排除約束時返回時段已被預訂
+func (bu *BookingUsecase) withCourtLock(courtID string, fn func(tx *gorm.DB) error) error {
+	err := bu.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockCourt(tx, courtID); err != nil {
+			return err
+		}
+		return fn(tx)
+	})
+	if isOverlapViolation(err) {
+		return apperror.New(apperror.CodeBookingSlotTaken)
+	}
+	return err
+}
+
+// lockCourt 以 SELECT ... FOR UPDATE 鎖定場地列直到事務結束
+func lockCourt(tx *gorm.DB, courtID string) error {
+	var court models.Court
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").Where("id = ?", courtID).First(&court).Error
+}
+
+// isOverlapViolation 判斷錯誤是否為 PostgreSQL 排除約束衝突（23P01）
+func isOverlapViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23P01"
+}
+
+// withAlternatives 為時段已被預訂的錯誤附上同一天內最接近的替代時段
+// 查詢替代時段失敗時只記錄日誌，仍返回原錯誤
+func (bu *BookingUsecase) withAlternatives(err error, court *models.Court, courtUnitID *string, userID string, startTime, endTime time.Time) error {
+	alternatives, altErr := bu.alternativeSlots(court, courtUnitID, userID, startTime, endTime)
+	if altErr != nil {
+		log.Printf("Failed to suggest alternative slots for court %s: %v", court.ID, altErr)
+		return err
+	}
+	return apperror.From(err).WithExtension("alternatives", alternatives)
+}
+
+// alternativeSlots 查詢同一天營業時間內相同時長的空閒時段，按與請求時間的差距排序
+// 營業時間同樣以休館或更改營業時間的例外優先，整天休館時沒有替代時段
+// 指定的球場在該時段空閒時優先建議該球場，否則建議編號最小的空閒球場
+func (bu *BookingUsecase) alternativeSlots(court *models.Court, courtUnitID *string, userID string, startTime, endTime time.Time) ([]dto.AlternativeSlot, error) {
+	startOfDay := time.Date(startTime.Year(), startTime.Month(), startTime.Day(), 0, 0, 0, 0, startTime.Location())
+	closures, err := courtClosures(bu.db, court.ID, startOfDay, startOfDay.Add(24*time.Hour))
+	if err != nil {
+		return nil, err
+	}
+	hours, _, err := dayOperatingHours(court, closures, startOfDay)
+	if err != nil {
+		return nil, err
+	}
+	if hours == "closed" {
+		return []dto.AlternativeSlot{}, nil
+	}
+	openTime, closeTime, err := bu.parseOperatingHours(hours)
+	if err != nil {
+		return nil, err
+	}
+
+	units, err := bu.courtUnits(bu.db, court.ID)
+	if err != nil {
+		return nil, err
+	}
+	activeUnits := make([]models.CourtUnit, 0, len(units))
+	for _, unit := range units {
+		if unit.IsActive {
+			activeUnits = append(activeUnits, unit)
+		}
+	}
+
+	occupied, err := bu.occupiedSlots(bu.db, court.ID, userID, "", startOfDay, startOfDay.Add(24*time.Hour))
+	if err != nil {
+		return nil, err
+	}
+
+	rules, err := bu.pricing.Rules(context.Background(), court.ID)
+	if err != nil {
+		return nil, err
+	}
+	price := func(start, end time.Time) *models.PriceBreakdown {
+		return services.CalculatePrice(court, rules, nil, start, end)
+	}
+
+	duration := int(endTime.Sub(startTime) / time.Minute)
+	slots := bu.generateTimeSlots(startTime, openTime, closeTime, duration, price, len(units) > 0, activeUnits, occupied)
+
+	now := time.Now()
+	alternatives := make([]dto.AlternativeSlot, 0, len(slots))
+	for _, slot := range slots {
+		if !slot.Available || slot.StartTime.Before(now) {
+			continue
+		}
+
+		alternative := dto.AlternativeSlot{
+			StartTime: slot.StartTime,
+			EndTime:   slot.EndTime,
+			Price:     slot.Price,
+		}
+		for _, unit := range slot.Units {
+			if !unit.Available {
+				continue
+			}
+			if alternative.CourtUnitID == nil || (courtUnitID != nil && unit.CourtUnitID == *courtUnitID) {
+				unitID := unit.CourtUnitID
+				alternative.CourtUnitID = &unitID
+			}
+		}
+		alternatives = append(alternatives, alternative)
+	}
+
+	sort.SliceStable(alternatives, func(i, j int) bool {
+		return absDuration(alternatives[i].StartTime.Sub(startTime)) < absDuration(alternatives[j].StartTime.Sub(startTime))
+	})
+	if len(alternatives) > maxAlternativeSlots {
+		alternatives = alternatives[:maxAlternativeSlots]
+	}
+
+	return alternatives, nil
+}
+
+// absDuration 時間差的絕對值
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
 }
 
 // GetBooking 獲取預訂詳情
 func (bu *BookingUsecase) GetBooking(bookingID string) (*models.Booking, error) {
 	var booking models.Booking
-	if err := bu.db.Preload("Court").Preload("User").Where("id = ? AND deleted_at IS NULL", bookingID).First(&booking).Error; err != nil {
+	if err := bu.db.Preload("Court").Preload("CourtUnit").Preload("User").Preload("AddOns").Where("id = ? AND deleted_at IS NULL", bookingID).First(&booking).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("預訂不存在")
+			return nil, apperror.New(apperror.CodeBookingNotFound)
 		}
 		return nil, errors.New("獲取預訂失敗")
 	}
@@ -158,28 +480,56 @@ func (bu *BookingUsecase) GetBooking(bookingID string) (*models.Booking, error)
 }
 
 // UpdateBooking 更新預訂
-func (bu *BookingUsecase) UpdateBooking(bookingID, userID string, req *dto.UpdateBookingRequest) (*models.Booking, error) {
+// expectedVersion 不為 nil 時需與目前版本一致，否則返回版本衝突錯誤
+func (bu *BookingUsecase) UpdateBooking(bookingID, userID string, req *dto.UpdateBookingRequest, expectedVersion *int64) (*models.Booking, error) {
 	// 獲取現有預訂
 	var booking models.Booking
 	if err := bu.db.Where("id = ? AND deleted_at IS NULL", bookingID).First(&booking).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("預訂不存在")
+			return nil, apperror.New(apperror.CodeBookingNotFound)
 		}
 		return nil, errors.New("獲取預訂失敗")
 	}
 
 	// 檢查權限（只有預訂者可以修改）
 	if booking.UserID != userID {
-		return nil, errors.New("無權限修改此預訂")
+		return nil, apperror.New(apperror.CodeBookingModifyForbidden)
+	}
+
+	if expectedVersion != nil && *expectedVersion != booking.Version {
+		return nil, apperror.New(apperror.CodePreconditionFailed)
+	}
+
+	// 狀態只由付款、取消及出席結算變更，避免跳過付款或取消政策
+	if req.Status != nil {
+		return nil, apperror.New(apperror.CodeBookingStatusReadOnly)
 	}
 
 	// 檢查預訂狀態（只有 pending 狀態可以修改時間）
 	if (req.StartTime != nil || req.EndTime != nil) && booking.Status != "pending" {
-		return nil, errors.New("只有待確認的預訂可以修改時間")
+		return nil, apperror.New(apperror.CodeBookingNotPending)
+	}
+
+	// 分攤付款以原本的金額計算，進行中時不可變更時間
+	if req.StartTime != nil || req.EndTime != nil {
+		var splits int64
+		if err := bu.db.Model(&models.BookingPaymentSplit{}).
+			Where("booking_id = ? AND status = ?", booking.ID, models.PaymentSplitOpen).
+			Count(&splits).Error; err != nil {
+			return nil, errors.New("獲取預訂失敗")
+		}
+		if splits > 0 {
+			return nil, apperror.New(apperror.CodeBookingSplitExists)
+		}
 	}
 
 	// 準備更新數據
 	updates := make(map[string]interface{})
+	oldStart, oldEnd := booking.StartTime, booking.EndTime
+	var newStart, newEnd *time.Time
+	var breakdown *models.PriceBreakdown
+	var addOns []models.BookingAddOn
+	var voided []models.Payment
 
 	// 如果要更新時間，需要重新驗證
 	if req.StartTime != nil || req.EndTime != nil {
@@ -198,121 +548,321 @@ func (bu *BookingUsecase) UpdateBooking(bookingID, userID string, req *dto.Updat
 			return nil, err
 		}
 
-		// 檢查時間衝突（排除當前預訂）
-		if err := bu.checkTimeConflict(booking.CourtID, startTime, endTime, bookingID); err != nil {
-			return nil, err
-		}
-
 		// 獲取場地信息檢查營業時間
 		var court models.Court
 		if err := bu.db.Where("id = ?", booking.CourtID).First(&court).Error; err != nil {
 			return nil, errors.New("獲取場地信息失敗")
 		}
 
-		if err := bu.checkOperatingHours(&court, startTime, endTime); err != nil {
+		if err := bu.checkOperatingHours(bu.db, &court, startTime, endTime); err != nil {
 			return nil, err
 		}
 
-		// 重新計算價格
-		duration := endTime.Sub(startTime).Hours()
-		totalPrice := duration * court.PricePerHour
+		// 按價格規則重新計算價格
+		var err error
+		breakdown, err = bu.pricing.Quote(context.Background(), &court, booking.UserID, startTime, endTime)
+		if err != nil {
+			return nil, errors.New("計算價格失敗")
+		}
 
+		// 租借的器材沿用租借時的單價，按新的時段重新計算金額
+		var reserved []models.BookingAddOn
+		if err := bu.db.Where("booking_id = ? AND status = ?", booking.ID, models.BookingAddOnReserved).
+			Order("created_at ASC").Find(&reserved).Error; err != nil {
+			return nil, errors.New("獲取租借器材失敗")
+		}
+		addOns = services.Reschedule(reserved, startTime, endTime)
+		services.ApplyAddOns(breakdown, addOns)
+
+		newStart, newEnd = &startTime, &endTime
 		updates["start_time"] = startTime
 		updates["end_time"] = endTime
-		updates["total_price"] = totalPrice
 	}
 
 	if req.Notes != nil {
 		updates["notes"] = *req.Notes
 	}
 
-	if req.Status != nil {
-		updates["status"] = *req.Status
-	}
-
-	// 記錄舊狀態用於通知
-	oldStatus := booking.Status
-
 	// 執行更新
 	if len(updates) > 0 {
-		if err := bu.db.Model(&booking).Updates(updates).Error; err != nil {
+		tx := bu.db.Begin()
+		defer func() {
+			if r := recover(); r != nil {
+				tx.Rollback()
+			}
+		}()
+
+		// 變更時間時鎖定場地，再檢查同一球場的時間衝突（排除當前預訂）
+		if newStart != nil {
+			if err := lockCourt(tx, booking.CourtID); err != nil {
+				tx.Rollback()
+				return nil, errors.New("更新預訂失敗")
+			}
+			if err := bu.checkTimeConflict(tx, booking.CourtID, booking.CourtUnitID, *newStart, *newEnd, bookingID, booking.UserID); err != nil {
+				tx.Rollback()
+				return nil, err
+			}
+
+			// 在新的時段重新檢查租借器材的庫存
+			if len(addOns) > 0 {
+				if err := bu.equipment.Replace(tx, booking.ID, addOns); err != nil {
+					tx.Rollback()
+					var appErr *apperror.Error
+					if errors.As(err, &appErr) {
+						return nil, err
+					}
+					return nil, errors.New("更新預訂失敗")
+				}
+			}
+
+			// 保留已套用的優惠碼，按新的價格重新計算折扣
+			if booking.PriceBreakdown != nil && booking.PriceBreakdown.Discount != nil {
+				if err := bu.promos.Reprice(tx, models.PromoTargetBooking, booking.ID, booking.PriceBreakdown.Discount, breakdown); err != nil {
+					tx.Rollback()
+					return nil, errors.New("更新預訂失敗")
+				}
+			}
+			updates["total_price"] = breakdown.Total
+			updates["price_breakdown"] = breakdown
+
+			// 金額變更時作廢以原金額發起的付款，需以新金額重新付款
+			if breakdown.Total != booking.TotalPrice {
+				var err error
+				voided, err = bu.cancellations.VoidPayments(tx, services.PaymentTargetBooking, booking.ID)
+				if err != nil {
+					tx.Rollback()
+					return nil, errors.New("更新預訂失敗")
+				}
+			}
+		}
+
+		// 以讀取時的版本號作為條件，避免覆蓋並發的修改
+		updates["version"] = gorm.Expr("version + 1")
+		result := tx.Model(&booking).Where("version = ?", booking.Version).Updates(updates)
+		if result.Error != nil {
+			tx.Rollback()
+			if isOverlapViolation(result.Error) {
+				return nil, apperror.New(apperror.CodeBookingSlotTaken)
+			}
 			return nil, errors.New("更新預訂失敗")
 		}
+		if result.RowsAffected == 0 {
+			tx.Rollback()
+			return nil, apperror.New(apperror.CodePreconditionFailed)
+		}
+
+		if err := tx.Commit().Error; err != nil {
+			return nil, errors.New("更新預訂失敗")
+		}
+		bu.notifyEventBus()
+		bu.cancellations.CancelVoidedPayments(context.Background(), voided)
+
+		// 改期後原時段釋出，提供給候補者
+		if newStart != nil {
+			bu.offerWaitlist(booking.CourtID, oldStart, oldEnd)
+		}
 	}
 
 	// 重新載入數據
-	if err := bu.db.Preload("Court").Preload("User").First(&booking, bookingID).Error; err != nil {
+	if err := bu.db.Preload("Court").Preload("CourtUnit").Preload("User").Preload("AddOns").First(&booking, "id = ?", bookingID).Error; err != nil {
 		return nil, errors.New("載入預訂數據失敗")
 	}
 
-	// 如果狀態有變更，發送通知
-	if bu.notificationService != nil && req.Status != nil && oldStatus != *req.Status {
-		if err := bu.notificationService.SendBookingStatusUpdate(&booking, oldStatus); err != nil {
-			// 記錄錯誤但不影響主要流程
-			fmt.Printf("Warning: Failed to send booking status update notification: %v\n", err)
+	return &booking, nil
+}
+
+// GetCancellationQuote 獲取此刻取消預訂的退款報價
+func (bu *BookingUsecase) GetCancellationQuote(bookingID, userID string) (*services.CancellationQuote, error) {
+	booking, err := bu.getCancellableBooking(bookingID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	quote, err := bu.quoteBookingCancellation(context.Background(), booking, bookingCancellationTarget(booking), services.CancellationInitiatorCustomer, time.Now())
+	if err != nil {
+		return nil, errors.New("計算退款金額失敗")
+	}
+	return quote, nil
+}
+
+// CancelBooking 依場地取消政策取消預訂，並退還已付款項的可退金額
+func (bu *BookingUsecase) CancelBooking(bookingID, userID string, req *dto.CancelBookingRequest) (*models.Cancellation, error) {
+	booking, err := bu.getCancellableBooking(bookingID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	target := bookingCancellationTarget(booking)
+	quote, err := bu.quoteBookingCancellation(ctx, booking, target, services.CancellationInitiatorCustomer, time.Now())
+	if err != nil {
+		return nil, errors.New("計算退款金額失敗")
+	}
+
+	// 檢查取消時間限制
+	if !quote.Allowed {
+		return nil, apperror.New(apperror.CodeBookingCancelWindowPassed).With("hours", quote.CutoffHours)
+	}
+	if err := checkExpectedRefund(quote, req.ExpectedRefundAmount); err != nil {
+		return nil, err
+	}
+
+	var cancellation *models.Cancellation
+	err = bu.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		cancellation, err = bu.cancelBooking(tx, booking, target, quote, userID, req.Reason, nil)
+		return err
+	})
+	if err != nil {
+		var appErr *apperror.Error
+		if errors.As(err, &appErr) {
+			return nil, err
 		}
+		return nil, errors.New("取消預訂失敗")
 	}
+	bu.notifyEventBus()
 
-	return &booking, nil
+	bu.settleCancellation(ctx, booking, cancellation)
+	bu.offerWaitlist(booking.CourtID, booking.StartTime, booking.EndTime)
+	return cancellation, nil
+}
+
+// cancelBooking 在事務中將預訂標記為取消、發布取消事件並記錄取消結果，分攤付款的預訂另外記錄各分攤的退款
+// closure 不為空表示因場地例外取消，取消事件附上例外以發送對應的通知
+func (bu *BookingUsecase) cancelBooking(tx *gorm.DB, booking *models.Booking, target *services.CancellationTarget, quote *services.CancellationQuote, userID string, reason *string, closure *models.CourtClosure) (*models.Cancellation, error) {
+	// 以讀取時的狀態及版本號作為條件，並發的取消只有一個生效，不會重複記錄取消及退款
+	oldStatus := booking.Status
+	result := tx.Model(&models.Booking{}).
+		Where("id = ? AND status = ? AND version = ?", booking.ID, oldStatus, booking.Version).
+		Updates(map[string]interface{}{
+			"status":  "cancelled",
+			"version": gorm.Expr("version + 1"),
+		})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, bookingCancelConflict(tx, booking.ID)
+	}
+	booking.Status = "cancelled"
+	booking.Version++
+
+	// 發布預訂取消事件
+	if bu.eventBus != nil {
+		payload := bookingEventPayload(booking, oldStatus)
+		if closure != nil {
+			payload.ClosureID = closure.ID
+		}
+		if err := bu.eventBus.Publish(tx, services.EventBookingCancelled, "booking", booking.ID, payload); err != nil {
+			return nil, err
+		}
+	}
+
+	cancellation, err := bu.cancellations.Record(tx, target, quote, userID, reason)
+	if err != nil {
+		return nil, err
+	}
+	if err := bu.cancelBookingSplit(tx, booking, quote, userID, reason); err != nil {
+		return nil, err
+	}
+	return cancellation, nil
+}
+
+// bookingCancelConflict 預訂在讀取後已被並發修改，已取消或已結束時返回對應錯誤，否則要求重新讀取
+func bookingCancelConflict(tx *gorm.DB, bookingID string) error {
+	var current models.Booking
+	if err := tx.Select("status").Where("id = ?", bookingID).First(&current).Error; err != nil {
+		return err
+	}
+	switch current.Status {
+	case "cancelled":
+		return apperror.New(apperror.CodeBookingAlreadyCancelled)
+	case "completed", "no_show":
+		return apperror.New(apperror.CodeBookingAlreadyCompleted)
+	default:
+		return apperror.New(apperror.CodePreconditionFailed)
+	}
+}
+
+// settleCancellation 事務提交後取消預訂尚未扣款的付款並發起退款
+// 預訂已取消，退款失敗時由取消政策服務定期重試
+func (bu *BookingUsecase) settleCancellation(ctx context.Context, booking *models.Booking, cancellation *models.Cancellation) {
+	bu.cancellations.ReleasePayments(ctx, services.PaymentTargetBooking, booking.ID)
+	if err := bu.cancellations.Settle(ctx, cancellation); err != nil {
+		log.Printf("Failed to refund cancelled booking %s: %v", booking.ID, err)
+	}
+	bu.settleBookingShares(ctx, booking)
 }
 
-// CancelBooking 取消預訂
-func (bu *BookingUsecase) CancelBooking(bookingID, userID string) error {
+// getCancellableBooking 獲取用戶可取消的預訂
+func (bu *BookingUsecase) getCancellableBooking(bookingID, userID string) (*models.Booking, error) {
 	var booking models.Booking
 	if err := bu.db.Where("id = ? AND deleted_at IS NULL", bookingID).First(&booking).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return errors.New("預訂不存在")
+			return nil, apperror.New(apperror.CodeBookingNotFound)
 		}
-		return errors.New("獲取預訂失敗")
+		return nil, errors.New("獲取預訂失敗")
 	}
 
 	// 檢查權限
 	if booking.UserID != userID {
-		return errors.New("無權限取消此預訂")
+		return nil, apperror.New(apperror.CodeBookingCancelForbidden)
 	}
 
 	// 檢查預訂狀態
 	if booking.Status == "cancelled" {
-		return errors.New("預訂已經取消")
+		return nil, apperror.New(apperror.CodeBookingAlreadyCancelled)
 	}
 
-	if booking.Status == "completed" {
-		return errors.New("已完成的預訂無法取消")
+	if booking.Status == "completed" || booking.Status == "no_show" {
+		return nil, apperror.New(apperror.CodeBookingAlreadyCompleted)
 	}
 
-	// 檢查取消時間限制（例如：開始時間前2小時才能取消）
-	if time.Now().Add(2 * time.Hour).After(booking.StartTime) {
-		return errors.New("預訂開始前2小時內無法取消")
-	}
+	return &booking, nil
+}
 
-	// 更新狀態為取消
-	if err := bu.db.Model(&booking).Update("status", "cancelled").Error; err != nil {
-		return errors.New("取消預訂失敗")
+// bookingCancellationTarget 預訂套用所屬場地的取消政策
+// 重複預訂整組付款時，取消其中一次最多退還該次的金額
+func bookingCancellationTarget(booking *models.Booking) *services.CancellationTarget {
+	target := &services.CancellationTarget{
+		TargetType: services.PaymentTargetBooking,
+		TargetID:   booking.ID,
+		OwnerType:  services.CancellationOwnerCourt,
+		OwnerID:    booking.CourtID,
+		StartTime:  booking.StartTime,
+		EndTime:    booking.EndTime,
+		PaymentID:  booking.PaymentID,
 	}
-
-	// 載入關聯數據用於通知
-	if err := bu.db.Preload("Court").Preload("User").First(&booking, bookingID).Error; err == nil {
-		// 發送取消通知
-		if bu.notificationService != nil {
-			if err := bu.notificationService.SendBookingCancellation(&booking); err != nil {
-				// 記錄錯誤但不影響主要流程
-				fmt.Printf("Warning: Failed to send booking cancellation notification: %v\n", err)
-			}
-		}
+	if booking.SeriesID != nil {
+		amountCap := booking.TotalPrice
+		target.AmountCap = &amountCap
 	}
+	return target
+}
 
-	return nil
+// checkExpectedRefund 客戶端確認的退款金額與取消當下的報價不符時拒絕取消
+func checkExpectedRefund(quote *services.CancellationQuote, expected *float64) error {
+	if expected == nil || math.Abs(*expected-quote.RefundAmount) < 0.005 {
+		return nil
+	}
+	return apperror.New(apperror.CodeCancellationQuoteChanged).With("refundAmount", quote.RefundAmount)
 }
 
 // GetBookings 獲取預訂列表
 func (bu *BookingUsecase) GetBookings(req *dto.BookingListRequest) (*dto.BookingListResponse, error) {
-	// 設置默認值
-	if req.Page <= 0 {
-		req.Page = 1
+	page := pagination.Query{
+		Keys: []pagination.Key{
+			{Column: "start_time", Kind: pagination.KindTime},
+			{Column: "id", Kind: pagination.KindString},
+		},
+		Desc:  true,
+		Limit: req.PageSize,
+		Page:  req.Page,
 	}
-	if req.PageSize <= 0 {
-		req.PageSize = 20
+	if req.Cursor != nil {
+		page.Cursor = *req.Cursor
 	}
+	page.Normalize(20, 100)
 
 	// 構建查詢
 	query := bu.db.Model(&models.Booking{}).Where("deleted_at IS NULL")
@@ -337,32 +887,46 @@ func (bu *BookingUsecase) GetBookings(req *dto.BookingListRequest) (*dto.Booking
 		query = query.Where("end_time <= ?", *req.EndDate)
 	}
 
-	// 計算總數
-	var total int64
-	if err := query.Count(&total).Error; err != nil {
-		return nil, errors.New("計算預訂總數失敗")
+	// 頁碼分頁時計算總數
+	var total *int64
+	if !page.IsCursor() {
+		var count int64
+		if err := query.Count(&count).Error; err != nil {
+			return nil, errors.New("計算預訂總數失敗")
+		}
+		total = &count
 	}
 
 	// 分頁查詢
+	pagedQuery, err := page.Apply(query.Preload("Court").Preload("CourtUnit").Preload("User"))
+	if err != nil {
+		return nil, err
+	}
+
 	var bookings []models.Booking
-	offset := (req.Page - 1) * req.PageSize
-	if err := query.Preload("Court").Preload("User").
-		Order("start_time DESC").
-		Offset(offset).Limit(req.PageSize).
-		Find(&bookings).Error; err != nil {
+	if err := pagedQuery.Find(&bookings).Error; err != nil {
 		return nil, errors.New("獲取預訂列表失敗")
 	}
 
-	// 計算總頁數
-	totalPages := int((total + int64(req.PageSize) - 1) / int64(req.PageSize))
+	bookings, pageInfo, err := pagination.Build(bookings, &page, total, func(b *models.Booking) []interface{} {
+		return []interface{}{b.StartTime, b.ID}
+	})
+	if err != nil {
+		return nil, errors.New("生成分頁游標失敗")
+	}
 
-	return &dto.BookingListResponse{
+	response := &dto.BookingListResponse{
 		Bookings:   bookings,
-		Total:      total,
-		Page:       req.Page,
-		PageSize:   req.PageSize,
-		TotalPages: totalPages,
-	}, nil
+		Pagination: pageInfo,
+	}
+	if total != nil {
+		response.Total = *total
+		response.Page = page.Page
+		response.PageSize = page.Limit
+		response.TotalPages = pageInfo.TotalPages
+	}
+
+	return response, nil
 }
 
 // GetAvailability 獲取場地可用時間
@@ -376,22 +940,47 @@ func (bu *BookingUsecase) GetAvailability(req *dto.AvailabilityRequest) (*dto.Av
 	var court models.Court
 	if err := bu.db.Where("id = ? AND deleted_at IS NULL AND is_active = true", req.CourtID).First(&court).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("場地不存在或不可用")
+			return nil, apperror.New(apperror.CodeCourtUnavailable)
 		}
 		return nil, errors.New("查詢場地失敗")
 	}
 
-	// 獲取當天的營業時間
-	weekday := req.Date.Weekday().String()
+	// 獲取開放預訂的球場，指定球場時只查詢該球場
+	units, err := bu.courtUnits(bu.db, court.ID)
+	if err != nil {
+		return nil, err
+	}
+	perUnit := len(units) > 0
+	openUnits := make([]models.CourtUnit, 0, len(units))
+	for _, unit := range units {
+		if req.CourtUnitID != nil && unit.ID != *req.CourtUnitID {
+			continue
+		}
+		if req.CourtUnitID != nil && !unit.IsActive {
+			return nil, apperror.New(apperror.CodeCourtUnitUnavailable).With("number", unit.Number)
+		}
+		if unit.IsActive {
+			openUnits = append(openUnits, unit)
+		}
+	}
+	if req.CourtUnitID != nil && len(openUnits) == 0 {
+		return nil, apperror.New(apperror.CodeCourtUnitNotFound)
+	}
+
+	// 獲取當天的營業時間，休館或更改營業時間的例外優先於每週的營業時間
+	startOfDay := time.Date(req.Date.Year(), req.Date.Month(), req.Date.Day(), 0, 0, 0, 0, req.Date.Location())
+	endOfDay := startOfDay.Add(24 * time.Hour)
 
-	// 解析 OperatingHours JSON 到 map
-	var operatingHours map[string]string
-	if err := json.Unmarshal(court.OperatingHours, &operatingHours); err != nil {
-		return nil, fmt.Errorf("解析營業時間失敗: %v", err)
+	closures, err := courtClosures(bu.db, court.ID, startOfDay, endOfDay)
+	if err != nil {
+		return nil, errors.New("獲取場地例外失敗")
 	}
 
-	hours, exists := operatingHours[weekday]
-	if !exists || hours == "closed" {
+	hours, _, err := dayOperatingHours(&court, closures, startOfDay)
+	if err != nil {
+		return nil, err
+	}
+	if hours == "closed" {
 		return &dto.AvailabilityResponse{
 			Date:      req.Date,
 			CourtID:   req.CourtID,
@@ -405,18 +994,32 @@ func (bu *BookingUsecase) GetAvailability(req *dto.AvailabilityRequest) (*dto.Av
 		return nil, fmt.Errorf("解析營業時間失敗: %v", err)
 	}
 
-	// 獲取當天已有的預訂
-	startOfDay := time.Date(req.Date.Year(), req.Date.Month(), req.Date.Day(), 0, 0, 0, 0, req.Date.Location())
-	endOfDay := startOfDay.Add(24 * time.Hour)
-
-	var existingBookings []models.Booking
-	if err := bu.db.Where("court_id = ? AND status IN (?, ?) AND start_time < ? AND end_time > ?",
-		req.CourtID, "pending", "confirmed", endOfDay, startOfDay).Find(&existingBookings).Error; err != nil {
+	// 獲取當天已有的預訂，其他用戶結帳中保留及被封鎖的時段同樣顯示為不可預訂
+	existingBookings, err := bu.occupiedSlots(bu.db, req.CourtID, "", "", startOfDay, endOfDay)
+	if err != nil {
 		return nil, errors.New("獲取現有預訂失敗")
 	}
 
+	// 查詢不需登入，時段以非會員價格顯示
+	rules, err := bu.pricing.Rules(context.Background(), court.ID)
+	if err != nil {
+		return nil, errors.New("獲取價格規則失敗")
+	}
+	price := func(start, end time.Time) *models.PriceBreakdown {
+		return services.CalculatePrice(&court, rules, nil, start, end)
+	}
+
 	// 生成時間段
-	timeSlots := bu.generateTimeSlots(req.Date, openTime, closeTime, req.Duration, court.PricePerHour, existingBookings)
+	timeSlots := bu.generateTimeSlots(req.Date, openTime, closeTime, req.Duration, price, perUnit, openUnits, existingBookings)
+
+	// 記錄已無空位的時段，供場地擁有者分析未能滿足的需求
+	var unavailable [][2]time.Time
+	for _, slot := range timeSlots {
+		if !slot.Available {
+			unavailable = append(unavailable, [2]time.Time{slot.StartTime, slot.EndTime})
+		}
+	}
+	recordSlotDemand(bu.db, court.ID, demandUnavailableViews, unavailable)
 
 	return &dto.AvailabilityResponse{
 		Date:      req.Date,
@@ -427,71 +1030,199 @@ func (bu *BookingUsecase) GetAvailability(req *dto.AvailabilityRequest) (*dto.Av
 
 // validateBookingTime 驗證預訂時間
 func (bu *BookingUsecase) validateBookingTime(startTime, endTime time.Time) error {
+	if err := validateBookingDuration(startTime, endTime); err != nil {
+		return err
+	}
+
+	// 檢查預訂時間是否在合理範圍內（例如：不能超過30天）
+	if startTime.After(time.Now().Add(30 * 24 * time.Hour)) {
+		return apperror.New(apperror.CodeBookingTooFarAhead).With("days", 30)
+	}
+
+	return nil
+}
+
+// validateBookingDuration 驗證預訂時段的時長及是否為未來時間，不限制可預訂的範圍
+func validateBookingDuration(startTime, endTime time.Time) error {
 	// 檢查結束時間是否晚於開始時間
 	if !endTime.After(startTime) {
-		return errors.New("結束時間必須晚於開始時間")
+		return apperror.New(apperror.CodeBookingInvalidTimeRange)
 	}
 
 	// 檢查預訂時長（最少30分鐘，最多8小時）
 	duration := endTime.Sub(startTime)
 	if duration < 30*time.Minute {
-		return errors.New("預訂時長不能少於30分鐘")
+		return apperror.New(apperror.CodeBookingTooShort).With("minutes", 30)
 	}
 	if duration > 8*time.Hour {
-		return errors.New("預訂時長不能超過8小時")
+		return apperror.New(apperror.CodeBookingTooLong).With("hours", 8)
 	}
 
 	// 檢查是否為未來時間
 	if startTime.Before(time.Now()) {
-		return errors.New("不能預訂過去的時間")
+		return apperror.New(apperror.CodeBookingInPast)
 	}
 
-	// 檢查預訂時間是否在合理範圍內（例如：不能超過30天）
-	if startTime.After(time.Now().Add(30 * 24 * time.Hour)) {
-		return errors.New("不能預訂30天後的時間")
+	return nil
+}
+
+// checkTimeConflict 檢查時間衝突
+// courtUnitID 為空時整個場地只能有一個預訂，否則只與同一球場及包下整個場地的預訂衝突；
+// 其他用戶未過期的保留視同預訂
+func (bu *BookingUsecase) checkTimeConflict(tx *gorm.DB, courtID string, courtUnitID *string, startTime, endTime time.Time, excludeBookingID, userID string) error {
+	occupied, err := bu.occupiedSlots(tx, courtID, userID, excludeBookingID, startTime, endTime)
+	if err != nil {
+		return errors.New("檢查時間衝突失敗")
+	}
+
+	for _, slot := range occupied {
+		if unitsOverlap(courtUnitID, slot.CourtUnitID) {
+			return apperror.New(apperror.CodeBookingSlotTaken)
+		}
 	}
 
 	return nil
 }
 
-// checkTimeConflict 檢查時間衝突
-func (bu *BookingUsecase) checkTimeConflict(courtID string, startTime, endTime time.Time, excludeBookingID string) error {
-	query := bu.db.Where("court_id = ? AND status IN (?, ?) AND deleted_at IS NULL", courtID, "pending", "confirmed")
+// unitsOverlap 判斷兩筆預訂的球場是否相同，任一方包下整個場地時視為相同
+func unitsOverlap(a, b *string) bool {
+	return a == nil || b == nil || *a == *b
+}
 
-	// 排除指定的預訂ID（用於更新時）
+// occupiedSlots 獲取場地在時段內的有效預訂、userID 以外用戶未過期的保留，以及休館或封鎖的時段
+// 保留及例外以只含球場及時間的 Booking 表示；userID 為空時包含所有保留
+func (bu *BookingUsecase) occupiedSlots(tx *gorm.DB, courtID, userID, excludeBookingID string, startTime, endTime time.Time) ([]models.Booking, error) {
+	query := tx.Select("id", "court_unit_id", "start_time", "end_time").
+		Where("court_id = ? AND status IN (?, ?) AND deleted_at IS NULL AND start_time < ? AND end_time > ?",
+			courtID, "pending", "confirmed", endTime, startTime)
 	if excludeBookingID != "" {
 		query = query.Where("id != ?", excludeBookingID)
 	}
 
-	// 檢查時間重疊
-	query = query.Where("(start_time < ? AND end_time > ?) OR (start_time < ? AND end_time > ?) OR (start_time >= ? AND end_time <= ?)",
-		endTime, startTime, startTime, startTime, startTime, endTime)
+	var bookings []models.Booking
+	if err := query.Find(&bookings).Error; err != nil {
+		return nil, err
+	}
 
-	var count int64
-	if err := query.Count(&count).Error; err != nil {
-		return errors.New("檢查時間衝突失敗")
+	var holds []models.SlotHold
+	if err := tx.Where("court_id = ? AND user_id != ? AND expires_at > ? AND start_time < ? AND end_time > ?",
+		courtID, userID, time.Now(), endTime, startTime).Find(&holds).Error; err != nil {
+		return nil, err
+	}
+	for _, hold := range holds {
+		bookings = append(bookings, models.Booking{
+			CourtUnitID: hold.CourtUnitID,
+			StartTime:   hold.StartTime,
+			EndTime:     hold.EndTime,
+		})
 	}
 
-	if count > 0 {
-		return errors.New("該時間段已被預訂")
+	closures, err := courtClosures(tx, courtID, startTime, endTime)
+	if err != nil {
+		return nil, err
+	}
+	for i := range closures {
+		if closures[i].Kind == models.CourtClosureHours {
+			continue
+		}
+		for _, interval := range closureIntervals(&closures[i], startTime, endTime) {
+			bookings = append(bookings, models.Booking{
+				CourtUnitID: closures[i].CourtUnitID,
+				StartTime:   interval[0],
+				EndTime:     interval[1],
+			})
+		}
 	}
 
-	return nil
+	return bookings, nil
 }
 
-// checkOperatingHours 檢查營業時間
-func (bu *BookingUsecase) checkOperatingHours(court *models.Court, startTime, endTime time.Time) error {
-	weekday := startTime.Weekday().String()
+// courtUnits 獲取場地的球場，按編號排序
+func (bu *BookingUsecase) courtUnits(tx *gorm.DB, courtID string) ([]models.CourtUnit, error) {
+	var units []models.CourtUnit
+	if err := tx.Where("court_id = ?", courtID).Order("number ASC").Find(&units).Error; err != nil {
+		return nil, errors.New("獲取球場失敗")
+	}
+	return units, nil
+}
 
-	// 解析 OperatingHours JSON 到 map
-	var operatingHours map[string]string
-	if err := json.Unmarshal(court.OperatingHours, &operatingHours); err != nil {
-		return fmt.Errorf("解析營業時間失敗: %v", err)
+// assignCourtUnit 決定預訂使用的球場並檢查時間衝突
+//
+// 場地未設置球場時返回 nil，預訂包下整個場地；指定球場時該球場需開放預訂且時段空閒；
+// 未指定時分配時段內空閒、編號最小的球場。userID 自己的保留不視為衝突。
+func (bu *BookingUsecase) assignCourtUnit(tx *gorm.DB, userID, courtID string, courtUnitID *string, startTime, endTime time.Time) (*string, error) {
+	units, err := bu.courtUnits(tx, courtID)
+	if err != nil {
+		return nil, err
+	}
+
+	if courtUnitID != nil {
+		var unit *models.CourtUnit
+		for i := range units {
+			if units[i].ID == *courtUnitID {
+				unit = &units[i]
+				break
+			}
+		}
+		if unit == nil {
+			return nil, apperror.New(apperror.CodeCourtUnitNotFound)
+		}
+		if !unit.IsActive {
+			return nil, apperror.New(apperror.CodeCourtUnitUnavailable).With("number", unit.Number)
+		}
+		if err := bu.checkTimeConflict(tx, courtID, &unit.ID, startTime, endTime, "", userID); err != nil {
+			return nil, err
+		}
+		return &unit.ID, nil
+	}
+
+	if len(units) == 0 {
+		if err := bu.checkTimeConflict(tx, courtID, nil, startTime, endTime, "", userID); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+
+	overlapping, err := bu.occupiedSlots(tx, courtID, userID, "", startTime, endTime)
+	if err != nil {
+		return nil, errors.New("檢查時間衝突失敗")
+	}
+
+	busy := make(map[string]bool, len(overlapping))
+	for _, booking := range overlapping {
+		if booking.CourtUnitID == nil {
+			// 包下整個場地的預訂佔用所有球場
+			return nil, apperror.New(apperror.CodeBookingSlotTaken)
+		}
+		busy[*booking.CourtUnitID] = true
+	}
+
+	for i := range units {
+		if units[i].IsActive && !busy[units[i].ID] {
+			return &units[i].ID, nil
+		}
+	}
+	return nil, apperror.New(apperror.CodeBookingSlotTaken)
+}
+
+// checkOperatingHours 檢查營業時間及場地例外
+// 當天休館或更改營業時間時以例外為準，整個場地被封鎖的時段無法預訂
+func (bu *BookingUsecase) checkOperatingHours(tx *gorm.DB, court *models.Court, startTime, endTime time.Time) error {
+	closures, err := courtClosures(tx, court.ID, startTime, endTime)
+	if err != nil {
+		return errors.New("獲取場地例外失敗")
 	}
 
-	hours, exists := operatingHours[weekday]
-	if !exists || hours == "closed" {
-		return errors.New("場地在該日期不營業")
+	hours, closure, err := dayOperatingHours(court, closures, startTime)
+	if err != nil {
+		return err
+	}
+	if hours == "closed" {
+		appErr := apperror.New(apperror.CodeCourtClosed)
+		if closure != nil {
+			appErr = appErr.WithExtension("closure", closure)
+		}
+		return appErr
 	}
 
 	openTime, closeTime, err := bu.parseOperatingHours(hours)
@@ -510,7 +1241,16 @@ func (bu *BookingUsecase) checkOperatingHours(court *models.Court, startTime, en
 	closeDateTime := dayStart.Add(closeTime)
 
 	if bookingStart.Before(openDateTime) || bookingEnd.After(closeDateTime) {
-		return fmt.Errorf("預訂時間超出營業時間範圍 (%s)", hours)
+		return apperror.New(apperror.CodeBookingOutsideHours).With("hours", hours)
+	}
+
+	for i := range closures {
+		if closures[i].CourtUnitID != nil || closures[i].Kind != models.CourtClosureBlock {
+			continue
+		}
+		if len(closureIntervals(&closures[i], startTime, endTime)) > 0 {
+			return apperror.New(apperror.CodeCourtBlocked).WithExtension("closure", &closures[i])
+		}
 	}
 
 	return nil
@@ -544,7 +1284,8 @@ func (bu *BookingUsecase) parseOperatingHours(hours string) (time.Duration, time
 }
 
 // generateTimeSlots 生成時間段
-func (bu *BookingUsecase) generateTimeSlots(date time.Time, openTime, closeTime time.Duration, slotDuration int, pricePerHour float64, existingBookings []models.Booking) []dto.TimeSlot {
+// price 計算時段的價格明細；perUnit 為 true 時按 units 中各球場分別判斷是否可預訂
+func (bu *BookingUsecase) generateTimeSlots(date time.Time, openTime, closeTime time.Duration, slotDuration int, price func(start, end time.Time) *models.PriceBreakdown, perUnit bool, units []models.CourtUnit, existingBookings []models.Booking) []dto.TimeSlot {
 	var timeSlots []dto.TimeSlot
 
 	dayStart := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
@@ -555,24 +1296,49 @@ func (bu *BookingUsecase) generateTimeSlots(date time.Time, openTime, closeTime
 	for currentTime.Add(slotDur).Before(endTime) || currentTime.Add(slotDur).Equal(endTime) {
 		slotEnd := currentTime.Add(slotDur)
 
-		// 檢查該時間段是否被預訂
-		available := true
+		// 檢查該時間段被預訂的球場，包下整個場地的預訂佔用所有球場
+		venueBooked := false
+		busy := make(map[string]bool)
 		for _, booking := range existingBookings {
 			if currentTime.Before(booking.EndTime) && slotEnd.After(booking.StartTime) {
-				available = false
-				break
+				if booking.CourtUnitID == nil {
+					venueBooked = true
+					break
+				}
+				busy[*booking.CourtUnitID] = true
 			}
 		}
 
-		// 計算該時間段的價格
-		hours := slotDur.Hours()
-		price := hours * pricePerHour
+		var unitSlots []dto.UnitTimeSlot
+		availableUnits := 0
+		if perUnit {
+			unitSlots = make([]dto.UnitTimeSlot, 0, len(units))
+			for _, unit := range units {
+				unitAvailable := !venueBooked && !busy[unit.ID]
+				if unitAvailable {
+					availableUnits++
+				}
+				unitSlots = append(unitSlots, dto.UnitTimeSlot{
+					CourtUnitID: unit.ID,
+					Number:      unit.Number,
+					Available:   unitAvailable,
+				})
+			}
+		} else if !venueBooked && len(busy) == 0 {
+			availableUnits = 1
+		}
+
+		// 計算該時間段的價格，跨越規則邊界時按比例計價
+		breakdown := price(currentTime, slotEnd)
 
 		timeSlots = append(timeSlots, dto.TimeSlot{
-			StartTime: currentTime,
-			EndTime:   slotEnd,
-			Available: available,
-			Price:     price,
+			StartTime:      currentTime,
+			EndTime:        slotEnd,
+			Available:      availableUnits > 0,
+			Price:          breakdown.Total,
+			Segments:       breakdown.Segments,
+			AvailableUnits: availableUnits,
+			Units:          unitSlots,
 		})
 
 		currentTime = currentTime.Add(30 * time.Minute) // 每30分鐘一個時間段
@@ -580,3 +1346,32 @@ func (bu *BookingUsecase) generateTimeSlots(date time.Time, openTime, closeTime
 
 	return timeSlots
 }
+
+// publishBookingEvent 在事務中發布預訂事件
+func (bu *BookingUsecase) publishBookingEvent(tx *gorm.DB, eventType string, booking *models.Booking, oldStatus string) error {
+	if bu.eventBus == nil {
+		return nil
+	}
+
+	return bu.eventBus.Publish(tx, eventType, "booking", booking.ID, bookingEventPayload(booking, oldStatus))
+}
+
+// bookingEventPayload 以預訂目前的狀態建立事件內容
+func bookingEventPayload(booking *models.Booking, oldStatus string) services.BookingEventPayload {
+	return services.BookingEventPayload{
+		BookingID: booking.ID,
+		CourtID:   booking.CourtID,
+		UserID:    booking.UserID,
+		StartTime: booking.StartTime,
+		EndTime:   booking.EndTime,
+		Status:    booking.Status,
+		OldStatus: oldStatus,
+	}
+}
+
+// notifyEventBus 事務提交後提示事件派送
+func (bu *BookingUsecase) notifyEventBus() {
+	if bu.eventBus != nil {
+		bu.eventBus.Notify()
+	}
+}
@@ -0,0 +1,177 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"tennis-platform/backend/internal/apperror"
+	"tennis-platform/backend/internal/dto"
+	"tennis-platform/backend/internal/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// bookingTestStart 明天上午十點，在測試場地的營業時間及可預訂範圍內
+func bookingTestStart() time.Time {
+	tomorrow := time.Now().UTC().Add(24 * time.Hour)
+	return time.Date(tomorrow.Year(), tomorrow.Month(), tomorrow.Day(), 10, 0, 0, 0, time.UTC)
+}
+
+// TestBookingUsecase_SlotConflictsUnderParallelRequests 從多個 goroutine 同時預訂同一時段，驗證衝突檢查及球場分配。
+// SQLite 只有單一連線，請求實際上依序執行，SELECT ... FOR UPDATE 及排除約束由 TestBookingUsecase_SlotConflictsOnPostgres 涵蓋
+func TestBookingUsecase_SlotConflictsUnderParallelRequests(t *testing.T) {
+	db := setupBookingTestDB(t)
+	// SQLite 的 :memory: 數據庫每個連線各自獨立，限制為單一連線讓所有請求共用同一數據庫
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+
+	units := NewCourtUnitUsecase(db)
+	bookings := NewBookingUsecase(db, nil)
+	ctx := context.Background()
+	courtID := "66666666-6666-6666-6666-666666666666"
+	start := bookingTestStart()
+
+	fire := func(n int, startTime time.Time) (created []*models.Booking, taken int) {
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		for i := 0; i < n; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				userID := fmt.Sprintf("00000000-0000-0000-0000-%012d", i)
+				booking, err := bookings.CreateBooking(userID, &dto.CreateBookingRequest{
+					CourtID:   courtID,
+					StartTime: startTime,
+					EndTime:   startTime.Add(time.Hour),
+				})
+
+				mu.Lock()
+				defer mu.Unlock()
+				if err != nil {
+					assert.True(t, apperror.HasCode(err, apperror.CodeBookingSlotTaken), "unexpected error: %v", err)
+					taken++
+					return
+				}
+				created = append(created, booking)
+			}(i)
+		}
+		wg.Wait()
+		return created, taken
+	}
+
+	// 未設置球場時同一時段只有一筆預訂成功
+	created, taken := fire(10, start)
+	assert.Len(t, created, 1)
+	assert.Equal(t, 9, taken)
+
+	// 設置兩面球場時同一時段各有一筆預訂成功
+	first, err := units.CreateUnit(ctx, bookingTestOwnerID, courtID, &dto.CourtUnitRequest{Number: 1, Surface: "hard"})
+	require.NoError(t, err)
+	second, err := units.CreateUnit(ctx, bookingTestOwnerID, courtID, &dto.CourtUnitRequest{Number: 2, Surface: "hard"})
+	require.NoError(t, err)
+
+	later := start.Add(3 * time.Hour)
+	created, taken = fire(10, later)
+	require.Len(t, created, 2)
+	assert.Equal(t, 8, taken)
+	assigned := []string{*created[0].CourtUnitID, *created[1].CourtUnitID}
+	assert.ElementsMatch(t, []string{first.ID, second.ID}, assigned)
+
+	var count int64
+	require.NoError(t, db.Model(&models.Booking{}).Where("start_time = ?", later).Count(&count).Error)
+	assert.Equal(t, int64(2), count)
+}
+
+func TestBookingUsecase_SlotHolds(t *testing.T) {
+	db := setupBookingTestDB(t)
+	units := NewCourtUnitUsecase(db)
+	bookings := NewBookingUsecase(db, nil)
+	ctx := context.Background()
+	courtID := "66666666-6666-6666-6666-666666666666"
+	otherUserID := "77777777-7777-7777-7777-777777777777"
+	start := bookingTestStart()
+	end := start.Add(time.Hour)
+
+	unit, err := units.CreateUnit(ctx, bookingTestOwnerID, courtID, &dto.CourtUnitRequest{Number: 1, Surface: "hard"})
+	require.NoError(t, err)
+
+	hold, err := bookings.HoldSlot(bookingTestUserID, &dto.CreateSlotHoldRequest{CourtID: courtID, StartTime: start, EndTime: end})
+	require.NoError(t, err)
+	require.NotNil(t, hold.CourtUnitID)
+	assert.Equal(t, unit.ID, *hold.CourtUnitID)
+	assert.WithinDuration(t, time.Now().Add(DefaultSlotHoldDuration), hold.ExpiresAt, time.Minute)
+
+	// 保留期間其他用戶無法預訂或保留，並獲得替代時段
+	_, err = bookings.CreateBooking(otherUserID, &dto.CreateBookingRequest{CourtID: courtID, StartTime: start, EndTime: end})
+	require.True(t, apperror.HasCode(err, apperror.CodeBookingSlotTaken))
+	alternatives, ok := apperror.From(err).Extensions["alternatives"].([]dto.AlternativeSlot)
+	require.True(t, ok)
+	require.Len(t, alternatives, maxAlternativeSlots)
+	assert.Equal(t, time.Hour, absDuration(alternatives[0].StartTime.Sub(start)))
+	for _, alternative := range alternatives {
+		assert.False(t, alternative.StartTime.Before(end) && alternative.EndTime.After(start), "替代時段不應與保留重疊")
+		assert.Equal(t, time.Hour, alternative.EndTime.Sub(alternative.StartTime))
+	}
+
+	_, err = bookings.HoldSlot(otherUserID, &dto.CreateSlotHoldRequest{CourtID: courtID, StartTime: start.Add(30 * time.Minute), EndTime: end.Add(30 * time.Minute)})
+	assert.True(t, apperror.HasCode(err, apperror.CodeBookingSlotTaken))
+
+	availability, err := bookings.GetAvailability(&dto.AvailabilityRequest{CourtID: courtID, Date: start, Duration: 60})
+	require.NoError(t, err)
+	for _, slot := range availability.TimeSlots {
+		if slot.StartTime.Equal(start) {
+			assert.False(t, slot.Available)
+		}
+	}
+
+	// 預訂內容需與保留一致
+	_, err = bookings.CreateBooking(bookingTestUserID, &dto.CreateBookingRequest{CourtID: courtID, StartTime: start, EndTime: end.Add(30 * time.Minute), HoldID: &hold.ID})
+	assert.True(t, apperror.HasCode(err, apperror.CodeBookingHoldMismatch))
+	_, err = bookings.CreateBooking(otherUserID, &dto.CreateBookingRequest{CourtID: courtID, StartTime: start, EndTime: end, HoldID: &hold.ID})
+	assert.True(t, apperror.HasCode(err, apperror.CodeBookingHoldNotFound))
+
+	booking, err := bookings.CreateBooking(bookingTestUserID, &dto.CreateBookingRequest{CourtID: courtID, StartTime: start, EndTime: end, HoldID: &hold.ID})
+	require.NoError(t, err)
+	assert.Equal(t, hold.CourtUnitID, booking.CourtUnitID)
+
+	// 預訂成立後保留被刪除
+	_, err = bookings.CreateBooking(bookingTestUserID, &dto.CreateBookingRequest{CourtID: courtID, StartTime: start, EndTime: end, HoldID: &hold.ID})
+	assert.True(t, apperror.HasCode(err, apperror.CodeBookingHoldNotFound))
+
+	// 過期的保留不再佔用時段
+	next, err := bookings.HoldSlot(otherUserID, &dto.CreateSlotHoldRequest{CourtID: courtID, StartTime: end, EndTime: end.Add(time.Hour)})
+	require.NoError(t, err)
+	require.NoError(t, db.Model(&models.SlotHold{}).Where("id = ?", next.ID).Update("expires_at", time.Now().Add(-time.Minute)).Error)
+	_, err = bookings.HoldSlot(bookingTestUserID, &dto.CreateSlotHoldRequest{CourtID: courtID, StartTime: end, EndTime: end.Add(time.Hour)})
+	require.NoError(t, err)
+
+	err = bookings.ReleaseHold(otherUserID, next.ID)
+	assert.True(t, apperror.HasCode(err, apperror.CodeBookingHoldNotFound))
+}
+
+func TestBookingUsecase_UpdateBookingRejectsStatus(t *testing.T) {
+	db := setupBookingTestDB(t)
+	bookings := NewBookingUsecase(db, nil)
+	courtID := "66666666-6666-6666-6666-666666666666"
+	start := bookingTestStart()
+
+	booking, err := bookings.CreateBooking(bookingTestUserID, &dto.CreateBookingRequest{CourtID: courtID, StartTime: start, EndTime: start.Add(time.Hour)})
+	require.NoError(t, err)
+
+	// 未付款的預訂不能自行確認，也不能跳過取消政策直接取消
+	for _, status := range []string{"confirmed", "cancelled", "completed"} {
+		_, err = bookings.UpdateBooking(booking.ID, bookingTestUserID, &dto.UpdateBookingRequest{Status: stringPtr(status)}, nil)
+		assert.True(t, apperror.HasCode(err, apperror.CodeBookingStatusReadOnly), status)
+	}
+
+	notes := "帶球拍"
+	updated, err := bookings.UpdateBooking(booking.ID, bookingTestUserID, &dto.UpdateBookingRequest{Notes: &notes}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "pending", updated.Status)
+	require.NotNil(t, updated.Notes)
+	assert.Equal(t, notes, *updated.Notes)
+}
@@ -0,0 +1,468 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"tennis-platform/backend/internal/apperror"
+	"tennis-platform/backend/internal/dto"
+	"tennis-platform/backend/internal/models"
+	"tennis-platform/backend/internal/services"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DefaultWaitlistClaimDuration 候補者認領釋出時段的默認期限
+const DefaultWaitlistClaimDuration = 30 * time.Minute
+
+// 候補的處理參數
+const (
+	waitlistSlotStep     = 30 * time.Minute // 在候補時間範圍內尋找可提供時段的間隔
+	waitlistPollInterval = 30 * time.Second // 檢查逾期未認領候補的間隔
+	waitlistBatchSize    = 50               // 每次處理的逾期候補數量
+)
+
+// JoinWaitlist 加入已滿時段的候補
+//
+// 時間範圍內仍有可預訂的時段時拒絕加入，用戶應直接預訂。
+func (bu *BookingUsecase) JoinWaitlist(userID string, req *dto.JoinWaitlistRequest) (*models.BookingWaitlistEntry, error) {
+	if err := bu.validateBookingTime(req.StartTime, req.EndTime); err != nil {
+		return nil, err
+	}
+	duration := int(req.EndTime.Sub(req.StartTime) / time.Minute)
+	if req.Duration > 0 {
+		if req.Duration > duration {
+			return nil, apperror.New(apperror.CodeWaitlistInvalidDuration)
+		}
+		duration = req.Duration
+	}
+
+	var court models.Court
+	if err := bu.db.Where("id = ? AND deleted_at IS NULL AND is_active = true", req.CourtID).First(&court).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.New(apperror.CodeCourtUnavailable)
+		}
+		return nil, errors.New("查詢場地失敗")
+	}
+
+	if err := bu.checkOperatingHours(bu.db, &court, req.StartTime, req.EndTime); err != nil {
+		return nil, err
+	}
+
+	if req.CourtUnitID != nil {
+		var unit models.CourtUnit
+		if err := bu.db.Where("id = ? AND court_id = ?", *req.CourtUnitID, req.CourtID).First(&unit).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, apperror.New(apperror.CodeCourtUnitNotFound)
+			}
+			return nil, errors.New("查詢球場失敗")
+		}
+	}
+
+	entry := models.BookingWaitlistEntry{
+		CourtID:     req.CourtID,
+		CourtUnitID: req.CourtUnitID,
+		UserID:      userID,
+		StartTime:   req.StartTime,
+		EndTime:     req.EndTime,
+		Duration:    duration,
+		Status:      models.WaitlistStatusWaiting,
+	}
+	err := bu.withCourtLock(req.CourtID, func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.BookingWaitlistEntry{}).
+			Where("court_id = ? AND user_id = ? AND status IN ? AND start_time < ? AND end_time > ?",
+				req.CourtID, userID, []string{models.WaitlistStatusWaiting, models.WaitlistStatusOffered}, req.EndTime, req.StartTime).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return apperror.New(apperror.CodeWaitlistDuplicate)
+		}
+
+		if _, _, _, err := bu.findWaitlistSlot(tx, &court, &entry, time.Now()); err == nil {
+			return apperror.New(apperror.CodeWaitlistSlotAvailable)
+		} else if !apperror.HasCode(err, apperror.CodeBookingSlotTaken) {
+			return err
+		}
+
+		return tx.Create(&entry).Error
+	})
+	if err != nil {
+		var appErr *apperror.Error
+		if errors.As(err, &appErr) {
+			return nil, err
+		}
+		return nil, errors.New("加入候補失敗")
+	}
+
+	return &entry, nil
+}
+
+// GetWaitlist 獲取用戶的候補，默認只列出等待中及已提供時段的候補
+func (bu *BookingUsecase) GetWaitlist(userID string, req *dto.WaitlistListRequest) ([]models.BookingWaitlistEntry, error) {
+	query := bu.db.Preload("Court").Preload("CourtUnit").Where("user_id = ?", userID)
+	if req.Status != nil {
+		query = query.Where("status = ?", *req.Status)
+	} else {
+		query = query.Where("status IN ?", []string{models.WaitlistStatusWaiting, models.WaitlistStatusOffered})
+	}
+
+	var entries []models.BookingWaitlistEntry
+	if err := query.Order("start_time ASC, created_at ASC").Find(&entries).Error; err != nil {
+		return nil, errors.New("獲取候補失敗")
+	}
+	return entries, nil
+}
+
+// GetWaitlistEntry 獲取用戶的候補
+func (bu *BookingUsecase) GetWaitlistEntry(entryID, userID string) (*models.BookingWaitlistEntry, error) {
+	entry, err := bu.getOwnedWaitlistEntry(entryID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := bu.db.Preload("Court").Preload("CourtUnit").First(entry, "id = ?", entry.ID).Error; err != nil {
+		return nil, errors.New("載入候補數據失敗")
+	}
+	return entry, nil
+}
+
+// LeaveWaitlist 退出候補，已提供的時段改為提供給下一位候補者
+func (bu *BookingUsecase) LeaveWaitlist(entryID, userID string) error {
+	entry, err := bu.getOwnedWaitlistEntry(entryID, userID)
+	if err != nil {
+		return err
+	}
+
+	if entry.Status == models.WaitlistStatusWaiting {
+		result := bu.db.Model(entry).Where("status = ?", models.WaitlistStatusWaiting).
+			Update("status", models.WaitlistStatusCancelled)
+		if result.Error != nil {
+			return errors.New("退出候補失敗")
+		}
+		if result.RowsAffected > 0 {
+			return nil
+		}
+
+		// 期間已提供時段，重新載入後釋出提供的時段
+		if entry, err = bu.getOwnedWaitlistEntry(entryID, userID); err != nil {
+			return err
+		}
+	}
+	if entry.Status != models.WaitlistStatusOffered {
+		return apperror.New(apperror.CodeWaitlistClosed)
+	}
+
+	if _, err := bu.releaseWaitlistOffer(entry, models.WaitlistStatusCancelled); err != nil {
+		return errors.New("退出候補失敗")
+	}
+	bu.notifyEventBus()
+	return nil
+}
+
+// ClaimWaitlistOffer 認領提供給候補的時段，以保留創建預訂
+func (bu *BookingUsecase) ClaimWaitlistOffer(entryID, userID string) (*models.Booking, error) {
+	entry, err := bu.getOwnedWaitlistEntry(entryID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case entry.Status == models.WaitlistStatusExpired:
+		return nil, apperror.New(apperror.CodeWaitlistOfferExpired)
+	case entry.Status == models.WaitlistStatusClaimed || entry.Status == models.WaitlistStatusCancelled:
+		return nil, apperror.New(apperror.CodeWaitlistClosed)
+	case entry.Status != models.WaitlistStatusOffered || entry.HoldID == nil:
+		return nil, apperror.New(apperror.CodeWaitlistNotOffered)
+	case entry.OfferExpiresAt != nil && !entry.OfferExpiresAt.After(time.Now()):
+		return nil, apperror.New(apperror.CodeWaitlistOfferExpired)
+	}
+
+	var hold models.SlotHold
+	if err := bu.db.Where("id = ?", *entry.HoldID).First(&hold).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.New(apperror.CodeWaitlistOfferExpired)
+		}
+		return nil, errors.New("獲取保留失敗")
+	}
+
+	// 預訂與候補的認領在同一事務中完成，見 CreateBooking
+	booking, err := bu.CreateBooking(userID, &dto.CreateBookingRequest{
+		CourtID:     hold.CourtID,
+		CourtUnitID: hold.CourtUnitID,
+		StartTime:   hold.StartTime,
+		EndTime:     hold.EndTime,
+		HoldID:      &hold.ID,
+	})
+	if apperror.HasCode(err, apperror.CodeBookingHoldNotFound) {
+		return nil, apperror.New(apperror.CodeWaitlistOfferExpired)
+	}
+	return booking, err
+}
+
+// StartWaitlist 定期將逾期未認領的時段提供給下一位候補者，直到 ctx 結束
+func (bu *BookingUsecase) StartWaitlist(ctx context.Context) {
+	ticker := time.NewTicker(waitlistPollInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := bu.ExpireWaitlistOffers(ctx); err != nil {
+			log.Printf("Waitlist expiry error: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ExpireWaitlistOffers 結束逾期未認領的提供並改為提供給下一位候補者，
+// 同時結束時間範圍已過的候補，返回本次處理數量
+func (bu *BookingUsecase) ExpireWaitlistOffers(ctx context.Context) (int, error) {
+	now := time.Now()
+
+	result := bu.db.WithContext(ctx).Model(&models.BookingWaitlistEntry{}).
+		Where("status = ? AND end_time <= ?", models.WaitlistStatusWaiting, now).
+		Update("status", models.WaitlistStatusExpired)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	processed := int(result.RowsAffected)
+
+	var entries []models.BookingWaitlistEntry
+	if err := bu.db.WithContext(ctx).
+		Where("status = ? AND offer_expires_at <= ?", models.WaitlistStatusOffered, now).
+		Order("offer_expires_at ASC").
+		Limit(waitlistBatchSize).
+		Find(&entries).Error; err != nil {
+		return processed, err
+	}
+
+	for i := range entries {
+		released, err := bu.releaseWaitlistOffer(&entries[i], models.WaitlistStatusExpired)
+		if err != nil {
+			log.Printf("Failed to expire waitlist offer %s: %v", entries[i].ID, err)
+			continue
+		}
+		if released {
+			processed++
+		}
+	}
+	bu.notifyEventBus()
+
+	return processed, nil
+}
+
+// offerWaitlist 時段釋出後依加入順序提供給時間範圍重疊的候補者
+// 提供失敗只記錄日誌，不影響釋出時段的操作
+func (bu *BookingUsecase) offerWaitlist(courtID string, startTime, endTime time.Time) {
+	if err := bu.offerReleasedSlot(courtID, startTime, endTime); err != nil {
+		log.Printf("Failed to offer freed slot on court %s to waitlist: %v", courtID, err)
+	}
+}
+
+// offerReleasedSlot 鎖定場地後把釋出的時段提供給候補者
+//
+// 已提供的時段由保留佔用，同一時段重複提供不會產生新的提供，因此可由取消操作及取消事件各調用一次。
+func (bu *BookingUsecase) offerReleasedSlot(courtID string, startTime, endTime time.Time) error {
+	err := bu.withCourtLock(courtID, func(tx *gorm.DB) error {
+		return bu.offerFreedSlot(tx, courtID, startTime, endTime, time.Now())
+	})
+	if err != nil {
+		return err
+	}
+	bu.notifyEventBus()
+	return nil
+}
+
+// RegisterWaitlistSubscribers 註冊候補相關的事件訂閱者
+//
+// 預訂在其他服務中取消時（例如逾期未付款），由取消事件把釋出的時段提供給候補者。
+func (bu *BookingUsecase) RegisterWaitlistSubscribers(bus *services.EventBus) {
+	bus.Subscribe(services.EventBookingCancelled, "waitlist", func(ctx context.Context, event *services.DomainEvent) error {
+		var payload services.BookingEventPayload
+		if err := event.Decode(&payload); err != nil {
+			return fmt.Errorf("failed to decode event payload: %w", err)
+		}
+		return bu.offerReleasedSlot(payload.CourtID, payload.StartTime, payload.EndTime)
+	})
+}
+
+// releaseWaitlistOffer 將已提供時段的候補轉為 status 並刪除保留，再把時段提供給下一位候補者
+// 候補已不是提供中（例如同時被認領）時返回 false
+func (bu *BookingUsecase) releaseWaitlistOffer(entry *models.BookingWaitlistEntry, status string) (bool, error) {
+	released := false
+	err := bu.withCourtLock(entry.CourtID, func(tx *gorm.DB) error {
+		result := tx.Model(entry).Where("status = ?", models.WaitlistStatusOffered).Update("status", status)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		released = true
+
+		if entry.HoldID != nil {
+			if err := tx.Where("id = ?", *entry.HoldID).Delete(&models.SlotHold{}).Error; err != nil {
+				return err
+			}
+		}
+		if entry.OfferStartTime == nil || entry.OfferEndTime == nil {
+			return nil
+		}
+		return bu.offerFreedSlot(tx, entry.CourtID, *entry.OfferStartTime, *entry.OfferEndTime, time.Now())
+	})
+	return released, err
+}
+
+// offerFreedSlot 在鎖定場地的事務中，把釋出的時段依加入順序提供給時間範圍重疊的候補者
+//
+// 每位候補者獲得時間範圍內最早的可預訂時段並以保留佔用，之後的候補者不會獲得重疊的時段。
+func (bu *BookingUsecase) offerFreedSlot(tx *gorm.DB, courtID string, startTime, endTime, now time.Time) error {
+	var court models.Court
+	if err := tx.Where("id = ? AND deleted_at IS NULL AND is_active = true", courtID).First(&court).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	var entries []models.BookingWaitlistEntry
+	if err := tx.Where("court_id = ? AND status = ? AND start_time < ? AND end_time > ? AND end_time > ?",
+		courtID, models.WaitlistStatusWaiting, endTime, startTime, now).
+		Order("created_at ASC, id ASC").
+		Find(&entries).Error; err != nil {
+		return err
+	}
+
+	for i := range entries {
+		if err := bu.offerWaitlistEntry(tx, &court, &entries[i], now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// offerWaitlistEntry 為候補者保留時間範圍內最早的可預訂時段，沒有可預訂時段時不處理
+// 認領期限不晚於時段的開始時間
+func (bu *BookingUsecase) offerWaitlistEntry(tx *gorm.DB, court *models.Court, entry *models.BookingWaitlistEntry, now time.Time) error {
+	startTime, endTime, courtUnitID, err := bu.findWaitlistSlot(tx, court, entry, now)
+	if apperror.HasCode(err, apperror.CodeBookingSlotTaken) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	expiresAt := now.Add(bu.waitlistClaim)
+	if expiresAt.After(startTime) {
+		expiresAt = startTime
+	}
+
+	hold := models.SlotHold{
+		CourtID:     court.ID,
+		CourtUnitID: courtUnitID,
+		UserID:      entry.UserID,
+		StartTime:   startTime,
+		EndTime:     endTime,
+		ExpiresAt:   expiresAt,
+	}
+	if err := tx.Create(&hold).Error; err != nil {
+		return err
+	}
+
+	result := tx.Model(entry).Where("status = ?", models.WaitlistStatusWaiting).Updates(map[string]interface{}{
+		"status":           models.WaitlistStatusOffered,
+		"hold_id":          hold.ID,
+		"offer_start_time": startTime,
+		"offer_end_time":   endTime,
+		"offered_at":       now,
+		"offer_expires_at": expiresAt,
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return tx.Delete(&hold).Error
+	}
+
+	entry.Status = models.WaitlistStatusOffered
+	entry.HoldID = &hold.ID
+	entry.OfferStartTime = &startTime
+	entry.OfferEndTime = &endTime
+	entry.OfferedAt = &now
+	entry.OfferExpiresAt = &expiresAt
+	return bu.publishWaitlistEvent(tx, services.EventWaitlistOffered, entry)
+}
+
+// findWaitlistSlot 在候補的時間範圍內以 waitlistSlotStep 為間隔，找出最早可預訂的時段及球場
+// 沒有可預訂的時段時返回時段已被預訂
+func (bu *BookingUsecase) findWaitlistSlot(tx *gorm.DB, court *models.Court, entry *models.BookingWaitlistEntry, now time.Time) (time.Time, time.Time, *string, error) {
+	duration := time.Duration(entry.Duration) * time.Minute
+	for startTime := entry.StartTime; !startTime.Add(duration).After(entry.EndTime); startTime = startTime.Add(waitlistSlotStep) {
+		if startTime.Before(now) {
+			continue
+		}
+		endTime := startTime.Add(duration)
+		if err := bu.checkOperatingHours(tx, court, startTime, endTime); err != nil {
+			continue
+		}
+
+		courtUnitID, err := bu.assignCourtUnit(tx, entry.UserID, court.ID, entry.CourtUnitID, startTime, endTime)
+		if err == nil {
+			return startTime, endTime, courtUnitID, nil
+		}
+		if !apperror.HasCode(err, apperror.CodeBookingSlotTaken) && !apperror.HasCode(err, apperror.CodeCourtUnitUnavailable) {
+			return time.Time{}, time.Time{}, nil, err
+		}
+	}
+	return time.Time{}, time.Time{}, nil, apperror.New(apperror.CodeBookingSlotTaken)
+}
+
+// claimWaitlistOffer 以保留創建預訂時，將提供該保留的候補標記為已認領
+func claimWaitlistOffer(tx *gorm.DB, holdID, bookingID string) error {
+	return tx.Model(&models.BookingWaitlistEntry{}).
+		Where("hold_id = ? AND status = ?", holdID, models.WaitlistStatusOffered).
+		Updates(map[string]interface{}{
+			"status":     models.WaitlistStatusClaimed,
+			"booking_id": bookingID,
+		}).Error
+}
+
+// getOwnedWaitlistEntry 獲取用戶的候補
+func (bu *BookingUsecase) getOwnedWaitlistEntry(entryID, userID string) (*models.BookingWaitlistEntry, error) {
+	var entry models.BookingWaitlistEntry
+	if err := bu.db.Where("id = ?", entryID).First(&entry).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.New(apperror.CodeWaitlistNotFound)
+		}
+		return nil, errors.New("獲取候補失敗")
+	}
+	if entry.UserID != userID {
+		return nil, apperror.New(apperror.CodeWaitlistForbidden)
+	}
+	return &entry, nil
+}
+
+// publishWaitlistEvent 在事務中發布候補事件
+func (bu *BookingUsecase) publishWaitlistEvent(tx *gorm.DB, eventType string, entry *models.BookingWaitlistEntry) error {
+	if bu.eventBus == nil {
+		return nil
+	}
+
+	payload := services.WaitlistEventPayload{
+		EntryID:   entry.ID,
+		CourtID:   entry.CourtID,
+		UserID:    entry.UserID,
+		StartTime: *entry.OfferStartTime,
+		EndTime:   *entry.OfferEndTime,
+		ExpiresAt: *entry.OfferExpiresAt,
+	}
+
+	return bu.eventBus.Publish(tx, eventType, "booking_waitlist_entry", entry.ID, payload)
+}
@@ -0,0 +1,194 @@
+package usecases
+
+import (
+	"context"
+	"tennis-platform/backend/internal/apperror"
+	"tennis-platform/backend/internal/dto"
+	"tennis-platform/backend/internal/models"
+	"tennis-platform/backend/internal/services"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBookingUsecase_Waitlist(t *testing.T) {
+	db := setupBookingTestDB(t)
+	bookings := NewBookingUsecase(db, nil)
+	ctx := context.Background()
+	courtID := "66666666-6666-6666-6666-666666666666"
+	firstUserID := "77777777-7777-7777-7777-777777777777"
+	secondUserID := "88888888-8888-8888-8888-888888888888"
+	start := bookingTestStart()
+	end := start.Add(time.Hour)
+
+	booking, err := bookings.CreateBooking(bookingTestUserID, &dto.CreateBookingRequest{CourtID: courtID, StartTime: start, EndTime: end})
+	require.NoError(t, err)
+
+	// 時間範圍內仍有空閒時段時應直接預訂
+	_, err = bookings.JoinWaitlist(firstUserID, &dto.JoinWaitlistRequest{CourtID: courtID, StartTime: start, EndTime: end.Add(time.Hour), Duration: 60})
+	assert.True(t, apperror.HasCode(err, apperror.CodeWaitlistSlotAvailable))
+	_, err = bookings.JoinWaitlist(firstUserID, &dto.JoinWaitlistRequest{CourtID: courtID, StartTime: start, EndTime: end, Duration: 90})
+	assert.True(t, apperror.HasCode(err, apperror.CodeWaitlistInvalidDuration))
+
+	first, err := bookings.JoinWaitlist(firstUserID, &dto.JoinWaitlistRequest{CourtID: courtID, StartTime: start, EndTime: end})
+	require.NoError(t, err)
+	assert.Equal(t, models.WaitlistStatusWaiting, first.Status)
+	assert.Equal(t, 60, first.Duration)
+	_, err = bookings.JoinWaitlist(firstUserID, &dto.JoinWaitlistRequest{CourtID: courtID, StartTime: start, EndTime: end})
+	assert.True(t, apperror.HasCode(err, apperror.CodeWaitlistDuplicate))
+	second, err := bookings.JoinWaitlist(secondUserID, &dto.JoinWaitlistRequest{CourtID: courtID, StartTime: start, EndTime: end})
+	require.NoError(t, err)
+
+	_, err = bookings.ClaimWaitlistOffer(first.ID, firstUserID)
+	assert.True(t, apperror.HasCode(err, apperror.CodeWaitlistNotOffered))
+	_, err = bookings.GetWaitlistEntry(first.ID, secondUserID)
+	assert.True(t, apperror.HasCode(err, apperror.CodeWaitlistForbidden))
+
+	// 取消後依加入順序提供給第一位候補者，並以保留佔用時段
+	_, err = bookings.CancelBooking(booking.ID, bookingTestUserID, &dto.CancelBookingRequest{})
+	require.NoError(t, err)
+
+	first, err = bookings.GetWaitlistEntry(first.ID, firstUserID)
+	require.NoError(t, err)
+	require.Equal(t, models.WaitlistStatusOffered, first.Status)
+	require.NotNil(t, first.HoldID)
+	assert.True(t, first.OfferStartTime.Equal(start))
+	assert.WithinDuration(t, time.Now().Add(DefaultWaitlistClaimDuration), *first.OfferExpiresAt, time.Minute)
+
+	second, err = bookings.GetWaitlistEntry(second.ID, secondUserID)
+	require.NoError(t, err)
+	assert.Equal(t, models.WaitlistStatusWaiting, second.Status)
+
+	_, err = bookings.CreateBooking(secondUserID, &dto.CreateBookingRequest{CourtID: courtID, StartTime: start, EndTime: end})
+	assert.True(t, apperror.HasCode(err, apperror.CodeBookingSlotTaken))
+
+	// 逾期未認領時改為提供給下一位候補者
+	past := time.Now().Add(-time.Minute)
+	require.NoError(t, db.Model(&models.BookingWaitlistEntry{}).Where("id = ?", first.ID).Update("offer_expires_at", past).Error)
+	require.NoError(t, db.Model(&models.SlotHold{}).Where("id = ?", *first.HoldID).Update("expires_at", past).Error)
+	processed, err := bookings.ExpireWaitlistOffers(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, processed)
+
+	_, err = bookings.ClaimWaitlistOffer(first.ID, firstUserID)
+	assert.True(t, apperror.HasCode(err, apperror.CodeWaitlistOfferExpired))
+
+	second, err = bookings.GetWaitlistEntry(second.ID, secondUserID)
+	require.NoError(t, err)
+	require.Equal(t, models.WaitlistStatusOffered, second.Status)
+
+	claimed, err := bookings.ClaimWaitlistOffer(second.ID, secondUserID)
+	require.NoError(t, err)
+	assert.Equal(t, secondUserID, claimed.UserID)
+	assert.True(t, claimed.StartTime.Equal(start))
+
+	second, err = bookings.GetWaitlistEntry(second.ID, secondUserID)
+	require.NoError(t, err)
+	assert.Equal(t, models.WaitlistStatusClaimed, second.Status)
+	require.NotNil(t, second.BookingID)
+	assert.Equal(t, claimed.ID, *second.BookingID)
+
+	err = bookings.LeaveWaitlist(second.ID, secondUserID)
+	assert.True(t, apperror.HasCode(err, apperror.CodeWaitlistClosed))
+
+	var holds int64
+	require.NoError(t, db.Model(&models.SlotHold{}).Count(&holds).Error)
+	assert.Equal(t, int64(0), holds)
+}
+
+func TestBookingUsecase_LeaveWaitlistOffer(t *testing.T) {
+	db := setupBookingTestDB(t)
+	bookings := NewBookingUsecase(db, nil)
+	courtID := "66666666-6666-6666-6666-666666666666"
+	firstUserID := "77777777-7777-7777-7777-777777777777"
+	secondUserID := "88888888-8888-8888-8888-888888888888"
+	start := bookingTestStart()
+	end := start.Add(2 * time.Hour)
+
+	booking, err := bookings.CreateBooking(bookingTestUserID, &dto.CreateBookingRequest{CourtID: courtID, StartTime: start, EndTime: end})
+	require.NoError(t, err)
+
+	// 候補時間範圍較長時提供範圍內最早釋出的時段
+	first, err := bookings.JoinWaitlist(firstUserID, &dto.JoinWaitlistRequest{CourtID: courtID, StartTime: start, EndTime: end, Duration: 60})
+	require.NoError(t, err)
+	second, err := bookings.JoinWaitlist(secondUserID, &dto.JoinWaitlistRequest{CourtID: courtID, StartTime: start, EndTime: end})
+	require.NoError(t, err)
+
+	_, err = bookings.CancelBooking(booking.ID, bookingTestUserID, &dto.CancelBookingRequest{})
+	require.NoError(t, err)
+
+	first, err = bookings.GetWaitlistEntry(first.ID, firstUserID)
+	require.NoError(t, err)
+	require.Equal(t, models.WaitlistStatusOffered, first.Status)
+	assert.True(t, first.OfferStartTime.Equal(start))
+	assert.True(t, first.OfferEndTime.Equal(start.Add(time.Hour)))
+
+	// 第一位的保留與第二位需要的兩小時重疊
+	second, err = bookings.GetWaitlistEntry(second.ID, secondUserID)
+	require.NoError(t, err)
+	assert.Equal(t, models.WaitlistStatusWaiting, second.Status)
+
+	// 退出後保留被刪除，時段改為提供給下一位
+	require.NoError(t, bookings.LeaveWaitlist(first.ID, firstUserID))
+	first, err = bookings.GetWaitlistEntry(first.ID, firstUserID)
+	require.NoError(t, err)
+	assert.Equal(t, models.WaitlistStatusCancelled, first.Status)
+
+	second, err = bookings.GetWaitlistEntry(second.ID, secondUserID)
+	require.NoError(t, err)
+	require.Equal(t, models.WaitlistStatusOffered, second.Status)
+	assert.True(t, second.OfferEndTime.Equal(end))
+
+	entries, err := bookings.GetWaitlist(secondUserID, &dto.WaitlistListRequest{})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, second.ID, entries[0].ID)
+}
+
+func TestBookingUsecase_WaitlistReleasedSlots(t *testing.T) {
+	db := setupBookingTestDB(t)
+	bus := services.NewEventBus(db)
+	bookings := NewBookingUsecase(db, bus)
+	bookings.UsePaymentHold(15 * time.Minute)
+	bookings.RegisterWaitlistSubscribers(bus)
+	payments := services.NewPaymentService(db, services.NewLocalPaymentProvider("secret"), bus, 15*time.Minute)
+	ctx := context.Background()
+	courtID := "66666666-6666-6666-6666-666666666666"
+	waitingUserID := "77777777-7777-7777-7777-777777777777"
+	start := bookingTestStart()
+	end := start.Add(time.Hour)
+
+	// 改期後原時段提供給候補者
+	booking, err := bookings.CreateBooking(bookingTestUserID, &dto.CreateBookingRequest{CourtID: courtID, StartTime: start, EndTime: end})
+	require.NoError(t, err)
+	entry, err := bookings.JoinWaitlist(waitingUserID, &dto.JoinWaitlistRequest{CourtID: courtID, StartTime: start, EndTime: end})
+	require.NoError(t, err)
+
+	newStart, newEnd := start.Add(3*time.Hour), end.Add(3*time.Hour)
+	_, err = bookings.UpdateBooking(booking.ID, bookingTestUserID, &dto.UpdateBookingRequest{StartTime: &newStart, EndTime: &newEnd}, nil)
+	require.NoError(t, err)
+	entry, err = bookings.GetWaitlistEntry(entry.ID, waitingUserID)
+	require.NoError(t, err)
+	require.Equal(t, models.WaitlistStatusOffered, entry.Status)
+	assert.True(t, entry.OfferStartTime.Equal(start))
+
+	// 逾期未付款而取消時，由取消事件把時段提供給候補者
+	entry, err = bookings.JoinWaitlist(waitingUserID, &dto.JoinWaitlistRequest{CourtID: courtID, StartTime: newStart, EndTime: newEnd})
+	require.NoError(t, err)
+	require.NoError(t, db.Model(&models.Booking{}).Where("id = ?", booking.ID).Update("payment_due_at", time.Now().Add(-time.Minute)).Error)
+	_, err = payments.ExpireDue(ctx)
+	require.NoError(t, err)
+
+	entry, err = bookings.GetWaitlistEntry(entry.ID, waitingUserID)
+	require.NoError(t, err)
+	assert.Equal(t, models.WaitlistStatusWaiting, entry.Status)
+
+	_, err = bus.DispatchPending(ctx)
+	require.NoError(t, err)
+	entry, err = bookings.GetWaitlistEntry(entry.ID, waitingUserID)
+	require.NoError(t, err)
+	require.Equal(t, models.WaitlistStatusOffered, entry.Status)
+	assert.True(t, entry.OfferStartTime.Equal(newStart))
+}
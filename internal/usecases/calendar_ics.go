@@ -0,0 +1,316 @@
+package usecases
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"tennis-platform/backend/internal/apperror"
+	"tennis-platform/backend/internal/dto"
+	"tennis-platform/backend/internal/ical"
+	"tennis-platform/backend/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	icalProdID = "-//Tennis Platform//Calendar//ZH"
+	// icalUIDDomain UID 的網域部分，同一項目改期或取消後 UID 不變
+	icalUIDDomain = "tennis-platform"
+	// feedPastDays、feedFutureDays 訂閱內容涵蓋的範圍
+	feedPastDays   = 30
+	feedFutureDays = 365
+	// feedRefreshInterval 建議客戶端的更新間隔
+	feedRefreshInterval = time.Hour
+)
+
+// GetCalendarFeed 獲取用戶的行事曆訂閱網址，尚未建立時自動建立
+func (cu *CalendarUsecase) GetCalendarFeed(ctx context.Context, userID string) (*dto.CalendarFeedResponse, error) {
+	var feed models.CalendarFeed
+	err := cu.db.WithContext(ctx).Where("user_id = ?", userID).First(&feed).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return cu.RotateCalendarFeed(ctx, userID)
+	}
+	if err != nil {
+		return nil, errors.New("獲取行事曆訂閱失敗")
+	}
+	return cu.feedResponse(&feed), nil
+}
+
+// RotateCalendarFeed 重新產生訂閱令牌，舊的訂閱網址立即失效
+func (cu *CalendarUsecase) RotateCalendarFeed(ctx context.Context, userID string) (*dto.CalendarFeedResponse, error) {
+	token, err := generateFeedToken()
+	if err != nil {
+		return nil, err
+	}
+
+	feed := models.CalendarFeed{UserID: userID, Token: token}
+	if err := cu.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"token", "created_at", "updated_at"}),
+	}).Create(&feed).Error; err != nil {
+		return nil, errors.New("建立行事曆訂閱失敗")
+	}
+	return cu.feedResponse(&feed), nil
+}
+
+// DeleteCalendarFeed 停用行事曆訂閱
+func (cu *CalendarUsecase) DeleteCalendarFeed(ctx context.Context, userID string) error {
+	if err := cu.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&models.CalendarFeed{}).Error; err != nil {
+		return errors.New("停用行事曆訂閱失敗")
+	}
+	return nil
+}
+
+// RenderCalendarFeed 以訂閱令牌產生用戶的 iCalendar 訂閱內容
+//
+// 包含過去 30 天至未來一年的預訂、課程及比賽；已取消的項目以 STATUS:CANCELLED 保留，
+// 讓已訂閱的客戶端移除對應的事件。
+func (cu *CalendarUsecase) RenderCalendarFeed(ctx context.Context, token string) ([]byte, error) {
+	var feed models.CalendarFeed
+	if err := cu.db.WithContext(ctx).Where("token = ?", token).First(&feed).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.New(apperror.CodeCalendarFeedNotFound)
+		}
+		return nil, errors.New("獲取行事曆訂閱失敗")
+	}
+
+	now := time.Now()
+	from, to := now.AddDate(0, 0, -feedPastDays), now.AddDate(0, 0, feedFutureDays)
+	db := cu.db.WithContext(ctx)
+
+	bookings, err := userBookings(db, feed.UserID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	lessons, err := userLessons(db, feed.UserID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	matches, participations, err := userMatches(db, feed.UserID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	calendar := &ical.Calendar{
+		ProdID:          icalProdID,
+		Name:            "網球行程",
+		RefreshInterval: feedRefreshInterval,
+		Events:          make([]ical.Event, 0, len(bookings)+len(lessons)+len(matches)),
+	}
+	for i := range bookings {
+		calendar.Events = append(calendar.Events, bookingEvent(&bookings[i]))
+	}
+	for i := range lessons {
+		calendar.Events = append(calendar.Events, lessonEvent(&lessons[i], feed.UserID))
+	}
+	for i := range matches {
+		calendar.Events = append(calendar.Events, matchEvent(&matches[i], participations[matches[i].ID]))
+	}
+
+	return calendar.Encode(), nil
+}
+
+// ExportCalendarItem 匯出單一預訂、課程或比賽的 .ics，只有相關用戶可以匯出
+func (cu *CalendarUsecase) ExportCalendarItem(ctx context.Context, userID, itemType, id string) ([]byte, error) {
+	db := cu.db.WithContext(ctx)
+	var event ical.Event
+
+	switch itemType {
+	case dto.CalendarItemBooking:
+		var booking models.Booking
+		if err := db.Preload("Court").Preload("CourtUnit").Where("id = ? AND user_id = ?", id, userID).First(&booking).Error; err != nil {
+			return nil, calendarItemError(err)
+		}
+		event = bookingEvent(&booking)
+
+	case dto.CalendarItemLesson:
+		var lesson models.Lesson
+		if err := db.Preload("Court").
+			Where("id = ?", id).
+			Where("(student_id = ? OR coach_id IN (?))", userID, db.Model(&models.Coach{}).Select("id").Where("user_id = ?", userID)).
+			First(&lesson).Error; err != nil {
+			return nil, calendarItemError(err)
+		}
+		event = lessonEvent(&lesson, userID)
+
+	case dto.CalendarItemMatch:
+		var participation models.MatchParticipant
+		if err := db.Where("match_id = ? AND user_id = ?", id, userID).First(&participation).Error; err != nil {
+			return nil, calendarItemError(err)
+		}
+		var match models.Match
+		if err := db.Preload("Court").Where("id = ? AND scheduled_at IS NOT NULL", id).First(&match).Error; err != nil {
+			return nil, calendarItemError(err)
+		}
+		event = matchEvent(&match, participation)
+
+	default:
+		return nil, apperror.New(apperror.CodeCalendarItemNotFound)
+	}
+
+	calendar := &ical.Calendar{ProdID: icalProdID, Events: []ical.Event{event}}
+	return calendar.Encode(), nil
+}
+
+func (cu *CalendarUsecase) feedResponse(feed *models.CalendarFeed) *dto.CalendarFeedResponse {
+	url := strings.TrimRight(cu.config.PublicURL, "/") + "/api/v1/calendar/feeds/" + feed.Token + ".ics"
+	webcal := url
+	if i := strings.Index(url, "://"); i >= 0 {
+		webcal = "webcal" + url[i:]
+	}
+	return &dto.CalendarFeedResponse{
+		URL:       url,
+		WebcalURL: webcal,
+		CreatedAt: feed.CreatedAt,
+	}
+}
+
+func calendarItemError(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return apperror.New(apperror.CodeCalendarItemNotFound)
+	}
+	return errors.New("獲取行程失敗")
+}
+
+// generateFeedToken 產生訂閱令牌
+func generateFeedToken() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("生成訂閱令牌失敗: %w", err)
+	}
+	return "cal_" + hex.EncodeToString(buf), nil
+}
+
+func icalUID(itemType, id string) string {
+	return itemType + "-" + id + "@" + icalUIDDomain
+}
+
+// courtLocation 場地名稱、地址及座標
+func courtLocation(court *models.Court) (string, *ical.Geo) {
+	if court == nil {
+		return "", nil
+	}
+	location := court.Name
+	if court.Address != "" {
+		location += ", " + court.Address
+	}
+	var geo *ical.Geo
+	if court.Latitude != 0 || court.Longitude != 0 {
+		geo = &ical.Geo{Latitude: court.Latitude, Longitude: court.Longitude}
+	}
+	return location, geo
+}
+
+// versionSequence 以樂觀鎖版本號作為 SEQUENCE，版本號從 1 開始，每次修改遞增
+func versionSequence(version int64) int {
+	if version < 1 {
+		return 0
+	}
+	return int(version - 1)
+}
+
+func bookingEvent(booking *models.Booking) ical.Event {
+	location, geo := courtLocation(booking.Court)
+	summary := "場地預訂"
+	if booking.Court != nil {
+		summary += " - " + booking.Court.Name
+	}
+	if booking.CourtUnit != nil {
+		summary += fmt.Sprintf(" %d號球場", booking.CourtUnit.Number)
+	}
+
+	status := ical.StatusConfirmed
+	switch booking.Status {
+	case "pending":
+		status = ical.StatusTentative
+	case "cancelled":
+		status = ical.StatusCancelled
+	}
+
+	event := ical.Event{
+		UID:          icalUID(dto.CalendarItemBooking, booking.ID),
+		Sequence:     versionSequence(booking.Version),
+		Stamp:        booking.UpdatedAt,
+		Start:        booking.StartTime,
+		End:          booking.EndTime,
+		Summary:      summary,
+		Location:     location,
+		Geo:          geo,
+		Status:       status,
+		LastModified: booking.UpdatedAt,
+	}
+	if booking.Notes != nil {
+		event.Description = *booking.Notes
+	}
+	return event
+}
+
+func lessonEvent(lesson *models.Lesson, userID string) ical.Event {
+	location, geo := courtLocation(lesson.Court)
+	summary := "網球課程：" + lessonTitle(lesson.Type)
+	if lessonRole(lesson, userID) == "coach" {
+		summary = "授課：" + lessonTitle(lesson.Type)
+	}
+
+	status := ical.StatusConfirmed
+	if lesson.Status == "cancelled" {
+		status = ical.StatusCancelled
+	}
+
+	event := ical.Event{
+		UID:          icalUID(dto.CalendarItemLesson, lesson.ID),
+		Sequence:     versionSequence(lesson.Version),
+		Stamp:        lesson.UpdatedAt,
+		Start:        lesson.ScheduledAt,
+		End:          lessonEnd(lesson),
+		Summary:      summary,
+		Location:     location,
+		Geo:          geo,
+		Status:       status,
+		LastModified: lesson.UpdatedAt,
+	}
+	if lesson.Notes != nil {
+		event.Description = *lesson.Notes
+	}
+	return event
+}
+
+// matchEvent 比賽沒有版本號，以距離建立時間的秒數作為遞增的 SEQUENCE
+func matchEvent(match *models.Match, participation models.MatchParticipant) ical.Event {
+	location, geo := courtLocation(match.Court)
+
+	status := ical.StatusConfirmed
+	switch {
+	case match.Status == "cancelled" || participation.Status == "declined":
+		status = ical.StatusCancelled
+	case match.Status == "pending" || participation.Status == "pending":
+		status = ical.StatusTentative
+	}
+
+	sequence := 0
+	if match.UpdatedAt.After(match.CreatedAt) {
+		sequence = int(match.UpdatedAt.Sub(match.CreatedAt) / time.Second)
+	}
+
+	event := ical.Event{
+		UID:          icalUID(dto.CalendarItemMatch, match.ID),
+		Sequence:     sequence,
+		Stamp:        match.UpdatedAt,
+		Start:        *match.ScheduledAt,
+		End:          matchEnd(match),
+		Summary:      "網球比賽：" + matchTitle(match.Type),
+		Location:     location,
+		Geo:          geo,
+		Status:       status,
+		LastModified: match.UpdatedAt,
+	}
+	if match.SpecialRequirements != nil {
+		event.Description = *match.SpecialRequirements
+	}
+	return event
+}
@@ -0,0 +1,94 @@
+package usecases
+
+import (
+	"context"
+	"strings"
+	"tennis-platform/backend/internal/apperror"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// unfoldICS 還原折行，方便比對內容
+func unfoldICS(data []byte) string {
+	return strings.ReplaceAll(string(data), "\r\n ", "")
+}
+
+func TestCalendarUsecase_Feed(t *testing.T) {
+	day := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 2)
+	db := setupCalendarTestDB(t, day)
+	uc := NewCalendarUsecase(db, calendarTestConfig)
+	ctx := context.Background()
+
+	feed, err := uc.GetCalendarFeed(ctx, calendarUserID)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(feed.URL, "https://api.example.com/api/v1/calendar/feeds/cal_"))
+	assert.True(t, strings.HasSuffix(feed.URL, ".ics"))
+	assert.Equal(t, "webcal://"+strings.TrimPrefix(feed.URL, "https://"), feed.WebcalURL)
+
+	again, err := uc.GetCalendarFeed(ctx, calendarUserID)
+	require.NoError(t, err)
+	assert.Equal(t, feed.URL, again.URL)
+
+	token := strings.TrimSuffix(feed.URL[strings.LastIndex(feed.URL, "/")+1:], ".ics")
+	data, err := uc.RenderCalendarFeed(ctx, token)
+	require.NoError(t, err)
+	output := unfoldICS(data)
+
+	assert.Contains(t, output, "UID:booking-booking-1@tennis-platform\r\nSEQUENCE:0\r\n")
+	assert.Contains(t, output, "LOCATION:大安網球場\\, 台北市大安區新生南路二段1號\r\n")
+	assert.Contains(t, output, "GEO:25.033000;121.565400\r\n")
+	assert.Contains(t, output, "SUMMARY:授課：團體課\r\n")
+	assert.Contains(t, output, "SUMMARY:網球課程：個人課\r\n")
+	// 已取消的預訂及已拒絕的比賽保留在訂閱中，讓客戶端移除
+	assert.Contains(t, output, "UID:booking-booking-2@tennis-platform")
+	assert.Equal(t, 2, strings.Count(output, "STATUS:CANCELLED"))
+	// 俱樂部活動不在訂閱範圍
+	assert.NotContains(t, output, "event-1")
+
+	// 改期後 UID 不變，SEQUENCE 遞增
+	newStart := day.Add(16 * time.Hour)
+	require.NoError(t, db.Exec("UPDATE bookings SET start_time = ?, end_time = ?, version = 2 WHERE id = 'booking-1'", newStart, newStart.Add(time.Hour)).Error)
+	data, err = uc.RenderCalendarFeed(ctx, token)
+	require.NoError(t, err)
+	assert.Contains(t, unfoldICS(data), "UID:booking-booking-1@tennis-platform\r\nSEQUENCE:1\r\n")
+	assert.Contains(t, unfoldICS(data), "DTSTART:"+newStart.Format("20060102T150405Z"))
+
+	// 輪換後舊網址失效
+	rotated, err := uc.RotateCalendarFeed(ctx, calendarUserID)
+	require.NoError(t, err)
+	assert.NotEqual(t, feed.URL, rotated.URL)
+	_, err = uc.RenderCalendarFeed(ctx, token)
+	assert.True(t, apperror.HasCode(err, apperror.CodeCalendarFeedNotFound))
+
+	require.NoError(t, uc.DeleteCalendarFeed(ctx, calendarUserID))
+	_, err = uc.RenderCalendarFeed(ctx, strings.TrimSuffix(rotated.URL[strings.LastIndex(rotated.URL, "/")+1:], ".ics"))
+	assert.True(t, apperror.HasCode(err, apperror.CodeCalendarFeedNotFound))
+}
+
+func TestCalendarUsecase_ExportCalendarItem(t *testing.T) {
+	day := time.Date(2030, 5, 4, 0, 0, 0, 0, time.UTC)
+	uc := NewCalendarUsecase(setupCalendarTestDB(t, day), calendarTestConfig)
+	ctx := context.Background()
+
+	data, err := uc.ExportCalendarItem(ctx, calendarUserID, "booking", "booking-1")
+	require.NoError(t, err)
+	assert.Equal(t, 1, strings.Count(string(data), "BEGIN:VEVENT"))
+	assert.Contains(t, string(data), "DTSTART:20300504T100000Z")
+
+	// 教練可以匯出自己授課的課程
+	data, err = uc.ExportCalendarItem(ctx, calendarUserID, "lesson", "lesson-2")
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "UID:lesson-lesson-2@tennis-platform")
+
+	data, err = uc.ExportCalendarItem(ctx, calendarUserID, "match", "match-1")
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "DTEND:20300504T110000Z")
+
+	for _, item := range [][2]string{{"booking", "booking-1"}, {"lesson", "lesson-1"}, {"match", "match-1"}} {
+		_, err := uc.ExportCalendarItem(ctx, calendarPrivateID, item[0], item[1])
+		assert.True(t, apperror.HasCode(err, apperror.CodeCalendarItemNotFound), item[0])
+	}
+}
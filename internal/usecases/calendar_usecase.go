@@ -0,0 +1,469 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"tennis-platform/backend/internal/apperror"
+	"tennis-platform/backend/internal/config"
+	"tennis-platform/backend/internal/dto"
+	"tennis-platform/backend/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// calendarDefaultDays 未指定結束時間時的查詢天數
+	calendarDefaultDays = 30
+	// calendarMaxDays 單次查詢的最大天數
+	calendarMaxDays = 92
+	// defaultMatchDuration 比賽未設定時長時的預估時長
+	defaultMatchDuration = 90 * time.Minute
+	// maxLessonLookback 課程及比賽以開始時間查詢，往前多取一天以涵蓋跨越查詢起點的項目
+	maxLessonLookback = 24 * time.Hour
+)
+
+var lessonTitles = map[string]string{
+	"individual": "個人課",
+	"group":      "團體課",
+	"clinic":     "訓練營",
+}
+
+var matchTitles = map[string]string{
+	"casual":     "休閒比賽",
+	"practice":   "練習賽",
+	"tournament": "錦標賽",
+}
+
+// CalendarUsecase 行事曆用例，整合預訂、課程、比賽及俱樂部活動
+type CalendarUsecase struct {
+	db     *gorm.DB
+	config *config.Config
+}
+
+// NewCalendarUsecase 創建新的行事曆用例
+func NewCalendarUsecase(db *gorm.DB, cfg *config.Config) *CalendarUsecase {
+	return &CalendarUsecase{
+		db:     db,
+		config: cfg,
+	}
+}
+
+// GetCalendar 獲取用戶在時間範圍內的行事曆，並標記時間重疊的項目
+func (cu *CalendarUsecase) GetCalendar(ctx context.Context, userID string, req *dto.CalendarRequest) (*dto.CalendarResponse, error) {
+	from, to, err := calendarRange(req.From, req.To)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := cu.collectItems(ctx, userID, from, to, req.IncludeCancelled)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(req.Types) > 0 {
+		wanted := make(map[string]bool, len(req.Types))
+		for _, t := range req.Types {
+			wanted[t] = true
+		}
+		filtered := items[:0]
+		for _, item := range items {
+			if wanted[item.Type] {
+				filtered = append(filtered, item)
+			}
+		}
+		items = filtered
+	}
+
+	overlapCount := markOverlaps(items)
+
+	return &dto.CalendarResponse{
+		From:         from,
+		To:           to,
+		Items:        items,
+		OverlapCount: overlapCount,
+	}, nil
+}
+
+// GetFreeBusy 獲取用戶的忙碌時段，只返回合併後的時段，不包含項目內容
+//
+// 未公開檔案的用戶只有本人可以查詢。
+func (cu *CalendarUsecase) GetFreeBusy(ctx context.Context, requesterID string, req *dto.FreeBusyRequest) (*dto.FreeBusyResponse, error) {
+	from, to, err := calendarRange(req.From, req.To)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []struct {
+		ID             string
+		ProfilePrivacy *string
+	}
+	if err := cu.db.WithContext(ctx).Table("users").
+		Select("users.id, user_profiles.profile_privacy").
+		Joins("LEFT JOIN user_profiles ON user_profiles.user_id = users.id").
+		Where("users.id IN ? AND users.deleted_at IS NULL", req.UserIDs).
+		Scan(&rows).Error; err != nil {
+		return nil, errors.New("查詢用戶失敗")
+	}
+
+	privacy := make(map[string]string, len(rows))
+	for _, row := range rows {
+		privacy[row.ID] = "public"
+		if row.ProfilePrivacy != nil {
+			privacy[row.ID] = *row.ProfilePrivacy
+		}
+	}
+
+	response := &dto.FreeBusyResponse{From: from, To: to, Users: []dto.UserFreeBusy{}}
+	seen := make(map[string]bool, len(req.UserIDs))
+	for _, userID := range req.UserIDs {
+		if seen[userID] {
+			continue
+		}
+		seen[userID] = true
+
+		level, ok := privacy[userID]
+		if !ok {
+			return nil, apperror.New(apperror.CodeUserNotFound)
+		}
+
+		entry := dto.UserFreeBusy{UserID: userID, Busy: []dto.BusyPeriod{}}
+		if level != "public" && userID != requesterID {
+			entry.Hidden = true
+			response.Users = append(response.Users, entry)
+			continue
+		}
+
+		items, err := cu.collectItems(ctx, userID, from, to, false)
+		if err != nil {
+			return nil, err
+		}
+		entry.Busy = mergeBusy(items, from, to)
+		response.Users = append(response.Users, entry)
+	}
+
+	return response, nil
+}
+
+// calendarRange 解析查詢範圍，默認從現在起 30 天
+func calendarRange(fromPtr, toPtr *time.Time) (time.Time, time.Time, error) {
+	from := time.Now().UTC().Truncate(time.Minute)
+	if fromPtr != nil {
+		from = fromPtr.UTC()
+	}
+	to := from.AddDate(0, 0, calendarDefaultDays)
+	if toPtr != nil {
+		to = toPtr.UTC()
+	}
+
+	if !to.After(from) {
+		return time.Time{}, time.Time{}, apperror.New(apperror.CodeCalendarInvalidRange)
+	}
+	if to.Sub(from) > calendarMaxDays*24*time.Hour {
+		return time.Time{}, time.Time{}, apperror.New(apperror.CodeCalendarRangeTooLong).With("days", calendarMaxDays)
+	}
+	return from, to, nil
+}
+
+// collectItems 收集用戶在時間範圍內的所有行事曆項目，依開始時間排序
+func (cu *CalendarUsecase) collectItems(ctx context.Context, userID string, from, to time.Time, includeCancelled bool) ([]dto.CalendarItem, error) {
+	db := cu.db.WithContext(ctx)
+	items := []dto.CalendarItem{}
+
+	// 場地預訂
+	bookings, err := userBookings(db, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	for _, booking := range bookings {
+		title := "場地預訂"
+		var location *string
+		if booking.Court != nil {
+			title = booking.Court.Name
+			location = &booking.Court.Name
+		}
+		courtID := booking.CourtID
+		items = append(items, dto.CalendarItem{
+			Type:      dto.CalendarItemBooking,
+			SourceID:  booking.ID,
+			Role:      "booker",
+			Title:     title,
+			Status:    booking.Status,
+			StartTime: booking.StartTime,
+			EndTime:   booking.EndTime,
+			CourtID:   &courtID,
+			Location:  location,
+			Cancelled: booking.Status == "cancelled",
+		})
+	}
+
+	// 課程（學生或教練）
+	lessons, err := userLessons(db, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	for _, lesson := range lessons {
+		var location *string
+		if lesson.Court != nil {
+			location = &lesson.Court.Name
+		}
+		items = append(items, dto.CalendarItem{
+			Type:      dto.CalendarItemLesson,
+			SourceID:  lesson.ID,
+			Role:      lessonRole(&lesson, userID),
+			Title:     lessonTitle(lesson.Type),
+			Status:    lesson.Status,
+			StartTime: lesson.ScheduledAt,
+			EndTime:   lessonEnd(&lesson),
+			CourtID:   lesson.CourtID,
+			Location:  location,
+			Cancelled: lesson.Status == "cancelled",
+		})
+	}
+
+	// 比賽，已拒絕的邀請不列入
+	matches, participations, err := userMatches(db, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	for _, match := range matches {
+		participation := participations[match.ID]
+		if participation.Status == "declined" {
+			continue
+		}
+		var location *string
+		if match.Court != nil {
+			location = &match.Court.Name
+		}
+		items = append(items, dto.CalendarItem{
+			Type:      dto.CalendarItemMatch,
+			SourceID:  match.ID,
+			Role:      participation.Role,
+			Title:     matchTitle(match.Type),
+			Status:    match.Status,
+			StartTime: *match.ScheduledAt,
+			EndTime:   matchEnd(&match),
+			CourtID:   match.CourtID,
+			Location:  location,
+			Cancelled: match.Status == "cancelled",
+		})
+	}
+
+	// 俱樂部活動
+	var registrations []models.ClubEventParticipant
+	if err := db.Where("user_id = ?", userID).Find(&registrations).Error; err != nil {
+		return nil, errors.New("獲取俱樂部活動失敗")
+	}
+	if len(registrations) > 0 {
+		statuses := make(map[string]string, len(registrations))
+		eventIDs := make([]string, 0, len(registrations))
+		for _, r := range registrations {
+			statuses[r.EventID] = r.Status
+			eventIDs = append(eventIDs, r.EventID)
+		}
+
+		var events []models.ClubEvent
+		if err := db.Where("id IN ? AND start_time < ? AND end_time > ?", eventIDs, to, from).
+			Find(&events).Error; err != nil {
+			return nil, errors.New("獲取俱樂部活動失敗")
+		}
+		for _, event := range events {
+			clubID := event.ClubID
+			items = append(items, dto.CalendarItem{
+				Type:      dto.CalendarItemClubEvent,
+				SourceID:  event.ID,
+				Role:      "participant",
+				Title:     event.Title,
+				Status:    event.Status,
+				StartTime: event.StartTime,
+				EndTime:   event.EndTime,
+				ClubID:    &clubID,
+				Location:  event.Location,
+				Cancelled: event.Status == "cancelled" || statuses[event.ID] == "cancelled",
+			})
+		}
+	}
+
+	result := items[:0]
+	for _, item := range items {
+		if item.Cancelled && !includeCancelled {
+			continue
+		}
+		item.ID = item.Type + ":" + item.SourceID
+		item.Overlaps = []string{}
+		result = append(result, item)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].StartTime.Equal(result[j].StartTime) {
+			return result[i].ID < result[j].ID
+		}
+		return result[i].StartTime.Before(result[j].StartTime)
+	})
+	return result, nil
+}
+
+// userBookings 獲取用戶在時間範圍內的場地預訂
+func userBookings(db *gorm.DB, userID string, from, to time.Time) ([]models.Booking, error) {
+	var bookings []models.Booking
+	if err := db.Preload("Court").Preload("CourtUnit").
+		Where("user_id = ? AND start_time < ? AND end_time > ?", userID, to, from).
+		Find(&bookings).Error; err != nil {
+		return nil, errors.New("獲取預訂失敗")
+	}
+	return bookings, nil
+}
+
+// userLessons 獲取用戶以學生或教練身份參與、在時間範圍內的課程
+func userLessons(db *gorm.DB, userID string, from, to time.Time) ([]models.Lesson, error) {
+	var lessons []models.Lesson
+	if err := db.Preload("Court").
+		Where("(student_id = ? OR coach_id IN (?))", userID, db.Model(&models.Coach{}).Select("id").Where("user_id = ?", userID)).
+		Where("scheduled_at < ? AND scheduled_at > ?", to, from.Add(-maxLessonLookback)).
+		Find(&lessons).Error; err != nil {
+		return nil, errors.New("獲取課程失敗")
+	}
+
+	result := lessons[:0]
+	for _, lesson := range lessons {
+		if lessonEnd(&lesson).After(from) {
+			result = append(result, lesson)
+		}
+	}
+	return result, nil
+}
+
+// userMatches 獲取用戶參與、在時間範圍內已排定時間的比賽，並以比賽 ID 返回用戶的參與記錄
+func userMatches(db *gorm.DB, userID string, from, to time.Time) ([]models.Match, map[string]models.MatchParticipant, error) {
+	var participants []models.MatchParticipant
+	if err := db.Where("user_id = ?", userID).Find(&participants).Error; err != nil {
+		return nil, nil, errors.New("獲取比賽失敗")
+	}
+	if len(participants) == 0 {
+		return nil, nil, nil
+	}
+
+	participations := make(map[string]models.MatchParticipant, len(participants))
+	matchIDs := make([]string, 0, len(participants))
+	for _, p := range participants {
+		participations[p.MatchID] = p
+		matchIDs = append(matchIDs, p.MatchID)
+	}
+
+	var matches []models.Match
+	if err := db.Preload("Court").
+		Where("id IN ? AND scheduled_at IS NOT NULL", matchIDs).
+		Where("scheduled_at < ? AND scheduled_at > ?", to, from.Add(-maxLessonLookback)).
+		Find(&matches).Error; err != nil {
+		return nil, nil, errors.New("獲取比賽失敗")
+	}
+
+	result := matches[:0]
+	for _, match := range matches {
+		if matchEnd(&match).After(from) {
+			result = append(result, match)
+		}
+	}
+	return result, participations, nil
+}
+
+func lessonEnd(lesson *models.Lesson) time.Time {
+	return lesson.ScheduledAt.Add(time.Duration(lesson.Duration) * time.Minute)
+}
+
+func lessonRole(lesson *models.Lesson, userID string) string {
+	if lesson.StudentID == userID {
+		return "student"
+	}
+	return "coach"
+}
+
+func lessonTitle(lessonType string) string {
+	if title, ok := lessonTitles[lessonType]; ok {
+		return title
+	}
+	return "網球課程"
+}
+
+// matchEnd 比賽結束時間，未設定時長時以 defaultMatchDuration 估算
+func matchEnd(match *models.Match) time.Time {
+	duration := defaultMatchDuration
+	if match.Duration != nil && *match.Duration > 0 {
+		duration = time.Duration(*match.Duration) * time.Minute
+	}
+	return match.ScheduledAt.Add(duration)
+}
+
+func matchTitle(matchType string) string {
+	if title, ok := matchTitles[matchType]; ok {
+		return title
+	}
+	return "比賽"
+}
+
+// markOverlaps 標記時間重疊的項目，返回有重疊的項目數
+//
+// 已取消的項目不參與比較；同一場地的預訂與課程或比賽視為該活動的場地預訂，不算衝突。
+func markOverlaps(items []dto.CalendarItem) int {
+	for i := range items {
+		if items[i].Cancelled {
+			continue
+		}
+		for j := i + 1; j < len(items); j++ {
+			if !items[j].StartTime.Before(items[i].EndTime) {
+				break
+			}
+			if items[j].Cancelled || isCourtReservation(items[i], items[j]) {
+				continue
+			}
+			items[i].Overlaps = append(items[i].Overlaps, items[j].ID)
+			items[j].Overlaps = append(items[j].Overlaps, items[i].ID)
+		}
+	}
+
+	count := 0
+	for _, item := range items {
+		if len(item.Overlaps) > 0 {
+			count++
+		}
+	}
+	return count
+}
+
+// isCourtReservation 判斷兩個項目是否為同一場地的預訂及其對應的課程或比賽
+func isCourtReservation(a, b dto.CalendarItem) bool {
+	if a.Type != dto.CalendarItemBooking {
+		a, b = b, a
+	}
+	if a.Type != dto.CalendarItemBooking || b.Type == dto.CalendarItemBooking || b.Type == dto.CalendarItemClubEvent {
+		return false
+	}
+	return a.CourtID != nil && b.CourtID != nil && *a.CourtID == *b.CourtID
+}
+
+// mergeBusy 將未取消的項目合併為忙碌時段，並裁切到查詢範圍內
+func mergeBusy(items []dto.CalendarItem, from, to time.Time) []dto.BusyPeriod {
+	busy := []dto.BusyPeriod{}
+	for _, item := range items {
+		if item.Cancelled {
+			continue
+		}
+		start, end := item.StartTime, item.EndTime
+		if start.Before(from) {
+			start = from
+		}
+		if end.After(to) {
+			end = to
+		}
+
+		if n := len(busy); n > 0 && !start.After(busy[n-1].End) {
+			if end.After(busy[n-1].End) {
+				busy[n-1].End = end
+			}
+			continue
+		}
+		busy = append(busy, dto.BusyPeriod{Start: start, End: end})
+	}
+	return busy
+}
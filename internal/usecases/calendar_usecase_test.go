@@ -0,0 +1,161 @@
+package usecases
+
+import (
+	"context"
+	"tennis-platform/backend/internal/apperror"
+	"tennis-platform/backend/internal/config"
+	"tennis-platform/backend/internal/dto"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+var calendarTestConfig = &config.Config{PublicURL: "https://api.example.com/"}
+
+const (
+	calendarUserID    = "11111111-1111-1111-1111-111111111111"
+	calendarPrivateID = "22222222-2222-2222-2222-222222222222"
+	calendarCourtID   = "33333333-3333-3333-3333-333333333333"
+)
+
+func setupCalendarTestDB(t *testing.T, day time.Time) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+
+	// 手動創建表結構，只包含行事曆需要的欄位
+	for _, stmt := range []string{
+		`CREATE TABLE users (id TEXT PRIMARY KEY, deleted_at DATETIME)`,
+		`CREATE TABLE user_profiles (user_id TEXT PRIMARY KEY, profile_privacy TEXT)`,
+		`CREATE TABLE courts (id TEXT PRIMARY KEY, name TEXT, address TEXT, latitude REAL, longitude REAL, deleted_at DATETIME)`,
+		`CREATE TABLE coaches (id TEXT PRIMARY KEY, user_id TEXT, deleted_at DATETIME)`,
+		`CREATE TABLE bookings (id TEXT PRIMARY KEY, court_id TEXT, user_id TEXT, start_time DATETIME, end_time DATETIME, status TEXT, notes TEXT, version INTEGER DEFAULT 1, created_at DATETIME, updated_at DATETIME, deleted_at DATETIME)`,
+		`CREATE TABLE lessons (id TEXT PRIMARY KEY, coach_id TEXT, student_id TEXT, court_id TEXT, type TEXT, duration INTEGER, scheduled_at DATETIME, status TEXT, notes TEXT, version INTEGER DEFAULT 1, created_at DATETIME, updated_at DATETIME, deleted_at DATETIME)`,
+		`CREATE TABLE matches (id TEXT PRIMARY KEY, type TEXT, status TEXT, court_id TEXT, scheduled_at DATETIME, duration INTEGER, special_requirements TEXT, created_at DATETIME, updated_at DATETIME, deleted_at DATETIME)`,
+		`CREATE TABLE match_participants (match_id TEXT, user_id TEXT, role TEXT, status TEXT)`,
+		`CREATE TABLE club_events (id TEXT PRIMARY KEY, club_id TEXT, title TEXT, start_time DATETIME, end_time DATETIME, location TEXT, status TEXT, deleted_at DATETIME)`,
+		`CREATE TABLE club_event_participants (id TEXT PRIMARY KEY, event_id TEXT, user_id TEXT, status TEXT, deleted_at DATETIME)`,
+		`CREATE TABLE calendar_feeds (user_id TEXT PRIMARY KEY, token TEXT UNIQUE NOT NULL, created_at DATETIME, updated_at DATETIME)`,
+	} {
+		require.NoError(t, db.Exec(stmt).Error)
+	}
+
+	at := func(hour, minute int) time.Time {
+		return day.Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)
+	}
+	exec := func(sql string, values ...interface{}) {
+		require.NoError(t, db.Exec(sql, values...).Error)
+	}
+
+	exec(`INSERT INTO users (id) VALUES (?), (?)`, calendarUserID, calendarPrivateID)
+	exec(`INSERT INTO user_profiles (user_id, profile_privacy) VALUES (?, 'public'), (?, 'private')`, calendarUserID, calendarPrivateID)
+	exec(`INSERT INTO courts (id, name, address, latitude, longitude) VALUES (?, '大安網球場', '台北市大安區新生南路二段1號', 25.0330, 121.5654)`, calendarCourtID)
+	exec(`INSERT INTO coaches (id, user_id) VALUES ('coach-1', ?)`, calendarUserID)
+
+	// 10:00-11:00 預訂場地打比賽，兩者為同一件事
+	exec(`INSERT INTO bookings (id, court_id, user_id, start_time, end_time, status, deleted_at) VALUES ('booking-1', ?, ?, ?, ?, 'confirmed', NULL)`, calendarCourtID, calendarUserID, at(10, 0), at(11, 0))
+	exec(`INSERT INTO matches (id, type, status, court_id, scheduled_at, duration, deleted_at) VALUES ('match-1', 'casual', 'confirmed', ?, ?, 60, NULL)`, calendarCourtID, at(10, 0))
+	exec(`INSERT INTO match_participants VALUES ('match-1', ?, 'organizer', 'accepted')`, calendarUserID)
+	// 10:30 上課，與比賽衝突
+	exec(`INSERT INTO lessons (id, coach_id, student_id, court_id, type, duration, scheduled_at, status, deleted_at) VALUES ('lesson-1', 'coach-9', ?, NULL, 'individual', 60, ?, 'scheduled', NULL)`, calendarUserID, at(10, 30))
+	// 14:00 以教練身份授課，同時段的預訂已取消
+	exec(`INSERT INTO lessons (id, coach_id, student_id, court_id, type, duration, scheduled_at, status, deleted_at) VALUES ('lesson-2', 'coach-1', 'student-1', NULL, 'group', 60, ?, 'scheduled', NULL)`, at(14, 0))
+	exec(`INSERT INTO bookings (id, court_id, user_id, start_time, end_time, status, deleted_at) VALUES ('booking-2', ?, ?, ?, ?, 'cancelled', NULL)`, calendarCourtID, calendarUserID, at(14, 0), at(15, 0))
+	// 已拒絕的比賽邀請
+	exec(`INSERT INTO matches (id, type, status, court_id, scheduled_at, duration, deleted_at) VALUES ('match-2', 'practice', 'pending', NULL, ?, NULL, NULL)`, at(14, 0))
+	exec(`INSERT INTO match_participants VALUES ('match-2', ?, 'player', 'declined')`, calendarUserID)
+	// 俱樂部活動，其中一個已取消報名
+	exec(`INSERT INTO club_events VALUES ('event-1', 'club-1', '週末聯誼賽', ?, ?, NULL, 'upcoming', NULL)`, at(18, 0), at(20, 0))
+	exec(`INSERT INTO club_events VALUES ('event-2', 'club-1', '新手訓練', ?, ?, NULL, 'upcoming', NULL)`, at(19, 0), at(21, 0))
+	exec(`INSERT INTO club_event_participants VALUES ('p-1', 'event-1', ?, 'registered', NULL)`, calendarUserID)
+	exec(`INSERT INTO club_event_participants VALUES ('p-2', 'event-2', ?, 'cancelled', NULL)`, calendarUserID)
+
+	return db
+}
+
+func TestCalendarUsecase_GetCalendar(t *testing.T) {
+	day := time.Date(2030, 5, 4, 0, 0, 0, 0, time.UTC)
+	uc := NewCalendarUsecase(setupCalendarTestDB(t, day), calendarTestConfig)
+	from, to := day, day.Add(24*time.Hour)
+
+	calendar, err := uc.GetCalendar(context.Background(), calendarUserID, &dto.CalendarRequest{From: &from, To: &to})
+	require.NoError(t, err)
+
+	ids := []string{}
+	overlaps := map[string][]string{}
+	for _, item := range calendar.Items {
+		ids = append(ids, item.ID)
+		overlaps[item.ID] = item.Overlaps
+	}
+	assert.Equal(t, []string{"booking:booking-1", "match:match-1", "lesson:lesson-1", "lesson:lesson-2", "club_event:event-1"}, ids)
+
+	// 同一場地的預訂與比賽不算衝突，課程與兩者都衝突
+	assert.Equal(t, []string{"lesson:lesson-1"}, overlaps["booking:booking-1"])
+	assert.Equal(t, []string{"lesson:lesson-1"}, overlaps["match:match-1"])
+	assert.ElementsMatch(t, []string{"booking:booking-1", "match:match-1"}, overlaps["lesson:lesson-1"])
+	assert.Empty(t, overlaps["lesson:lesson-2"])
+	assert.Equal(t, 3, calendar.OverlapCount)
+
+	assert.Equal(t, "coach", calendar.Items[3].Role)
+	assert.Equal(t, "student", calendar.Items[2].Role)
+	assert.Equal(t, "大安網球場", calendar.Items[0].Title)
+	assert.Equal(t, 11, calendar.Items[2].EndTime.Hour())
+
+	// 包含已取消的項目時不參與衝突判斷
+	calendar, err = uc.GetCalendar(context.Background(), calendarUserID, &dto.CalendarRequest{From: &from, To: &to, IncludeCancelled: true})
+	require.NoError(t, err)
+	assert.Len(t, calendar.Items, 7)
+	assert.Equal(t, 3, calendar.OverlapCount)
+
+	calendar, err = uc.GetCalendar(context.Background(), calendarUserID, &dto.CalendarRequest{From: &from, To: &to, Types: []string{"lesson"}})
+	require.NoError(t, err)
+	assert.Len(t, calendar.Items, 2)
+	assert.Equal(t, 0, calendar.OverlapCount)
+}
+
+func TestCalendarUsecase_InvalidRange(t *testing.T) {
+	uc := NewCalendarUsecase(nil, calendarTestConfig)
+	from := time.Date(2030, 5, 4, 0, 0, 0, 0, time.UTC)
+
+	_, err := uc.GetCalendar(context.Background(), calendarUserID, &dto.CalendarRequest{From: &from, To: &from})
+	assert.True(t, apperror.HasCode(err, apperror.CodeCalendarInvalidRange))
+
+	to := from.AddDate(0, 6, 0)
+	_, err = uc.GetCalendar(context.Background(), calendarUserID, &dto.CalendarRequest{From: &from, To: &to})
+	assert.True(t, apperror.HasCode(err, apperror.CodeCalendarRangeTooLong))
+}
+
+func TestCalendarUsecase_GetFreeBusy(t *testing.T) {
+	day := time.Date(2030, 5, 4, 0, 0, 0, 0, time.UTC)
+	uc := NewCalendarUsecase(setupCalendarTestDB(t, day), calendarTestConfig)
+	from, to := day.Add(10*time.Hour+15*time.Minute), day.Add(19*time.Hour)
+
+	result, err := uc.GetFreeBusy(context.Background(), "requester", &dto.FreeBusyRequest{
+		UserIDs: []string{calendarUserID, calendarPrivateID},
+		From:    &from,
+		To:      &to,
+	})
+	require.NoError(t, err)
+	require.Len(t, result.Users, 2)
+
+	// 重疊的項目合併，並裁切到查詢範圍
+	assert.Equal(t, []dto.BusyPeriod{
+		{Start: from, End: day.Add(11*time.Hour + 30*time.Minute)},
+		{Start: day.Add(14 * time.Hour), End: day.Add(15 * time.Hour)},
+		{Start: day.Add(18 * time.Hour), End: to},
+	}, result.Users[0].Busy)
+
+	assert.True(t, result.Users[1].Hidden)
+	assert.Empty(t, result.Users[1].Busy)
+
+	// 本人可以查詢自己的忙碌時段
+	result, err = uc.GetFreeBusy(context.Background(), calendarPrivateID, &dto.FreeBusyRequest{UserIDs: []string{calendarPrivateID}, From: &from, To: &to})
+	require.NoError(t, err)
+	assert.False(t, result.Users[0].Hidden)
+
+	_, err = uc.GetFreeBusy(context.Background(), "requester", &dto.FreeBusyRequest{UserIDs: []string{"44444444-4444-4444-4444-444444444444"}, From: &from, To: &to})
+	assert.True(t, apperror.HasCode(err, apperror.CodeUserNotFound))
+}
@@ -0,0 +1,265 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"tennis-platform/backend/internal/apperror"
+	"tennis-platform/backend/internal/dto"
+	"tennis-platform/backend/internal/models"
+	"tennis-platform/backend/internal/services"
+
+	"gorm.io/gorm"
+)
+
+// CancellationPolicyUsecase 取消政策用例，場地擁有者及教練管理自己的取消政策及天候、不可抗力例外
+type CancellationPolicyUsecase struct {
+	db            *gorm.DB
+	cancellations *services.CancellationService
+}
+
+// NewCancellationPolicyUsecase 創建新的取消政策用例
+func NewCancellationPolicyUsecase(db *gorm.DB, cancellations *services.CancellationService) *CancellationPolicyUsecase {
+	return &CancellationPolicyUsecase{
+		db:            db,
+		cancellations: cancellations,
+	}
+}
+
+// GetCourtPolicy 獲取場地的取消政策，未設置時返回默認政策
+func (cu *CancellationPolicyUsecase) GetCourtPolicy(ctx context.Context, courtID string) (*models.CancellationPolicy, error) {
+	var court models.Court
+	if err := cu.db.WithContext(ctx).Select("id").Where("id = ?", courtID).First(&court).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.New(apperror.CodeCourtNotFound)
+		}
+		return nil, errors.New("獲取場地失敗")
+	}
+	return cu.getPolicy(ctx, services.CancellationOwnerCourt, court.ID)
+}
+
+// GetCoachPolicy 獲取教練的取消政策，未設置時返回默認政策
+func (cu *CancellationPolicyUsecase) GetCoachPolicy(ctx context.Context, coachID string) (*models.CancellationPolicy, error) {
+	var coach models.Coach
+	if err := cu.db.WithContext(ctx).Select("id").Where("id = ?", coachID).First(&coach).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.New(apperror.CodeCoachNotFound)
+		}
+		return nil, errors.New("獲取教練信息失敗")
+	}
+	return cu.getPolicy(ctx, services.CancellationOwnerCoach, coach.ID)
+}
+
+// UpdateCourtPolicy 設置場地的取消政策
+func (cu *CancellationPolicyUsecase) UpdateCourtPolicy(ctx context.Context, userID, courtID string, req *dto.UpdateCancellationPolicyRequest) (*models.CancellationPolicy, error) {
+	ownerID, err := cu.ownedCourt(ctx, userID, courtID)
+	if err != nil {
+		return nil, err
+	}
+	return cu.savePolicy(ctx, services.CancellationOwnerCourt, ownerID, req)
+}
+
+// UpdateMyCoachPolicy 設置教練自己的取消政策
+func (cu *CancellationPolicyUsecase) UpdateMyCoachPolicy(ctx context.Context, userID string, req *dto.UpdateCancellationPolicyRequest) (*models.CancellationPolicy, error) {
+	ownerID, err := cu.coachIDForUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return cu.savePolicy(ctx, services.CancellationOwnerCoach, ownerID, req)
+}
+
+// DeleteCourtPolicy 刪除場地的取消政策，恢復使用默認政策
+func (cu *CancellationPolicyUsecase) DeleteCourtPolicy(ctx context.Context, userID, courtID string) (*models.CancellationPolicy, error) {
+	ownerID, err := cu.ownedCourt(ctx, userID, courtID)
+	if err != nil {
+		return nil, err
+	}
+	return cu.deletePolicy(ctx, services.CancellationOwnerCourt, ownerID)
+}
+
+// DeleteMyCoachPolicy 刪除教練自己的取消政策，恢復使用默認政策
+func (cu *CancellationPolicyUsecase) DeleteMyCoachPolicy(ctx context.Context, userID string) (*models.CancellationPolicy, error) {
+	ownerID, err := cu.coachIDForUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return cu.deletePolicy(ctx, services.CancellationOwnerCoach, ownerID)
+}
+
+// GetCourtOverrides 獲取場地的取消例外
+func (cu *CancellationPolicyUsecase) GetCourtOverrides(ctx context.Context, userID, courtID string) ([]models.CancellationOverride, error) {
+	ownerID, err := cu.ownedCourt(ctx, userID, courtID)
+	if err != nil {
+		return nil, err
+	}
+	return cu.listOverrides(ctx, services.CancellationOwnerCourt, ownerID)
+}
+
+// GetMyCoachOverrides 獲取教練自己的取消例外
+func (cu *CancellationPolicyUsecase) GetMyCoachOverrides(ctx context.Context, userID string) ([]models.CancellationOverride, error) {
+	ownerID, err := cu.coachIDForUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return cu.listOverrides(ctx, services.CancellationOwnerCoach, ownerID)
+}
+
+// CreateCourtOverride 為場地創建天候或不可抗力例外
+func (cu *CancellationPolicyUsecase) CreateCourtOverride(ctx context.Context, userID, courtID string, req *dto.CreateCancellationOverrideRequest) (*models.CancellationOverride, error) {
+	ownerID, err := cu.ownedCourt(ctx, userID, courtID)
+	if err != nil {
+		return nil, err
+	}
+	return cu.createOverride(ctx, userID, services.CancellationOwnerCourt, ownerID, req)
+}
+
+// CreateMyCoachOverride 為教練自己創建天候或不可抗力例外
+func (cu *CancellationPolicyUsecase) CreateMyCoachOverride(ctx context.Context, userID string, req *dto.CreateCancellationOverrideRequest) (*models.CancellationOverride, error) {
+	ownerID, err := cu.coachIDForUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return cu.createOverride(ctx, userID, services.CancellationOwnerCoach, ownerID, req)
+}
+
+// DeleteCourtOverride 刪除場地的取消例外
+func (cu *CancellationPolicyUsecase) DeleteCourtOverride(ctx context.Context, userID, courtID, overrideID string) error {
+	ownerID, err := cu.ownedCourt(ctx, userID, courtID)
+	if err != nil {
+		return err
+	}
+	return cu.deleteOverride(ctx, services.CancellationOwnerCourt, ownerID, overrideID)
+}
+
+// DeleteMyCoachOverride 刪除教練自己的取消例外
+func (cu *CancellationPolicyUsecase) DeleteMyCoachOverride(ctx context.Context, userID, overrideID string) error {
+	ownerID, err := cu.coachIDForUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	return cu.deleteOverride(ctx, services.CancellationOwnerCoach, ownerID, overrideID)
+}
+
+// getPolicy 獲取取消政策
+func (cu *CancellationPolicyUsecase) getPolicy(ctx context.Context, ownerType, ownerID string) (*models.CancellationPolicy, error) {
+	policy, err := cu.cancellations.Policy(ctx, ownerType, ownerID)
+	if err != nil {
+		return nil, errors.New("獲取取消政策失敗")
+	}
+	return policy, nil
+}
+
+// savePolicy 創建或更新取消政策，同一時數只能有一個級距
+func (cu *CancellationPolicyUsecase) savePolicy(ctx context.Context, ownerType, ownerID string, req *dto.UpdateCancellationPolicyRequest) (*models.CancellationPolicy, error) {
+	tiers := make(models.CancellationTiers, 0, len(req.Tiers))
+	seen := make(map[float64]bool, len(req.Tiers))
+	for _, tier := range req.Tiers {
+		if seen[tier.MinHoursBefore] {
+			return nil, apperror.New(apperror.CodeCancellationPolicyInvalidTiers)
+		}
+		seen[tier.MinHoursBefore] = true
+		tiers = append(tiers, models.CancellationTier{MinHoursBefore: tier.MinHoursBefore, RefundPercent: tier.RefundPercent})
+	}
+
+	db := cu.db.WithContext(ctx)
+	var policy models.CancellationPolicy
+	err := db.Where("owner_type = ? AND owner_id = ?", ownerType, ownerID).First(&policy).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errors.New("獲取取消政策失敗")
+	}
+
+	policy.OwnerType = ownerType
+	policy.OwnerID = ownerID
+	policy.Tiers = tiers
+	policy.CutoffHours = req.CutoffHours
+	if err := db.Save(&policy).Error; err != nil {
+		return nil, errors.New("保存取消政策失敗")
+	}
+	return &policy, nil
+}
+
+// deletePolicy 刪除取消政策並返回恢復使用的默認政策
+func (cu *CancellationPolicyUsecase) deletePolicy(ctx context.Context, ownerType, ownerID string) (*models.CancellationPolicy, error) {
+	if err := cu.db.WithContext(ctx).Where("owner_type = ? AND owner_id = ?", ownerType, ownerID).Delete(&models.CancellationPolicy{}).Error; err != nil {
+		return nil, errors.New("刪除取消政策失敗")
+	}
+	return services.DefaultCancellationPolicy(ownerType, ownerID), nil
+}
+
+// listOverrides 獲取取消例外，按開始時間倒序
+func (cu *CancellationPolicyUsecase) listOverrides(ctx context.Context, ownerType, ownerID string) ([]models.CancellationOverride, error) {
+	overrides := []models.CancellationOverride{}
+	if err := cu.db.WithContext(ctx).
+		Where("owner_type = ? AND owner_id = ?", ownerType, ownerID).
+		Order("start_time DESC").
+		Find(&overrides).Error; err != nil {
+		return nil, errors.New("獲取取消例外失敗")
+	}
+	return overrides, nil
+}
+
+// createOverride 創建取消例外，未指定退款比例時全額退款
+func (cu *CancellationPolicyUsecase) createOverride(ctx context.Context, userID, ownerType, ownerID string, req *dto.CreateCancellationOverrideRequest) (*models.CancellationOverride, error) {
+	if !req.EndTime.After(req.StartTime) {
+		return nil, apperror.New(apperror.CodeCancellationOverrideInvalid)
+	}
+
+	override := models.CancellationOverride{
+		OwnerType:     ownerType,
+		OwnerID:       ownerID,
+		StartTime:     req.StartTime,
+		EndTime:       req.EndTime,
+		Reason:        req.Reason,
+		RefundPercent: 100,
+		Note:          req.Note,
+		CreatedBy:     userID,
+	}
+	if req.RefundPercent != nil {
+		override.RefundPercent = *req.RefundPercent
+	}
+
+	if err := cu.db.WithContext(ctx).Create(&override).Error; err != nil {
+		return nil, errors.New("創建取消例外失敗")
+	}
+	return &override, nil
+}
+
+// deleteOverride 刪除取消例外，已按例外取消的記錄不受影響
+func (cu *CancellationPolicyUsecase) deleteOverride(ctx context.Context, ownerType, ownerID, overrideID string) error {
+	result := cu.db.WithContext(ctx).
+		Where("id = ? AND owner_type = ? AND owner_id = ?", overrideID, ownerType, ownerID).
+		Delete(&models.CancellationOverride{})
+	if result.Error != nil {
+		return errors.New("刪除取消例外失敗")
+	}
+	if result.RowsAffected == 0 {
+		return apperror.New(apperror.CodeCancellationOverrideNotFound)
+	}
+	return nil
+}
+
+// ownedCourt 確認用戶是場地擁有者
+func (cu *CancellationPolicyUsecase) ownedCourt(ctx context.Context, userID, courtID string) (string, error) {
+	var court models.Court
+	if err := cu.db.WithContext(ctx).Select("id", "owner_id").Where("id = ?", courtID).First(&court).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", apperror.New(apperror.CodeCourtNotFound)
+		}
+		return "", errors.New("獲取場地失敗")
+	}
+	if court.OwnerID == nil || *court.OwnerID != userID {
+		return "", apperror.New(apperror.CodeCancellationPolicyForbidden)
+	}
+	return court.ID, nil
+}
+
+// coachIDForUser 獲取用戶的教練ID
+func (cu *CancellationPolicyUsecase) coachIDForUser(ctx context.Context, userID string) (string, error) {
+	var coach models.Coach
+	if err := cu.db.WithContext(ctx).Select("id").Where("user_id = ?", userID).First(&coach).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", apperror.New(apperror.CodeCoachNotFound)
+		}
+		return "", errors.New("獲取教練信息失敗")
+	}
+	return coach.ID, nil
+}
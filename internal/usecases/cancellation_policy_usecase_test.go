@@ -0,0 +1,177 @@
+package usecases
+
+import (
+	"context"
+	"tennis-platform/backend/internal/apperror"
+	"tennis-platform/backend/internal/dto"
+	"tennis-platform/backend/internal/models"
+	"tennis-platform/backend/internal/services"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+const (
+	cancellationOwnerID = "22222222-2222-2222-2222-222222222222"
+	cancellationUserID  = "33333333-3333-3333-3333-333333333333"
+)
+
+func setupCancellationUsecaseTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+
+	// 手動創建表結構，只包含取消流程需要的欄位
+	for _, stmt := range []string{
+		`CREATE TABLE courts (id TEXT PRIMARY KEY, name TEXT, owner_id TEXT, currency TEXT, deleted_at DATETIME)`,
+		`CREATE TABLE bookings (id TEXT PRIMARY KEY, court_id TEXT, user_id TEXT, start_time DATETIME, end_time DATETIME, total_price REAL, status TEXT, payment_id TEXT, payment_due_at DATETIME, version INTEGER DEFAULT 1, updated_at DATETIME, deleted_at DATETIME)`,
+		`CREATE TABLE booking_payment_splits (id TEXT PRIMARY KEY, booking_id TEXT NOT NULL, owner_id TEXT NOT NULL, match_id TEXT, mode TEXT NOT NULL, fallback TEXT NOT NULL, status TEXT NOT NULL, due_at DATETIME NOT NULL, owner_charged_at DATETIME, total REAL NOT NULL, currency TEXT NOT NULL DEFAULT 'TWD', created_at DATETIME, updated_at DATETIME)`,
+		`CREATE TABLE booking_payment_shares (id TEXT PRIMARY KEY, split_id TEXT NOT NULL, booking_id TEXT NOT NULL, user_id TEXT NOT NULL, is_owner BOOLEAN NOT NULL DEFAULT false, amount REAL NOT NULL, status TEXT NOT NULL, payment_id TEXT, paid_at DATETIME, created_at DATETIME, updated_at DATETIME)`,
+		`CREATE TABLE payments (id TEXT PRIMARY KEY, user_id TEXT NOT NULL, target_type TEXT NOT NULL, target_id TEXT NOT NULL, amount REAL NOT NULL, currency TEXT, status TEXT NOT NULL, provider TEXT NOT NULL, provider_payment_id TEXT NOT NULL UNIQUE, client_secret TEXT, refunded_amount REAL NOT NULL DEFAULT 0, failure_reason TEXT, expires_at DATETIME, authorized_at DATETIME, captured_at DATETIME, cancelled_at DATETIME, created_at DATETIME, updated_at DATETIME)`,
+		`CREATE TABLE cancellation_policies (id TEXT PRIMARY KEY, owner_type TEXT NOT NULL, owner_id TEXT NOT NULL, tiers TEXT NOT NULL, cutoff_hours REAL NOT NULL DEFAULT 0, created_at DATETIME, updated_at DATETIME)`,
+		`CREATE TABLE cancellation_overrides (id TEXT PRIMARY KEY, owner_type TEXT NOT NULL, owner_id TEXT NOT NULL, start_time DATETIME NOT NULL, end_time DATETIME NOT NULL, reason TEXT NOT NULL, refund_percent INTEGER NOT NULL DEFAULT 100, note TEXT, created_by TEXT NOT NULL, created_at DATETIME)`,
+		`CREATE TABLE cancellations (id TEXT PRIMARY KEY, target_type TEXT NOT NULL, target_id TEXT NOT NULL, payment_id TEXT, cancelled_by TEXT NOT NULL, initiator TEXT NOT NULL, reason TEXT, policy_id TEXT, override_id TEXT, hours_before REAL, refund_percent INTEGER, paid_amount REAL NOT NULL DEFAULT 0, refund_amount REAL NOT NULL DEFAULT 0, fee_amount REAL NOT NULL DEFAULT 0, currency TEXT NOT NULL DEFAULT 'TWD', refund_status TEXT NOT NULL DEFAULT 'none', refund_error TEXT, refund_attempts INTEGER NOT NULL DEFAULT 0, created_at DATETIME, updated_at DATETIME)`,
+	} {
+		require.NoError(t, db.Exec(stmt).Error)
+	}
+
+	require.NoError(t, db.Exec(`INSERT INTO courts (id, name, owner_id, currency) VALUES ('court-1', '大安森林公園網球場', ?, 'TWD')`, cancellationOwnerID).Error)
+	return db
+}
+
+func TestCancellationPolicyUsecase_CourtPolicy(t *testing.T) {
+	db := setupCancellationUsecaseTestDB(t)
+	uc := NewCancellationPolicyUsecase(db, services.NewCancellationService(db, nil))
+	ctx := context.Background()
+
+	// 未設置時返回默認政策
+	policy, err := uc.GetCourtPolicy(ctx, "court-1")
+	require.NoError(t, err)
+	assert.Empty(t, policy.ID)
+	assert.InDelta(t, 2, policy.CutoffHours, 0.001)
+
+	req := &dto.UpdateCancellationPolicyRequest{
+		Tiers: []dto.CancellationTierRequest{
+			{MinHoursBefore: 24, RefundPercent: 100},
+			{MinHoursBefore: 2, RefundPercent: 50},
+		},
+		CutoffHours: 2,
+	}
+	_, err = uc.UpdateCourtPolicy(ctx, cancellationUserID, "court-1", req)
+	assert.True(t, apperror.HasCode(err, apperror.CodeCancellationPolicyForbidden))
+
+	policy, err = uc.UpdateCourtPolicy(ctx, cancellationOwnerID, "court-1", req)
+	require.NoError(t, err)
+	assert.NotEmpty(t, policy.ID)
+
+	// 再次設置更新同一筆政策
+	req.Tiers = append(req.Tiers, dto.CancellationTierRequest{MinHoursBefore: 48, RefundPercent: 100})
+	updated, err := uc.UpdateCourtPolicy(ctx, cancellationOwnerID, "court-1", req)
+	require.NoError(t, err)
+	assert.Equal(t, policy.ID, updated.ID)
+	assert.Len(t, updated.Tiers, 3)
+
+	req.Tiers = append(req.Tiers, dto.CancellationTierRequest{MinHoursBefore: 2, RefundPercent: 10})
+	_, err = uc.UpdateCourtPolicy(ctx, cancellationOwnerID, "court-1", req)
+	assert.True(t, apperror.HasCode(err, apperror.CodeCancellationPolicyInvalidTiers))
+
+	_, err = uc.CreateCourtOverride(ctx, cancellationOwnerID, "court-1", &dto.CreateCancellationOverrideRequest{
+		StartTime: time.Now(),
+		EndTime:   time.Now().Add(-time.Hour),
+		Reason:    services.CancellationOverrideWeather,
+	})
+	assert.True(t, apperror.HasCode(err, apperror.CodeCancellationOverrideInvalid))
+
+	err = uc.DeleteCourtOverride(ctx, cancellationOwnerID, "court-1", "missing")
+	assert.True(t, apperror.HasCode(err, apperror.CodeCancellationOverrideNotFound))
+
+	_, err = uc.GetCourtPolicy(ctx, "court-missing")
+	assert.True(t, apperror.HasCode(err, apperror.CodeCourtNotFound))
+}
+
+func TestBookingUsecase_CancelBookingWithPolicy(t *testing.T) {
+	db := setupCancellationUsecaseTestDB(t)
+	provider := services.NewLocalPaymentProvider("secret")
+	cancellations := services.NewCancellationService(db, services.NewPaymentService(db, provider, nil, 0))
+	policies := NewCancellationPolicyUsecase(db, cancellations)
+	bookings := NewBookingUsecase(db, nil)
+	bookings.UseCancellations(cancellations)
+	ctx := context.Background()
+
+	_, err := policies.UpdateCourtPolicy(ctx, cancellationOwnerID, "court-1", &dto.UpdateCancellationPolicyRequest{
+		Tiers: []dto.CancellationTierRequest{
+			{MinHoursBefore: 24, RefundPercent: 100},
+			{MinHoursBefore: 2, RefundPercent: 50},
+		},
+		CutoffHours: 2,
+	})
+	require.NoError(t, err)
+
+	// 已扣款且 10 小時後開始的預訂
+	intent, err := provider.CreateIntent(ctx, services.PaymentIntentParams{Amount: 800, Currency: "TWD"})
+	require.NoError(t, err)
+	require.NoError(t, provider.Capture(ctx, intent.ProviderPaymentID))
+	payment := models.Payment{
+		UserID:            cancellationUserID,
+		TargetType:        services.PaymentTargetBooking,
+		TargetID:          "booking-1",
+		Amount:            800,
+		Currency:          "TWD",
+		Status:            services.PaymentStatusCaptured,
+		Provider:          provider.Name(),
+		ProviderPaymentID: intent.ProviderPaymentID,
+	}
+	require.NoError(t, db.Create(&payment).Error)
+	start := time.Now().Add(10 * time.Hour)
+	require.NoError(t, db.Exec(`INSERT INTO bookings (id, court_id, user_id, start_time, end_time, total_price, status, payment_id) VALUES ('booking-1', 'court-1', ?, ?, ?, 800, 'confirmed', ?)`,
+		cancellationUserID, start, start.Add(time.Hour), payment.ID).Error)
+
+	quote, err := bookings.GetCancellationQuote("booking-1", cancellationUserID)
+	require.NoError(t, err)
+	assert.True(t, quote.Allowed)
+	assert.InDelta(t, 400, quote.RefundAmount, 0.001)
+
+	// 客戶端確認的金額與報價不符時不取消
+	stale := 800.0
+	_, err = bookings.CancelBooking("booking-1", cancellationUserID, &dto.CancelBookingRequest{ExpectedRefundAmount: &stale})
+	assert.True(t, apperror.HasCode(err, apperror.CodeCancellationQuoteChanged))
+
+	_, err = bookings.CancelBooking("booking-1", cancellationOwnerID, &dto.CancelBookingRequest{})
+	assert.True(t, apperror.HasCode(err, apperror.CodeBookingCancelForbidden))
+
+	racing, err := bookings.getCancellableBooking("booking-1", cancellationUserID)
+	require.NoError(t, err)
+	cancellation, err := bookings.CancelBooking("booking-1", cancellationUserID, &dto.CancelBookingRequest{ExpectedRefundAmount: &quote.RefundAmount})
+	require.NoError(t, err)
+	assert.Equal(t, services.RefundStatusSucceeded, cancellation.RefundStatus)
+	assert.InDelta(t, 400, cancellation.FeeAmount, 0.001)
+
+	var booking models.Booking
+	require.NoError(t, db.First(&booking, "id = ?", "booking-1").Error)
+	assert.Equal(t, "cancelled", booking.Status)
+
+	var refunded models.Payment
+	require.NoError(t, db.First(&refunded, "id = ?", payment.ID).Error)
+	assert.Equal(t, services.PaymentStatusPartiallyRefunded, refunded.Status)
+	assert.InDelta(t, 400, refunded.RefundedAmount, 0.001)
+
+	// 並發的取消請求在預訂取消前完成讀取，寫入時不再重複記錄取消及退款
+	err = db.Transaction(func(tx *gorm.DB) error {
+		_, err := bookings.cancelBooking(tx, racing, bookingCancellationTarget(racing), quote, cancellationUserID, nil, nil)
+		return err
+	})
+	assert.True(t, apperror.HasCode(err, apperror.CodeBookingAlreadyCancelled))
+	var recorded int64
+	require.NoError(t, db.Model(&models.Cancellation{}).Where("target_id = ?", "booking-1").Count(&recorded).Error)
+	assert.Equal(t, int64(1), recorded)
+
+	// 取消期限內不可取消
+	soon := time.Now().Add(time.Hour)
+	require.NoError(t, db.Exec(`INSERT INTO bookings (id, court_id, user_id, start_time, end_time, total_price, status) VALUES ('booking-2', 'court-1', ?, ?, ?, 800, 'pending')`,
+		cancellationUserID, soon, soon.Add(time.Hour)).Error)
+	_, err = bookings.CancelBooking("booking-2", cancellationUserID, &dto.CancelBookingRequest{})
+	assert.True(t, apperror.HasCode(err, apperror.CodeBookingCancelWindowPassed))
+}
@@ -3,8 +3,10 @@ package usecases
 import (
 	"errors"
 	"fmt"
+	"tennis-platform/backend/internal/apperror"
 	"tennis-platform/backend/internal/dto"
 	"tennis-platform/backend/internal/models"
+	"tennis-platform/backend/internal/pagination"
 	"time"
 
 	"github.com/google/uuid"
@@ -35,7 +37,7 @@ type UserInfo struct {
 func (uc *ChatUsecase) CreateChatRoom(userID string, req *dto.CreateChatRoomRequest) (*dto.ChatRoomResponse, error) {
 	// 驗證請求
 	if len(req.ParticipantIDs) == 0 {
-		return nil, errors.New("至少需要一個參與者")
+		return nil, apperror.New(apperror.CodeChatParticipantsRequired)
 	}
 
 	// 檢查是否為直接聊天且已存在
@@ -102,7 +104,7 @@ func (uc *ChatUsecase) SendMessage(userID string, req *dto.SendMessageRequest) (
 	if err := uc.db.Where("chat_room_id = ? AND user_id = ? AND is_active = ?",
 		req.ChatRoomID, userID, true).First(&participant).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("您不是此聊天室的參與者")
+			return nil, apperror.New(apperror.CodeChatNotParticipant)
 		}
 		return nil, fmt.Errorf("檢查參與者失敗: %w", err)
 	}
@@ -133,24 +135,32 @@ func (uc *ChatUsecase) SendMessage(userID string, req *dto.SendMessageRequest) (
 }
 
 // GetMessages 獲取聊天室訊息
-func (uc *ChatUsecase) GetMessages(userID string, req *dto.GetMessagesRequest) ([]dto.ChatMessageResponse, error) {
+func (uc *ChatUsecase) GetMessages(userID string, req *dto.GetMessagesRequest) (*dto.ChatMessageListResponse, error) {
 	// 檢查用戶是否為聊天室參與者
 	var participant models.ChatParticipant
 	if err := uc.db.Where("chat_room_id = ? AND user_id = ? AND is_active = ?",
 		req.ChatRoomID, userID, true).First(&participant).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("您不是此聊天室的參與者")
+			return nil, apperror.New(apperror.CodeChatNotParticipant)
 		}
 		return nil, fmt.Errorf("檢查參與者失敗: %w", err)
 	}
 
-	// 設置默認分頁參數
-	if req.Page <= 0 {
-		req.Page = 1
+	// 設置分頁參數，由新到舊
+	page := pagination.Query{
+		Keys: []pagination.Key{
+			{Column: "created_at", Kind: pagination.KindTime},
+			{Column: "id", Kind: pagination.KindString},
+		},
+		Desc:   true,
+		Limit:  req.Limit,
+		Cursor: req.Cursor,
+		Page:   req.Page,
 	}
-	if req.Limit <= 0 || req.Limit > 100 {
-		req.Limit = 50
+	if req.Limit > 100 {
+		page.Limit = 0
 	}
+	page.Normalize(50, 100)
 
 	// 構建查詢
 	query := uc.db.Where("chat_room_id = ?", req.ChatRoomID)
@@ -159,17 +169,23 @@ func (uc *ChatUsecase) GetMessages(userID string, req *dto.GetMessagesRequest) (
 		query = query.Where("created_at < ?", *req.Before)
 	}
 
-	var messages []models.ChatMessage
-	offset := (req.Page - 1) * req.Limit
+	query, err := page.Apply(query.Preload("Sender").Preload("Sender.Profile"))
+	if err != nil {
+		return nil, err
+	}
 
-	if err := query.Preload("Sender").Preload("Sender.Profile").
-		Order("created_at DESC").
-		Limit(req.Limit).
-		Offset(offset).
-		Find(&messages).Error; err != nil {
+	var messages []models.ChatMessage
+	if err := query.Find(&messages).Error; err != nil {
 		return nil, fmt.Errorf("獲取訊息失敗: %w", err)
 	}
 
+	messages, pageInfo, err := pagination.Build(messages, &page, nil, func(m *models.ChatMessage) []interface{} {
+		return []interface{}{m.CreatedAt, m.ID}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("生成分頁游標失敗: %w", err)
+	}
+
 	// 轉換為響應格式
 	responses := make([]dto.ChatMessageResponse, len(messages))
 	for i, message := range messages {
@@ -194,7 +210,10 @@ func (uc *ChatUsecase) GetMessages(userID string, req *dto.GetMessagesRequest) (
 		}
 	}
 
-	return responses, nil
+	return &dto.ChatMessageListResponse{
+		Messages:   responses,
+		Pagination: pageInfo,
+	}, nil
 }
 
 // GetChatRooms 獲取用戶的聊天室列表
@@ -227,7 +246,7 @@ func (uc *ChatUsecase) GetChatRoom(chatRoomID, userID string) (*dto.ChatRoomResp
 	if err := uc.db.Where("chat_room_id = ? AND user_id = ? AND is_active = ?",
 		chatRoomID, userID, true).First(&participant).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("您不是此聊天室的參與者")
+			return nil, apperror.New(apperror.CodeChatNotParticipant)
 		}
 		return nil, fmt.Errorf("檢查參與者失敗: %w", err)
 	}
@@ -236,7 +255,7 @@ func (uc *ChatUsecase) GetChatRoom(chatRoomID, userID string) (*dto.ChatRoomResp
 	if err := uc.db.Where("id = ? AND is_active = ?", chatRoomID, true).
 		First(&chatRoom).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("聊天室不存在")
+			return nil, apperror.New(apperror.CodeChatRoomNotFound)
 		}
 		return nil, fmt.Errorf("獲取聊天室失敗: %w", err)
 	}
@@ -251,7 +270,7 @@ func (uc *ChatUsecase) MarkMessagesAsRead(userID, chatRoomID string) error {
 	if err := uc.db.Where("chat_room_id = ? AND user_id = ? AND is_active = ?",
 		chatRoomID, userID, true).First(&participant).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return errors.New("您不是此聊天室的參與者")
+			return apperror.New(apperror.CodeChatNotParticipant)
 		}
 		return fmt.Errorf("檢查參與者失敗: %w", err)
 	}
@@ -272,7 +291,7 @@ func (uc *ChatUsecase) LeaveChatRoom(userID, chatRoomID string) error {
 	if err := uc.db.Where("chat_room_id = ? AND user_id = ? AND is_active = ?",
 		chatRoomID, userID, true).First(&participant).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return errors.New("您不是此聊天室的參與者")
+			return apperror.New(apperror.CodeChatNotParticipant)
 		}
 		return fmt.Errorf("檢查參與者失敗: %w", err)
 	}
@@ -0,0 +1,182 @@
+package usecases
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"tennis-platform/backend/internal/apperror"
+	"tennis-platform/backend/internal/dto"
+	"tennis-platform/backend/internal/ical"
+	"tennis-platform/backend/internal/models"
+	"tennis-platform/backend/internal/services"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// maxCoachCalendarSources 每位教練最多可連結的外部行事曆數量
+const maxCoachCalendarSources = 10
+
+// CoachCalendarUsecase 教練外部行事曆用例
+type CoachCalendarUsecase struct {
+	db              *gorm.DB
+	calendarService *services.CoachCalendarService
+}
+
+// NewCoachCalendarUsecase 創建新的教練外部行事曆用例
+func NewCoachCalendarUsecase(db *gorm.DB, calendarService *services.CoachCalendarService) *CoachCalendarUsecase {
+	return &CoachCalendarUsecase{
+		db:              db,
+		calendarService: calendarService,
+	}
+}
+
+// GetCalendarSources 獲取教練連結的外部行事曆
+func (cu *CoachCalendarUsecase) GetCalendarSources(ctx context.Context, userID string) ([]models.CoachCalendarSource, error) {
+	coachID, err := cu.coachIDForUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	sources := []models.CoachCalendarSource{}
+	if err := cu.db.WithContext(ctx).Where("coach_id = ?", coachID).Order("created_at ASC").Find(&sources).Error; err != nil {
+		return nil, errors.New("獲取外部行事曆失敗")
+	}
+	return sources, nil
+}
+
+// CreateCalendarSource 連結外部行事曆訂閱網址並立即同步
+//
+// 同步失敗不影響建立，原因記錄在 lastError，之後由背景任務定期重試。
+func (cu *CoachCalendarUsecase) CreateCalendarSource(ctx context.Context, userID string, req *dto.CreateCoachCalendarRequest) (*models.CoachCalendarSource, error) {
+	if err := validateCalendarURL(req.URL); err != nil {
+		return nil, err
+	}
+
+	coachID, err := cu.coachIDForUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	feedURL := req.URL
+	return cu.createSource(ctx, &models.CoachCalendarSource{
+		CoachID: coachID,
+		Name:    req.Name,
+		URL:     &feedURL,
+	})
+}
+
+// UploadCalendarFile 上傳 .ics 文件作為外部行事曆
+func (cu *CoachCalendarUsecase) UploadCalendarFile(ctx context.Context, userID, name string, content []byte) (*models.CoachCalendarSource, error) {
+	if _, err := ical.Parse(bytes.NewReader(content)); err != nil {
+		return nil, apperror.Wrap(apperror.CodeCoachCalendarInvalidFile, err)
+	}
+
+	coachID, err := cu.coachIDForUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	text := strings.ToValidUTF8(string(content), "")
+	return cu.createSource(ctx, &models.CoachCalendarSource{
+		CoachID: coachID,
+		Name:    name,
+		Content: &text,
+	})
+}
+
+// SyncCalendarSource 立即同步外部行事曆，失敗原因記錄在 lastError
+func (cu *CoachCalendarUsecase) SyncCalendarSource(ctx context.Context, userID, sourceID string) (*models.CoachCalendarSource, error) {
+	source, err := cu.getOwnedSource(ctx, userID, sourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 失敗原因已記錄在 source.LastError
+	_ = cu.calendarService.Sync(ctx, source)
+	return source, nil
+}
+
+// DeleteCalendarSource 刪除外部行事曆及其忙碌時段
+func (cu *CoachCalendarUsecase) DeleteCalendarSource(ctx context.Context, userID, sourceID string) error {
+	source, err := cu.getOwnedSource(ctx, userID, sourceID)
+	if err != nil {
+		return err
+	}
+
+	err = cu.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("source_id = ?", source.ID).Delete(&models.CoachBusyBlock{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(source).Error
+	})
+	if err != nil {
+		return errors.New("刪除外部行事曆失敗")
+	}
+	return nil
+}
+
+// createSource 檢查數量上限後建立來源並立即同步
+func (cu *CoachCalendarUsecase) createSource(ctx context.Context, source *models.CoachCalendarSource) (*models.CoachCalendarSource, error) {
+	var count int64
+	if err := cu.db.WithContext(ctx).Model(&models.CoachCalendarSource{}).Where("coach_id = ?", source.CoachID).Count(&count).Error; err != nil {
+		return nil, errors.New("獲取外部行事曆失敗")
+	}
+	if count >= maxCoachCalendarSources {
+		return nil, apperror.New(apperror.CodeCoachCalendarLimitReached).With("max", maxCoachCalendarSources)
+	}
+
+	source.NextSyncAt = time.Now()
+	if err := cu.db.WithContext(ctx).Create(source).Error; err != nil {
+		return nil, errors.New("創建外部行事曆失敗")
+	}
+
+	// 失敗原因已記錄在 source.LastError
+	_ = cu.calendarService.Sync(ctx, source)
+	return source, nil
+}
+
+// getOwnedSource 獲取屬於該教練的外部行事曆
+func (cu *CoachCalendarUsecase) getOwnedSource(ctx context.Context, userID, sourceID string) (*models.CoachCalendarSource, error) {
+	coachID, err := cu.coachIDForUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var source models.CoachCalendarSource
+	if err := cu.db.WithContext(ctx).Where("id = ? AND coach_id = ?", sourceID, coachID).First(&source).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.New(apperror.CodeCoachCalendarNotFound)
+		}
+		return nil, errors.New("獲取外部行事曆失敗")
+	}
+	return &source, nil
+}
+
+// coachIDForUser 獲取用戶的教練檔案ID
+func (cu *CoachCalendarUsecase) coachIDForUser(ctx context.Context, userID string) (string, error) {
+	var coach models.Coach
+	if err := cu.db.WithContext(ctx).Select("id").Where("user_id = ?", userID).First(&coach).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", apperror.New(apperror.CodeCoachNotFound)
+		}
+		return "", errors.New("獲取教練信息失敗")
+	}
+	return coach.ID, nil
+}
+
+// validateCalendarURL 驗證外部行事曆地址，Google、Apple 等提供的 webcal:// 網址以 https 下載
+func validateCalendarURL(rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Host == "" {
+		return apperror.New(apperror.CodeCoachCalendarInvalidURL)
+	}
+	switch strings.ToLower(parsed.Scheme) {
+	case "https", "http", "webcal":
+		return nil
+	default:
+		return apperror.New(apperror.CodeCoachCalendarInvalidURL)
+	}
+}
@@ -0,0 +1,88 @@
+package usecases
+
+import (
+	"context"
+	"tennis-platform/backend/internal/apperror"
+	"tennis-platform/backend/internal/services"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+const (
+	coachCalendarUserID  = "coach-user-1"
+	coachCalendarCoachID = "coach-1"
+)
+
+func setupCoachCalendarTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+
+	// 手動創建表結構，只包含外部行事曆及可用時間需要的欄位
+	for _, stmt := range []string{
+		`CREATE TABLE coaches (id TEXT PRIMARY KEY, user_id TEXT, deleted_at DATETIME)`,
+		`CREATE TABLE coach_calendar_sources (id TEXT PRIMARY KEY, coach_id TEXT NOT NULL, name TEXT NOT NULL, url TEXT, content TEXT, block_count INTEGER DEFAULT 0, last_synced_at DATETIME, last_error TEXT, next_sync_at DATETIME NOT NULL, created_at DATETIME, updated_at DATETIME)`,
+		`CREATE TABLE coach_busy_blocks (id TEXT PRIMARY KEY, source_id TEXT NOT NULL, coach_id TEXT NOT NULL, start_time DATETIME NOT NULL, end_time DATETIME NOT NULL)`,
+		`CREATE TABLE lesson_schedules (id TEXT PRIMARY KEY, coach_id TEXT, day_of_week INTEGER, start_time TEXT, end_time TEXT, is_active BOOLEAN, created_at DATETIME, updated_at DATETIME)`,
+		`CREATE TABLE lessons (id TEXT PRIMARY KEY, coach_id TEXT, duration INTEGER, scheduled_at DATETIME, status TEXT, deleted_at DATETIME)`,
+	} {
+		require.NoError(t, db.Exec(stmt).Error)
+	}
+
+	require.NoError(t, db.Exec(`INSERT INTO coaches (id, user_id) VALUES (?, ?)`, coachCalendarCoachID, coachCalendarUserID).Error)
+	return db
+}
+
+func TestCoachCalendarUsecase_UploadCalendarFile(t *testing.T) {
+	db := setupCoachCalendarTestDB(t)
+	uc := NewCoachCalendarUsecase(db, services.NewCoachCalendarService(db))
+	ctx := context.Background()
+
+	// 明天 14:00-15:00 (UTC) 的外部行程
+	day := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 1)
+	content := "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nBEGIN:VEVENT\r\nUID:other@example.com\r\n" +
+		"DTSTART:" + day.Add(14*time.Hour).Format("20060102T150405Z") + "\r\n" +
+		"DTEND:" + day.Add(15*time.Hour).Format("20060102T150405Z") + "\r\n" +
+		"END:VEVENT\r\nEND:VCALENDAR\r\n"
+
+	source, err := uc.UploadCalendarFile(ctx, coachCalendarUserID, "其他球場", []byte(content))
+	require.NoError(t, err)
+	assert.Nil(t, source.LastError)
+	assert.Equal(t, 1, source.BlockCount)
+
+	require.NoError(t, db.Exec(`INSERT INTO lesson_schedules (id, coach_id, day_of_week, start_time, end_time, is_active) VALUES ('schedule-1', ?, ?, '13:00', '16:00', true)`,
+		coachCalendarCoachID, int(day.Weekday())).Error)
+
+	slots, err := NewCoachUsecase(db, nil).GetCoachAvailability(coachCalendarCoachID, day.Format("2006-01-02"))
+	require.NoError(t, err)
+	require.Len(t, slots, 3)
+	assert.False(t, slots[0].IsBooked)
+	assert.True(t, slots[1].IsBooked)
+	assert.False(t, slots[2].IsBooked)
+
+	// 刪除後不再扣除
+	require.NoError(t, uc.DeleteCalendarSource(ctx, coachCalendarUserID, source.ID))
+	slots, err = NewCoachUsecase(db, nil).GetCoachAvailability(coachCalendarCoachID, day.Format("2006-01-02"))
+	require.NoError(t, err)
+	assert.False(t, slots[1].IsBooked)
+
+	_, err = uc.UploadCalendarFile(ctx, coachCalendarUserID, "壞掉的文件", []byte("hello"))
+	assert.True(t, apperror.HasCode(err, apperror.CodeCoachCalendarInvalidFile))
+
+	_, err = uc.UploadCalendarFile(ctx, "someone-else", "其他球場", []byte(content))
+	assert.True(t, apperror.HasCode(err, apperror.CodeCoachNotFound))
+
+	err = uc.DeleteCalendarSource(ctx, coachCalendarUserID, source.ID)
+	assert.True(t, apperror.HasCode(err, apperror.CodeCoachCalendarNotFound))
+}
+
+func TestValidateCalendarURL(t *testing.T) {
+	assert.NoError(t, validateCalendarURL("webcal://p01-caldav.icloud.com/published/2/abc"))
+	assert.NoError(t, validateCalendarURL("https://calendar.google.com/calendar/ical/x/basic.ics"))
+	assert.True(t, apperror.HasCode(validateCalendarURL("ftp://example.com/a.ics"), apperror.CodeCoachCalendarInvalidURL))
+	assert.True(t, apperror.HasCode(validateCalendarURL("not a url"), apperror.CodeCoachCalendarInvalidURL))
+}
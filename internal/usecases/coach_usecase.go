@@ -1,10 +1,14 @@
 package usecases
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"log"
+	"tennis-platform/backend/internal/apperror"
 	"tennis-platform/backend/internal/dto"
 	"tennis-platform/backend/internal/models"
+	"tennis-platform/backend/internal/pagination"
 	"tennis-platform/backend/internal/services"
 	"time"
 
@@ -14,28 +18,45 @@ import (
 
 // CoachUsecase 教練用例
 type CoachUsecase struct {
-	db *gorm.DB
+	db            *gorm.DB
+	eventBus      *services.EventBus
+	cancellations *services.CancellationService
+	promos        *services.PromoService
+	paymentHold   time.Duration // 未付款課程的保留時間，0 表示不限時
 }
 
 // NewCoachUsecase 創建新的教練用例
-func NewCoachUsecase(db *gorm.DB) *CoachUsecase {
+func NewCoachUsecase(db *gorm.DB, eventBus *services.EventBus) *CoachUsecase {
 	return &CoachUsecase{
-		db: db,
+		db:            db,
+		eventBus:      eventBus,
+		cancellations: services.NewCancellationService(db, nil),
+		promos:        services.NewPromoService(db),
 	}
 }
 
+// UseCancellations 設置取消政策服務，取消時依教練政策計算並發起退款
+func (cu *CoachUsecase) UseCancellations(cancellations *services.CancellationService) {
+	cu.cancellations = cancellations
+}
+
+// UsePaymentHold 設置未付款課程的保留時間，逾期未付款的課程由付款服務取消
+func (cu *CoachUsecase) UsePaymentHold(hold time.Duration) {
+	cu.paymentHold = hold
+}
+
 // CreateCoachProfile 創建教練檔案
 func (cu *CoachUsecase) CreateCoachProfile(userID string, req *dto.CreateCoachProfileRequest) (*models.Coach, error) {
 	// 檢查用戶是否存在
 	var user models.User
 	if err := cu.db.Where("id = ?", userID).First(&user).Error; err != nil {
-		return nil, errors.New("用戶不存在")
+		return nil, apperror.New(apperror.CodeUserNotFound)
 	}
 
 	// 檢查是否已有教練檔案
 	var existingCoach models.Coach
 	if err := cu.db.Where("user_id = ?", userID).First(&existingCoach).Error; err == nil {
-		return nil, errors.New("教練檔案已存在")
+		return nil, apperror.New(apperror.CodeCoachProfileExists)
 	}
 
 	// 驗證可用時間格式
@@ -82,7 +103,7 @@ func (cu *CoachUsecase) GetCoachByID(coachID string) (*models.Coach, error) {
 	var coach models.Coach
 	if err := cu.db.Preload("User").Preload("User.Profile").Where("id = ? AND is_active = ?", coachID, true).First(&coach).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("教練不存在")
+			return nil, apperror.New(apperror.CodeCoachNotFound)
 		}
 		return nil, errors.New("獲取教練信息失敗")
 	}
@@ -94,7 +115,7 @@ func (cu *CoachUsecase) GetCoachByUserID(userID string) (*models.Coach, error) {
 	var coach models.Coach
 	if err := cu.db.Preload("User").Preload("User.Profile").Where("user_id = ? AND is_active = ?", userID, true).First(&coach).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("教練檔案不存在")
+			return nil, apperror.New(apperror.CodeCoachNotFound)
 		}
 		return nil, errors.New("獲取教練信息失敗")
 	}
@@ -106,7 +127,7 @@ func (cu *CoachUsecase) UpdateCoachProfile(coachID string, req *dto.UpdateCoachP
 	// 查找教練
 	var coach models.Coach
 	if err := cu.db.Where("id = ?", coachID).First(&coach).Error; err != nil {
-		return nil, errors.New("教練不存在")
+		return nil, apperror.New(apperror.CodeCoachNotFound)
 	}
 
 	// 驗證可用時間格式
@@ -267,7 +288,7 @@ func (cu *CoachUsecase) VerifyCoach(req *dto.CoachVerificationRequest) (*models.
 	// 查找教練
 	var coach models.Coach
 	if err := cu.db.Where("id = ?", req.CoachID).First(&coach).Error; err != nil {
-		return nil, errors.New("教練不存在")
+		return nil, apperror.New(apperror.CodeCoachNotFound)
 	}
 
 	// 更新認證狀態
@@ -326,12 +347,12 @@ func (cu *CoachUsecase) validateAvailableHours(availableHours models.AvailableHo
 
 	for day, timeSlots := range availableHours {
 		if !validDays[day] {
-			return fmt.Errorf("無效的星期: %s", day)
+			return apperror.New(apperror.CodeCoachInvalidWeekday).With("day", day)
 		}
 
 		for _, timeSlot := range timeSlots {
 			if err := cu.validateTimeSlot(timeSlot); err != nil {
-				return fmt.Errorf("無效的時間段 %s: %v", timeSlot, err)
+				return err
 			}
 		}
 	}
@@ -342,30 +363,26 @@ func (cu *CoachUsecase) validateAvailableHours(availableHours models.AvailableHo
 // validateTimeSlot 驗證時間段格式 (例如: "09:00-12:00")
 func (cu *CoachUsecase) validateTimeSlot(timeSlot string) error {
 	// 簡單的時間格式驗證
-	if len(timeSlot) != 11 {
-		return errors.New("時間段格式應為 HH:MM-HH:MM")
-	}
-
-	if timeSlot[5] != '-' {
-		return errors.New("時間段格式應為 HH:MM-HH:MM")
+	if len(timeSlot) != 11 || timeSlot[5] != '-' {
+		return apperror.New(apperror.CodeCoachInvalidTimeSlot).With("value", timeSlot)
 	}
 
 	startTime := timeSlot[:5]
 	endTime := timeSlot[6:]
 
 	if err := cu.validateTime(startTime); err != nil {
-		return fmt.Errorf("開始時間格式錯誤: %v", err)
+		return err
 	}
 
 	if err := cu.validateTime(endTime); err != nil {
-		return fmt.Errorf("結束時間格式錯誤: %v", err)
+		return err
 	}
 
 	// 驗證開始時間小於結束時間
 	start, _ := time.Parse("15:04", startTime)
 	end, _ := time.Parse("15:04", endTime)
 	if start.After(end) || start.Equal(end) {
-		return errors.New("開始時間必須早於結束時間")
+		return apperror.New(apperror.CodeCoachInvalidTimeRange)
 	}
 
 	return nil
@@ -375,7 +392,7 @@ func (cu *CoachUsecase) validateTimeSlot(timeSlot string) error {
 func (cu *CoachUsecase) validateTime(timeStr string) error {
 	_, err := time.Parse("15:04", timeStr)
 	if err != nil {
-		return errors.New("時間格式應為 HH:MM")
+		return apperror.New(apperror.CodeCoachInvalidTime).With("value", timeStr)
 	}
 	return nil
 }
@@ -395,16 +412,16 @@ func (cu *CoachUsecase) CreateLessonType(coachID string, req *dto.CreateLessonTy
 	// 檢查教練是否存在
 	var coach models.Coach
 	if err := cu.db.Where("id = ? AND is_active = ?", coachID, true).First(&coach).Error; err != nil {
-		return nil, errors.New("教練不存在")
+		return nil, apperror.New(apperror.CodeCoachNotFound)
 	}
 
 	// 驗證團體課程參數
 	if req.Type == "group" || req.Type == "clinic" {
 		if req.MaxParticipants == nil || *req.MaxParticipants < 2 {
-			return nil, errors.New("團體課程必須設定最大參與人數（至少2人）")
+			return nil, apperror.New(apperror.CodeLessonTypeGroupSizeRequired)
 		}
 		if req.MinParticipants != nil && *req.MinParticipants > *req.MaxParticipants {
-			return nil, errors.New("最小參與人數不能大於最大參與人數")
+			return nil, apperror.New(apperror.CodeLessonTypeInvalidParticipants)
 		}
 	}
 
@@ -457,7 +474,7 @@ func (cu *CoachUsecase) UpdateLessonType(lessonTypeID string, req *dto.UpdateLes
 	// 查找課程類型
 	var lessonType models.LessonType
 	if err := cu.db.Where("id = ?", lessonTypeID).First(&lessonType).Error; err != nil {
-		return nil, errors.New("課程類型不存在")
+		return nil, apperror.New(apperror.CodeLessonTypeNotFound)
 	}
 
 	// 更新欄位
@@ -523,7 +540,7 @@ func (cu *CoachUsecase) DeleteLessonType(lessonTypeID string) error {
 	}
 
 	if count > 0 {
-		return errors.New("無法刪除：存在關聯的課程")
+		return apperror.New(apperror.CodeLessonTypeInUse)
 	}
 
 	// 軟刪除課程類型
@@ -539,13 +556,13 @@ func (cu *CoachUsecase) CreateLesson(req *dto.CreateLessonRequest) (*models.Less
 	// 檢查教練是否存在
 	var coach models.Coach
 	if err := cu.db.Where("id = ? AND is_active = ?", req.CoachID, true).First(&coach).Error; err != nil {
-		return nil, errors.New("教練不存在")
+		return nil, apperror.New(apperror.CodeCoachNotFound)
 	}
 
 	// 檢查學生是否存在
 	var student models.User
 	if err := cu.db.Where("id = ?", req.StudentID).First(&student).Error; err != nil {
-		return nil, errors.New("學生不存在")
+		return nil, apperror.New(apperror.CodeUserNotFound)
 	}
 
 	// 檢查時間衝突
@@ -575,7 +592,55 @@ func (cu *CoachUsecase) CreateLesson(req *dto.CreateLessonRequest) (*models.Less
 		Notes:        req.Notes,
 	}
 
-	if err := cu.db.Create(&lesson).Error; err != nil {
+	// 套用優惠碼，使用次數在寫入課程的事務中扣除
+	var promo *services.PromoDiscount
+	var purchase *services.PromoPurchase
+	if req.PromoCode != nil && *req.PromoCode != "" {
+		purchase = &services.PromoPurchase{
+			UserID:     req.StudentID,
+			TargetType: models.PromoTargetLesson,
+			CoachID:    req.CoachID,
+			Amount:     req.Price,
+			Currency:   currency,
+			At:         req.ScheduledAt,
+		}
+		if req.LessonTypeID != nil {
+			purchase.LessonTypeID = *req.LessonTypeID
+		}
+		var err error
+		if promo, err = cu.promos.Quote(context.Background(), *req.PromoCode, purchase); err != nil {
+			var appErr *apperror.Error
+			if errors.As(err, &appErr) {
+				return nil, err
+			}
+			return nil, errors.New("驗證優惠碼失敗")
+		}
+		lesson.Price = promo.Total
+		lesson.DiscountAmount = promo.Amount
+		lesson.PromoCodeID = &promo.PromoCode.ID
+	}
+
+	if cu.paymentHold > 0 && lesson.Price > 0 {
+		dueAt := time.Now().Add(cu.paymentHold)
+		lesson.PaymentDueAt = &dueAt
+	}
+
+	err := cu.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&lesson).Error; err != nil {
+			return err
+		}
+		if promo != nil {
+			if _, err := cu.promos.Redeem(tx, promo, purchase, lesson.ID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		var appErr *apperror.Error
+		if errors.As(err, &appErr) {
+			return nil, err
+		}
 		return nil, errors.New("創建課程失敗")
 	}
 
@@ -592,7 +657,7 @@ func (cu *CoachUsecase) GetLesson(lessonID string) (*models.Lesson, error) {
 	var lesson models.Lesson
 	if err := cu.db.Preload("Coach").Preload("Student").Preload("LessonType").Preload("Court").Where("id = ?", lessonID).First(&lesson).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("課程不存在")
+			return nil, apperror.New(apperror.CodeLessonNotFound)
 		}
 		return nil, errors.New("獲取課程信息失敗")
 	}
@@ -656,16 +721,26 @@ func (cu *CoachUsecase) GetLessons(req *dto.GetLessonsRequest) ([]models.Lesson,
 }
 
 // UpdateLesson 更新課程
-func (cu *CoachUsecase) UpdateLesson(lessonID string, req *dto.UpdateLessonRequest) (*models.Lesson, error) {
+// expectedVersion 不為 nil 時需與目前版本一致，否則返回版本衝突錯誤
+func (cu *CoachUsecase) UpdateLesson(lessonID string, req *dto.UpdateLessonRequest, expectedVersion *int64) (*models.Lesson, error) {
 	// 查找課程
 	var lesson models.Lesson
 	if err := cu.db.Where("id = ?", lessonID).First(&lesson).Error; err != nil {
-		return nil, errors.New("課程不存在")
+		return nil, apperror.New(apperror.CodeLessonNotFound)
+	}
+
+	if expectedVersion != nil && *expectedVersion != lesson.Version {
+		return nil, apperror.New(apperror.CodePreconditionFailed)
 	}
 
 	// 檢查課程狀態
 	if lesson.Status == "completed" || lesson.Status == "cancelled" {
-		return nil, errors.New("已完成或已取消的課程無法修改")
+		return nil, apperror.New(apperror.CodeLessonNotEditable)
+	}
+
+	// 取消需經取消課程套用取消政策、退款及退回優惠碼，並發布課程取消事件
+	if req.Status != nil && *req.Status == "cancelled" {
+		return nil, apperror.New(apperror.CodeLessonStatusReadOnly)
 	}
 
 	// 檢查時間衝突（如果更新時間）
@@ -692,9 +767,15 @@ func (cu *CoachUsecase) UpdateLesson(lessonID string, req *dto.UpdateLessonReque
 	}
 
 	if len(updates) > 0 {
-		if err := cu.db.Model(&lesson).Updates(updates).Error; err != nil {
+		// 以讀取時的版本號作為條件，避免覆蓋並發的修改
+		updates["version"] = gorm.Expr("version + 1")
+		result := cu.db.Model(&lesson).Where("version = ?", lesson.Version).Updates(updates)
+		if result.Error != nil {
 			return nil, errors.New("更新課程失敗")
 		}
+		if result.RowsAffected == 0 {
+			return nil, apperror.New(apperror.CodePreconditionFailed)
+		}
 	}
 
 	// 重新載入數據
@@ -705,35 +786,159 @@ func (cu *CoachUsecase) UpdateLesson(lessonID string, req *dto.UpdateLessonReque
 	return &lesson, nil
 }
 
+// GetLessonCancellationQuote 獲取此刻取消課程的退款報價
+func (cu *CoachUsecase) GetLessonCancellationQuote(lessonID, userID string) (*services.CancellationQuote, error) {
+	lesson, initiator, err := cu.getCancellableLesson(lessonID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	quote, err := cu.cancellations.Quote(context.Background(), lessonCancellationTarget(lesson), initiator, time.Now())
+	if err != nil {
+		return nil, errors.New("計算退款金額失敗")
+	}
+	return quote, nil
+}
+
 // CancelLesson 取消課程
-func (cu *CoachUsecase) CancelLesson(lessonID string, req *dto.CancelLessonRequest) (*models.Lesson, error) {
-	// 查找課程
-	var lesson models.Lesson
-	if err := cu.db.Where("id = ?", lessonID).First(&lesson).Error; err != nil {
-		return nil, errors.New("課程不存在")
+//
+// 學生取消時套用教練的取消政策；教練取消時全額退款。
+func (cu *CoachUsecase) CancelLesson(lessonID, userID string, req *dto.CancelLessonRequest) (*dto.CancelLessonResponse, error) {
+	lesson, initiator, err := cu.getCancellableLesson(lessonID, userID)
+	if err != nil {
+		return nil, err
 	}
 
-	// 檢查課程狀態
-	if lesson.Status == "completed" || lesson.Status == "cancelled" {
-		return nil, errors.New("課程已完成或已取消")
+	ctx := context.Background()
+	target := lessonCancellationTarget(lesson)
+	quote, err := cu.cancellations.Quote(ctx, target, initiator, time.Now())
+	if err != nil {
+		return nil, errors.New("計算退款金額失敗")
+	}
+	if !quote.Allowed {
+		return nil, apperror.New(apperror.CodeLessonCancelWindowPassed).With("hours", quote.CutoffHours)
+	}
+	if err := checkExpectedRefund(quote, req.ExpectedRefundAmount); err != nil {
+		return nil, err
 	}
 
 	// 更新狀態
 	updates := map[string]interface{}{
 		"status":        "cancelled",
 		"cancel_reason": req.Reason,
+		"version":       gorm.Expr("version + 1"),
 	}
 
-	if err := cu.db.Model(&lesson).Updates(updates).Error; err != nil {
+	tx := cu.db.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// 以讀取時的狀態及版本號作為條件，並發的取消只有一個生效，不會重複記錄取消及退款
+	result := tx.Model(&models.Lesson{}).
+		Where("id = ? AND status = ? AND version = ?", lesson.ID, lesson.Status, lesson.Version).
+		Updates(updates)
+	if result.Error != nil {
+		tx.Rollback()
+		return nil, errors.New("取消課程失敗")
+	}
+	if result.RowsAffected == 0 {
+		var current models.Lesson
+		err := tx.Select("status").Where("id = ?", lesson.ID).First(&current).Error
+		tx.Rollback()
+		if err != nil {
+			return nil, errors.New("取消課程失敗")
+		}
+		if current.Status == "completed" || current.Status == "cancelled" {
+			return nil, apperror.New(apperror.CodeLessonNotCancellable)
+		}
+		return nil, apperror.New(apperror.CodePreconditionFailed)
+	}
+
+	// 發布課程取消事件
+	if cu.eventBus != nil {
+		payload := services.LessonEventPayload{
+			LessonID:    lesson.ID,
+			CoachID:     lesson.CoachID,
+			StudentID:   lesson.StudentID,
+			ScheduledAt: lesson.ScheduledAt,
+			Status:      "cancelled",
+			Reason:      req.Reason,
+		}
+		if err := cu.eventBus.Publish(tx, services.EventLessonCancelled, "lesson", lesson.ID, payload); err != nil {
+			tx.Rollback()
+			return nil, errors.New("取消課程失敗")
+		}
+	}
+
+	cancellation, err := cu.cancellations.Record(tx, target, quote, userID, &req.Reason)
+	if err != nil {
+		tx.Rollback()
 		return nil, errors.New("取消課程失敗")
 	}
 
+	if err := tx.Commit().Error; err != nil {
+		return nil, errors.New("取消課程失敗")
+	}
+	if cu.eventBus != nil {
+		cu.eventBus.Notify()
+	}
+
+	// 課程已取消，退款失敗時由取消政策服務定期重試
+	cu.cancellations.ReleasePayments(ctx, services.PaymentTargetLesson, lesson.ID)
+	if err := cu.cancellations.Settle(ctx, cancellation); err != nil {
+		log.Printf("Failed to refund cancelled lesson %s: %v", lesson.ID, err)
+	}
+
 	// 重新載入數據
-	if err := cu.db.Preload("Coach").Preload("Student").Preload("LessonType").Preload("Court").Where("id = ?", lessonID).First(&lesson).Error; err != nil {
+	if err := cu.db.Preload("Coach").Preload("Student").Preload("LessonType").Preload("Court").Where("id = ?", lessonID).First(lesson).Error; err != nil {
 		return nil, errors.New("載入課程數據失敗")
 	}
 
-	return &lesson, nil
+	return &dto.CancelLessonResponse{Lesson: lesson, Cancellation: cancellation}, nil
+}
+
+// getCancellableLesson 獲取用戶可取消的課程及取消發起方
+func (cu *CoachUsecase) getCancellableLesson(lessonID, userID string) (*models.Lesson, string, error) {
+	// 查找課程
+	var lesson models.Lesson
+	if err := cu.db.Preload("Coach").Where("id = ?", lessonID).First(&lesson).Error; err != nil {
+		return nil, "", apperror.New(apperror.CodeLessonNotFound)
+	}
+
+	// 學生依政策取消，教練取消視為提供方取消
+	var initiator string
+	switch {
+	case lesson.StudentID == userID:
+		initiator = services.CancellationInitiatorCustomer
+	case lesson.Coach != nil && lesson.Coach.UserID == userID:
+		initiator = services.CancellationInitiatorProvider
+	default:
+		return nil, "", apperror.New(apperror.CodeLessonCancelForbidden)
+	}
+
+	// 檢查課程狀態
+	if lesson.Status == "completed" || lesson.Status == "cancelled" {
+		return nil, "", apperror.New(apperror.CodeLessonNotCancellable)
+	}
+
+	return &lesson, initiator, nil
+}
+
+// lessonCancellationTarget 課程套用教練的取消政策
+func lessonCancellationTarget(lesson *models.Lesson) *services.CancellationTarget {
+	return &services.CancellationTarget{
+		TargetType: services.PaymentTargetLesson,
+		TargetID:   lesson.ID,
+		OwnerType:  services.CancellationOwnerCoach,
+		OwnerID:    lesson.CoachID,
+		StartTime:  lesson.ScheduledAt,
+		EndTime:    lesson.ScheduledAt.Add(time.Duration(lesson.Duration) * time.Minute),
+		PaymentID:  lesson.PaymentID,
+		Currency:   lesson.Currency,
+	}
 }
 
 // GetCoachAvailability 獲取教練可用時間
@@ -741,7 +946,7 @@ func (cu *CoachUsecase) GetCoachAvailability(coachID string, date string) ([]mod
 	// 解析日期
 	targetDate, err := time.Parse("2006-01-02", date)
 	if err != nil {
-		return nil, errors.New("日期格式錯誤")
+		return nil, apperror.New(apperror.CodeCoachInvalidDate)
 	}
 
 	// 獲取星期幾 (0=Sunday, 6=Saturday)
@@ -767,12 +972,18 @@ func (cu *CoachUsecase) GetCoachAvailability(coachID string, date string) ([]mod
 		return nil, errors.New("獲取已預訂課程失敗")
 	}
 
+	// 獲取外部行事曆的忙碌時段
+	busyBlocks, err := services.LoadCoachBusyBlocks(cu.db, coachID, startOfDay, endOfDay)
+	if err != nil {
+		return nil, errors.New("獲取外部行事曆失敗")
+	}
+
 	// 生成可用時間段
 	var availableSlots []models.TimeSlot
 	for _, schedule := range schedules {
 		slots := cu.generateTimeSlots(schedule.StartTime, schedule.EndTime, 60) // 60分鐘間隔
 		for _, slot := range slots {
-			isBooked := cu.isTimeSlotBooked(slot, bookedLessons, targetDate)
+			isBooked := cu.isTimeSlotBooked(slot, bookedLessons, busyBlocks, targetDate)
 			availableSlots = append(availableSlots, models.TimeSlot{
 				StartTime: slot.StartTime,
 				EndTime:   slot.EndTime,
@@ -789,23 +1000,23 @@ func (cu *CoachUsecase) UpdateCoachSchedule(coachID string, req *dto.UpdateSched
 	// 檢查教練是否存在
 	var coach models.Coach
 	if err := cu.db.Where("id = ? AND is_active = ?", coachID, true).First(&coach).Error; err != nil {
-		return errors.New("教練不存在")
+		return apperror.New(apperror.CodeCoachNotFound)
 	}
 
 	// 驗證時間格式
 	for _, schedule := range req.Schedules {
 		if err := cu.validateTime(schedule.StartTime); err != nil {
-			return fmt.Errorf("開始時間格式錯誤: %v", err)
+			return err
 		}
 		if err := cu.validateTime(schedule.EndTime); err != nil {
-			return fmt.Errorf("結束時間格式錯誤: %v", err)
+			return err
 		}
 
 		// 驗證開始時間小於結束時間
 		start, _ := time.Parse("15:04", schedule.StartTime)
 		end, _ := time.Parse("15:04", schedule.EndTime)
 		if start.After(end) || start.Equal(end) {
-			return errors.New("開始時間必須早於結束時間")
+			return apperror.New(apperror.CodeCoachInvalidTimeRange)
 		}
 	}
 
@@ -869,7 +1080,7 @@ func (cu *CoachUsecase) checkTimeConflict(coachID string, scheduledAt time.Time,
 	}
 
 	if count > 0 {
-		return errors.New("時間衝突：該時段已有其他課程")
+		return apperror.New(apperror.CodeLessonTimeConflict)
 	}
 
 	return nil
@@ -888,7 +1099,7 @@ func (cu *CoachUsecase) checkTimeConflictExcluding(coachID string, scheduledAt t
 	}
 
 	if count > 0 {
-		return errors.New("時間衝突：該時段已有其他課程")
+		return apperror.New(apperror.CodeLessonTimeConflict)
 	}
 
 	return nil
@@ -920,8 +1131,8 @@ func (cu *CoachUsecase) generateTimeSlots(startTime, endTime string, intervalMin
 	return slots
 }
 
-// isTimeSlotBooked 檢查時間段是否已預訂
-func (cu *CoachUsecase) isTimeSlotBooked(slot models.TimeSlot, bookedLessons []models.Lesson, targetDate time.Time) bool {
+// isTimeSlotBooked 檢查時間段是否已預訂或與外部行事曆的忙碌時段重疊
+func (cu *CoachUsecase) isTimeSlotBooked(slot models.TimeSlot, bookedLessons []models.Lesson, busyBlocks []models.CoachBusyBlock, targetDate time.Time) bool {
 	slotStart, _ := time.Parse("15:04", slot.StartTime)
 	slotEnd, _ := time.Parse("15:04", slot.EndTime)
 
@@ -940,7 +1151,7 @@ func (cu *CoachUsecase) isTimeSlotBooked(slot models.TimeSlot, bookedLessons []m
 		}
 	}
 
-	return false
+	return services.OverlapsBusyBlock(slotStartTime, slotEndTime, busyBlocks)
 }
 
 // GetIntelligentRecommendations 獲取智能推薦
@@ -1097,30 +1308,30 @@ func (cu *CoachUsecase) CreateCoachReview(userID string, req *dto.CreateCoachRev
 	// 檢查教練是否存在
 	var coach models.Coach
 	if err := cu.db.Where("id = ? AND is_active = ?", req.CoachID, true).First(&coach).Error; err != nil {
-		return nil, errors.New("教練不存在")
+		return nil, apperror.New(apperror.CodeCoachNotFound)
 	}
 
 	// 檢查用戶是否存在
 	var user models.User
 	if err := cu.db.Where("id = ?", userID).First(&user).Error; err != nil {
-		return nil, errors.New("用戶不存在")
+		return nil, apperror.New(apperror.CodeUserNotFound)
 	}
 
 	// 如果提供了課程ID，檢查課程是否存在且已完成
 	if req.LessonID != nil {
 		var lesson models.Lesson
 		if err := cu.db.Where("id = ? AND student_id = ? AND coach_id = ?", *req.LessonID, userID, req.CoachID).First(&lesson).Error; err != nil {
-			return nil, errors.New("課程不存在或無權限評價")
+			return nil, apperror.New(apperror.CodeCoachReviewLessonNotFound)
 		}
 
 		if lesson.Status != "completed" {
-			return nil, errors.New("只能評價已完成的課程")
+			return nil, apperror.New(apperror.CodeCoachReviewLessonNotCompleted)
 		}
 
 		// 檢查是否已經評價過該課程
 		var existingReview models.CoachReview
 		if err := cu.db.Where("lesson_id = ? AND user_id = ?", *req.LessonID, userID).First(&existingReview).Error; err == nil {
-			return nil, errors.New("該課程已經評價過")
+			return nil, apperror.New(apperror.CodeCoachReviewLessonReviewed)
 		}
 	}
 
@@ -1128,7 +1339,7 @@ func (cu *CoachUsecase) CreateCoachReview(userID string, req *dto.CreateCoachRev
 	if req.LessonID == nil {
 		var existingReview models.CoachReview
 		if err := cu.db.Where("coach_id = ? AND user_id = ? AND lesson_id IS NULL", req.CoachID, userID).First(&existingReview).Error; err == nil {
-			return nil, errors.New("已經評價過該教練")
+			return nil, apperror.New(apperror.CodeCoachReviewDuplicate)
 		}
 	}
 
@@ -1159,15 +1370,22 @@ func (cu *CoachUsecase) GetCoachReview(reviewID string) (*models.CoachReview, er
 	var review models.CoachReview
 	if err := cu.db.Preload("Coach").Preload("User").Preload("User.Profile").Preload("Lesson").Where("coach_id = ?", reviewID).First(&review).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("評價不存在")
+			return nil, apperror.New(apperror.CodeCoachReviewNotFound)
 		}
 		return nil, errors.New("獲取評價失敗")
 	}
 	return &review, nil
 }
 
+// coachReviewSortKeys 教練評價列表可用的排序欄位
+var coachReviewSortKeys = map[string]pagination.Key{
+	"rating":    {Column: "rating", Kind: pagination.KindNumber},
+	"createdAt": {Column: "created_at", Kind: pagination.KindTime},
+	"isHelpful": {Column: "is_helpful", Kind: pagination.KindNumber},
+}
+
 // GetCoachReviews 獲取教練評價列表
-func (cu *CoachUsecase) GetCoachReviews(req *dto.CoachReviewSearchRequest) ([]models.CoachReview, int64, error) {
+func (cu *CoachUsecase) GetCoachReviews(req *dto.CoachReviewSearchRequest) ([]models.CoachReview, pagination.PageInfo, error) {
 	query := cu.db.Model(&models.CoachReview{}).Preload("User").Preload("User.Profile").Preload("Lesson")
 
 	// 基本篩選條件
@@ -1192,43 +1410,59 @@ func (cu *CoachUsecase) GetCoachReviews(req *dto.CoachReviewSearchRequest) ([]mo
 		query = query.Where("tags && ?", pq.StringArray(req.Tags))
 	}
 
-	// 計算總數
-	var total int64
-	if err := query.Count(&total).Error; err != nil {
-		return nil, 0, errors.New("計算評價總數失敗")
-	}
-
 	// 排序
 	sortBy := req.SortBy
 	if sortBy == "" {
-		sortBy = "created_at"
+		sortBy = "createdAt"
 	}
-	sortOrder := req.SortOrder
-	if sortOrder == "" {
-		sortOrder = "desc"
+	sortKey, ok := coachReviewSortKeys[sortBy]
+	if !ok {
+		return nil, pagination.PageInfo{}, apperror.New(apperror.CodeUnsupportedSort)
 	}
-	query = query.Order(fmt.Sprintf("%s %s", sortBy, sortOrder))
 
 	// 分頁
-	page := req.Page
-	if page < 1 {
-		page = 1
-	}
-	limit := req.Limit
-	if limit < 1 || limit > 100 {
-		limit = 20
+	page := pagination.Query{
+		Keys:  []pagination.Key{sortKey, {Column: "id", Kind: pagination.KindString}},
+		Desc:  req.SortOrder != "asc",
+		Limit: req.Limit,
+		Page:  req.Page,
+	}
+	if req.Cursor != nil {
+		page.Cursor = *req.Cursor
+	}
+	page.Normalize(20, 100)
+
+	// 頁碼分頁時計算總數
+	var total *int64
+	if !page.IsCursor() {
+		var count int64
+		if err := query.Count(&count).Error; err != nil {
+			return nil, pagination.PageInfo{}, errors.New("計算評價總數失敗")
+		}
+		total = &count
 	}
-	offset := (page - 1) * limit
 
-	query = query.Offset(offset).Limit(limit)
+	query, err := page.Apply(query)
+	if err != nil {
+		return nil, pagination.PageInfo{}, err
+	}
 
 	// 執行查詢
 	var reviews []models.CoachReview
 	if err := query.Find(&reviews).Error; err != nil {
-		return nil, 0, errors.New("獲取評價列表失敗")
+		return nil, pagination.PageInfo{}, errors.New("獲取評價列表失敗")
 	}
 
-	return reviews, total, nil
+	return pagination.Build(reviews, &page, total, func(r *models.CoachReview) []interface{} {
+		switch sortKey.Column {
+		case "rating":
+			return []interface{}{r.Rating, r.ID}
+		case "is_helpful":
+			return []interface{}{r.IsHelpful, r.ID}
+		default:
+			return []interface{}{r.CreatedAt, r.ID}
+		}
+	})
 }
 
 // UpdateCoachReview 更新教練評價
@@ -1236,17 +1470,17 @@ func (cu *CoachUsecase) UpdateCoachReview(reviewID string, userID string, req *d
 	// 查找評價
 	var review models.CoachReview
 	if err := cu.db.Where("id = ?", reviewID).First(&review).Error; err != nil {
-		return nil, errors.New("評價不存在")
+		return nil, apperror.New(apperror.CodeCoachReviewNotFound)
 	}
 
 	// 檢查權限：只有評價者本人可以更新
 	if review.UserID != userID {
-		return nil, errors.New("無權限更新此評價")
+		return nil, apperror.New(apperror.CodeCoachReviewNotOwned)
 	}
 
 	// 檢查評價是否在可編輯時間內（例如：創建後24小時內）
 	if time.Since(review.CreatedAt) > 24*time.Hour {
-		return nil, errors.New("評價創建超過24小時，無法修改")
+		return nil, apperror.New(apperror.CodeCoachReviewNotEditable)
 	}
 
 	// 更新欄位
@@ -1281,17 +1515,17 @@ func (cu *CoachUsecase) DeleteCoachReview(reviewID string, userID string) error
 	// 查找評價
 	var review models.CoachReview
 	if err := cu.db.Where("id = ?", reviewID).First(&review).Error; err != nil {
-		return errors.New("評價不存在")
+		return apperror.New(apperror.CodeCoachReviewNotFound)
 	}
 
 	// 檢查權限：只有評價者本人可以刪除
 	if review.UserID != userID {
-		return errors.New("無權限刪除此評價")
+		return apperror.New(apperror.CodeCoachReviewNotOwned)
 	}
 
 	// 檢查評價是否在可刪除時間內（例如：創建後24小時內）
 	if time.Since(review.CreatedAt) > 24*time.Hour {
-		return errors.New("評價創建超過24小時，無法刪除")
+		return apperror.New(apperror.CodeCoachReviewNotEditable)
 	}
 
 	// 軟刪除評價
@@ -1307,12 +1541,12 @@ func (cu *CoachUsecase) MarkReviewHelpful(userID string, req *dto.MarkReviewHelp
 	// 查找評價
 	var review models.CoachReview
 	if err := cu.db.Where("id = ?", req.ReviewID).First(&review).Error; err != nil {
-		return nil, errors.New("評價不存在")
+		return nil, apperror.New(apperror.CodeCoachReviewNotFound)
 	}
 
 	// 檢查用戶不能標記自己的評價
 	if review.UserID == userID {
-		return nil, errors.New("不能標記自己的評價")
+		return nil, apperror.New(apperror.CodeCoachReviewOwnHelpful)
 	}
 
 	// 更新有用計數
@@ -1339,7 +1573,7 @@ func (cu *CoachUsecase) GetCoachReviewStatistics(coachID string) (map[string]int
 	// 檢查教練是否存在
 	var coach models.Coach
 	if err := cu.db.Where("id = ? AND is_active = ?", coachID, true).First(&coach).Error; err != nil {
-		return nil, errors.New("教練不存在")
+		return nil, apperror.New(apperror.CodeCoachNotFound)
 	}
 
 	// 獲取評分分佈
@@ -0,0 +1,47 @@
+package usecases
+
+import (
+	"tennis-platform/backend/internal/apperror"
+	"tennis-platform/backend/internal/dto"
+	"tennis-platform/backend/internal/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCoachUsecase_UpdateLessonRejectsCancellation(t *testing.T) {
+	db := setupCoachCalendarTestDB(t)
+	require.NoError(t, db.Exec(`INSERT INTO lessons (id, coach_id, duration, scheduled_at, status) VALUES ('lesson-1', ?, 60, ?, 'scheduled')`,
+		coachCalendarCoachID, time.Now().Add(48*time.Hour)).Error)
+	coaches := NewCoachUsecase(db, nil)
+
+	// 取消需經取消課程套用取消政策及退款
+	cancelled := "cancelled"
+	_, err := coaches.UpdateLesson("lesson-1", &dto.UpdateLessonRequest{Status: &cancelled}, nil)
+	assert.True(t, apperror.HasCode(err, apperror.CodeLessonStatusReadOnly))
+
+	var lesson models.Lesson
+	require.NoError(t, db.First(&lesson, "id = ?", "lesson-1").Error)
+	assert.Equal(t, "scheduled", lesson.Status)
+}
+
+func TestCoachUsecase_TypedErrors(t *testing.T) {
+	db := setupCoachCalendarTestDB(t)
+	coaches := NewCoachUsecase(db, nil)
+
+	_, err := coaches.UpdateLesson("missing", &dto.UpdateLessonRequest{}, nil)
+	assert.True(t, apperror.HasCode(err, apperror.CodeLessonNotFound))
+
+	// 可用時間的星期、時間段格式及先後順序各自返回對應錯誤碼
+	err = coaches.validateAvailableHours(models.AvailableHours{"someday": {"09:00-12:00"}})
+	assert.True(t, apperror.HasCode(err, apperror.CodeCoachInvalidWeekday))
+	err = coaches.validateAvailableHours(models.AvailableHours{"monday": {"9-12"}})
+	assert.True(t, apperror.HasCode(err, apperror.CodeCoachInvalidTimeSlot))
+	err = coaches.validateAvailableHours(models.AvailableHours{"monday": {"09:00-25:00"}})
+	assert.True(t, apperror.HasCode(err, apperror.CodeCoachInvalidTime))
+	err = coaches.validateAvailableHours(models.AvailableHours{"monday": {"12:00-09:00"}})
+	assert.True(t, apperror.HasCode(err, apperror.CodeCoachInvalidTimeRange))
+	assert.NoError(t, coaches.validateAvailableHours(models.AvailableHours{"monday": {"09:00-12:00"}}))
+}
@@ -0,0 +1,582 @@
+package usecases
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+	"tennis-platform/backend/internal/apperror"
+	"tennis-platform/backend/internal/dto"
+	"tennis-platform/backend/internal/models"
+	"tennis-platform/backend/internal/services"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// analyticsDefaultDays 未指定範圍時統計的天數，包含今天
+	analyticsDefaultDays = 30
+	// analyticsMaxDays 單次查詢的範圍上限
+	analyticsMaxDays = 366
+)
+
+// 需求計數的欄位
+const (
+	demandUnavailableViews = "unavailable_views"
+	demandRejectedBookings = "rejected_bookings"
+)
+
+// analyticsBookedStatuses 視為成立的預訂狀態，計入使用率及營收
+var analyticsBookedStatuses = []string{"confirmed", "completed", "no_show"}
+
+// CourtAnalyticsUsecase 場地分析用例，場地擁有者查看使用率、營收及未能滿足的需求
+//
+// 指標由開始時間在範圍內的預訂計算，日期、星期及小時以請求的時區判斷。
+type CourtAnalyticsUsecase struct {
+	db       *gorm.DB
+	bookings *BookingUsecase
+}
+
+// NewCourtAnalyticsUsecase 創建新的場地分析用例，營業時間及球場沿用 bookings 的解析方式
+func NewCourtAnalyticsUsecase(db *gorm.DB, bookings *BookingUsecase) *CourtAnalyticsUsecase {
+	return &CourtAnalyticsUsecase{db: db, bookings: bookings}
+}
+
+// analyticsRange 場地分析的日期範圍，end 為最後一天的隔天零時
+type analyticsRange struct {
+	from   time.Time
+	end    time.Time
+	loc    *time.Location
+	period string
+}
+
+// GetAnalytics 獲取場地在範圍內的預訂指標、各星期及小時的使用率及各週期的營收
+func (au *CourtAnalyticsUsecase) GetAnalytics(ctx context.Context, userID, courtID string, req *dto.CourtAnalyticsRequest) (*dto.CourtAnalyticsResponse, error) {
+	court, err := au.ownedCourt(ctx, userID, courtID)
+	if err != nil {
+		return nil, err
+	}
+	rng, err := parseAnalyticsRange(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var bookings []models.Booking
+	if err := au.db.WithContext(ctx).
+		Select("id", "user_id", "court_unit_id", "start_time", "end_time", "total_price", "status", "created_at").
+		Where("court_id = ? AND start_time >= ? AND start_time < ?", court.ID, rng.from.UTC(), rng.end.UTC()).
+		Order("start_time ASC").
+		Find(&bookings).Error; err != nil {
+		return nil, errors.New("獲取預訂失敗")
+	}
+
+	response := &dto.CourtAnalyticsResponse{
+		CourtID:  court.ID,
+		From:     rng.from.Format(closureDateLayout),
+		To:       rng.end.AddDate(0, 0, -1).Format(closureDateLayout),
+		Timezone: rng.loc.String(),
+		Period:   rng.period,
+		Currency: court.Currency,
+	}
+
+	var booked, capacity int64
+	response.Occupancy, booked, capacity, err = au.occupancy(ctx, court, bookings, rng)
+	if err != nil {
+		return nil, err
+	}
+	response.Revenue, err = au.revenue(ctx, bookings, rng)
+	if err != nil {
+		return nil, err
+	}
+	response.Summary, err = au.summary(ctx, court.ID, bookings, rng)
+	if err != nil {
+		return nil, err
+	}
+	response.Summary.OccupancyRate = percent(float64(booked), float64(capacity))
+	for _, period := range response.Revenue {
+		response.Summary.Revenue += period.Revenue
+	}
+	response.Summary.Revenue = math.Round(response.Summary.Revenue*100) / 100
+	return response, nil
+}
+
+// GetDemand 獲取場地在範圍內各星期及小時未能滿足的需求：查詢時已無空位的次數及預訂或保留時段失敗的次數
+func (au *CourtAnalyticsUsecase) GetDemand(ctx context.Context, userID, courtID string, req *dto.CourtAnalyticsRequest) (*dto.CourtDemandResponse, error) {
+	court, err := au.ownedCourt(ctx, userID, courtID)
+	if err != nil {
+		return nil, err
+	}
+	rng, err := parseAnalyticsRange(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var demands []models.CourtSlotDemand
+	if err := au.db.WithContext(ctx).
+		Where("court_id = ? AND slot_start >= ? AND slot_start < ?", court.ID, rng.from.UTC(), rng.end.UTC()).
+		Find(&demands).Error; err != nil {
+		return nil, errors.New("獲取需求記錄失敗")
+	}
+
+	response := &dto.CourtDemandResponse{
+		CourtID:  court.ID,
+		From:     rng.from.Format(closureDateLayout),
+		To:       rng.end.AddDate(0, 0, -1).Format(closureDateLayout),
+		Timezone: rng.loc.String(),
+		Cells:    []dto.DemandCell{},
+	}
+	var grid [7][24]dto.DemandCell
+	for _, demand := range demands {
+		slot := demand.SlotStart.In(rng.loc)
+		cell := &grid[slot.Weekday()][slot.Hour()]
+		cell.UnavailableViews += demand.UnavailableViews
+		cell.RejectedBookings += demand.RejectedBookings
+		response.TotalUnavailableViews += demand.UnavailableViews
+		response.TotalRejectedBookings += demand.RejectedBookings
+	}
+	for weekday := range grid {
+		for hour, cell := range grid[weekday] {
+			if cell.UnavailableViews == 0 && cell.RejectedBookings == 0 {
+				continue
+			}
+			cell.Weekday, cell.Hour = weekday, hour
+			response.Cells = append(response.Cells, cell)
+		}
+	}
+	return response, nil
+}
+
+// ExportAnalytics 以 CSV 匯出指定的報表：summary 為各項指標，occupancy、revenue、demand 為對應的列表
+func (au *CourtAnalyticsUsecase) ExportAnalytics(ctx context.Context, userID, courtID string, req *dto.CourtAnalyticsExportRequest) ([]byte, error) {
+	var rows [][]string
+	if req.Report == "demand" {
+		demand, err := au.GetDemand(ctx, userID, courtID, &req.CourtAnalyticsRequest)
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, []string{"weekday", "hour", "unavailable_views", "rejected_bookings"})
+		for _, cell := range demand.Cells {
+			rows = append(rows, []string{time.Weekday(cell.Weekday).String(), strconv.Itoa(cell.Hour), formatInt(cell.UnavailableViews), formatInt(cell.RejectedBookings)})
+		}
+	} else {
+		analytics, err := au.GetAnalytics(ctx, userID, courtID, &req.CourtAnalyticsRequest)
+		if err != nil {
+			return nil, err
+		}
+		switch req.Report {
+		case "summary":
+			summary := analytics.Summary
+			rows = [][]string{
+				{"metric", "value"},
+				{"from", analytics.From},
+				{"to", analytics.To},
+				{"timezone", analytics.Timezone},
+				{"currency", analytics.Currency},
+				{"bookings", formatInt(summary.Bookings)},
+				{"cancelled", formatInt(summary.Cancelled)},
+				{"no_shows", formatInt(summary.NoShows)},
+				{"cancellation_rate", formatFloat(summary.CancellationRate)},
+				{"no_show_rate", formatFloat(summary.NoShowRate)},
+				{"occupancy_rate", formatFloat(summary.OccupancyRate)},
+				{"revenue", formatFloat(summary.Revenue)},
+				{"average_lead_hours", formatFloat(summary.AverageLeadHours)},
+				{"customers", formatInt(summary.Customers)},
+				{"repeat_customers", formatInt(summary.RepeatCustomers)},
+				{"repeat_customer_rate", formatFloat(summary.RepeatCustomerRate)},
+			}
+		case "occupancy":
+			rows = append(rows, []string{"weekday", "hour", "booked_minutes", "capacity_minutes", "rate"})
+			for _, cell := range analytics.Occupancy {
+				rows = append(rows, []string{time.Weekday(cell.Weekday).String(), strconv.Itoa(cell.Hour), formatInt(cell.BookedMinutes), formatInt(cell.CapacityMinutes), formatFloat(cell.Rate)})
+			}
+		case "revenue":
+			rows = append(rows, []string{"period", "bookings", "booking_revenue", "cancellation_fees", "revenue"})
+			for _, period := range analytics.Revenue {
+				rows = append(rows, []string{period.Period, formatInt(period.Bookings), formatFloat(period.BookingRevenue), formatFloat(period.CancellationFees), formatFloat(period.Revenue)})
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	if err := writer.WriteAll(rows); err != nil {
+		return nil, errors.New("匯出報表失敗")
+	}
+	return buf.Bytes(), nil
+}
+
+// occupancy 計算各星期及小時已預訂及可預訂的分鐘數
+//
+// 可預訂的分鐘數為當天營業時間乘以開放預訂的球場數，扣除封鎖的時段及停用的球場；
+// 未設置球場的場地視為一個球場，包下整個場地的預訂佔用所有球場。
+func (au *CourtAnalyticsUsecase) occupancy(ctx context.Context, court *models.Court, bookings []models.Booking, rng *analyticsRange) ([]dto.OccupancyCell, int64, int64, error) {
+	db := au.db.WithContext(ctx)
+	units, err := au.bookings.courtUnits(db, court.ID)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	activeUnits := map[string]bool{}
+	for _, unit := range units {
+		if unit.IsActive {
+			activeUnits[unit.ID] = true
+		}
+	}
+	unitCount := int64(len(activeUnits))
+	if len(units) == 0 {
+		unitCount = 1
+	}
+
+	closures, err := courtClosures(db, court.ID, rng.from, rng.end)
+	if err != nil {
+		return nil, 0, 0, errors.New("獲取場地例外失敗")
+	}
+
+	var booked, capacity [7][24]int64
+	for day := rng.from; day.Before(rng.end); day = day.AddDate(0, 0, 1) {
+		hours, _, err := dayOperatingHours(court, closures, day)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		if hours == "closed" {
+			continue
+		}
+		openTime, closeTime, err := au.bookings.parseOperatingHours(hours)
+		if err != nil {
+			return nil, 0, 0, errors.New("解析營業時間失敗")
+		}
+		openAt := time.Date(day.Year(), day.Month(), day.Day(), 0, int(openTime.Minutes()), 0, 0, rng.loc)
+		closeAt := time.Date(day.Year(), day.Month(), day.Day(), 0, int(closeTime.Minutes()), 0, 0, rng.loc)
+		addHourlyMinutes(&capacity, openAt, closeAt, unitCount)
+
+		// 更改營業時間及整個場地休館已反映在當天的營業時間
+		for i := range closures {
+			closure := &closures[i]
+			weight := unitCount
+			if closure.CourtUnitID != nil {
+				if !activeUnits[*closure.CourtUnitID] {
+					continue
+				}
+				weight = 1
+			} else if closure.Kind != models.CourtClosureBlock {
+				continue
+			}
+			for _, interval := range closureIntervals(closure, openAt, closeAt) {
+				addHourlyMinutes(&capacity, laterTime(interval[0], openAt), earlierTime(interval[1], closeAt), -weight)
+			}
+		}
+	}
+
+	for _, booking := range bookings {
+		if !isAnalyticsBooked(booking.Status) {
+			continue
+		}
+		weight := int64(1)
+		if booking.CourtUnitID == nil {
+			weight = unitCount
+		}
+		addHourlyMinutes(&booked, booking.StartTime.In(rng.loc), earlierTime(booking.EndTime, rng.end).In(rng.loc), weight)
+	}
+
+	// 封鎖的時段可能重疊，已預訂的分鐘數不超過可預訂的分鐘數
+	cells := []dto.OccupancyCell{}
+	var totalBooked, totalCapacity int64
+	for weekday := range capacity {
+		for hour := range capacity[weekday] {
+			available := max(capacity[weekday][hour], 0)
+			if available == 0 {
+				continue
+			}
+			used := min(booked[weekday][hour], available)
+			totalBooked += used
+			totalCapacity += available
+			cells = append(cells, dto.OccupancyCell{
+				Weekday:         weekday,
+				Hour:            hour,
+				BookedMinutes:   used,
+				CapacityMinutes: available,
+				Rate:            percent(float64(used), float64(available)),
+			})
+		}
+	}
+	return cells, totalBooked, totalCapacity, nil
+}
+
+// revenue 按預訂開始時間所在的週期統計成立預訂的金額及已取消預訂的手續費
+func (au *CourtAnalyticsUsecase) revenue(ctx context.Context, bookings []models.Booking, rng *analyticsRange) ([]dto.RevenuePeriod, error) {
+	periods := []dto.RevenuePeriod{}
+	index := map[string]int{}
+	for start := analyticsPeriodStart(rng.from, rng.period); start.Before(rng.end); start = nextAnalyticsPeriod(start, rng.period) {
+		key := start.Format(closureDateLayout)
+		index[key] = len(periods)
+		periods = append(periods, dto.RevenuePeriod{Period: key})
+	}
+	periodOf := func(booking *models.Booking) *dto.RevenuePeriod {
+		return &periods[index[analyticsPeriodStart(booking.StartTime.In(rng.loc), rng.period).Format(closureDateLayout)]]
+	}
+
+	cancelled := map[string]*models.Booking{}
+	cancelledIDs := []string{}
+	for i := range bookings {
+		booking := &bookings[i]
+		switch {
+		case isAnalyticsBooked(booking.Status):
+			period := periodOf(booking)
+			period.Bookings++
+			period.BookingRevenue += booking.TotalPrice
+		case booking.Status == "cancelled":
+			cancelled[booking.ID] = booking
+			cancelledIDs = append(cancelledIDs, booking.ID)
+		}
+	}
+
+	// 分攤付款的預訂另有各分攤的取消記錄，預訂本身的記錄已包含全部分攤，只計算一次
+	if len(cancelledIDs) > 0 {
+		var cancellations []models.Cancellation
+		if err := au.db.WithContext(ctx).Select("target_id", "fee_amount").
+			Where("target_type = ? AND target_id IN ?", services.PaymentTargetBooking, cancelledIDs).
+			Find(&cancellations).Error; err != nil {
+			return nil, errors.New("獲取取消記錄失敗")
+		}
+		for _, cancellation := range cancellations {
+			periodOf(cancelled[cancellation.TargetID]).CancellationFees += cancellation.FeeAmount
+		}
+	}
+
+	for i := range periods {
+		period := &periods[i]
+		period.BookingRevenue = math.Round(period.BookingRevenue*100) / 100
+		period.CancellationFees = math.Round(period.CancellationFees*100) / 100
+		period.Revenue = math.Round((period.BookingRevenue+period.CancellationFees)*100) / 100
+	}
+	return periods, nil
+}
+
+// summary 計算取消率、未到場率、平均提前預訂時數及回頭客比例
+func (au *CourtAnalyticsUsecase) summary(ctx context.Context, courtID string, bookings []models.Booking, rng *analyticsRange) (dto.CourtAnalyticsSummary, error) {
+	summary := dto.CourtAnalyticsSummary{Bookings: int64(len(bookings))}
+	now := time.Now()
+	var booked, ended int64
+	var leadHours float64
+	customers := map[string]bool{}
+	for _, booking := range bookings {
+		switch booking.Status {
+		case "cancelled":
+			summary.Cancelled++
+		case "no_show":
+			summary.NoShows++
+		}
+		if !isAnalyticsBooked(booking.Status) {
+			continue
+		}
+		booked++
+		if !booking.EndTime.After(now) {
+			ended++
+		}
+		leadHours += booking.StartTime.Sub(booking.CreatedAt).Hours()
+		customers[booking.UserID] = true
+	}
+
+	summary.CancellationRate = percent(float64(summary.Cancelled), float64(summary.Bookings))
+	summary.NoShowRate = percent(float64(summary.NoShows), float64(ended))
+	summary.Customers = int64(len(customers))
+	if booked > 0 {
+		summary.AverageLeadHours = math.Round(leadHours/float64(booked)*100) / 100
+	}
+	if len(customers) == 0 {
+		return summary, nil
+	}
+
+	// 回頭客包含範圍開始前已在此場地預訂過的用戶
+	userIDs := make([]string, 0, len(customers))
+	for userID := range customers {
+		userIDs = append(userIDs, userID)
+	}
+	sort.Strings(userIDs)
+	var repeat []string
+	if err := au.db.WithContext(ctx).Model(&models.Booking{}).
+		Where("court_id = ? AND status IN ? AND start_time < ? AND user_id IN ?", courtID, analyticsBookedStatuses, rng.end.UTC(), userIDs).
+		Group("user_id").
+		Having("COUNT(*) >= 2").
+		Pluck("user_id", &repeat).Error; err != nil {
+		return summary, errors.New("獲取回頭客失敗")
+	}
+	summary.RepeatCustomers = int64(len(repeat))
+	summary.RepeatCustomerRate = percent(float64(summary.RepeatCustomers), float64(summary.Customers))
+	return summary, nil
+}
+
+// ownedCourt 獲取場地並確認用戶是場地擁有者
+func (au *CourtAnalyticsUsecase) ownedCourt(ctx context.Context, userID, courtID string) (*models.Court, error) {
+	var court models.Court
+	if err := au.db.WithContext(ctx).Where("id = ?", courtID).First(&court).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.New(apperror.CodeCourtNotFound)
+		}
+		return nil, errors.New("獲取場地失敗")
+	}
+	if court.OwnerID == nil || *court.OwnerID != userID {
+		return nil, apperror.New(apperror.CodeCourtAnalyticsForbidden)
+	}
+	return &court, nil
+}
+
+// parseAnalyticsRange 解析請求的時區及日期範圍，默認為包含今天的最近 30 天
+func parseAnalyticsRange(req *dto.CourtAnalyticsRequest) (*analyticsRange, error) {
+	loc := time.UTC
+	if req.Timezone != "" {
+		// Local 依伺服器設定而不同，不接受
+		l, err := time.LoadLocation(req.Timezone)
+		if err != nil || req.Timezone == "Local" {
+			return nil, apperror.New(apperror.CodeCourtAnalyticsInvalidTimezone)
+		}
+		loc = l
+	}
+
+	invalid := apperror.New(apperror.CodeCourtAnalyticsInvalidRange).With("days", analyticsMaxDays)
+	now := time.Now().In(loc)
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	if req.To != nil {
+		parsed, err := time.ParseInLocation(closureDateLayout, *req.To, loc)
+		if err != nil {
+			return nil, invalid
+		}
+		to = parsed
+	}
+	from := to.AddDate(0, 0, 1-analyticsDefaultDays)
+	if req.From != nil {
+		parsed, err := time.ParseInLocation(closureDateLayout, *req.From, loc)
+		if err != nil {
+			return nil, invalid
+		}
+		from = parsed
+	}
+	end := to.AddDate(0, 0, 1)
+	if !from.Before(end) || from.AddDate(0, 0, analyticsMaxDays).Before(end) {
+		return nil, invalid
+	}
+
+	period := req.Period
+	if period == "" {
+		period = "day"
+	}
+	return &analyticsRange{from: from, end: end, loc: loc, period: period}, nil
+}
+
+// analyticsPeriodStart 返回 t 所在週期的開始日期，週從週一開始
+func analyticsPeriodStart(t time.Time, period string) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	switch period {
+	case "week":
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	case "month":
+		return day.AddDate(0, 0, 1-day.Day())
+	}
+	return day
+}
+
+// nextAnalyticsPeriod 返回下一個週期的開始日期
+func nextAnalyticsPeriod(start time.Time, period string) time.Time {
+	switch period {
+	case "week":
+		return start.AddDate(0, 0, 7)
+	case "month":
+		return start.AddDate(0, 1, 0)
+	}
+	return start.AddDate(0, 0, 1)
+}
+
+// addHourlyMinutes 將 start 至 end 按所在的星期及小時累加分鐘數乘以 weight
+func addHourlyMinutes(grid *[7][24]int64, start, end time.Time, weight int64) {
+	for start.Before(end) {
+		next := time.Date(start.Year(), start.Month(), start.Day(), start.Hour()+1, 0, 0, 0, start.Location())
+		if next.After(end) {
+			next = end
+		}
+		grid[start.Weekday()][start.Hour()] += int64(next.Sub(start).Minutes()) * weight
+		start = next
+	}
+}
+
+// recordSlotDemand 累加場地在各時段所涵蓋整點的需求計數，同一整點只計一次，已結束的整點不計
+//
+// 需求記錄只用於分析，失敗時記錄日誌，不影響查詢或預訂。
+func recordSlotDemand(db *gorm.DB, courtID, column string, slots [][2]time.Time) {
+	now := time.Now()
+	seen := map[time.Time]bool{}
+	var demands []models.CourtSlotDemand
+	for _, slot := range slots {
+		for hour := slot[0].UTC().Truncate(time.Hour); hour.Before(slot[1]); hour = hour.Add(time.Hour) {
+			if seen[hour] || !hour.Add(time.Hour).After(now) {
+				continue
+			}
+			seen[hour] = true
+			demand := models.CourtSlotDemand{CourtID: courtID, SlotStart: hour}
+			if column == demandUnavailableViews {
+				demand.UnavailableViews = 1
+			} else {
+				demand.RejectedBookings = 1
+			}
+			demands = append(demands, demand)
+		}
+	}
+	if len(demands) == 0 {
+		return
+	}
+
+	err := db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "court_id"}, {Name: "slot_start"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			column:       gorm.Expr("court_slot_demands." + column + " + 1"),
+			"updated_at": now,
+		}),
+	}).Create(&demands).Error
+	if err != nil {
+		log.Printf("Failed to record slot demand for court %s: %v", courtID, err)
+	}
+}
+
+// isAnalyticsBooked 判斷預訂狀態是否視為成立
+func isAnalyticsBooked(status string) bool {
+	for _, booked := range analyticsBookedStatuses {
+		if status == booked {
+			return true
+		}
+	}
+	return false
+}
+
+// percent 返回 part 佔 whole 的百分比，取至小數點後兩位
+func percent(part, whole float64) float64 {
+	if whole == 0 {
+		return 0
+	}
+	return math.Round(part/whole*10000) / 100
+}
+
+func laterTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+func earlierTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+func formatInt(v int64) string {
+	return strconv.FormatInt(v, 10)
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
@@ -0,0 +1,153 @@
+package usecases
+
+import (
+	"context"
+	"strings"
+	"tennis-platform/backend/internal/apperror"
+	"tennis-platform/backend/internal/dto"
+	"tennis-platform/backend/internal/models"
+	"tennis-platform/backend/internal/services"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	analyticsCourtID   = "66666666-6666-6666-6666-666666666666"
+	analyticsRegularID = "77777777-7777-7777-7777-777777777771"
+	analyticsNewUserID = "77777777-7777-7777-7777-777777777772"
+)
+
+func TestCourtAnalyticsUsecase_GetAnalytics(t *testing.T) {
+	db := setupBookingTestDB(t)
+	uc := NewCourtAnalyticsUsecase(db, NewBookingUsecase(db, nil))
+	ctx := context.Background()
+
+	// 2024-03-04 為週一，時間以台北時間表示
+	taipei, err := time.LoadLocation("Asia/Taipei")
+	require.NoError(t, err)
+	at := func(day, hour int) time.Time {
+		return time.Date(2024, 3, day, hour, 0, 0, 0, taipei).UTC()
+	}
+	addBooking := func(id, userID string, day, hour, hours int, price float64, status string, leadHours int) {
+		start := at(day, hour)
+		require.NoError(t, db.Create(&models.Booking{
+			ID: id, CourtID: analyticsCourtID, UserID: userID, StartTime: start, EndTime: start.Add(time.Duration(hours) * time.Hour),
+			TotalPrice: price, Status: status, CreatedAt: start.Add(-time.Duration(leadHours) * time.Hour),
+		}).Error)
+	}
+	addBooking("b0000000-0000-0000-0000-000000000001", bookingTestUserID, 4, 10, 2, 800, "confirmed", 48)
+	addBooking("b0000000-0000-0000-0000-000000000002", analyticsRegularID, 5, 10, 1, 400, "completed", 24)
+	addBooking("b0000000-0000-0000-0000-000000000003", bookingTestUserID, 6, 10, 1, 400, "cancelled", 24)
+	addBooking("b0000000-0000-0000-0000-000000000004", bookingTestUserID, 7, 10, 1, 400, "no_show", 12)
+	addBooking("b0000000-0000-0000-0000-000000000005", analyticsNewUserID, 8, 20, 1, 400, "confirmed", 24)
+	// 範圍開始前的預訂讓該用戶成為回頭客
+	addBooking("b0000000-0000-0000-0000-000000000006", analyticsRegularID, 1, 10, 1, 400, "completed", 24)
+
+	// 分攤的取消記錄已包含在預訂的取消記錄中
+	require.NoError(t, db.Create(&models.Cancellation{TargetType: services.PaymentTargetBooking, TargetID: "b0000000-0000-0000-0000-000000000003", CancelledBy: bookingTestUserID, Initiator: services.CancellationInitiatorCustomer, FeeAmount: 100}).Error)
+	require.NoError(t, db.Create(&models.Cancellation{TargetType: services.PaymentTargetBookingShare, TargetID: "c0000000-0000-0000-0000-000000000003", CancelledBy: bookingTestUserID, Initiator: services.CancellationInitiatorCustomer, FeeAmount: 50}).Error)
+
+	// 週六上午封鎖 4 小時
+	blockStart, blockEnd := "08:00", "12:00"
+	require.NoError(t, db.Create(&models.CourtClosure{
+		CourtID: analyticsCourtID, Kind: models.CourtClosureBlock, StartDate: "2024-03-09", EndDate: "2024-03-09",
+		StartTime: &blockStart, EndTime: &blockEnd, Reason: models.CourtClosureReasonMaintenance, CreatedBy: bookingTestOwnerID,
+	}).Error)
+
+	req := &dto.CourtAnalyticsRequest{From: stringPtr("2024-03-04"), To: stringPtr("2024-03-10"), Timezone: "Asia/Taipei", Period: "week"}
+	_, err = uc.GetAnalytics(ctx, bookingTestUserID, analyticsCourtID, req)
+	assert.True(t, apperror.HasCode(err, apperror.CodeCourtAnalyticsForbidden))
+	_, err = uc.GetAnalytics(ctx, bookingTestOwnerID, analyticsCourtID, &dto.CourtAnalyticsRequest{Timezone: "Mars/Olympus"})
+	assert.True(t, apperror.HasCode(err, apperror.CodeCourtAnalyticsInvalidTimezone))
+	_, err = uc.GetAnalytics(ctx, bookingTestOwnerID, analyticsCourtID, &dto.CourtAnalyticsRequest{From: stringPtr("2024-03-10"), To: stringPtr("2024-03-04")})
+	assert.True(t, apperror.HasCode(err, apperror.CodeCourtAnalyticsInvalidRange))
+	_, err = uc.GetAnalytics(ctx, bookingTestOwnerID, analyticsCourtID, &dto.CourtAnalyticsRequest{From: stringPtr("2023-01-01"), To: stringPtr("2024-03-04")})
+	assert.True(t, apperror.HasCode(err, apperror.CodeCourtAnalyticsInvalidRange))
+
+	analytics, err := uc.GetAnalytics(ctx, bookingTestOwnerID, analyticsCourtID, req)
+	require.NoError(t, err)
+	summary := analytics.Summary
+	assert.Equal(t, int64(5), summary.Bookings)
+	assert.Equal(t, int64(1), summary.Cancelled)
+	assert.Equal(t, int64(1), summary.NoShows)
+	assert.InDelta(t, 20, summary.CancellationRate, 0.001)
+	assert.InDelta(t, 25, summary.NoShowRate, 0.001)
+	assert.InDelta(t, 27, summary.AverageLeadHours, 0.001)
+	assert.Equal(t, int64(3), summary.Customers)
+	assert.Equal(t, int64(2), summary.RepeatCustomers)
+	assert.InDelta(t, 66.67, summary.RepeatCustomerRate, 0.001)
+	assert.InDelta(t, 2100, summary.Revenue, 0.001)
+
+	// 每天營業 14 小時，扣除週六封鎖的 4 小時；已預訂 5 小時
+	assert.InDelta(t, 5.32, summary.OccupancyRate, 0.001)
+	cells := map[[2]int]dto.OccupancyCell{}
+	for _, cell := range analytics.Occupancy {
+		cells[[2]int{cell.Weekday, cell.Hour}] = cell
+	}
+	assert.Equal(t, int64(60), cells[[2]int{int(time.Monday), 11}].BookedMinutes)
+	assert.InDelta(t, 100, cells[[2]int{int(time.Monday), 11}].Rate, 0.001)
+	assert.Zero(t, cells[[2]int{int(time.Wednesday), 10}].BookedMinutes)
+	assert.Equal(t, int64(60), cells[[2]int{int(time.Wednesday), 10}].CapacityMinutes)
+	_, blocked := cells[[2]int{int(time.Saturday), 9}]
+	assert.False(t, blocked)
+	_, outside := cells[[2]int{int(time.Monday), 7}]
+	assert.False(t, outside)
+
+	require.Len(t, analytics.Revenue, 1)
+	assert.Equal(t, dto.RevenuePeriod{Period: "2024-03-04", Bookings: 4, BookingRevenue: 2000, CancellationFees: 100, Revenue: 2100}, analytics.Revenue[0])
+
+	req.Period = "day"
+	daily, err := uc.GetAnalytics(ctx, bookingTestOwnerID, analyticsCourtID, req)
+	require.NoError(t, err)
+	require.Len(t, daily.Revenue, 7)
+	assert.InDelta(t, 800, daily.Revenue[0].Revenue, 0.001)
+	assert.InDelta(t, 100, daily.Revenue[2].Revenue, 0.001)
+
+	// CSV 匯出
+	data, err := uc.ExportAnalytics(ctx, bookingTestOwnerID, analyticsCourtID, &dto.CourtAnalyticsExportRequest{CourtAnalyticsRequest: *req, Report: "revenue"})
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 8)
+	assert.Equal(t, "period,bookings,booking_revenue,cancellation_fees,revenue", lines[0])
+	assert.Equal(t, "2024-03-04,1,800,0,800", lines[1])
+}
+
+func TestCourtAnalyticsUsecase_Demand(t *testing.T) {
+	db := setupBookingTestDB(t)
+	bookings := NewBookingUsecase(db, nil)
+	uc := NewCourtAnalyticsUsecase(db, bookings)
+	ctx := context.Background()
+	start := bookingTestStart()
+
+	_, err := bookings.CreateBooking(bookingTestUserID, &dto.CreateBookingRequest{CourtID: analyticsCourtID, StartTime: start, EndTime: start.Add(time.Hour)})
+	require.NoError(t, err)
+
+	// 查詢可用時段時已無空位的整點每次查詢只計一次
+	for i := 0; i < 2; i++ {
+		_, err = bookings.GetAvailability(&dto.AvailabilityRequest{CourtID: analyticsCourtID, Date: start})
+		require.NoError(t, err)
+	}
+	_, err = bookings.CreateBooking(analyticsRegularID, &dto.CreateBookingRequest{CourtID: analyticsCourtID, StartTime: start, EndTime: start.Add(time.Hour)})
+	assert.True(t, apperror.HasCode(err, apperror.CodeBookingSlotTaken))
+
+	day := start.Format(closureDateLayout)
+	demand, err := uc.GetDemand(ctx, bookingTestOwnerID, analyticsCourtID, &dto.CourtAnalyticsRequest{From: &day, To: &day})
+	require.NoError(t, err)
+	var cell *dto.DemandCell
+	for i := range demand.Cells {
+		if demand.Cells[i].Hour == start.Hour() {
+			cell = &demand.Cells[i]
+		}
+	}
+	require.NotNil(t, cell)
+	assert.Equal(t, int(start.Weekday()), cell.Weekday)
+	assert.Equal(t, int64(2), cell.UnavailableViews)
+	assert.Equal(t, int64(1), cell.RejectedBookings)
+	assert.Equal(t, int64(1), demand.TotalRejectedBookings)
+
+	_, err = uc.GetDemand(ctx, bookingTestUserID, analyticsCourtID, &dto.CourtAnalyticsRequest{From: &day, To: &day})
+	assert.True(t, apperror.HasCode(err, apperror.CodeCourtAnalyticsForbidden))
+}
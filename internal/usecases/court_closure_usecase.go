@@ -0,0 +1,405 @@
+package usecases
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"tennis-platform/backend/internal/apperror"
+	"tennis-platform/backend/internal/dto"
+	"tennis-platform/backend/internal/models"
+	"tennis-platform/backend/internal/services"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// closureDateLayout 場地例外的日期格式
+const closureDateLayout = "2006-01-02"
+
+// CourtClosureUsecase 場地例外用例，場地擁有者設置休館、更改營業時間及封鎖時段
+//
+// 例外涵蓋已有的預訂時，由場地取消這些預訂並全額退款，預訂者會收到說明原因的通知。
+type CourtClosureUsecase struct {
+	db       *gorm.DB
+	bookings *BookingUsecase
+}
+
+// NewCourtClosureUsecase 創建新的場地例外用例，取消受影響的預訂時使用 bookings 的取消流程
+func NewCourtClosureUsecase(db *gorm.DB, bookings *BookingUsecase) *CourtClosureUsecase {
+	return &CourtClosureUsecase{db: db, bookings: bookings}
+}
+
+// GetClosures 獲取場地的例外，按開始日期排序，未指定範圍時列出尚未結束的例外
+func (cu *CourtClosureUsecase) GetClosures(ctx context.Context, courtID string, req *dto.CourtClosureListRequest) ([]models.CourtClosure, error) {
+	var court models.Court
+	if err := cu.db.WithContext(ctx).Select("id").Where("id = ?", courtID).First(&court).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.New(apperror.CodeCourtNotFound)
+		}
+		return nil, errors.New("獲取場地失敗")
+	}
+
+	from := time.Now().Format(closureDateLayout)
+	if req.From != nil {
+		from = *req.From
+	}
+	query := cu.db.WithContext(ctx).Preload("CourtUnit").Where("court_id = ? AND end_date >= ?", court.ID, from)
+	if req.To != nil {
+		query = query.Where("start_date <= ?", *req.To)
+	}
+
+	closures := []models.CourtClosure{}
+	if err := query.Order("start_date ASC, created_at ASC").Find(&closures).Error; err != nil {
+		return nil, errors.New("獲取場地例外失敗")
+	}
+	return closures, nil
+}
+
+// PreviewClosure 預覽例外會取消的預訂及退款金額，不寫入例外
+func (cu *CourtClosureUsecase) PreviewClosure(ctx context.Context, userID, courtID string, req *dto.CourtClosureRequest) (*dto.CourtClosureImpact, error) {
+	if err := cu.checkOwner(ctx, userID, courtID); err != nil {
+		return nil, err
+	}
+
+	closure := models.CourtClosure{CourtID: courtID, CreatedBy: userID}
+	if err := cu.applyRequest(ctx, &closure, req); err != nil {
+		return nil, err
+	}
+
+	affected, err := cu.affectedBookings(ctx, &closure)
+	if err != nil {
+		return nil, err
+	}
+
+	impact := &dto.CourtClosureImpact{Bookings: make([]dto.AffectedBooking, 0, len(affected))}
+	now := time.Now()
+	for i := range affected {
+		booking := &affected[i]
+		quote, err := cu.bookings.quoteBookingCancellation(ctx, booking, bookingCancellationTarget(booking), services.CancellationInitiatorProvider, now)
+		if err != nil {
+			return nil, errors.New("計算退款金額失敗")
+		}
+		impact.Bookings = append(impact.Bookings, dto.AffectedBooking{
+			BookingID:    booking.ID,
+			UserID:       booking.UserID,
+			CourtUnitID:  booking.CourtUnitID,
+			StartTime:    booking.StartTime,
+			EndTime:      booking.EndTime,
+			Status:       booking.Status,
+			PaidAmount:   quote.PaidAmount,
+			RefundAmount: quote.RefundAmount,
+		})
+		impact.RefundAmount += quote.RefundAmount
+	}
+	impact.RefundAmount = math.Round(impact.RefundAmount*100) / 100
+	return impact, nil
+}
+
+// CreateClosure 創建場地例外，並取消例外涵蓋的預訂
+//
+// 例外在場地鎖內寫入，之後的預訂及保留都會檢查例外；已有的預訂由場地取消並全額退款。
+// 已付款的退款在取消後才發起，失敗時由取消政策服務定期重試。
+func (cu *CourtClosureUsecase) CreateClosure(ctx context.Context, userID, courtID string, req *dto.CourtClosureRequest) (*dto.CourtClosureResponse, error) {
+	if err := cu.checkOwner(ctx, userID, courtID); err != nil {
+		return nil, err
+	}
+
+	closure := models.CourtClosure{CourtID: courtID, CreatedBy: userID}
+	if err := cu.applyRequest(ctx, &closure, req); err != nil {
+		return nil, err
+	}
+
+	if err := cu.bookings.withCourtLock(courtID, func(tx *gorm.DB) error {
+		return tx.Create(&closure).Error
+	}); err != nil {
+		return nil, errors.New("創建場地例外失敗")
+	}
+
+	cancellations, err := cu.cancelAffectedBookings(ctx, userID, &closure)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cu.db.WithContext(ctx).Preload("CourtUnit").First(&closure, "id = ?", closure.ID).Error; err != nil {
+		return nil, errors.New("載入場地例外失敗")
+	}
+	return &dto.CourtClosureResponse{
+		Closure:       &closure,
+		Cancellations: cancellations,
+	}, nil
+}
+
+// DeleteClosure 刪除場地例外，時段恢復開放，已取消的預訂不會恢復
+func (cu *CourtClosureUsecase) DeleteClosure(ctx context.Context, userID, courtID, closureID string) error {
+	if err := cu.checkOwner(ctx, userID, courtID); err != nil {
+		return err
+	}
+
+	result := cu.db.WithContext(ctx).Where("id = ? AND court_id = ?", closureID, courtID).Delete(&models.CourtClosure{})
+	if result.Error != nil {
+		return errors.New("刪除場地例外失敗")
+	}
+	if result.RowsAffected == 0 {
+		return apperror.New(apperror.CodeCourtClosureNotFound)
+	}
+	return nil
+}
+
+// cancelAffectedBookings 由場地取消例外涵蓋的預訂並全額退款，返回取消記錄
+func (cu *CourtClosureUsecase) cancelAffectedBookings(ctx context.Context, userID string, closure *models.CourtClosure) ([]models.Cancellation, error) {
+	affected, err := cu.affectedBookings(ctx, closure)
+	if err != nil {
+		return nil, err
+	}
+	cancellations := make([]models.Cancellation, 0, len(affected))
+	if len(affected) == 0 {
+		return cancellations, nil
+	}
+
+	targets := make([]*services.CancellationTarget, len(affected))
+	quotes := make([]*services.CancellationQuote, len(affected))
+	now := time.Now()
+	for i := range affected {
+		targets[i] = bookingCancellationTarget(&affected[i])
+		quotes[i], err = cu.bookings.quoteBookingCancellation(ctx, &affected[i], targets[i], services.CancellationInitiatorProvider, now)
+		if err != nil {
+			return nil, errors.New("計算退款金額失敗")
+		}
+	}
+
+	reason := closure.Reason
+	if closure.Note != nil && *closure.Note != "" {
+		reason = *closure.Note
+	}
+	err = cu.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for i := range affected {
+			cancellation, err := cu.bookings.cancelBooking(tx, &affected[i], targets[i], quotes[i], userID, &reason, closure)
+			if err != nil {
+				return err
+			}
+			cancellations = append(cancellations, *cancellation)
+		}
+		return nil
+	})
+	if err != nil {
+		var appErr *apperror.Error
+		if errors.As(err, &appErr) {
+			return nil, err
+		}
+		return nil, errors.New("取消受影響的預訂失敗")
+	}
+	cu.bookings.notifyEventBus()
+
+	for i := range affected {
+		cu.bookings.settleCancellation(ctx, &affected[i], &cancellations[i])
+		// 整組付款的金額已改變，取消以舊金額發起且尚未完成的付款
+		if affected[i].SeriesID != nil {
+			cu.bookings.cancellations.ReleasePayments(ctx, services.PaymentTargetBookingSeries, *affected[i].SeriesID)
+		}
+	}
+	return cancellations, nil
+}
+
+// affectedBookings 獲取尚未結束且與例外時段重疊的有效預訂
+// 指定球場的例外只影響該球場及包下整個場地的預訂；更改營業時間影響新營業時間以外的預訂
+func (cu *CourtClosureUsecase) affectedBookings(ctx context.Context, closure *models.CourtClosure) ([]models.Booking, error) {
+	// 日期以預訂時間所在時區判斷，先以前後各一天的範圍篩選候選預訂
+	startDate, err := time.Parse(closureDateLayout, closure.StartDate)
+	if err != nil {
+		return nil, apperror.New(apperror.CodeCourtClosureInvalidDateRange)
+	}
+	endDate, err := time.Parse(closureDateLayout, closure.EndDate)
+	if err != nil {
+		return nil, apperror.New(apperror.CodeCourtClosureInvalidDateRange)
+	}
+
+	var candidates []models.Booking
+	if err := cu.db.WithContext(ctx).
+		Where("court_id = ? AND status IN (?, ?) AND deleted_at IS NULL AND end_time > ? AND start_time < ? AND end_time > ?",
+			closure.CourtID, "pending", "confirmed", time.Now(), endDate.AddDate(0, 0, 2), startDate.AddDate(0, 0, -1)).
+		Order("start_time ASC").
+		Find(&candidates).Error; err != nil {
+		return nil, errors.New("獲取受影響的預訂失敗")
+	}
+
+	affected := make([]models.Booking, 0, len(candidates))
+	for _, booking := range candidates {
+		if !unitsOverlap(closure.CourtUnitID, booking.CourtUnitID) {
+			continue
+		}
+		if len(closureIntervals(closure, booking.StartTime, booking.EndTime)) > 0 {
+			affected = append(affected, booking)
+		}
+	}
+	return affected, nil
+}
+
+// checkOwner 確認用戶是場地擁有者
+func (cu *CourtClosureUsecase) checkOwner(ctx context.Context, userID, courtID string) error {
+	var court models.Court
+	if err := cu.db.WithContext(ctx).Select("id", "owner_id").Where("id = ?", courtID).First(&court).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apperror.New(apperror.CodeCourtNotFound)
+		}
+		return errors.New("獲取場地失敗")
+	}
+	if court.OwnerID == nil || *court.OwnerID != userID {
+		return apperror.New(apperror.CodeCourtClosureForbidden)
+	}
+	return nil
+}
+
+// applyRequest 驗證並寫入例外資料，指定的球場需屬於該場地
+func (cu *CourtClosureUsecase) applyRequest(ctx context.Context, closure *models.CourtClosure, req *dto.CourtClosureRequest) error {
+	if err := applyCourtClosureRequest(closure, req); err != nil {
+		return err
+	}
+	if closure.CourtUnitID == nil {
+		return nil
+	}
+
+	var unit models.CourtUnit
+	if err := cu.db.WithContext(ctx).Select("id").Where("id = ? AND court_id = ?", *closure.CourtUnitID, closure.CourtID).First(&unit).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apperror.New(apperror.CodeCourtUnitNotFound)
+		}
+		return errors.New("獲取球場失敗")
+	}
+	return nil
+}
+
+// applyCourtClosureRequest 驗證日期及時間並寫入例外資料，休館不使用時間
+func applyCourtClosureRequest(closure *models.CourtClosure, req *dto.CourtClosureRequest) error {
+	for _, date := range []string{req.StartDate, req.EndDate} {
+		if _, err := time.Parse(closureDateLayout, date); err != nil {
+			return apperror.New(apperror.CodeCourtClosureInvalidDateRange)
+		}
+	}
+	if req.StartDate > req.EndDate {
+		return apperror.New(apperror.CodeCourtClosureInvalidDateRange)
+	}
+
+	startTime, endTime := req.StartTime, req.EndTime
+	if req.Kind == models.CourtClosureClosed {
+		startTime, endTime = nil, nil
+	} else {
+		// 時段不支援跨午夜，結束時間可為 24:00
+		if startTime == nil || endTime == nil {
+			return apperror.New(apperror.CodeCourtClosureInvalidTimeRange)
+		}
+		startMinutes, err := services.ParseClockMinutes(*startTime)
+		if err != nil {
+			return apperror.New(apperror.CodeCourtClosureInvalidTimeRange)
+		}
+		endMinutes, err := services.ParseClockMinutes(*endTime)
+		if err != nil || endMinutes <= startMinutes {
+			return apperror.New(apperror.CodeCourtClosureInvalidTimeRange)
+		}
+	}
+
+	// 更改營業時間適用於整個場地
+	if req.Kind == models.CourtClosureHours && req.CourtUnitID != nil {
+		return apperror.New(apperror.CodeCourtClosureUnitNotAllowed)
+	}
+
+	closure.CourtUnitID = req.CourtUnitID
+	closure.Kind = req.Kind
+	closure.StartDate = req.StartDate
+	closure.EndDate = req.EndDate
+	closure.StartTime = startTime
+	closure.EndTime = endTime
+	closure.Reason = req.Reason
+	closure.Note = req.Note
+	return nil
+}
+
+// courtClosures 獲取場地在時段所涵蓋日期的例外，較新的例外在前
+func courtClosures(tx *gorm.DB, courtID string, startTime, endTime time.Time) ([]models.CourtClosure, error) {
+	from := startTime.Format(closureDateLayout)
+	to := endTime.In(startTime.Location()).Format(closureDateLayout)
+
+	var closures []models.CourtClosure
+	if err := tx.Where("court_id = ? AND start_date <= ? AND end_date >= ?", courtID, to, from).
+		Order("created_at DESC").
+		Find(&closures).Error; err != nil {
+		return nil, err
+	}
+	return closures, nil
+}
+
+// dayOperatingHours 獲取整個場地在 day 當天的營業時間，格式同每週營業時間，休館時為 closed
+// 當天有休館或更改營業時間的例外時返回該例外，同一天有多個更改營業時間時以最新的為準
+func dayOperatingHours(court *models.Court, closures []models.CourtClosure, day time.Time) (string, *models.CourtClosure, error) {
+	date := day.Format(closureDateLayout)
+	var altered *models.CourtClosure
+	for i := range closures {
+		closure := &closures[i]
+		if closure.CourtUnitID != nil || date < closure.StartDate || date > closure.EndDate {
+			continue
+		}
+		switch closure.Kind {
+		case models.CourtClosureClosed:
+			return "closed", closure, nil
+		case models.CourtClosureHours:
+			if altered == nil {
+				altered = closure
+			}
+		}
+	}
+	if altered != nil {
+		return *altered.StartTime + "-" + *altered.EndTime, altered, nil
+	}
+
+	// 解析 OperatingHours JSON 到 map
+	var operatingHours map[string]string
+	if err := json.Unmarshal(court.OperatingHours, &operatingHours); err != nil {
+		return "", nil, fmt.Errorf("解析營業時間失敗: %v", err)
+	}
+	hours, exists := operatingHours[day.Weekday().String()]
+	if !exists {
+		return "closed", nil, nil
+	}
+	return hours, nil, nil
+}
+
+// closureIntervals 以 from 的時區展開例外在 from 至 to 之間不開放的時段
+// closed 為整天；block 為封鎖的時段；hours 為新營業時間以外的時段
+func closureIntervals(closure *models.CourtClosure, from, to time.Time) [][2]time.Time {
+	loc := from.Location()
+	to = to.In(loc)
+
+	var startMinutes, endMinutes int
+	if closure.StartTime != nil && closure.EndTime != nil {
+		startMinutes, _ = services.ParseClockMinutes(*closure.StartTime)
+		endMinutes, _ = services.ParseClockMinutes(*closure.EndTime)
+	}
+
+	var intervals [][2]time.Time
+	add := func(start, end time.Time) {
+		if start.Before(end) && start.Before(to) && end.After(from) {
+			intervals = append(intervals, [2]time.Time{start, end})
+		}
+	}
+	for day := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, loc); day.Before(to); day = day.AddDate(0, 0, 1) {
+		date := day.Format(closureDateLayout)
+		if date < closure.StartDate || date > closure.EndDate {
+			continue
+		}
+		nextDay := day.AddDate(0, 0, 1)
+		at := func(minutes int) time.Time {
+			return time.Date(day.Year(), day.Month(), day.Day(), 0, minutes, 0, 0, loc)
+		}
+		switch closure.Kind {
+		case models.CourtClosureClosed:
+			add(day, nextDay)
+		case models.CourtClosureBlock:
+			add(at(startMinutes), at(endMinutes))
+		case models.CourtClosureHours:
+			add(day, at(startMinutes))
+			add(at(endMinutes), nextDay)
+		}
+	}
+	return intervals
+}
@@ -0,0 +1,168 @@
+package usecases
+
+import (
+	"context"
+	"tennis-platform/backend/internal/apperror"
+	"tennis-platform/backend/internal/dto"
+	"tennis-platform/backend/internal/models"
+	"tennis-platform/backend/internal/services"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCourtClosureUsecase_RespectClosures(t *testing.T) {
+	db := setupBookingTestDB(t)
+	bookings := NewBookingUsecase(db, nil)
+	closures := NewCourtClosureUsecase(db, bookings)
+	ctx := context.Background()
+	courtID := "66666666-6666-6666-6666-666666666666"
+	start := bookingTestStart()
+	date := start.Format("2006-01-02")
+
+	// 只有場地擁有者可以設置例外
+	_, err := closures.CreateClosure(ctx, bookingTestUserID, courtID, &dto.CourtClosureRequest{Kind: models.CourtClosureClosed, StartDate: date, EndDate: date, Reason: models.CourtClosureReasonHoliday})
+	assert.True(t, apperror.HasCode(err, apperror.CodeCourtClosureForbidden))
+	_, err = closures.CreateClosure(ctx, bookingTestOwnerID, courtID, &dto.CourtClosureRequest{Kind: models.CourtClosureBlock, StartDate: date, EndDate: date, Reason: models.CourtClosureReasonTournament})
+	assert.True(t, apperror.HasCode(err, apperror.CodeCourtClosureInvalidTimeRange))
+	_, err = closures.CreateClosure(ctx, bookingTestOwnerID, courtID, &dto.CourtClosureRequest{Kind: models.CourtClosureClosed, StartDate: date, EndDate: "2000-01-01", Reason: models.CourtClosureReasonHoliday})
+	assert.True(t, apperror.HasCode(err, apperror.CodeCourtClosureInvalidDateRange))
+
+	// 封鎖 10:00-12:00：重疊的時段無法預訂，可用時間中顯示為不可預訂
+	_, err = closures.CreateClosure(ctx, bookingTestOwnerID, courtID, &dto.CourtClosureRequest{
+		Kind:      models.CourtClosureBlock,
+		StartDate: date,
+		EndDate:   date,
+		StartTime: stringPtr("10:00"),
+		EndTime:   stringPtr("12:00"),
+		Reason:    models.CourtClosureReasonTournament,
+	})
+	require.NoError(t, err)
+
+	_, err = bookings.CreateBooking(bookingTestUserID, &dto.CreateBookingRequest{CourtID: courtID, StartTime: start.Add(time.Hour), EndTime: start.Add(2 * time.Hour)})
+	assert.True(t, apperror.HasCode(err, apperror.CodeCourtBlocked))
+	_, err = bookings.CreateBooking(bookingTestUserID, &dto.CreateBookingRequest{CourtID: courtID, StartTime: start.Add(2 * time.Hour), EndTime: start.Add(3 * time.Hour)})
+	require.NoError(t, err)
+
+	availability, err := bookings.GetAvailability(&dto.AvailabilityRequest{CourtID: courtID, Date: start})
+	require.NoError(t, err)
+	for _, slot := range availability.TimeSlots {
+		blocked := slot.StartTime.Before(start.Add(2*time.Hour)) && slot.EndTime.After(start)
+		if blocked {
+			assert.False(t, slot.Available, slot.StartTime.String())
+		}
+	}
+
+	// 更改營業時間以例外為準
+	_, err = closures.CreateClosure(ctx, bookingTestOwnerID, courtID, &dto.CourtClosureRequest{
+		Kind:      models.CourtClosureHours,
+		StartDate: date,
+		EndDate:   date,
+		StartTime: stringPtr("08:00"),
+		EndTime:   stringPtr("14:00"),
+		Reason:    models.CourtClosureReasonHoliday,
+	})
+	require.NoError(t, err)
+	_, err = bookings.CreateBooking(bookingTestUserID, &dto.CreateBookingRequest{CourtID: courtID, StartTime: start.Add(5 * time.Hour), EndTime: start.Add(6 * time.Hour)})
+	assert.True(t, apperror.HasCode(err, apperror.CodeBookingOutsideHours))
+
+	availability, err = bookings.GetAvailability(&dto.AvailabilityRequest{CourtID: courtID, Date: start})
+	require.NoError(t, err)
+	require.NotEmpty(t, availability.TimeSlots)
+	last := availability.TimeSlots[len(availability.TimeSlots)-1]
+	assert.True(t, last.EndTime.Equal(start.Add(4*time.Hour)), last.EndTime.String())
+
+	// 時段已被預訂時建議的替代時段同樣在更改後的營業時間內
+	_, err = bookings.CreateBooking("77777777-7777-7777-7777-777777777777", &dto.CreateBookingRequest{CourtID: courtID, StartTime: start.Add(2 * time.Hour), EndTime: start.Add(3 * time.Hour)})
+	require.True(t, apperror.HasCode(err, apperror.CodeBookingSlotTaken))
+	alternatives, ok := apperror.From(err).Extensions["alternatives"].([]dto.AlternativeSlot)
+	require.True(t, ok)
+	require.NotEmpty(t, alternatives)
+	for _, alternative := range alternatives {
+		assert.False(t, alternative.EndTime.After(start.Add(4*time.Hour)), alternative.StartTime.String())
+	}
+
+	// 整天休館時沒有可預訂的時段
+	nextDay := start.AddDate(0, 0, 1)
+	_, err = closures.CreateClosure(ctx, bookingTestOwnerID, courtID, &dto.CourtClosureRequest{
+		Kind:      models.CourtClosureClosed,
+		StartDate: nextDay.Format("2006-01-02"),
+		EndDate:   nextDay.Format("2006-01-02"),
+		Reason:    models.CourtClosureReasonMaintenance,
+	})
+	require.NoError(t, err)
+	_, err = bookings.CreateBooking(bookingTestUserID, &dto.CreateBookingRequest{CourtID: courtID, StartTime: nextDay, EndTime: nextDay.Add(time.Hour)})
+	assert.True(t, apperror.HasCode(err, apperror.CodeCourtClosed))
+	availability, err = bookings.GetAvailability(&dto.AvailabilityRequest{CourtID: courtID, Date: nextDay})
+	require.NoError(t, err)
+	assert.Empty(t, availability.TimeSlots)
+
+	var court models.Court
+	require.NoError(t, db.First(&court, "id = ?", courtID).Error)
+	alternatives, err = bookings.alternativeSlots(&court, nil, bookingTestUserID, nextDay, nextDay.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Empty(t, alternatives)
+
+	list, err := closures.GetClosures(ctx, courtID, &dto.CourtClosureListRequest{})
+	require.NoError(t, err)
+	assert.Len(t, list, 3)
+}
+
+func TestCourtClosureUsecase_CancelAffectedBookings(t *testing.T) {
+	db := setupBookingTestDB(t)
+	bookings := NewBookingUsecase(db, nil)
+	closures := NewCourtClosureUsecase(db, bookings)
+	ctx := context.Background()
+	courtID := "66666666-6666-6666-6666-666666666666"
+	start := bookingTestStart()
+	date := start.Format("2006-01-02")
+
+	affected, err := bookings.CreateBooking(bookingTestUserID, &dto.CreateBookingRequest{CourtID: courtID, StartTime: start, EndTime: start.Add(time.Hour)})
+	require.NoError(t, err)
+	unaffected, err := bookings.CreateBooking(bookingTestUserID, &dto.CreateBookingRequest{CourtID: courtID, StartTime: start.Add(3 * time.Hour), EndTime: start.Add(4 * time.Hour)})
+	require.NoError(t, err)
+
+	req := &dto.CourtClosureRequest{
+		Kind:      models.CourtClosureBlock,
+		StartDate: date,
+		EndDate:   date,
+		StartTime: stringPtr("09:00"),
+		EndTime:   stringPtr("12:00"),
+		Reason:    models.CourtClosureReasonWeather,
+		Note:      stringPtr("颱風停班"),
+	}
+
+	// 預覽不寫入例外，只列出受影響的預訂
+	impact, err := closures.PreviewClosure(ctx, bookingTestOwnerID, courtID, req)
+	require.NoError(t, err)
+	require.Len(t, impact.Bookings, 1)
+	assert.Equal(t, affected.ID, impact.Bookings[0].BookingID)
+
+	var count int64
+	require.NoError(t, db.Model(&models.CourtClosure{}).Count(&count).Error)
+	assert.Equal(t, int64(0), count)
+
+	response, err := closures.CreateClosure(ctx, bookingTestOwnerID, courtID, req)
+	require.NoError(t, err)
+	require.Len(t, response.Cancellations, 1)
+	cancellation := response.Cancellations[0]
+	assert.Equal(t, affected.ID, cancellation.TargetID)
+	assert.Equal(t, services.CancellationInitiatorProvider, cancellation.Initiator)
+	assert.Equal(t, 100, cancellation.RefundPercent)
+	assert.Equal(t, bookingTestOwnerID, cancellation.CancelledBy)
+
+	var cancelled, kept models.Booking
+	require.NoError(t, db.First(&cancelled, "id = ?", affected.ID).Error)
+	assert.Equal(t, "cancelled", cancelled.Status)
+	require.NoError(t, db.First(&kept, "id = ?", unaffected.ID).Error)
+	assert.Equal(t, "pending", kept.Status)
+
+	// 刪除例外後時段恢復開放
+	require.NoError(t, closures.DeleteClosure(ctx, bookingTestOwnerID, courtID, response.Closure.ID))
+	err = closures.DeleteClosure(ctx, bookingTestOwnerID, courtID, response.Closure.ID)
+	assert.True(t, apperror.HasCode(err, apperror.CodeCourtClosureNotFound))
+	_, err = bookings.CreateBooking(bookingTestUserID, &dto.CreateBookingRequest{CourtID: courtID, StartTime: start, EndTime: start.Add(time.Hour)})
+	require.NoError(t, err)
+}
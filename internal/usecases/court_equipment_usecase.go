@@ -0,0 +1,173 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"tennis-platform/backend/internal/apperror"
+	"tennis-platform/backend/internal/dto"
+	"tennis-platform/backend/internal/models"
+	"tennis-platform/backend/internal/services"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// CourtEquipmentUsecase 租借器材用例，場地擁有者管理可隨預訂租借的器材
+type CourtEquipmentUsecase struct {
+	db        *gorm.DB
+	equipment *services.EquipmentService
+}
+
+// NewCourtEquipmentUsecase 創建新的租借器材用例
+func NewCourtEquipmentUsecase(db *gorm.DB) *CourtEquipmentUsecase {
+	return &CourtEquipmentUsecase{
+		db:        db,
+		equipment: services.NewEquipmentService(db),
+	}
+}
+
+// GetEquipment 獲取場地的租借器材，包含停用的器材，按名稱排序
+//
+// 提供時段時一併計算各器材在時段內扣除其他預訂後還可以租借的數量。
+func (eu *CourtEquipmentUsecase) GetEquipment(ctx context.Context, courtID string, req *dto.CourtEquipmentListRequest) ([]dto.CourtEquipmentResponse, error) {
+	if (req.StartTime == nil) != (req.EndTime == nil) || (req.StartTime != nil && !req.EndTime.After(*req.StartTime)) {
+		return nil, apperror.New(apperror.CodeBookingInvalidTimeRange)
+	}
+
+	var court models.Court
+	if err := eu.db.WithContext(ctx).Select("id").Where("id = ?", courtID).First(&court).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.New(apperror.CodeCourtNotFound)
+		}
+		return nil, errors.New("獲取場地失敗")
+	}
+
+	var equipment []models.CourtEquipment
+	if err := eu.db.WithContext(ctx).Where("court_id = ?", court.ID).Order("name ASC").Find(&equipment).Error; err != nil {
+		return nil, errors.New("獲取租借器材失敗")
+	}
+
+	var availability map[string]int
+	if req.StartTime != nil {
+		var err error
+		if availability, err = eu.equipment.Availability(ctx, equipment, *req.StartTime, *req.EndTime); err != nil {
+			return nil, errors.New("計算可租借數量失敗")
+		}
+	}
+
+	response := make([]dto.CourtEquipmentResponse, len(equipment))
+	for i := range equipment {
+		response[i].CourtEquipment = equipment[i]
+		if available, ok := availability[equipment[i].ID]; ok {
+			response[i].Available = &available
+		}
+	}
+	return response, nil
+}
+
+// CreateEquipment 為場地創建租借器材
+func (eu *CourtEquipmentUsecase) CreateEquipment(ctx context.Context, userID, courtID string, req *dto.CourtEquipmentRequest) (*models.CourtEquipment, error) {
+	if err := eu.checkOwner(ctx, userID, courtID); err != nil {
+		return nil, err
+	}
+
+	item := models.CourtEquipment{CourtID: courtID}
+	applyCourtEquipmentRequest(&item, req)
+
+	if err := eu.db.WithContext(ctx).Create(&item).Error; err != nil {
+		return nil, errors.New("創建租借器材失敗")
+	}
+	return &item, nil
+}
+
+// UpdateEquipment 更新場地的租借器材
+//
+// 修改價格及停用只影響之後的租借；減少庫存不會取消已租借的項目，只限制之後的租借。
+func (eu *CourtEquipmentUsecase) UpdateEquipment(ctx context.Context, userID, courtID, equipmentID string, req *dto.CourtEquipmentRequest) (*models.CourtEquipment, error) {
+	if err := eu.checkOwner(ctx, userID, courtID); err != nil {
+		return nil, err
+	}
+
+	item, err := eu.getEquipment(ctx, courtID, equipmentID)
+	if err != nil {
+		return nil, err
+	}
+	applyCourtEquipmentRequest(item, req)
+
+	if err := eu.db.WithContext(ctx).Save(item).Error; err != nil {
+		return nil, errors.New("更新租借器材失敗")
+	}
+	return item, nil
+}
+
+// DeleteEquipment 刪除場地的租借器材，還有未結束的租借時需先取消預訂或改為停用
+func (eu *CourtEquipmentUsecase) DeleteEquipment(ctx context.Context, userID, courtID, equipmentID string) error {
+	if err := eu.checkOwner(ctx, userID, courtID); err != nil {
+		return err
+	}
+
+	item, err := eu.getEquipment(ctx, courtID, equipmentID)
+	if err != nil {
+		return err
+	}
+
+	db := eu.db.WithContext(ctx)
+	var count int64
+	if err := db.Model(&models.BookingAddOn{}).
+		Joins("JOIN bookings ON bookings.id = booking_add_ons.booking_id").
+		Where("booking_add_ons.equipment_id = ? AND booking_add_ons.status = ? AND booking_add_ons.end_time > ?",
+			item.ID, models.BookingAddOnReserved, time.Now()).
+		Where("bookings.status IN (?, ?) AND bookings.deleted_at IS NULL", "pending", "confirmed").
+		Count(&count).Error; err != nil {
+		return errors.New("檢查器材租借失敗")
+	}
+	if count > 0 {
+		return apperror.New(apperror.CodeEquipmentHasReservations).With("count", count)
+	}
+
+	if err := db.Delete(item).Error; err != nil {
+		return errors.New("刪除租借器材失敗")
+	}
+	return nil
+}
+
+// getEquipment 獲取場地的租借器材
+func (eu *CourtEquipmentUsecase) getEquipment(ctx context.Context, courtID, equipmentID string) (*models.CourtEquipment, error) {
+	var item models.CourtEquipment
+	if err := eu.db.WithContext(ctx).Where("id = ? AND court_id = ?", equipmentID, courtID).First(&item).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.New(apperror.CodeEquipmentNotFound)
+		}
+		return nil, errors.New("獲取租借器材失敗")
+	}
+	return &item, nil
+}
+
+// checkOwner 確認用戶是場地擁有者
+func (eu *CourtEquipmentUsecase) checkOwner(ctx context.Context, userID, courtID string) error {
+	var court models.Court
+	if err := eu.db.WithContext(ctx).Select("id", "owner_id").Where("id = ?", courtID).First(&court).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apperror.New(apperror.CodeCourtNotFound)
+		}
+		return errors.New("獲取場地失敗")
+	}
+	if court.OwnerID == nil || *court.OwnerID != userID {
+		return apperror.New(apperror.CodeEquipmentForbidden)
+	}
+	return nil
+}
+
+// applyCourtEquipmentRequest 寫入器材資料，未指定計價方式時每次預訂收費一次，未指定是否開放時默認開放租借
+func applyCourtEquipmentRequest(item *models.CourtEquipment, req *dto.CourtEquipmentRequest) {
+	item.Name = req.Name
+	item.Description = req.Description
+	item.Category = req.Category
+	item.Quantity = *req.Quantity
+	item.Price = *req.Price
+	item.PriceUnit = req.PriceUnit
+	if item.PriceUnit == "" {
+		item.PriceUnit = models.EquipmentPricePerBooking
+	}
+	item.IsActive = req.IsActive == nil || *req.IsActive
+}
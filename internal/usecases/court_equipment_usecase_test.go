@@ -0,0 +1,132 @@
+package usecases
+
+import (
+	"context"
+	"tennis-platform/backend/internal/apperror"
+	"tennis-platform/backend/internal/dto"
+	"tennis-platform/backend/internal/models"
+	"tennis-platform/backend/internal/services"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCourtEquipmentUsecase_RentWithBookings(t *testing.T) {
+	db := setupBookingTestDB(t)
+	bus := services.NewEventBus(db)
+	services.RegisterEquipmentSubscribers(bus, services.NewEquipmentService(db))
+	bookings := NewBookingUsecase(db, bus)
+	equipment := NewCourtEquipmentUsecase(db)
+	units := NewCourtUnitUsecase(db)
+	ctx := context.Background()
+	courtID := "66666666-6666-6666-6666-666666666666"
+	otherUserID := "77777777-7777-7777-7777-777777777777"
+	start := bookingTestStart()
+
+	// 兩面球場讓重疊的預訂可以同時成立，器材由整個場地共用
+	for number := 1; number <= 2; number++ {
+		_, err := units.CreateUnit(ctx, bookingTestOwnerID, courtID, &dto.CourtUnitRequest{Number: number, Surface: "hard"})
+		require.NoError(t, err)
+	}
+
+	one, three := 1, 3
+	machinePrice, racketPrice := 150.0, 100.0
+	machineReq := &dto.CourtEquipmentRequest{Name: "發球機", Category: "ball_machine", Quantity: &one, Price: &machinePrice, PriceUnit: models.EquipmentPricePerHour}
+	_, err := equipment.CreateEquipment(ctx, bookingTestUserID, courtID, machineReq)
+	assert.True(t, apperror.HasCode(err, apperror.CodeEquipmentForbidden))
+	machine, err := equipment.CreateEquipment(ctx, bookingTestOwnerID, courtID, machineReq)
+	require.NoError(t, err)
+	assert.True(t, machine.IsActive)
+	racketReq := &dto.CourtEquipmentRequest{Name: "球拍", Category: "racket", Quantity: &three, Price: &racketPrice}
+	racket, err := equipment.CreateEquipment(ctx, bookingTestOwnerID, courtID, racketReq)
+	require.NoError(t, err)
+	assert.Equal(t, models.EquipmentPricePerBooking, racket.PriceUnit)
+
+	book := func(userID string, offset time.Duration, hours int, addOns ...dto.BookingAddOnRequest) (*models.Booking, error) {
+		return bookings.CreateBooking(userID, &dto.CreateBookingRequest{
+			CourtID:   courtID,
+			StartTime: start.Add(offset),
+			EndTime:   start.Add(offset + time.Duration(hours)*time.Hour),
+			AddOns:    addOns,
+		})
+	}
+	rent := func(item *models.CourtEquipment, quantity int) dto.BookingAddOnRequest {
+		return dto.BookingAddOnRequest{EquipmentID: item.ID, Quantity: quantity}
+	}
+
+	// 場地 800 元，發球機每小時 150 元共 300 元，兩支球拍 200 元
+	first, err := book(bookingTestUserID, 0, 2, rent(machine, 1), rent(racket, 2))
+	require.NoError(t, err)
+	assert.InDelta(t, 1300, first.TotalPrice, 0.001)
+	require.Len(t, first.PriceBreakdown.AddOns, 2)
+	assert.InDelta(t, 300, first.PriceBreakdown.AddOns[0].Amount, 0.001)
+	require.Len(t, first.AddOns, 2)
+
+	// 重疊時段的庫存已被租借，失敗的預訂不會建立
+	_, err = book(otherUserID, time.Hour, 2, rent(machine, 1))
+	assert.True(t, apperror.HasCode(err, apperror.CodeEquipmentInsufficient))
+	_, err = book(otherUserID, time.Hour, 2, rent(racket, 2))
+	assert.True(t, apperror.HasCode(err, apperror.CodeEquipmentInsufficient))
+	var count int64
+	require.NoError(t, db.Model(&models.Booking{}).Where("user_id = ?", otherUserID).Count(&count).Error)
+	assert.Equal(t, int64(0), count)
+
+	second, err := book(otherUserID, time.Hour, 2, rent(racket, 1))
+	require.NoError(t, err)
+	assert.InDelta(t, 900, second.TotalPrice, 0.001)
+
+	windowStart, windowEnd := start, start.Add(3*time.Hour)
+	list, err := equipment.GetEquipment(ctx, courtID, &dto.CourtEquipmentListRequest{StartTime: &windowStart, EndTime: &windowEnd})
+	require.NoError(t, err)
+	available := map[string]int{}
+	for _, item := range list {
+		require.NotNil(t, item.Available)
+		available[item.ID] = *item.Available
+	}
+	assert.Equal(t, map[string]int{machine.ID: 0, racket.ID: 0}, available)
+
+	err = equipment.DeleteEquipment(ctx, bookingTestOwnerID, courtID, machine.ID)
+	assert.True(t, apperror.HasCode(err, apperror.CodeEquipmentHasReservations))
+
+	// 改期後按新的時長計算發球機的租金，並釋出原時段的庫存
+	newStart, newEnd := start.Add(4*time.Hour), start.Add(7*time.Hour)
+	first, err = bookings.UpdateBooking(first.ID, bookingTestUserID, &dto.UpdateBookingRequest{StartTime: &newStart, EndTime: &newEnd}, nil)
+	require.NoError(t, err)
+	assert.InDelta(t, 1850, first.TotalPrice, 0.001)
+	require.Len(t, first.AddOns, 2)
+	assert.True(t, first.AddOns[0].StartTime.Equal(newStart))
+
+	// 修改租借器材只限預訂者，以目前的價格重新計算總價
+	_, err = bookings.UpdateBookingAddOns(second.ID, bookingTestUserID, &dto.UpdateBookingAddOnsRequest{})
+	assert.True(t, apperror.HasCode(err, apperror.CodeBookingModifyForbidden))
+	second, err = bookings.UpdateBookingAddOns(second.ID, otherUserID, &dto.UpdateBookingAddOnsRequest{
+		AddOns: []dto.BookingAddOnRequest{rent(machine, 1)},
+	})
+	require.NoError(t, err)
+	assert.InDelta(t, 1100, second.TotalPrice, 0.001)
+	require.Len(t, second.AddOns, 1)
+	assert.Equal(t, machine.ID, second.AddOns[0].EquipmentID)
+
+	// 停用的器材不開放租借
+	inactive := false
+	racketReq.IsActive = &inactive
+	_, err = equipment.UpdateEquipment(ctx, bookingTestOwnerID, courtID, racket.ID, racketReq)
+	require.NoError(t, err)
+	_, err = book(otherUserID, 8*time.Hour, 1, rent(racket, 1))
+	assert.True(t, apperror.HasCode(err, apperror.CodeEquipmentUnavailable))
+
+	// 取消預訂後立即釋出庫存，事件訂閱者將租借項目標記為已退回
+	_, err = bookings.CancelBooking(second.ID, otherUserID, &dto.CancelBookingRequest{})
+	require.NoError(t, err)
+	_, err = book(bookingTestUserID, time.Hour, 1, rent(machine, 1))
+	require.NoError(t, err)
+	_, err = bus.DispatchPending(ctx)
+	require.NoError(t, err)
+	var returned []models.BookingAddOn
+	require.NoError(t, db.Where("booking_id = ?", second.ID).Find(&returned).Error)
+	require.Len(t, returned, 1)
+	assert.Equal(t, models.BookingAddOnReturned, returned[0].Status)
+	assert.NotNil(t, returned[0].ReturnedAt)
+}
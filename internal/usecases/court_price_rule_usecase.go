@@ -0,0 +1,200 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"tennis-platform/backend/internal/apperror"
+	"tennis-platform/backend/internal/dto"
+	"tennis-platform/backend/internal/models"
+	"tennis-platform/backend/internal/services"
+	"time"
+
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
+
+// CourtPriceRuleUsecase 場地價格規則用例，場地擁有者設定平日晚間、週末、假日及燈光等不同價格
+type CourtPriceRuleUsecase struct {
+	db *gorm.DB
+}
+
+// NewCourtPriceRuleUsecase 創建新的場地價格規則用例
+func NewCourtPriceRuleUsecase(db *gorm.DB) *CourtPriceRuleUsecase {
+	return &CourtPriceRuleUsecase{db: db}
+}
+
+// GetRules 獲取場地的價格規則，包含停用的規則，按優先級由高到低排序
+func (pu *CourtPriceRuleUsecase) GetRules(ctx context.Context, courtID string) ([]models.CourtPriceRule, error) {
+	var court models.Court
+	if err := pu.db.WithContext(ctx).Select("id").Where("id = ?", courtID).First(&court).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.New(apperror.CodeCourtNotFound)
+		}
+		return nil, errors.New("獲取場地失敗")
+	}
+
+	rules := []models.CourtPriceRule{}
+	if err := pu.db.WithContext(ctx).
+		Where("court_id = ?", court.ID).
+		Order("priority DESC, created_at ASC").
+		Find(&rules).Error; err != nil {
+		return nil, errors.New("獲取價格規則失敗")
+	}
+	return rules, nil
+}
+
+// CreateRule 為場地創建價格規則
+func (pu *CourtPriceRuleUsecase) CreateRule(ctx context.Context, userID, courtID string, req *dto.CourtPriceRuleRequest) (*models.CourtPriceRule, error) {
+	if err := pu.checkOwner(ctx, userID, courtID); err != nil {
+		return nil, err
+	}
+
+	rule := models.CourtPriceRule{CourtID: courtID}
+	if err := applyPriceRuleRequest(&rule, req); err != nil {
+		return nil, err
+	}
+
+	if err := pu.db.WithContext(ctx).Create(&rule).Error; err != nil {
+		return nil, errors.New("創建價格規則失敗")
+	}
+	return &rule, nil
+}
+
+// UpdateRule 更新場地的價格規則，只影響之後的預訂
+func (pu *CourtPriceRuleUsecase) UpdateRule(ctx context.Context, userID, courtID, ruleID string, req *dto.CourtPriceRuleRequest) (*models.CourtPriceRule, error) {
+	if err := pu.checkOwner(ctx, userID, courtID); err != nil {
+		return nil, err
+	}
+
+	db := pu.db.WithContext(ctx)
+	var rule models.CourtPriceRule
+	if err := db.Where("id = ? AND court_id = ?", ruleID, courtID).First(&rule).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.New(apperror.CodePriceRuleNotFound)
+		}
+		return nil, errors.New("獲取價格規則失敗")
+	}
+
+	if err := applyPriceRuleRequest(&rule, req); err != nil {
+		return nil, err
+	}
+
+	if err := db.Save(&rule).Error; err != nil {
+		return nil, errors.New("更新價格規則失敗")
+	}
+	return &rule, nil
+}
+
+// DeleteRule 刪除場地的價格規則，已預訂的價格明細不受影響
+func (pu *CourtPriceRuleUsecase) DeleteRule(ctx context.Context, userID, courtID, ruleID string) error {
+	if err := pu.checkOwner(ctx, userID, courtID); err != nil {
+		return err
+	}
+
+	result := pu.db.WithContext(ctx).Where("id = ? AND court_id = ?", ruleID, courtID).Delete(&models.CourtPriceRule{})
+	if result.Error != nil {
+		return errors.New("刪除價格規則失敗")
+	}
+	if result.RowsAffected == 0 {
+		return apperror.New(apperror.CodePriceRuleNotFound)
+	}
+	return nil
+}
+
+// checkOwner 確認用戶是場地擁有者
+func (pu *CourtPriceRuleUsecase) checkOwner(ctx context.Context, userID, courtID string) error {
+	var court models.Court
+	if err := pu.db.WithContext(ctx).Select("id", "owner_id").Where("id = ?", courtID).First(&court).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apperror.New(apperror.CodeCourtNotFound)
+		}
+		return errors.New("獲取場地失敗")
+	}
+	if court.OwnerID == nil || *court.OwnerID != userID {
+		return apperror.New(apperror.CodePriceRuleForbidden)
+	}
+	return nil
+}
+
+// applyPriceRuleRequest 驗證請求並寫入價格規則
+func applyPriceRuleRequest(rule *models.CourtPriceRule, req *dto.CourtPriceRuleRequest) error {
+	// 時段需同時提供開始及結束，不支援跨午夜的時段
+	if (req.StartTime == nil) != (req.EndTime == nil) {
+		return apperror.New(apperror.CodePriceRuleInvalidTimeRange).With("value", priceRuleRange(req.StartTime, req.EndTime))
+	}
+	if req.StartTime != nil {
+		startMinutes, err := services.ParseClockMinutes(*req.StartTime)
+		if err != nil {
+			return apperror.New(apperror.CodePriceRuleInvalidTimeRange).With("value", priceRuleRange(req.StartTime, req.EndTime))
+		}
+		endMinutes, err := services.ParseClockMinutes(*req.EndTime)
+		if err != nil || endMinutes <= startMinutes {
+			return apperror.New(apperror.CodePriceRuleInvalidTimeRange).With("value", priceRuleRange(req.StartTime, req.EndTime))
+		}
+	}
+
+	for _, date := range []*string{req.StartDate, req.EndDate} {
+		if date == nil {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", *date); err != nil {
+			return apperror.New(apperror.CodePriceRuleInvalidDateRange).With("value", priceRuleRange(req.StartDate, req.EndDate))
+		}
+	}
+	if req.StartDate != nil && req.EndDate != nil && *req.StartDate > *req.EndDate {
+		return apperror.New(apperror.CodePriceRuleInvalidDateRange).With("value", priceRuleRange(req.StartDate, req.EndDate))
+	}
+
+	audience := req.Audience
+	if audience == "" {
+		audience = services.PriceAudienceAll
+	}
+	clubID := req.ClubID
+	if audience == services.PriceAudienceAll {
+		clubID = nil
+	} else if clubID == nil {
+		return apperror.New(apperror.CodePriceRuleClubRequired)
+	}
+
+	kind := req.Kind
+	if kind == "" {
+		kind = services.PriceRuleKindRate
+	}
+
+	// 去除重複的星期並排序
+	seen := make(map[int]bool, len(req.DaysOfWeek))
+	days := pq.Int64Array{}
+	for _, day := range req.DaysOfWeek {
+		if !seen[day] {
+			seen[day] = true
+			days = append(days, int64(day))
+		}
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i] < days[j] })
+
+	rule.Name = req.Name
+	rule.Kind = kind
+	rule.PricePerHour = *req.PricePerHour
+	rule.DaysOfWeek = days
+	rule.StartTime = req.StartTime
+	rule.EndTime = req.EndTime
+	rule.StartDate = req.StartDate
+	rule.EndDate = req.EndDate
+	rule.Audience = audience
+	rule.ClubID = clubID
+	rule.Priority = req.Priority
+	rule.IsActive = req.IsActive == nil || *req.IsActive
+	return nil
+}
+
+// priceRuleRange 格式化錯誤訊息中的範圍
+func priceRuleRange(start, end *string) string {
+	value := func(s *string) string {
+		if s == nil {
+			return ""
+		}
+		return *s
+	}
+	return value(start) + "-" + value(end)
+}
@@ -0,0 +1,112 @@
+package usecases
+
+import (
+	"context"
+	"tennis-platform/backend/internal/apperror"
+	"tennis-platform/backend/internal/dto"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCourtPriceRuleUsecase_ManageRules(t *testing.T) {
+	db := setupBookingTestDB(t)
+	uc := NewCourtPriceRuleUsecase(db)
+	ctx := context.Background()
+	courtID := "66666666-6666-6666-6666-666666666666"
+
+	price := 600.0
+	req := &dto.CourtPriceRuleRequest{
+		Name:         "晚間",
+		PricePerHour: &price,
+		DaysOfWeek:   []int{5, 1, 1},
+		StartTime:    stringPtr("18:00"),
+		EndTime:      stringPtr("22:00"),
+		Priority:     10,
+	}
+
+	_, err := uc.CreateRule(ctx, bookingTestUserID, courtID, req)
+	assert.True(t, apperror.HasCode(err, apperror.CodePriceRuleForbidden))
+
+	rule, err := uc.CreateRule(ctx, bookingTestOwnerID, courtID, req)
+	require.NoError(t, err)
+	assert.Equal(t, "rate", rule.Kind)
+	assert.Equal(t, "all", rule.Audience)
+	assert.Equal(t, []int64{1, 5}, []int64(rule.DaysOfWeek))
+	assert.True(t, rule.IsActive)
+
+	// 停用規則
+	inactive := false
+	req.IsActive = &inactive
+	updated, err := uc.UpdateRule(ctx, bookingTestOwnerID, courtID, rule.ID, req)
+	require.NoError(t, err)
+	assert.False(t, updated.IsActive)
+
+	rules, err := uc.GetRules(ctx, courtID)
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+	assert.False(t, rules[0].IsActive)
+
+	// 驗證時段、日期及會員規則
+	invalid := *req
+	invalid.EndTime = stringPtr("17:00")
+	_, err = uc.CreateRule(ctx, bookingTestOwnerID, courtID, &invalid)
+	assert.True(t, apperror.HasCode(err, apperror.CodePriceRuleInvalidTimeRange))
+
+	invalid = *req
+	invalid.EndTime = nil
+	_, err = uc.CreateRule(ctx, bookingTestOwnerID, courtID, &invalid)
+	assert.True(t, apperror.HasCode(err, apperror.CodePriceRuleInvalidTimeRange))
+
+	invalid = *req
+	invalid.StartDate = stringPtr("2024-03-01")
+	invalid.EndDate = stringPtr("2024-02-01")
+	_, err = uc.CreateRule(ctx, bookingTestOwnerID, courtID, &invalid)
+	assert.True(t, apperror.HasCode(err, apperror.CodePriceRuleInvalidDateRange))
+
+	invalid = *req
+	invalid.Audience = "member"
+	_, err = uc.CreateRule(ctx, bookingTestOwnerID, courtID, &invalid)
+	assert.True(t, apperror.HasCode(err, apperror.CodePriceRuleClubRequired))
+
+	require.NoError(t, uc.DeleteRule(ctx, bookingTestOwnerID, courtID, rule.ID))
+	err = uc.DeleteRule(ctx, bookingTestOwnerID, courtID, rule.ID)
+	assert.True(t, apperror.HasCode(err, apperror.CodePriceRuleNotFound))
+}
+
+func TestBookingUsecase_GetAvailabilityPricesSlots(t *testing.T) {
+	db := setupBookingTestDB(t)
+	rules := NewCourtPriceRuleUsecase(db)
+	bookings := NewBookingUsecase(db, nil)
+	ctx := context.Background()
+	courtID := "66666666-6666-6666-6666-666666666666"
+
+	price := 600.0
+	_, err := rules.CreateRule(ctx, bookingTestOwnerID, courtID, &dto.CourtPriceRuleRequest{
+		Name:         "晚間",
+		PricePerHour: &price,
+		StartTime:    stringPtr("18:00"),
+		EndTime:      stringPtr("22:00"),
+	})
+	require.NoError(t, err)
+
+	date := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	availability, err := bookings.GetAvailability(&dto.AvailabilityRequest{CourtID: courtID, Date: date, Duration: 60})
+	require.NoError(t, err)
+
+	prices := make(map[int]float64)
+	segments := make(map[int]int)
+	for _, slot := range availability.TimeSlots {
+		minutes := slot.StartTime.Hour()*60 + slot.StartTime.Minute()
+		prices[minutes] = slot.Price
+		segments[minutes] = len(slot.Segments)
+	}
+
+	assert.InDelta(t, 400, prices[16*60], 0.001)
+	// 17:30-18:30 跨越規則邊界，按比例計價
+	assert.InDelta(t, 500, prices[17*60+30], 0.001)
+	assert.Equal(t, 2, segments[17*60+30])
+	assert.InDelta(t, 600, prices[18*60], 0.001)
+}
@@ -0,0 +1,162 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"tennis-platform/backend/internal/apperror"
+	"tennis-platform/backend/internal/dto"
+	"tennis-platform/backend/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// CourtUnitUsecase 場地球場用例，場地擁有者管理場地內可單獨預訂的球場
+type CourtUnitUsecase struct {
+	db *gorm.DB
+}
+
+// NewCourtUnitUsecase 創建新的場地球場用例
+func NewCourtUnitUsecase(db *gorm.DB) *CourtUnitUsecase {
+	return &CourtUnitUsecase{db: db}
+}
+
+// GetUnits 獲取場地的球場，包含停用的球場，按編號排序
+func (uu *CourtUnitUsecase) GetUnits(ctx context.Context, courtID string) ([]models.CourtUnit, error) {
+	var court models.Court
+	if err := uu.db.WithContext(ctx).Select("id").Where("id = ?", courtID).First(&court).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.New(apperror.CodeCourtNotFound)
+		}
+		return nil, errors.New("獲取場地失敗")
+	}
+
+	units := []models.CourtUnit{}
+	if err := uu.db.WithContext(ctx).Where("court_id = ?", court.ID).Order("number ASC").Find(&units).Error; err != nil {
+		return nil, errors.New("獲取球場失敗")
+	}
+	return units, nil
+}
+
+// CreateUnit 為場地創建球場
+//
+// 場地設置球場後，預訂需指定球場或由系統自動分配；設置前的預訂視為包下整個場地。
+func (uu *CourtUnitUsecase) CreateUnit(ctx context.Context, userID, courtID string, req *dto.CourtUnitRequest) (*models.CourtUnit, error) {
+	if err := uu.checkOwner(ctx, userID, courtID); err != nil {
+		return nil, err
+	}
+
+	unit := models.CourtUnit{CourtID: courtID}
+	if err := uu.checkNumber(ctx, &unit, req.Number); err != nil {
+		return nil, err
+	}
+	applyCourtUnitRequest(&unit, req)
+
+	if err := uu.db.WithContext(ctx).Create(&unit).Error; err != nil {
+		return nil, errors.New("創建球場失敗")
+	}
+	return &unit, nil
+}
+
+// UpdateUnit 更新場地的球場，停用後不再開放預訂，已有的預訂不受影響
+func (uu *CourtUnitUsecase) UpdateUnit(ctx context.Context, userID, courtID, unitID string, req *dto.CourtUnitRequest) (*models.CourtUnit, error) {
+	if err := uu.checkOwner(ctx, userID, courtID); err != nil {
+		return nil, err
+	}
+
+	unit, err := uu.getUnit(ctx, courtID, unitID)
+	if err != nil {
+		return nil, err
+	}
+	if err := uu.checkNumber(ctx, unit, req.Number); err != nil {
+		return nil, err
+	}
+	applyCourtUnitRequest(unit, req)
+
+	if err := uu.db.WithContext(ctx).Save(unit).Error; err != nil {
+		return nil, errors.New("更新球場失敗")
+	}
+	return unit, nil
+}
+
+// DeleteUnit 刪除場地的球場，還有未結束的預訂時需先取消或改為停用
+func (uu *CourtUnitUsecase) DeleteUnit(ctx context.Context, userID, courtID, unitID string) error {
+	if err := uu.checkOwner(ctx, userID, courtID); err != nil {
+		return err
+	}
+
+	unit, err := uu.getUnit(ctx, courtID, unitID)
+	if err != nil {
+		return err
+	}
+
+	db := uu.db.WithContext(ctx)
+	var count int64
+	if err := db.Model(&models.Booking{}).
+		Where("court_unit_id = ? AND status IN (?, ?) AND end_time > ?", unit.ID, "pending", "confirmed", time.Now()).
+		Count(&count).Error; err != nil {
+		return errors.New("檢查球場預訂失敗")
+	}
+	if count > 0 {
+		return apperror.New(apperror.CodeCourtUnitHasBookings).With("count", count)
+	}
+
+	if err := db.Delete(unit).Error; err != nil {
+		return errors.New("刪除球場失敗")
+	}
+	return nil
+}
+
+// getUnit 獲取場地的球場
+func (uu *CourtUnitUsecase) getUnit(ctx context.Context, courtID, unitID string) (*models.CourtUnit, error) {
+	var unit models.CourtUnit
+	if err := uu.db.WithContext(ctx).Where("id = ? AND court_id = ?", unitID, courtID).First(&unit).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.New(apperror.CodeCourtUnitNotFound)
+		}
+		return nil, errors.New("獲取球場失敗")
+	}
+	return &unit, nil
+}
+
+// checkNumber 確認球場編號在場地內沒有重複
+func (uu *CourtUnitUsecase) checkNumber(ctx context.Context, unit *models.CourtUnit, number int) error {
+	query := uu.db.WithContext(ctx).Model(&models.CourtUnit{}).Where("court_id = ? AND number = ?", unit.CourtID, number)
+	if unit.ID != "" {
+		query = query.Where("id <> ?", unit.ID)
+	}
+
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return errors.New("檢查球場編號失敗")
+	}
+	if count > 0 {
+		return apperror.New(apperror.CodeCourtUnitDuplicate).With("number", number)
+	}
+	return nil
+}
+
+// checkOwner 確認用戶是場地擁有者
+func (uu *CourtUnitUsecase) checkOwner(ctx context.Context, userID, courtID string) error {
+	var court models.Court
+	if err := uu.db.WithContext(ctx).Select("id", "owner_id").Where("id = ?", courtID).First(&court).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apperror.New(apperror.CodeCourtNotFound)
+		}
+		return errors.New("獲取場地失敗")
+	}
+	if court.OwnerID == nil || *court.OwnerID != userID {
+		return apperror.New(apperror.CodeCourtUnitForbidden)
+	}
+	return nil
+}
+
+// applyCourtUnitRequest 寫入球場資料，未指定是否開放時默認開放預訂
+func applyCourtUnitRequest(unit *models.CourtUnit, req *dto.CourtUnitRequest) {
+	unit.Number = req.Number
+	unit.Name = req.Name
+	unit.Surface = req.Surface
+	unit.IsIndoor = req.IsIndoor
+	unit.HasLights = req.HasLights
+	unit.IsActive = req.IsActive == nil || *req.IsActive
+}
@@ -0,0 +1,160 @@
+package usecases
+
+import (
+	"context"
+	"tennis-platform/backend/internal/apperror"
+	"tennis-platform/backend/internal/dto"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCourtUnitUsecase_ManageUnits(t *testing.T) {
+	db := setupBookingTestDB(t)
+	uc := NewCourtUnitUsecase(db)
+	ctx := context.Background()
+	courtID := "66666666-6666-6666-6666-666666666666"
+
+	req := &dto.CourtUnitRequest{Number: 1, Surface: "hard", HasLights: true}
+
+	_, err := uc.CreateUnit(ctx, bookingTestUserID, courtID, req)
+	assert.True(t, apperror.HasCode(err, apperror.CodeCourtUnitForbidden))
+
+	unit, err := uc.CreateUnit(ctx, bookingTestOwnerID, courtID, req)
+	require.NoError(t, err)
+	assert.True(t, unit.IsActive)
+
+	_, err = uc.CreateUnit(ctx, bookingTestOwnerID, courtID, req)
+	assert.True(t, apperror.HasCode(err, apperror.CodeCourtUnitDuplicate))
+
+	second, err := uc.CreateUnit(ctx, bookingTestOwnerID, courtID, &dto.CourtUnitRequest{Number: 2, Surface: "clay"})
+	require.NoError(t, err)
+
+	// 停用球場，保留原編號
+	inactive := false
+	updated, err := uc.UpdateUnit(ctx, bookingTestOwnerID, courtID, second.ID, &dto.CourtUnitRequest{Number: 2, Surface: "clay", IsActive: &inactive})
+	require.NoError(t, err)
+	assert.False(t, updated.IsActive)
+
+	_, err = uc.UpdateUnit(ctx, bookingTestOwnerID, courtID, second.ID, req)
+	assert.True(t, apperror.HasCode(err, apperror.CodeCourtUnitDuplicate))
+
+	units, err := uc.GetUnits(ctx, courtID)
+	require.NoError(t, err)
+	require.Len(t, units, 2)
+	assert.Equal(t, 1, units[0].Number)
+	assert.False(t, units[1].IsActive)
+
+	// 還有未結束的預訂時不可刪除
+	start := time.Now().Add(24 * time.Hour)
+	require.NoError(t, db.Exec(`INSERT INTO bookings (id, court_id, court_unit_id, user_id, start_time, end_time, status) VALUES ('b1', ?, ?, ?, ?, ?, 'confirmed')`,
+		courtID, unit.ID, bookingTestUserID, start, start.Add(time.Hour)).Error)
+	err = uc.DeleteUnit(ctx, bookingTestOwnerID, courtID, unit.ID)
+	assert.True(t, apperror.HasCode(err, apperror.CodeCourtUnitHasBookings))
+
+	require.NoError(t, uc.DeleteUnit(ctx, bookingTestOwnerID, courtID, second.ID))
+	err = uc.DeleteUnit(ctx, bookingTestOwnerID, courtID, second.ID)
+	assert.True(t, apperror.HasCode(err, apperror.CodeCourtUnitNotFound))
+}
+
+func TestBookingUsecase_AssignCourtUnit(t *testing.T) {
+	db := setupBookingTestDB(t)
+	units := NewCourtUnitUsecase(db)
+	bookings := NewBookingUsecase(db, nil)
+	ctx := context.Background()
+	courtID := "66666666-6666-6666-6666-666666666666"
+
+	start := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	end := start.Add(time.Hour)
+
+	// 未設置球場時整個場地只能有一筆預訂
+	assigned, err := bookings.assignCourtUnit(db, bookingTestUserID, courtID, nil, start, end)
+	require.NoError(t, err)
+	assert.Nil(t, assigned)
+
+	first, err := units.CreateUnit(ctx, bookingTestOwnerID, courtID, &dto.CourtUnitRequest{Number: 1, Surface: "hard"})
+	require.NoError(t, err)
+	second, err := units.CreateUnit(ctx, bookingTestOwnerID, courtID, &dto.CourtUnitRequest{Number: 2, Surface: "hard"})
+	require.NoError(t, err)
+
+	book := func(unitID string) {
+		require.NoError(t, db.Exec(`INSERT INTO bookings (id, court_id, court_unit_id, user_id, start_time, end_time, status) VALUES (?, ?, ?, ?, ?, ?, 'confirmed')`,
+			"booking-"+unitID, courtID, unitID, bookingTestUserID, start, end).Error)
+	}
+
+	// 自動分配編號最小的空閒球場
+	assigned, err = bookings.assignCourtUnit(db, bookingTestUserID, courtID, nil, start, end)
+	require.NoError(t, err)
+	require.NotNil(t, assigned)
+	assert.Equal(t, first.ID, *assigned)
+	book(first.ID)
+
+	_, err = bookings.assignCourtUnit(db, bookingTestUserID, courtID, &first.ID, start, end)
+	assert.True(t, apperror.HasCode(err, apperror.CodeBookingSlotTaken))
+
+	assigned, err = bookings.assignCourtUnit(db, bookingTestUserID, courtID, nil, start, end)
+	require.NoError(t, err)
+	assert.Equal(t, second.ID, *assigned)
+	book(second.ID)
+
+	_, err = bookings.assignCourtUnit(db, bookingTestUserID, courtID, nil, start, end)
+	assert.True(t, apperror.HasCode(err, apperror.CodeBookingSlotTaken))
+
+	// 時段錯開時可再次預訂
+	assigned, err = bookings.assignCourtUnit(db, bookingTestUserID, courtID, nil, end, end.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, first.ID, *assigned)
+
+	missing := "77777777-7777-7777-7777-777777777777"
+	_, err = bookings.assignCourtUnit(db, bookingTestUserID, courtID, &missing, end, end.Add(time.Hour))
+	assert.True(t, apperror.HasCode(err, apperror.CodeCourtUnitNotFound))
+}
+
+func TestBookingUsecase_GetAvailabilityPerUnit(t *testing.T) {
+	db := setupBookingTestDB(t)
+	units := NewCourtUnitUsecase(db)
+	bookings := NewBookingUsecase(db, nil)
+	ctx := context.Background()
+	courtID := "66666666-6666-6666-6666-666666666666"
+
+	first, err := units.CreateUnit(ctx, bookingTestOwnerID, courtID, &dto.CourtUnitRequest{Number: 1, Surface: "hard"})
+	require.NoError(t, err)
+	_, err = units.CreateUnit(ctx, bookingTestOwnerID, courtID, &dto.CourtUnitRequest{Number: 2, Surface: "hard"})
+	require.NoError(t, err)
+
+	date := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, db.Exec(`INSERT INTO bookings (id, court_id, court_unit_id, user_id, start_time, end_time, status) VALUES ('b1', ?, ?, ?, ?, ?, 'confirmed')`,
+		courtID, first.ID, bookingTestUserID, date.Add(10*time.Hour), date.Add(11*time.Hour)).Error)
+	// 未指定球場的舊預訂佔用整個場地
+	require.NoError(t, db.Exec(`INSERT INTO bookings (id, court_id, user_id, start_time, end_time, status) VALUES ('b2', ?, ?, ?, ?, 'confirmed')`,
+		courtID, bookingTestUserID, date.Add(14*time.Hour), date.Add(15*time.Hour)).Error)
+
+	availability, err := bookings.GetAvailability(&dto.AvailabilityRequest{CourtID: courtID, Date: date, Duration: 60})
+	require.NoError(t, err)
+
+	slots := make(map[int]dto.TimeSlot)
+	for _, slot := range availability.TimeSlots {
+		slots[slot.StartTime.Hour()*60+slot.StartTime.Minute()] = slot
+	}
+
+	assert.Equal(t, 2, slots[9*60].AvailableUnits)
+	assert.Equal(t, 1, slots[10*60].AvailableUnits)
+	assert.True(t, slots[10*60].Available)
+	require.Len(t, slots[10*60].Units, 2)
+	assert.False(t, slots[10*60].Units[0].Available)
+	assert.True(t, slots[10*60].Units[1].Available)
+	assert.Equal(t, 0, slots[14*60].AvailableUnits)
+	assert.False(t, slots[14*60].Available)
+
+	// 只查詢指定球場
+	availability, err = bookings.GetAvailability(&dto.AvailabilityRequest{CourtID: courtID, CourtUnitID: &first.ID, Date: date, Duration: 60})
+	require.NoError(t, err)
+	for _, slot := range availability.TimeSlots {
+		if slot.StartTime.Hour() == 10 && slot.StartTime.Minute() == 0 {
+			assert.False(t, slot.Available)
+			assert.Len(t, slot.Units, 1)
+		}
+	}
+}
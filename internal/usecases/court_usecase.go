@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"tennis-platform/backend/internal/apperror"
 	"tennis-platform/backend/internal/dto"
 	"tennis-platform/backend/internal/models"
 
@@ -108,27 +109,28 @@ func (cu *CourtUsecase) CreateCourt(req *dto.CreateCourtRequest) (*models.Court,
 	// 轉換營業時間為 JSON
 	operatingHoursJSON, err := json.Marshal(req.OperatingHours)
 	if err != nil {
-		return nil, errors.New("營業時間格式錯誤")
+		return nil, apperror.New(apperror.CodeCourtInvalidHours)
 	}
 
 	// 創建場地
 	court := models.Court{
-		Name:           req.Name,
-		Description:    req.Description,
-		Address:        req.Address,
-		Latitude:       req.Latitude,
-		Longitude:      req.Longitude,
-		Facilities:     req.Facilities,
-		CourtType:      req.CourtType,
-		PricePerHour:   req.PricePerHour,
-		Currency:       req.Currency,
-		Images:         req.Images,
-		OperatingHours: operatingHoursJSON,
-		ContactPhone:   req.ContactPhone,
-		ContactEmail:   req.ContactEmail,
-		Website:        req.Website,
-		OwnerID:        req.OwnerID,
-		IsActive:       true,
+		Name:            req.Name,
+		Description:     req.Description,
+		Address:         req.Address,
+		Latitude:        req.Latitude,
+		Longitude:       req.Longitude,
+		Facilities:      req.Facilities,
+		CourtType:       req.CourtType,
+		PricePerHour:    req.PricePerHour,
+		Currency:        req.Currency,
+		Images:          req.Images,
+		OperatingHours:  operatingHoursJSON,
+		ContactPhone:    req.ContactPhone,
+		ContactEmail:    req.ContactEmail,
+		Website:         req.Website,
+		OwnerID:         req.OwnerID,
+		IsActive:        true,
+		CheckInRequired: req.CheckInRequired,
 	}
 
 	// 設置默認貨幣
@@ -150,7 +152,7 @@ func (cu *CourtUsecase) GetCourtByID(courtID string) (*models.Court, error) {
 		return db.Where("status = 'active'").Order("created_at DESC").Limit(5)
 	}).Preload("Reviews.User").Where("id = ? AND deleted_at IS NULL", courtID).First(&court).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("場地不存在")
+			return nil, apperror.New(apperror.CodeCourtNotFound)
 		}
 		return nil, errors.New("獲取場地失敗")
 	}
@@ -158,16 +160,21 @@ func (cu *CourtUsecase) GetCourtByID(courtID string) (*models.Court, error) {
 }
 
 // UpdateCourt 更新場地
-func (cu *CourtUsecase) UpdateCourt(courtID string, req *dto.UpdateCourtRequest) (*models.Court, error) {
+// expectedVersion 不為 nil 時需與目前版本一致，否則返回版本衝突錯誤
+func (cu *CourtUsecase) UpdateCourt(courtID string, req *dto.UpdateCourtRequest, expectedVersion *int64) (*models.Court, error) {
 	// 檢查場地是否存在
 	var court models.Court
 	if err := cu.db.Where("id = ? AND deleted_at IS NULL", courtID).First(&court).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("場地不存在")
+			return nil, apperror.New(apperror.CodeCourtNotFound)
 		}
 		return nil, errors.New("獲取場地失敗")
 	}
 
+	if expectedVersion != nil && *expectedVersion != court.Version {
+		return nil, apperror.New(apperror.CodePreconditionFailed)
+	}
+
 	// 驗證營業時間格式
 	if req.OperatingHours != nil {
 		if err := cu.validateOperatingHours(req.OperatingHours); err != nil {
@@ -230,11 +237,20 @@ func (cu *CourtUsecase) UpdateCourt(courtID string, req *dto.UpdateCourtRequest)
 	if req.IsActive != nil {
 		updates["is_active"] = *req.IsActive
 	}
+	if req.CheckInRequired != nil {
+		updates["check_in_required"] = *req.CheckInRequired
+	}
 
 	if len(updates) > 0 {
-		if err := cu.db.Model(&court).Updates(updates).Error; err != nil {
+		// 以讀取時的版本號作為條件，避免覆蓋並發的修改
+		updates["version"] = gorm.Expr("version + 1")
+		result := cu.db.Model(&court).Where("version = ?", court.Version).Updates(updates)
+		if result.Error != nil {
 			return nil, errors.New("更新場地失敗")
 		}
+		if result.RowsAffected == 0 {
+			return nil, apperror.New(apperror.CodePreconditionFailed)
+		}
 	}
 
 	// 重新載入場地數據
@@ -250,7 +266,7 @@ func (cu *CourtUsecase) DeleteCourt(courtID string) error {
 	var court models.Court
 	if err := cu.db.Where("id = ? AND deleted_at IS NULL", courtID).First(&court).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return errors.New("場地不存在")
+			return apperror.New(apperror.CodeCourtNotFound)
 		}
 		return errors.New("獲取場地失敗")
 	}
@@ -441,7 +457,7 @@ func (cu *CourtUsecase) validateOperatingHours(hours map[string]string) error {
 
 	for day, timeRange := range hours {
 		if !validDays[day] {
-			return fmt.Errorf("無效的星期: %s", day)
+			return apperror.New(apperror.CodeCourtInvalidWeekday).With("day", day)
 		}
 
 		// 驗證時間格式 (例如: "09:00-18:00" 或 "closed")
@@ -451,7 +467,7 @@ func (cu *CourtUsecase) validateOperatingHours(hours map[string]string) error {
 
 		// 簡單的時間格式驗證
 		if len(timeRange) < 11 || timeRange[5] != '-' {
-			return fmt.Errorf("無效的時間格式: %s，應為 HH:MM-HH:MM 或 closed", timeRange)
+			return apperror.New(apperror.CodeCourtInvalidTimeRange).With("value", timeRange)
 		}
 	}
 
@@ -477,7 +493,7 @@ func (cu *CourtUsecase) validateFacilities(facilities []string) error {
 
 	for _, facility := range facilities {
 		if !validFacilities[facility] {
-			return fmt.Errorf("無效的設施: %s", facility)
+			return apperror.New(apperror.CodeCourtInvalidFacility).With("facility", facility)
 		}
 	}
 
@@ -0,0 +1,114 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"tennis-platform/backend/internal/apperror"
+	"tennis-platform/backend/internal/dto"
+	"tennis-platform/backend/internal/models"
+	"tennis-platform/backend/internal/services"
+
+	"gorm.io/gorm"
+)
+
+// InvoiceUsecase 發票用例
+type InvoiceUsecase struct {
+	db             *gorm.DB
+	invoiceService *services.InvoiceService
+}
+
+// NewInvoiceUsecase 創建新的發票用例
+func NewInvoiceUsecase(db *gorm.DB, invoiceService *services.InvoiceService) *InvoiceUsecase {
+	return &InvoiceUsecase{
+		db:             db,
+		invoiceService: invoiceService,
+	}
+}
+
+// ListInvoices 列出自己付款的發票，或自己的場地、課程及俱樂部開立的發票
+func (iu *InvoiceUsecase) ListInvoices(ctx context.Context, userID string, req *dto.InvoiceListRequest) (*dto.InvoiceListResponse, error) {
+	query := iu.db.WithContext(ctx).Model(&models.Invoice{})
+	if req.Role == "seller" {
+		query = query.Where("seller_user_id = ?", userID)
+	} else {
+		query = query.Where("buyer_id = ?", userID)
+	}
+	if req.PaymentID != "" {
+		query = query.Where("payment_id = ?", req.PaymentID)
+	}
+	if req.Kind != "" {
+		query = query.Where("kind = ?", req.Kind)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, errors.New("獲取發票總數失敗")
+	}
+
+	page := req.Page
+	if page < 1 {
+		page = 1
+	}
+	pageSize := req.PageSize
+	if pageSize < 1 {
+		pageSize = 20
+	}
+
+	invoices := []models.Invoice{}
+	if err := query.Order("issued_at DESC, number DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&invoices).Error; err != nil {
+		return nil, errors.New("獲取發票列表失敗")
+	}
+
+	return &dto.InvoiceListResponse{
+		Invoices:   invoices,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: int((total + int64(pageSize) - 1) / int64(pageSize)),
+	}, nil
+}
+
+// GetInvoice 獲取發票詳情，只有付款人及開立方可以查看
+func (iu *InvoiceUsecase) GetInvoice(ctx context.Context, userID, invoiceID string) (*models.Invoice, error) {
+	var invoice models.Invoice
+	if err := iu.db.WithContext(ctx).Where("id = ?", invoiceID).First(&invoice).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.New(apperror.CodeInvoiceNotFound)
+		}
+		return nil, errors.New("獲取發票失敗")
+	}
+
+	// 不透露其他用戶的發票是否存在
+	if invoice.BuyerID != userID && (invoice.SellerUserID == nil || *invoice.SellerUserID != userID) {
+		return nil, apperror.New(apperror.CodeInvoiceNotFound)
+	}
+	return &invoice, nil
+}
+
+// DownloadInvoice 返回發票的 PDF，尚未生成時先生成
+func (iu *InvoiceUsecase) DownloadInvoice(ctx context.Context, userID, invoiceID string) (*models.Invoice, []byte, error) {
+	invoice, err := iu.GetInvoice(ctx, userID, invoiceID)
+	if err != nil {
+		return nil, nil, err
+	}
+	data, err := iu.invoiceService.PDF(ctx, invoice)
+	if err != nil {
+		return nil, nil, errors.New("生成發票文件失敗")
+	}
+	return invoice, data, nil
+}
+
+// ResendInvoice 重新寄送發票給付款人
+func (iu *InvoiceUsecase) ResendInvoice(ctx context.Context, userID, invoiceID string) (*models.Invoice, error) {
+	invoice, err := iu.GetInvoice(ctx, userID, invoiceID)
+	if err != nil {
+		return nil, err
+	}
+	if err := iu.invoiceService.Send(ctx, invoice); err != nil {
+		return nil, errors.New("寄送發票失敗")
+	}
+	return invoice, nil
+}
@@ -0,0 +1,112 @@
+package usecases
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"tennis-platform/backend/internal/apperror"
+	"tennis-platform/backend/internal/config"
+	"tennis-platform/backend/internal/dto"
+	"tennis-platform/backend/internal/models"
+	"tennis-platform/backend/internal/services"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInvoiceUsecase_IssueAndCreditNote(t *testing.T) {
+	db := setupBookingTestDB(t)
+	paymentService := services.NewPaymentService(db, services.NewLocalPaymentProvider("secret"), nil, 15*time.Minute)
+	payments := NewPaymentUsecase(db, paymentService)
+	uploads := services.NewUploadService(&config.Config{}, services.NewLocalStorage(t.TempDir(), "secret"))
+	invoiceService := services.NewInvoiceService(db, uploads, services.NewMockNotificationService(), config.InvoiceConfig{TaxRate: 5})
+	invoices := NewInvoiceUsecase(db, invoiceService)
+	ctx := context.Background()
+
+	require.NoError(t, db.Exec(`INSERT INTO users (id, email) VALUES (?, 'ming@example.com')`, bookingTestUserID).Error)
+	require.NoError(t, db.Exec(`INSERT INTO user_profiles (user_id, first_name, last_name) VALUES (?, 'Ming', 'Wang')`, bookingTestUserID).Error)
+
+	pay := func(bookingID string) *models.Payment {
+		start := time.Now().Add(48 * time.Hour).UTC().Truncate(time.Hour)
+		require.NoError(t, db.Create(&models.Booking{
+			ID: bookingID, CourtID: "66666666-6666-6666-6666-666666666666", UserID: bookingTestUserID,
+			StartTime: start, EndTime: start.Add(2 * time.Hour), TotalPrice: 1050, Status: "pending", Version: 1,
+		}).Error)
+		payment, err := payments.CreatePayment(ctx, bookingTestUserID, &dto.CreatePaymentRequest{TargetType: services.PaymentTargetBooking, TargetID: bookingID})
+		require.NoError(t, err)
+		payment, err = payments.CapturePayment(ctx, bookingTestUserID, payment.ID)
+		require.NoError(t, err)
+		return payment
+	}
+	payment := pay("d1000000-0000-0000-0000-000000000001")
+	year := time.Now().Year()
+
+	invoice, err := invoiceService.IssueForPayment(ctx, payment.ID)
+	require.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("INV-%d-000001", year), invoice.Number)
+	assert.Equal(t, models.InvoiceKindInvoice, invoice.Kind)
+	assert.Equal(t, "Ming Wang", invoice.BuyerName)
+	assert.Equal(t, "河濱網球場", invoice.SellerName)
+	require.NotNil(t, invoice.SellerUserID)
+	assert.Equal(t, bookingTestOwnerID, *invoice.SellerUserID)
+	assert.InDelta(t, 1050, invoice.Total, 0.001)
+	assert.InDelta(t, 50, invoice.TaxAmount, 0.001)
+	assert.InDelta(t, 1000, invoice.Subtotal, 0.001)
+	require.Len(t, invoice.Lines, 1)
+	assert.InDelta(t, 1050, invoice.Lines[0].Amount, 0.001)
+	assert.NotEmpty(t, invoice.FileKey)
+
+	// 重複處理同一扣款不重複開立
+	again, err := invoiceService.IssueForPayment(ctx, payment.ID)
+	require.NoError(t, err)
+	assert.Equal(t, invoice.ID, again.ID)
+
+	// 付款人及場地擁有者可以下載，其他用戶看不到發票
+	got, data, err := invoices.DownloadInvoice(ctx, bookingTestUserID, invoice.ID)
+	require.NoError(t, err)
+	assert.Equal(t, invoice.Number, got.Number)
+	assert.True(t, bytes.HasPrefix(data, []byte("%PDF")))
+	_, err = invoices.GetInvoice(ctx, bookingTestOwnerID, invoice.ID)
+	require.NoError(t, err)
+	_, err = invoices.GetInvoice(ctx, "77777777-7777-7777-7777-777777777777", invoice.ID)
+	assert.True(t, apperror.HasCode(err, apperror.CodeInvoiceNotFound))
+
+	// 部分退款開立折讓單
+	require.NoError(t, paymentService.Refund(ctx, payment, 210))
+	note, err := invoiceService.IssueCreditNote(ctx, payment.ID, 210, 210)
+	require.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("CN-%d-000001", year), note.Number)
+	assert.Equal(t, models.InvoiceKindCreditNote, note.Kind)
+	require.NotNil(t, note.OriginalInvoiceID)
+	assert.Equal(t, invoice.ID, *note.OriginalInvoiceID)
+	assert.InDelta(t, 210, note.Total, 0.001)
+	assert.InDelta(t, 10, note.TaxAmount, 0.001)
+	noteAgain, err := invoiceService.IssueCreditNote(ctx, payment.ID, 210, 210)
+	require.NoError(t, err)
+	assert.Equal(t, note.ID, noteAgain.ID)
+
+	// 號碼按字軌遞增
+	second, err := invoiceService.IssueForPayment(ctx, pay("d1000000-0000-0000-0000-000000000002").ID)
+	require.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("INV-%d-000002", year), second.Number)
+
+	list, err := invoices.ListInvoices(ctx, bookingTestUserID, &dto.InvoiceListRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), list.Total)
+	list, err = invoices.ListInvoices(ctx, bookingTestUserID, &dto.InvoiceListRequest{PaymentID: payment.ID, Kind: models.InvoiceKindCreditNote})
+	require.NoError(t, err)
+	require.Len(t, list.Invoices, 1)
+	assert.Equal(t, note.ID, list.Invoices[0].ID)
+	list, err = invoices.ListInvoices(ctx, bookingTestOwnerID, &dto.InvoiceListRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), list.Total)
+	list, err = invoices.ListInvoices(ctx, bookingTestOwnerID, &dto.InvoiceListRequest{Role: "seller"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), list.Total)
+
+	resent, err := invoices.ResendInvoice(ctx, bookingTestUserID, invoice.ID)
+	require.NoError(t, err)
+	assert.NotNil(t, resent.EmailedAt)
+}
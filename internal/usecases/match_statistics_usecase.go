@@ -1,7 +1,9 @@
 package usecases
 
 import (
+	"errors"
 	"fmt"
+	"tennis-platform/backend/internal/apperror"
 	"tennis-platform/backend/internal/models"
 	"tennis-platform/backend/internal/services"
 
@@ -16,10 +18,10 @@ type MatchStatisticsUseCase struct {
 }
 
 // NewMatchStatisticsUseCase 創建新的配對統計用例
-func NewMatchStatisticsUseCase(db *gorm.DB) *MatchStatisticsUseCase {
+func NewMatchStatisticsUseCase(db *gorm.DB, eventBus *services.EventBus) *MatchStatisticsUseCase {
 	return &MatchStatisticsUseCase{
 		db:                     db,
-		matchStatisticsService: services.NewMatchStatisticsService(db),
+		matchStatisticsService: services.NewMatchStatisticsService(db, eventBus),
 		reputationService:      services.NewReputationService(db),
 	}
 }
@@ -55,7 +57,7 @@ func (msuc *MatchStatisticsUseCase) GetUserMatchHistory(userID, requestingUserID
 
 	isOwner := userID == requestingUserID
 	if !isOwner && !privacy.ShowMatchHistory {
-		return nil, fmt.Errorf("match history is private")
+		return nil, apperror.New(apperror.CodeMatchHistoryPrivate)
 	}
 
 	// 獲取配對歷史
@@ -85,7 +87,10 @@ func (msuc *MatchStatisticsUseCase) RecordMatchResult(matchID, winnerID, loserID
 	var match models.Match
 	err := msuc.db.Preload("Participants").Where("id = ?", matchID).First(&match).Error
 	if err != nil {
-		return fmt.Errorf("match not found: %w", err)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apperror.New(apperror.CodeMatchNotFound)
+		}
+		return fmt.Errorf("failed to get match: %w", err)
 	}
 
 	isParticipant := false
@@ -97,7 +102,7 @@ func (msuc *MatchStatisticsUseCase) RecordMatchResult(matchID, winnerID, loserID
 	}
 
 	if !isParticipant {
-		return fmt.Errorf("only match participants can record results")
+		return apperror.New(apperror.CodeMatchNotParticipant)
 	}
 
 	// 驗證勝負者都是比賽參與者
@@ -113,7 +118,7 @@ func (msuc *MatchStatisticsUseCase) RecordMatchResult(matchID, winnerID, loserID
 	}
 
 	if !winnerIsParticipant || !loserIsParticipant {
-		return fmt.Errorf("winner and loser must be match participants")
+		return apperror.New(apperror.CodeMatchResultInvalidPlayers)
 	}
 
 	// 記錄比賽結果
@@ -133,16 +138,7 @@ func (msuc *MatchStatisticsUseCase) RecordMatchResult(matchID, winnerID, loserID
 		return fmt.Errorf("failed to update loser reputation: %w", err)
 	}
 
-	// 自動調整技術等級
-	err = msuc.matchStatisticsService.AutoAdjustSkillLevel(winnerID)
-	if err != nil {
-		return fmt.Errorf("failed to auto adjust winner skill level: %w", err)
-	}
-
-	err = msuc.matchStatisticsService.AutoAdjustSkillLevel(loserID)
-	if err != nil {
-		return fmt.Errorf("failed to auto adjust loser skill level: %w", err)
-	}
+	// 技術等級調整在雙方確認結果後由事件訂閱者處理
 
 	return nil
 }
@@ -162,7 +158,7 @@ func (msuc *MatchStatisticsUseCase) GetSkillLevelProgression(userID, requestingU
 
 	isOwner := userID == requestingUserID
 	if !isOwner && !privacy.ShowSkillProgression {
-		return nil, fmt.Errorf("skill progression is private")
+		return nil, apperror.New(apperror.CodeSkillProgressionPrivate)
 	}
 
 	var skillRecords []models.SkillLevelRecord
@@ -182,7 +178,7 @@ func (msuc *MatchStatisticsUseCase) GetSkillLevelProgression(userID, requestingU
 func (msuc *MatchStatisticsUseCase) ManuallyAdjustSkillLevel(userID string, newLevel float64, reason string, adjustedBy string) error {
 	// 驗證等級範圍
 	if newLevel < 1.0 || newLevel > 7.0 {
-		return fmt.Errorf("NTRP level must be between 1.0 and 7.0")
+		return apperror.New(apperror.CodeUserInvalidNTRPLevel)
 	}
 
 	// 獲取用戶當前等級
@@ -273,7 +269,7 @@ func (msuc *MatchStatisticsUseCase) GetReputationScoreWithPrivacy(userID, reques
 
 	isOwner := userID == requestingUserID
 	if !isOwner && !privacy.ShowReputationScore {
-		return nil, fmt.Errorf("reputation score is private")
+		return nil, apperror.New(apperror.CodeReputationScorePrivate)
 	}
 
 	// 獲取信譽分數
@@ -295,7 +291,7 @@ func (msuc *MatchStatisticsUseCase) GetBehaviorReviewsWithPrivacy(userID, reques
 
 	isOwner := userID == requestingUserID
 	if !isOwner && !privacy.ShowBehaviorReviews {
-		return nil, fmt.Errorf("behavior reviews are private")
+		return nil, apperror.New(apperror.CodeBehaviorReviewsPrivate)
 	}
 
 	// 獲取行為評價
@@ -8,7 +8,9 @@ import (
 	"strings"
 	"time"
 
+	"tennis-platform/backend/internal/apperror"
 	"tennis-platform/backend/internal/models"
+	"tennis-platform/backend/internal/pagination"
 	"tennis-platform/backend/internal/services"
 
 	"gorm.io/gorm"
@@ -18,13 +20,15 @@ import (
 type MatchingUsecase struct {
 	db              *gorm.DB
 	matchingService *services.MatchingService
+	eventBus        *services.EventBus
 }
 
 // NewMatchingUsecase 創建配對業務邏輯實例
-func NewMatchingUsecase(db *gorm.DB) *MatchingUsecase {
+func NewMatchingUsecase(db *gorm.DB, eventBus *services.EventBus) *MatchingUsecase {
 	return &MatchingUsecase{
 		db:              db,
 		matchingService: services.NewMatchingService(),
+		eventBus:        eventBus,
 	}
 }
 
@@ -410,31 +414,54 @@ func (uc *MatchingUsecase) UpdateUserReputationScore(
 	return nil
 }
 
-// GetMatchingHistory 獲取配對歷史
+// GetMatchingHistory 獲取配對歷史，matchTypes 為空時返回所有類型
 func (uc *MatchingUsecase) GetMatchingHistory(
 	ctx context.Context,
 	userID string,
-	limit, offset int,
-) ([]models.Match, error) {
-	var matches []models.Match
+	matchTypes []string,
+	page *pagination.Query,
+) ([]models.Match, pagination.PageInfo, error) {
+	page.Keys = []pagination.Key{
+		{Column: "matches.created_at", Kind: pagination.KindTime},
+		{Column: "matches.id", Kind: pagination.KindString},
+	}
+	page.Desc = true
 
-	err := uc.db.WithContext(ctx).
+	query := uc.db.WithContext(ctx).Model(&models.Match{}).
 		Joins("JOIN match_participants ON matches.id = match_participants.match_id").
-		Where("match_participants.user_id = ?", userID).
+		Where("match_participants.user_id = ?", userID)
+
+	if len(matchTypes) > 0 {
+		query = query.Where("matches.type IN ?", matchTypes)
+	}
+
+	// 頁碼分頁時計算總數
+	var total *int64
+	if !page.IsCursor() {
+		var count int64
+		if err := query.Count(&count).Error; err != nil {
+			return nil, pagination.PageInfo{}, fmt.Errorf("failed to count matching history: %w", err)
+		}
+		total = &count
+	}
+
+	query, err := page.Apply(query.
 		Preload("Participants").
 		Preload("Participants.Profile").
 		Preload("Court").
-		Preload("Results").
-		Order("matches.created_at DESC").
-		Limit(limit).
-		Offset(offset).
-		Find(&matches).Error
-
+		Preload("Results"))
 	if err != nil {
-		return nil, fmt.Errorf("failed to get matching history: %w", err)
+		return nil, pagination.PageInfo{}, err
 	}
 
-	return matches, nil
+	var matches []models.Match
+	if err := query.Find(&matches).Error; err != nil {
+		return nil, pagination.PageInfo{}, fmt.Errorf("failed to get matching history: %w", err)
+	}
+
+	return pagination.Build(matches, page, total, func(m *models.Match) []interface{} {
+		return []interface{}{m.CreatedAt, m.ID}
+	})
 }
 
 // CreateMatch 創建配對
@@ -754,11 +781,26 @@ func (uc *MatchingUsecase) ProcessCardAction(
 			}
 
 			// 創建通知
-			if err := uc.createMatchNotifications(tx, userID, targetUserID, match.ID); err != nil {
+			notificationIDs, err := uc.createMatchNotifications(tx, userID, targetUserID, match.ID)
+			if err != nil {
 				tx.Rollback()
 				return nil, fmt.Errorf("failed to create notifications: %w", err)
 			}
 
+			// 發布配對成功事件
+			if uc.eventBus != nil {
+				payload := services.CardMatchedEventPayload{
+					MatchID:         match.ID,
+					ChatRoomID:      match.ChatRoom.ID,
+					UserIDs:         []string{userID, targetUserID},
+					NotificationIDs: notificationIDs,
+				}
+				if err := uc.eventBus.Publish(tx, services.EventCardMatched, "match", match.ID, payload); err != nil {
+					tx.Rollback()
+					return nil, fmt.Errorf("failed to publish match event: %w", err)
+				}
+			}
+
 			result.IsMatch = true
 			result.MatchID = match.ID
 			result.ChatRoomID = match.ChatRoom.ID
@@ -775,6 +817,10 @@ func (uc *MatchingUsecase) ProcessCardAction(
 		return nil, fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
+	if result.IsMatch && uc.eventBus != nil {
+		uc.eventBus.Notify()
+	}
+
 	return result, nil
 }
 
@@ -838,11 +884,11 @@ func (uc *MatchingUsecase) createMatchFromCardInteraction(
 	return &match, nil
 }
 
-// createMatchNotifications 創建配對通知
+// createMatchNotifications 創建配對通知，返回通知ID
 func (uc *MatchingUsecase) createMatchNotifications(
 	tx *gorm.DB,
 	userID1, userID2, matchID string,
-) error {
+) ([]string, error) {
 	notifications := []models.MatchNotification{
 		{
 			UserID:  userID1,
@@ -860,13 +906,15 @@ func (uc *MatchingUsecase) createMatchNotifications(
 		},
 	}
 
-	for _, notification := range notifications {
-		if err := tx.Create(&notification).Error; err != nil {
-			return err
+	notificationIDs := make([]string, 0, len(notifications))
+	for i := range notifications {
+		if err := tx.Create(&notifications[i]).Error; err != nil {
+			return nil, err
 		}
+		notificationIDs = append(notificationIDs, notifications[i].ID)
 	}
 
-	return nil
+	return notificationIDs, nil
 }
 
 // GetCardInteractionHistory 獲取抽卡互動歷史
@@ -959,7 +1007,7 @@ func (uc *MatchingUsecase) MarkNotificationAsRead(
 	}
 
 	if result.RowsAffected == 0 {
-		return fmt.Errorf("notification not found")
+		return apperror.New(apperror.CodeMatchNotificationNotFound)
 	}
 
 	return nil
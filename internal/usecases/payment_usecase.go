@@ -0,0 +1,366 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"tennis-platform/backend/internal/apperror"
+	"tennis-platform/backend/internal/dto"
+	"tennis-platform/backend/internal/models"
+	"tennis-platform/backend/internal/services"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PaymentUsecase 付款用例
+type PaymentUsecase struct {
+	db             *gorm.DB
+	paymentService *services.PaymentService
+}
+
+// NewPaymentUsecase 創建新的付款用例
+func NewPaymentUsecase(db *gorm.DB, paymentService *services.PaymentService) *PaymentUsecase {
+	return &PaymentUsecase{
+		db:             db,
+		paymentService: paymentService,
+	}
+}
+
+// paymentTarget 付款目標（預訂、重複預訂、課程、活動報名或會費）的付款資訊
+type paymentTarget struct {
+	userID      string
+	amount      float64
+	currency    string
+	description string
+	payable     bool // 目標仍在等待付款
+	paid        bool
+	dueAt       *time.Time
+}
+
+// CreatePayment 為預訂、整組付款的重複預訂、課程、活動報名或俱樂部會費發起付款
+//
+// 同一目標已有進行中的付款時直接返回該付款，重複提交不會建立多筆付款意圖。
+func (pu *PaymentUsecase) CreatePayment(ctx context.Context, userID string, req *dto.CreatePaymentRequest) (*models.Payment, error) {
+	target, err := pu.loadTarget(ctx, req.TargetType, req.TargetID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 不透露其他用戶的項目是否存在
+	if target.userID != userID {
+		return nil, apperror.New(apperror.CodePaymentTargetNotFound)
+	}
+	if target.paid {
+		return nil, apperror.New(apperror.CodePaymentAlreadyPaid)
+	}
+	if target.amount <= 0 {
+		return nil, apperror.New(apperror.CodePaymentNotRequired)
+	}
+	holdExpired := target.dueAt != nil && !time.Now().Before(*target.dueAt)
+	if holdExpired {
+		return nil, apperror.New(apperror.CodePaymentHoldExpired)
+	}
+	if !target.payable {
+		return nil, apperror.New(apperror.CodePaymentNotRequired)
+	}
+
+	var existing models.Payment
+	err = pu.db.WithContext(ctx).
+		Where("target_type = ? AND target_id = ? AND status IN ?", req.TargetType, req.TargetID,
+			[]string{services.PaymentStatusPending, services.PaymentStatusAuthorized}).
+		Order("created_at DESC").
+		First(&existing).Error
+	if err == nil {
+		return &existing, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errors.New("獲取付款失敗")
+	}
+
+	provider := pu.paymentService.Provider()
+	intent, err := provider.CreateIntent(ctx, services.PaymentIntentParams{
+		Amount:      target.amount,
+		Currency:    target.currency,
+		Description: target.description,
+		Metadata: map[string]string{
+			"targetType": req.TargetType,
+			"targetId":   req.TargetID,
+			"userId":     userID,
+		},
+	})
+	if err != nil {
+		return nil, apperror.Wrap(apperror.CodePaymentProviderError, err)
+	}
+
+	payment := models.Payment{
+		UserID:            userID,
+		TargetType:        req.TargetType,
+		TargetID:          req.TargetID,
+		Amount:            target.amount,
+		Currency:          target.currency,
+		Status:            services.PaymentStatusPending,
+		Provider:          provider.Name(),
+		ProviderPaymentID: intent.ProviderPaymentID,
+		ClientSecret:      intent.ClientSecret,
+		ExpiresAt:         target.dueAt,
+	}
+	if err := pu.db.WithContext(ctx).Create(&payment).Error; err != nil {
+		// 記錄寫入失敗時取消服務商的意圖，避免付款人完成一筆無法追蹤的付款
+		_ = provider.Cancel(ctx, intent.ProviderPaymentID)
+		return nil, errors.New("創建付款失敗")
+	}
+
+	return &payment, nil
+}
+
+// GetPayment 獲取付款詳情
+func (pu *PaymentUsecase) GetPayment(ctx context.Context, userID, paymentID string) (*models.Payment, error) {
+	return pu.getOwnedPayment(ctx, userID, paymentID)
+}
+
+// CapturePayment 扣款並確認付款目標，重複扣款返回目前的付款記錄
+func (pu *PaymentUsecase) CapturePayment(ctx context.Context, userID, paymentID string) (*models.Payment, error) {
+	payment, err := pu.getOwnedPayment(ctx, userID, paymentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if payment.Status == services.PaymentStatusCaptured {
+		return payment, nil
+	}
+	if !services.IsPaymentActive(payment.Status) {
+		return nil, apperror.New(apperror.CodePaymentInvalidState).With("status", payment.Status)
+	}
+	if payment.ExpiresAt != nil && !time.Now().Before(*payment.ExpiresAt) {
+		return nil, apperror.New(apperror.CodePaymentHoldExpired)
+	}
+
+	if err := pu.paymentService.Provider().Capture(ctx, payment.ProviderPaymentID); err != nil {
+		return nil, apperror.Wrap(apperror.CodePaymentProviderError, err)
+	}
+
+	confirmed, err := pu.paymentService.MarkCaptured(ctx, payment)
+	if err != nil {
+		return nil, err
+	}
+	if !confirmed {
+		// 保留在扣款前已被取消，款項已自動退還
+		return nil, apperror.New(apperror.CodePaymentHoldExpired)
+	}
+
+	return pu.getOwnedPayment(ctx, userID, paymentID)
+}
+
+// CancelPayment 放棄尚未扣款的付款，付款目標保持未付款，可重新發起付款
+func (pu *PaymentUsecase) CancelPayment(ctx context.Context, userID, paymentID string) (*models.Payment, error) {
+	payment, err := pu.getOwnedPayment(ctx, userID, paymentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := pu.paymentService.Cancel(ctx, payment); err != nil {
+		return nil, err
+	}
+
+	return pu.getOwnedPayment(ctx, userID, paymentID)
+}
+
+// HandleWebhook 處理付款服務商的通知
+func (pu *PaymentUsecase) HandleWebhook(ctx context.Context, provider string, payload []byte, header http.Header) error {
+	if provider != pu.paymentService.Provider().Name() {
+		return apperror.New(apperror.CodePaymentInvalidSignature)
+	}
+	return pu.paymentService.HandleWebhook(ctx, payload, header)
+}
+
+// getOwnedPayment 獲取屬於用戶的付款
+func (pu *PaymentUsecase) getOwnedPayment(ctx context.Context, userID, paymentID string) (*models.Payment, error) {
+	var payment models.Payment
+	if err := pu.db.WithContext(ctx).Where("id = ? AND user_id = ?", paymentID, userID).First(&payment).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.New(apperror.CodePaymentNotFound)
+		}
+		return nil, errors.New("獲取付款失敗")
+	}
+	return &payment, nil
+}
+
+// loadTarget 讀取付款目標的金額、付款人及狀態
+func (pu *PaymentUsecase) loadTarget(ctx context.Context, targetType, targetID string) (*paymentTarget, error) {
+	db := pu.db.WithContext(ctx)
+
+	switch targetType {
+	case services.PaymentTargetBooking:
+		var booking models.Booking
+		if err := db.Preload("Court").Where("id = ?", targetID).First(&booking).Error; err != nil {
+			return nil, pu.targetError(err)
+		}
+		currency := "TWD"
+		description := "場地預訂"
+		if booking.Court != nil {
+			if booking.Court.Currency != "" {
+				currency = booking.Court.Currency
+			}
+			description = fmt.Sprintf("場地預訂 %s", booking.Court.Name)
+		}
+		payable := booking.Status == "pending"
+		if payable && booking.SeriesID != nil {
+			// 整組付款的重複預訂只能以重複預訂付款
+			var aggregate int64
+			if err := db.Model(&models.BookingSeries{}).
+				Where("id = ? AND billing_mode = ?", *booking.SeriesID, models.BookingSeriesBillingAggregate).
+				Count(&aggregate).Error; err != nil {
+				return nil, errors.New("獲取付款項目失敗")
+			}
+			payable = aggregate == 0
+		}
+		if payable {
+			// 進行中分攤付款的預訂由各付款人分別支付分攤
+			var splits int64
+			if err := db.Model(&models.BookingPaymentSplit{}).
+				Where("booking_id = ? AND status = ?", booking.ID, models.PaymentSplitOpen).
+				Count(&splits).Error; err != nil {
+				return nil, errors.New("獲取付款項目失敗")
+			}
+			payable = splits == 0
+		}
+		return &paymentTarget{
+			userID:      booking.UserID,
+			amount:      booking.TotalPrice,
+			currency:    currency,
+			description: description,
+			payable:     payable,
+			paid:        booking.PaymentID != nil,
+			dueAt:       booking.PaymentDueAt,
+		}, nil
+
+	case services.PaymentTargetBookingSeries:
+		var series models.BookingSeries
+		if err := db.Preload("Court").Where("id = ?", targetID).First(&series).Error; err != nil {
+			return nil, pu.targetError(err)
+		}
+		// 金額為尚未付款、未取消的各次預訂總和
+		var amount float64
+		if err := db.Model(&models.Booking{}).
+			Where("series_id = ? AND status = ? AND payment_id IS NULL", series.ID, "pending").
+			Select("COALESCE(SUM(total_price), 0)").
+			Scan(&amount).Error; err != nil {
+			return nil, errors.New("獲取付款項目失敗")
+		}
+		currency := "TWD"
+		description := "重複預訂"
+		if series.Court != nil {
+			if series.Court.Currency != "" {
+				currency = series.Court.Currency
+			}
+			description = fmt.Sprintf("重複預訂 %s", series.Court.Name)
+		}
+		return &paymentTarget{
+			userID:      series.UserID,
+			amount:      amount,
+			currency:    currency,
+			description: description,
+			payable:     series.Status == "active" && series.BillingMode == models.BookingSeriesBillingAggregate,
+			paid:        series.PaymentID != nil,
+			dueAt:       series.PaymentDueAt,
+		}, nil
+
+	case services.PaymentTargetLesson:
+		var lesson models.Lesson
+		if err := db.Where("id = ?", targetID).First(&lesson).Error; err != nil {
+			return nil, pu.targetError(err)
+		}
+		currency := lesson.Currency
+		if currency == "" {
+			currency = "TWD"
+		}
+		return &paymentTarget{
+			userID:      lesson.StudentID,
+			amount:      lesson.Price,
+			currency:    currency,
+			description: "網球課程",
+			payable:     lesson.Status == "scheduled",
+			paid:        lesson.PaymentID != nil,
+			dueAt:       lesson.PaymentDueAt,
+		}, nil
+
+	case services.PaymentTargetClubEventRegistration:
+		var participant models.ClubEventParticipant
+		if err := db.Preload("Event").Where("id = ?", targetID).First(&participant).Error; err != nil {
+			return nil, pu.targetError(err)
+		}
+		target := &paymentTarget{
+			userID:   participant.UserID,
+			currency: "TWD",
+			payable:  participant.Status == "registered",
+			paid:     participant.PaymentID != nil,
+			dueAt:    participant.PaymentDueAt,
+		}
+		if participant.Event != nil {
+			if participant.Event.RegistrationFee != nil {
+				target.amount = *participant.Event.RegistrationFee
+			}
+			if participant.Event.Currency != "" {
+				target.currency = participant.Event.Currency
+			}
+			target.description = fmt.Sprintf("活動報名 %s", participant.Event.Title)
+		}
+		return target, nil
+
+	case services.PaymentTargetBookingShare:
+		var share models.BookingPaymentShare
+		if err := db.Preload("Split").Preload("Booking.Court").Where("id = ?", targetID).First(&share).Error; err != nil {
+			return nil, pu.targetError(err)
+		}
+		target := &paymentTarget{
+			userID:      share.UserID,
+			amount:      share.Amount,
+			currency:    "TWD",
+			description: "分攤場地費用",
+			payable:     share.Status == models.PaymentShareStatusPending && share.Split != nil && share.Split.Status == models.PaymentSplitOpen,
+			paid:        share.PaymentID != nil,
+		}
+		if share.Split != nil {
+			target.currency = share.Split.Currency
+			target.dueAt = &share.Split.DueAt
+		}
+		if share.Booking != nil && share.Booking.Court != nil {
+			target.description = fmt.Sprintf("分攤場地費用 %s", share.Booking.Court.Name)
+		}
+		return target, nil
+
+	case services.PaymentTargetClubMembership:
+		var member models.ClubMember
+		if err := db.Preload("Club").Where("id = ?", targetID).First(&member).Error; err != nil {
+			return nil, pu.targetError(err)
+		}
+		// 會費依俱樂部設定的會籍類型收費
+		target := &paymentTarget{
+			userID:   member.UserID,
+			currency: "TWD",
+			payable:  member.Status == "active",
+			paid:     member.PaymentID != nil,
+		}
+		if member.Club != nil {
+			target.amount = member.Club.MembershipFees[member.MembershipType]
+			if member.Club.Currency != "" {
+				target.currency = member.Club.Currency
+			}
+			target.description = fmt.Sprintf("俱樂部會費 %s", member.Club.Name)
+		}
+		return target, nil
+
+	default:
+		return nil, apperror.New(apperror.CodePaymentTargetNotFound)
+	}
+}
+
+// targetError 將讀取付款目標的錯誤轉換為應用錯誤
+func (pu *PaymentUsecase) targetError(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return apperror.New(apperror.CodePaymentTargetNotFound)
+	}
+	return errors.New("獲取付款項目失敗")
+}
@@ -0,0 +1,140 @@
+package usecases
+
+import (
+	"context"
+	"tennis-platform/backend/internal/apperror"
+	"tennis-platform/backend/internal/dto"
+	"tennis-platform/backend/internal/models"
+	"tennis-platform/backend/internal/services"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+const paymentUserID = "11111111-1111-1111-1111-111111111111"
+
+func setupPaymentUsecaseTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+
+	// 手動創建表結構，只包含付款流程需要的欄位
+	for _, stmt := range []string{
+		`CREATE TABLE payments (id TEXT PRIMARY KEY, user_id TEXT NOT NULL, target_type TEXT NOT NULL, target_id TEXT NOT NULL, amount REAL NOT NULL, currency TEXT, status TEXT NOT NULL, provider TEXT NOT NULL, provider_payment_id TEXT NOT NULL UNIQUE, client_secret TEXT, refunded_amount REAL NOT NULL DEFAULT 0, failure_reason TEXT, expires_at DATETIME, authorized_at DATETIME, captured_at DATETIME, cancelled_at DATETIME, created_at DATETIME, updated_at DATETIME)`,
+		`CREATE TABLE courts (id TEXT PRIMARY KEY, name TEXT, currency TEXT, deleted_at DATETIME)`,
+		`CREATE TABLE bookings (id TEXT PRIMARY KEY, court_id TEXT, user_id TEXT, start_time DATETIME, end_time DATETIME, total_price REAL, status TEXT, payment_id TEXT, payment_due_at DATETIME, version INTEGER DEFAULT 1, updated_at DATETIME, deleted_at DATETIME)`,
+		`CREATE TABLE booking_payment_splits (id TEXT PRIMARY KEY, booking_id TEXT NOT NULL, owner_id TEXT NOT NULL, match_id TEXT, mode TEXT NOT NULL, fallback TEXT NOT NULL, status TEXT NOT NULL, due_at DATETIME NOT NULL, owner_charged_at DATETIME, total REAL NOT NULL, currency TEXT NOT NULL DEFAULT 'TWD', created_at DATETIME, updated_at DATETIME)`,
+		`CREATE TABLE booking_payment_shares (id TEXT PRIMARY KEY, split_id TEXT NOT NULL, booking_id TEXT NOT NULL, user_id TEXT NOT NULL, is_owner BOOLEAN NOT NULL DEFAULT false, amount REAL NOT NULL, status TEXT NOT NULL, payment_id TEXT, paid_at DATETIME, created_at DATETIME, updated_at DATETIME)`,
+		`CREATE TABLE lessons (id TEXT PRIMARY KEY, coach_id TEXT, student_id TEXT, scheduled_at DATETIME, price REAL, currency TEXT, status TEXT, payment_id TEXT, payment_due_at DATETIME, cancel_reason TEXT, version INTEGER DEFAULT 1, updated_at DATETIME, deleted_at DATETIME)`,
+	} {
+		require.NoError(t, db.Exec(stmt).Error)
+	}
+
+	require.NoError(t, db.Exec(`INSERT INTO courts (id, name, currency) VALUES ('court-1', '大安森林公園網球場', 'TWD')`).Error)
+	return db
+}
+
+func TestPaymentUsecase_BookingFlow(t *testing.T) {
+	db := setupPaymentUsecaseTestDB(t)
+	service := services.NewPaymentService(db, services.NewLocalPaymentProvider("secret"), nil, 15*time.Minute)
+	uc := NewPaymentUsecase(db, service)
+	ctx := context.Background()
+
+	dueAt := time.Now().Add(10 * time.Minute)
+	require.NoError(t, db.Exec(`INSERT INTO bookings (id, court_id, user_id, total_price, status, payment_due_at) VALUES ('booking-1', 'court-1', ?, 800, 'pending', ?)`, paymentUserID, dueAt).Error)
+	req := &dto.CreatePaymentRequest{TargetType: services.PaymentTargetBooking, TargetID: "booking-1"}
+
+	payment, err := uc.CreatePayment(ctx, paymentUserID, req)
+	require.NoError(t, err)
+	assert.Equal(t, services.PaymentStatusPending, payment.Status)
+	assert.InDelta(t, 800, payment.Amount, 0.001)
+	assert.NotEmpty(t, payment.ClientSecret)
+	require.NotNil(t, payment.ExpiresAt)
+
+	// 重複發起返回同一筆付款
+	again, err := uc.CreatePayment(ctx, paymentUserID, req)
+	require.NoError(t, err)
+	assert.Equal(t, payment.ID, again.ID)
+
+	// 其他用戶無法為此預訂付款或查看付款
+	_, err = uc.CreatePayment(ctx, "someone-else", req)
+	assert.True(t, apperror.HasCode(err, apperror.CodePaymentTargetNotFound))
+	_, err = uc.GetPayment(ctx, "someone-else", payment.ID)
+	assert.True(t, apperror.HasCode(err, apperror.CodePaymentNotFound))
+
+	captured, err := uc.CapturePayment(ctx, paymentUserID, payment.ID)
+	require.NoError(t, err)
+	assert.Equal(t, services.PaymentStatusCaptured, captured.Status)
+
+	var booking models.Booking
+	require.NoError(t, db.First(&booking, "id = ?", "booking-1").Error)
+	assert.Equal(t, "confirmed", booking.Status)
+
+	_, err = uc.CreatePayment(ctx, paymentUserID, req)
+	assert.True(t, apperror.HasCode(err, apperror.CodePaymentAlreadyPaid))
+
+	_, err = uc.CancelPayment(ctx, paymentUserID, payment.ID)
+	assert.True(t, apperror.HasCode(err, apperror.CodePaymentInvalidState))
+}
+
+func TestPaymentUsecase_RescheduleVoidsPayment(t *testing.T) {
+	db := setupBookingTestDB(t)
+	service := services.NewPaymentService(db, services.NewLocalPaymentProvider("secret"), nil, 15*time.Minute)
+	bookings := NewBookingUsecase(db, nil)
+	bookings.UsePaymentHold(service.HoldDuration)
+	bookings.UseCancellations(services.NewCancellationService(db, service))
+	uc := NewPaymentUsecase(db, service)
+	ctx := context.Background()
+	start := bookingTestStart()
+
+	booking, err := bookings.CreateBooking(bookingTestUserID, &dto.CreateBookingRequest{CourtID: "66666666-6666-6666-6666-666666666666", StartTime: start, EndTime: start.Add(time.Hour)})
+	require.NoError(t, err)
+	req := &dto.CreatePaymentRequest{TargetType: services.PaymentTargetBooking, TargetID: booking.ID}
+	stale, err := uc.CreatePayment(ctx, bookingTestUserID, req)
+	require.NoError(t, err)
+	assert.InDelta(t, 400, stale.Amount, 0.001)
+
+	// 延長時段後金額變更，原金額的付款作廢，不能再扣款
+	newEnd := start.Add(2 * time.Hour)
+	booking, err = bookings.UpdateBooking(booking.ID, bookingTestUserID, &dto.UpdateBookingRequest{EndTime: &newEnd}, nil)
+	require.NoError(t, err)
+	assert.InDelta(t, 800, booking.TotalPrice, 0.001)
+
+	_, err = uc.CapturePayment(ctx, bookingTestUserID, stale.ID)
+	assert.True(t, apperror.HasCode(err, apperror.CodePaymentInvalidState))
+
+	// 重新發起付款以新的金額扣款
+	payment, err := uc.CreatePayment(ctx, bookingTestUserID, req)
+	require.NoError(t, err)
+	assert.NotEqual(t, stale.ID, payment.ID)
+	assert.InDelta(t, 800, payment.Amount, 0.001)
+	_, err = uc.CapturePayment(ctx, bookingTestUserID, payment.ID)
+	require.NoError(t, err)
+
+	var stored models.Booking
+	require.NoError(t, db.First(&stored, "id = ?", booking.ID).Error)
+	assert.Equal(t, "confirmed", stored.Status)
+}
+
+func TestPaymentUsecase_LessonHoldExpired(t *testing.T) {
+	db := setupPaymentUsecaseTestDB(t)
+	service := services.NewPaymentService(db, services.NewLocalPaymentProvider("secret"), nil, 15*time.Minute)
+	uc := NewPaymentUsecase(db, service)
+	ctx := context.Background()
+
+	require.NoError(t, db.Exec(`INSERT INTO lessons (id, coach_id, student_id, price, currency, status, payment_due_at) VALUES ('lesson-1', 'coach-1', ?, 1200, 'TWD', 'scheduled', ?)`,
+		paymentUserID, time.Now().Add(-time.Minute)).Error)
+	require.NoError(t, db.Exec(`INSERT INTO lessons (id, coach_id, student_id, price, currency, status) VALUES ('lesson-free', 'coach-1', ?, 0, 'TWD', 'scheduled')`, paymentUserID).Error)
+
+	_, err := uc.CreatePayment(ctx, paymentUserID, &dto.CreatePaymentRequest{TargetType: services.PaymentTargetLesson, TargetID: "lesson-1"})
+	assert.True(t, apperror.HasCode(err, apperror.CodePaymentHoldExpired))
+
+	_, err = uc.CreatePayment(ctx, paymentUserID, &dto.CreatePaymentRequest{TargetType: services.PaymentTargetLesson, TargetID: "lesson-free"})
+	assert.True(t, apperror.HasCode(err, apperror.CodePaymentNotRequired))
+
+	_, err = uc.CreatePayment(ctx, paymentUserID, &dto.CreatePaymentRequest{TargetType: services.PaymentTargetLesson, TargetID: "lesson-missing"})
+	assert.True(t, apperror.HasCode(err, apperror.CodePaymentTargetNotFound))
+}
@@ -0,0 +1,296 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"tennis-platform/backend/internal/apperror"
+	"tennis-platform/backend/internal/dto"
+	"tennis-platform/backend/internal/models"
+	"tennis-platform/backend/internal/services"
+
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
+
+// PromoCodeUsecase 優惠碼用例，場地擁有者及教練管理自己的優惠碼
+type PromoCodeUsecase struct {
+	db *gorm.DB
+}
+
+// NewPromoCodeUsecase 創建新的優惠碼用例
+func NewPromoCodeUsecase(db *gorm.DB) *PromoCodeUsecase {
+	return &PromoCodeUsecase{db: db}
+}
+
+// CreatePromoCode 創建自己的場地或課程的優惠碼
+func (pu *PromoCodeUsecase) CreatePromoCode(ctx context.Context, userID string, req *dto.PromoCodeRequest) (*models.PromoCode, error) {
+	promo := models.PromoCode{CreatedBy: userID}
+	if err := pu.applyRequest(ctx, userID, &promo, req); err != nil {
+		return nil, err
+	}
+
+	if err := pu.db.WithContext(ctx).Create(&promo).Error; err != nil {
+		return nil, errors.New("創建優惠碼失敗")
+	}
+	return &promo, nil
+}
+
+// ListPromoCodes 列出自己創建的優惠碼
+func (pu *PromoCodeUsecase) ListPromoCodes(ctx context.Context, userID string, req *dto.PromoCodeListRequest) (*dto.PromoCodeListResponse, error) {
+	query := pu.db.WithContext(ctx).Model(&models.PromoCode{}).Where("created_by = ?", userID)
+	if req.CourtID != "" {
+		query = query.Where("court_id = ?", req.CourtID)
+	}
+	if req.CoachID != "" {
+		query = query.Where("coach_id = ?", req.CoachID)
+	}
+	if req.IsActive != nil {
+		query = query.Where("is_active = ?", *req.IsActive)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, errors.New("獲取優惠碼總數失敗")
+	}
+
+	page := req.Page
+	if page < 1 {
+		page = 1
+	}
+	pageSize := req.PageSize
+	if pageSize < 1 {
+		pageSize = 20
+	}
+
+	promoCodes := []models.PromoCode{}
+	if err := query.Order("created_at DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&promoCodes).Error; err != nil {
+		return nil, errors.New("獲取優惠碼列表失敗")
+	}
+
+	return &dto.PromoCodeListResponse{
+		PromoCodes: promoCodes,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: int((total + int64(pageSize) - 1) / int64(pageSize)),
+	}, nil
+}
+
+// GetPromoCode 獲取自己創建的優惠碼
+func (pu *PromoCodeUsecase) GetPromoCode(ctx context.Context, userID, promoCodeID string) (*models.PromoCode, error) {
+	var promo models.PromoCode
+	if err := pu.db.WithContext(ctx).Where("id = ?", promoCodeID).First(&promo).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.New(apperror.CodePromoCodeNotFound)
+		}
+		return nil, errors.New("獲取優惠碼失敗")
+	}
+	if promo.CreatedBy != userID {
+		return nil, apperror.New(apperror.CodePromoCodeForbidden)
+	}
+	return &promo, nil
+}
+
+// UpdatePromoCode 更新優惠碼，已套用的折扣不受影響
+func (pu *PromoCodeUsecase) UpdatePromoCode(ctx context.Context, userID, promoCodeID string, req *dto.PromoCodeRequest) (*models.PromoCode, error) {
+	promo, err := pu.GetPromoCode(ctx, userID, promoCodeID)
+	if err != nil {
+		return nil, err
+	}
+	if err := pu.applyRequest(ctx, userID, promo, req); err != nil {
+		return nil, err
+	}
+
+	// 使用次數由使用及退回時的條件更新維護，不隨請求覆寫
+	if err := pu.db.WithContext(ctx).Omit("redemption_count").Save(promo).Error; err != nil {
+		return nil, errors.New("更新優惠碼失敗")
+	}
+	return promo, nil
+}
+
+// ListRedemptions 列出優惠碼的使用記錄
+func (pu *PromoCodeUsecase) ListRedemptions(ctx context.Context, userID, promoCodeID string, req *dto.PromoRedemptionListRequest) (*dto.PromoRedemptionListResponse, error) {
+	promo, err := pu.GetPromoCode(ctx, userID, promoCodeID)
+	if err != nil {
+		return nil, err
+	}
+
+	query := pu.db.WithContext(ctx).Model(&models.PromoRedemption{}).Where("promo_code_id = ?", promo.ID)
+	if req.Status != "" {
+		query = query.Where("status = ?", req.Status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, errors.New("獲取使用記錄總數失敗")
+	}
+
+	page := req.Page
+	if page < 1 {
+		page = 1
+	}
+	pageSize := req.PageSize
+	if pageSize < 1 {
+		pageSize = 20
+	}
+
+	redemptions := []models.PromoRedemption{}
+	if err := query.Order("created_at DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&redemptions).Error; err != nil {
+		return nil, errors.New("獲取使用記錄失敗")
+	}
+
+	return &dto.PromoRedemptionListResponse{
+		Redemptions: redemptions,
+		Total:       total,
+		Page:        page,
+		PageSize:    pageSize,
+		TotalPages:  int((total + int64(pageSize) - 1) / int64(pageSize)),
+	}, nil
+}
+
+// applyRequest 驗證請求並寫入優惠碼
+func (pu *PromoCodeUsecase) applyRequest(ctx context.Context, userID string, promo *models.PromoCode, req *dto.PromoCodeRequest) error {
+	db := pu.db.WithContext(ctx)
+
+	value := *req.DiscountValue
+	if value <= 0 || (req.DiscountType == models.PromoDiscountPercent && value > 100) {
+		return apperror.New(apperror.CodePromoCodeInvalidDiscount)
+	}
+
+	if err := pu.checkScope(ctx, userID, req); err != nil {
+		return err
+	}
+
+	if req.StartsAt != nil && req.EndsAt != nil && !req.StartsAt.Before(*req.EndsAt) {
+		return apperror.New(apperror.CodePromoCodeInvalidTimeRange)
+	}
+	// 時段需同時提供開始及結束，不支援跨午夜的時段
+	if (req.StartTime == nil) != (req.EndTime == nil) {
+		return apperror.New(apperror.CodePromoCodeInvalidTimeRange)
+	}
+	if req.StartTime != nil {
+		startMinutes, err := services.ParseClockMinutes(*req.StartTime)
+		if err != nil {
+			return apperror.New(apperror.CodePromoCodeInvalidTimeRange)
+		}
+		endMinutes, err := services.ParseClockMinutes(*req.EndTime)
+		if err != nil || endMinutes <= startMinutes {
+			return apperror.New(apperror.CodePromoCodeInvalidTimeRange)
+		}
+	}
+
+	code := services.NormalizePromoCode(req.Code)
+	existingQuery := db.Model(&models.PromoCode{}).Where("code = ?", code)
+	if promo.ID != "" {
+		existingQuery = existingQuery.Where("id <> ?", promo.ID)
+	}
+	var existing int64
+	if err := existingQuery.Count(&existing).Error; err != nil {
+		return errors.New("檢查優惠碼失敗")
+	}
+	if existing > 0 {
+		return apperror.New(apperror.CodePromoCodeExists).With("code", code)
+	}
+
+	// 去除重複的星期並排序
+	seen := make(map[int]bool, len(req.DaysOfWeek))
+	days := pq.Int64Array{}
+	for _, day := range req.DaysOfWeek {
+		if !seen[day] {
+			seen[day] = true
+			days = append(days, int64(day))
+		}
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i] < days[j] })
+
+	maxDiscount := req.MaxDiscount
+	if req.DiscountType == models.PromoDiscountFixed {
+		maxDiscount = nil
+	}
+
+	promo.Code = code
+	promo.Description = req.Description
+	promo.CourtID = req.CourtID
+	promo.CoachID = req.CoachID
+	promo.LessonTypeID = req.LessonTypeID
+	promo.ClubID = req.ClubID
+	promo.DiscountType = req.DiscountType
+	promo.DiscountValue = value
+	promo.MaxDiscount = maxDiscount
+	promo.MinSpend = req.MinSpend
+	promo.StartsAt = req.StartsAt
+	promo.EndsAt = req.EndsAt
+	promo.DaysOfWeek = days
+	promo.StartTime = req.StartTime
+	promo.EndTime = req.EndTime
+	promo.MaxRedemptions = req.MaxRedemptions
+	promo.MaxRedemptionsPerUser = req.MaxRedemptionsPerUser
+	promo.FirstPurchaseOnly = req.FirstPurchaseOnly
+	promo.IsActive = req.IsActive == nil || *req.IsActive
+	return nil
+}
+
+// checkScope 確認優惠碼指定自己的場地或教練檔案，課程類型屬於該教練，俱樂部存在
+func (pu *PromoCodeUsecase) checkScope(ctx context.Context, userID string, req *dto.PromoCodeRequest) error {
+	db := pu.db.WithContext(ctx)
+
+	if (req.CourtID == nil) == (req.CoachID == nil) {
+		return apperror.New(apperror.CodePromoCodeInvalidScope)
+	}
+
+	if req.CourtID != nil {
+		if req.LessonTypeID != nil {
+			return apperror.New(apperror.CodePromoCodeInvalidScope)
+		}
+		var court models.Court
+		if err := db.Select("id", "owner_id").Where("id = ?", *req.CourtID).First(&court).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return apperror.New(apperror.CodeCourtNotFound)
+			}
+			return errors.New("獲取場地失敗")
+		}
+		if court.OwnerID == nil || *court.OwnerID != userID {
+			return apperror.New(apperror.CodePromoCodeInvalidScope)
+		}
+	} else {
+		var coach models.Coach
+		if err := db.Select("id", "user_id").Where("id = ?", *req.CoachID).First(&coach).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return apperror.New(apperror.CodePromoCodeInvalidScope)
+			}
+			return errors.New("獲取教練檔案失敗")
+		}
+		if coach.UserID != userID {
+			return apperror.New(apperror.CodePromoCodeInvalidScope)
+		}
+		if req.LessonTypeID != nil {
+			var count int64
+			if err := db.Model(&models.LessonType{}).
+				Where("id = ? AND coach_id = ?", *req.LessonTypeID, coach.ID).
+				Count(&count).Error; err != nil {
+				return errors.New("獲取課程類型失敗")
+			}
+			if count == 0 {
+				return apperror.New(apperror.CodePromoCodeInvalidScope)
+			}
+		}
+	}
+
+	if req.ClubID != nil {
+		var count int64
+		if err := db.Model(&models.Club{}).Where("id = ?", *req.ClubID).Count(&count).Error; err != nil {
+			return errors.New("獲取俱樂部失敗")
+		}
+		if count == 0 {
+			return apperror.New(apperror.CodePromoCodeInvalidScope)
+		}
+	}
+	return nil
+}
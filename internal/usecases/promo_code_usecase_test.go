@@ -0,0 +1,188 @@
+package usecases
+
+import (
+	"context"
+	"tennis-platform/backend/internal/apperror"
+	"tennis-platform/backend/internal/dto"
+	"tennis-platform/backend/internal/models"
+	"tennis-platform/backend/internal/services"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPromoCodeUsecase_ApplyToBookings(t *testing.T) {
+	db := setupBookingTestDB(t)
+	bus := services.NewEventBus(db)
+	services.RegisterPromoSubscribers(bus, services.NewPromoService(db))
+	bookings := NewBookingUsecase(db, bus)
+	promoCodes := NewPromoCodeUsecase(db)
+	ctx := context.Background()
+	courtID := "66666666-6666-6666-6666-666666666666"
+	otherUserID := "77777777-7777-7777-7777-777777777777"
+	thirdUserID := "88888888-8888-8888-8888-888888888888"
+	start := bookingTestStart()
+
+	// 只有場地擁有者可以為場地創建優惠碼，代碼不分大小寫且不能重複
+	value, maxDiscount, limit, perUser := 25.0, 150.0, 2, 1
+	req := &dto.PromoCodeRequest{
+		Code:                  "spring25",
+		CourtID:               &courtID,
+		DiscountType:          models.PromoDiscountPercent,
+		DiscountValue:         &value,
+		MaxDiscount:           &maxDiscount,
+		MaxRedemptions:        &limit,
+		MaxRedemptionsPerUser: &perUser,
+	}
+	_, err := promoCodes.CreatePromoCode(ctx, bookingTestUserID, req)
+	assert.True(t, apperror.HasCode(err, apperror.CodePromoCodeInvalidScope))
+	promo, err := promoCodes.CreatePromoCode(ctx, bookingTestOwnerID, req)
+	require.NoError(t, err)
+	assert.Equal(t, "SPRING25", promo.Code)
+	assert.True(t, promo.IsActive)
+	_, err = promoCodes.CreatePromoCode(ctx, bookingTestOwnerID, req)
+	assert.True(t, apperror.HasCode(err, apperror.CodePromoCodeExists))
+
+	book := func(userID string, offset time.Duration, hours int, code string) (*models.Booking, error) {
+		return bookings.CreateBooking(userID, &dto.CreateBookingRequest{
+			CourtID:   courtID,
+			StartTime: start.Add(offset),
+			EndTime:   start.Add(offset + time.Duration(hours)*time.Hour),
+			PromoCode: &code,
+		})
+	}
+
+	// 800 元的 25% 為 200 元，超過上限時折抵 150 元
+	first, err := book(bookingTestUserID, 0, 2, "Spring25")
+	require.NoError(t, err)
+	assert.InDelta(t, 650, first.TotalPrice, 0.001)
+	assert.InDelta(t, 800, first.PriceBreakdown.Subtotal, 0.001)
+	require.NotNil(t, first.PriceBreakdown.Discount)
+	assert.Equal(t, promo.ID, first.PriceBreakdown.Discount.PromoCodeID)
+	assert.InDelta(t, 150, first.PriceBreakdown.Discount.Amount, 0.001)
+
+	_, err = book(bookingTestUserID, 3*time.Hour, 1, "SPRING25")
+	assert.True(t, apperror.HasCode(err, apperror.CodePromoCodeUserLimit))
+
+	second, err := book(otherUserID, 3*time.Hour, 1, "SPRING25")
+	require.NoError(t, err)
+	assert.InDelta(t, 300, second.TotalPrice, 0.001)
+
+	// 改期後按新的價格重新計算折扣
+	newEnd := second.EndTime.Add(time.Hour)
+	second, err = bookings.UpdateBooking(second.ID, otherUserID, &dto.UpdateBookingRequest{EndTime: &newEnd}, nil)
+	require.NoError(t, err)
+	assert.InDelta(t, 650, second.TotalPrice, 0.001)
+	require.NotNil(t, second.PriceBreakdown.Discount)
+	assert.InDelta(t, 150, second.PriceBreakdown.Discount.Amount, 0.001)
+
+	// 總使用次數用完後其他用戶無法使用，失敗的預訂不會建立
+	_, err = book(thirdUserID, 5*time.Hour, 1, "SPRING25")
+	assert.True(t, apperror.HasCode(err, apperror.CodePromoCodeExhausted))
+	var count int64
+	require.NoError(t, db.Model(&models.Booking{}).Where("user_id = ?", thirdUserID).Count(&count).Error)
+	assert.Equal(t, int64(0), count)
+
+	// 取消預訂後由事件訂閱者退回使用次數，重複處理不會重複退回
+	_, err = bookings.CancelBooking(first.ID, bookingTestUserID, &dto.CancelBookingRequest{})
+	require.NoError(t, err)
+	_, err = bus.DispatchPending(ctx)
+	require.NoError(t, err)
+	promo, err = promoCodes.GetPromoCode(ctx, bookingTestOwnerID, promo.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, promo.RedemptionCount)
+
+	require.NoError(t, services.NewPromoService(db).Reverse(ctx, models.PromoTargetBooking, first.ID))
+	promo, err = promoCodes.GetPromoCode(ctx, bookingTestOwnerID, promo.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, promo.RedemptionCount)
+	redemptions, err := promoCodes.ListRedemptions(ctx, bookingTestOwnerID, promo.ID, &dto.PromoRedemptionListRequest{Status: models.PromoRedemptionReversed})
+	require.NoError(t, err)
+	require.Len(t, redemptions.Redemptions, 1)
+	assert.Equal(t, first.ID, redemptions.Redemptions[0].TargetID)
+	assert.NotNil(t, redemptions.Redemptions[0].ReversedAt)
+
+	_, err = book(thirdUserID, 5*time.Hour, 1, "SPRING25")
+	require.NoError(t, err)
+
+	_, err = promoCodes.GetPromoCode(ctx, bookingTestUserID, promo.ID)
+	assert.True(t, apperror.HasCode(err, apperror.CodePromoCodeForbidden))
+}
+
+func TestPromoCodeUsecase_Restrictions(t *testing.T) {
+	db := setupBookingTestDB(t)
+	bookings := NewBookingUsecase(db, nil)
+	promoCodes := NewPromoCodeUsecase(db)
+	ctx := context.Background()
+	courtID := "66666666-6666-6666-6666-666666666666"
+	start := bookingTestStart()
+
+	invalid := 120.0
+	_, err := promoCodes.CreatePromoCode(ctx, bookingTestOwnerID, &dto.PromoCodeRequest{
+		Code: "TOOMUCH", CourtID: &courtID, DiscountType: models.PromoDiscountPercent, DiscountValue: &invalid,
+	})
+	assert.True(t, apperror.HasCode(err, apperror.CodePromoCodeInvalidDiscount))
+
+	// 平日早上折抵 100 元，最低消費 500 元
+	fixed := 100.0
+	weekday := int(start.Weekday())
+	morning, err := promoCodes.CreatePromoCode(ctx, bookingTestOwnerID, &dto.PromoCodeRequest{
+		Code: "MORNING100", CourtID: &courtID, DiscountType: models.PromoDiscountFixed, DiscountValue: &fixed,
+		MinSpend: 500, DaysOfWeek: []int{weekday}, StartTime: stringPtr("08:00"), EndTime: stringPtr("12:00"),
+	})
+	require.NoError(t, err)
+
+	book := func(offset time.Duration, hours int, code string) (*models.Booking, error) {
+		return bookings.CreateBooking(bookingTestUserID, &dto.CreateBookingRequest{
+			CourtID:   courtID,
+			StartTime: start.Add(offset),
+			EndTime:   start.Add(offset + time.Duration(hours)*time.Hour),
+			PromoCode: &code,
+		})
+	}
+
+	_, err = book(0, 1, "NOPE")
+	assert.True(t, apperror.HasCode(err, apperror.CodePromoCodeInvalid))
+	_, err = book(0, 1, "MORNING100")
+	assert.True(t, apperror.HasCode(err, apperror.CodePromoCodeMinSpend))
+	_, err = book(4*time.Hour, 2, "MORNING100")
+	assert.True(t, apperror.HasCode(err, apperror.CodePromoCodeNotApplicable))
+	booking, err := book(0, 2, "MORNING100")
+	require.NoError(t, err)
+	assert.InDelta(t, 700, booking.TotalPrice, 0.001)
+
+	// 已過期及停用的優惠碼無法使用
+	ended := time.Now().Add(-time.Hour)
+	began := ended.Add(-24 * time.Hour)
+	inactive := false
+	_, err = promoCodes.UpdatePromoCode(ctx, bookingTestOwnerID, morning.ID, &dto.PromoCodeRequest{
+		Code: "MORNING100", CourtID: &courtID, DiscountType: models.PromoDiscountFixed, DiscountValue: &fixed,
+		StartsAt: &began, EndsAt: &ended,
+	})
+	require.NoError(t, err)
+	_, err = book(4*time.Hour, 2, "MORNING100")
+	assert.True(t, apperror.HasCode(err, apperror.CodePromoCodeExpired))
+
+	updated, err := promoCodes.UpdatePromoCode(ctx, bookingTestOwnerID, morning.ID, &dto.PromoCodeRequest{
+		Code: "MORNING100", CourtID: &courtID, DiscountType: models.PromoDiscountFixed, DiscountValue: &fixed, IsActive: &inactive,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, updated.RedemptionCount)
+	_, err = book(4*time.Hour, 2, "MORNING100")
+	assert.True(t, apperror.HasCode(err, apperror.CodePromoCodeInvalid))
+
+	// 首次預訂優惠只限從未預訂過此場地的用戶
+	first := 50.0
+	_, err = promoCodes.CreatePromoCode(ctx, bookingTestOwnerID, &dto.PromoCodeRequest{
+		Code: "WELCOME", CourtID: &courtID, DiscountType: models.PromoDiscountPercent, DiscountValue: &first, FirstPurchaseOnly: true,
+	})
+	require.NoError(t, err)
+	_, err = book(4*time.Hour, 1, "WELCOME")
+	assert.True(t, apperror.HasCode(err, apperror.CodePromoCodeFirstPurchaseOnly))
+
+	list, err := promoCodes.ListPromoCodes(ctx, bookingTestOwnerID, &dto.PromoCodeListRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), list.Total)
+}
@@ -3,6 +3,7 @@ package usecases
 import (
 	"errors"
 	"fmt"
+	"tennis-platform/backend/internal/apperror"
 	"tennis-platform/backend/internal/dto"
 	"tennis-platform/backend/internal/models"
 	"time"
@@ -29,7 +30,7 @@ func (u *RacketPriceUsecase) CreateRacketPrice(req *dto.CreateRacketPriceRequest
 	err := u.db.Where("id = ? AND deleted_at IS NULL", req.RacketID).First(&racket).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("racket not found")
+			return nil, apperror.New(apperror.CodeRacketNotFound)
 		}
 		return nil, fmt.Errorf("failed to check racket: %w", err)
 	}
@@ -38,7 +39,7 @@ func (u *RacketPriceUsecase) CreateRacketPrice(req *dto.CreateRacketPriceRequest
 	var existingPrice models.RacketPrice
 	err = u.db.Where("racket_id = ? AND retailer = ? AND deleted_at IS NULL", req.RacketID, req.Retailer).First(&existingPrice).Error
 	if err == nil {
-		return nil, errors.New("price for this retailer already exists")
+		return nil, apperror.New(apperror.CodeRacketPriceDuplicate)
 	}
 	if !errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, fmt.Errorf("failed to check existing price: %w", err)
@@ -73,7 +74,7 @@ func (u *RacketPriceUsecase) UpdateRacketPrice(priceID string, req *dto.UpdateRa
 	err := u.db.Where("id = ? AND deleted_at IS NULL", priceID).First(&price).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("price not found")
+			return nil, apperror.New(apperror.CodeRacketPriceNotFound)
 		}
 		return nil, fmt.Errorf("failed to find price: %w", err)
 	}
@@ -83,7 +84,7 @@ func (u *RacketPriceUsecase) UpdateRacketPrice(priceID string, req *dto.UpdateRa
 		var existingPrice models.RacketPrice
 		err := u.db.Where("racket_id = ? AND retailer = ? AND id != ? AND deleted_at IS NULL", price.RacketID, *req.Retailer, priceID).First(&existingPrice).Error
 		if err == nil {
-			return nil, errors.New("price for this retailer already exists")
+			return nil, apperror.New(apperror.CodeRacketPriceDuplicate)
 		}
 		if !errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, fmt.Errorf("failed to check existing price: %w", err)
@@ -127,7 +128,7 @@ func (u *RacketPriceUsecase) DeleteRacketPrice(priceID string) error {
 	err := u.db.Where("id = ? AND deleted_at IS NULL", priceID).First(&price).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return errors.New("price not found")
+			return apperror.New(apperror.CodeRacketPriceNotFound)
 		}
 		return fmt.Errorf("failed to find price: %w", err)
 	}
@@ -176,7 +177,7 @@ func (u *RacketPriceUsecase) UpdatePriceAvailability(priceID string, isAvailable
 	err := u.db.Where("id = ? AND deleted_at IS NULL", priceID).First(&price).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return errors.New("price not found")
+			return apperror.New(apperror.CodeRacketPriceNotFound)
 		}
 		return fmt.Errorf("failed to find price: %w", err)
 	}
@@ -3,6 +3,7 @@ package usecases
 import (
 	"errors"
 	"fmt"
+	"tennis-platform/backend/internal/apperror"
 	"tennis-platform/backend/internal/dto"
 	"tennis-platform/backend/internal/models"
 
@@ -28,7 +29,7 @@ func (u *RacketReviewUsecase) CreateRacketReview(userID string, req *dto.CreateR
 	err := u.db.Where("id = ? AND deleted_at IS NULL", req.RacketID).First(&racket).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("racket not found")
+			return nil, apperror.New(apperror.CodeRacketNotFound)
 		}
 		return nil, fmt.Errorf("failed to check racket: %w", err)
 	}
@@ -37,7 +38,7 @@ func (u *RacketReviewUsecase) CreateRacketReview(userID string, req *dto.CreateR
 	var existingReview models.RacketReview
 	err = u.db.Where("racket_id = ? AND user_id = ? AND deleted_at IS NULL", req.RacketID, userID).First(&existingReview).Error
 	if err == nil {
-		return nil, errors.New("user has already reviewed this racket")
+		return nil, apperror.New(apperror.CodeRacketReviewDuplicate)
 	}
 	if !errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, fmt.Errorf("failed to check existing review: %w", err)
@@ -73,7 +74,7 @@ func (u *RacketReviewUsecase) GetRacketReview(reviewID string) (*models.RacketRe
 	err := u.db.Preload("User").Preload("Racket").Where("id = ? AND deleted_at IS NULL", reviewID).First(&review).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("review not found")
+			return nil, apperror.New(apperror.CodeRacketReviewNotFound)
 		}
 		return nil, fmt.Errorf("failed to get review: %w", err)
 	}
@@ -87,7 +88,7 @@ func (u *RacketReviewUsecase) UpdateRacketReview(reviewID, userID string, req *d
 	err := u.db.Where("id = ? AND user_id = ? AND deleted_at IS NULL", reviewID, userID).First(&review).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("review not found or not owned by user")
+			return nil, apperror.New(apperror.CodeRacketReviewNotOwned)
 		}
 		return nil, fmt.Errorf("failed to find review: %w", err)
 	}
@@ -134,7 +135,7 @@ func (u *RacketReviewUsecase) DeleteRacketReview(reviewID, userID string) error
 	err := u.db.Where("id = ? AND user_id = ? AND deleted_at IS NULL", reviewID, userID).First(&review).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return errors.New("review not found or not owned by user")
+			return apperror.New(apperror.CodeRacketReviewNotOwned)
 		}
 		return fmt.Errorf("failed to find review: %w", err)
 	}
@@ -237,14 +238,14 @@ func (u *RacketReviewUsecase) MarkReviewHelpful(reviewID, userID string, helpful
 	err := u.db.Where("id = ? AND deleted_at IS NULL", reviewID).First(&review).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return errors.New("review not found")
+			return apperror.New(apperror.CodeRacketReviewNotFound)
 		}
 		return fmt.Errorf("failed to find review: %w", err)
 	}
 
 	// 檢查用戶是否是評價的作者（不能標記自己的評價）
 	if review.UserID == userID {
-		return errors.New("cannot mark own review as helpful")
+		return apperror.New(apperror.CodeRacketReviewOwnHelpful)
 	}
 
 	// 更新有用計數
@@ -267,7 +268,7 @@ func (u *RacketReviewUsecase) GetRacketReviewStatistics(racketID string) (*dto.R
 	err := u.db.Where("id = ? AND deleted_at IS NULL", racketID).First(&racket).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("racket not found")
+			return nil, apperror.New(apperror.CodeRacketNotFound)
 		}
 		return nil, fmt.Errorf("failed to check racket: %w", err)
 	}
@@ -3,8 +3,10 @@ package usecases
 import (
 	"errors"
 	"fmt"
+	"tennis-platform/backend/internal/apperror"
 	"tennis-platform/backend/internal/dto"
 	"tennis-platform/backend/internal/models"
+	"tennis-platform/backend/internal/pagination"
 
 	"gorm.io/gorm"
 )
@@ -27,7 +29,7 @@ func (u *RacketUsecase) CreateRacket(req *dto.CreateRacketRequest) (*models.Rack
 	var existingRacket models.Racket
 	err := u.db.Where("brand = ? AND model = ? AND deleted_at IS NULL", req.Brand, req.Model).First(&existingRacket).Error
 	if err == nil {
-		return nil, errors.New("racket with same brand and model already exists")
+		return nil, apperror.New(apperror.CodeRacketDuplicate)
 	}
 	if !errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, fmt.Errorf("failed to check existing racket: %w", err)
@@ -80,7 +82,7 @@ func (u *RacketUsecase) GetRacketByID(racketID string) (*models.Racket, error) {
 	err := u.db.Preload("Reviews").Preload("Prices").Where("id = ? AND deleted_at IS NULL", racketID).First(&racket).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("racket not found")
+			return nil, apperror.New(apperror.CodeRacketNotFound)
 		}
 		return nil, fmt.Errorf("failed to get racket: %w", err)
 	}
@@ -89,16 +91,21 @@ func (u *RacketUsecase) GetRacketByID(racketID string) (*models.Racket, error) {
 }
 
 // UpdateRacket 更新球拍
-func (u *RacketUsecase) UpdateRacket(racketID string, req *dto.UpdateRacketRequest) (*models.Racket, error) {
+// expectedVersion 不為 nil 時需與目前版本一致，否則返回版本衝突錯誤
+func (u *RacketUsecase) UpdateRacket(racketID string, req *dto.UpdateRacketRequest, expectedVersion *int64) (*models.Racket, error) {
 	var racket models.Racket
 	err := u.db.Where("id = ? AND deleted_at IS NULL", racketID).First(&racket).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("racket not found")
+			return nil, apperror.New(apperror.CodeRacketNotFound)
 		}
 		return nil, fmt.Errorf("failed to find racket: %w", err)
 	}
 
+	if expectedVersion != nil && *expectedVersion != racket.Version {
+		return nil, apperror.New(apperror.CodePreconditionFailed)
+	}
+
 	// 檢查品牌和型號是否與其他球拍衝突
 	if req.Brand != nil || req.Model != nil {
 		brand := racket.Brand
@@ -113,7 +120,7 @@ func (u *RacketUsecase) UpdateRacket(racketID string, req *dto.UpdateRacketReque
 		var existingRacket models.Racket
 		err := u.db.Where("brand = ? AND model = ? AND id != ? AND deleted_at IS NULL", brand, model, racketID).First(&existingRacket).Error
 		if err == nil {
-			return nil, errors.New("racket with same brand and model already exists")
+			return nil, apperror.New(apperror.CodeRacketDuplicate)
 		}
 		if !errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, fmt.Errorf("failed to check existing racket: %w", err)
@@ -183,8 +190,14 @@ func (u *RacketUsecase) UpdateRacket(racketID string, req *dto.UpdateRacketReque
 		updates["is_active"] = *req.IsActive
 	}
 
-	if err := u.db.Model(&racket).Updates(updates).Error; err != nil {
-		return nil, fmt.Errorf("failed to update racket: %w", err)
+	// 以讀取時的版本號作為條件，避免覆蓋並發的修改
+	updates["version"] = gorm.Expr("version + 1")
+	result := u.db.Model(&racket).Where("version = ?", racket.Version).Updates(updates)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to update racket: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, apperror.New(apperror.CodePreconditionFailed)
 	}
 
 	// 重新載入更新後的球拍
@@ -201,7 +214,7 @@ func (u *RacketUsecase) DeleteRacket(racketID string) error {
 	err := u.db.Where("id = ? AND deleted_at IS NULL", racketID).First(&racket).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return errors.New("racket not found")
+			return apperror.New(apperror.CodeRacketNotFound)
 		}
 		return fmt.Errorf("failed to find racket: %w", err)
 	}
@@ -215,16 +228,6 @@ func (u *RacketUsecase) DeleteRacket(racketID string) error {
 
 // SearchRackets 搜尋球拍
 func (u *RacketUsecase) SearchRackets(req *dto.RacketSearchRequest) (*dto.RacketSearchResponse, error) {
-	// 設置默認值
-	page := req.Page
-	if page <= 0 {
-		page = 1
-	}
-	pageSize := req.PageSize
-	if pageSize <= 0 {
-		pageSize = 20
-	}
-
 	query := u.db.Model(&models.Racket{}).Where("deleted_at IS NULL AND is_active = true")
 
 	// 應用篩選條件
@@ -284,10 +287,23 @@ func (u *RacketUsecase) SearchRackets(req *dto.RacketSearchRequest) (*dto.Racket
 		query = query.Where("id IN (?)", priceQuery)
 	}
 
-	// 計算總數
-	var total int64
-	if err := query.Count(&total).Error; err != nil {
-		return nil, fmt.Errorf("failed to count rackets: %w", err)
+	page := pagination.Query{
+		Limit: req.PageSize,
+		Page:  req.Page,
+	}
+	if req.Cursor != nil {
+		page.Cursor = *req.Cursor
+	}
+	page.Normalize(20, 100)
+
+	// 頁碼分頁時計算總數
+	var total *int64
+	if !page.IsCursor() {
+		var count int64
+		if err := query.Count(&count).Error; err != nil {
+			return nil, fmt.Errorf("failed to count rackets: %w", err)
+		}
+		total = &count
 	}
 
 	// 應用排序
@@ -299,46 +315,74 @@ func (u *RacketUsecase) SearchRackets(req *dto.RacketSearchRequest) (*dto.Racket
 	if req.SortOrder != nil {
 		sortOrder = *req.SortOrder
 	}
+	page.Desc = sortOrder == "desc"
 
+	idKey := pagination.Key{Column: "rackets.id", Kind: pagination.KindString}
 	switch sortBy {
-	case "brand":
-		query = query.Order("brand " + sortOrder + ", model " + sortOrder)
 	case "model":
-		query = query.Order("model " + sortOrder)
+		page.Keys = []pagination.Key{{Column: "rackets.model", Kind: pagination.KindString}, idKey}
 	case "price":
-		// 按最低價格排序
+		// 按最低價格排序（聚合排序僅支援頁碼分頁）
+		if page.IsCursor() {
+			return nil, pagination.ErrInvalidCursor
+		}
 		query = query.Joins("LEFT JOIN racket_prices ON rackets.id = racket_prices.racket_id AND racket_prices.deleted_at IS NULL AND racket_prices.is_available = true").
 			Group("rackets.id").
 			Order("MIN(racket_prices.price) " + sortOrder)
 	case "rating":
-		query = query.Order("average_rating " + sortOrder)
+		page.Keys = []pagination.Key{{Column: "rackets.average_rating", Kind: pagination.KindNumber}, idKey}
 	case "popularity":
-		query = query.Order("total_reviews " + sortOrder)
+		page.Keys = []pagination.Key{{Column: "rackets.total_reviews", Kind: pagination.KindNumber}, idKey}
 	default:
-		query = query.Order("brand " + sortOrder + ", model " + sortOrder)
+		page.Keys = []pagination.Key{
+			{Column: "rackets.brand", Kind: pagination.KindString},
+			{Column: "rackets.model", Kind: pagination.KindString},
+			idKey,
+		}
 	}
 
 	// 應用分頁
-	offset := (page - 1) * pageSize
-	query = query.Offset(offset).Limit(pageSize)
+	pagedQuery, err := page.Apply(query)
+	if err != nil {
+		return nil, err
+	}
 
 	// 預載入相關數據
-	query = query.Preload("Prices", "deleted_at IS NULL AND is_available = true")
+	pagedQuery = pagedQuery.Preload("Prices", "deleted_at IS NULL AND is_available = true")
 
 	var rackets []models.Racket
-	if err := query.Find(&rackets).Error; err != nil {
+	if err := pagedQuery.Find(&rackets).Error; err != nil {
 		return nil, fmt.Errorf("failed to search rackets: %w", err)
 	}
 
-	totalPages := int((total + int64(pageSize) - 1) / int64(pageSize))
+	rackets, pageInfo, err := pagination.Build(rackets, &page, total, func(r *models.Racket) []interface{} {
+		switch sortBy {
+		case "model":
+			return []interface{}{r.Model, r.ID}
+		case "rating":
+			return []interface{}{r.AverageRating, r.ID}
+		case "popularity":
+			return []interface{}{r.TotalReviews, r.ID}
+		default:
+			return []interface{}{r.Brand, r.Model, r.ID}
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to build racket cursor: %w", err)
+	}
 
-	return &dto.RacketSearchResponse{
+	response := &dto.RacketSearchResponse{
 		Rackets:    rackets,
-		Total:      total,
-		Page:       page,
-		PageSize:   pageSize,
-		TotalPages: totalPages,
-	}, nil
+		Pagination: pageInfo,
+	}
+	if total != nil {
+		response.Total = *total
+		response.Page = page.Page
+		response.PageSize = page.Limit
+		response.TotalPages = pageInfo.TotalPages
+	}
+
+	return response, nil
 }
 
 // GetAvailableBrands 獲取可用品牌列表
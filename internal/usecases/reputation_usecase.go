@@ -1,7 +1,9 @@
 package usecases
 
 import (
+	"errors"
 	"fmt"
+	"tennis-platform/backend/internal/apperror"
 	"tennis-platform/backend/internal/models"
 	"tennis-platform/backend/internal/services"
 	"time"
@@ -39,7 +41,10 @@ func (ruc *ReputationUseCase) RecordMatchAttendance(userID, matchID, status stri
 	var match models.Match
 	err := ruc.db.Preload("Participants").Where("id = ?", matchID).First(&match).Error
 	if err != nil {
-		return fmt.Errorf("match not found: %w", err)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apperror.New(apperror.CodeMatchNotFound)
+		}
+		return fmt.Errorf("failed to get match: %w", err)
 	}
 
 	// 檢查用戶是否是比賽參與者
@@ -52,7 +57,7 @@ func (ruc *ReputationUseCase) RecordMatchAttendance(userID, matchID, status stri
 	}
 
 	if !isParticipant {
-		return fmt.Errorf("user is not a participant of this match")
+		return apperror.New(apperror.CodeMatchNotParticipant)
 	}
 
 	// 更新出席率
@@ -65,11 +70,14 @@ func (ruc *ReputationUseCase) RecordMatchPunctuality(userID, matchID string, arr
 	var match models.Match
 	err := ruc.db.Where("id = ?", matchID).First(&match).Error
 	if err != nil {
-		return fmt.Errorf("match not found: %w", err)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apperror.New(apperror.CodeMatchNotFound)
+		}
+		return fmt.Errorf("failed to get match: %w", err)
 	}
 
 	if match.ScheduledAt == nil {
-		return fmt.Errorf("match has no scheduled time")
+		return apperror.New(apperror.CodeReputationMatchNotScheduled)
 	}
 
 	// 計算是否準時和遲到時間
@@ -89,14 +97,17 @@ func (ruc *ReputationUseCase) RecordMatchPunctuality(userID, matchID string, arr
 func (ruc *ReputationUseCase) RecordSkillLevelAccuracy(userID, matchID string, reportedLevel, observedLevel float64) error {
 	// 驗證等級範圍
 	if reportedLevel < 1.0 || reportedLevel > 7.0 || observedLevel < 1.0 || observedLevel > 7.0 {
-		return fmt.Errorf("NTRP level must be between 1.0 and 7.0")
+		return apperror.New(apperror.CodeUserInvalidNTRPLevel)
 	}
 
 	// 驗證比賽存在
 	var match models.Match
 	err := ruc.db.Where("id = ?", matchID).First(&match).Error
 	if err != nil {
-		return fmt.Errorf("match not found: %w", err)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apperror.New(apperror.CodeMatchNotFound)
+		}
+		return fmt.Errorf("failed to get match: %w", err)
 	}
 
 	// 更新技術等級準確度
@@ -107,12 +118,12 @@ func (ruc *ReputationUseCase) RecordSkillLevelAccuracy(userID, matchID string, r
 func (ruc *ReputationUseCase) SubmitBehaviorReview(reviewerID, userID, matchID string, rating float64, comment *string, tags []string) error {
 	// 驗證評分範圍
 	if rating < 1.0 || rating > 5.0 {
-		return fmt.Errorf("rating must be between 1.0 and 5.0")
+		return apperror.New(apperror.CodeReputationInvalidRating)
 	}
 
 	// 驗證評價者和被評價者不是同一人
 	if reviewerID == userID {
-		return fmt.Errorf("cannot review yourself")
+		return apperror.New(apperror.CodeReputationSelfReview)
 	}
 
 	// 驗證比賽存在且雙方都是參與者
@@ -120,7 +131,10 @@ func (ruc *ReputationUseCase) SubmitBehaviorReview(reviewerID, userID, matchID s
 		var match models.Match
 		err := ruc.db.Preload("Participants").Where("id = ?", matchID).First(&match).Error
 		if err != nil {
-			return fmt.Errorf("match not found: %w", err)
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return apperror.New(apperror.CodeMatchNotFound)
+			}
+			return fmt.Errorf("failed to get match: %w", err)
 		}
 
 		reviewerIsParticipant := false
@@ -136,7 +150,7 @@ func (ruc *ReputationUseCase) SubmitBehaviorReview(reviewerID, userID, matchID s
 		}
 
 		if !reviewerIsParticipant || !userIsParticipant {
-			return fmt.Errorf("both users must be participants of the match")
+			return apperror.New(apperror.CodeReputationNotParticipants)
 		}
 	}
 
@@ -144,7 +158,7 @@ func (ruc *ReputationUseCase) SubmitBehaviorReview(reviewerID, userID, matchID s
 	var existingReview models.BehaviorReview
 	err := ruc.db.Where("reviewer_id = ? AND user_id = ? AND match_id = ?", reviewerID, userID, matchID).First(&existingReview).Error
 	if err == nil {
-		return fmt.Errorf("you have already reviewed this user for this match")
+		return apperror.New(apperror.CodeReputationDuplicateReview)
 	} else if err != gorm.ErrRecordNotFound {
 		return fmt.Errorf("failed to check existing review: %w", err)
 	}
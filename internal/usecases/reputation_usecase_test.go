@@ -0,0 +1,33 @@
+package usecases
+
+import (
+	"tennis-platform/backend/internal/apperror"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReputationUseCase_TypedErrors(t *testing.T) {
+	db := setupBookingTestDB(t)
+	require.NoError(t, db.Exec(`CREATE TABLE matches (id TEXT PRIMARY KEY, scheduled_at DATETIME, deleted_at DATETIME)`).Error)
+	require.NoError(t, db.Exec(`INSERT INTO matches (id) VALUES ('match-1')`).Error)
+	reputation := NewReputationUseCase(db)
+
+	err := reputation.RecordMatchPunctuality("user-1", "missing", time.Now())
+	assert.True(t, apperror.HasCode(err, apperror.CodeMatchNotFound))
+
+	// 未排定時間的比賽無法判斷是否準時
+	err = reputation.RecordMatchPunctuality("user-1", "match-1", time.Now())
+	assert.True(t, apperror.HasCode(err, apperror.CodeReputationMatchNotScheduled))
+
+	err = reputation.RecordSkillLevelAccuracy("user-1", "match-1", 0.5, 3.0)
+	assert.True(t, apperror.HasCode(err, apperror.CodeUserInvalidNTRPLevel))
+
+	err = reputation.SubmitBehaviorReview("user-1", "user-2", "match-1", 6, nil, nil)
+	assert.True(t, apperror.HasCode(err, apperror.CodeReputationInvalidRating))
+
+	err = reputation.SubmitBehaviorReview("user-1", "user-1", "match-1", 4, nil, nil)
+	assert.True(t, apperror.HasCode(err, apperror.CodeReputationSelfReview))
+}
@@ -3,8 +3,10 @@ package usecases
 import (
 	"errors"
 	"fmt"
+	"tennis-platform/backend/internal/apperror"
 	"tennis-platform/backend/internal/dto"
 	"tennis-platform/backend/internal/models"
+	"tennis-platform/backend/internal/pagination"
 	"tennis-platform/backend/internal/services"
 
 	"gorm.io/gorm"
@@ -79,7 +81,7 @@ func (ru *ReviewUsecase) CreateReview(userID string, req *dto.CreateReviewReques
 	var court models.Court
 	if err := ru.db.Where("id = ? AND deleted_at IS NULL", req.CourtID).First(&court).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("場地不存在")
+			return nil, apperror.New(apperror.CodeCourtNotFound)
 		}
 		return nil, errors.New("檢查場地失敗")
 	}
@@ -87,7 +89,7 @@ func (ru *ReviewUsecase) CreateReview(userID string, req *dto.CreateReviewReques
 	// 檢查用戶是否已經評價過該場地
 	var existingReview models.CourtReview
 	if err := ru.db.Where("court_id = ? AND user_id = ? AND deleted_at IS NULL", req.CourtID, userID).First(&existingReview).Error; err == nil {
-		return nil, errors.New("您已經評價過該場地")
+		return nil, apperror.New(apperror.CodeReviewDuplicate)
 	}
 
 	// 創建評價
@@ -139,7 +141,7 @@ func (ru *ReviewUsecase) GetReview(reviewID string) (*models.CourtReview, error)
 	if err := ru.db.Preload("User").Preload("User.Profile").Preload("Court").
 		Where("id = ? AND deleted_at IS NULL AND status = 'active'", reviewID).First(&review).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("評價不存在")
+			return nil, apperror.New(apperror.CodeReviewNotFound)
 		}
 		return nil, errors.New("獲取評價失敗")
 	}
@@ -152,14 +154,14 @@ func (ru *ReviewUsecase) UpdateReview(reviewID, userID string, req *dto.UpdateRe
 	var review models.CourtReview
 	if err := ru.db.Where("id = ? AND user_id = ? AND deleted_at IS NULL", reviewID, userID).First(&review).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("評價不存在或無權限修改")
+			return nil, apperror.New(apperror.CodeReviewNotOwned)
 		}
 		return nil, errors.New("檢查評價失敗")
 	}
 
 	// 檢查評價狀態
 	if review.Status != "active" {
-		return nil, errors.New("該評價無法修改")
+		return nil, apperror.New(apperror.CodeReviewNotEditable)
 	}
 
 	// 準備更新數據
@@ -222,7 +224,7 @@ func (ru *ReviewUsecase) DeleteReview(reviewID, userID string) error {
 	var review models.CourtReview
 	if err := ru.db.Where("id = ? AND user_id = ? AND deleted_at IS NULL", reviewID, userID).First(&review).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return errors.New("評價不存在或無權限刪除")
+			return apperror.New(apperror.CodeReviewNotOwned)
 		}
 		return errors.New("檢查評價失敗")
 	}
@@ -255,15 +257,16 @@ func (ru *ReviewUsecase) DeleteReview(reviewID, userID string) error {
 	return nil
 }
 
+// reviewSortKeys 評價列表可用的排序欄位
+var reviewSortKeys = map[string]pagination.Key{
+	"rating":     {Column: "rating", Kind: pagination.KindNumber},
+	"created_at": {Column: "created_at", Kind: pagination.KindTime},
+	"helpful":    {Column: "is_helpful", Kind: pagination.KindNumber},
+}
+
 // GetReviews 獲取評價列表
 func (ru *ReviewUsecase) GetReviews(req *dto.ReviewListRequest) (*dto.ReviewListResponse, error) {
 	// 設置默認值
-	if req.Page <= 0 {
-		req.Page = 1
-	}
-	if req.PageSize <= 0 {
-		req.PageSize = 20
-	}
 	if req.SortBy == nil {
 		sortBy := "created_at"
 		req.SortBy = &sortBy
@@ -273,6 +276,22 @@ func (ru *ReviewUsecase) GetReviews(req *dto.ReviewListRequest) (*dto.ReviewList
 		req.SortOrder = &sortOrder
 	}
 
+	sortKey, ok := reviewSortKeys[*req.SortBy]
+	if !ok {
+		return nil, apperror.New(apperror.CodeUnsupportedSort)
+	}
+
+	page := pagination.Query{
+		Keys:  []pagination.Key{sortKey, {Column: "id", Kind: pagination.KindString}},
+		Desc:  *req.SortOrder == "desc",
+		Limit: req.PageSize,
+		Page:  req.Page,
+	}
+	if req.Cursor != nil {
+		page.Cursor = *req.Cursor
+	}
+	page.Normalize(20, 50)
+
 	// 構建查詢
 	query := ru.db.Model(&models.CourtReview{}).
 		Preload("User").Preload("User.Profile").Preload("Court").
@@ -289,36 +308,54 @@ func (ru *ReviewUsecase) GetReviews(req *dto.ReviewListRequest) (*dto.ReviewList
 		query = query.Where("rating = ?", *req.Rating)
 	}
 
-	// 計算總數
-	var total int64
-	if err := query.Count(&total).Error; err != nil {
-		return nil, errors.New("計算評價總數失敗")
+	// 頁碼分頁時計算總數
+	var total *int64
+	if !page.IsCursor() {
+		var count int64
+		if err := query.Count(&count).Error; err != nil {
+			return nil, errors.New("計算評價總數失敗")
+		}
+		total = &count
 	}
 
-	// 排序
-	orderClause := fmt.Sprintf("%s %s", *req.SortBy, *req.SortOrder)
-	query = query.Order(orderClause)
-
-	// 分頁
-	offset := (req.Page - 1) * req.PageSize
-	query = query.Offset(offset).Limit(req.PageSize)
+	// 排序與分頁
+	pagedQuery, err := page.Apply(query)
+	if err != nil {
+		return nil, err
+	}
 
 	// 執行查詢
 	var reviews []models.CourtReview
-	if err := query.Find(&reviews).Error; err != nil {
+	if err := pagedQuery.Find(&reviews).Error; err != nil {
 		return nil, errors.New("獲取評價列表失敗")
 	}
 
-	// 計算總頁數
-	totalPages := int((total + int64(req.PageSize) - 1) / int64(req.PageSize))
+	reviews, pageInfo, err := pagination.Build(reviews, &page, total, func(r *models.CourtReview) []interface{} {
+		switch sortKey.Column {
+		case "rating":
+			return []interface{}{r.Rating, r.ID}
+		case "is_helpful":
+			return []interface{}{r.IsHelpful, r.ID}
+		default:
+			return []interface{}{r.CreatedAt, r.ID}
+		}
+	})
+	if err != nil {
+		return nil, errors.New("生成分頁游標失敗")
+	}
 
-	return &dto.ReviewListResponse{
+	response := &dto.ReviewListResponse{
 		Reviews:    reviews,
-		Total:      total,
-		Page:       req.Page,
-		PageSize:   req.PageSize,
-		TotalPages: totalPages,
-	}, nil
+		Pagination: pageInfo,
+	}
+	if total != nil {
+		response.Total = *total
+		response.Page = page.Page
+		response.PageSize = page.Limit
+		response.TotalPages = pageInfo.TotalPages
+	}
+
+	return response, nil
 }
 
 // ReportReview 舉報評價
@@ -327,7 +364,7 @@ func (ru *ReviewUsecase) ReportReview(reviewID, userID string, req *dto.ReportRe
 	var review models.CourtReview
 	if err := ru.db.Where("id = ? AND deleted_at IS NULL", reviewID).First(&review).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return errors.New("評價不存在")
+			return apperror.New(apperror.CodeReviewNotFound)
 		}
 		return errors.New("檢查評價失敗")
 	}
@@ -335,7 +372,7 @@ func (ru *ReviewUsecase) ReportReview(reviewID, userID string, req *dto.ReportRe
 	// 檢查是否已經舉報過
 	var existingReport models.ReviewReport
 	if err := ru.db.Where("review_id = ? AND user_id = ? AND deleted_at IS NULL", reviewID, userID).First(&existingReport).Error; err == nil {
-		return errors.New("您已經舉報過該評價")
+		return apperror.New(apperror.CodeReviewAlreadyReported)
 	}
 
 	// 創建舉報記錄
@@ -386,14 +423,14 @@ func (ru *ReviewUsecase) MarkReviewHelpful(reviewID, userID string, helpful bool
 	var review models.CourtReview
 	if err := ru.db.Where("id = ? AND deleted_at IS NULL AND status = 'active'", reviewID).First(&review).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return errors.New("評價不存在")
+			return apperror.New(apperror.CodeReviewNotFound)
 		}
 		return errors.New("檢查評價失敗")
 	}
 
 	// 不能對自己的評價標記有用
 	if review.UserID == userID {
-		return errors.New("不能對自己的評價標記有用")
+		return apperror.New(apperror.CodeReviewOwnHelpful)
 	}
 
 	// 更新有用計數
@@ -417,7 +454,7 @@ func (ru *ReviewUsecase) GetReviewStatistics(courtID string) (*dto.ReviewStatist
 	var court models.Court
 	if err := ru.db.Where("id = ? AND deleted_at IS NULL", courtID).First(&court).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("場地不存在")
+			return nil, apperror.New(apperror.CodeCourtNotFound)
 		}
 		return nil, errors.New("檢查場地失敗")
 	}
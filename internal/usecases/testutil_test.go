@@ -0,0 +1,57 @@
+package usecases
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+const (
+	bookingTestOwnerID = "44444444-4444-4444-4444-444444444444"
+	bookingTestUserID  = "55555555-5555-5555-5555-555555555555"
+)
+
+func setupBookingTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+
+	// 手動創建預約相關測試共用的表結構，只包含用到的欄位
+	for _, stmt := range []string{
+		`CREATE TABLE courts (id TEXT PRIMARY KEY, name TEXT, owner_id TEXT, price_per_hour REAL, currency TEXT, operating_hours TEXT, check_in_required BOOLEAN DEFAULT false, is_active BOOLEAN DEFAULT true, deleted_at DATETIME)`,
+		`CREATE TABLE users (id TEXT PRIMARY KEY, email TEXT, deleted_at DATETIME)`,
+		`CREATE TABLE user_profiles (user_id TEXT PRIMARY KEY, first_name TEXT NOT NULL, last_name TEXT NOT NULL)`,
+		`CREATE TABLE bookings (id TEXT PRIMARY KEY, court_id TEXT, court_unit_id TEXT, series_id TEXT, user_id TEXT, start_time DATETIME, end_time DATETIME, total_price REAL, price_breakdown TEXT, status TEXT, payment_id TEXT, payment_due_at DATETIME, notes TEXT, checked_in_at DATETIME, checked_in_by TEXT, version INTEGER NOT NULL DEFAULT 1, created_at DATETIME, updated_at DATETIME, deleted_at DATETIME)`,
+		`CREATE TABLE booking_series (id TEXT PRIMARY KEY, court_id TEXT NOT NULL, court_unit_id TEXT, user_id TEXT NOT NULL, rrule TEXT NOT NULL, start_time DATETIME NOT NULL, end_time DATETIME NOT NULL, until DATETIME, count INTEGER, billing_mode TEXT NOT NULL, status TEXT NOT NULL, payment_id TEXT, payment_due_at DATETIME, notes TEXT, version INTEGER NOT NULL DEFAULT 1, created_at DATETIME, updated_at DATETIME, deleted_at DATETIME)`,
+		`CREATE TABLE payments (id TEXT PRIMARY KEY, user_id TEXT NOT NULL, target_type TEXT NOT NULL, target_id TEXT NOT NULL, amount REAL NOT NULL, currency TEXT, status TEXT NOT NULL, provider TEXT NOT NULL, provider_payment_id TEXT NOT NULL UNIQUE, client_secret TEXT, refunded_amount REAL NOT NULL DEFAULT 0, failure_reason TEXT, expires_at DATETIME, authorized_at DATETIME, captured_at DATETIME, cancelled_at DATETIME, created_at DATETIME, updated_at DATETIME)`,
+		`CREATE TABLE cancellation_policies (id TEXT PRIMARY KEY, owner_type TEXT NOT NULL, owner_id TEXT NOT NULL, tiers TEXT NOT NULL, cutoff_hours REAL NOT NULL DEFAULT 0, created_at DATETIME, updated_at DATETIME)`,
+		`CREATE TABLE cancellation_overrides (id TEXT PRIMARY KEY, owner_type TEXT NOT NULL, owner_id TEXT NOT NULL, start_time DATETIME NOT NULL, end_time DATETIME NOT NULL, reason TEXT NOT NULL, refund_percent INTEGER NOT NULL DEFAULT 100, note TEXT, created_by TEXT NOT NULL, created_at DATETIME)`,
+		`CREATE TABLE cancellations (id TEXT PRIMARY KEY, target_type TEXT NOT NULL, target_id TEXT NOT NULL, payment_id TEXT, cancelled_by TEXT NOT NULL, initiator TEXT NOT NULL, reason TEXT, policy_id TEXT, override_id TEXT, hours_before REAL, refund_percent INTEGER, paid_amount REAL NOT NULL DEFAULT 0, refund_amount REAL NOT NULL DEFAULT 0, fee_amount REAL NOT NULL DEFAULT 0, currency TEXT NOT NULL DEFAULT 'TWD', refund_status TEXT NOT NULL DEFAULT 'none', refund_error TEXT, refund_attempts INTEGER NOT NULL DEFAULT 0, created_at DATETIME, updated_at DATETIME)`,
+		`CREATE TABLE booking_waitlist_entries (id TEXT PRIMARY KEY, court_id TEXT NOT NULL, court_unit_id TEXT, user_id TEXT NOT NULL, start_time DATETIME NOT NULL, end_time DATETIME NOT NULL, duration INTEGER NOT NULL, status TEXT NOT NULL, hold_id TEXT, offer_start_time DATETIME, offer_end_time DATETIME, offered_at DATETIME, offer_expires_at DATETIME, booking_id TEXT, created_at DATETIME, updated_at DATETIME)`,
+		`CREATE TABLE slot_holds (id TEXT PRIMARY KEY, court_id TEXT NOT NULL, court_unit_id TEXT, user_id TEXT NOT NULL, start_time DATETIME NOT NULL, end_time DATETIME NOT NULL, expires_at DATETIME NOT NULL, created_at DATETIME)`,
+		`CREATE TABLE court_units (id TEXT PRIMARY KEY, court_id TEXT NOT NULL, number INTEGER NOT NULL, name TEXT, surface TEXT, is_indoor BOOLEAN, has_lights BOOLEAN, is_active BOOLEAN NOT NULL, created_at DATETIME, updated_at DATETIME, deleted_at DATETIME)`,
+		`CREATE TABLE court_closures (id TEXT PRIMARY KEY, court_id TEXT NOT NULL, court_unit_id TEXT, kind TEXT NOT NULL, start_date TEXT NOT NULL, end_date TEXT NOT NULL, start_time TEXT, end_time TEXT, reason TEXT NOT NULL, note TEXT, created_by TEXT NOT NULL, created_at DATETIME, updated_at DATETIME)`,
+		`CREATE TABLE booking_payment_splits (id TEXT PRIMARY KEY, booking_id TEXT NOT NULL, owner_id TEXT NOT NULL, match_id TEXT, mode TEXT NOT NULL, fallback TEXT NOT NULL, status TEXT NOT NULL, due_at DATETIME NOT NULL, owner_charged_at DATETIME, total REAL NOT NULL, currency TEXT NOT NULL DEFAULT 'TWD', created_at DATETIME, updated_at DATETIME)`,
+		`CREATE TABLE booking_payment_shares (id TEXT PRIMARY KEY, split_id TEXT NOT NULL, booking_id TEXT NOT NULL, user_id TEXT NOT NULL, is_owner BOOLEAN NOT NULL DEFAULT false, amount REAL NOT NULL, status TEXT NOT NULL, payment_id TEXT, paid_at DATETIME, created_at DATETIME, updated_at DATETIME)`,
+		`CREATE TABLE lessons (id TEXT PRIMARY KEY, student_id TEXT, status TEXT, payment_id TEXT, payment_due_at DATETIME, deleted_at DATETIME)`,
+		`CREATE TABLE club_event_participants (id TEXT PRIMARY KEY, event_id TEXT, status TEXT, payment_id TEXT, payment_due_at DATETIME, deleted_at DATETIME)`,
+		`CREATE TABLE match_participants (match_id TEXT NOT NULL, user_id TEXT NOT NULL, role TEXT, status TEXT, joined_at DATETIME, created_at DATETIME, PRIMARY KEY (match_id, user_id))`,
+		`CREATE TABLE court_slot_demands (court_id TEXT NOT NULL, slot_start DATETIME NOT NULL, unavailable_views INTEGER NOT NULL DEFAULT 0, rejected_bookings INTEGER NOT NULL DEFAULT 0, updated_at DATETIME, PRIMARY KEY (court_id, slot_start))`,
+		`CREATE TABLE invoices (id TEXT PRIMARY KEY, number TEXT NOT NULL UNIQUE, kind TEXT NOT NULL, source_key TEXT NOT NULL UNIQUE, payment_id TEXT NOT NULL, original_invoice_id TEXT, target_type TEXT NOT NULL, target_id TEXT NOT NULL, buyer_id TEXT NOT NULL, buyer_name TEXT NOT NULL, buyer_email TEXT NOT NULL, seller_type TEXT NOT NULL, seller_id TEXT NOT NULL, seller_user_id TEXT, seller_name TEXT NOT NULL, seller_address TEXT, lines TEXT NOT NULL, subtotal REAL NOT NULL, tax_rate REAL NOT NULL, tax_amount REAL NOT NULL, total REAL NOT NULL, currency TEXT NOT NULL DEFAULT 'TWD', issued_at DATETIME NOT NULL, file_key TEXT, emailed_at DATETIME, created_at DATETIME, updated_at DATETIME)`,
+		`CREATE TABLE invoice_sequences (prefix TEXT NOT NULL, year INTEGER NOT NULL, last_number INTEGER NOT NULL DEFAULT 0, PRIMARY KEY (prefix, year))`,
+		`CREATE TABLE promo_codes (id TEXT PRIMARY KEY, code TEXT NOT NULL UNIQUE, description TEXT, created_by TEXT NOT NULL, court_id TEXT, coach_id TEXT, lesson_type_id TEXT, club_id TEXT, discount_type TEXT NOT NULL, discount_value REAL NOT NULL, max_discount REAL, min_spend REAL NOT NULL DEFAULT 0, starts_at DATETIME, ends_at DATETIME, days_of_week TEXT, start_time TEXT, end_time TEXT, max_redemptions INTEGER, max_redemptions_per_user INTEGER, redemption_count INTEGER NOT NULL DEFAULT 0, first_purchase_only BOOLEAN NOT NULL DEFAULT false, is_active BOOLEAN NOT NULL, created_at DATETIME, updated_at DATETIME)`,
+		`CREATE TABLE promo_redemptions (id TEXT PRIMARY KEY, promo_code_id TEXT NOT NULL, user_id TEXT NOT NULL, target_type TEXT NOT NULL, target_id TEXT NOT NULL, original_amount REAL NOT NULL, discount_amount REAL NOT NULL, currency TEXT NOT NULL DEFAULT 'TWD', status TEXT NOT NULL, reversed_at DATETIME, created_at DATETIME, UNIQUE (target_type, target_id))`,
+		`CREATE TABLE court_equipment (id TEXT PRIMARY KEY, court_id TEXT NOT NULL, name TEXT NOT NULL, description TEXT, category TEXT NOT NULL, quantity INTEGER NOT NULL, price REAL NOT NULL, price_unit TEXT NOT NULL, is_active BOOLEAN NOT NULL, created_at DATETIME, updated_at DATETIME, deleted_at DATETIME)`,
+		`CREATE TABLE booking_add_ons (id TEXT PRIMARY KEY, booking_id TEXT NOT NULL, equipment_id TEXT NOT NULL, name TEXT NOT NULL, quantity INTEGER NOT NULL, unit_price REAL NOT NULL, price_unit TEXT NOT NULL, amount REAL NOT NULL, start_time DATETIME NOT NULL, end_time DATETIME NOT NULL, status TEXT NOT NULL, returned_at DATETIME, created_at DATETIME, updated_at DATETIME)`,
+		`CREATE TABLE court_price_rules (id TEXT PRIMARY KEY, court_id TEXT NOT NULL, name TEXT NOT NULL, kind TEXT NOT NULL, price_per_hour REAL NOT NULL, days_of_week TEXT, start_time TEXT, end_time TEXT, start_date TEXT, end_date TEXT, audience TEXT NOT NULL, club_id TEXT, priority INTEGER NOT NULL DEFAULT 0, is_active BOOLEAN NOT NULL, created_at DATETIME, updated_at DATETIME)`,
+		`CREATE TABLE outbox_events (id TEXT PRIMARY KEY, event_type TEXT NOT NULL, aggregate_type TEXT NOT NULL, aggregate_id TEXT NOT NULL, payload TEXT, status TEXT DEFAULT 'pending', attempts INTEGER DEFAULT 0, last_error TEXT, available_at DATETIME NOT NULL, dispatched_at DATETIME, created_at DATETIME, updated_at DATETIME)`,
+		`CREATE TABLE processed_events (event_id TEXT NOT NULL, subscriber TEXT NOT NULL, processed_at DATETIME NOT NULL, PRIMARY KEY (event_id, subscriber))`,
+	} {
+		require.NoError(t, db.Exec(stmt).Error)
+	}
+
+	hours := `{"Monday":"08:00-22:00","Tuesday":"08:00-22:00","Wednesday":"08:00-22:00","Thursday":"08:00-22:00","Friday":"08:00-22:00","Saturday":"08:00-22:00","Sunday":"08:00-22:00"}`
+	require.NoError(t, db.Exec(`INSERT INTO courts (id, name, owner_id, price_per_hour, currency, operating_hours) VALUES ('66666666-6666-6666-6666-666666666666', '河濱網球場', ?, 400, 'TWD', ?)`, bookingTestOwnerID, hours).Error)
+	return db
+}
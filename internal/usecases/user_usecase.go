@@ -2,6 +2,7 @@ package usecases
 
 import (
 	"errors"
+	"tennis-platform/backend/internal/apperror"
 	"tennis-platform/backend/internal/dto"
 	"tennis-platform/backend/internal/models"
 	"time"
@@ -81,7 +82,7 @@ type LocationUpdateRequest struct {
 func (uu *UserUsecase) GetUserByID(userID string) (*models.User, error) {
 	var user models.User
 	if err := uu.db.Preload("Profile").Where("id = ?", userID).First(&user).Error; err != nil {
-		return nil, errors.New("用戶不存在")
+		return nil, apperror.New(apperror.CodeUserNotFound)
 	}
 	return &user, nil
 }
@@ -91,13 +92,13 @@ func (uu *UserUsecase) CreateUserProfile(userID string, req *dto.CreateProfileRe
 	// 檢查用戶是否存在
 	var user models.User
 	if err := uu.db.Where("id = ?", userID).First(&user).Error; err != nil {
-		return nil, errors.New("用戶不存在")
+		return nil, apperror.New(apperror.CodeUserNotFound)
 	}
 
 	// 檢查是否已有檔案
 	var existingProfile models.UserProfile
 	if err := uu.db.Where("user_id = ?", userID).First(&existingProfile).Error; err == nil {
-		return nil, errors.New("用戶檔案已存在")
+		return nil, apperror.New(apperror.CodeUserProfileExists)
 	}
 
 	// 創建新檔案
@@ -135,7 +136,7 @@ func (uu *UserUsecase) UpdateUserProfile(userID string, req *dto.UpdateProfileRe
 	// 查找用戶
 	var user models.User
 	if err := uu.db.Preload("Profile").Where("id = ?", userID).First(&user).Error; err != nil {
-		return nil, errors.New("用戶不存在")
+		return nil, apperror.New(apperror.CodeUserNotFound)
 	}
 
 	// 如果用戶沒有檔案，先創建一個
@@ -217,7 +218,7 @@ func (uu *UserUsecase) UpdateUserPreferences(userID string, req *dto.UserPrefere
 	// 查找用戶
 	var user models.User
 	if err := uu.db.Preload("Profile").Where("id = ?", userID).First(&user).Error; err != nil {
-		return nil, errors.New("用戶不存在")
+		return nil, apperror.New(apperror.CodeUserNotFound)
 	}
 
 	// 如果用戶沒有檔案，先創建一個
@@ -265,7 +266,7 @@ func (uu *UserUsecase) UpdateUserLocation(userID string, req *dto.LocationUpdate
 	// 查找用戶
 	var user models.User
 	if err := uu.db.Preload("Profile").Where("id = ?", userID).First(&user).Error; err != nil {
-		return nil, errors.New("用戶不存在")
+		return nil, apperror.New(apperror.CodeUserNotFound)
 	}
 
 	// 如果用戶沒有檔案，先創建一個
@@ -307,7 +308,7 @@ func (uu *UserUsecase) UpdateUserAvatar(userID string, avatarURL string) (*model
 	// 查找用戶
 	var user models.User
 	if err := uu.db.Preload("Profile").Where("id = ?", userID).First(&user).Error; err != nil {
-		return nil, errors.New("用戶不存在")
+		return nil, apperror.New(apperror.CodeUserNotFound)
 	}
 
 	// 如果用戶沒有檔案，先創建一個
@@ -336,7 +337,7 @@ func (uu *UserUsecase) UpdateUserAvatar(userID string, avatarURL string) (*model
 // validateNTRPLevel 驗證 NTRP 等級
 func (uu *UserUsecase) validateNTRPLevel(level float64) error {
 	if level < 1.0 || level > 7.0 {
-		return errors.New("NTRP 等級必須在 1.0 到 7.0 之間")
+		return apperror.New(apperror.CodeUserInvalidNTRPLevel)
 	}
 
 	// 檢查是否為有效的 NTRP 等級（0.5 的倍數）
@@ -347,7 +348,7 @@ func (uu *UserUsecase) validateNTRPLevel(level float64) error {
 		}
 	}
 
-	return errors.New("NTRP 等級必須為 0.5 的倍數（例如：1.0, 1.5, 2.0...）")
+	return apperror.New(apperror.CodeUserInvalidNTRPLevel)
 }
 
 // GetNTRPLevelDescription 獲取 NTRP 等級描述
@@ -0,0 +1,277 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"tennis-platform/backend/internal/apperror"
+	"tennis-platform/backend/internal/dto"
+	"tennis-platform/backend/internal/models"
+	"tennis-platform/backend/internal/services"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// WebhookUsecase Webhook 用例
+type WebhookUsecase struct {
+	db             *gorm.DB
+	webhookService *services.WebhookService
+}
+
+// NewWebhookUsecase 創建新的 Webhook 用例
+func NewWebhookUsecase(db *gorm.DB, webhookService *services.WebhookService) *WebhookUsecase {
+	return &WebhookUsecase{
+		db:             db,
+		webhookService: webhookService,
+	}
+}
+
+// CreateSubscription 創建 Webhook 訂閱
+func (wu *WebhookUsecase) CreateSubscription(ownerID string, req *dto.CreateWebhookRequest) (*dto.WebhookSecretResponse, error) {
+	if err := wu.validateURL(req.URL); err != nil {
+		return nil, err
+	}
+	if err := validateWebhookEventTypes(req.EventTypes); err != nil {
+		return nil, err
+	}
+
+	secret, err := services.GenerateWebhookSecret()
+	if err != nil {
+		return nil, errors.New("生成簽名密鑰失敗")
+	}
+
+	subscription := models.WebhookSubscription{
+		OwnerID:     ownerID,
+		URL:         req.URL,
+		Description: req.Description,
+		EventTypes:  models.StringArray(req.EventTypes),
+		Secret:      secret,
+		IsActive:    true,
+	}
+
+	if err := wu.db.Create(&subscription).Error; err != nil {
+		return nil, errors.New("創建 Webhook 訂閱失敗")
+	}
+
+	return &dto.WebhookSecretResponse{
+		Subscription: &subscription,
+		Secret:       secret,
+	}, nil
+}
+
+// GetSubscriptions 獲取擁有者的所有 Webhook 訂閱
+func (wu *WebhookUsecase) GetSubscriptions(ownerID string) ([]models.WebhookSubscription, error) {
+	var subscriptions []models.WebhookSubscription
+	if err := wu.db.Where("owner_id = ?", ownerID).Order("created_at DESC").Find(&subscriptions).Error; err != nil {
+		return nil, errors.New("獲取 Webhook 訂閱失敗")
+	}
+	return subscriptions, nil
+}
+
+// GetSubscription 獲取 Webhook 訂閱詳情
+func (wu *WebhookUsecase) GetSubscription(subscriptionID, ownerID string) (*models.WebhookSubscription, error) {
+	var subscription models.WebhookSubscription
+	if err := wu.db.Where("id = ? AND owner_id = ?", subscriptionID, ownerID).First(&subscription).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.New(apperror.CodeWebhookNotFound)
+		}
+		return nil, errors.New("獲取 Webhook 訂閱失敗")
+	}
+	return &subscription, nil
+}
+
+// UpdateSubscription 更新 Webhook 訂閱
+func (wu *WebhookUsecase) UpdateSubscription(subscriptionID, ownerID string, req *dto.UpdateWebhookRequest) (*models.WebhookSubscription, error) {
+	subscription, err := wu.GetSubscription(subscriptionID, ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := make(map[string]interface{})
+
+	if req.URL != nil {
+		if err := wu.validateURL(*req.URL); err != nil {
+			return nil, err
+		}
+		updates["url"] = *req.URL
+	}
+
+	if req.EventTypes != nil {
+		if err := validateWebhookEventTypes(req.EventTypes); err != nil {
+			return nil, err
+		}
+		updates["event_types"] = models.StringArray(req.EventTypes)
+	}
+
+	if req.Description != nil {
+		updates["description"] = *req.Description
+	}
+
+	if req.IsActive != nil {
+		updates["is_active"] = *req.IsActive
+		if *req.IsActive {
+			// 重新啟用時清除失敗計數
+			updates["consecutive_failures"] = 0
+			updates["disabled_at"] = nil
+			updates["disabled_reason"] = nil
+		} else if subscription.IsActive {
+			reason := "由擁有者停用"
+			updates["disabled_at"] = time.Now()
+			updates["disabled_reason"] = reason
+		}
+	}
+
+	if len(updates) > 0 {
+		if err := wu.db.Model(subscription).Updates(updates).Error; err != nil {
+			return nil, errors.New("更新 Webhook 訂閱失敗")
+		}
+	}
+
+	return wu.GetSubscription(subscriptionID, ownerID)
+}
+
+// DeleteSubscription 刪除 Webhook 訂閱
+func (wu *WebhookUsecase) DeleteSubscription(subscriptionID, ownerID string) error {
+	subscription, err := wu.GetSubscription(subscriptionID, ownerID)
+	if err != nil {
+		return err
+	}
+
+	if err := wu.db.Delete(subscription).Error; err != nil {
+		return errors.New("刪除 Webhook 訂閱失敗")
+	}
+
+	return nil
+}
+
+// RotateSecret 輪換簽名密鑰
+func (wu *WebhookUsecase) RotateSecret(subscriptionID, ownerID string) (*dto.WebhookSecretResponse, error) {
+	subscription, err := wu.GetSubscription(subscriptionID, ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := services.GenerateWebhookSecret()
+	if err != nil {
+		return nil, errors.New("生成簽名密鑰失敗")
+	}
+
+	if err := wu.db.Model(subscription).Update("secret", secret).Error; err != nil {
+		return nil, errors.New("更新簽名密鑰失敗")
+	}
+
+	return &dto.WebhookSecretResponse{
+		Subscription: subscription,
+		Secret:       secret,
+	}, nil
+}
+
+// GetDeliveries 獲取投遞記錄
+func (wu *WebhookUsecase) GetDeliveries(subscriptionID, ownerID string, req *dto.WebhookDeliveryListRequest) (*dto.WebhookDeliveryListResponse, error) {
+	if _, err := wu.GetSubscription(subscriptionID, ownerID); err != nil {
+		return nil, err
+	}
+
+	// 設置默認值
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = 20
+	}
+
+	query := wu.db.Model(&models.WebhookDelivery{}).Where("subscription_id = ?", subscriptionID)
+	if req.Status != nil {
+		query = query.Where("status = ?", *req.Status)
+	}
+
+	// 計算總數
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, errors.New("計算投遞記錄總數失敗")
+	}
+
+	// 分頁查詢
+	var deliveries []models.WebhookDelivery
+	offset := (req.Page - 1) * req.PageSize
+	if err := query.Order("created_at DESC").
+		Offset(offset).Limit(req.PageSize).
+		Find(&deliveries).Error; err != nil {
+		return nil, errors.New("獲取投遞記錄失敗")
+	}
+
+	// 計算總頁數
+	totalPages := int((total + int64(req.PageSize) - 1) / int64(req.PageSize))
+
+	return &dto.WebhookDeliveryListResponse{
+		Deliveries: deliveries,
+		Total:      total,
+		Page:       req.Page,
+		PageSize:   req.PageSize,
+		TotalPages: totalPages,
+	}, nil
+}
+
+// Redeliver 重新投遞指定記錄
+func (wu *WebhookUsecase) Redeliver(subscriptionID, deliveryID, ownerID string) (*models.WebhookDelivery, error) {
+	subscription, err := wu.GetSubscription(subscriptionID, ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !subscription.IsActive {
+		return nil, apperror.New(apperror.CodeWebhookDisabled)
+	}
+
+	var delivery models.WebhookDelivery
+	if err := wu.db.Where("id = ? AND subscription_id = ?", deliveryID, subscriptionID).First(&delivery).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.New(apperror.CodeWebhookDeliveryNotFound)
+		}
+		return nil, errors.New("獲取投遞記錄失敗")
+	}
+
+	redelivery, err := wu.webhookService.Redeliver(context.Background(), &delivery)
+	if err != nil {
+		return nil, errors.New("重新投遞失敗")
+	}
+
+	return redelivery, nil
+}
+
+// validateURL 驗證 Webhook 地址，主機需能解析且不能指向內部網絡
+//
+// 投遞時會在連線前再次檢查實際連接的地址，這裡的檢查讓用戶在訂閱時即可得知錯誤。
+func (wu *WebhookUsecase) validateURL(rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Hostname() == "" {
+		return apperror.New(apperror.CodeWebhookInvalidURL)
+	}
+	if parsed.Scheme != "https" && parsed.Scheme != "http" {
+		return apperror.New(apperror.CodeWebhookInvalidScheme)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := wu.webhookService.CheckHost(ctx, parsed.Hostname()); err != nil {
+		if errors.Is(err, services.ErrWebhookPrivateAddress) {
+			return apperror.New(apperror.CodeWebhookPrivateAddress)
+		}
+		return apperror.Wrap(apperror.CodeWebhookInvalidURL, err)
+	}
+	return nil
+}
+
+// validateWebhookEventTypes 驗證訂閱的事件類型
+func validateWebhookEventTypes(eventTypes []string) error {
+	if len(eventTypes) == 0 {
+		return apperror.New(apperror.CodeWebhookEventTypesRequired)
+	}
+	for _, eventType := range eventTypes {
+		if eventType != "*" && !services.IsWebhookEventType(eventType) {
+			return apperror.New(apperror.CodeWebhookUnsupportedEvent).With("eventType", eventType)
+		}
+	}
+	return nil
+}